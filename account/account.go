@@ -0,0 +1,60 @@
+// Package account holds the account operations both the REST routes (context/v1) and the gRPC
+// methods (grpc) offer. Failures clients can act on are data.APIError, a PasswordPolicyError
+// for passwords breaking the policy, or a LoginThrottledError for clients that have to wait before
+// logging in again, which each API renders its own way
+package account
+
+import (
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/axiomzen/zenauth/config"
+	"github.com/axiomzen/zenauth/constants"
+	"github.com/axiomzen/zenauth/data"
+	"github.com/axiomzen/zenauth/helpers"
+	"github.com/axiomzen/zenauth/models"
+)
+
+// Service runs the account operations of a request or call
+type Service struct {
+	Config *config.ZENAUTHConfig
+	// DAL is scoped to the tenant of the request
+	DAL data.ZENAUTHProvider
+	Log *logrus.Entry
+	// ClientIP and UserAgent are the client's, for login throttles and the audit log
+	ClientIP  string
+	UserAgent string
+}
+
+// apiError returns the error of a failed operation
+func apiError(code constants.APIErrorCode, status constants.HTTPStatusCode, msg string) error {
+	return data.APIError{Code: code, Status: status, Message: msg}
+}
+
+// dalError returns the API error of a DAL error, see data.ToAPIError
+func dalError(err error, code constants.APIErrorCode, msg string) error {
+	return data.ToAPIError(err, code, msg)
+}
+
+// isNoneAffected tells whether err is the DAL error of a query that found nothing
+func isNoneAffected(err error) bool {
+	dalErr, _ := err.(data.DALError)
+	return dalErr.ErrorCode == data.DALErrorCodeNoneAffected
+}
+
+// TokenTenantID returns the tenant a token was issued for, tokens from before tenants are the
+// default tenant's
+func TokenTenantID(conf *config.ZENAUTHConfig, result *helpers.JWTokenValidateResult) string {
+	if tenantID := result.StringClaim(conf.JwtClaimTenant); tenantID != "" {
+		return tenantID
+	}
+	return constants.DefaultTenantID
+}
+
+// Exists tells whether a user has the email or the username
+func (s *Service) Exists(email, userName string) bool {
+	var user models.User
+	user.Email = strings.ToLower(strings.Trim(email, " "))
+	user.UserName = userName
+	return s.DAL.GetUserByEmailOrUserName(&user) == nil
+}
@@ -0,0 +1,41 @@
+package account
+
+import (
+	"time"
+
+	"github.com/axiomzen/zenauth/constants"
+	"github.com/axiomzen/zenauth/helpers"
+	"github.com/axiomzen/zenauth/models"
+)
+
+// deletedSince is when the accounts that can still be restored were deleted after
+func (s *Service) deletedSince() time.Time {
+	return time.Now().Add(-s.Config.UserDeletionGracePeriod)
+}
+
+// GetMFAUser loads the user of a validated mfa token. The account of a token restoring it is
+// still deleted, it is only restored once the code is checked
+func (s *Service) GetMFAUser(mfaToken *helpers.JWTokenValidateResult, user *models.User) error {
+	user.ID = mfaToken.Value
+	var err error
+	if IsRestoreMFAToken(s.Config, mfaToken) {
+		err = s.DAL.GetDeletedUserByID(user, s.deletedSince())
+	} else {
+		err = s.DAL.GetUserByID(user)
+	}
+	if isNoneAffected(err) {
+		return apiError(constants.APIInvalidMFAToken, constants.StatusUnauthorized, "user not found")
+	} else if err != nil {
+		return apiError(constants.APIDatabaseGetUser, constants.StatusInternalServerError, err.Error())
+	}
+	return nil
+}
+
+// RestoreUser cancels the deletion of the user's account during the grace period
+func (s *Service) RestoreUser(user *models.User) error {
+	if err := s.DAL.RestoreUser(user, s.deletedSince()); err != nil {
+		return dalError(err, constants.APIDatabaseUpdateUser, err.Error())
+	}
+	s.Audit(user.ID, constants.AuditEventAccountRestored)
+	return nil
+}
@@ -0,0 +1,66 @@
+package account
+
+import (
+	"strings"
+
+	"github.com/axiomzen/zenauth/constants"
+	"github.com/axiomzen/zenauth/email"
+	"github.com/axiomzen/zenauth/helpers"
+	"github.com/axiomzen/zenauth/models"
+)
+
+// SendVerificationEmail emails the user the link to verify their email. The token of the link
+// is stateless, it only holds the email and the tenant
+func (s *Service) SendVerificationEmail(user *models.User) error {
+	emailer, err := email.Get(s.Config)
+	if err != nil {
+		return apiError(constants.APIVerifyEmailMessageError, constants.StatusInternalServerError, err.Error())
+	}
+
+	claims := make(map[string]interface{}, 2)
+	claims[s.Config.JwtClaimUserEmail] = user.Email
+	claims[s.Config.JwtClaimTenant] = s.DAL.TenantID()
+	jwt := helpers.JWTHelper{HashSecretBytes: s.Config.HashSecretBytes, Keys: s.Config.JwtKeyring}
+	if err := jwt.Generate(claims, s.Config.PasswordResetValidTokenDuration); err != nil {
+		return apiError(constants.APIVerifyEmailMessageError, constants.StatusInternalServerError, err.Error())
+	}
+	user.VerifyEmailToken = jwt.Token
+
+	msg, err := email.GetVerifyEmailMessage(s.Config, user)
+	if err != nil {
+		return apiError(constants.APIVerifyEmailMessageError, constants.StatusInternalServerError, err.Error())
+	}
+
+	// we don't care about email fails, they are logged
+	go func(m *email.Message) {
+		if err := emailer.Send(m); err != nil {
+			s.Log.WithError(err).Warn("error sending email")
+		}
+	}(msg)
+	return nil
+}
+
+// VerifyEmail marks the email of the link's token as verified, in the tenant of the token, and
+// loads the user
+func (s *Service) VerifyEmail(token, emailAddr string, user *models.User) error {
+	emailAddr = strings.ToLower(emailAddr)
+	jwt := helpers.JWTHelper{HashSecretBytes: s.Config.HashSecretBytes, Keys: s.Config.JwtKeyring, Token: token}
+	jwtTokenResult := jwt.Validate(s.Config.JwtClaimUserEmail)
+	switch jwtTokenResult.Status {
+	case helpers.JWTokenStatusValid:
+	case helpers.JWTokenStatusExpired:
+		return apiError(constants.APIInvalidVerifyEmailToken, constants.StatusBadRequest, "Token Exipired")
+	default:
+		return apiError(constants.APIInvalidVerifyEmailToken, constants.StatusBadRequest, "Invalid Token")
+	}
+	if jwtTokenResult.Value != emailAddr {
+		return apiError(constants.APIInvalidVerifyEmailToken, constants.StatusBadRequest, "Email doesn't match")
+	}
+
+	user.Email = emailAddr
+	user.Verified = true
+	if err := s.DAL.ForTenant(TokenTenantID(s.Config, jwtTokenResult)).UpdateUserVerified(user); err != nil {
+		return dalError(err, constants.APIDatabaseUpdateUser, err.Error())
+	}
+	return nil
+}
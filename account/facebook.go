@@ -0,0 +1,55 @@
+package account
+
+import (
+	"github.com/axiomzen/zenauth/constants"
+	"github.com/axiomzen/zenauth/helpers"
+	"github.com/axiomzen/zenauth/models"
+)
+
+// NewUserIdentity is the identity to store for a verified provider account
+func NewUserIdentity(social *helpers.SocialIdentity) *models.UserIdentity {
+	return &models.UserIdentity{
+		Provider:     social.Provider,
+		Subject:      social.Subject,
+		Email:        social.Email,
+		AccessToken:  social.AccessToken,
+		RefreshToken: social.RefreshToken,
+	}
+}
+
+// ValidateFacebookUser verifies the token of the Facebook user, returns the identity the token
+// belongs to
+func (s *Service) ValidateFacebookUser(fbUser *models.FacebookUser) (*models.UserIdentity, error) {
+	if fbUser.FacebookID == "" || fbUser.FacebookToken == "" {
+		return nil, apiError(constants.APIValidation, constants.StatusBadRequest, "Missing a field in request")
+	}
+
+	provider := s.Config.SocialProviders[constants.SocialProviderFacebook]
+	social, err := provider.Authenticate(&helpers.SocialCredentials{Subject: fbUser.FacebookID, AccessToken: fbUser.FacebookToken})
+	if err != nil {
+		return nil, apiError(constants.APIFacebookLoginNotValid, constants.StatusBadRequest, err.Error())
+	}
+	identity := NewUserIdentity(social)
+	if identity.Email == "" {
+		identity.Email = fbUser.FacebookEmail
+	}
+	return identity, nil
+}
+
+// LinkFacebook links the Facebook account to the user with the id, and loads the user
+func (s *Service) LinkFacebook(userID string, fbUser *models.FacebookUser, user *models.User) error {
+	identity, err := s.ValidateFacebookUser(fbUser)
+	if err != nil {
+		return err
+	}
+
+	identity.UserID = userID
+	if err := s.DAL.SaveUserIdentity(identity); err != nil {
+		return dalError(err, constants.APIDatabaseUpdateUser, err.Error())
+	}
+	fbUpdate := models.FacebookUpdate{ID: userID, FacebookUser: *fbUser}
+	if err := s.DAL.UpdateUser(&fbUpdate, user); err != nil {
+		return dalError(err, constants.APIDatabaseCreateUser, err.Error())
+	}
+	return nil
+}
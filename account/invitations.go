@@ -0,0 +1,50 @@
+package account
+
+import (
+	"strings"
+
+	"github.com/axiomzen/null"
+	"github.com/axiomzen/zenauth/constants"
+	"github.com/axiomzen/zenauth/helpers"
+	"github.com/axiomzen/zenauth/models"
+)
+
+// CreateInvitations invites the emails or Facebook IDs in codes, depending on invitationType, on
+// behalf of the user with the id. No invitation is created if any of them already has an account
+func (s *Service) CreateInvitations(userID, invitationType string, codes []string) (models.Invitations, error) {
+	invitations := make(models.Invitations, len(codes))
+	var user models.User
+	for idx, code := range codes {
+		switch invitationType {
+		case constants.InvitationTypeEmail:
+			// Verify invite email is valid
+			if strings.Count(code, "@") == 0 {
+				return nil, apiError(constants.APIValidationEmailNotValid, constants.StatusBadRequest, "Invalid email address")
+			}
+			code = helpers.EmailSanitize(code)
+			// Verify we don't already have a user with this email
+			user.Email = code
+			if err := s.DAL.GetUserByEmail(&user); err == nil {
+				return nil, apiError(constants.APIDatabaseCreateInvitation, constants.StatusBadRequest, "User with email already exists")
+			}
+		case constants.InvitationTypeFacebook:
+			// Verify we don't already have a user with this facebookID
+			user.FacebookID = code
+			if err := s.DAL.GetUserByFacebookID(&user); err == nil {
+				return nil, apiError(constants.APIDatabaseCreateInvitation, constants.StatusBadRequest, "User with facebookID already exists")
+			}
+		default:
+			return nil, apiError(constants.APIInvalidRequest, constants.StatusBadRequest, "Unknown invitation type "+invitationType)
+		}
+		invitations[idx] = &models.Invitation{
+			Type:      invitationType,
+			Code:      code,
+			CreatedBy: null.StringFrom(userID),
+		}
+	}
+
+	if err := s.DAL.CreateInvitations(&invitations); err != nil {
+		return nil, apiError(constants.APIInvitationsCreationError, constants.StatusBadRequest, err.Error())
+	}
+	return invitations, nil
+}
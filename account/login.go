@@ -0,0 +1,75 @@
+package account
+
+import (
+	"github.com/axiomzen/zenauth/constants"
+	"github.com/axiomzen/zenauth/helpers"
+	"github.com/axiomzen/zenauth/models"
+)
+
+// invalidLogin returns the error of a login with a wrong email, username or password
+func invalidLogin() error {
+	return apiError(constants.APILoginSignupInvalidCombination, constants.StatusUnauthorized, "Invalid email/username/password combination")
+}
+
+// LoginUnknownUser counts a login with an email or username no user has against the client ip,
+// returning the error of the login
+func (s *Service) LoginUnknownUser() error {
+	if err := s.CheckLoginThrottle(""); err != nil {
+		return err
+	}
+	s.LoginFailed("")
+	return invalidLogin()
+}
+
+// Login checks the password of the user, counting failures against the account and the client ip.
+// The caller issues the user's tokens, unless the user enabled two factor authentication: then
+// the client finishes logging in with mfaToken
+func (s *Service) Login(user *models.User, password string) (mfaToken string, err error) {
+	return s.login(user, password, false)
+}
+
+// LoginToRestore is Login for a user restoring their deleted account, the caller restores it.
+// The account is only restored once the client sends the two factor code along with mfaToken
+func (s *Service) LoginToRestore(user *models.User, password string) (mfaToken string, err error) {
+	return s.login(user, password, true)
+}
+
+func (s *Service) login(user *models.User, password string, restore bool) (string, error) {
+	if err := s.CheckLoginThrottle(user.ID); err != nil {
+		return "", err
+	}
+	if helpers.IsZeroString(user.Hash) {
+		// signed up with facebook or another provider
+		return "", apiError(constants.APILoginSignupInvalidCombination, constants.StatusBadRequest, "No password associated with this account")
+	}
+	passwordOK, err := s.Config.PasswordHasher.Check(*user.Hash, password)
+	if err != nil {
+		return "", apiError(constants.APIParsingPasswordHash, constants.StatusInternalServerError, err.Error())
+	}
+	if !passwordOK {
+		s.LoginFailed(user.ID)
+		return "", invalidLogin()
+	}
+	s.LoginSucceeded(user.ID)
+
+	go func(user models.User) {
+		// replace hashes of older schemes and weaker parameters
+		if update, newHash := s.Config.PasswordHasher.Upgrade(*user.Hash, password); update {
+			if err := s.DAL.UpdateUserHash(newHash, &user); err != nil {
+				s.Log.WithError(err).WithField("code", constants.APIDatabaseUpdate).Error("Could not update user hash")
+			}
+		}
+	}(*user)
+
+	if !user.TOTPEnabled {
+		return "", nil
+	}
+	if err := CheckUserEnabled(user); err != nil {
+		return "", err
+	}
+	mfaToken, err := s.newMFAToken(user.ID, restore)
+	if err != nil {
+		return "", apiError(constants.APIAuthTokenCreation, constants.StatusInternalServerError, err.Error())
+	}
+	return mfaToken, nil
+}
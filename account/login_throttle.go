@@ -0,0 +1,88 @@
+package account
+
+import (
+	"time"
+
+	"github.com/axiomzen/zenauth/constants"
+	"github.com/axiomzen/zenauth/models"
+)
+
+// loginThrottleKeys returns the throttle keys of a login attempt, the account key is
+// left out when no account matched so unknown emails don't fill up the table
+func loginThrottleKeys(userID, ip string) (accountKey, ipKey string) {
+	if userID != "" {
+		accountKey = models.LoginThrottleAccountKey(userID)
+	}
+	return accountKey, models.LoginThrottleIPKey(ip)
+}
+
+// LoginThrottledError is the error of a login the client has to wait RetryAfter before trying again
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+}
+
+// Error implements error
+func (e *LoginThrottledError) Error() string {
+	return "too many failed logins"
+}
+
+// CheckLoginThrottle returns a LoginThrottledError if the client has to wait before logging in to the
+// account with the id again
+func (s *Service) CheckLoginThrottle(userID string) error {
+	retryAfter, err := s.LoginRetryAfter(userID)
+	if err != nil {
+		return apiError(constants.APIDatabaseGet, constants.StatusInternalServerError, err.Error())
+	}
+	if retryAfter > 0 {
+		return &LoginThrottledError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// LoginRetryAfter returns how long the client has to wait before trying to log in to the account
+// with the id again, zero if it can try now
+func (s *Service) LoginRetryAfter(userID string) (time.Duration, error) {
+	accountKey, ipKey := loginThrottleKeys(userID, s.ClientIP)
+	throttles, err := s.DAL.GetLoginThrottles(accountKey, ipKey)
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now()
+	var retryAfter time.Duration
+	for _, throttle := range throttles {
+		policy := s.Config.LoginIPThrottle
+		if throttle.Key == accountKey {
+			policy = s.Config.LoginAccountThrottle
+		}
+		if wait := policy.RetryAfter(throttle.Failures, throttle.LastFailureAt.Time, throttle.LockedUntil.Time, now); wait > retryAfter {
+			retryAfter = wait
+		}
+	}
+	return retryAfter, nil
+}
+
+// LoginFailed counts a failed login against the account and the client ip, failures are logged
+func (s *Service) LoginFailed(userID string) {
+	accountKey, ipKey := loginThrottleKeys(userID, s.ClientIP)
+	if accountKey != "" {
+		throttle := models.LoginThrottle{Key: accountKey}
+		if err := s.DAL.RecordLoginFailure(&throttle, s.Config.LoginAccountThrottle); err != nil {
+			s.Log.WithError(err).Error("Could not record failed login")
+		} else if throttle.Failures == s.Config.LoginAccountThrottle.LockoutThreshold {
+			s.Audit(userID, constants.AuditEventAccountLocked)
+		}
+	}
+	throttle := models.LoginThrottle{Key: ipKey}
+	if err := s.DAL.RecordLoginFailure(&throttle, s.Config.LoginIPThrottle); err != nil {
+		s.Log.WithError(err).Error("Could not record failed login")
+	}
+}
+
+// LoginSucceeded forgets the failed logins of the account. The ip's are kept,
+// or logging into one account would reset guessing the passwords of others
+func (s *Service) LoginSucceeded(userID string) {
+	accountKey, _ := loginThrottleKeys(userID, "")
+	if err := s.DAL.ClearLoginThrottle(accountKey); err != nil {
+		s.Log.WithError(err).Error("Could not clear failed logins")
+	}
+}
@@ -0,0 +1,136 @@
+package account
+
+import (
+	"time"
+
+	"github.com/axiomzen/null"
+	"github.com/axiomzen/zenauth/constants"
+	"github.com/axiomzen/zenauth/data"
+	"github.com/axiomzen/zenauth/helpers"
+	"github.com/axiomzen/zenauth/models"
+)
+
+// CheckMFACode checks either a TOTP code or a recovery code of the user
+func (s *Service) CheckMFACode(user *models.User, code string) error {
+	if helpers.IsTOTPCode(code) {
+		return s.CheckTOTP(user, code)
+	}
+	return s.CheckRecoveryCode(user, code)
+}
+
+// isInvalidCode tells whether err is the error of a code that doesn't match
+func isInvalidCode(err error) bool {
+	apiErr, ok := err.(data.APIError)
+	return ok && apiErr.Code == constants.APIInvalidMFACode
+}
+
+// VerifyMFACode checks either a TOTP code or a recovery code of the user as CheckMFACode does,
+// counting bad codes against the login throttle of the account. Callers check LoginRetryAfter first
+func (s *Service) VerifyMFACode(user *models.User, code string) error {
+	err := s.CheckMFACode(user, code)
+	if isInvalidCode(err) {
+		s.LoginFailed(user.ID)
+	}
+	return err
+}
+
+// CheckMFALogin checks the code of the second step of a login, with the validated mfa token of
+// the user. Bad codes count against the login throttle of the account, and the mfa token is
+// revoked after MFAMaxAttempts of them so the password has to be entered again
+func (s *Service) CheckMFALogin(mfaToken *helpers.JWTokenValidateResult, user *models.User, code string) error {
+	revoked, err := s.DAL.IsTokenRevoked(user.ID, mfaToken.JTI, mfaToken.IssuedAt)
+	if err != nil {
+		return apiError(constants.APIDatabaseGet, constants.StatusInternalServerError, err.Error())
+	}
+	if revoked {
+		return apiError(constants.APIInvalidMFAToken, constants.StatusUnauthorized, "mfa token revoked")
+	}
+
+	key := models.LoginThrottleMFAKey(mfaToken.JTI)
+	err = s.VerifyMFACode(user, code)
+	if isInvalidCode(err) {
+		// the throttle only counts the bad codes of the token while it is valid
+		throttle := models.LoginThrottle{Key: key}
+		policy := helpers.LoginThrottlePolicy{LockoutDuration: s.Config.MFAPendingTokenDuration}
+		if recordErr := s.DAL.RecordLoginFailure(&throttle, policy); recordErr != nil {
+			s.Log.WithError(recordErr).Error("Could not record bad mfa code")
+		} else if throttle.Failures >= int(s.Config.MFAMaxAttempts) {
+			if revokeErr := s.DAL.RevokeToken(user.ID, mfaToken.JTI, mfaToken.ExpiresAt); revokeErr != nil {
+				s.Log.WithError(revokeErr).Error("Could not revoke mfa token")
+			}
+		}
+		return err
+	} else if err != nil {
+		return err
+	}
+
+	s.LoginSucceeded(user.ID)
+	if err := s.DAL.ClearLoginThrottle(key); err != nil {
+		s.Log.WithError(err).Error("Could not clear bad mfa codes")
+	}
+	return nil
+}
+
+// CheckTOTP checks a code against the user's TOTP secret. Codes can only be used once
+func (s *Service) CheckTOTP(user *models.User, code string) error {
+	if user.TOTPSecret == nil {
+		return apiError(constants.APIMFANotEnrolled, constants.StatusBadRequest, "no TOTP secret")
+	}
+	secret, err := helpers.DecryptSecret(s.Config.MFAEncryptionKeyBytes, *user.TOTPSecret)
+	if err != nil {
+		return apiError(constants.APIParsing, constants.StatusInternalServerError, err.Error())
+	}
+	step, ok := helpers.ValidateTOTP(secret, code, time.Now(), int(s.Config.TOTPSkew))
+	if ok {
+		err = s.DAL.ConsumeUserTOTPStep(user, step)
+		if isNoneAffected(err) {
+			// replayed code
+			ok = false
+		} else if err != nil {
+			return apiError(constants.APIDatabaseUpdateUser, constants.StatusInternalServerError, err.Error())
+		}
+	}
+	if !ok {
+		return apiError(constants.APIInvalidMFACode, constants.StatusUnauthorized, "invalid code")
+	}
+	return nil
+}
+
+// CheckRecoveryCode checks the code against the user's unused recovery codes, consuming the one that matches
+func (s *Service) CheckRecoveryCode(user *models.User, code string) error {
+	var codes models.RecoveryCodeList
+	if err := s.DAL.GetUnusedRecoveryCodes(user.ID, &codes); err != nil {
+		return apiError(constants.APIDatabaseGet, constants.StatusInternalServerError, err.Error())
+	}
+	code = helpers.NormalizeRecoveryCode(code)
+	for _, recoveryCode := range codes {
+		if ok, _ := helpers.CheckPasswordBcrypt(recoveryCode.Hash, code); !ok {
+			continue
+		}
+		err := s.DAL.ConsumeRecoveryCode(recoveryCode)
+		if isNoneAffected(err) {
+			// used concurrently
+			break
+		} else if err != nil {
+			return apiError(constants.APIDatabaseUpdate, constants.StatusInternalServerError, err.Error())
+		}
+		s.Audit(user.ID, constants.AuditEventRecoveryCodeUsed)
+		return nil
+	}
+	return apiError(constants.APIInvalidMFACode, constants.StatusUnauthorized, "invalid recovery code")
+}
+
+// Audit records an event of the user in the audit log, with the client of the request. Failures
+// are logged but don't fail the operation
+func (s *Service) Audit(userID, event string) {
+	entry := models.AuditEntry{UserID: userID, Event: event}
+	if s.ClientIP != "" {
+		entry.IPAddress = null.StringFrom(s.ClientIP)
+	}
+	if s.UserAgent != "" {
+		entry.UserAgent = null.StringFrom(s.UserAgent)
+	}
+	if err := s.DAL.CreateAuditEntry(&entry); err != nil {
+		s.Log.WithError(err).WithField("event", event).Error("Could not record audit entry")
+	}
+}
@@ -0,0 +1,216 @@
+package account
+
+import (
+	"strings"
+
+	"github.com/axiomzen/zenauth/constants"
+	"github.com/axiomzen/zenauth/data"
+	"github.com/axiomzen/zenauth/email"
+	"github.com/axiomzen/zenauth/helpers"
+	"github.com/axiomzen/zenauth/models"
+)
+
+// PasswordPolicyError is the error of a password breaking the policy, with each rule it breaks
+type PasswordPolicyError struct {
+	Violations []helpers.PasswordViolation
+}
+
+// Code returns the API error code of the error. It stays APIValidationPasswordTooShort for short
+// passwords, for older clients
+func (e *PasswordPolicyError) Code() constants.APIErrorCode {
+	if e.Violations[0].Rule == helpers.PasswordRuleMinLength {
+		return constants.APIValidationPasswordTooShort
+	}
+	return constants.APIValidationPasswordPolicy
+}
+
+// Error returns the rules broken and their messages
+func (e *PasswordPolicyError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, violation := range e.Violations {
+		messages[i] = violation.Rule + ": " + violation.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// passwordHashes returns the user's current and previous hashes, newest first
+func (s *Service) passwordHashes(user *models.User) ([]string, error) {
+	var history models.PasswordHistoryList
+	if err := s.DAL.GetPasswordHistory(user.ID, s.Config.PasswordPolicy.HistorySize, &history); err != nil {
+		return nil, err
+	}
+	var hashes []string
+	if !helpers.IsZeroString(user.Hash) && (len(history) == 0 || history[0].Hash != *user.Hash) {
+		hashes = append(hashes, *user.Hash)
+	}
+	for _, entry := range history {
+		hashes = append(hashes, entry.Hash)
+	}
+	return hashes, nil
+}
+
+// CheckPasswordPolicy returns a PasswordPolicyError if password breaks the policy for user, who
+// has no ID yet when signing up. The previous passwords of users with an ID can't be used again
+func (s *Service) CheckPasswordPolicy(password string, user *models.User) error {
+	policy := s.Config.PasswordPolicy
+	violations, err := policy.Check(password, user.Email, user.UserName)
+	if err != nil {
+		return apiError(constants.APIGeneric, constants.StatusInternalServerError, err.Error())
+	}
+	// the history is only worth the hash comparisons if the rest passed
+	if len(violations) == 0 && user.ID != "" && policy.HistorySize > 0 {
+		hashes, err := s.passwordHashes(user)
+		if err != nil {
+			return apiError(constants.APIDatabaseGet, constants.StatusInternalServerError, err.Error())
+		}
+		violation, err := policy.Reused(s.Config.PasswordHasher, password, hashes)
+		if err != nil {
+			return apiError(constants.APIParsingPasswordHash, constants.StatusInternalServerError, err.Error())
+		}
+		if violation != nil {
+			violations = append(violations, *violation)
+		}
+	}
+	if len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// RecordPasswordHistory keeps the new hash of the user so it can't be used again, failures are logged
+func (s *Service) RecordPasswordHistory(userID, hash string) {
+	if s.Config.PasswordPolicy.HistorySize <= 0 {
+		return
+	}
+	entry := models.PasswordHistory{UserID: userID, Hash: hash}
+	if err := s.DAL.AddPasswordHistory(&entry, s.Config.PasswordPolicy.HistorySize); err != nil {
+		s.Log.WithError(err).Error("Could not record password history")
+	}
+}
+
+// revokeAllTokens logs the user out everywhere after their password changed, the caller issues
+// the user new tokens
+func revokeAllTokens(dal data.ZENAUTHProvider, userID string) error {
+	if err := dal.RevokeAllUserTokens(userID); err != nil {
+		return apiError(constants.APIDatabaseCreate, constants.StatusInternalServerError, err.Error())
+	}
+	return nil
+}
+
+// ChangePassword changes the password of the user with the id after checking their old one, and
+// loads the user. The user is logged out everywhere, the caller issues them new tokens
+func (s *Service) ChangePassword(userID, oldPassword, newPassword string, user *models.User) error {
+	user.ID = userID
+	if err := s.DAL.GetUserByID(user); err != nil {
+		return dalError(err, constants.APIDatabaseGetUser, err.Error())
+	}
+
+	// if we have no hash, then we have no old password
+	if helpers.IsZeroString(user.Hash) {
+		emailAddr := "NULL"
+		if user.Email != "" {
+			emailAddr = user.Email
+		}
+		return apiError(constants.APIDatabaseUpdateUser, constants.StatusBadRequest, "No password associated with this email: "+emailAddr)
+	}
+	if passwordOK, err := s.Config.PasswordHasher.Check(*user.Hash, oldPassword); err != nil {
+		return apiError(constants.APIDatabaseUpdateUser, constants.StatusInternalServerError, err.Error())
+	} else if !passwordOK {
+		return apiError(constants.APIDatabaseUpdateUser, constants.StatusBadRequest, "Old password incorrect")
+	}
+	if err := s.CheckPasswordPolicy(newPassword, user); err != nil {
+		return err
+	}
+
+	newHash, err := s.Config.PasswordHasher.Hash(newPassword)
+	if err != nil {
+		return apiError(constants.APIParsingPasswordHash, constants.StatusInternalServerError, err.Error())
+	}
+	if err := s.DAL.UpdateUserHash(newHash, user); err != nil {
+		return dalError(err, constants.APIDatabaseUpdateUser, err.Error())
+	}
+	s.RecordPasswordHistory(user.ID, newHash)
+	return revokeAllTokens(s.DAL, user.ID)
+}
+
+// SendPasswordReset saves a single use reset token on the user with the email, and emails them
+// the reset link
+func (s *Service) SendPasswordReset(emailAddr string) error {
+	// generate a JWT with expiry and other claims (email) so that we don't have to check the DB on the get
+	claims := make(map[string]interface{}, 2)
+	claims[s.Config.JwtClaimUserEmail] = emailAddr
+	claims[s.Config.JwtClaimTenant] = s.DAL.TenantID()
+	jwt := helpers.JWTHelper{HashSecretBytes: s.Config.HashSecretBytes, Keys: s.Config.JwtKeyring}
+	if err := jwt.Generate(claims, s.Config.PasswordResetValidTokenDuration); err != nil {
+		return apiError(constants.APIParsingQueryParams, constants.StatusInternalServerError, err.Error())
+	}
+
+	// the token is saved on the user so that it is single use only
+	var user models.User
+	user.Email = emailAddr
+	user.ResetToken = &jwt.Token
+	if err := s.DAL.CreateUserResetToken(&user); err != nil {
+		if isNoneAffected(err) {
+			return apiError(constants.APIEmailNotFound, constants.StatusBadRequest, "Email does not exist")
+		}
+		return apiError(constants.APIParsingQueryParams, constants.StatusInternalServerError, err.Error())
+	}
+
+	emailer, err := email.Get(s.Config)
+	if err != nil {
+		return apiError(constants.APIForgotPasswordMessageError, constants.StatusInternalServerError, err.Error())
+	}
+	msg, err := email.GetResetPasswordMessage(s.Config, &user)
+	if err != nil {
+		return apiError(constants.APIForgotPasswordMessageError, constants.StatusInternalServerError, err.Error())
+	}
+	go func(m *email.Message) {
+		if err := emailer.Send(m); err != nil {
+			s.Log.WithError(err).Warn("error sending email")
+		}
+	}(msg)
+	return nil
+}
+
+// ResetPassword sets the new password of the user the reset token was sent to, in the tenant of
+// the token, and loads the user. The token can only be used once. The user is logged out
+// everywhere, the caller issues them new tokens
+func (s *Service) ResetPassword(reset *models.UserPasswordReset, user *models.User) error {
+	jwt := helpers.JWTHelper{HashSecretBytes: s.Config.HashSecretBytes, Keys: s.Config.JwtKeyring, Token: reset.Token}
+	jwtTokenResult := jwt.Validate(s.Config.JwtClaimUserEmail)
+	switch jwtTokenResult.Status {
+	case helpers.JWTokenStatusValid:
+	case helpers.JWTokenStatusExpired:
+		return apiError(constants.APIInvalidResetToken, constants.StatusBadRequest, "Reset Request Expired")
+	default:
+		return apiError(constants.APIInvalidResetToken, constants.StatusBadRequest, "Invalid Token")
+	}
+	if jwtTokenResult.Value != reset.Email {
+		return apiError(constants.APIInvalidResetToken, constants.StatusBadRequest, "Email doesn't match")
+	}
+	dal := s.DAL.ForTenant(TokenTenantID(s.Config, jwtTokenResult))
+
+	// the policy needs the user's username and previous passwords
+	var current models.User
+	current.Email = reset.Email
+	if err := dal.GetUserByEmail(&current); err != nil {
+		return dalError(err, constants.APIDatabaseGetUser, err.Error())
+	}
+	if err := s.CheckPasswordPolicy(reset.NewPassword, &current); err != nil {
+		return err
+	}
+	newHash, err := s.Config.PasswordHasher.Hash(reset.NewPassword)
+	if err != nil {
+		return apiError(constants.APIParsingPasswordHash, constants.StatusInternalServerError, err.Error())
+	}
+
+	// this sets the token to null and updates the hash only if the token matches
+	user.Email = reset.Email
+	user.ResetToken = &reset.Token
+	user.Hash = &newHash
+	if err := dal.ConsumeUserResetToken(user); err != nil {
+		return dalError(err, constants.APIParsingPasswordHash, err.Error())
+	}
+	s.RecordPasswordHistory(current.ID, newHash)
+	return revokeAllTokens(dal, current.ID)
+}
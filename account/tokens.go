@@ -0,0 +1,140 @@
+package account
+
+import (
+	"time"
+
+	"github.com/axiomzen/zenauth/config"
+	"github.com/axiomzen/zenauth/constants"
+	"github.com/axiomzen/zenauth/data"
+	"github.com/axiomzen/zenauth/helpers"
+	"github.com/axiomzen/zenauth/models"
+	"github.com/twinj/uuid"
+)
+
+// NewAuthToken creates a new auth token for the user, with their tenant and the names of their roles
+// and permissions. familyID is the refresh token family issued along with it, if any, so logging out
+// can revoke it. The user's Roles are set along the way
+func (s *Service) NewAuthToken(user *models.User, familyID string) (string, error) {
+	var access models.UserAccess
+	if err := s.DAL.GetUserAccess(user.ID, &access); err != nil {
+		return "", err
+	}
+	user.Roles = access.Roles
+	claims := make(map[string]interface{}, 4)
+	claims[s.Config.JwtClaimUserID] = user.ID
+	claims[s.Config.JwtClaimTenant] = user.TenantID
+	claims[s.Config.JwtClaimRoles] = access.Roles
+	claims[s.Config.JwtClaimPermissions] = access.Permissions
+	if familyID != "" {
+		claims[s.Config.JwtClaimRefreshFamily] = familyID
+	}
+	jwt := helpers.JWTHelper{HashSecretBytes: s.Config.HashSecretBytes, Keys: s.Config.JwtKeyring}
+	err := jwt.Generate(claims, s.Config.JwtUserTokenDuration)
+	return jwt.Token, err
+}
+
+// NewRefreshToken creates and stores a refresh token for the user with the id, starting a new token
+// family. Returns the token and its family id
+func (s *Service) NewRefreshToken(userID string) (string, string, error) {
+	token, hash, err := helpers.GenerateOpaqueToken(int(s.Config.RefreshTokenLength))
+	if err != nil {
+		return "", "", err
+	}
+	refreshToken := models.RefreshToken{
+		UserID:    userID,
+		FamilyID:  uuid.NewV4().String(),
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(s.Config.RefreshTokenDuration),
+	}
+	if err := s.DAL.CreateRefreshToken(&refreshToken); err != nil {
+		return "", "", err
+	}
+	return token, refreshToken.FamilyID, nil
+}
+
+// NewMFAToken creates the short lived token that stands in for the password while the user with
+// the id enters their two factor code
+func (s *Service) NewMFAToken(userID string) (string, error) {
+	return s.newMFAToken(userID, false)
+}
+
+// IsRestoreMFAToken tells whether the validated mfa token restores a deleted account
+func IsRestoreMFAToken(conf *config.ZENAUTHConfig, mfaToken *helpers.JWTokenValidateResult) bool {
+	return mfaToken.StringClaim(conf.JwtClaimMFARestore) != ""
+}
+
+func (s *Service) newMFAToken(userID string, restore bool) (string, error) {
+	claims := make(map[string]interface{}, 2)
+	claims[s.Config.JwtClaimMFAPending] = userID
+	if restore {
+		claims[s.Config.JwtClaimMFARestore] = "true"
+	}
+	jwt := helpers.JWTHelper{HashSecretBytes: s.Config.HashSecretBytes, Keys: s.Config.JwtKeyring}
+	err := jwt.Generate(claims, s.Config.MFAPendingTokenDuration)
+	return jwt.Token, err
+}
+
+// CheckUserEnabled errs if an admin disabled the account, which can't get new tokens
+func CheckUserEnabled(user *models.User) error {
+	if user.DisabledAt.Valid {
+		return apiError(constants.APIAccountDisabled, constants.StatusForbidden, "account disabled")
+	}
+	return nil
+}
+
+// IssueTokens sets a new auth token and refresh token on the user, unless their account is disabled
+func (s *Service) IssueTokens(user *models.User) error {
+	if err := CheckUserEnabled(user); err != nil {
+		return err
+	}
+	refreshToken, familyID, err := s.NewRefreshToken(user.ID)
+	if err != nil {
+		return apiError(constants.APIAuthTokenCreation, constants.StatusInternalServerError, err.Error())
+	}
+	authToken, err := s.NewAuthToken(user, familyID)
+	if err != nil {
+		return apiError(constants.APIAuthTokenCreation, constants.StatusInternalServerError, err.Error())
+	}
+	user.AuthToken = authToken
+	user.RefreshToken = refreshToken
+	return nil
+}
+
+// RefreshToken exchanges a refresh token for a new one in the same family, and loads its user
+// with a new auth token. Reusing a refresh token revokes its whole family
+func (s *Service) RefreshToken(refreshToken string, user *models.User) error {
+	token, hash, err := helpers.GenerateOpaqueToken(int(s.Config.RefreshTokenLength))
+	if err != nil {
+		return apiError(constants.APIAuthTokenCreation, constants.StatusInternalServerError, err.Error())
+	}
+	old := models.RefreshToken{TokenHash: helpers.HashOpaqueToken(refreshToken)}
+	next := models.RefreshToken{
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(s.Config.RefreshTokenDuration),
+	}
+	if err := s.DAL.RotateRefreshToken(&old, &next); err != nil {
+		dalErr, _ := err.(data.DALError)
+		switch dalErr.ErrorCode {
+		case data.DALErrorCodeNoneAffected:
+			return apiError(constants.APIInvalidRefreshToken, constants.StatusUnauthorized, err.Error())
+		case data.DALErrorCodeTokenReused:
+			s.Log.WithField("userID", old.UserID).Warn("refresh token reused, token family revoked")
+		}
+		return dalError(err, constants.APIDatabaseUpdate, err.Error())
+	}
+
+	user.ID = next.UserID
+	if err := s.DAL.GetUserByID(user); err != nil {
+		return apiError(constants.APIDatabaseGetUser, constants.StatusInternalServerError, err.Error())
+	}
+	if err := CheckUserEnabled(user); err != nil {
+		return err
+	}
+	authToken, err := s.NewAuthToken(user, next.FamilyID)
+	if err != nil {
+		return apiError(constants.APIAuthTokenCreation, constants.StatusInternalServerError, err.Error())
+	}
+	user.AuthToken = authToken
+	user.RefreshToken = token
+	return nil
+}
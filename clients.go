@@ -0,0 +1,79 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/axiomzen/zenauth/config"
+	"github.com/axiomzen/zenauth/data"
+	"github.com/axiomzen/zenauth/helpers"
+	"github.com/axiomzen/zenauth/models"
+)
+
+const clientsUsage = `usage: zenauth clients <command> [flags]
+
+Manages the clients of the OpenID Connect provider.
+
+commands:
+  add   register a client, prints its id and secret (the secret can't be shown again)
+`
+
+// stringsFlag collects a flag that can be repeated
+type stringsFlag []string
+
+func (s *stringsFlag) String() string {
+	return fmt.Sprint(*s)
+}
+
+func (s *stringsFlag) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// clientsCommand runs the clients subcommand, returns the exit code
+func clientsCommand(args []string) int {
+	if len(args) == 0 || args[0] != "add" {
+		fmt.Fprint(os.Stderr, clientsUsage)
+		return 2
+	}
+
+	flags := flag.NewFlagSet("clients add", flag.ContinueOnError)
+	name := flags.String("name", "", "name of the client, shown on the login page")
+	public := flags.Bool("public", false, "register a public client (e.g. a mobile app), which gets no secret and relies on PKCE")
+	var redirectURIs stringsFlag
+	flags.Var(&redirectURIs, "redirect-uri", "allowed redirect uri, can be repeated")
+	if err := flags.Parse(args[1:]); err != nil {
+		return 2
+	}
+	if *name == "" || len(redirectURIs) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: zenauth clients add -name <name> -redirect-uri <uri> [-redirect-uri <uri>] [-public]")
+		return 2
+	}
+
+	conf, err := config.Get()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+	dal, err := data.Get(conf)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+
+	client := models.OAuthClient{Name: *name, RedirectURIs: redirectURIs}
+	if client.ClientID, client.ClientSecret, client.SecretHash, err = helpers.GenerateOAuthClientCredentials(*public); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+	if err := dal.CreateOAuthClient(&client); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+	fmt.Println("client_id:", client.ClientID)
+	if client.ClientSecret != "" {
+		fmt.Println("client_secret:", client.ClientSecret)
+	}
+	return 0
+}
@@ -7,7 +7,12 @@ package config
 // from this you can generate the html page to get the config route
 // TODO: custom go generate tool to generate the html template
 
-import "time"
+import (
+	"net/http"
+	"time"
+
+	"github.com/axiomzen/zenauth/helpers"
+)
 
 // references
 // https://blog.gopheracademy.com/advent-2013/day-03-building-a-twelve-factor-app-in-go/
@@ -24,7 +29,9 @@ type ZENAUTHConfig struct {
 	APIToken                           string        `required:"true"`
 	PasswordResetValidTokenDuration    time.Duration `default:"60m"`
 	RandomPasswordLengthBeforeEncoding uint16        `default:"8"`
-	JwtUserTokenDuration               time.Duration `default:"8760h"`
+	JwtUserTokenDuration               time.Duration `default:"15m"`
+	RefreshTokenDuration               time.Duration `default:"720h"`
+	RefreshTokenLength                 uint16        `default:"32"`
 	JwtClaimUserID                     string        `default:"userid"`
 	JwtClaimUserEmail                  string        `default:"emailaddr"`
 	DefaultContentType                 string        `default:"application/json"`
@@ -36,11 +43,153 @@ type ZENAUTHConfig struct {
 	TestDomainHost                     string        `default:"localhost"`
 	Port                               uint16        `default:"5000"`
 	GRPCPort                           uint16        `default:"5001"`
+	GRPCRequireAPIToken                bool          `default:"true"`
+	GRPCReflection                     bool          `default:"true"`
 	MinPasswordLength                  uint16        `default:"8"`
 
+	// password policy, MinPasswordLength is above. bcrypt only uses the first 72 bytes of a password
+	MaxPasswordLength          uint16   `default:"72"`
+	PasswordRequiredClasses    []string `required:"false"`
+	PasswordRejectPersonalInfo bool     `default:"true"`
+	// file of breached password SHA1 hashes, e.g. a Have I Been Pwned download
+	BreachedPasswordsFile string                 `required:"false"`
+	PasswordHistorySize   uint16                 `default:"0"`
+	PasswordPolicy        helpers.PasswordPolicy `ignored:"true"`
+
 	BcryptCost          uint16 `default:"8"`
 	AllowHashDowngrades bool   `default:"false"`
 
+	// scheme new password hashes are made with, bcrypt or argon2id. Hashes of the other
+	// schemes are still checked, and replaced on login
+	PasswordHashScheme string `default:"argon2id"`
+	Argon2Time         uint32 `default:"2"`
+	// in KiB
+	Argon2Memory  uint32 `default:"19456"`
+	Argon2Threads uint8  `default:"1"`
+	// iterations the pbkdf2 hashes of older deployments were made with
+	PBKDF2Iterations uint32                 `default:"4096"`
+	PasswordHasher   helpers.PasswordHasher `ignored:"true"`
+
+	// PEM encoded RSA, EC or Ed25519 private key to sign tokens with instead of HashSecret
+	JwtSigningKeyFile string `required:"false"`
+	// directory of signing keys managed with `zenauth keys`, instead of JwtSigningKeyFile.
+	// ZENAUTH_JWTKEYRINGMAXPREVIOUS is only read by `zenauth keys promote`
+	JwtKeyringDir string           `required:"false"`
+	JwtKeyring    *helpers.Keyring `ignored:"true"`
+
+	// key the TOTP secrets are encrypted with, derived from HashSecret if not set
+	MFAEncryptionKey        string        `required:"false"`
+	MFAEncryptionKeyBytes   []byte        `ignored:"true"`
+	MFAPendingTokenDuration time.Duration `default:"5m"`
+	JwtClaimMFAPending      string        `default:"mfapending"`
+	// set on the mfa token of a login restoring a deleted account
+	JwtClaimMFARestore string `default:"mfarestore"`
+	// bad codes after which an mfa token is revoked, the user has to log in again
+	MFAMaxAttempts uint16 `default:"5"`
+	// number of 30 second steps of clock drift allowed either way
+	TOTPSkew uint16 `default:"1"`
+	// number of recovery codes generated at enrollment
+	RecoveryCodeCount uint16 `default:"10"`
+
+	// WebAuthn relying party, passkeys are scoped to the id (a domain) and can be used from the origins
+	WebAuthnRPID                    string        `default:"localhost"`
+	WebAuthnRPName                  string        `required:"false"`
+	WebAuthnOrigins                 []string      `default:"https://localhost"`
+	WebAuthnRequireUserVerification bool          `default:"false"`
+	WebAuthnChallengeDuration       time.Duration `default:"5m"`
+	JwtClaimWebAuthnChallenge       string        `default:"webauthnchallenge"`
+
+	// passwordless login links, the link defaults to the consume route of this service
+	MagicLinkURL                string        `required:"false"`
+	MagicLinkValidTokenDuration time.Duration `default:"15m"`
+	JwtClaimMagicLink           string        `default:"magiclinkemail"`
+	// name of the email templates in TemplatesPath, without the .html.tmpl and .txt.tmpl extensions
+	MagicLinkTemplate string `default:"magic_link"`
+
+	// social login providers besides Facebook, each is enabled by setting its client id
+	GoogleClientID     string `required:"false"`
+	GoogleClientSecret string `required:"false"`
+	AppleClientID      string `required:"false"`
+	AppleClientSecret  string `required:"false"`
+	GitHubClientID     string `required:"false"`
+	GitHubClientSecret string `required:"false"`
+	// a generic OpenID Connect provider, configured by discovery from the issuer
+	OIDCProviderName string                              `default:"oidc"`
+	OIDCIssuer       string                              `required:"false"`
+	OIDCClientID     string                              `required:"false"`
+	OIDCClientSecret string                              `required:"false"`
+	SocialProviders  map[string]helpers.IdentityProvider `ignored:"true"`
+	// timeout of the requests to identity providers, Facebook included
+	SocialHTTPTimeout time.Duration `default:"10s"`
+	SocialHTTPClient  *http.Client  `ignored:"true"`
+
+	// OpenID Connect provider for other apps to log users in with, the issuer defaults to the url of this service
+	OAuthIssuer       string        `required:"false"`
+	OAuthCodeDuration time.Duration `default:"60s"`
+	// name of the login page template in HTMLTemplatesPath
+	OAuthLoginTemplate string `default:"oauth_login.html.tmpl"`
+
+	// failed login throttling, counted per account and per client ip
+	LoginThrottleFreeAttempts   uint16        `default:"3"`
+	LoginThrottleBaseDelay      time.Duration `default:"1s"`
+	LoginThrottleMaxDelay       time.Duration `default:"1m"`
+	LoginLockoutThreshold       uint16        `default:"10"`
+	LoginLockoutDuration        time.Duration `default:"15m"`
+	LoginIPThrottleFreeAttempts uint16        `default:"20"`
+	LoginIPLockoutThreshold     uint16        `default:"100"`
+	// header a trusted proxy puts the client ip in (e.g. X-Forwarded-For), the connection's address is used if empty
+	ClientIPHeader       string                      `required:"false"`
+	LoginAccountThrottle helpers.LoginThrottlePolicy `ignored:"true"`
+	LoginIPThrottle      helpers.LoginThrottlePolicy `ignored:"true"`
+
+	// token bucket rate limits as name=key:burst/period, where key is ip, api_token or user.
+	// Route groups are named in InitRouter, gRPC limits by method name. A burst of 0 turns a default off
+	RateLimitBackend     string                       `default:"memory"`
+	RateLimits           []string                     `default:"signup=ip:10/1h,login=ip:60/1m,exists=ip:30/1m,forgot_password=ip:5/1h,magic_link=ip:5/1h,facebook=ip:60/1m,social=ip:60/1m,webauthn_login=ip:60/1m,refresh=ip:120/1m"`
+	GRPCRateLimits       []string                     `default:"AuthUserByEmail=ip:60/1m,AuthUserByMFA=ip:60/1m,AuthUserByFacebook=ip:60/1m,AuthUserBySocial=ip:60/1m,RefreshToken=ip:120/1m,Exists=ip:30/1m,ForgotPassword=ip:5/1h"`
+	RouteRateLimits      map[string]helpers.RateLimit `ignored:"true"`
+	GRPCMethodRateLimits map[string]helpers.RateLimit `ignored:"true"`
+
+	// TLS of the gRPC server, which is plaintext without a certificate. With a client CA, callers
+	// need a certificate it signed. The files are reloaded when they change
+	GRPCTLSCertFile       string        `required:"false"`
+	GRPCTLSKeyFile        string        `required:"false"`
+	GRPCTLSClientCAFile   string        `required:"false"`
+	GRPCTLSReloadInterval time.Duration `default:"30s"`
+	// services allowed to call gRPC methods as method=identity|identity, where an identity is the
+	// common name, a DNS name or a URI of a client certificate. Other methods take any client
+	GRPCMethodClients          []string            `required:"false"`
+	GRPCMethodClientIdentities map[string][]string `ignored:"true"`
+
+	// token of the /v1/admins routes, which are disabled when it is empty
+	AdminToken       string `required:"false"`
+	AdminTokenHeader string `default:"x-admin-token"`
+	// users created per transaction by imports
+	UserImportBatchSize uint16 `default:"500"`
+	// users per page of the admin user listing, unless the request asks for fewer
+	AdminUserPageSize uint16 `default:"50"`
+
+	// data exports are emailed as a download link, which defaults to the download route of this service
+	UserExportURL           string        `required:"false"`
+	UserExportValidDuration time.Duration `default:"72h"`
+	JwtClaimUserExport      string        `default:"userexport"`
+
+	// deleted accounts can be restored during the grace period, then the purger removes them.
+	// Set the interval to 0 to run the purge from cron with zenauth users purge instead
+	UserDeletionGracePeriod time.Duration `default:"720h"`
+	UserPurgeInterval       time.Duration `default:"1h"`
+
+	// claims of the names of the user's roles and permissions in auth tokens
+	JwtClaimRoles       string `default:"roles"`
+	JwtClaimPermissions string `default:"permissions"`
+	// claim of the refresh token family an auth token was issued with, logging out revokes the family
+	JwtClaimRefreshFamily string `default:"refreshfamily"`
+
+	// claim of the tenant in tokens, and the gRPC metadata selecting a tenant by id
+	JwtClaimTenant       string `default:"tenant"`
+	TenantHeader         string `default:"x-tenant-id"`
+	TenantAPITokenLength uint16 `default:"32"`
+
 	DeflateCompression    int8          `default:"-1"`
 	DrainAndDieTimeout    time.Duration `default:"60s"`
 	TransportReadTimeout  time.Duration `default:"60s"`
@@ -75,6 +224,10 @@ type ZENAUTHConfig struct {
 	PostgreSQLSSL            *bool         `default:"true"`
 	PostgreSQLRetryNumTimes  uint16        `default:"10"`
 	PostgreSQLRetrySleepTime time.Duration `default:"30s"`
+	// how long revoked token lookups are cached before going back to the database. Replicas drop
+	// their cached lookups of a user as soon as any of them revokes one of the user's tokens, over
+	// Postgres LISTEN/NOTIFY; the duration only bounds how stale they get while that connection is down
+	RevocationCacheDuration time.Duration `default:"30s"`
 
 	// how to override the environment var
 	//AccessorServiceFQDN string `envconfig:"ACCESSOR_ENV_DOCKERCLOUD_SERVICE_FQDN"`
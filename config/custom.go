@@ -5,11 +5,17 @@ package config
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
 	"regexp"
 	"strconv"
+	"strings"
 
 	"github.com/axiomzen/zenauth/constants"
+	"github.com/axiomzen/zenauth/helpers"
 	"github.com/axiomzen/zenauth/routes"
 )
 
@@ -40,6 +46,16 @@ func (c *ZENAUTHConfig) GetURL(path string) string {
 func (c *ZENAUTHConfig) ComputeDependents() error {
 
 	c.PasswordResetLinkBase = c.GetURL(routes.V1 + routes.ResourceUsers + routes.ResourceResetPassword + "?token=")
+	if c.MagicLinkURL == "" {
+		c.MagicLinkURL = c.GetURL(routes.V1 + routes.ResourceUsers + routes.ResourceMagicLink + routes.ResourceConsume)
+	}
+	if c.UserExportURL == "" {
+		c.UserExportURL = c.GetURL(routes.V1 + routes.ResourceUsers + routes.ResourceExport + routes.ResourceDownload)
+	}
+	if c.OAuthIssuer == "" {
+		c.OAuthIssuer = c.GetURL("")
+	}
+	c.OAuthIssuer = strings.TrimSuffix(c.OAuthIssuer, "/")
 	// check secret value if in production
 	if c.Environment == constants.EnvironmentProduction {
 		if reg, err := regexp.Compile(`^([a-zA-Z_]{1}[a-zA-Z0-9_]{31})$`); err != nil {
@@ -51,6 +67,132 @@ func (c *ZENAUTHConfig) ComputeDependents() error {
 	// computed once here so we don't have to keep converting to bytes
 	c.HashSecretBytes = []byte(c.HashSecret)
 
+	// tokens are signed with the hash secret unless a signing key is configured,
+	// in which case the hash secret can still verify tokens issued before the switch
+	secretKey := helpers.NewHMACSigningKey(c.HashSecretBytes)
+	switch {
+	case c.JwtSigningKeyFile != "" && c.JwtKeyringDir != "":
+		return errors.New("only one of JwtSigningKeyFile and JwtKeyringDir can be set")
+	case c.JwtKeyringDir != "":
+		keyring, err := helpers.LoadKeyring(c.JwtKeyringDir, secretKey)
+		if err != nil {
+			return fmt.Errorf("JwtKeyringDir: %s", err.Error())
+		}
+		c.JwtKeyring = keyring
+	case c.JwtSigningKeyFile != "":
+		pemBytes, err := ioutil.ReadFile(c.JwtSigningKeyFile)
+		if err != nil {
+			return err
+		}
+		signingKey, err := helpers.ParseSigningKeyPEM(pemBytes)
+		if err != nil {
+			return fmt.Errorf("JwtSigningKeyFile: %s", err.Error())
+		}
+		c.JwtKeyring = helpers.NewKeyring(signingKey, secretKey)
+	default:
+		c.JwtKeyring = helpers.NewKeyring(secretKey)
+	}
+
+	// TOTP secrets need a 32 byte AES key
+	mfaKey := sha256.Sum256([]byte(c.MFAEncryptionKey))
+	if c.MFAEncryptionKey == "" {
+		mfaKey = sha256.Sum256([]byte("mfa:" + c.HashSecret))
+	}
+	c.MFAEncryptionKeyBytes = mfaKey[:]
+
+	// identity providers by name, Facebook is always there for the facebook routes. They share a
+	// client so a slow provider can't hold requests forever
+	c.SocialHTTPClient = &http.Client{Timeout: c.SocialHTTPTimeout}
+	c.SocialProviders = map[string]helpers.IdentityProvider{
+		constants.SocialProviderFacebook: &helpers.FacebookProvider{ProviderName: constants.SocialProviderFacebook, AppID: c.FacebookAppID, AppSecret: c.FacebookAppSecret, Client: c.SocialHTTPClient},
+	}
+	if c.GoogleClientID != "" {
+		c.SocialProviders[constants.SocialProviderGoogle] = helpers.NewGoogleProvider(constants.SocialProviderGoogle, c.GoogleClientID, c.GoogleClientSecret, c.SocialHTTPClient)
+	}
+	if c.AppleClientID != "" {
+		c.SocialProviders[constants.SocialProviderApple] = helpers.NewAppleProvider(constants.SocialProviderApple, c.AppleClientID, c.AppleClientSecret, c.SocialHTTPClient)
+	}
+	if c.GitHubClientID != "" {
+		c.SocialProviders[constants.SocialProviderGitHub] = &helpers.GitHubProvider{ProviderName: constants.SocialProviderGitHub, ClientID: c.GitHubClientID, ClientSecret: c.GitHubClientSecret, Client: c.SocialHTTPClient}
+	}
+	if c.OIDCIssuer != "" {
+		if c.OIDCClientID == "" {
+			return errors.New("OIDCClientID is required with OIDCIssuer")
+		}
+		if _, ok := c.SocialProviders[c.OIDCProviderName]; ok {
+			return fmt.Errorf("OIDCProviderName %s is already used", c.OIDCProviderName)
+		}
+		c.SocialProviders[c.OIDCProviderName] = &helpers.OIDCProvider{ProviderName: c.OIDCProviderName, Issuer: c.OIDCIssuer, ClientID: c.OIDCClientID, ClientSecret: c.OIDCClientSecret, Client: c.SocialHTTPClient}
+	}
+
+	c.LoginAccountThrottle = helpers.LoginThrottlePolicy{
+		FreeAttempts:     int(c.LoginThrottleFreeAttempts),
+		BaseDelay:        c.LoginThrottleBaseDelay,
+		MaxDelay:         c.LoginThrottleMaxDelay,
+		LockoutThreshold: int(c.LoginLockoutThreshold),
+		LockoutDuration:  c.LoginLockoutDuration,
+	}
+	// clients behind the same NAT share an ip, so it gets more attempts
+	c.LoginIPThrottle = c.LoginAccountThrottle
+	c.LoginIPThrottle.FreeAttempts = int(c.LoginIPThrottleFreeAttempts)
+	c.LoginIPThrottle.LockoutThreshold = int(c.LoginIPLockoutThreshold)
+
+	if err := helpers.ValidatePasswordClasses(c.PasswordRequiredClasses); err != nil {
+		return err
+	}
+	c.PasswordPolicy = helpers.PasswordPolicy{
+		MinLength:          int(c.MinPasswordLength),
+		MaxLength:          int(c.MaxPasswordLength),
+		RequiredClasses:    c.PasswordRequiredClasses,
+		RejectPersonalInfo: c.PasswordRejectPersonalInfo,
+		HistorySize:        int(c.PasswordHistorySize),
+	}
+	if c.BreachedPasswordsFile != "" {
+		breached, err := helpers.LoadBreachedPasswordFile(c.BreachedPasswordsFile)
+		if err != nil {
+			return err
+		}
+		c.PasswordPolicy.Breached = breached
+	}
+
+	if c.PasswordHashScheme != helpers.PasswordSchemeBcrypt && c.PasswordHashScheme != helpers.PasswordSchemeArgon2id {
+		return fmt.Errorf("PasswordHashScheme must be %s or %s", helpers.PasswordSchemeBcrypt, helpers.PasswordSchemeArgon2id)
+	}
+	if c.PasswordHashScheme == helpers.PasswordSchemeArgon2id && (c.Argon2Time == 0 || c.Argon2Memory < 8*uint32(c.Argon2Threads) || c.Argon2Threads == 0) {
+		return errors.New("Argon2Time and Argon2Threads must be positive, and Argon2Memory at least 8 KiB per thread")
+	}
+	c.PasswordHasher = helpers.PasswordHasher{
+		Scheme:           c.PasswordHashScheme,
+		BcryptCost:       int(c.BcryptCost),
+		Argon2:           helpers.Argon2Params{Time: c.Argon2Time, Memory: c.Argon2Memory, Threads: c.Argon2Threads},
+		PBKDF2Iterations: c.PBKDF2Iterations,
+		AllowDowngrades:  c.AllowHashDowngrades,
+	}
+
+	if c.RateLimitBackend != constants.RateLimitBackendMemory && c.RateLimitBackend != constants.RateLimitBackendPostgres {
+		return fmt.Errorf("RateLimitBackend must be %s or %s", constants.RateLimitBackendMemory, constants.RateLimitBackendPostgres)
+	}
+	var err error
+	if c.RouteRateLimits, err = helpers.ParseRateLimits(c.RateLimits); err != nil {
+		return err
+	}
+	if c.GRPCMethodRateLimits, err = helpers.ParseRateLimits(c.GRPCRateLimits); err != nil {
+		return err
+	}
+
+	if (c.GRPCTLSCertFile == "") != (c.GRPCTLSKeyFile == "") {
+		return errors.New("GRPCTLSCertFile and GRPCTLSKeyFile must be set together")
+	}
+	if c.GRPCTLSClientCAFile != "" && c.GRPCTLSCertFile == "" {
+		return errors.New("GRPCTLSClientCAFile needs GRPCTLSCertFile and GRPCTLSKeyFile")
+	}
+	if c.GRPCMethodClientIdentities, err = helpers.ParseMethodClients(c.GRPCMethodClients); err != nil {
+		return err
+	}
+	if len(c.GRPCMethodClientIdentities) > 0 && c.GRPCTLSClientCAFile == "" {
+		return errors.New("GRPCMethodClients needs GRPCTLSClientCAFile")
+	}
+
 	// if you specified things, check that they are not the defaults
 	if c.AnalyticsEnabled && c.MixpanelAPIToken == "token" {
 		return errors.New("if Mixpanel is enabled you need a proper token")
@@ -16,6 +16,8 @@ const (
 	StatusOK HTTPStatusCode = http.StatusOK
 	// StatusCreated for POSTs
 	StatusCreated = http.StatusCreated
+	// StatusAccepted for requests processed in the background
+	StatusAccepted = http.StatusAccepted
 	// StatusNotFound not found
 	StatusNotFound = http.StatusNotFound
 	// StatusNoContent for PUTs
@@ -30,6 +32,8 @@ const (
 	StatusExpiredToken = 440
 	// StatusTokenNotAvailableYet for tokens that are not valid yet
 	StatusTokenNotAvailableYet = 441
+	// StatusTooManyRequests for throttled requests
+	StatusTooManyRequests = http.StatusTooManyRequests
 	// StatusServiceUnavailable not sure?
 	StatusServiceUnavailable = http.StatusServiceUnavailable
 	// StatusMovedPermanently for permantent redirects (http->https for example)
@@ -105,10 +109,18 @@ const (
 	APIParsingUUIDUser
 	// APIParsingPasswordHash has didn't work
 	APIParsingPasswordHash
+	// APIParsingUserImport the user import file could not be read
+	APIParsingUserImport
+	// APIParsingRole the role or permission names are invalid
+	APIParsingRole
+	// APIParsingTenant the tenant name is invalid
+	APIParsingTenant
 )
 const (
 	// APIGeneric generic errors
 	APIGeneric APIErrorCode = 3000 + iota
+	// APIRateLimited too many requests for the rate limit of the route or method
+	APIRateLimited
 )
 const (
 	// APIValidation request validation errors
@@ -118,6 +130,8 @@ const (
 	// APIValidationEmailNotValid email not valid
 	APIValidationEmailNotValid
 	APIValidationUserNameNotValid
+	// APIValidationPasswordPolicy password breaks the password policy, see the details
+	APIValidationPasswordPolicy
 )
 const (
 	// APINetworkError for network errors
@@ -144,6 +158,32 @@ const (
 	APIIncorrectAccountType
 	// APIEmailNotFound Email does not exist in our db
 	APIEmailNotFound
+	// APIInvalidMFACode the two factor code is wrong or was already used
+	APIInvalidMFACode
+	// APIMFAAlreadyEnabled two factor authentication is already enabled
+	APIMFAAlreadyEnabled
+	// APIMFANotEnrolled two factor authentication was not set up
+	APIMFANotEnrolled
+	// APIWebAuthnInvalid the webauthn credential or assertion could not be verified
+	APIWebAuthnInvalid
+	// APIWebAuthnCredentialExists the webauthn credential is already registered
+	APIWebAuthnCredentialExists
+	// APISocialLoginNotValid the identity provider credentials could not be verified
+	APISocialLoginNotValid
+	// APISocialProviderNotFound the identity provider is not configured
+	APISocialProviderNotFound
+	// APILoginThrottled too many failed logins, retry after the Retry-After header
+	APILoginThrottled
+	// APIAdminUnauthorized admin token missing or incorrect
+	APIAdminUnauthorized
+	// APIConfirmationRequired the password or a two factor code is needed to confirm the request
+	APIConfirmationRequired
+	// APIAccountDisabled the account was disabled by an admin
+	APIAccountDisabled
+	// APIUserNameInUse the username is already taken
+	APIUserNameInUse
+	// APIClientUnauthorized the client certificate isn't allowed to call the method
+	APIClientUnauthorized
 )
 
 const (
@@ -153,6 +193,26 @@ const (
 	APIExpiredAuthToken
 	// APIInvalidAuthToken for invalid tokens
 	APIInvalidAuthToken
+	// APIInvalidRefreshToken for unknown or revoked refresh tokens
+	APIInvalidRefreshToken
+	// APIExpiredRefreshToken for expired refresh tokens
+	APIExpiredRefreshToken
+	// APIRefreshTokenReused for refresh tokens that were already exchanged
+	APIRefreshTokenReused
+	// APIRevokedAuthToken for tokens revoked by logging out
+	APIRevokedAuthToken
+	// APIInvalidMFAToken for invalid mfa pending tokens
+	APIInvalidMFAToken
+	// APIInvalidWebAuthnChallenge for invalid or already used webauthn challenge tokens
+	APIInvalidWebAuthnChallenge
+	// APIInvalidMagicLinkToken for invalid, expired or already used magic link tokens
+	APIInvalidMagicLinkToken
+	// APIInvalidUserExportToken for invalid or expired export download links
+	APIInvalidUserExportToken
+	// APIInvalidResetToken for invalid, expired or already used password reset tokens
+	APIInvalidResetToken
+	// APIInvalidVerifyEmailToken for invalid or expired email verification tokens
+	APIInvalidVerifyEmailToken
 )
 
 const (
@@ -160,6 +220,8 @@ const (
 	APIForgotPasswordMessageError APIErrorCode = 8000 + iota
 	// APIVerifyEmailMessageError Error generating email verification emails
 	APIVerifyEmailMessageError
+	// APIMagicLinkMessageError Error generating magic link emails
+	APIMagicLinkMessageError
 )
 
 const (
@@ -184,6 +246,78 @@ const (
 
 	InvitationTypeEmail    = "email"
 	InvitationTypeFacebook = "facebook"
+
+	// DefaultTenantID is the tenant of ZENAUTH_APITOKEN, which has the users from before tenants
+	DefaultTenantID = "00000000-0000-0000-0000-000000000000"
+)
+
+// social identity providers
+const (
+	SocialProviderFacebook = "facebook"
+	SocialProviderGoogle   = "google"
+	SocialProviderApple    = "apple"
+	SocialProviderGitHub   = "github"
+)
+
+// where rate limit buckets are kept
+const (
+	RateLimitBackendMemory   = "memory"
+	RateLimitBackendPostgres = "postgres"
+)
+
+// formats of user import files
+const (
+	UserImportFormatNDJSON = "ndjson"
+	UserImportFormatCSV    = "csv"
+)
+
+// formats of user data export downloads
+const (
+	UserExportFormatJSON = "json"
+	UserExportFormatZip  = "zip"
+)
+
+// OAuth 2.0 error codes, grant types and scopes of the OpenID Connect provider
+const (
+	OAuthErrorInvalidRequest          = "invalid_request"
+	OAuthErrorInvalidClient           = "invalid_client"
+	OAuthErrorInvalidGrant            = "invalid_grant"
+	OAuthErrorUnsupportedGrantType    = "unsupported_grant_type"
+	OAuthErrorUnsupportedResponseType = "unsupported_response_type"
+	OAuthErrorInvalidScope            = "invalid_scope"
+	OAuthErrorAccessDenied            = "access_denied"
+	OAuthErrorInvalidToken            = "invalid_token"
+	OAuthErrorServerError             = "server_error"
+
+	OAuthResponseTypeCode           = "code"
+	OAuthGrantTypeAuthorizationCode = "authorization_code"
+	OAuthScopeOpenID                = "openid"
+)
+
+// audit log events
+const (
+	// AuditEventRecoveryCodeUsed a recovery code was used instead of a TOTP code
+	AuditEventRecoveryCodeUsed = "recovery_code_used"
+	// AuditEventRecoveryCodesRegenerated the user replaced their recovery codes
+	AuditEventRecoveryCodesRegenerated = "recovery_codes_regenerated"
+	// AuditEventAccountLocked too many failed logins locked the account
+	AuditEventAccountLocked = "account_locked"
+	// AuditEventAccountUnlocked an admin unlocked the account
+	AuditEventAccountUnlocked = "account_unlocked"
+	// AuditEventAccountDeleted the user deleted their account, it is purged after the grace period
+	AuditEventAccountDeleted = "account_deleted"
+	// AuditEventAccountRestored the user cancelled the deletion of their account
+	AuditEventAccountRestored = "account_restored"
+	// AuditEventAccountDisabled an admin disabled the account
+	AuditEventAccountDisabled = "account_disabled"
+	// AuditEventAccountEnabled an admin enabled the account again
+	AuditEventAccountEnabled = "account_enabled"
+	// AuditEventEmailVerifiedByAdmin an admin marked the email as verified
+	AuditEventEmailVerifiedByAdmin = "email_verified_by_admin"
+	// AuditEventPasswordResetByAdmin an admin set the password or sent a reset link
+	AuditEventPasswordResetByAdmin = "password_reset_by_admin"
+	// AuditEventTokensRevokedByAdmin an admin logged the user out everywhere
+	AuditEventTokensRevokedByAdmin = "tokens_revoked_by_admin"
 )
 
 var (
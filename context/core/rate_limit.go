@@ -0,0 +1,77 @@
+package core
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/axiomzen/zenauth/constants"
+	"github.com/axiomzen/zenauth/helpers"
+	"github.com/axiomzen/zenauth/models"
+	"github.com/gocraft/web"
+)
+
+type (
+	// requestContexter is any context that embeds the RequestContext
+	requestContexter interface {
+		requestContext() *RequestContext
+	}
+
+	// RateLimitUser is a context that knows the user of the request, for limits keyed by user
+	RateLimitUser interface {
+		RateLimitUserID() string
+	}
+)
+
+// requestContext returns the RequestContext, it is promoted to every context embedding it
+func (c *RequestContext) requestContext() *RequestContext {
+	return c
+}
+
+// RateLimit returns a middleware taking a token from the bucket of group for every request.
+// It can be added to a router of any context embedding the RequestContext
+func RateLimit(limiter helpers.RateLimiter, group string, limit helpers.RateLimit) func(interface{}, web.ResponseWriter, *web.Request, web.NextMiddlewareFunc) {
+	return func(ctx interface{}, w web.ResponseWriter, r *web.Request, next web.NextMiddlewareFunc) {
+		c := ctx.(requestContexter).requestContext()
+		result, err := limiter.Take(group+":"+rateLimitKey(ctx, c, limit.Key, r), limit)
+		if err != nil {
+			// better to let requests through than to be down with the limiter
+			c.Log.WithError(err).WithField("group", group).Error("Could not take rate limit token")
+			next(w, r)
+			return
+		}
+		setRateLimitHeaders(w, limit, result)
+		if !result.Allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(result.RetryAfter.Seconds()))))
+			model := models.NewErrorResponse(constants.APIRateLimited, models.NewAZError("rate limit exceeded"), "Too many requests, try again later")
+			c.Render(constants.StatusTooManyRequests, model, w, r)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// rateLimitKey returns what the request is counted by. Requests without a user are counted by ip
+func rateLimitKey(ctx interface{}, c *RequestContext, key string, r *web.Request) string {
+	switch key {
+	case helpers.RateLimitKeyAPIToken:
+		return key + ":" + helpers.HashOpaqueToken(r.Header.Get(c.Config.APITokenHeader))
+	case helpers.RateLimitKeyUser:
+		if user, ok := ctx.(RateLimitUser); ok && user.RateLimitUserID() != "" {
+			return key + ":" + user.RateLimitUserID()
+		}
+	}
+	forwarded := ""
+	if c.Config.ClientIPHeader != "" {
+		forwarded = r.Header.Get(c.Config.ClientIPHeader)
+	}
+	return helpers.RateLimitKeyIP + ":" + helpers.ClientIP(r.RemoteAddr, forwarded)
+}
+
+// setRateLimitHeaders sets the RateLimit-* headers of the IETF draft
+func setRateLimitHeaders(w web.ResponseWriter, limit helpers.RateLimit, result helpers.RateLimitResult) {
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	w.Header().Set("RateLimit-Reset", strconv.Itoa(int(math.Ceil(result.Reset.Seconds()))))
+	w.Header().Set("RateLimit-Policy", strconv.Itoa(limit.Burst)+";w="+strconv.Itoa(int(limit.Period/time.Second)))
+}
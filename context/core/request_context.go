@@ -293,6 +293,24 @@ func (c *RequestContext) PingResponse(rw web.ResponseWriter, req *web.Request) {
 	c.Render(constants.StatusOK, &ping, rw, req)
 }
 
+// JWKSResponse publishes the public keys tokens can be verified with.
+// Shared secrets are never published
+//
+// Type: GET
+// Route: /.well-known/jwks.json
+//
+// Output:
+//
+//     HTTP 200
+//       {
+//         "keys": [{"kty": "EC", "kid": "...", ...}]
+//       }
+func (c *RequestContext) JWKSResponse(rw web.ResponseWriter, req *web.Request) {
+	jwks := c.Config.JwtKeyring.JWKS()
+	rw.Header().Set("Cache-Control", "public, max-age=300")
+	c.Render(constants.StatusOK, &jwks, rw, req)
+}
+
 // Render renders the interface to the response
 func (c *RequestContext) Render(statusCode constants.HTTPStatusCode, v interface{}, w web.ResponseWriter, r *web.Request) {
 	// all headers need to be set now before the call to WriteHeader
@@ -393,3 +411,15 @@ func (c *RequestContext) NotFound(rw web.ResponseWriter, req *web.Request) {
 	model := models.NewErrorResponse(constants.APINotFound, nil, "not found")
 	c.Render(constants.StatusNotFound, model, rw, req)
 }
+
+// RenderDALError renders the API error of a DAL error, see data.ToAPIError. code and msg
+// are used for errors the client can't act on
+func (c *RequestContext) RenderDALError(err error, code constants.APIErrorCode, msg string, rw web.ResponseWriter, req *web.Request) {
+	apiErr := data.ToAPIError(err, code, msg)
+	if apiErr.Code == constants.APINotFound {
+		c.NotFound(rw, req)
+		return
+	}
+	model := models.NewErrorResponse(apiErr.Code, models.NewAZError(err.Error()), apiErr.Message)
+	c.Render(apiErr.Status, model, rw, req)
+}
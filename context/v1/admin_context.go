@@ -0,0 +1,443 @@
+package v1
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"mime"
+	"strconv"
+	"time"
+
+	"github.com/axiomzen/null"
+	"github.com/axiomzen/zenauth/constants"
+	"github.com/axiomzen/zenauth/data"
+	"github.com/axiomzen/zenauth/helpers"
+	"github.com/axiomzen/zenauth/models"
+	"github.com/gocraft/web"
+)
+
+// AdminContext for the back office routes, guarded by the admin token.
+// UserID is never set, the user acted on is the one of the path
+type AdminContext struct {
+	*UserContext
+}
+
+// AdminAuthRequired checks the admin token, the routes are disabled when none is configured
+func (c *AdminContext) AdminAuthRequired(w web.ResponseWriter, r *web.Request, next web.NextMiddlewareFunc) {
+	adminToken := r.Header.Get(c.Config.AdminTokenHeader)
+	if c.Config.AdminToken == "" || subtle.ConstantTimeCompare([]byte(adminToken), []byte(c.Config.AdminToken)) != 1 {
+		var model = models.NewErrorResponse(constants.APIAdminUnauthorized, models.NewAZError("not authorized"), "Not Authorized")
+		c.render(constants.StatusUnauthorized, model, w, r)
+		return
+	}
+	next(w, r)
+}
+
+// render renders v in the default content type when the request body is an import file,
+// as the response can't be encoded in the content type of the request
+func (c *AdminContext) render(status constants.HTTPStatusCode, v interface{}, rw web.ResponseWriter, req *web.Request) {
+	if importFormat(req) != "" {
+		req.Header.Set("Content-Type", c.Config.DefaultContentType)
+	}
+	c.Render(status, v, rw, req)
+}
+
+// importFormat returns the format of an import request, from the format query parameter or the content type
+func importFormat(req *web.Request) string {
+	if format := req.URL.Query().Get("format"); format != "" {
+		return format
+	}
+	mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	switch mediaType {
+	case "text/csv":
+		return constants.UserImportFormatCSV
+	case "application/x-ndjson", "application/jsonl":
+		return constants.UserImportFormatNDJSON
+	}
+	return ""
+}
+
+// ImportUsers creates the users of an NDJSON or CSV body, with the password hashes of the system
+// they come from. They are rehashed when they first log in
+//
+//   POST /admins/users/import
+//
+// Returns
+//   200 OK with the rows that could not be imported
+func (c *AdminContext) ImportUsers(rw web.ResponseWriter, req *web.Request) {
+	reader, err := models.NewUserImportReader(req.Body, importFormat(req))
+	if err != nil {
+		model := models.NewErrorResponse(constants.APIParsingUserImport, models.NewAZError(err.Error()), "Could not read users")
+		c.render(constants.StatusBadRequest, model, rw, req)
+		return
+	}
+	report, err := data.ImportUserFile(c.DAL, reader, int(c.Config.UserImportBatchSize))
+	if err != nil {
+		// the batches before the error were committed, the report says which
+		c.Log.WithError(err).WithField("imported", report.Imported).Error("User import stopped")
+		model := models.NewErrorResponse(constants.APIDatabaseCreateUser, models.NewAZError(err.Error()), "Could not import users")
+		c.render(constants.StatusInternalServerError, model, rw, req)
+		return
+	}
+	c.render(constants.StatusOK, &report, rw, req)
+}
+
+// ExportUser starts an export of the user's data, the download link is emailed to the user
+//
+//   POST /admins/users/:id/export
+//
+// Returns
+//   202 Accepted
+func (c *AdminContext) ExportUser(rw web.ResponseWriter, req *web.Request) {
+	var user models.User
+	if !c.pathUser(&user, rw, req) {
+		return
+	}
+	if !c.startUserExport(&user, rw, req) {
+		return
+	}
+	c.Render(constants.StatusAccepted, nil, rw, req)
+}
+
+// pathUser gets the user of the id path parameter, rendering not found if there is none
+func (c *AdminContext) pathUser(user *models.User, rw web.ResponseWriter, req *web.Request) bool {
+	user.ID = req.PathParams["id"]
+	if err := c.DAL.GetUserByID(user); err != nil {
+		dalErr, _ := err.(data.DALError)
+		if dalErr.ErrorCode == data.DALErrorCodeNoneAffected {
+			c.NotFound(rw, req)
+			return false
+		}
+		model := models.NewErrorResponse(constants.APIDatabaseGetUser, models.NewAZError(err.Error()), "Could not get user")
+		c.Render(constants.StatusInternalServerError, model, rw, req)
+		return false
+	}
+	return true
+}
+
+// parseUserSearch reads the filters of the user listing from the query string
+func (c *AdminContext) parseUserSearch(req *web.Request) (*models.UserSearch, error) {
+	query := req.URL.Query()
+	search := models.UserSearch{
+		EmailPrefix: helpers.EmailSanitize(query.Get("email")),
+		UserName:    query.Get("userName"),
+		FacebookID:  query.Get("facebookId"),
+		Limit:       int(c.Config.AdminUserPageSize),
+	}
+	for param, t := range map[string]*null.Time{"createdAfter": &search.CreatedAfter, "createdBefore": &search.CreatedBefore} {
+		if v := query.Get(param); v != "" {
+			parsed, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return nil, fmt.Errorf("%s is not an RFC 3339 time", param)
+			}
+			*t = null.TimeFrom(parsed)
+		}
+	}
+	for param, b := range map[string]*null.Bool{"verified": &search.Verified, "disabled": &search.Disabled} {
+		if v := query.Get(param); v != "" {
+			parsed, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, fmt.Errorf("%s is not a boolean", param)
+			}
+			*b = null.BoolFrom(parsed)
+		}
+	}
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			return nil, fmt.Errorf("limit is not a positive integer")
+		}
+		if limit < search.Limit {
+			search.Limit = limit
+		}
+	}
+	if v := query.Get("cursor"); v != "" {
+		cursor, err := models.DecodeUserCursor(v)
+		if err != nil {
+			return nil, err
+		}
+		search.Cursor = cursor
+	}
+	return &search, nil
+}
+
+// ListUsers lists the users in creation order, a page at a time. Follow nextCursor for the next page.
+// Filters are combined, deleted users are left out
+//
+//   GET /admins/users?email=:prefix:&userName=:userName:&facebookId=:id:&createdAfter=:time:&createdBefore=:time:&verified=:bool:&disabled=:bool:&limit=:n:&cursor=:cursor:
+//
+// Returns
+//   200 OK
+func (c *AdminContext) ListUsers(rw web.ResponseWriter, req *web.Request) {
+	search, err := c.parseUserSearch(req)
+	if err != nil {
+		model := models.NewErrorResponse(constants.APIParsingQueryParams, models.NewAZError(err.Error()), "Invalid query parameters")
+		c.Render(constants.StatusBadRequest, model, rw, req)
+		return
+	}
+	// one more than the page tells whether there is a next one
+	limit := search.Limit
+	search.Limit++
+	var users models.Users
+	if err := c.DAL.SearchUsers(search, &users); err != nil {
+		model := models.NewErrorResponse(constants.APIDatabaseGetUser, models.NewAZError(err.Error()), "Could not list users")
+		c.Render(constants.StatusInternalServerError, model, rw, req)
+		return
+	}
+	list := models.AdminUserList{Users: make([]*models.AdminUser, 0, limit)}
+	if len(users) > limit {
+		users = users[:limit]
+		list.NextCursor = models.NewUserCursor(users[limit-1]).Encode()
+	}
+	for _, user := range users {
+		list.Users = append(list.Users, models.NewAdminUser(user))
+	}
+	c.Render(constants.StatusOK, &list, rw, req)
+}
+
+// GetUser gets a user, with their account state
+//
+//   GET /admins/users/:id
+//
+// Returns
+//   200 OK
+func (c *AdminContext) GetUser(rw web.ResponseWriter, req *web.Request) {
+	var user models.User
+	if !c.pathUser(&user, rw, req) {
+		return
+	}
+	c.Render(constants.StatusOK, models.NewAdminUser(&user), rw, req)
+}
+
+// VerifyUserEmail marks the user's email as verified, without the verification email
+//
+//   POST /admins/users/:id/verify_email
+//
+// Returns
+//   200 OK
+func (c *AdminContext) VerifyUserEmail(rw web.ResponseWriter, req *web.Request) {
+	var user models.User
+	if !c.pathUser(&user, rw, req) {
+		return
+	}
+	if user.Email == "" {
+		model := models.NewErrorResponse(constants.APIEmailNotFound, models.NewAZError("user has no email"), "User has no email")
+		c.Render(constants.StatusBadRequest, model, rw, req)
+		return
+	}
+	user.Verified = true
+	if err := c.DAL.UpdateUserVerified(&user); err != nil {
+		model := models.NewErrorResponse(constants.APIDatabaseUpdateUser, models.NewAZError(err.Error()), "Could not verify email")
+		c.Render(constants.StatusInternalServerError, model, rw, req)
+		return
+	}
+	c.account(req).Audit(user.ID, constants.AuditEventEmailVerifiedByAdmin)
+	c.Render(constants.StatusOK, models.NewAdminUser(&user), rw, req)
+}
+
+// ResetUserPassword sets the user's password, which has to meet the password policy, and logs them
+// out everywhere. Without a password, the user is emailed a reset link as in ForgotPassword
+//
+//   POST /admins/users/:id/reset_password
+//
+// Assumes format:
+//   {
+//     "password":"..."
+//   }
+//
+// Returns
+//   204 No Content
+func (c *AdminContext) ResetUserPassword(rw web.ResponseWriter, req *web.Request) {
+	var reset models.AdminPasswordReset
+	if req.ContentLength != 0 && !c.DecodeHelper(&reset, "Couldn't decode password reset", rw, req) {
+		return
+	}
+	var user models.User
+	if !c.pathUser(&user, rw, req) {
+		return
+	}
+
+	if reset.Password == "" {
+		if user.Email == "" {
+			model := models.NewErrorResponse(constants.APIEmailNotFound, models.NewAZError("user has no email"), "User has no email to send a reset link to")
+			c.Render(constants.StatusBadRequest, model, rw, req)
+			return
+		}
+		if !c.sendResetPasswordEmail(user.Email, rw, req) {
+			return
+		}
+		c.account(req).Audit(user.ID, constants.AuditEventPasswordResetByAdmin)
+		c.Render(constants.StatusNoContent, nil, rw, req)
+		return
+	}
+
+	if !c.checkPasswordPolicy(reset.Password, &user, "Could not reset password", rw, req) {
+		return
+	}
+	newHash, err := c.Config.PasswordHasher.Hash(reset.Password)
+	if err != nil {
+		model := models.NewErrorResponse(constants.APIParsingPasswordHash, models.NewAZError(err.Error()), "Could not generate user hash")
+		c.Render(constants.StatusInternalServerError, model, rw, req)
+		return
+	}
+	if err := c.DAL.SetUserHash(newHash, &user); err != nil {
+		model := models.NewErrorResponse(constants.APIDatabaseUpdateUser, models.NewAZError(err.Error()), "Could not update user")
+		c.Render(constants.StatusInternalServerError, model, rw, req)
+		return
+	}
+	c.account(req).RecordPasswordHistory(user.ID, newHash)
+	c.account(req).Audit(user.ID, constants.AuditEventPasswordResetByAdmin)
+	if err := c.DAL.RevokeAllUserTokens(user.ID); err != nil {
+		c.Log.WithError(err).Error("Could not revoke the tokens of the user after a password reset")
+	}
+	c.Render(constants.StatusNoContent, nil, rw, req)
+}
+
+// DisableUser disables the account and logs the user out everywhere. It can't log in
+// or refresh tokens until it is enabled again
+//
+//   POST /admins/users/:id/disable
+//
+// Returns
+//   200 OK
+func (c *AdminContext) DisableUser(rw web.ResponseWriter, req *web.Request) {
+	c.setUserDisabled(true, rw, req)
+}
+
+// EnableUser enables an account that was disabled
+//
+//   POST /admins/users/:id/enable
+//
+// Returns
+//   200 OK
+func (c *AdminContext) EnableUser(rw web.ResponseWriter, req *web.Request) {
+	c.setUserDisabled(false, rw, req)
+}
+
+func (c *AdminContext) setUserDisabled(disabled bool, rw web.ResponseWriter, req *web.Request) {
+	var user models.User
+	user.ID = req.PathParams["id"]
+	if err := c.DAL.SetUserDisabled(&user, disabled); err != nil {
+		dalErr, _ := err.(data.DALError)
+		if dalErr.ErrorCode == data.DALErrorCodeNoneAffected {
+			c.NotFound(rw, req)
+			return
+		}
+		model := models.NewErrorResponse(constants.APIDatabaseUpdateUser, models.NewAZError(err.Error()), "Could not update user")
+		c.Render(constants.StatusInternalServerError, model, rw, req)
+		return
+	}
+	if disabled {
+		c.account(req).Audit(user.ID, constants.AuditEventAccountDisabled)
+		if err := c.DAL.RevokeAllUserTokens(user.ID); err != nil {
+			model := models.NewErrorResponse(constants.APIDatabaseCreate, models.NewAZError(err.Error()), "Could not revoke auth tokens")
+			c.Render(constants.StatusInternalServerError, model, rw, req)
+			return
+		}
+	} else {
+		c.account(req).Audit(user.ID, constants.AuditEventAccountEnabled)
+	}
+	c.Render(constants.StatusOK, models.NewAdminUser(&user), rw, req)
+}
+
+// RestoreUser cancels the deletion of an account during the grace period, for users who can't
+// restore it themselves, such as users without a password
+//
+//   POST /admins/users/:id/restore
+//
+// Returns
+//   200 OK
+func (c *AdminContext) RestoreUser(rw web.ResponseWriter, req *web.Request) {
+	var user models.User
+	user.ID = req.PathParams["id"]
+	if err := c.account(req).RestoreUser(&user); err != nil {
+		c.renderAccountError(err, "Could not restore account", rw, req)
+		return
+	}
+	c.Render(constants.StatusOK, models.NewAdminUser(&user), rw, req)
+}
+
+// RevokeUserTokens revokes every auth and refresh token of the user, as when they log out everywhere
+//
+//   POST /admins/users/:id/revoke_tokens
+//
+// Returns
+//   204 No Content
+func (c *AdminContext) RevokeUserTokens(rw web.ResponseWriter, req *web.Request) {
+	var user models.User
+	if !c.pathUser(&user, rw, req) {
+		return
+	}
+	if err := c.DAL.RevokeAllUserTokens(user.ID); err != nil {
+		model := models.NewErrorResponse(constants.APIDatabaseCreate, models.NewAZError(err.Error()), "Could not revoke auth tokens")
+		c.Render(constants.StatusInternalServerError, model, rw, req)
+		return
+	}
+	c.account(req).Audit(user.ID, constants.AuditEventTokensRevokedByAdmin)
+	c.Render(constants.StatusNoContent, nil, rw, req)
+}
+
+// UnlockUser forgets the failed logins of the user, lifting any lockout
+//
+//   POST /admins/users/:id/unlock
+//
+// Returns
+//   204 No Content
+func (c *AdminContext) UnlockUser(rw web.ResponseWriter, req *web.Request) {
+	var user models.User
+	if !c.pathUser(&user, rw, req) {
+		return
+	}
+	if err := c.DAL.ClearLoginThrottle(models.LoginThrottleAccountKey(user.ID)); err != nil {
+		model := models.NewErrorResponse(constants.APIDatabaseDelete, models.NewAZError(err.Error()), "Could not clear failed logins")
+		c.Render(constants.StatusInternalServerError, model, rw, req)
+		return
+	}
+	c.account(req).Audit(user.ID, constants.AuditEventAccountUnlocked)
+	c.Render(constants.StatusNoContent, nil, rw, req)
+}
+
+// DeleteUser deletes the account as if the user did, so it can be restored during the grace period.
+// With purge=true it is hard deleted right away instead
+//
+//   DELETE /admins/users/:id?purge=:bool:
+//
+// Returns
+//   200 OK, or 204 No Content when purged
+func (c *AdminContext) DeleteUser(rw web.ResponseWriter, req *web.Request) {
+	purge, _ := strconv.ParseBool(req.URL.Query().Get("purge"))
+	var user models.User
+	user.ID = req.PathParams["id"]
+
+	if purge {
+		if err := c.DAL.PurgeUser(&user); err != nil {
+			dalErr, _ := err.(data.DALError)
+			if dalErr.ErrorCode == data.DALErrorCodeNoneAffected {
+				c.NotFound(rw, req)
+				return
+			}
+			model := models.NewErrorResponse(constants.APIDatabaseDeleteUser, models.NewAZError(err.Error()), "Could not delete user")
+			c.Render(constants.StatusInternalServerError, model, rw, req)
+			return
+		}
+		c.Log.WithField("deletedUserID", user.ID).Info("User purged by admin")
+		c.Render(constants.StatusNoContent, nil, rw, req)
+		return
+	}
+
+	if err := c.DAL.SoftDeleteUser(&user); err != nil {
+		dalErr, _ := err.(data.DALError)
+		if dalErr.ErrorCode == data.DALErrorCodeNoneAffected {
+			c.NotFound(rw, req)
+			return
+		}
+		model := models.NewErrorResponse(constants.APIDatabaseDeleteUser, models.NewAZError(err.Error()), "Could not delete user")
+		c.Render(constants.StatusInternalServerError, model, rw, req)
+		return
+	}
+	c.account(req).Audit(user.ID, constants.AuditEventAccountDeleted)
+	if err := c.DAL.RevokeAllUserTokens(user.ID); err != nil {
+		c.Log.WithError(err).Error("Could not revoke the tokens of the deleted user")
+	}
+	status := models.UserDeletionStatus{DeletedAt: user.DeletedAt.Time, PurgeAt: user.DeletedAt.Time.Add(c.Config.UserDeletionGracePeriod)}
+	c.Render(constants.StatusOK, &status, rw, req)
+}
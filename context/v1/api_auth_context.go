@@ -1,8 +1,11 @@
 package v1
 
 import (
+	"github.com/axiomzen/zenauth/account"
 	"github.com/axiomzen/zenauth/constants"
 	"github.com/axiomzen/zenauth/context/core"
+	"github.com/axiomzen/zenauth/data"
+	"github.com/axiomzen/zenauth/helpers"
 	"github.com/axiomzen/zenauth/models"
 	"github.com/gocraft/web"
 )
@@ -21,10 +24,75 @@ func (c *APIAuthContext) APIAuthRequired(w web.ResponseWriter, r *web.Request, n
 
 	if apiToken == c.Config.APIToken {
 		//optional: store string c.Token = apiToken
+		c.useTenant(constants.DefaultTenantID)
 		next(w, r)
-	} else {
-		var model = models.NewErrorResponse(constants.APIUnauthorized, models.NewAZError("not authorized"), "Not Authorized")
-		c.Render(constants.StatusUnauthorized, model, w, r)
+		return
+	}
+	// the other tenants have their own API token
+	if apiToken != "" {
+		hash := helpers.HashOpaqueToken(apiToken)
+		tenant := models.Tenant{APITokenHash: &hash}
+		err := c.DAL.GetTenantByAPITokenHash(&tenant)
+		if err == nil {
+			c.useTenant(tenant.ID)
+			next(w, r)
+			return
+		}
+		if dalErr, _ := err.(data.DALError); dalErr.ErrorCode != data.DALErrorCodeNoneAffected {
+			model := models.NewErrorResponse(constants.APIDatabaseGet, models.NewAZError(err.Error()), "Could not check API token")
+			c.Render(constants.StatusInternalServerError, model, w, r)
+			return
+		}
+	}
+	var model = models.NewErrorResponse(constants.APIUnauthorized, models.NewAZError("not authorized"), "Not Authorized")
+	c.Render(constants.StatusUnauthorized, model, w, r)
+}
+
+// useTenant scopes the DAL of the request to the tenant
+func (c *APIAuthContext) useTenant(tenantID string) {
+	c.DAL = c.DAL.ForTenant(tenantID)
+	c.Log = c.Log.WithField("tenantID", tenantID)
+}
+
+// tokenTenantID returns the tenant a token was issued for, tokens from before tenants are the default tenant's
+func (c *APIAuthContext) tokenTenantID(result *helpers.JWTokenValidateResult) string {
+	return account.TokenTenantID(c.Config, result)
+}
+
+// clientIP returns the address of the client making the request
+func (c *APIAuthContext) clientIP(req *web.Request) string {
+	forwarded := ""
+	if c.Config.ClientIPHeader != "" {
+		forwarded = req.Header.Get(c.Config.ClientIPHeader)
+	}
+	return helpers.ClientIP(req.RemoteAddr, forwarded)
+}
+
+// account returns the account operations of the request, in its tenant
+func (c *APIAuthContext) account(req *web.Request) *account.Service {
+	return &account.Service{Config: c.Config, DAL: c.DAL, Log: c.Log, ClientIP: c.clientIP(req), UserAgent: req.UserAgent()}
+}
+
+// renderAccountError renders the error of an account operation with msg. Passwords breaking the
+// policy list each rule broken, see newPasswordPolicyError, throttled logins set Retry-After
+func (c *APIAuthContext) renderAccountError(err error, msg string, rw web.ResponseWriter, req *web.Request) {
+	switch accountErr := err.(type) {
+	case *account.PasswordPolicyError:
+		c.Render(constants.StatusBadRequest, newPasswordPolicyError(accountErr, msg), rw, req)
+	case *account.LoginThrottledError:
+		setRetryAfter(rw, accountErr.RetryAfter)
+		model := models.NewErrorResponse(constants.APILoginThrottled, models.NewAZError(accountErr.Error()), "Too many failed logins, try again later")
+		c.Render(constants.StatusTooManyRequests, model, rw, req)
+	case data.APIError:
+		if accountErr.Code == constants.APINotFound {
+			c.NotFound(rw, req)
+			return
+		}
+		model := models.NewErrorResponse(accountErr.Code, models.NewAZError(accountErr.Message), msg)
+		c.Render(accountErr.Status, model, rw, req)
+	default:
+		model := models.NewErrorResponse(constants.APIGeneric, models.NewAZError(err.Error()), msg)
+		c.Render(constants.StatusInternalServerError, model, rw, req)
 	}
 }
 
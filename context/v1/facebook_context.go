@@ -2,8 +2,6 @@ package v1
 
 import (
 	"github.com/axiomzen/zenauth/constants"
-	"github.com/axiomzen/zenauth/data"
-	"github.com/axiomzen/zenauth/helpers"
 	"github.com/axiomzen/zenauth/models"
 	"github.com/gocraft/web"
 	//"fmt"
@@ -14,53 +12,41 @@ type FacebookContext struct {
 	*UserContext
 }
 
-// validateFacebookUser helper function
-func (c *FacebookContext) validateFacebookUser(fbUser *models.FacebookUser, rw web.ResponseWriter, req *web.Request) bool {
+// validateFacebookUser helper function, returns the identity the token belongs to
+func (c *FacebookContext) validateFacebookUser(fbUser *models.FacebookUser, rw web.ResponseWriter, req *web.Request) (*models.UserIdentity, bool) {
 	// TODO Change to check hashed password against db & require username and password fields
-	if fbUser.FacebookID == "" || fbUser.FacebookToken == "" {
-		model := models.NewErrorResponse(constants.APIValidation, models.NewAZError("Missing a field in request"), "Error with request")
-		c.Render(constants.StatusBadRequest, model, rw, req)
-		return false
-	}
-
-	if valid, err := helpers.ValidateFacebookLogin(fbUser.FacebookID, fbUser.FacebookToken, c.Config.FacebookAppID, c.Config.FacebookAppSecret); err != nil {
-		model := models.NewErrorResponse(constants.APIFacebookLoginNotValid, models.NewAZError(err.Error()), "Error with fb login request")
-		c.Render(constants.StatusBadRequest, model, rw, req)
-		return false
-	} else if !valid {
-		model := models.NewErrorResponse(constants.APIFacebookLoginNotValid, models.NewAZError(err.Error()), "Could not validate facebook token")
-		c.Render(constants.StatusBadRequest, model, rw, req)
-		return false
+	identity, err := c.account(req).ValidateFacebookUser(fbUser)
+	if err != nil {
+		c.renderAccountError(err, "Error with fb login request", rw, req)
+		return nil, false
 	}
-	return true
+	return identity, true
 }
 
 // createFacebookUser helper function
-func (c *FacebookContext) createFacebookUser(user *models.User, rw web.ResponseWriter, req *web.Request) bool {
-	if err := c.DAL.CreateUser(user); err != nil {
+func (c *FacebookContext) createFacebookUser(user *models.User, identity *models.UserIdentity, rw web.ResponseWriter, req *web.Request) bool {
+	if err := c.DAL.CreateUserWithIdentity(user, identity); err != nil {
 		// facebook id might not be unique
 		// email might not be unique
-		dalErr, _ := err.(data.DALError)
-
-		switch dalErr.ErrorCode {
-		case data.DALErrorCodeUniqueEmail:
-			model := models.NewErrorResponse(constants.APIEmailInUse, models.NewAZError(err.Error()), "Email already in use/exists")
-			c.Render(constants.StatusForbidden, model, rw, req)
-			return false
-		case data.DALErrorCodeFacebookIDUnique:
-			model := models.NewErrorResponse(constants.APISocialAccountExists, models.NewAZError(err.Error()), "Social account already exists")
-			c.Render(constants.StatusForbidden, model, rw, req)
-			return false
-		default:
-			model := models.NewErrorResponse(constants.APIDatabaseCreateUser, models.NewAZError(err.Error()), "Could not create new User")
-			c.Render(constants.StatusInternalServerError, model, rw, req)
-			return false
-		}
+		c.RenderDALError(err, constants.APIDatabaseCreateUser, "Could not create new User", rw, req)
+		return false
 	}
 	return true
 }
 
-// Login logs a user in (via facebook)
+// loginFacebookUser renders the tokens of the user, or the mfa challenge if they enabled two
+// factor authentication
+func (c *FacebookContext) loginFacebookUser(user *models.User, rw web.ResponseWriter, req *web.Request) {
+	c.UserID = user.ID
+	c.Log = c.Log.WithField("userID", c.UserID)
+	if user.TOTPEnabled {
+		c.renderMFAChallenge(user, rw, req)
+		return
+	}
+	c.renderUserResponseWithNewToken(user, constants.StatusOK, false, rw, req)
+}
+
+// Login logs a user in (via facebook), users with two factor authentication get an mfa challenge
 //   POST /fblogin
 //
 // Returns
@@ -74,21 +60,20 @@ func (c *FacebookContext) Login(rw web.ResponseWriter, req *web.Request) {
 		return
 	}
 
-	if !c.validateFacebookUser(&fbLogin, rw, req) {
+	identity, ok := c.validateFacebookUser(&fbLogin, rw, req)
+	if !ok {
 		return
 	}
 
 	var user models.User
 
-	user.FacebookUser = fbLogin
-
-	if err := c.DAL.GetUserByFacebookID(&user); err != nil {
+	if err := c.DAL.GetUserBySocialIdentity(identity, &user); err != nil {
 		model := models.NewErrorResponse(constants.APILoginUserDoesNotExist, models.NewAZError(err.Error()), "User does not exist")
 		c.Render(constants.StatusForbidden, model, rw, req)
 		return
 	}
 
-	c.renderUserResponseWithNewToken(&user, constants.StatusOK, false, rw, req)
+	c.loginFacebookUser(&user, rw, req)
 }
 
 // Signup signs a user up via facebook (optionally links their account to an existing account)
@@ -107,7 +92,8 @@ func (c *FacebookContext) Signup(rw web.ResponseWriter, req *web.Request) {
 	}
 
 	// validate
-	if !c.validateFacebookUser(&fbSignup.FacebookUser, rw, req) {
+	identity, ok := c.validateFacebookUser(&fbSignup.FacebookUser, rw, req)
+	if !ok {
 		return
 	}
 
@@ -117,7 +103,7 @@ func (c *FacebookContext) Signup(rw web.ResponseWriter, req *web.Request) {
 	// user.Email = helpers.EmailSanitize(fbSignup.FacebookEmail)
 
 	// otherwise, we want to create (should populate user with new id)
-	if !c.createFacebookUser(&user, rw, req) {
+	if !c.createFacebookUser(&user, identity, rw, req) {
 		return
 	}
 
@@ -140,37 +126,18 @@ func (c *FacebookContext) Link(rw web.ResponseWriter, req *web.Request) {
 		return
 	}
 
-	// validate
-	if !c.validateFacebookUser(&fbUpdate.FacebookUser, rw, req) {
-		return
-	}
-
 	// user id will be populated at this point from the token
-	fbUpdate.ID = c.UserID
 	var user models.User
-
-	if err := c.DAL.UpdateUser(&fbUpdate, &user); err != nil {
-		dalErr, _ := err.(data.DALError)
-
-		if dalErr.ErrorCode == data.DALErrorCodeFacebookIDUnique {
-			model := models.NewErrorResponse(constants.APISocialAccountExists, models.NewAZError(err.Error()), "Social account already exists")
-			c.Render(constants.StatusForbidden, model, rw, req)
-			return
-		} else if dalErr.ErrorCode == data.DALErrorCodeNoneAffected {
-			c.NotFound(rw, req)
-			return
-		}
-
-		// user might not be found
-		model := models.NewErrorResponse(constants.APIDatabaseCreateUser, models.NewAZError(err.Error()), "Could not update User")
-		c.Render(constants.StatusInternalServerError, model, rw, req)
+	if err := c.account(req).LinkFacebook(c.UserID, &fbUpdate.FacebookUser, &user); err != nil {
+		c.renderAccountError(err, "Could not link social account", rw, req)
 		return
 	}
 	// create a new token
 	c.renderUserResponseWithNewToken(&user, constants.StatusOK, false, rw, req)
 }
 
-// Facebook will log the user in, and create an account if it doesn't already exist
+// Facebook will log the user in, and create an account if it doesn't already exist. Users with
+// two factor authentication get an mfa challenge
 //
 //   POST /facebook
 //
@@ -186,7 +153,8 @@ func (c *FacebookContext) Facebook(rw web.ResponseWriter, req *web.Request) {
 	}
 
 	// validate
-	if !c.validateFacebookUser(&fbSignup.FacebookUser, rw, req) {
+	identity, ok := c.validateFacebookUser(&fbSignup.FacebookUser, rw, req)
+	if !ok {
 		return
 	}
 
@@ -195,7 +163,11 @@ func (c *FacebookContext) Facebook(rw web.ResponseWriter, req *web.Request) {
 	user.FacebookUser = fbSignup.FacebookUser
 
 	if err := c.DAL.UpdateUserFacebookInfo(&user); err == nil {
-		c.renderUserResponseWithNewToken(&user, constants.StatusOK, false, rw, req)
+		identity.UserID = user.ID
+		if err := c.DAL.SaveUserIdentity(identity); err != nil {
+			c.Log.WithError(err).Warn("Could not update facebook identity")
+		}
+		c.loginFacebookUser(&user, rw, req)
 		return
 	}
 
@@ -203,7 +175,7 @@ func (c *FacebookContext) Facebook(rw web.ResponseWriter, req *web.Request) {
 	// user.Email = helpers.EmailSanitize(fbSignup.FacebookEmail)
 
 	// otherwise, we want to create (should populate user with new id)
-	if !c.createFacebookUser(&user, rw, req) {
+	if !c.createFacebookUser(&user, identity, rw, req) {
 		return
 	}
 
@@ -1,10 +1,7 @@
 package v1
 
 import (
-	"strings"
-
 	"github.com/axiomzen/zenauth/constants"
-	"github.com/axiomzen/zenauth/helpers"
 	"github.com/axiomzen/zenauth/models"
 	"github.com/axiomzen/zenauth/protobuf"
 	"github.com/gocraft/web"
@@ -15,18 +12,23 @@ type InvitationContext struct {
 	*UserContext
 }
 
-func (c *InvitationContext) createInvitationsResponse(invitations models.Invitations, rw web.ResponseWriter, req *web.Request) {
+// createInvitationsResponse creates the invitations of the type and renders them
+func (c *InvitationContext) createInvitationsResponse(invitationType string, rw web.ResponseWriter, req *web.Request) {
+	var invitationRequest models.InvitationRequest
+	// decode request
+	if !c.DecodeHelper(&invitationRequest, "Couldn't decode the request body", rw, req) {
+		return
+	}
 
-	if err := c.DAL.CreateInvitations(&invitations); err != nil {
-		model := models.NewErrorResponse(constants.APIInvitationsCreationError, models.NewAZError(err.Error()), "unable to create the invitations")
-		c.Render(constants.StatusBadRequest, model, rw, req)
+	invitations, err := c.account(req).CreateInvitations(c.UserID, invitationType, invitationRequest.InviteCodes)
+	if err != nil {
+		c.renderAccountError(err, "Could not create invitation", rw, req)
 		return
 	}
 
 	invitationResponse := models.InvitationResponse{
 		Users: make([]*protobuf.UserPublic, len(invitations)),
 	}
-	var err error
 	for idx, invitation := range invitations {
 		invitationResponse.Users[idx], err = invitation.UserPublicProtobuf()
 		if err != nil {
@@ -48,38 +50,7 @@ func (c *InvitationContext) createInvitationsResponse(invitations models.Invitat
 // Returns
 //   201 Status Created
 func (c *InvitationContext) CreateEmailInvitations(rw web.ResponseWriter, req *web.Request) {
-	var invitationRequest models.InvitationRequest
-	// decode request
-	if !c.DecodeHelper(&invitationRequest, "Couldn't decode the request body", rw, req) {
-		return
-	}
-
-	invitations := make(models.Invitations, len(invitationRequest.InviteCodes))
-	var user models.User
-	for idx, email := range invitationRequest.InviteCodes {
-		// Verify invite email is valid
-		if strings.Count(email, "@") == 0 {
-			model := models.NewErrorResponse(constants.APIValidationEmailNotValid,
-				models.NewAZError("Invalid email address"), "Could not create invitation")
-			c.Render(constants.StatusBadRequest, model, rw, req)
-			return
-		}
-		invitations[idx] = &models.Invitation{
-			Type: constants.InvitationTypeEmail,
-			Code: helpers.EmailSanitize(email),
-		}
-		// Verify we don't already have a user with this email
-		user.Email = invitations[idx].Code
-
-		// TODO: Should we error here? Or continue inviting the rest of the list?
-		if err := c.DAL.GetUserByEmail(&user); err == nil {
-			model := models.NewErrorResponse(constants.APIDatabaseCreateInvitation,
-				models.NewAZError("User with email already exists"), "Could not create invitation")
-			c.Render(constants.StatusBadRequest, model, rw, req)
-			return
-		}
-	}
-	c.createInvitationsResponse(invitations, rw, req)
+	c.createInvitationsResponse(constants.InvitationTypeEmail, rw, req)
 }
 
 // CreateFacebookInvitations invitation route creates multiple invitations
@@ -89,30 +60,5 @@ func (c *InvitationContext) CreateEmailInvitations(rw web.ResponseWriter, req *w
 // Returns
 //   201 Status Created
 func (c *InvitationContext) CreateFacebookInvitations(rw web.ResponseWriter, req *web.Request) {
-	var invitationRequest models.InvitationRequest
-	// decode request
-	if !c.DecodeHelper(&invitationRequest, "Couldn't decode the request body", rw, req) {
-		return
-	}
-
-	invitations := make(models.Invitations, len(invitationRequest.InviteCodes))
-	var user models.User
-	for idx, facebookID := range invitationRequest.InviteCodes {
-		invitations[idx] = &models.Invitation{
-			Type: constants.InvitationTypeFacebook,
-			Code: facebookID,
-		}
-		// Verify we don't already have a user with this facebookID
-		user.FacebookID = invitations[idx].Code
-
-		// TODO: Should we error here? Or continue inviting the rest of the list?
-		if err := c.DAL.GetUserByFacebookID(&user); err == nil {
-			model := models.NewErrorResponse(constants.APIDatabaseCreateInvitation,
-				models.NewAZError("User with facebookID already exists"), "Could not create invitation")
-			c.Render(constants.StatusBadRequest, model, rw, req)
-			return
-		}
-	}
-
-	c.createInvitationsResponse(invitations, rw, req)
+	c.createInvitationsResponse(constants.InvitationTypeFacebook, rw, req)
 }
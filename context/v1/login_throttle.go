@@ -0,0 +1,24 @@
+package v1
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gocraft/web"
+)
+
+// setRetryAfter sets the Retry-After header, in whole seconds
+func setRetryAfter(w web.ResponseWriter, retryAfter time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+}
+
+// checkLoginThrottle renders the throttled error if the client has to wait before logging in
+// to the account again, returns true if it can go ahead
+func (c *UserContext) checkLoginThrottle(userID string, w web.ResponseWriter, req *web.Request) bool {
+	if err := c.account(req).CheckLoginThrottle(userID); err != nil {
+		c.renderAccountError(err, "Could not check failed logins", w, req)
+		return false
+	}
+	return true
+}
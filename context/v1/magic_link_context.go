@@ -0,0 +1,175 @@
+package v1
+
+import (
+	"github.com/axiomzen/zenauth/constants"
+	"github.com/axiomzen/zenauth/data"
+	"github.com/axiomzen/zenauth/email"
+	"github.com/axiomzen/zenauth/helpers"
+	"github.com/axiomzen/zenauth/models"
+	"github.com/gocraft/web"
+)
+
+// MagicLinkContext for passwordless login through an emailed link
+type MagicLinkContext struct {
+	*UserContext
+}
+
+// Send emails a single use login link to the user. Works whether or not the
+// user has a password
+//
+//   POST /magic_link
+//
+// Assumes format:
+//   {
+//     "email":"..."
+//   }
+//
+// Returns
+//   204 No Content
+func (c *MagicLinkContext) Send(rw web.ResponseWriter, req *web.Request) {
+	var request models.MagicLinkRequest
+	if !c.DecodeHelper(&request, "Couldn't decode magic link request", rw, req) {
+		return
+	}
+	if request.Email == "" {
+		model := models.NewErrorResponse(constants.APIParsing, models.NewAZError("email expected"), "Email is required")
+		c.Render(constants.StatusBadRequest, model, rw, req)
+		return
+	}
+
+	// same as a reset token, but with its own claim so one can't be used as the other
+	emailStr := helpers.EmailSanitize(request.Email)
+	claims := make(map[string]interface{}, 2)
+	claims[c.Config.JwtClaimMagicLink] = emailStr
+	claims[c.Config.JwtClaimTenant] = c.DAL.TenantID()
+	jwt := helpers.JWTHelper{HashSecretBytes: c.Config.HashSecretBytes, Keys: c.Config.JwtKeyring}
+	if err := jwt.Generate(claims, c.Config.MagicLinkValidTokenDuration); err != nil {
+		model := models.NewErrorResponse(constants.APIAuthTokenCreation, models.NewAZError(err.Error()), "unable to generate magic link token")
+		c.Render(constants.StatusInternalServerError, model, rw, req)
+		return
+	}
+
+	// saved on the user so it is single use, and a new link replaces the previous one
+	var user models.User
+	user.Email = emailStr
+	user.MagicLinkToken = &jwt.Token
+	if err := c.DAL.CreateUserMagicLinkToken(&user); err != nil {
+		dalErr, _ := err.(data.DALError)
+		if dalErr.ErrorCode == data.DALErrorCodeNoneAffected {
+			model := models.NewErrorResponse(constants.APIEmailNotFound, models.NewAZError(err.Error()), "Email does not exist")
+			c.Render(constants.StatusBadRequest, model, rw, req)
+			return
+		}
+		model := models.NewErrorResponse(constants.APIDatabaseUpdateUser, models.NewAZError(err.Error()), "unable to save magic link token")
+		c.Render(constants.StatusInternalServerError, model, rw, req)
+		return
+	}
+
+	emailer, err := email.Get(c.Config)
+	if err != nil {
+		model := models.NewErrorResponse(constants.APIMagicLinkMessageError, models.NewAZError(err.Error()), "unable to generate magic link email")
+		c.Render(constants.StatusInternalServerError, model, rw, req)
+		return
+	}
+	msg, err := email.GetMagicLinkMessage(c.Config, &user)
+	if err != nil {
+		model := models.NewErrorResponse(constants.APIMagicLinkMessageError, models.NewAZError(err.Error()), "unable to generate magic link email")
+		c.Render(constants.StatusInternalServerError, model, rw, req)
+		return
+	}
+
+	go func(m *email.Message) {
+		if err := emailer.Send(m); err != nil {
+			c.Log.WithError(err).Warn("error sending email")
+		}
+	}(msg)
+	c.Render(constants.StatusNoContent, nil, rw, req)
+}
+
+// ConsumeGet exchanges the token from a login link for an auth token,
+// with the token and email in the query string as they are in the link
+//
+//   GET /magic_link/consume?token=...&email=...
+//
+// Returns
+//   200 OK
+func (c *MagicLinkContext) ConsumeGet(rw web.ResponseWriter, req *web.Request) {
+	queryMap := req.URL.Query()
+	c.consume(models.MagicLinkLogin{Email: queryMap.Get("email"), Token: queryMap.Get("token")}, rw, req)
+}
+
+// ConsumePost exchanges the token from a login link for an auth token
+//
+//   POST /magic_link/consume
+//
+// Assumes format:
+//   {
+//     "email":"...",
+//     "token":"..."
+//   }
+//
+// Returns
+//   200 OK
+func (c *MagicLinkContext) ConsumePost(rw web.ResponseWriter, req *web.Request) {
+	var login models.MagicLinkLogin
+	if !c.DecodeHelper(&login, "Couldn't decode magic link login", rw, req) {
+		return
+	}
+	c.consume(login, rw, req)
+}
+
+func (c *MagicLinkContext) consume(login models.MagicLinkLogin, rw web.ResponseWriter, req *web.Request) {
+	if login.Email == "" || login.Token == "" {
+		model := models.NewErrorResponse(constants.APIParsing, models.NewAZError("email and token expected"), "Email and token are required")
+		c.Render(constants.StatusBadRequest, model, rw, req)
+		return
+	}
+
+	jwt := helpers.JWTHelper{HashSecretBytes: c.Config.HashSecretBytes, Keys: c.Config.JwtKeyring, Token: login.Token}
+	jwtTokenResult := jwt.Validate(c.Config.JwtClaimMagicLink)
+	switch jwtTokenResult.Status {
+	case helpers.JWTokenStatusValid:
+	case helpers.JWTokenStatusExpired:
+		model := models.NewErrorResponse(constants.APIInvalidMagicLinkToken, models.NewAZError("token expired"), "Login link expired")
+		c.Render(constants.StatusUnauthorized, model, rw, req)
+		return
+	default:
+		model := models.NewErrorResponse(constants.APIInvalidMagicLinkToken, models.NewAZError("invalid token"), "Invalid login link")
+		c.Render(constants.StatusUnauthorized, model, rw, req)
+		return
+	}
+	emailStr := helpers.EmailSanitize(login.Email)
+	if jwtTokenResult.Value != emailStr {
+		model := models.NewErrorResponse(constants.APIInvalidMagicLinkToken, models.NewAZError("email doesn't match"), "Invalid login link")
+		c.Render(constants.StatusUnauthorized, model, rw, req)
+		return
+	}
+
+	// the link is opened without an API token
+	c.useTenant(c.tokenTenantID(jwtTokenResult))
+	var user models.User
+	user.Email = emailStr
+	user.MagicLinkToken = &login.Token
+	if err := c.DAL.ConsumeUserMagicLinkToken(&user); err != nil {
+		dalErr, _ := err.(data.DALError)
+		if dalErr.ErrorCode == data.DALErrorCodeNoneAffected {
+			// already used, or replaced by a newer link
+			model := models.NewErrorResponse(constants.APIInvalidMagicLinkToken, models.NewAZError(err.Error()), "Login link already used")
+			c.Render(constants.StatusUnauthorized, model, rw, req)
+			return
+		}
+		model := models.NewErrorResponse(constants.APIDatabaseUpdateUser, models.NewAZError(err.Error()), "Could not consume login link")
+		c.Render(constants.StatusInternalServerError, model, rw, req)
+		return
+	}
+
+	c.UserID = user.ID
+	c.Log = c.Log.WithField("userID", c.UserID)
+
+	// the link stands in for the password, so the second factor is still required
+	if user.TOTPEnabled {
+		c.renderMFAChallenge(&user, rw, req)
+		return
+	}
+	c.renderUserResponseWithNewToken(&user, constants.StatusOK, false, rw, req)
+}
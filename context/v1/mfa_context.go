@@ -0,0 +1,329 @@
+package v1
+
+import (
+	"github.com/axiomzen/zenauth/account"
+	"github.com/axiomzen/zenauth/constants"
+	"github.com/axiomzen/zenauth/helpers"
+	"github.com/axiomzen/zenauth/models"
+	"github.com/gocraft/web"
+)
+
+// MFAContext for two factor authentication
+type MFAContext struct {
+	*UserContext
+}
+
+// getUser helper function, renders the error if the user could not be retrieved
+func (c *MFAContext) getUser(user *models.User, rw web.ResponseWriter, req *web.Request) bool {
+	user.ID = c.UserID
+	if err := c.DAL.GetUserByID(user); err != nil {
+		model := models.NewErrorResponse(constants.APIDatabaseGetUser, models.NewAZError(err.Error()), "Could not get user")
+		c.Render(constants.StatusInternalServerError, model, rw, req)
+		return false
+	}
+	return true
+}
+
+// verifyTOTP checks a code against the user's TOTP secret, rendering the error if it doesn't match.
+// Codes can only be used once
+func (c *UserContext) verifyTOTP(user *models.User, code string, rw web.ResponseWriter, req *web.Request) bool {
+	if err := c.account(req).CheckTOTP(user, code); err != nil {
+		c.renderAccountError(err, "Invalid two factor code", rw, req)
+		return false
+	}
+	return true
+}
+
+// verifyMFACode checks either a TOTP code or a recovery code, rendering the error if it doesn't match.
+// Bad codes count against the login throttle of the account, as bad passwords do
+func (c *UserContext) verifyMFACode(user *models.User, code string, rw web.ResponseWriter, req *web.Request) bool {
+	if !c.checkLoginThrottle(user.ID, rw, req) {
+		return false
+	}
+	if err := c.account(req).VerifyMFACode(user, code); err != nil {
+		c.renderAccountError(err, "Invalid two factor code", rw, req)
+		return false
+	}
+	return true
+}
+
+// newRecoveryCodes replaces the user's recovery codes, returning the new codes
+func (c *UserContext) newRecoveryCodes(userID string) ([]string, error) {
+	codes := make([]string, c.Config.RecoveryCodeCount)
+	recoveryCodes := make(models.RecoveryCodeList, len(codes))
+	for i := range codes {
+		code, err := helpers.GenerateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		hash, err := helpers.HashPasswordBcrypt(helpers.NormalizeRecoveryCode(code), int(c.Config.BcryptCost))
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = code
+		recoveryCodes[i] = &models.RecoveryCode{Hash: hash}
+	}
+	if err := c.DAL.ReplaceRecoveryCodes(userID, &recoveryCodes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// renderMFAChallenge renders the mfa pending token the user exchanges, along with a code, for an auth token
+func (c *UserContext) renderMFAChallenge(user *models.User, w web.ResponseWriter, r *web.Request) {
+	if !c.checkUserEnabled(user, w, r) {
+		return
+	}
+	mfaToken, err := c.account(r).NewMFAToken(user.ID)
+	if err != nil {
+		model := models.NewErrorResponse(constants.APIAuthTokenCreation, models.NewAZError(err.Error()), "Could not create mfa token")
+		c.Render(constants.StatusInternalServerError, model, w, r)
+		return
+	}
+	c.renderMFAToken(mfaToken, w, r)
+}
+
+// renderMFAToken renders the mfa challenge of a login with the mfa pending token
+func (c *UserContext) renderMFAToken(mfaToken string, w web.ResponseWriter, r *web.Request) {
+	c.Render(constants.StatusOK, &models.MFAChallenge{MFARequired: true, MFAToken: mfaToken}, w, r)
+}
+
+// EnrollTOTP generates a new TOTP secret for the user, which has to be confirmed
+// with a first code before it is enabled
+//
+//	POST /mfa/totp
+//
+// Returns
+//
+//	200 OK
+func (c *MFAContext) EnrollTOTP(rw web.ResponseWriter, req *web.Request) {
+	var user models.User
+	if !c.getUser(&user, rw, req) {
+		return
+	}
+	if user.TOTPEnabled {
+		model := models.NewErrorResponse(constants.APIMFAAlreadyEnabled, models.NewAZError("TOTP already enabled"), "Two factor authentication is already enabled")
+		c.Render(constants.StatusForbidden, model, rw, req)
+		return
+	}
+	if helpers.IsZeroString(user.Hash) {
+		model := models.NewErrorResponse(constants.APIIncorrectAccountType, models.NewAZError("No password associated with this account"), "Two factor authentication requires a password")
+		c.Render(constants.StatusBadRequest, model, rw, req)
+		return
+	}
+
+	secret, err := helpers.GenerateTOTPSecret()
+	if err != nil {
+		model := models.NewErrorResponse(constants.APIGeneric, models.NewAZError(err.Error()), "Could not generate TOTP secret")
+		c.Render(constants.StatusInternalServerError, model, rw, req)
+		return
+	}
+	encrypted, err := helpers.EncryptSecret(c.Config.MFAEncryptionKeyBytes, secret)
+	if err != nil {
+		model := models.NewErrorResponse(constants.APIGeneric, models.NewAZError(err.Error()), "Could not encrypt TOTP secret")
+		c.Render(constants.StatusInternalServerError, model, rw, req)
+		return
+	}
+	user.TOTPSecret = &encrypted
+	if err := c.DAL.SetUserTOTPSecret(&user); err != nil {
+		model := models.NewErrorResponse(constants.APIDatabaseUpdateUser, models.NewAZError(err.Error()), "Could not save TOTP secret")
+		c.Render(constants.StatusInternalServerError, model, rw, req)
+		return
+	}
+
+	recoveryCodes, err := c.newRecoveryCodes(user.ID)
+	if err != nil {
+		model := models.NewErrorResponse(constants.APIDatabaseCreate, models.NewAZError(err.Error()), "Could not create recovery codes")
+		c.Render(constants.StatusInternalServerError, model, rw, req)
+		return
+	}
+
+	account := user.Email
+	if account == "" {
+		account = user.UserName
+	}
+	enrollment := models.TOTPEnrollment{
+		Secret:        secret,
+		URI:           helpers.TOTPURI(c.Config.AppName, account, secret),
+		RecoveryCodes: recoveryCodes,
+	}
+	c.Render(constants.StatusOK, &enrollment, rw, req)
+}
+
+// ConfirmTOTP enables TOTP with a first code from the authenticator app
+//
+//	POST /mfa/totp/confirm
+//
+// Assumes format:
+//
+//	{
+//	  "code":"123456"
+//	}
+//
+// Returns
+//
+//	200 OK
+func (c *MFAContext) ConfirmTOTP(rw web.ResponseWriter, req *web.Request) {
+	var code models.MFACode
+	if !c.DecodeHelper(&code, "Couldn't decode code", rw, req) {
+		return
+	}
+	var user models.User
+	if !c.getUser(&user, rw, req) {
+		return
+	}
+	if user.TOTPEnabled {
+		model := models.NewErrorResponse(constants.APIMFAAlreadyEnabled, models.NewAZError("TOTP already enabled"), "Two factor authentication is already enabled")
+		c.Render(constants.StatusForbidden, model, rw, req)
+		return
+	}
+	if !c.verifyTOTP(&user, code.Code, rw, req) {
+		return
+	}
+	if err := c.DAL.EnableUserTOTP(&user); err != nil {
+		model := models.NewErrorResponse(constants.APIDatabaseUpdateUser, models.NewAZError(err.Error()), "Could not enable two factor authentication")
+		c.Render(constants.StatusInternalServerError, model, rw, req)
+		return
+	}
+	c.Render(constants.StatusOK, &user, rw, req)
+}
+
+// DisableTOTP disables TOTP, which requires a current code or a recovery code
+//
+//	POST /mfa/totp/disable
+//
+// Assumes format:
+//
+//	{
+//	  "code":"123456"
+//	}
+//
+// Returns
+//
+//	200 OK
+func (c *MFAContext) DisableTOTP(rw web.ResponseWriter, req *web.Request) {
+	var code models.MFACode
+	if !c.DecodeHelper(&code, "Couldn't decode code", rw, req) {
+		return
+	}
+	var user models.User
+	if !c.getUser(&user, rw, req) {
+		return
+	}
+	if !user.TOTPEnabled {
+		model := models.NewErrorResponse(constants.APIMFANotEnrolled, models.NewAZError("TOTP not enabled"), "Two factor authentication is not enabled")
+		c.Render(constants.StatusBadRequest, model, rw, req)
+		return
+	}
+	if !c.verifyMFACode(&user, code.Code, rw, req) {
+		return
+	}
+	if err := c.DAL.DisableUserTOTP(&user); err != nil {
+		model := models.NewErrorResponse(constants.APIDatabaseUpdateUser, models.NewAZError(err.Error()), "Could not disable two factor authentication")
+		c.Render(constants.StatusInternalServerError, model, rw, req)
+		return
+	}
+	c.Render(constants.StatusOK, &user, rw, req)
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes, which requires a current code or a recovery code
+//
+//	POST /mfa/recovery_codes
+//
+// Assumes format:
+//
+//	{
+//	  "code":"123456"
+//	}
+//
+// Returns
+//
+//	200 OK
+func (c *MFAContext) RegenerateRecoveryCodes(rw web.ResponseWriter, req *web.Request) {
+	var code models.MFACode
+	if !c.DecodeHelper(&code, "Couldn't decode code", rw, req) {
+		return
+	}
+	var user models.User
+	if !c.getUser(&user, rw, req) {
+		return
+	}
+	if !user.TOTPEnabled {
+		model := models.NewErrorResponse(constants.APIMFANotEnrolled, models.NewAZError("TOTP not enabled"), "Two factor authentication is not enabled")
+		c.Render(constants.StatusBadRequest, model, rw, req)
+		return
+	}
+	if !c.verifyMFACode(&user, code.Code, rw, req) {
+		return
+	}
+	recoveryCodes, err := c.newRecoveryCodes(user.ID)
+	if err != nil {
+		model := models.NewErrorResponse(constants.APIDatabaseCreate, models.NewAZError(err.Error()), "Could not create recovery codes")
+		c.Render(constants.StatusInternalServerError, model, rw, req)
+		return
+	}
+	c.account(req).Audit(user.ID, constants.AuditEventRecoveryCodesRegenerated)
+	c.Render(constants.StatusOK, &models.RecoveryCodes{RecoveryCodes: recoveryCodes}, rw, req)
+}
+
+// Login is the second step of logging in for users with two factor authentication,
+// it exchanges the mfa token from the password step and a code (or a recovery code) for an auth token.
+// The account of a login restoring it is restored here
+//
+//	POST /login/mfa
+//
+// Assumes format:
+//
+//	{
+//	  "mfaToken":"...",
+//	  "code":"123456"
+//	}
+//
+// Returns
+//
+//	200 OK
+func (c *MFAContext) Login(rw web.ResponseWriter, req *web.Request) {
+	var login models.MFALogin
+	if !c.DecodeHelper(&login, "Couldn't decode mfa login", rw, req) {
+		return
+	}
+
+	jwt := helpers.JWTHelper{HashSecretBytes: c.Config.HashSecretBytes, Keys: c.Config.JwtKeyring, Token: login.MFAToken}
+	jwtTokenResult := jwt.Validate(c.Config.JwtClaimMFAPending)
+	switch jwtTokenResult.Status {
+	case helpers.JWTokenStatusValid:
+	case helpers.JWTokenStatusExpired:
+		c.ExpiredHandler(rw, req)
+		return
+	default:
+		model := models.NewErrorResponse(constants.APIInvalidMFAToken, models.NewAZError(jwtTokenResult.Message), "Invalid mfa token")
+		c.Render(constants.StatusUnauthorized, model, rw, req)
+		return
+	}
+
+	c.UserID = jwtTokenResult.Value
+	var user models.User
+	if err := c.account(req).GetMFAUser(jwtTokenResult, &user); err != nil {
+		c.renderAccountError(err, "Could not get user", rw, req)
+		return
+	}
+	if !user.TOTPEnabled {
+		model := models.NewErrorResponse(constants.APIInvalidMFAToken, models.NewAZError("TOTP not enabled"), "Invalid mfa token")
+		c.Render(constants.StatusUnauthorized, model, rw, req)
+		return
+	}
+	if !c.checkLoginThrottle(user.ID, rw, req) {
+		return
+	}
+	if err := c.account(req).CheckMFALogin(jwtTokenResult, &user, login.Code); err != nil {
+		c.renderAccountError(err, "Invalid two factor code", rw, req)
+		return
+	}
+	if account.IsRestoreMFAToken(c.Config, jwtTokenResult) {
+		if err := c.account(req).RestoreUser(&user); err != nil {
+			c.renderAccountError(err, "Could not restore account", rw, req)
+			return
+		}
+	}
+	c.renderUserResponseWithNewToken(&user, constants.StatusOK, false, rw, req)
+}
@@ -0,0 +1,483 @@
+package v1
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ajg/form"
+	"github.com/axiomzen/zenauth/account"
+	"github.com/axiomzen/zenauth/constants"
+	"github.com/axiomzen/zenauth/data"
+	"github.com/axiomzen/zenauth/helpers"
+	"github.com/axiomzen/zenauth/models"
+	"github.com/axiomzen/zenauth/routes"
+	"github.com/gocraft/web"
+	"github.com/twinj/uuid"
+)
+
+// oauthCodeLength is the number of random bytes in an authorization code
+const oauthCodeLength = 32
+
+// OAuthContext for the OpenID Connect provider, which lets other apps log users in
+// with the authorization code flow and PKCE
+type OAuthContext struct {
+	*UserContext
+}
+
+// decodeOAuthForm decodes the query string and form body into v,
+// ignoring the parameters we don't support
+func decodeOAuthForm(v interface{}, req *web.Request) error {
+	if err := req.ParseForm(); err != nil {
+		return err
+	}
+	decoder := form.NewDecoder(nil)
+	decoder.IgnoreUnknownKeys(true)
+	return decoder.DecodeValues(v, req.Form)
+}
+
+// hasScope checks for a scope in a space separated list of scopes
+func hasScope(scopes, scope string) bool {
+	for _, s := range strings.Fields(scopes) {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// renderJSON renders v as json, whatever the content type of the request was,
+// as OAuth clients send forms but expect json back
+func (c *OAuthContext) renderJSON(status constants.HTTPStatusCode, v interface{}, rw web.ResponseWriter, req *web.Request) {
+	req.Header.Set("Content-Type", "application/json")
+	rw.Header().Set("Cache-Control", "no-store")
+	rw.Header().Set("Pragma", "no-cache")
+	c.Render(status, v, rw, req)
+}
+
+// renderHTML renders a page of the authorization endpoint
+func (c *OAuthContext) renderHTML(status constants.HTTPStatusCode, html string, rw web.ResponseWriter, req *web.Request) {
+	req.Header.Set("Content-Type", "text/html")
+	rw.Header().Set("Cache-Control", "no-store")
+	// the login page must not be framed by another site
+	rw.Header().Set("X-Frame-Options", "DENY")
+	c.Render(status, html, rw, req)
+}
+
+// renderMessage renders an error that can't be sent back to the client,
+// because the client or its redirect uri can't be trusted
+func (c *OAuthContext) renderMessage(status constants.HTTPStatusCode, message string, rw web.ResponseWriter, req *web.Request) {
+	html, err := GetGeneralMessageHTML(message)
+	if err != nil {
+		msg := models.Message{Message: message}
+		c.Render(status, &msg, rw, req)
+		return
+	}
+	c.renderHTML(status, html, rw, req)
+}
+
+// renderLogin renders the login page, or the two factor code page if there is an mfa token
+func (c *OAuthContext) renderLogin(status constants.HTTPStatusCode, client *models.OAuthClient, request *models.OAuthAuthorizeRequest, mfaToken, message string, rw web.ResponseWriter, req *web.Request) {
+	html, err := GetOAuthLoginHTML(c.Config, client, request, mfaToken, message)
+	if err != nil {
+		c.redirectError(request, constants.OAuthErrorServerError, "could not render the login page", rw, req)
+		return
+	}
+	c.renderHTML(status, html, rw, req)
+}
+
+// redirect sends the browser back to the client with the parameters added to the redirect uri
+func (c *OAuthContext) redirect(request *models.OAuthAuthorizeRequest, values url.Values, rw web.ResponseWriter, req *web.Request) {
+	redirectURI, err := url.Parse(request.RedirectURI)
+	if err != nil {
+		c.renderMessage(constants.StatusBadRequest, "400 - Invalid redirect_uri", rw, req)
+		return
+	}
+	query := redirectURI.Query()
+	for name, value := range values {
+		query[name] = value
+	}
+	if request.State != "" {
+		query.Set("state", request.State)
+	}
+	redirectURI.RawQuery = query.Encode()
+	rw.Header().Set("Location", redirectURI.String())
+	c.Render(constants.StatusFound, nil, rw, req)
+}
+
+// redirectError sends an error back to the client
+func (c *OAuthContext) redirectError(request *models.OAuthAuthorizeRequest, code, description string, rw web.ResponseWriter, req *web.Request) {
+	c.redirect(request, url.Values{"error": {code}, "error_description": {description}}, rw, req)
+}
+
+// getClient gets the client of the authorization request and checks the redirect uri
+// was registered, rendering the error if not. Errors are never redirected to unregistered uris
+func (c *OAuthContext) getClient(request *models.OAuthAuthorizeRequest, rw web.ResponseWriter, req *web.Request) (*models.OAuthClient, bool) {
+	client := models.OAuthClient{ClientID: request.ClientID}
+	if request.ClientID == "" {
+		c.renderMessage(constants.StatusBadRequest, "400 - Missing client_id", rw, req)
+		return nil, false
+	}
+	if err := c.DAL.GetOAuthClient(&client); err != nil {
+		if dalErr, _ := err.(data.DALError); dalErr.ErrorCode == data.DALErrorCodeNoneAffected {
+			c.renderMessage(constants.StatusBadRequest, "400 - Unknown client", rw, req)
+			return nil, false
+		}
+		c.Log.WithError(err).Error("Could not get oauth client")
+		c.renderMessage(constants.StatusInternalServerError, "500 - Internal Server Error", rw, req)
+		return nil, false
+	}
+	for _, redirectURI := range client.RedirectURIs {
+		if redirectURI == request.RedirectURI {
+			return &client, true
+		}
+	}
+	c.renderMessage(constants.StatusBadRequest, "400 - Invalid redirect_uri", rw, req)
+	return nil, false
+}
+
+// login checks the credentials posted from the login page, re-rendering the page
+// if they don't match or the user still has to enter their two factor code
+func (c *OAuthContext) login(client *models.OAuthClient, request *models.OAuthAuthorizeRequest, rw web.ResponseWriter, req *web.Request) (*models.User, bool) {
+	var user models.User
+	if request.MFAToken != "" {
+		jwt := helpers.JWTHelper{HashSecretBytes: c.Config.HashSecretBytes, Keys: c.Config.JwtKeyring, Token: request.MFAToken}
+		jwtTokenResult := jwt.Validate(c.Config.JwtClaimMFAPending)
+		if jwtTokenResult.Status != helpers.JWTokenStatusValid {
+			c.renderLogin(constants.StatusUnauthorized, client, request, "", "Your login expired, please log in again", rw, req)
+			return nil, false
+		}
+		user.ID = jwtTokenResult.Value
+		if err := c.DAL.GetUserByID(&user); err != nil {
+			c.redirectError(request, constants.OAuthErrorServerError, "could not get the user", rw, req)
+			return nil, false
+		}
+		retryAfter, err := c.account(req).LoginRetryAfter(user.ID)
+		if err != nil {
+			c.redirectError(request, constants.OAuthErrorServerError, "could not check failed logins", rw, req)
+			return nil, false
+		}
+		if retryAfter > 0 {
+			setRetryAfter(rw, retryAfter)
+			c.renderLogin(constants.StatusTooManyRequests, client, request, request.MFAToken, "Too many failed attempts, try again later", rw, req)
+			return nil, false
+		}
+		if err := c.account(req).CheckMFALogin(jwtTokenResult, &user, request.OTP); err != nil {
+			apiErr, ok := err.(data.APIError)
+			switch {
+			case ok && apiErr.Code == constants.APIInvalidMFAToken:
+				// too many bad codes
+				c.renderLogin(apiErr.Status, client, request, "", "Your login expired, please log in again", rw, req)
+			case ok && apiErr.Status == constants.StatusUnauthorized:
+				c.renderLogin(apiErr.Status, client, request, request.MFAToken, "Invalid authentication code", rw, req)
+			default:
+				c.redirectError(request, constants.OAuthErrorServerError, "could not check the authentication code", rw, req)
+			}
+			return nil, false
+		}
+		return &user, true
+	}
+
+	user.Email = helpers.EmailSanitize(request.Email)
+	err := c.DAL.GetUserByEmail(&user)
+	var mfaToken string
+	if err == nil {
+		mfaToken, err = c.account(req).Login(&user, request.Password)
+	} else if dalErr, _ := err.(data.DALError); dalErr.ErrorCode == data.DALErrorCodeNoneAffected {
+		err = c.account(req).LoginUnknownUser()
+	} else {
+		c.redirectError(request, constants.OAuthErrorServerError, "could not get the user", rw, req)
+		return nil, false
+	}
+	if throttled, ok := err.(*account.LoginThrottledError); ok {
+		setRetryAfter(rw, throttled.RetryAfter)
+		c.renderLogin(constants.StatusTooManyRequests, client, request, "", "Too many failed logins, try again later", rw, req)
+		return nil, false
+	}
+	if err != nil {
+		apiErr, _ := err.(data.APIError)
+		switch apiErr.Code {
+		case constants.APILoginSignupInvalidCombination:
+			c.renderLogin(constants.StatusUnauthorized, client, request, "", "Invalid email or password", rw, req)
+		case constants.APIAccountDisabled:
+			c.redirectError(request, constants.OAuthErrorAccessDenied, "account disabled", rw, req)
+		default:
+			c.redirectError(request, constants.OAuthErrorServerError, "could not log in", rw, req)
+		}
+		return nil, false
+	}
+	if mfaToken != "" {
+		c.renderLogin(constants.StatusOK, client, request, mfaToken, "", rw, req)
+		return nil, false
+	}
+	return &user, true
+}
+
+// Configuration is the OpenID Connect discovery document
+//
+//   GET /.well-known/openid-configuration
+//
+// Returns
+//   200 OK
+func (c *OAuthContext) Configuration(rw web.ResponseWriter, req *web.Request) {
+	issuer := c.Config.OAuthIssuer
+	configuration := models.OIDCConfiguration{
+		Issuer:                            issuer,
+		AuthorizationEndpoint:             issuer + routes.ResourceAuthorize,
+		TokenEndpoint:                     issuer + routes.ResourceToken,
+		UserInfoEndpoint:                  issuer + routes.ResourceUserInfo,
+		JWKSURI:                           issuer + routes.ResourceWellKnown + routes.ResourceJWKS,
+		ScopesSupported:                   []string{constants.OAuthScopeOpenID, "email", "profile"},
+		ResponseTypesSupported:            []string{constants.OAuthResponseTypeCode},
+		GrantTypesSupported:               []string{constants.OAuthGrantTypeAuthorizationCode},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  []string{c.Config.JwtKeyring.Current.Method.Alg()},
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		CodeChallengeMethodsSupported:     []string{helpers.PKCEMethodS256},
+		ClaimsSupported:                   []string{"iss", "sub", "aud", "exp", "iat", "auth_time", "nonce", "email", "email_verified", "preferred_username", "picture", "updated_at"},
+	}
+	rw.Header().Set("Cache-Control", "public, max-age=300")
+	req.Header.Set("Content-Type", "application/json")
+	c.Render(constants.StatusOK, &configuration, rw, req)
+}
+
+// Authorize shows the login page for an authorization request, and redirects back
+// to the client with an authorization code once the user logs in
+//
+//   GET /authorize?response_type=code&client_id=...&redirect_uri=...&scope=openid&state=...&nonce=...&code_challenge=...&code_challenge_method=S256
+//   POST /authorize
+//
+// Returns
+//   200 OK (the login page)
+//   302 Found (back to the client)
+func (c *OAuthContext) Authorize(rw web.ResponseWriter, req *web.Request) {
+	var request models.OAuthAuthorizeRequest
+	if err := decodeOAuthForm(&request, req); err != nil {
+		c.renderMessage(constants.StatusBadRequest, "400 - Bad Request", rw, req)
+		return
+	}
+	client, ok := c.getClient(&request, rw, req)
+	if !ok {
+		return
+	}
+
+	// from here on errors go back to the client
+	if request.ResponseType != constants.OAuthResponseTypeCode {
+		c.redirectError(&request, constants.OAuthErrorUnsupportedResponseType, "only the code response type is supported", rw, req)
+		return
+	}
+	if !hasScope(request.Scope, constants.OAuthScopeOpenID) {
+		c.redirectError(&request, constants.OAuthErrorInvalidScope, "the openid scope is required", rw, req)
+		return
+	}
+	if request.CodeChallenge == "" || request.CodeChallengeMethod != helpers.PKCEMethodS256 {
+		c.redirectError(&request, constants.OAuthErrorInvalidRequest, "a S256 code_challenge is required", rw, req)
+		return
+	}
+
+	if req.Method != "POST" {
+		c.renderLogin(constants.StatusOK, client, &request, "", "", rw, req)
+		return
+	}
+	user, ok := c.login(client, &request, rw, req)
+	if !ok {
+		return
+	}
+	c.UserID = user.ID
+	c.Log = c.Log.WithField("userID", c.UserID)
+
+	code, hash, err := helpers.GenerateOpaqueToken(oauthCodeLength)
+	if err != nil {
+		c.redirectError(&request, constants.OAuthErrorServerError, "could not create the code", rw, req)
+		return
+	}
+	authorizationCode := models.OAuthAuthorizationCode{
+		CodeHash:      hash,
+		ClientID:      client.ClientID,
+		UserID:        user.ID,
+		RedirectURI:   request.RedirectURI,
+		Scope:         request.Scope,
+		Nonce:         request.Nonce,
+		CodeChallenge: request.CodeChallenge,
+		ExpiresAt:     time.Now().Add(c.Config.OAuthCodeDuration),
+	}
+	if err := c.DAL.CreateOAuthAuthorizationCode(&authorizationCode); err != nil {
+		c.Log.WithError(err).Error("Could not store authorization code")
+		c.redirectError(&request, constants.OAuthErrorServerError, "could not store the code", rw, req)
+		return
+	}
+	c.redirect(&request, url.Values{"code": {code}}, rw, req)
+}
+
+// Token exchanges an authorization code for an access token, which is a regular
+// auth token of the user, and an ID token. Confidential clients authenticate with
+// their secret, in the Authorization header or the form
+//
+//   POST /token
+//
+// Assumes format:
+//   grant_type=authorization_code&code=...&redirect_uri=...&client_id=...&code_verifier=...
+//
+// Returns
+//   200 OK
+func (c *OAuthContext) Token(rw web.ResponseWriter, req *web.Request) {
+	var request models.OAuthTokenRequest
+	if err := decodeOAuthForm(&request, req); err != nil {
+		c.renderJSON(constants.StatusBadRequest, &models.OAuthError{Error: constants.OAuthErrorInvalidRequest, ErrorDescription: err.Error()}, rw, req)
+		return
+	}
+	if clientID, secret, ok := req.BasicAuth(); ok {
+		// form encoded before being put in the header (RFC 6749 2.3.1)
+		request.ClientID, _ = url.QueryUnescape(clientID)
+		request.ClientSecret, _ = url.QueryUnescape(secret)
+	}
+	if request.GrantType != constants.OAuthGrantTypeAuthorizationCode {
+		c.renderJSON(constants.StatusBadRequest, &models.OAuthError{Error: constants.OAuthErrorUnsupportedGrantType}, rw, req)
+		return
+	}
+
+	client := models.OAuthClient{ClientID: request.ClientID}
+	if err := c.DAL.GetOAuthClient(&client); err != nil {
+		if dalErr, _ := err.(data.DALError); dalErr.ErrorCode == data.DALErrorCodeNoneAffected || request.ClientID == "" {
+			c.renderJSON(constants.StatusUnauthorized, &models.OAuthError{Error: constants.OAuthErrorInvalidClient}, rw, req)
+			return
+		}
+		c.renderJSON(constants.StatusInternalServerError, &models.OAuthError{Error: constants.OAuthErrorServerError}, rw, req)
+		return
+	}
+	if client.SecretHash != "" && subtle.ConstantTimeCompare([]byte(helpers.HashOpaqueToken(request.ClientSecret)), []byte(client.SecretHash)) != 1 {
+		c.renderJSON(constants.StatusUnauthorized, &models.OAuthError{Error: constants.OAuthErrorInvalidClient}, rw, req)
+		return
+	}
+
+	// the code is used up even if the rest of the request is wrong
+	code := models.OAuthAuthorizationCode{CodeHash: helpers.HashOpaqueToken(request.Code)}
+	if err := c.DAL.ConsumeOAuthAuthorizationCode(&code); err != nil {
+		if dalErr, _ := err.(data.DALError); dalErr.ErrorCode == data.DALErrorCodeNoneAffected {
+			c.renderJSON(constants.StatusBadRequest, &models.OAuthError{Error: constants.OAuthErrorInvalidGrant, ErrorDescription: "unknown or already used code"}, rw, req)
+			return
+		}
+		c.renderJSON(constants.StatusInternalServerError, &models.OAuthError{Error: constants.OAuthErrorServerError}, rw, req)
+		return
+	}
+	switch {
+	case code.ClientID != client.ClientID:
+		c.renderJSON(constants.StatusBadRequest, &models.OAuthError{Error: constants.OAuthErrorInvalidGrant, ErrorDescription: "code was issued to another client"}, rw, req)
+		return
+	case code.RedirectURI != request.RedirectURI:
+		c.renderJSON(constants.StatusBadRequest, &models.OAuthError{Error: constants.OAuthErrorInvalidGrant, ErrorDescription: "redirect_uri doesn't match"}, rw, req)
+		return
+	case time.Now().After(code.ExpiresAt):
+		c.renderJSON(constants.StatusBadRequest, &models.OAuthError{Error: constants.OAuthErrorInvalidGrant, ErrorDescription: "code expired"}, rw, req)
+		return
+	case !helpers.VerifyPKCE(request.CodeVerifier, code.CodeChallenge):
+		c.renderJSON(constants.StatusBadRequest, &models.OAuthError{Error: constants.OAuthErrorInvalidGrant, ErrorDescription: "code_verifier doesn't match"}, rw, req)
+		return
+	}
+
+	var user models.User
+	user.ID = code.UserID
+	if err := c.DAL.GetUserByID(&user); err != nil {
+		c.renderJSON(constants.StatusInternalServerError, &models.OAuthError{Error: constants.OAuthErrorServerError}, rw, req)
+		return
+	}
+	if user.DisabledAt.Valid {
+		c.renderJSON(constants.StatusBadRequest, &models.OAuthError{Error: constants.OAuthErrorInvalidGrant, ErrorDescription: "account disabled"}, rw, req)
+		return
+	}
+	c.UserID = user.ID
+	c.Log = c.Log.WithField("userID", c.UserID)
+
+	accessToken, err := c.account(req).NewAuthToken(&user, "")
+	if err != nil {
+		c.renderJSON(constants.StatusInternalServerError, &models.OAuthError{Error: constants.OAuthErrorServerError}, rw, req)
+		return
+	}
+	idToken, err := c.newIDToken(&user, &code)
+	if err != nil {
+		c.renderJSON(constants.StatusInternalServerError, &models.OAuthError{Error: constants.OAuthErrorServerError}, rw, req)
+		return
+	}
+	c.renderJSON(constants.StatusOK, &models.OAuthTokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(c.Config.JwtUserTokenDuration / time.Second),
+		IDToken:     idToken,
+		Scope:       code.Scope,
+	}, rw, req)
+}
+
+// newIDToken creates the ID token for the client the code was issued to
+func (c *OAuthContext) newIDToken(user *models.User, code *models.OAuthAuthorizationCode) (string, error) {
+	var claims map[string]interface{}
+	userInfo, err := json.Marshal(user.OIDCUserInfo())
+	if err != nil {
+		return "", err
+	}
+	if err := json.Unmarshal(userInfo, &claims); err != nil {
+		return "", err
+	}
+	claims["iss"] = c.Config.OAuthIssuer
+	claims["aud"] = code.ClientID
+	if code.CreatedAt.Valid {
+		claims["auth_time"] = code.CreatedAt.Time.Unix()
+	}
+	if code.Nonce != "" {
+		claims["nonce"] = code.Nonce
+	}
+	jwt := helpers.JWTHelper{HashSecretBytes: c.Config.HashSecretBytes, Keys: c.Config.JwtKeyring}
+	err = jwt.Generate(claims, c.Config.JwtUserTokenDuration)
+	return jwt.Token, err
+}
+
+// UserInfo returns the claims of the user the access token was issued to
+//
+//   GET /userinfo
+//   Authorization: Bearer ...
+//
+// Returns
+//   200 OK
+func (c *OAuthContext) UserInfo(rw web.ResponseWriter, req *web.Request) {
+	invalidToken := func(description string) {
+		rw.Header().Set("WWW-Authenticate", `Bearer error="`+constants.OAuthErrorInvalidToken+`"`)
+		c.renderJSON(constants.StatusUnauthorized, &models.OAuthError{Error: constants.OAuthErrorInvalidToken, ErrorDescription: description}, rw, req)
+	}
+
+	authorization := req.Header.Get("Authorization")
+	if len(authorization) < 7 || !strings.EqualFold(authorization[:7], "Bearer ") {
+		rw.Header().Set("WWW-Authenticate", "Bearer")
+		c.renderJSON(constants.StatusUnauthorized, &models.OAuthError{Error: constants.OAuthErrorInvalidRequest, ErrorDescription: "missing bearer token"}, rw, req)
+		return
+	}
+	jwt := helpers.JWTHelper{HashSecretBytes: c.Config.HashSecretBytes, Keys: c.Config.JwtKeyring, Token: authorization[7:]}
+	jwtTokenResult := jwt.Validate(c.Config.JwtClaimUserID)
+	if jwtTokenResult.Status != helpers.JWTokenStatusValid {
+		invalidToken(jwtTokenResult.Message)
+		return
+	}
+	if _, err := uuid.Parse(jwtTokenResult.Value); err != nil {
+		invalidToken("invalid subject")
+		return
+	}
+	if revoked, err := c.DAL.IsTokenRevoked(jwtTokenResult.Value, jwtTokenResult.JTI, jwtTokenResult.IssuedAt); err != nil {
+		c.renderJSON(constants.StatusInternalServerError, &models.OAuthError{Error: constants.OAuthErrorServerError}, rw, req)
+		return
+	} else if revoked {
+		invalidToken("token revoked")
+		return
+	}
+
+	var user models.User
+	user.ID = jwtTokenResult.Value
+	if err := c.DAL.GetUserByID(&user); err != nil {
+		if dalErr, _ := err.(data.DALError); dalErr.ErrorCode == data.DALErrorCodeNoneAffected {
+			invalidToken("user does not exist")
+			return
+		}
+		c.renderJSON(constants.StatusInternalServerError, &models.OAuthError{Error: constants.OAuthErrorServerError}, rw, req)
+		return
+	}
+	c.renderJSON(constants.StatusOK, user.OIDCUserInfo(), rw, req)
+}
@@ -0,0 +1,28 @@
+package v1
+
+import (
+	"github.com/axiomzen/zenauth/account"
+	"github.com/axiomzen/zenauth/models"
+	"github.com/gocraft/web"
+)
+
+// newPasswordPolicyError returns the error of a password breaking the policy, with each rule broken in
+// the details
+func newPasswordPolicyError(policyErr *account.PasswordPolicyError, msg string) *models.ErrorResponse {
+	violations := policyErr.Violations
+	model := models.NewErrorResponse(policyErr.Code(), models.NewAZError(violations[0].Message), msg)
+	for _, violation := range violations {
+		model.Details = append(model.Details, models.ErrorDetail{Field: "password", Rule: violation.Rule, Message: violation.Message})
+	}
+	return model
+}
+
+// checkPasswordPolicy renders the rules password breaks for user, who has no ID yet when signing up.
+// Returns true if the password can be used
+func (c *UserContext) checkPasswordPolicy(password string, user *models.User, msg string, w web.ResponseWriter, req *web.Request) bool {
+	if err := c.account(req).CheckPasswordPolicy(password, user); err != nil {
+		c.renderAccountError(err, msg, w, req)
+		return false
+	}
+	return true
+}
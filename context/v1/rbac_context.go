@@ -0,0 +1,169 @@
+package v1
+
+import (
+	"github.com/axiomzen/zenauth/constants"
+	"github.com/axiomzen/zenauth/models"
+	"github.com/gocraft/web"
+)
+
+// ListRoles lists all the roles with their permissions
+//
+//   GET /admins/roles
+//
+// Returns
+//   200 OK
+func (c *AdminContext) ListRoles(rw web.ResponseWriter, req *web.Request) {
+	roles := models.Roles{}
+	if err := c.DAL.GetRoles(&roles); err != nil {
+		model := models.NewErrorResponse(constants.APIDatabaseGet, models.NewAZError(err.Error()), "Could not get roles")
+		c.Render(constants.StatusInternalServerError, model, rw, req)
+		return
+	}
+	c.Render(constants.StatusOK, roles, rw, req)
+}
+
+// GetRole gets a role with its permissions
+//
+//   GET /admins/roles/:name
+//
+// Returns
+//   200 OK
+func (c *AdminContext) GetRole(rw web.ResponseWriter, req *web.Request) {
+	role := models.Role{Name: req.PathParams["name"]}
+	if err := c.DAL.GetRoleByName(&role); err != nil {
+		c.RenderDALError(err, constants.APIDatabaseGet, "Could not get role", rw, req)
+		return
+	}
+	c.Render(constants.StatusOK, &role, rw, req)
+}
+
+// SaveRole creates the role, or replaces the description and permissions of the one of that name.
+// Permissions are created the first time a role is given them. Users get the new permissions
+// in their next auth token
+//
+//   PUT /admins/roles/:name
+//
+// Assumes format:
+//   {
+//     "description":"...",
+//     "permissions":["posts:read","posts:write"]
+//   }
+//
+// Returns
+//   200 OK
+func (c *AdminContext) SaveRole(rw web.ResponseWriter, req *web.Request) {
+	var role models.Role
+	if !c.DecodeHelper(&role, "Couldn't decode role", rw, req) {
+		return
+	}
+	role.Name = req.PathParams["name"]
+	if err := role.Validate(); err != nil {
+		model := models.NewErrorResponse(constants.APIParsingRole, models.NewAZError(err.Error()), "Invalid role")
+		c.Render(constants.StatusBadRequest, model, rw, req)
+		return
+	}
+	if err := c.DAL.SaveRole(&role); err != nil {
+		model := models.NewErrorResponse(constants.APIDatabaseUpdate, models.NewAZError(err.Error()), "Could not save role")
+		c.Render(constants.StatusInternalServerError, model, rw, req)
+		return
+	}
+	c.Render(constants.StatusOK, &role, rw, req)
+}
+
+// DeleteRole deletes a role, taking it away from its users
+//
+//   DELETE /admins/roles/:name
+//
+// Returns
+//   204 No Content
+func (c *AdminContext) DeleteRole(rw web.ResponseWriter, req *web.Request) {
+	role := models.Role{Name: req.PathParams["name"]}
+	if err := c.DAL.DeleteRole(&role); err != nil {
+		c.RenderDALError(err, constants.APIDatabaseDelete, "Could not delete role", rw, req)
+		return
+	}
+	c.Render(constants.StatusNoContent, nil, rw, req)
+}
+
+// ListPermissions lists all the permissions roles were given
+//
+//   GET /admins/permissions
+//
+// Returns
+//   200 OK
+func (c *AdminContext) ListPermissions(rw web.ResponseWriter, req *web.Request) {
+	permissions := models.Permissions{}
+	if err := c.DAL.GetPermissions(&permissions); err != nil {
+		model := models.NewErrorResponse(constants.APIDatabaseGet, models.NewAZError(err.Error()), "Could not get permissions")
+		c.Render(constants.StatusInternalServerError, model, rw, req)
+		return
+	}
+	c.Render(constants.StatusOK, permissions, rw, req)
+}
+
+// DeletePermission deletes a permission, taking it away from the roles that have it
+//
+//   DELETE /admins/permissions/:name
+//
+// Returns
+//   204 No Content
+func (c *AdminContext) DeletePermission(rw web.ResponseWriter, req *web.Request) {
+	permission := models.Permission{Name: req.PathParams["name"]}
+	if err := c.DAL.DeletePermission(&permission); err != nil {
+		c.RenderDALError(err, constants.APIDatabaseDelete, "Could not delete permission", rw, req)
+		return
+	}
+	c.Render(constants.StatusNoContent, nil, rw, req)
+}
+
+// GetUserRoles gets the roles of a user with their permissions
+//
+//   GET /admins/users/:id/roles
+//
+// Returns
+//   200 OK
+func (c *AdminContext) GetUserRoles(rw web.ResponseWriter, req *web.Request) {
+	var user models.User
+	if !c.pathUser(&user, rw, req) {
+		return
+	}
+	roles := models.Roles{}
+	if err := c.DAL.GetUserRoles(user.ID, &roles); err != nil {
+		model := models.NewErrorResponse(constants.APIDatabaseGet, models.NewAZError(err.Error()), "Could not get roles")
+		c.Render(constants.StatusInternalServerError, model, rw, req)
+		return
+	}
+	c.Render(constants.StatusOK, roles, rw, req)
+}
+
+// AssignUserRole gives a role to a user, it is in their auth tokens from the next one
+//
+//   PUT /admins/users/:id/roles/:name
+//
+// Returns
+//   204 No Content
+func (c *AdminContext) AssignUserRole(rw web.ResponseWriter, req *web.Request) {
+	var user models.User
+	if !c.pathUser(&user, rw, req) {
+		return
+	}
+	if err := c.DAL.AssignUserRole(user.ID, req.PathParams["name"]); err != nil {
+		c.RenderDALError(err, constants.APIDatabaseCreate, "Could not assign role", rw, req)
+		return
+	}
+	c.Render(constants.StatusNoContent, nil, rw, req)
+}
+
+// UnassignUserRole takes a role away from a user
+//
+//   DELETE /admins/users/:id/roles/:name
+//
+// Returns
+//   204 No Content
+func (c *AdminContext) UnassignUserRole(rw web.ResponseWriter, req *web.Request) {
+	if err := c.DAL.UnassignUserRole(req.PathParams["id"], req.PathParams["name"]); err != nil {
+		c.RenderDALError(err, constants.APIDatabaseDelete, "Could not unassign role", rw, req)
+		return
+	}
+	c.Render(constants.StatusNoContent, nil, rw, req)
+}
@@ -0,0 +1,227 @@
+package v1
+
+import (
+	"strconv"
+
+	"github.com/axiomzen/zenauth/account"
+	"github.com/axiomzen/zenauth/constants"
+	"github.com/axiomzen/zenauth/data"
+	"github.com/axiomzen/zenauth/helpers"
+	"github.com/axiomzen/zenauth/models"
+	"github.com/gocraft/web"
+)
+
+// SocialContext for logging in with social identity providers
+type SocialContext struct {
+	*UserContext
+}
+
+// authenticate verifies the credentials in the request with the provider in the path,
+// rendering the error if they aren't valid
+func (c *SocialContext) authenticate(rw web.ResponseWriter, req *web.Request) (*helpers.SocialIdentity, bool) {
+	name := req.PathParams["provider"]
+	provider, ok := c.Config.SocialProviders[name]
+	if !ok {
+		model := models.NewErrorResponse(constants.APISocialProviderNotFound, models.NewAZError("unknown provider: "+name), "Social login provider not found")
+		c.Render(constants.StatusNotFound, model, rw, req)
+		return nil, false
+	}
+
+	var login models.SocialLogin
+	if !c.DecodeHelper(&login, "Couldn't decode social login", rw, req) {
+		return nil, false
+	}
+	social, err := provider.Authenticate(&helpers.SocialCredentials{
+		AccessToken:  login.AccessToken,
+		IDToken:      login.IDToken,
+		Code:         login.Code,
+		RedirectURI:  login.RedirectURI,
+		CodeVerifier: login.CodeVerifier,
+		Nonce:        login.Nonce,
+		Subject:      login.Subject,
+	})
+	if err != nil {
+		c.Log.WithError(err).WithField("provider", name).Warn("social login failed")
+		model := models.NewErrorResponse(constants.APISocialLoginNotValid, models.NewAZError(err.Error()), "Could not verify social login")
+		c.Render(constants.StatusBadRequest, model, rw, req)
+		return nil, false
+	}
+	return social, true
+}
+
+// login renders the user the identity is linked to, returns false without
+// rendering anything if it isn't linked to anyone
+func (c *SocialContext) login(social *helpers.SocialIdentity, rw web.ResponseWriter, req *web.Request) bool {
+	identity := account.NewUserIdentity(social)
+	var user models.User
+	if err := c.DAL.GetUserBySocialIdentity(identity, &user); err != nil {
+		if dalErr, _ := err.(data.DALError); dalErr.ErrorCode == data.DALErrorCodeNoneAffected {
+			return false
+		}
+		model := models.NewErrorResponse(constants.APIDatabaseGetUser, models.NewAZError(err.Error()), "Could not get user")
+		c.Render(constants.StatusInternalServerError, model, rw, req)
+		return true
+	}
+
+	// keep the latest tokens from the provider
+	identity.UserID = user.ID
+	if err := c.DAL.SaveUserIdentity(identity); err != nil {
+		c.Log.WithError(err).WithField("provider", identity.Provider).Warn("Could not update identity")
+	}
+
+	c.UserID = user.ID
+	c.Log = c.Log.WithField("userID", c.UserID)
+	if user.TOTPEnabled {
+		c.renderMFAChallenge(&user, rw, req)
+		return true
+	}
+	c.renderUserResponseWithNewToken(&user, constants.StatusOK, false, rw, req)
+	return true
+}
+
+// signup creates a user for the identity
+func (c *SocialContext) signup(social *helpers.SocialIdentity, rw web.ResponseWriter, req *web.Request) {
+	user := models.User{}
+	if social.Provider == constants.SocialProviderFacebook {
+		// the facebook columns are still used by the facebook routes and invitations
+		user.FacebookUser = models.FacebookUser{
+			FacebookID:       social.Subject,
+			FacebookToken:    social.AccessToken,
+			FacebookEmail:    social.Email,
+			FacebookUsername: social.Name,
+			FacebookPicture:  social.Picture,
+		}
+	} else if social.Email != "" {
+		user.Email = helpers.EmailSanitize(social.Email)
+		user.Verified = social.EmailVerified
+	}
+	if c.Config.RequireUsername {
+		user.UserName = social.Name
+		if count, err := c.DAL.GetUsernameCount(user.UserName); err != nil {
+			c.Log.WithError(err).Error("Could not count similar usernames")
+		} else if count > 0 {
+			user.UserName = user.UserName + " " + strconv.Itoa(count)
+		}
+	}
+
+	if err := c.DAL.CreateUserWithIdentity(&user, account.NewUserIdentity(social)); err != nil {
+		// accounts are never linked by email, a taken email means the user has to log in and link them
+		c.RenderDALError(err, constants.APIDatabaseCreateUser, "Could not create new User", rw, req)
+		return
+	}
+
+	c.UserID = user.ID
+	c.Log = c.Log.WithField("userID", c.UserID)
+	c.renderUserResponseWithNewToken(&user, constants.StatusCreated, !user.Verified, rw, req)
+}
+
+// Login logs a user in with an identity provider account they linked or signed up with
+//
+//   POST /social/:provider/login
+//
+// Assumes format:
+//   {
+//     "idToken":"...",
+//     "accessToken":"...",
+//     "code":"...",
+//     "redirectUri":"...",
+//     "codeVerifier":"...",
+//     "nonce":"...",
+//     "subject":"..."
+//   }
+// with the fields the provider needs
+//
+// Returns
+//   200 OK
+func (c *SocialContext) Login(rw web.ResponseWriter, req *web.Request) {
+	social, ok := c.authenticate(rw, req)
+	if !ok {
+		return
+	}
+	if !c.login(social, rw, req) {
+		model := models.NewErrorResponse(constants.APILoginUserDoesNotExist, models.NewAZError("no user for "+social.Provider+" account"), "User does not exist")
+		c.Render(constants.StatusForbidden, model, rw, req)
+	}
+}
+
+// Signup signs a user up with an identity provider account
+//
+//   POST /social/:provider/signup
+//
+// Returns
+//   201 Created
+func (c *SocialContext) Signup(rw web.ResponseWriter, req *web.Request) {
+	social, ok := c.authenticate(rw, req)
+	if !ok {
+		return
+	}
+	c.signup(social, rw, req)
+}
+
+// Social logs the user in, and signs them up if the account isn't linked to anyone yet
+//
+//   POST /social/:provider
+//
+// Returns
+//   200 OK
+//   201 Created
+func (c *SocialContext) Social(rw web.ResponseWriter, req *web.Request) {
+	social, ok := c.authenticate(rw, req)
+	if !ok {
+		return
+	}
+	if !c.login(social, rw, req) {
+		c.signup(social, rw, req)
+	}
+}
+
+// Link links an identity provider account to the logged in user
+//
+//   POST /users/social/:provider/link
+//
+// Returns
+//   200 OK
+func (c *SocialContext) Link(rw web.ResponseWriter, req *web.Request) {
+	social, ok := c.authenticate(rw, req)
+	if !ok {
+		return
+	}
+
+	identity := account.NewUserIdentity(social)
+	identity.UserID = c.UserID
+	if err := c.DAL.SaveUserIdentity(identity); err != nil {
+		if dalErr, _ := err.(data.DALError); dalErr.ErrorCode == data.DALErrorCodeUniqueIdentity {
+			model := models.NewErrorResponse(constants.APISocialAccountExists, models.NewAZError(err.Error()), "Social account already exists")
+			c.Render(constants.StatusForbidden, model, rw, req)
+			return
+		}
+		model := models.NewErrorResponse(constants.APIDatabaseUpdateUser, models.NewAZError(err.Error()), "Could not link social account")
+		c.Render(constants.StatusInternalServerError, model, rw, req)
+		return
+	}
+
+	var user models.User
+	if social.Provider == constants.SocialProviderFacebook {
+		// keep the facebook columns in step with the identity
+		fbUpdate := models.FacebookUpdate{ID: c.UserID, FacebookUser: models.FacebookUser{
+			FacebookID:       social.Subject,
+			FacebookToken:    social.AccessToken,
+			FacebookEmail:    social.Email,
+			FacebookUsername: social.Name,
+			FacebookPicture:  social.Picture,
+		}}
+		if err := c.DAL.UpdateUser(&fbUpdate, &user); err != nil {
+			model := models.NewErrorResponse(constants.APIDatabaseUpdateUser, models.NewAZError(err.Error()), "Could not update User")
+			c.Render(constants.StatusInternalServerError, model, rw, req)
+			return
+		}
+	} else {
+		user.ID = c.UserID
+		if err := c.DAL.GetUserByID(&user); err != nil {
+			model := models.NewErrorResponse(constants.APIDatabaseGetUser, models.NewAZError(err.Error()), "Could not get user")
+			c.Render(constants.StatusInternalServerError, model, rw, req)
+			return
+		}
+	}
+	c.renderUserResponseWithNewToken(&user, constants.StatusOK, false, rw, req)
+}
@@ -8,29 +8,34 @@ import (
 
 	"github.com/axiomzen/zenauth/config"
 	"github.com/axiomzen/zenauth/models"
+	"github.com/axiomzen/zenauth/routes"
 )
 
 var changePasswordHTMLTmpl *template.Template
 var generalMessageHTMLTmpl *template.Template
+var oauthLoginHTMLTmpl *template.Template
 
-func init() {
-	conf, err := config.Get()
-	if err != nil {
-		panic(err)
-	}
+// LoadTemplates parses the HTML templates of the configuration. It must be called before any
+// page is rendered
+func LoadTemplates(conf *config.ZENAUTHConfig) error {
 	templates, err := template.ParseGlob(filepath.Join(conf.HTMLTemplatesPath, "*.tmpl"))
 	if err != nil {
-		panic(err)
+		return err
 	}
 
 	changePasswordHTMLTmpl = templates.Lookup("change_password.html.tmpl")
 	if changePasswordHTMLTmpl == nil {
-		panic(fmt.Errorf("Change password HTML template not found"))
+		return fmt.Errorf("Change password HTML template not found")
 	}
 	generalMessageHTMLTmpl = templates.Lookup("general_message.html.tmpl")
 	if generalMessageHTMLTmpl == nil {
-		panic(fmt.Errorf("General message HTML template not found"))
+		return fmt.Errorf("General message HTML template not found")
+	}
+	oauthLoginHTMLTmpl = templates.Lookup(conf.OAuthLoginTemplate)
+	if oauthLoginHTMLTmpl == nil {
+		return fmt.Errorf("OAuth login HTML template not found")
 	}
+	return nil
 }
 
 // GetChangePasswordHTML returns a Template instance for the reset password action
@@ -64,3 +69,25 @@ func GetGeneralMessageHTML(message string) (string, error) {
 	}
 	return bufHTML.String(), nil
 }
+
+// GetOAuthLoginHTML returns the login page of the authorization endpoint. The page asks
+// for the two factor code instead of the password when there is an mfa token
+func GetOAuthLoginHTML(conf *config.ZENAUTHConfig, client *models.OAuthClient, request *models.OAuthAuthorizeRequest, mfaToken, message string) (string, error) {
+	name := client.Name
+	if name == "" {
+		name = client.ClientID
+	}
+	variables := map[string]interface{}{
+		"title":    "Log in to " + name,
+		"client":   name,
+		"request":  request,
+		"mfaToken": mfaToken,
+		"error":    message,
+		"URL":      conf.OAuthIssuer + routes.ResourceAuthorize,
+	}
+	bufHTML := &bytes.Buffer{}
+	if err := oauthLoginHTMLTmpl.Execute(bufHTML, variables); err != nil {
+		return "", err
+	}
+	return bufHTML.String(), nil
+}
@@ -0,0 +1,113 @@
+package v1
+
+import (
+	"github.com/axiomzen/zenauth/constants"
+	"github.com/axiomzen/zenauth/helpers"
+	"github.com/axiomzen/zenauth/models"
+	"github.com/gocraft/web"
+)
+
+// generateTenantAPIToken sets a new API token on the tenant, only its hash is stored
+func (c *AdminContext) generateTenantAPIToken(tenant *models.Tenant, rw web.ResponseWriter, req *web.Request) bool {
+	token, hash, err := helpers.GenerateOpaqueToken(int(c.Config.TenantAPITokenLength))
+	if err != nil {
+		model := models.NewErrorResponse(constants.APIAuthTokenCreation, models.NewAZError(err.Error()), "Could not create API token")
+		c.Render(constants.StatusInternalServerError, model, rw, req)
+		return false
+	}
+	tenant.APIToken = token
+	tenant.APITokenHash = &hash
+	return true
+}
+
+// ListTenants lists all the tenants
+//
+//   GET /admins/tenants
+//
+// Returns
+//   200 OK
+func (c *AdminContext) ListTenants(rw web.ResponseWriter, req *web.Request) {
+	tenants := models.Tenants{}
+	if err := c.DAL.GetTenants(&tenants); err != nil {
+		model := models.NewErrorResponse(constants.APIDatabaseGet, models.NewAZError(err.Error()), "Could not get tenants")
+		c.Render(constants.StatusInternalServerError, model, rw, req)
+		return
+	}
+	c.Render(constants.StatusOK, tenants, rw, req)
+}
+
+// CreateTenant creates a tenant with its API token, which is only returned here
+//
+//   POST /admins/tenants
+//
+// Assumes format:
+//   {
+//     "name":"..."
+//   }
+//
+// Returns
+//   201 Created
+func (c *AdminContext) CreateTenant(rw web.ResponseWriter, req *web.Request) {
+	var body models.Tenant
+	if !c.DecodeHelper(&body, "Couldn't decode tenant", rw, req) {
+		return
+	}
+	tenant := models.Tenant{Name: body.Name}
+	if err := tenant.Validate(); err != nil {
+		model := models.NewErrorResponse(constants.APIParsingTenant, models.NewAZError(err.Error()), "Invalid tenant")
+		c.Render(constants.StatusBadRequest, model, rw, req)
+		return
+	}
+	if !c.generateTenantAPIToken(&tenant, rw, req) {
+		return
+	}
+	if err := c.DAL.CreateTenant(&tenant); err != nil {
+		c.RenderDALError(err, constants.APIDatabaseCreate, "Could not create tenant", rw, req)
+		return
+	}
+	c.Render(constants.StatusCreated, &tenant, rw, req)
+}
+
+// RotateTenantAPIToken replaces the API token of a tenant, the previous one stops working.
+// The default tenant's is ZENAUTH_APITOKEN
+//
+//   POST /admins/tenants/:id/api_token
+//
+// Returns
+//   200 OK
+func (c *AdminContext) RotateTenantAPIToken(rw web.ResponseWriter, req *web.Request) {
+	tenant := models.Tenant{ID: req.PathParams["id"]}
+	if tenant.ID == constants.DefaultTenantID {
+		model := models.NewErrorResponse(constants.APIParsingTenant, models.NewAZError("default tenant"), "The default tenant's API token is configured")
+		c.Render(constants.StatusBadRequest, model, rw, req)
+		return
+	}
+	if !c.generateTenantAPIToken(&tenant, rw, req) {
+		return
+	}
+	if err := c.DAL.UpdateTenantAPITokenHash(&tenant); err != nil {
+		c.RenderDALError(err, constants.APIDatabaseUpdate, "Could not update tenant", rw, req)
+		return
+	}
+	c.Render(constants.StatusOK, &tenant, rw, req)
+}
+
+// DeleteTenant deletes a tenant along with its users and invitations. The default tenant can't be deleted
+//
+//   DELETE /admins/tenants/:id
+//
+// Returns
+//   204 No Content
+func (c *AdminContext) DeleteTenant(rw web.ResponseWriter, req *web.Request) {
+	tenant := models.Tenant{ID: req.PathParams["id"]}
+	if tenant.ID == constants.DefaultTenantID {
+		model := models.NewErrorResponse(constants.APIParsingTenant, models.NewAZError("default tenant"), "The default tenant can't be deleted")
+		c.Render(constants.StatusBadRequest, model, rw, req)
+		return
+	}
+	if err := c.DAL.DeleteTenant(&tenant); err != nil {
+		c.RenderDALError(err, constants.APIDatabaseDelete, "Could not delete tenant", rw, req)
+		return
+	}
+	c.Render(constants.StatusNoContent, nil, rw, req)
+}
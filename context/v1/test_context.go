@@ -99,6 +99,84 @@ func (c *TestContext) UserPasswordResetTokenGet(rw web.ResponseWriter, req *web.
 	c.Render(constants.StatusOK, model, rw, req)
 }
 
+// UserMagicLinkTokenGet gets a users magic link token
+//
+//   GET /test/users/ResourceMagicLinkToken
+//
+// Returns
+//   200 OK
+func (c *TestContext) UserMagicLinkTokenGet(rw web.ResponseWriter, req *web.Request) {
+	var user models.User
+	user.Email = req.URL.Query().Get("email")
+	if user.Email == "" {
+		model := models.NewErrorResponse(constants.APIParsingQueryParams, models.NewAZError("Could not find email in url"), "Query params error")
+		c.Render(constants.StatusBadRequest, model, rw, req)
+		return
+	}
+
+	if err := c.DAL.GetUserByEmail(&user); err != nil {
+		dalErr, _ := err.(data.DALError)
+		if dalErr.ErrorCode == data.DALErrorCodeNoneAffected {
+			c.Render(constants.StatusNotFound, nil, rw, req)
+			return
+		}
+		model := models.NewErrorResponse(constants.APIDatabaseGetUser, models.NewAZError(err.Error()), "Error retrieving user")
+		c.Render(constants.StatusInternalServerError, model, rw, req)
+		return
+	}
+
+	if helpers.IsZeroString(user.MagicLinkToken) {
+		model := models.NewErrorResponse(constants.APIDatabaseGetUser, models.NewAZError("magic link token was empty"), "Error user has no token")
+		c.Render(constants.StatusBadRequest, model, rw, req)
+		return
+	}
+
+	model := models.TestResetToken{Token: *user.MagicLinkToken}
+	c.Render(constants.StatusOK, model, rw, req)
+}
+
+// UserExportTokenGet gets a download token for the latest export of a user, as the emailed link has
+//
+//   GET /test/users/ResourceExportToken
+//
+// Returns
+//   200 OK
+func (c *TestContext) UserExportTokenGet(rw web.ResponseWriter, req *web.Request) {
+	var user models.User
+	user.Email = req.URL.Query().Get("email")
+	if user.Email == "" {
+		model := models.NewErrorResponse(constants.APIParsingQueryParams, models.NewAZError("Could not find email in url"), "Query params error")
+		c.Render(constants.StatusBadRequest, model, rw, req)
+		return
+	}
+
+	var export models.UserExport
+	err := c.DAL.GetUserByEmail(&user)
+	if err == nil {
+		err = c.DAL.GetLatestUserExport(user.ID, &export)
+	}
+	if err != nil {
+		dalErr, _ := err.(data.DALError)
+		if dalErr.ErrorCode == data.DALErrorCodeNoneAffected {
+			c.Render(constants.StatusNotFound, nil, rw, req)
+			return
+		}
+		model := models.NewErrorResponse(constants.APIDatabaseGet, models.NewAZError(err.Error()), "Error retrieving export")
+		c.Render(constants.StatusInternalServerError, model, rw, req)
+		return
+	}
+
+	claims := make(map[string]interface{}, 1)
+	claims[c.Config.JwtClaimUserExport] = export.ID
+	jwt := helpers.JWTHelper{HashSecretBytes: c.Config.HashSecretBytes, Keys: c.Config.JwtKeyring}
+	if err := jwt.Generate(claims, c.Config.UserExportValidDuration); err != nil {
+		model := models.NewErrorResponse(constants.APIAuthTokenCreation, models.NewAZError(err.Error()), "unable to generate export token")
+		c.Render(constants.StatusInternalServerError, model, rw, req)
+		return
+	}
+	c.Render(constants.StatusOK, models.TestResetToken{Token: jwt.Token}, rw, req)
+}
+
 // UserPasswordResetTokenDelete deletes a users reset password token
 //
 //   DELETE /test/users/ResourcePasswordReset/userid
@@ -199,3 +277,67 @@ func (c *TestContext) InvitationsDelete(rw web.ResponseWriter, req *web.Request)
 	}
 	c.Render(constants.StatusNoContent, nil, rw, req)
 }
+
+// OAuthClientCreate registers an OpenID Connect client
+//
+//   POST /test/oauth_clients
+//
+// Returns
+//   201 Created (with the client secret)
+func (c *TestContext) OAuthClientCreate(rw web.ResponseWriter, req *web.Request) {
+	var registration models.OAuthClientRegistration
+	if !c.DecodeHelper(&registration, "Couldn't decode client registration", rw, req) {
+		return
+	}
+
+	client := models.OAuthClient{Name: registration.Name, RedirectURIs: registration.RedirectURIs}
+	var err error
+	if client.ClientID, client.ClientSecret, client.SecretHash, err = helpers.GenerateOAuthClientCredentials(registration.Public); err != nil {
+		model := models.NewErrorResponse(constants.APIInvalidRequest, models.NewAZError(err.Error()), "Error generating client credentials")
+		c.Render(constants.StatusInternalServerError, model, rw, req)
+		return
+	}
+	if err := c.DAL.CreateOAuthClient(&client); err != nil {
+		model := models.NewErrorResponse(constants.APIDatabaseCreate, models.NewAZError(err.Error()), "Error creating client")
+		c.Render(constants.StatusInternalServerError, model, rw, req)
+		return
+	}
+	c.Render(constants.StatusCreated, &client, rw, req)
+}
+
+// UserLoginThrottleDelete unlocks an account, like the users unlock command
+//
+//   DELETE /test/users/ResourceLoginThrottle?email=
+//
+// Returns
+//   204 No Content
+func (c *TestContext) UserLoginThrottleDelete(rw web.ResponseWriter, req *web.Request) {
+	var user models.User
+	user.Email = helpers.EmailSanitize(req.URL.Query().Get("email"))
+	if err := c.DAL.GetUserByEmail(&user); err != nil {
+		dalErr, _ := err.(data.DALError)
+		if dalErr.ErrorCode == data.DALErrorCodeNoneAffected {
+			c.Render(constants.StatusNotFound, nil, rw, req)
+			return
+		}
+		model := models.NewErrorResponse(constants.APIDatabaseGetUser, models.NewAZError(err.Error()), "Error retrieving user")
+		c.Render(constants.StatusInternalServerError, model, rw, req)
+		return
+	}
+	if err := c.DAL.ClearLoginThrottle(models.LoginThrottleAccountKey(user.ID)); err != nil {
+		model := models.NewErrorResponse(constants.APIDatabaseDelete, models.NewAZError(err.Error()), "Error clearing failed logins")
+		c.Render(constants.StatusInternalServerError, model, rw, req)
+		return
+	}
+	c.Render(constants.StatusNoContent, nil, rw, req)
+}
+
+// RateLimited does nothing, it is behind the "test" rate limit group
+//
+//   GET /test/rate-limited
+//
+// Returns
+//   204 or 429
+func (c *TestContext) RateLimited(rw web.ResponseWriter, req *web.Request) {
+	c.Render(constants.StatusNoContent, nil, rw, req)
+}
@@ -7,9 +7,9 @@ import (
 	"regexp"
 	"strings"
 
+	"github.com/axiomzen/zenauth/account"
 	"github.com/axiomzen/zenauth/constants"
 	"github.com/axiomzen/zenauth/data"
-	"github.com/axiomzen/zenauth/email"
 	"github.com/axiomzen/zenauth/helpers"
 	"github.com/axiomzen/zenauth/models"
 	"github.com/gocraft/web"
@@ -21,14 +21,21 @@ type UserContext struct {
 	*APIAuthContext
 
 	UserID string
+	// Token is the validated auth token of the request (user authenticated routes only)
+	Token *helpers.JWTokenValidateResult
 }
 
 var versionRegexp = regexp.MustCompile(`^/v[\d]+/`)
 
+// RateLimitUserID counts user rate limits by the authenticated user, empty before AuthRequired
+func (c *UserContext) RateLimitUserID() string {
+	return c.UserID
+}
+
 // AuthRequired Middleware: Authorizes a user by authenticating the Json Web Token
 func (c *UserContext) AuthRequired(w web.ResponseWriter, r *web.Request, next web.NextMiddlewareFunc) {
 
-	jwt := helpers.JWTHelper{HashSecretBytes: c.Config.HashSecretBytes, Token: r.Header.Get(c.Config.AuthTokenHeader)}
+	jwt := helpers.JWTHelper{HashSecretBytes: c.Config.HashSecretBytes, Keys: c.Config.JwtKeyring, Token: r.Header.Get(c.Config.AuthTokenHeader)}
 
 	jwtTokenResult := jwt.Validate(c.Config.JwtClaimUserID)
 	//fmt.Println("jwtTokenResult: " + jwtTokenResult.Message)
@@ -40,7 +47,24 @@ func (c *UserContext) AuthRequired(w web.ResponseWriter, r *web.Request, next we
 			c.UnauthorizedHandler(w, r)
 			return
 		}
+		// tokens only work with the API token of their tenant
+		if c.tokenTenantID(jwtTokenResult) != c.DAL.TenantID() {
+			c.UnauthorizedHandler(w, r)
+			return
+		}
+
+		revoked, err := c.DAL.IsTokenRevoked(jwtTokenResult.Value, jwtTokenResult.JTI, jwtTokenResult.IssuedAt)
+		if err != nil {
+			model := models.NewErrorResponse(constants.APIDatabaseGet, models.NewAZError(err.Error()), "Could not check auth token")
+			c.Render(constants.StatusInternalServerError, model, w, r)
+			return
+		} else if revoked {
+			model := models.NewErrorResponse(constants.APIRevokedAuthToken, models.NewAZError("Revoked auth token"), "Auth token revoked")
+			c.Render(constants.StatusUnauthorized, model, w, r)
+			return
+		}
 		c.UserID = jwtTokenResult.Value
+		c.Token = jwtTokenResult
 
 		c.Log = c.Log.WithField("userID", jwtTokenResult.Value)
 		next(w, r)
@@ -51,37 +75,22 @@ func (c *UserContext) AuthRequired(w web.ResponseWriter, r *web.Request, next we
 	}
 }
 
-// NewAuthToken creates a new auth token for a given admin id
-func (c *UserContext) NewAuthToken(ID string) (*helpers.JWTHelper, error) {
-	claims := make(map[string]interface{}, 2)
-	claims[c.Config.JwtClaimUserID] = ID
-	jwt := helpers.JWTHelper{HashSecretBytes: c.Config.HashSecretBytes}
-	err := jwt.Generate(claims, c.Config.JwtUserTokenDuration)
-	return &jwt, err
+// checkUserEnabled renders an error if an admin disabled the account, which can't get new tokens
+func (c *UserContext) checkUserEnabled(user *models.User, w web.ResponseWriter, r *web.Request) bool {
+	if err := account.CheckUserEnabled(user); err != nil {
+		c.renderAccountError(err, "Account disabled", w, r)
+		return false
+	}
+	return true
 }
 
 // renderUserResponseWithNewToken will render a UserResponse with a new token, given a user and a status
 func (c *UserContext) renderUserResponseWithNewToken(user *models.User, status constants.HTTPStatusCode, sendVerificationEmail bool, w web.ResponseWriter, r *web.Request) {
-
-	// create a new token
-
-	tokenHelper, tokenErr := c.NewAuthToken(user.ID)
-
-	if tokenErr != nil {
-		model := models.NewErrorResponse(constants.APIAuthTokenCreation, models.NewAZError(tokenErr.Error()), "Could not create auth token")
-		c.Render(constants.StatusInternalServerError, model, w, r)
+	if err := c.account(r).IssueTokens(user); err != nil {
+		c.renderAccountError(err, "Could not create auth token", w, r)
 		return
 	}
 
-	// TODO: add in JTI field (TODO: this could just be a stand alone table that we 'go' )
-	// compound primary key
-	// but again not sure what the use case is ( stolen/hacked account? )
-	// if that was the case we should be logging anytime you hit a login/auth route
-	// so we have more evidence
-
-	// render response
-	user.AuthToken = tokenHelper.Token
-
 	// special case for 201 created
 	// TODO: a more elegant way of doing this
 	if status == constants.StatusCreated {
@@ -97,45 +106,10 @@ func (c *UserContext) renderUserResponseWithNewToken(user *models.User, status c
 
 	c.Render(status, user, w, r)
 
-	// TODO
 	if sendVerificationEmail && user.Email != "" {
-		// create the user email validation email
-		// send the email
-		// TODO: do we even have the route to verify the email for the user?
-		// TODO: can/should this token have an expiry?
-		// put email in it for claims
-		emailer, err := email.Get(c.Config)
-		if err != nil {
-			model := models.NewErrorResponse(constants.APIVerifyEmailMessageError, models.NewAZError(err.Error()), "unable to generate verification email")
-			c.Render(constants.StatusInternalServerError, model, w, r)
-			return
-		}
-
-		claims := make(map[string]interface{}, 1)
-		claims[c.Config.JwtClaimUserEmail] = user.Email
-		jwt := helpers.JWTHelper{HashSecretBytes: c.Config.HashSecretBytes}
-		if err := jwt.Generate(claims, c.Config.PasswordResetValidTokenDuration); err != nil {
-			model := models.NewErrorResponse(constants.APIVerifyEmailMessageError, models.NewAZError(err.Error()), "unable to generate verification token")
-			c.Render(constants.StatusInternalServerError, model, w, r)
-			return
-		}
-		user.VerifyEmailToken = jwt.Token
-
-		msg, err := email.GetVerifyEmailMessage(c.Config, user)
-		if err != nil {
-			model := models.NewErrorResponse(constants.APIVerifyEmailMessageError, models.NewAZError(err.Error()), "unable to generate verification email")
-			c.Render(constants.StatusInternalServerError, model, w, r)
-			return
+		if err := c.account(r).SendVerificationEmail(user); err != nil {
+			c.renderAccountError(err, "unable to generate verification email", w, r)
 		}
-
-		// we don't care about email fails do we? perhaps log it
-		go func(m *email.Message) {
-			err := emailer.Send(m)
-			if err != nil {
-				c.Log.WithError(err).Warn("error sending email")
-			}
-		}(msg)
-		// 	go models.CreateUserEmailValidationToken(user.Email)
 	}
 }
 
@@ -163,44 +137,19 @@ func (c *UserContext) VerifyEmail(rw web.ResponseWriter, req *web.Request) {
 		return
 	}
 
-	// lower email
-	emailAddr := strings.ToLower(emailSlice[0])
-
-	jwt := helpers.JWTHelper{HashSecretBytes: c.Config.HashSecretBytes, Token: tokenSlice[0]}
-	jwtTokenResult := jwt.Validate(c.Config.JwtClaimUserEmail)
-
-	switch jwtTokenResult.Status {
-	case helpers.JWTokenStatusValid:
-		// verify that the emails are the same
-		if jwtTokenResult.Value == emailAddr {
-			// everything ok, set this users email to verified = true
-			// we're not going to know to look by email, so we need a custom
-			// method
-			var user models.User
-			user.Email = emailAddr
-			user.Verified = true
-			if err := c.DAL.UpdateUserVerified(&user); err != nil {
-				// render error response
-				msg := models.Message{Message: "500 - Bad Request (Database)"}
-				c.Render(constants.StatusInternalServerError, &msg, rw, req)
-				return
-			}
-			// render OK, or redirect?
-			//************TODO: decide what you want to do here (redirect or what)
-			c.Render(constants.StatusOK, &user, rw, req)
-			return
+	var user models.User
+	if err := c.account(req).VerifyEmail(tokenSlice[0], emailSlice[0], &user); err != nil {
+		var status constants.HTTPStatusCode = constants.StatusInternalServerError
+		if apiErr, ok := err.(data.APIError); ok {
+			status = apiErr.Status
 		}
-		// render error, email doesn't matches
-		msg := models.Message{Message: "400 - Email doesn't match"}
-		c.Render(constants.StatusBadRequest, &msg, rw, req)
-	case helpers.JWTokenStatusExpired:
-		// render expired
-		msg := models.Message{Message: "400 - Token Exipired"}
-		c.Render(constants.StatusBadRequest, &msg, rw, req)
-	case helpers.JWTokenStatusInvalid, helpers.JWTokenNotAvailableYet:
-		msg := models.Message{Message: "400 - Invalid Token"}
-		c.Render(constants.StatusBadRequest, &msg, rw, req)
+		msg := models.Message{Message: fmt.Sprintf("%d - %s", status, err.Error())}
+		c.Render(status, &msg, rw, req)
+		return
 	}
+	// render OK, or redirect?
+	//************TODO: decide what you want to do here (redirect or what)
+	c.Render(constants.StatusOK, &user, rw, req)
 }
 
 // ForgotPassword route
@@ -227,77 +176,29 @@ func (c *UserContext) ForgotPassword(rw web.ResponseWriter, req *web.Request) {
 		return
 	}
 
-	// generate a JWT with expiry and other claims (email) so that we don't have to check the DB on the get
-	claims := make(map[string]interface{}, 2)
 	// This is here because in the query string, + are replaced by spaces, so undoing bad encoding
 	// TODO: this is not the best way to handle. Need to think up a better solution
 	//email := strings.Replace(emailSlice[0], " ", "+", -1)
 	emailStr := strings.Replace(helpers.EmailSanitize(queryMap.Get("email")), " ", "+", -1)
 	// TODO: test email with spaces
-	claims[c.Config.JwtClaimUserEmail] = emailStr
-	jwt := helpers.JWTHelper{HashSecretBytes: c.Config.HashSecretBytes}
-	err := jwt.Generate(claims, c.Config.PasswordResetValidTokenDuration)
-
-	if err != nil {
-		model := models.NewErrorResponse(constants.APIParsingQueryParams, models.NewAZError(err.Error()), "unable to generate reset token")
-		c.Render(constants.StatusInternalServerError, model, rw, req)
+	if !c.sendResetPasswordEmail(emailStr, rw, req) {
 		return
 	}
 
-	// save the token in the database, so that it is single use only
-	// might as well save it to the users table, as when we consume it
-	// we need to update the users password, so we need to do a where condition
-	// anyways
-
-	var user models.User
-	// fmt.Printf("user email: %s\n", emailStr)
-	user.Email = emailStr
-	user.ResetToken = &jwt.Token
-	//fmt.Printf("reset token before: %s\n", user.ResetToken.String)
-
-	if err = c.DAL.CreateUserResetToken(&user); err != nil {
-		// check to see what kind of error; if its none affected just return no content anyways
-		dalErr, _ := err.(data.DALError)
-		if dalErr.ErrorCode == data.DALErrorCodeNoneAffected {
-			// Previously was hiding if users has email in our service or not
-			// For now, this seems unimportant compared to importance in UX
-			model := models.NewErrorResponse(constants.APIEmailNotFound,
-				models.NewAZError(err.Error()), "Email does not exist")
-			c.Render(constants.StatusBadRequest, model, rw, req)
-			return
-		}
-		// some other error
-		model := models.NewErrorResponse(constants.APIParsingQueryParams, models.NewAZError(err.Error()), "unable to save reset token")
-		c.Render(constants.StatusInternalServerError, model, rw, req)
-		return
-	}
-
-	// fmt.Printf("reset token after: %s\n", user.ResetToken)
-
-	// send the reset password email with the generated token
-	emailer, err := email.Get(c.Config)
-	if err != nil {
-		model := models.NewErrorResponse(constants.APIForgotPasswordMessageError, models.NewAZError(err.Error()), "unable to generate reset token email")
-		c.Render(constants.StatusInternalServerError, model, rw, req)
-		return
-	}
-	msg, err := email.GetResetPasswordMessage(c.Config, &user)
-	if err != nil {
-		model := models.NewErrorResponse(constants.APIForgotPasswordMessageError, models.NewAZError(err.Error()), "unable to generate reset token email")
-		c.Render(constants.StatusInternalServerError, model, rw, req)
-		return
-	}
-
-	go func(m *email.Message) {
-		err := emailer.Send(m)
-		if err != nil {
-			c.Log.WithError(err).Warn("error sending email")
-		}
-	}(msg)
 	// render response
 	c.Render(constants.StatusNoContent, nil, rw, req)
 }
 
+// sendResetPasswordEmail saves a single use reset token on the user with the email, and emails
+// them the reset link
+func (c *UserContext) sendResetPasswordEmail(emailStr string, rw web.ResponseWriter, req *web.Request) bool {
+	if err := c.account(req).SendPasswordReset(emailStr); err != nil {
+		c.renderAccountError(err, "unable to send reset token email", rw, req)
+		return false
+	}
+	return true
+}
+
 // ChangePasswordHTML route will validate a reset token
 // this was the decided design from timeline, but feel free
 // to alter
@@ -326,7 +227,7 @@ func (c *UserContext) ChangePasswordHTML(rw web.ResponseWriter, req *web.Request
 	// check the time on the token itself
 	// and check that there is an email associated with it
 	// and that it matches the email sent interface{}
-	jwt := helpers.JWTHelper{HashSecretBytes: c.Config.HashSecretBytes, Token: tokenSlice[0]}
+	jwt := helpers.JWTHelper{HashSecretBytes: c.Config.HashSecretBytes, Keys: c.Config.JwtKeyring, Token: tokenSlice[0]}
 	jwtTokenResult := jwt.Validate(c.Config.JwtClaimUserEmail)
 
 	switch jwtTokenResult.Status {
@@ -342,6 +243,7 @@ func (c *UserContext) ChangePasswordHTML(rw web.ResponseWriter, req *web.Request
 		// check that the token exists in the DB (to see if it is not already consumed)
 		// get user by email and check the token
 		// could also just return a bool
+		c.useTenant(c.tokenTenantID(jwtTokenResult))
 		var user models.User
 		user.Email = emailSlice[0]
 		if err := c.DAL.GetUserByEmail(&user); err != nil {
@@ -415,78 +317,23 @@ func (c *UserContext) ResetPassword(rw web.ResponseWriter, req *web.Request) {
 		return
 	}
 
-	// verify the token
-	jwt := helpers.JWTHelper{HashSecretBytes: c.Config.HashSecretBytes, Token: userPasswordReset.Token}
-	jwtTokenResult := jwt.Validate(c.Config.JwtClaimUserEmail)
-
-	switch jwtTokenResult.Status {
-	case helpers.JWTokenStatusValid:
-		// verify that the emails are the same
-		if jwtTokenResult.Value != userPasswordReset.Email {
-			// render error, email doesn't matches
-			msg := models.Message{Message: "400 - Email doesn't match"}
-			c.Render(constants.StatusBadRequest, &msg, rw, req)
-			return
-		}
-
-		if len(userPasswordReset.NewPassword) < int(c.Config.MinPasswordLength) {
-			model := models.NewErrorResponse(constants.APIValidationPasswordTooShort,
-				models.NewAZError(fmt.Sprintf("Password needs to be at least %d characters long!", c.Config.MinPasswordLength)), "Could not create account")
-			c.Render(constants.StatusBadRequest, model, rw, req)
-			return
-		}
-
-		var user models.User
-		user.ResetToken = &userPasswordReset.Token
-		user.Email = userPasswordReset.Email
-
-		newHash, hashErr := helpers.HashPasswordBcrypt(userPasswordReset.NewPassword, int(c.Config.BcryptCost))
-
-		if hashErr != nil {
-			model := models.NewErrorResponse(constants.APIParsingPasswordHash, models.NewAZError(hashErr.Error()), "Could not update user")
-			c.Render(constants.StatusInternalServerError, model, rw, req)
-			return
-		}
-
-		// set the hash
-		user.Hash = &newHash
-		//fmt.Printf("new user hash: %s\n", *user.Hash)
-
-		// this will set the token to null, and update the password hash for the user by email
-		// only if the token matches
-		if err := c.DAL.ConsumeUserResetToken(&user); err != nil {
-			dalErr, _ := err.(data.DALError)
-			// no such user/email
-			if dalErr.ErrorCode == data.DALErrorCodeNoneAffected {
-				c.NotFound(rw, req)
-				return
-			}
-
-			model := models.NewErrorResponse(constants.APIParsingPasswordHash, models.NewAZError(err.Error()), "Could not update user")
-			c.Render(constants.StatusInternalServerError, model, rw, req)
-			return
-		}
-		if userPasswordReset.Redirect != "" {
-			rw.Header().Set("Location", userPasswordReset.Redirect+"?message="+
-				url.QueryEscape("Successfully changed your password."))
-			rw.WriteHeader(constants.StatusSeeOther)
-			return
-		}
-		c.renderUserResponseWithNewToken(&user, constants.StatusOK, false, rw, req)
-
-		// TODO: localization (we need to get a string via id => it will have appropriate %s etc)
-		// TODO: does one instance have 1 file or do all instances have all files and a localization?
-
-	case helpers.JWTokenStatusExpired:
-		// render expired
-		msg := models.Message{Message: "400 - Reset Request Expired"}
-		c.Render(constants.StatusBadRequest, &msg, rw, req)
-
-	case helpers.JWTokenStatusInvalid, helpers.JWTokenNotAvailableYet:
-		msg := models.Message{Message: "400 - Invalid Token"}
-		c.Render(constants.StatusBadRequest, &msg, rw, req)
-
+	var user models.User
+	if err := c.account(req).ResetPassword(&userPasswordReset, &user); err != nil {
+		c.renderAccountError(err, "Could not reset password", rw, req)
+		return
 	}
+	// the reset token may be of another tenant than the API token
+	c.useTenant(user.TenantID)
+	if userPasswordReset.Redirect != "" {
+		rw.Header().Set("Location", userPasswordReset.Redirect+"?message="+
+			url.QueryEscape("Successfully changed your password."))
+		rw.WriteHeader(constants.StatusSeeOther)
+		return
+	}
+	c.renderUserResponseWithNewToken(&user, constants.StatusOK, false, rw, req)
+
+	// TODO: localization (we need to get a string via id => it will have appropriate %s etc)
+	// TODO: does one instance have 1 file or do all instances have all files and a localization?
 }
 
 // Exists route - expects one query param, email
@@ -507,20 +354,9 @@ func (c *UserContext) Exists(rw web.ResponseWriter, req *web.Request) {
 		c.Render(constants.StatusBadRequest, model, rw, req)
 		return
 	}
-	var user models.User
-
-	if emailOK {
-		// fixup email
-		email := strings.Replace(queryMap.Get("email"), " ", "+", -1)
-		email = strings.ToLower(strings.Trim(email, " "))
-
-		// check to see if user exists
-		user.Email = email
-	}
-	if userNameOK {
-		user.UserName = queryMap.Get("userName")
-	}
-	response.Exists = c.DAL.GetUserByEmailOrUserName(&user) == nil
+	// fixup email
+	email := strings.Replace(queryMap.Get("email"), " ", "+", -1)
+	response.Exists = c.account(req).Exists(email, queryMap.Get("userName"))
 
 	// render resposne
 	c.Render(constants.StatusOK, &response, rw, req)
@@ -630,93 +466,14 @@ func (c *UserContext) PasswordPut(rw web.ResponseWriter, req *web.Request) {
 		return
 	}
 
-	// TODO: refactor
-	// check new password
-	if len(userChangePassword.NewPassword) < int(c.Config.MinPasswordLength) {
-		model := models.NewErrorResponse(constants.APIValidationPasswordTooShort,
-			models.NewAZError(fmt.Sprintf("Password needs to be at least %d characters long!", c.Config.MinPasswordLength)), "Could not update password")
-		c.Render(constants.StatusBadRequest, model, rw, req)
-		return
-	}
-
-	// TODO: check old password
-	// we can't just have a where clause as
-	// we don't store the old password in the DB
-
-	// get the user
 	var user models.User
-	user.ID = c.UserID
-	if err := c.DAL.GetUserByID(&user); err != nil {
-		// no such user
-		dalErr, _ := err.(data.DALError)
-		if dalErr.ErrorCode == data.DALErrorCodeNoneAffected {
-			c.NotFound(rw, req)
-			return
-		}
-		model := models.NewErrorResponse(constants.APIDatabaseGetUser, models.NewAZError(err.Error()), "Could not get user")
-		c.Render(constants.StatusInternalServerError, model, rw, req)
-		return
-	}
-
-	// if we have no hash, then we have no old password
-	if helpers.IsZeroString(user.Hash) {
-		email := "NULL"
-		if user.Email != "" {
-			email = user.Email
-		}
-		model := models.NewErrorResponse(constants.APIDatabaseUpdateUser,
-			models.NewAZError("No password associated with this email: "+email), "Could not update user")
-		c.Render(constants.StatusBadRequest, model, rw, req)
-		return
-	}
-
-	// check to see if old password is correct
-	//fmt.Println("user hash before checkpassword: %s\n", *user.Hash)
-	//fmt.Println("user password before checkpassword: %s\n", userChangePassword.OldPassword)
-
-	if passwordOK, err := helpers.CheckPasswordBcrypt(*user.Hash, userChangePassword.OldPassword); err != nil {
-
-		model := models.NewErrorResponse(constants.APIDatabaseUpdateUser, models.NewAZError(err.Error()), "Could not check user password")
-		c.Render(constants.StatusInternalServerError, model, rw, req)
-		return
-	} else if !passwordOK {
-		// wrong password
-		model := models.NewErrorResponse(constants.APIDatabaseUpdateUser, models.NewAZError("Old password incorrect"), "Could not update user")
-		c.Render(constants.StatusBadRequest, model, rw, req)
+	if err := c.account(req).ChangePassword(c.UserID, userChangePassword.OldPassword, userChangePassword.NewPassword, &user); err != nil {
+		c.renderAccountError(err, "Could not update password", rw, req)
 		return
 	}
 
-	// safe to update has now
-	// generate new hash
-
-	newHash, hashErr := helpers.HashPasswordBcrypt(userChangePassword.NewPassword, int(c.Config.BcryptCost))
-
-	if hashErr != nil {
-		model := models.NewErrorResponse(constants.APIParsingPasswordHash, models.NewAZError(hashErr.Error()), "Could not generate user hash")
-		c.Render(constants.StatusInternalServerError, model, rw, req)
-		return
-	}
-
-	// attempt to update the password, may fail
-	if err := c.DAL.UpdateUserHash(newHash, &user); err != nil {
-		dalErr, _ := err.(data.DALError)
-		if dalErr.ErrorCode == data.DALErrorCodeNoneAffected {
-			c.NotFound(rw, req)
-			return
-		}
-		model := models.NewErrorResponse(constants.APIDatabaseUpdateUser, models.NewAZError(err.Error()), "Could not update user")
-		c.Render(constants.StatusInternalServerError, model, rw, req)
-		return
-	}
-
-	// everything ok
-	// get token from header
-	// TODO: we could invalidate all the old tokens here
-	// and generate a new one
-	// TODO: generate new token and invalidate old one?
-	user.AuthToken = req.Header.Get(c.Config.AuthTokenHeader)
-	// render response
-	c.Render(constants.StatusOK, user, rw, req)
+	// the old tokens were revoked along with every other session's
+	c.renderUserResponseWithNewToken(&user, constants.StatusOK, false, rw, req)
 }
 
 // EmailPut changes the email address of the user
@@ -787,12 +544,15 @@ func (c *UserContext) Login(w web.ResponseWriter, req *web.Request) {
 
 	token := req.Header.Get(c.Config.AuthTokenHeader)
 	if token != "" {
-		jwt := helpers.JWTHelper{HashSecretBytes: c.Config.HashSecretBytes, Token: token}
+		jwt := helpers.JWTHelper{HashSecretBytes: c.Config.HashSecretBytes, Keys: c.Config.JwtKeyring, Token: token}
 		jwtTokenResult := jwt.Validate(c.Config.JwtClaimUserID)
 
 		if jwtTokenResult.Status == helpers.JWTokenStatusValid {
 
-			if _, err := uuid.Parse(jwtTokenResult.Value); err == nil {
+			// revoked tokens fall through to a regular login
+			revoked, revokedErr := c.DAL.IsTokenRevoked(jwtTokenResult.Value, jwtTokenResult.JTI, jwtTokenResult.IssuedAt)
+
+			if _, err := uuid.Parse(jwtTokenResult.Value); err == nil && revokedErr == nil && !revoked {
 				c.UserID = jwtTokenResult.Value
 
 				c.Log = c.Log.WithField("userID", jwtTokenResult.Value)
@@ -843,8 +603,8 @@ func (c *UserContext) Login(w web.ResponseWriter, req *web.Request) {
 	if err != nil {
 		dalErr, _ := err.(data.DALError)
 		if dalErr.ErrorCode == data.DALErrorCodeNoneAffected {
-			model := models.NewErrorResponse(constants.APILoginSignupInvalidCombination, models.NewAZError(err.Error()), "Invalid email/username/password combination")
-			c.Render(constants.StatusUnauthorized, model, w, req)
+			// guessing emails counts against the client ip
+			c.renderAccountError(c.account(req).LoginUnknownUser(), "Invalid email/username/password combination", w, req)
 			return
 		}
 		model := models.NewErrorResponse(constants.APIDatabaseGetUser, models.NewAZError(err.Error()), "Could not get user")
@@ -861,41 +621,93 @@ func (c *UserContext) Login(w web.ResponseWriter, req *web.Request) {
 	// 	return
 	// }
 
-	// check that they have a password - not sure how they wouldn't
-	if helpers.IsZeroString(user.Hash) {
-		model := models.NewErrorResponse(constants.APILoginSignupInvalidCombination, models.NewAZError("No password associated with this email: "+user.Email), "Invalid email/username/password combination")
-		c.Render(constants.StatusBadRequest, model, w, req)
+	mfaToken, err := c.account(req).Login(&user, login.Password)
+	if err != nil {
+		c.renderAccountError(err, "Invalid email/username/password combination", w, req)
+		return
+	}
+	if mfaToken != "" {
+		c.renderMFAToken(mfaToken, w, req)
 		return
 	}
 
-	//fmt.Printf("User hash (login): %s\n", *user.Hash)
-	//fmt.Printf("User password (login): %s\n", login.Password)
+	c.renderUserResponseWithNewToken(&user, constants.StatusOK, false, w, req)
+}
 
-	if passwordOK, err := helpers.CheckPasswordBcrypt(*user.Hash, login.Password); err != nil {
+// RefreshToken exchanges a refresh token for a new auth token and refresh token.
+// Each refresh token can only be used once; presenting it again revokes
+// every token issued from the same login
+//
+//   POST /token/refresh
+//
+// Assumes format:
+//   {
+//     "refreshToken":"..."
+//   }
+//
+// Returns
+//   200 OK
+func (c *UserContext) RefreshToken(w web.ResponseWriter, req *web.Request) {
+	var refresh models.TokenRefresh
 
-		model := models.NewErrorResponse(constants.APIDatabaseUpdateUser, models.NewAZError(err.Error()), "Could not check user password")
-		c.Render(constants.StatusInternalServerError, model, w, req)
+	// decode request
+	if !c.DecodeHelper(&refresh, "Couldn't decode token refresh", w, req) {
 		return
-	} else if !passwordOK {
-		// wrong password
-		model := models.NewErrorResponse(constants.APILoginSignupInvalidCombination, models.NewAZError("Username/Password combination incorrect"), "Invalid email/username/password combination")
-		c.Render(constants.StatusUnauthorized, model, w, req)
+	}
+
+	if refresh.RefreshToken == "" {
+		model := models.NewErrorResponse(constants.APIInvalidRefreshToken, models.NewAZError("refreshToken is required"), "Missing refresh token")
+		c.Render(constants.StatusBadRequest, model, w, req)
 		return
 	}
 
-	go func(user models.User, login models.Login, c *UserContext) {
-		// Check to see if user hash meets current security standards
-		if update, newHash := helpers.UpgradeHashBcrypt(*user.Hash, login.Password, c.Config.BcryptCost, c.Config.AllowHashDowngrades); update {
+	var user models.User
+	if err := c.account(req).RefreshToken(refresh.RefreshToken, &user); err != nil {
+		c.renderAccountError(err, "Could not refresh token", w, req)
+		return
+	}
 
-			// attempt to update the password, may fail
-			if err := c.DAL.UpdateUserHash(newHash, &user); err != nil {
-				c.Log.WithError(err).WithField("code", constants.APIDatabaseUpdate).Error("Could not update user hash")
-			}
+	c.Render(constants.StatusOK, user, w, req)
+}
 
+// Logout revokes the auth token used to make the request, along with the refresh tokens
+// of the login it was issued for
+//
+//   POST /logout
+//
+// Returns
+//   204 No Content
+func (c *UserContext) Logout(rw web.ResponseWriter, req *web.Request) {
+	if err := c.DAL.RevokeToken(c.UserID, c.Token.JTI, c.Token.ExpiresAt); err != nil {
+		model := models.NewErrorResponse(constants.APIDatabaseCreate, models.NewAZError(err.Error()), "Could not revoke auth token")
+		c.Render(constants.StatusInternalServerError, model, rw, req)
+		return
+	}
+	// tokens from before families were recorded only revoke themselves
+	if familyID := c.Token.StringClaim(c.Config.JwtClaimRefreshFamily); familyID != "" {
+		if err := c.DAL.RevokeRefreshTokenFamily(familyID); err != nil {
+			model := models.NewErrorResponse(constants.APIDatabaseUpdate, models.NewAZError(err.Error()), "Could not revoke refresh tokens")
+			c.Render(constants.StatusInternalServerError, model, rw, req)
+			return
 		}
-	}(user, login, c)
+	}
+	c.Render(constants.StatusNoContent, nil, rw, req)
+}
 
-	c.renderUserResponseWithNewToken(&user, constants.StatusOK, false, w, req)
+// LogoutAll revokes every auth token issued to the user up until now, along with
+// all of their refresh tokens
+//
+//   POST /logout_all
+//
+// Returns
+//   204 No Content
+func (c *UserContext) LogoutAll(rw web.ResponseWriter, req *web.Request) {
+	if err := c.DAL.RevokeAllUserTokens(c.UserID); err != nil {
+		model := models.NewErrorResponse(constants.APIDatabaseCreate, models.NewAZError(err.Error()), "Could not revoke auth tokens")
+		c.Render(constants.StatusInternalServerError, model, rw, req)
+		return
+	}
+	c.Render(constants.StatusNoContent, nil, rw, req)
 }
 
 // Signup signs a user up via email
@@ -919,11 +731,9 @@ func (c *UserContext) Signup(w web.ResponseWriter, req *web.Request) {
 		return
 	}
 
-	//check password long enough
-	if len(signup.Password) < int(c.Config.MinPasswordLength) {
-		model := models.NewErrorResponse(constants.APIValidationPasswordTooShort,
-			models.NewAZError(fmt.Sprintf("Password needs to be at least %d characters long!", c.Config.MinPasswordLength)), "Could not create account")
-		c.Render(constants.StatusBadRequest, model, w, req)
+	// check the password against the policy
+	signupUser := models.User{UserBase: models.UserBase{Email: signup.Email, UserName: signup.UserName}}
+	if !c.checkPasswordPolicy(signup.Password, &signupUser, "Could not create account", w, req) {
 		return
 	}
 
@@ -966,7 +776,7 @@ func (c *UserContext) Signup(w web.ResponseWriter, req *web.Request) {
 		}
 	}
 
-	hash, hashErr := helpers.HashPasswordBcrypt(signup.Password, int(c.Config.BcryptCost))
+	hash, hashErr := c.Config.PasswordHasher.Hash(signup.Password)
 
 	if hashErr != nil {
 		model := models.NewErrorResponse(constants.APIParsingPasswordHash, models.NewAZError(hashErr.Error()), "Could not create new user")
@@ -976,23 +786,13 @@ func (c *UserContext) Signup(w web.ResponseWriter, req *web.Request) {
 
 	user.Hash = &hash
 
-	userErr := c.DAL.CreateUser(&user)
-
-	if userErr != nil {
-
-		dalErr, _ := userErr.(data.DALError)
-
-		if dalErr.ErrorCode == data.DALErrorCodeUniqueEmail {
-			model := models.NewErrorResponse(constants.APIEmailInUse, models.NewAZError(userErr.Error()), "Email already in use/exists")
-			c.Render(constants.StatusForbidden, model, w, req)
-			return
-		}
-
-		model := models.NewErrorResponse(constants.APIDatabaseCreateUser, models.NewAZError(userErr.Error()), "Could not create new user")
-		c.Render(constants.StatusBadRequest, model, w, req)
+	if userErr := c.DAL.CreateUser(&user); userErr != nil {
+		c.RenderDALError(userErr, constants.APIDatabaseCreateUser, "Could not create new user", w, req)
 		return
 	}
 
+	c.account(req).RecordPasswordHistory(user.ID, hash)
+
 	//********* TODO: if you want the email to be verified first, you would need
 	//********* to not give them an auth token here
 	//********* the current logic allows signup without email verification
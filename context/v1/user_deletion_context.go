@@ -0,0 +1,145 @@
+package v1
+
+import (
+	"strings"
+	"time"
+
+	"github.com/axiomzen/zenauth/constants"
+	"github.com/axiomzen/zenauth/data"
+	"github.com/axiomzen/zenauth/helpers"
+	"github.com/axiomzen/zenauth/models"
+	"github.com/gocraft/web"
+)
+
+// UserDeletionContext for users deleting their account, and restoring it during the grace period
+type UserDeletionContext struct {
+	*UserContext
+}
+
+// Delete deletes the user's account, which is purged once the grace period is over.
+// It has to be confirmed with the password, or a two factor code if two factor authentication
+// is enabled. Users with neither only need their auth token
+//
+//   DELETE /me
+//
+// Assumes format:
+//   {
+//     "password":"...",
+//     "code":"123456"
+//   }
+//
+// Returns
+//   200 OK
+func (c *UserDeletionContext) Delete(rw web.ResponseWriter, req *web.Request) {
+	var confirmation models.UserDeletion
+	// users with nothing to confirm with may send no body at all
+	if req.ContentLength != 0 && !c.DecodeHelper(&confirmation, "Couldn't decode confirmation", rw, req) {
+		return
+	}
+	var user models.User
+	user.ID = c.UserID
+	if err := c.DAL.GetUserByID(&user); err != nil {
+		dalErr, _ := err.(data.DALError)
+		if dalErr.ErrorCode == data.DALErrorCodeNoneAffected {
+			c.NotFound(rw, req)
+			return
+		}
+		model := models.NewErrorResponse(constants.APIDatabaseGetUser, models.NewAZError(err.Error()), "Could not get user")
+		c.Render(constants.StatusInternalServerError, model, rw, req)
+		return
+	}
+
+	hasPassword := !helpers.IsZeroString(user.Hash)
+	switch {
+	case confirmation.Password != "" && hasPassword:
+		if !c.checkLoginThrottle(user.ID, rw, req) {
+			return
+		}
+		if passwordOK, err := c.Config.PasswordHasher.Check(*user.Hash, confirmation.Password); err != nil {
+			model := models.NewErrorResponse(constants.APIDatabaseUpdateUser, models.NewAZError(err.Error()), "Could not check user password")
+			c.Render(constants.StatusInternalServerError, model, rw, req)
+			return
+		} else if !passwordOK {
+			c.account(req).LoginFailed(user.ID)
+			model := models.NewErrorResponse(constants.APILoginSignupInvalidCombination, models.NewAZError("Password incorrect"), "Could not delete account")
+			c.Render(constants.StatusUnauthorized, model, rw, req)
+			return
+		}
+	case confirmation.Code != "" && user.TOTPEnabled:
+		if !c.verifyMFACode(&user, confirmation.Code, rw, req) {
+			return
+		}
+	case hasPassword || user.TOTPEnabled:
+		model := models.NewErrorResponse(constants.APIConfirmationRequired, models.NewAZError("password or code expected"), "Password or two factor code required")
+		c.Render(constants.StatusUnauthorized, model, rw, req)
+		return
+	}
+
+	if err := c.DAL.SoftDeleteUser(&user); err != nil {
+		model := models.NewErrorResponse(constants.APIDatabaseDeleteUser, models.NewAZError(err.Error()), "Could not delete account")
+		c.Render(constants.StatusInternalServerError, model, rw, req)
+		return
+	}
+	c.account(req).Audit(user.ID, constants.AuditEventAccountDeleted)
+
+	// log them out everywhere, as in LogoutAll
+	if err := c.DAL.RevokeAllUserTokens(user.ID); err != nil {
+		c.Log.WithError(err).Error("Could not revoke the tokens of the deleted user")
+	}
+
+	status := models.UserDeletionStatus{DeletedAt: user.DeletedAt.Time, PurgeAt: user.DeletedAt.Time.Add(c.Config.UserDeletionGracePeriod)}
+	c.Render(constants.StatusOK, &status, rw, req)
+}
+
+// Restore cancels the deletion of an account during the grace period, logging the user in.
+// Two factor authentication is required as when logging in, the account is then only restored
+// once the code is checked at POST /login/mfa. Users without a password ask an admin to restore it
+//
+//   POST /restore
+//
+// Assumes format:
+//   {
+//     "email":"...",
+//     "password":"..."
+//   }
+//
+// Returns
+//   200 OK
+func (c *UserDeletionContext) Restore(rw web.ResponseWriter, req *web.Request) {
+	var login models.Login
+	if !c.DecodeHelper(&login, "Couldn't decode login", rw, req) {
+		return
+	}
+
+	since := time.Now().Add(-c.Config.UserDeletionGracePeriod)
+	var user models.User
+	user.Email = strings.ToLower(strings.Trim(login.Email, " "))
+	if err := c.DAL.GetDeletedUserByEmail(&user, since); err != nil {
+		dalErr, _ := err.(data.DALError)
+		if dalErr.ErrorCode == data.DALErrorCodeNoneAffected {
+			c.renderAccountError(c.account(req).LoginUnknownUser(), "Invalid email/password combination", rw, req)
+			return
+		}
+		model := models.NewErrorResponse(constants.APIDatabaseGetUser, models.NewAZError(err.Error()), "Could not get user")
+		c.Render(constants.StatusInternalServerError, model, rw, req)
+		return
+	}
+
+	mfaToken, err := c.account(req).LoginToRestore(&user, login.Password)
+	if err != nil {
+		c.renderAccountError(err, "Invalid email/password combination", rw, req)
+		return
+	}
+	c.UserID = user.ID
+	c.Log = c.Log.WithField("userID", c.UserID)
+
+	if mfaToken != "" {
+		c.renderMFAToken(mfaToken, rw, req)
+		return
+	}
+	if err := c.account(req).RestoreUser(&user); err != nil {
+		c.renderAccountError(err, "Could not restore account", rw, req)
+		return
+	}
+	c.renderUserResponseWithNewToken(&user, constants.StatusOK, false, rw, req)
+}
@@ -0,0 +1,158 @@
+package v1
+
+import (
+	"archive/zip"
+	"time"
+
+	"github.com/axiomzen/zenauth/constants"
+	"github.com/axiomzen/zenauth/data"
+	"github.com/axiomzen/zenauth/email"
+	"github.com/axiomzen/zenauth/helpers"
+	"github.com/axiomzen/zenauth/models"
+	"github.com/gocraft/web"
+)
+
+// UserExportContext for exports of everything we hold about a user
+type UserExportContext struct {
+	*UserContext
+}
+
+// Request starts an export of the user's data, a download link is emailed once it is ready
+//
+//   GET /users/me/export
+//
+// Returns
+//   202 Accepted
+func (c *UserExportContext) Request(rw web.ResponseWriter, req *web.Request) {
+	var user models.User
+	user.ID = c.UserID
+	if err := c.DAL.GetUserByID(&user); err != nil {
+		dalErr, _ := err.(data.DALError)
+		if dalErr.ErrorCode == data.DALErrorCodeNoneAffected {
+			c.NotFound(rw, req)
+			return
+		}
+		model := models.NewErrorResponse(constants.APIDatabaseGetUser, models.NewAZError(err.Error()), "Could not get user")
+		c.Render(constants.StatusInternalServerError, model, rw, req)
+		return
+	}
+	if !c.startUserExport(&user, rw, req) {
+		return
+	}
+	c.Render(constants.StatusAccepted, nil, rw, req)
+}
+
+// startUserExport builds the export of the user in the background and emails them the link.
+// Returns false if it rendered an error instead, when the user has no email to send the link to
+func (c *APIAuthContext) startUserExport(user *models.User, rw web.ResponseWriter, req *web.Request) bool {
+	if user.Email == "" {
+		model := models.NewErrorResponse(constants.APIEmailNotFound, models.NewAZError("user has no email"), "An email address is needed to send the export to")
+		c.Render(constants.StatusBadRequest, model, rw, req)
+		return false
+	}
+	log := c.Log.WithField("userID", user.ID)
+	go func(user models.User) {
+		export, err := data.ExportUser(c.DAL, &user, time.Now().Add(c.Config.UserExportValidDuration))
+		if err != nil {
+			log.WithError(err).Error("User export failed")
+			return
+		}
+		claims := make(map[string]interface{}, 1)
+		claims[c.Config.JwtClaimUserExport] = export.ID
+		jwt := helpers.JWTHelper{HashSecretBytes: c.Config.HashSecretBytes, Keys: c.Config.JwtKeyring}
+		if err := jwt.Generate(claims, c.Config.UserExportValidDuration); err != nil {
+			log.WithError(err).Error("Could not generate user export token")
+			return
+		}
+		emailer, err := email.Get(c.Config)
+		if err != nil {
+			log.WithError(err).Error("Could not get emailer")
+			return
+		}
+		msg, err := email.GetUserExportMessage(c.Config, &user, jwt.Token)
+		if err != nil {
+			log.WithError(err).Error("Could not generate user export email")
+			return
+		}
+		if err := emailer.Send(msg); err != nil {
+			log.WithError(err).Warn("error sending email")
+		}
+	}(*user)
+	return true
+}
+
+// Download sends an export, the token is the one of the emailed link.
+// Exports are json, or a zip file with the json in it when the format is zip
+//
+//   GET /users/export/download?token=...&format=zip
+//
+// Returns
+//   200 OK
+func (c *UserExportContext) Download(rw web.ResponseWriter, req *web.Request) {
+	// the link is opened by a browser, errors are rendered as json whatever it accepts
+	req.Header.Set("Content-Type", c.Config.DefaultContentType)
+
+	query := req.URL.Query()
+	jwt := helpers.JWTHelper{HashSecretBytes: c.Config.HashSecretBytes, Keys: c.Config.JwtKeyring, Token: query.Get("token")}
+	jwtTokenResult := jwt.Validate(c.Config.JwtClaimUserExport)
+	switch jwtTokenResult.Status {
+	case helpers.JWTokenStatusValid:
+	case helpers.JWTokenStatusExpired:
+		model := models.NewErrorResponse(constants.APIInvalidUserExportToken, models.NewAZError("token expired"), "Download link expired")
+		c.Render(constants.StatusUnauthorized, model, rw, req)
+		return
+	default:
+		model := models.NewErrorResponse(constants.APIInvalidUserExportToken, models.NewAZError("invalid token"), "Invalid download link")
+		c.Render(constants.StatusUnauthorized, model, rw, req)
+		return
+	}
+
+	format := query.Get("format")
+	if format == "" {
+		format = constants.UserExportFormatJSON
+	}
+	if format != constants.UserExportFormatJSON && format != constants.UserExportFormatZip {
+		model := models.NewErrorResponse(constants.APIParsingQueryParams, models.NewAZError("unknown format "+format), "Format must be json or zip")
+		c.Render(constants.StatusBadRequest, model, rw, req)
+		return
+	}
+
+	var export models.UserExport
+	export.ID = jwtTokenResult.Value
+	if err := c.DAL.GetUserExport(&export); err != nil {
+		dalErr, _ := err.(data.DALError)
+		if dalErr.ErrorCode == data.DALErrorCodeNoneAffected {
+			model := models.NewErrorResponse(constants.APIInvalidUserExportToken, models.NewAZError(err.Error()), "Export expired")
+			c.Render(constants.StatusNotFound, model, rw, req)
+			return
+		}
+		model := models.NewErrorResponse(constants.APIDatabaseGet, models.NewAZError(err.Error()), "Could not get export")
+		c.Render(constants.StatusInternalServerError, model, rw, req)
+		return
+	}
+
+	filename := "export-" + export.CreatedAt.Time.UTC().Format("20060102")
+	if format == constants.UserExportFormatJSON {
+		rw.Header().Set("Content-Type", "application/json")
+		rw.Header().Set("Content-Disposition", `attachment; filename="`+filename+`.json"`)
+		rw.WriteHeader(int(constants.StatusOK))
+		if _, err := rw.Write(export.Data); err != nil {
+			c.Log.WithError(err).Warn("error writing export")
+		}
+		return
+	}
+	rw.Header().Set("Content-Type", "application/zip")
+	rw.Header().Set("Content-Disposition", `attachment; filename="`+filename+`.zip"`)
+	rw.WriteHeader(int(constants.StatusOK))
+	archive := zip.NewWriter(rw)
+	file, err := archive.CreateHeader(&zip.FileHeader{Name: "export.json", Method: zip.Deflate, Modified: export.CreatedAt.Time})
+	if err == nil {
+		_, err = file.Write(export.Data)
+	}
+	if err == nil {
+		err = archive.Close()
+	}
+	if err != nil {
+		c.Log.WithError(err).Warn("error writing export")
+	}
+}
@@ -0,0 +1,429 @@
+package v1
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+
+	"github.com/axiomzen/zenauth/constants"
+	"github.com/axiomzen/zenauth/data"
+	"github.com/axiomzen/zenauth/helpers"
+	"github.com/axiomzen/zenauth/models"
+	"github.com/gocraft/web"
+)
+
+const (
+	webAuthnCredentialType = "public-key"
+)
+
+// WebAuthnContext for passkey registration and login
+type WebAuthnContext struct {
+	*UserContext
+}
+
+func (c *WebAuthnContext) relyingParty() *helpers.WebAuthnRelyingParty {
+	return &helpers.WebAuthnRelyingParty{
+		ID:                      c.Config.WebAuthnRPID,
+		Origins:                 c.Config.WebAuthnOrigins,
+		RequireUserVerification: c.Config.WebAuthnRequireUserVerification,
+	}
+}
+
+func (c *WebAuthnContext) userVerification() string {
+	if c.Config.WebAuthnRequireUserVerification {
+		return "required"
+	}
+	return "preferred"
+}
+
+// newChallenge generates a challenge along with the signed token the client sends back with its response,
+// renders the error if it couldn't
+func (c *WebAuthnContext) newChallenge(rw web.ResponseWriter, req *web.Request) (challenge, token string, ok bool) {
+	challenge, err := helpers.NewWebAuthnChallenge()
+	if err == nil {
+		claims := make(map[string]interface{}, 1)
+		claims[c.Config.JwtClaimWebAuthnChallenge] = challenge
+		jwt := helpers.JWTHelper{HashSecretBytes: c.Config.HashSecretBytes, Keys: c.Config.JwtKeyring}
+		if err = jwt.Generate(claims, c.Config.WebAuthnChallengeDuration); err == nil {
+			return challenge, jwt.Token, true
+		}
+	}
+	model := models.NewErrorResponse(constants.APIAuthTokenCreation, models.NewAZError(err.Error()), "Could not create challenge")
+	c.Render(constants.StatusInternalServerError, model, rw, req)
+	return "", "", false
+}
+
+// validateChallenge validates a challenge token, rendering the error if it isn't valid
+func (c *WebAuthnContext) validateChallenge(token string, rw web.ResponseWriter, req *web.Request) (*helpers.JWTokenValidateResult, bool) {
+	jwt := helpers.JWTHelper{HashSecretBytes: c.Config.HashSecretBytes, Keys: c.Config.JwtKeyring, Token: token}
+	result := jwt.Validate(c.Config.JwtClaimWebAuthnChallenge)
+	switch result.Status {
+	case helpers.JWTokenStatusValid:
+		return result, true
+	case helpers.JWTokenStatusExpired:
+		c.ExpiredHandler(rw, req)
+		return nil, false
+	}
+	model := models.NewErrorResponse(constants.APIInvalidWebAuthnChallenge, models.NewAZError(result.Message), "Invalid challenge")
+	c.Render(constants.StatusUnauthorized, model, rw, req)
+	return nil, false
+}
+
+// consumeChallenge revokes a challenge token once the user it was used for is known,
+// so each challenge can only be answered once
+func (c *WebAuthnContext) consumeChallenge(userID string, result *helpers.JWTokenValidateResult, rw web.ResponseWriter, req *web.Request) bool {
+	revoked, err := c.DAL.IsTokenRevoked(userID, result.JTI, result.IssuedAt)
+	if err == nil && !revoked {
+		err = c.DAL.RevokeToken(userID, result.JTI, result.ExpiresAt)
+	}
+	if err != nil {
+		model := models.NewErrorResponse(constants.APIDatabaseGet, models.NewAZError(err.Error()), "Could not check challenge")
+		c.Render(constants.StatusInternalServerError, model, rw, req)
+		return false
+	}
+	if revoked {
+		model := models.NewErrorResponse(constants.APIInvalidWebAuthnChallenge, models.NewAZError("challenge already used"), "Invalid challenge")
+		c.Render(constants.StatusUnauthorized, model, rw, req)
+		return false
+	}
+	return true
+}
+
+// credentialDescriptors lists the user's credentials for the options of a ceremony,
+// renders the error if they could not be retrieved
+func (c *WebAuthnContext) credentialDescriptors(userID string, rw web.ResponseWriter, req *web.Request) ([]models.WebAuthnCredentialDescriptor, bool) {
+	var credentials models.WebAuthnCredentials
+	if err := c.DAL.GetUserWebAuthnCredentials(userID, &credentials); err != nil {
+		model := models.NewErrorResponse(constants.APIDatabaseGet, models.NewAZError(err.Error()), "Could not get credentials")
+		c.Render(constants.StatusInternalServerError, model, rw, req)
+		return nil, false
+	}
+	descriptors := make([]models.WebAuthnCredentialDescriptor, len(credentials))
+	for i, credential := range credentials {
+		descriptors[i] = models.WebAuthnCredentialDescriptor{Type: webAuthnCredentialType, ID: credential.CredentialID, Transports: credential.Transports}
+	}
+	return descriptors, true
+}
+
+// fakeCredentialDescriptors stands in for the passkeys of an email without any, so the emails of
+// users with passkeys can't be told apart from the others. The same email always gets the same one
+func (c *WebAuthnContext) fakeCredentialDescriptors(email string) []models.WebAuthnCredentialDescriptor {
+	mac := hmac.New(sha256.New, c.Config.HashSecretBytes)
+	mac.Write([]byte("webauthn credential " + email))
+	id := base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+	return []models.WebAuthnCredentialDescriptor{{Type: webAuthnCredentialType, ID: id}}
+}
+
+// decodeBase64URL decodes the binary fields of WebAuthn responses, with or without padding
+func decodeBase64URL(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
+
+// RegisterBegin starts registering a passkey for the user
+//
+//   POST /webauthn/register/begin
+//
+// Returns
+//   200 OK
+func (c *WebAuthnContext) RegisterBegin(rw web.ResponseWriter, req *web.Request) {
+	var user models.User
+	user.ID = c.UserID
+	if err := c.DAL.GetUserByID(&user); err != nil {
+		model := models.NewErrorResponse(constants.APIDatabaseGetUser, models.NewAZError(err.Error()), "Could not get user")
+		c.Render(constants.StatusInternalServerError, model, rw, req)
+		return
+	}
+	existing, ok := c.credentialDescriptors(user.ID, rw, req)
+	if !ok {
+		return
+	}
+	challenge, token, ok := c.newChallenge(rw, req)
+	if !ok {
+		return
+	}
+
+	name, displayName := user.Email, user.UserName
+	if name == "" {
+		name = user.UserName
+	}
+	if displayName == "" {
+		displayName = name
+	}
+	rpName := c.Config.WebAuthnRPName
+	if rpName == "" {
+		rpName = c.Config.AppName
+	}
+	params := make([]models.WebAuthnCredentialParameter, len(helpers.WebAuthnAlgorithms))
+	for i, alg := range helpers.WebAuthnAlgorithms {
+		params[i] = models.WebAuthnCredentialParameter{Type: webAuthnCredentialType, Alg: alg}
+	}
+
+	c.Render(constants.StatusOK, &models.WebAuthnRegistrationChallenge{
+		ChallengeToken: token,
+		PublicKey: models.WebAuthnCreationOptions{
+			Challenge: challenge,
+			RP:        models.WebAuthnRelyingPartyEntity{ID: c.Config.WebAuthnRPID, Name: rpName},
+			User: models.WebAuthnUserEntity{
+				ID:          base64.RawURLEncoding.EncodeToString([]byte(user.ID)),
+				Name:        name,
+				DisplayName: displayName,
+			},
+			PubKeyCredParams:   params,
+			Timeout:            int64(c.Config.WebAuthnChallengeDuration.Seconds() * 1000),
+			ExcludeCredentials: existing,
+			AuthenticatorSelection: models.WebAuthnAuthenticatorSelection{
+				ResidentKey:      "preferred",
+				UserVerification: c.userVerification(),
+			},
+			Attestation: "none",
+		},
+	}, rw, req)
+}
+
+// RegisterFinish verifies and stores the new credential
+//
+//   POST /webauthn/register/finish
+//
+// Assumes format:
+//   {
+//     "challengeToken":"...",
+//     "name":"My phone",
+//     "id":"...",
+//     "response":{
+//       "clientDataJSON":"...",
+//       "attestationObject":"...",
+//       "transports":["internal"]
+//     }
+//   }
+//
+// Returns
+//   201 Created
+func (c *WebAuthnContext) RegisterFinish(rw web.ResponseWriter, req *web.Request) {
+	var registration models.WebAuthnRegistration
+	if !c.DecodeHelper(&registration, "Couldn't decode credential", rw, req) {
+		return
+	}
+	challenge, ok := c.validateChallenge(registration.ChallengeToken, rw, req)
+	if !ok || !c.consumeChallenge(c.UserID, challenge, rw, req) {
+		return
+	}
+
+	clientData, err := decodeBase64URL(registration.Response.ClientDataJSON)
+	if err != nil {
+		model := models.NewErrorResponse(constants.APIParsing, models.NewAZError(err.Error()), "Couldn't decode client data")
+		c.Render(constants.StatusBadRequest, model, rw, req)
+		return
+	}
+	attestationObject, err := decodeBase64URL(registration.Response.AttestationObject)
+	if err != nil {
+		model := models.NewErrorResponse(constants.APIParsing, models.NewAZError(err.Error()), "Couldn't decode attestation object")
+		c.Render(constants.StatusBadRequest, model, rw, req)
+		return
+	}
+	verified, err := c.relyingParty().VerifyRegistration(challenge.Value, clientData, attestationObject)
+	if err != nil {
+		model := models.NewErrorResponse(constants.APIWebAuthnInvalid, models.NewAZError(err.Error()), "Could not verify credential")
+		c.Render(constants.StatusBadRequest, model, rw, req)
+		return
+	}
+
+	credential := models.WebAuthnCredential{
+		UserID:       c.UserID,
+		CredentialID: base64.RawURLEncoding.EncodeToString(verified.ID),
+		PublicKey:    verified.PublicKey,
+		SignCount:    int64(verified.SignCount),
+		Transports:   registration.Response.Transports,
+		Name:         registration.Name,
+	}
+	if err := c.DAL.CreateWebAuthnCredential(&credential); err != nil {
+		c.RenderDALError(err, constants.APIDatabaseCreate, "Could not save credential", rw, req)
+		return
+	}
+	c.Render(constants.StatusCreated, &credential, rw, req)
+}
+
+// Credentials lists the user's credentials
+//
+//   GET /webauthn/credentials
+//
+// Returns
+//   200 OK
+func (c *WebAuthnContext) Credentials(rw web.ResponseWriter, req *web.Request) {
+	credentials := models.WebAuthnCredentials{}
+	if err := c.DAL.GetUserWebAuthnCredentials(c.UserID, &credentials); err != nil {
+		model := models.NewErrorResponse(constants.APIDatabaseGet, models.NewAZError(err.Error()), "Could not get credentials")
+		c.Render(constants.StatusInternalServerError, model, rw, req)
+		return
+	}
+	c.Render(constants.StatusOK, &credentials, rw, req)
+}
+
+// DeleteCredential removes one of the user's credentials
+//
+//   DELETE /webauthn/credentials/:id
+//
+// Returns
+//   204 No Content
+func (c *WebAuthnContext) DeleteCredential(rw web.ResponseWriter, req *web.Request) {
+	credential := models.WebAuthnCredential{ID: req.PathParams["id"], UserID: c.UserID}
+	if err := c.DAL.DeleteWebAuthnCredential(&credential); err != nil {
+		if dalErr, isDALError := err.(data.DALError); isDALError && dalErr.ErrorCode == data.DALErrorCodeNoneAffected {
+			model := models.NewErrorResponse(constants.APINotFound, models.NewAZError(err.Error()), "Credential not found")
+			c.Render(constants.StatusNotFound, model, rw, req)
+			return
+		}
+		model := models.NewErrorResponse(constants.APIDatabaseDelete, models.NewAZError(err.Error()), "Could not delete credential")
+		c.Render(constants.StatusInternalServerError, model, rw, req)
+		return
+	}
+	c.Render(constants.StatusNoContent, nil, rw, req)
+}
+
+// LoginBegin starts logging in with a passkey. Without an email any discoverable credential can be used.
+// Unknown emails, and users without passkeys, are allowed a made up credential
+//
+//   POST /webauthn/login/begin
+//
+// Assumes format:
+//   {
+//     "email":"user@email.com"
+//   }
+//
+// Returns
+//   200 OK
+func (c *WebAuthnContext) LoginBegin(rw web.ResponseWriter, req *web.Request) {
+	var begin models.WebAuthnLoginBegin
+	if !c.DecodeHelper(&begin, "Couldn't decode login", rw, req) {
+		return
+	}
+	allowed := []models.WebAuthnCredentialDescriptor{}
+	if begin.Email != "" {
+		var user models.User
+		user.Email = helpers.EmailSanitize(begin.Email)
+		err := c.DAL.GetUserByEmail(&user)
+		if err == nil {
+			var ok bool
+			if allowed, ok = c.credentialDescriptors(user.ID, rw, req); !ok {
+				return
+			}
+		} else if dalErr, _ := err.(data.DALError); dalErr.ErrorCode != data.DALErrorCodeNoneAffected {
+			model := models.NewErrorResponse(constants.APIDatabaseGetUser, models.NewAZError(err.Error()), "Could not get user")
+			c.Render(constants.StatusInternalServerError, model, rw, req)
+			return
+		}
+		if len(allowed) == 0 {
+			allowed = c.fakeCredentialDescriptors(user.Email)
+		}
+	}
+	challenge, token, ok := c.newChallenge(rw, req)
+	if !ok {
+		return
+	}
+	c.Render(constants.StatusOK, &models.WebAuthnLoginChallenge{
+		ChallengeToken: token,
+		PublicKey: models.WebAuthnRequestOptions{
+			Challenge:        challenge,
+			RPID:             c.Config.WebAuthnRPID,
+			Timeout:          int64(c.Config.WebAuthnChallengeDuration.Seconds() * 1000),
+			AllowCredentials: allowed,
+			UserVerification: c.userVerification(),
+		},
+	}, rw, req)
+}
+
+// LoginFinish verifies the assertion and logs the user in
+//
+//   POST /webauthn/login/finish
+//
+// Assumes format:
+//   {
+//     "challengeToken":"...",
+//     "id":"...",
+//     "response":{
+//       "clientDataJSON":"...",
+//       "authenticatorData":"...",
+//       "signature":"...",
+//       "userHandle":"..."
+//     }
+//   }
+//
+// Returns
+//   200 OK
+func (c *WebAuthnContext) LoginFinish(rw web.ResponseWriter, req *web.Request) {
+	var login models.WebAuthnLogin
+	if !c.DecodeHelper(&login, "Couldn't decode login", rw, req) {
+		return
+	}
+	challenge, ok := c.validateChallenge(login.ChallengeToken, rw, req)
+	if !ok {
+		return
+	}
+
+	invalid := func(err error) {
+		model := models.NewErrorResponse(constants.APIWebAuthnInvalid, models.NewAZError(err.Error()), "Could not verify credential")
+		c.Render(constants.StatusUnauthorized, model, rw, req)
+	}
+
+	credential := models.WebAuthnCredential{CredentialID: strings.TrimRight(login.ID, "=")}
+	if err := c.DAL.GetWebAuthnCredential(&credential); err != nil {
+		if dalErr, _ := err.(data.DALError); dalErr.ErrorCode == data.DALErrorCodeNoneAffected {
+			invalid(err)
+			return
+		}
+		model := models.NewErrorResponse(constants.APIDatabaseGet, models.NewAZError(err.Error()), "Could not get credential")
+		c.Render(constants.StatusInternalServerError, model, rw, req)
+		return
+	}
+	if login.Response.UserHandle != "" {
+		if userHandle, err := decodeBase64URL(login.Response.UserHandle); err != nil || string(userHandle) != credential.UserID {
+			invalid(models.NewAZError("user handle mismatch"))
+			return
+		}
+	}
+	if !c.consumeChallenge(credential.UserID, challenge, rw, req) {
+		return
+	}
+
+	clientData, err := decodeBase64URL(login.Response.ClientDataJSON)
+	if err != nil {
+		invalid(err)
+		return
+	}
+	authData, err := decodeBase64URL(login.Response.AuthenticatorData)
+	if err != nil {
+		invalid(err)
+		return
+	}
+	signature, err := decodeBase64URL(login.Response.Signature)
+	if err != nil {
+		invalid(err)
+		return
+	}
+	signCount, err := c.relyingParty().VerifyAssertion(challenge.Value, credential.PublicKey, uint32(credential.SignCount), clientData, authData, signature)
+	if err != nil {
+		c.Log.WithError(err).WithField("credentialID", credential.ID).Warn("webauthn assertion failed")
+		invalid(err)
+		return
+	}
+	if err := c.DAL.UpdateWebAuthnCredentialSignCount(&credential, int64(signCount)); err != nil {
+		if dalErr, _ := err.(data.DALError); dalErr.ErrorCode == data.DALErrorCodeNoneAffected {
+			invalid(err)
+			return
+		}
+		model := models.NewErrorResponse(constants.APIDatabaseUpdate, models.NewAZError(err.Error()), "Could not update credential")
+		c.Render(constants.StatusInternalServerError, model, rw, req)
+		return
+	}
+
+	c.UserID = credential.UserID
+	c.Log = c.Log.WithField("userID", c.UserID)
+	var user models.User
+	user.ID = c.UserID
+	if err := c.DAL.GetUserByID(&user); err != nil {
+		model := models.NewErrorResponse(constants.APIDatabaseGetUser, models.NewAZError(err.Error()), "Could not get user")
+		c.Render(constants.StatusInternalServerError, model, rw, req)
+		return
+	}
+	c.renderUserResponseWithNewToken(&user, constants.StatusOK, false, rw, req)
+}
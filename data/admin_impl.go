@@ -0,0 +1,70 @@
+package data
+
+import (
+	"strings"
+
+	"github.com/axiomzen/zenauth/models"
+)
+
+// likeEscaper escapes the wildcards of a LIKE pattern
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// SearchUsers gets a page of the users matching the search, in creation order. Deleted users are left out
+func (dp *dataProvider) SearchUsers(search *models.UserSearch, users *models.Users) error {
+	q := dp.db.Model(users).Where("deleted_at IS NULL").Where("tenant_id = ?", dp.tenantID)
+	if search.EmailPrefix != "" {
+		q = q.Where("email LIKE ?", likeEscaper.Replace(strings.ToLower(search.EmailPrefix))+"%")
+	}
+	if search.UserName != "" {
+		q = q.Where("user_name = ?", search.UserName)
+	}
+	if search.FacebookID != "" {
+		q = q.Where("facebook_id = ?", search.FacebookID)
+	}
+	if search.CreatedAfter.Valid {
+		q = q.Where("created_at >= ?", search.CreatedAfter.Time)
+	}
+	if search.CreatedBefore.Valid {
+		q = q.Where("created_at < ?", search.CreatedBefore.Time)
+	}
+	if search.Verified.Valid {
+		q = q.Where("verified = ?", search.Verified.Bool)
+	}
+	if search.Disabled.Valid {
+		if search.Disabled.Bool {
+			q = q.Where("disabled_at IS NOT NULL")
+		} else {
+			q = q.Where("disabled_at IS NULL")
+		}
+	}
+	if search.Cursor != nil {
+		q = q.Where("(created_at, id) > (?, ?)", search.Cursor.CreatedAt, search.Cursor.ID)
+	}
+	return wrapError(q.Order("created_at ASC").Order("id ASC").Limit(search.Limit).Select())
+}
+
+// SetUserDisabled disables the user, or enables them again
+func (dp *dataProvider) SetUserDisabled(user *models.User, disabled bool) error {
+	set := "disabled_at = NULL"
+	if disabled {
+		set = "disabled_at = now()"
+	}
+	res, err := dp.db.Model(user).Set(set).Where("id = ?id").Where("deleted_at IS NULL").Where("tenant_id = ?", dp.tenantID).Returning("*").Update()
+	if err == nil {
+		if res.Affected() != 1 {
+			return DALError{Inner: errNoneAffected, ErrorCode: DALErrorCodeNoneAffected}
+		}
+	}
+	return wrapError(err)
+}
+
+// SetUserHash sets the password of a user, whatever it was before
+func (dp *dataProvider) SetUserHash(newHash string, user *models.User) error {
+	res, err := dp.db.Model(user).Set("hash = ?", newHash).Where("id = ?id").Where("deleted_at IS NULL").Where("tenant_id = ?", dp.tenantID).Returning("*").Update()
+	if err == nil {
+		if res.Affected() != 1 {
+			return DALError{Inner: errNoneAffected, ErrorCode: DALErrorCodeNoneAffected}
+		}
+	}
+	return wrapError(err)
+}
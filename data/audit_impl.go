@@ -0,0 +1,10 @@
+package data
+
+import (
+	"github.com/axiomzen/zenauth/models"
+)
+
+// CreateAuditEntry records an event in the audit log
+func (dp *dataProvider) CreateAuditEntry(entry *models.AuditEntry) error {
+	return wrapError(dp.db.Create(entry))
+}
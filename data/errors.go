@@ -2,6 +2,8 @@ package data
 
 import (
 	"fmt"
+
+	"github.com/axiomzen/zenauth/constants"
 )
 
 // DALErrorCode the error code for the access layer errors
@@ -18,6 +20,16 @@ const (
 	DALErrorCodeFacebookIDUnique
 	// DALErrorCodeUniqueUsername returned when the username already exists
 	DALErrorCodeUniqueUsername
+	// DALErrorCodeTokenExpired returned when a stored token has expired
+	DALErrorCodeTokenExpired
+	// DALErrorCodeTokenReused returned when a single use token is used twice
+	DALErrorCodeTokenReused
+	// DALErrorCodeUniqueCredential returned when a webauthn credential is already registered
+	DALErrorCodeUniqueCredential
+	// DALErrorCodeUniqueIdentity returned when an identity provider account is linked to another user
+	DALErrorCodeUniqueIdentity
+	// DALErrorCodeUniqueTenantName returned when another tenant has the name
+	DALErrorCodeUniqueTenantName
 )
 
 // DALError The error from the data access layer
@@ -39,3 +51,41 @@ func (e DALError) Error() string {
 
 	return fmt.Sprintf("Unknown Error: %d", e.ErrorCode)
 }
+
+// APIError is how a DAL error, or a failed account operation, is reported, the same on the REST
+// and gRPC APIs
+type APIError struct {
+	Code    constants.APIErrorCode
+	Status  constants.HTTPStatusCode
+	Message string
+}
+
+// Error returns the message of the API error
+func (e APIError) Error() string {
+	return e.Message
+}
+
+// ToAPIError maps the DAL errors clients can act on, like a taken email, to their API error. Any
+// other error is reported with code and msg as an internal server error
+func ToAPIError(err error, code constants.APIErrorCode, msg string) APIError {
+	dalErr, _ := err.(DALError)
+	switch dalErr.ErrorCode {
+	case DALErrorCodeNoneAffected:
+		return APIError{constants.APINotFound, constants.StatusNotFound, "not found"}
+	case DALErrorCodeUniqueEmail:
+		return APIError{constants.APIEmailInUse, constants.StatusForbidden, "Email already in use/exists"}
+	case DALErrorCodeFacebookIDUnique, DALErrorCodeUniqueIdentity:
+		return APIError{constants.APISocialAccountExists, constants.StatusForbidden, "Social account already exists"}
+	case DALErrorCodeUniqueUsername:
+		return APIError{constants.APIUserNameInUse, constants.StatusForbidden, "Username already in use"}
+	case DALErrorCodeUniqueCredential:
+		return APIError{constants.APIWebAuthnCredentialExists, constants.StatusForbidden, "Credential already registered"}
+	case DALErrorCodeUniqueTenantName:
+		return APIError{constants.APIParsingTenant, constants.StatusBadRequest, "Tenant name taken"}
+	case DALErrorCodeTokenExpired:
+		return APIError{constants.APIExpiredRefreshToken, constants.StatusExpiredToken, "Refresh token expired"}
+	case DALErrorCodeTokenReused:
+		return APIError{constants.APIRefreshTokenReused, constants.StatusUnauthorized, "Refresh token already used"}
+	}
+	return APIError{code, constants.StatusInternalServerError, msg}
+}
@@ -6,6 +6,7 @@ package data
 import (
 	"errors"
 	"github.com/axiomzen/zenauth/config"
+	"github.com/axiomzen/zenauth/constants"
 	"sync"
 
 	"gopkg.in/pg.v4"
@@ -21,7 +22,7 @@ func CreateProvider(conf *config.ZENAUTHConfig) (ZENAUTHProvider, error) {
 	var err = errors.New("temp")
 	var numtries uint16
 
-	var provider ZENAUTHProvider
+	var provider *dataProvider
 	for err != nil && numtries < conf.PostgreSQLRetryNumTimes {
 		pgdb := pg.Connect(&pg.Options{
 			Addr:     conf.PostgreSQLHost + ":" + strconv.FormatUint(uint64(conf.PostgreSQLPort), 10),
@@ -30,7 +31,12 @@ func CreateProvider(conf *config.ZENAUTHConfig) (ZENAUTHProvider, error) {
 			Database: conf.PostgreSQLDatabase,
 			SSL:      *conf.PostgreSQLSSL,
 		})
-		provider = &dataProvider{db: pgdb}
+		provider = &dataProvider{
+			db:                pgdb,
+			revocations:       newRevocationCache(conf.RevocationCacheDuration),
+			authTokenDuration: conf.JwtUserTokenDuration,
+			tenantID:          constants.DefaultTenantID,
+		}
 		err = provider.Ping()
 		if err != nil {
 			log.WithFields(log.Fields{
@@ -47,6 +53,9 @@ func CreateProvider(conf *config.ZENAUTHConfig) (ZENAUTHProvider, error) {
 		}
 		numtries++
 	}
+	if err == nil && conf.RevocationCacheDuration > 0 {
+		go provider.listenForRevocations()
+	}
 	return provider, err
 
 }
@@ -0,0 +1,44 @@
+package data
+
+import (
+	"github.com/axiomzen/zenauth/models"
+	"gopkg.in/pg.v4"
+)
+
+// GetUserBySocialIdentity gets the user an identity provider account is linked to
+func (dp *dataProvider) GetUserBySocialIdentity(identity *models.UserIdentity, user *models.User) error {
+	return wrapError(dp.db.Model(user).
+		Where("id = (SELECT user_id FROM user_identities WHERE tenant_id = ? AND provider = ? AND subject = ?)",
+			dp.tenantID, identity.Provider, identity.Subject).
+		Where("deleted_at IS NULL").
+		Select())
+}
+
+// CreateUserWithIdentity creates a user signing up with an identity provider
+func (dp *dataProvider) CreateUserWithIdentity(user *models.User, identity *models.UserIdentity) error {
+	return wrapError(dp.Tx(func(tx *pg.Tx) error {
+		if err := dp.createUser(tx, user); err != nil {
+			return err
+		}
+		identity.UserID = user.ID
+		identity.TenantID = dp.tenantID
+		return tx.Create(identity)
+	}))
+}
+
+// SaveUserIdentity links an identity to the user, or refreshes its email and tokens if it already is.
+// Fails with DALErrorCodeUniqueIdentity if the identity belongs to another user
+func (dp *dataProvider) SaveUserIdentity(identity *models.UserIdentity) error {
+	identity.TenantID = dp.tenantID
+	res, err := dp.db.Model(identity).
+		OnConflict("(tenant_id, provider, subject) DO UPDATE SET email = EXCLUDED.email, access_token = EXCLUDED.access_token, " +
+			"refresh_token = EXCLUDED.refresh_token WHERE user_identity.user_id = EXCLUDED.user_id").
+		Returning("*").
+		Create()
+	if err == nil {
+		if res.Affected() != 1 {
+			return DALError{Inner: errUniqueIdentity, ErrorCode: DALErrorCodeUniqueIdentity}
+		}
+	}
+	return wrapError(err)
+}
@@ -9,6 +9,7 @@ import (
 	"io/ioutil"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/axiomzen/zenauth/constants"
 	"github.com/axiomzen/zenauth/models"
@@ -25,6 +26,24 @@ var errUniqueEmail = errors.New("Email must be unique")
 // errFacebookIDUnique returned when the facebook id already exists
 var errFacebookIDUnique = errors.New("Facebook ID must be unique")
 
+// errUniqueUsername returned when the username already exists
+var errUniqueUsername = errors.New("Username must be unique")
+
+// errUniqueCredential returned when the webauthn credential already exists
+var errUniqueCredential = errors.New("Credential already registered")
+
+// errUniqueIdentity returned when the identity is linked to another user
+var errUniqueIdentity = errors.New("Identity already linked to another user")
+
+// errUniqueTenantName returned when another tenant has the name
+var errUniqueTenantName = errors.New("Tenant name must be unique")
+
+// errTokenExpired returned when a stored token has expired
+var errTokenExpired = errors.New("Token expired")
+
+// errTokenReused returned when a single use token is presented again
+var errTokenReused = errors.New("Token already used")
+
 // wrapError wraps our outgoing error
 func wrapError(err error) error {
 	if err != nil {
@@ -36,9 +55,21 @@ func wrapError(err error) error {
 		if strings.HasPrefix(str, "ERROR #23505") && strings.Contains(str, "users_email_idx") {
 			return DALError{Inner: errUniqueEmail, ErrorCode: DALErrorCodeUniqueEmail}
 		}
-		if strings.HasPrefix(str, "ERROR #23505") && strings.Contains(str, "users_facebook_id_key") {
+		if strings.HasPrefix(str, "ERROR #23505") && strings.Contains(str, "users_facebook_id_idx") {
 			return DALError{Inner: errFacebookIDUnique, ErrorCode: DALErrorCodeFacebookIDUnique}
 		}
+		if strings.HasPrefix(str, "ERROR #23505") && strings.Contains(str, "users_user_name_idx") {
+			return DALError{Inner: errUniqueUsername, ErrorCode: DALErrorCodeUniqueUsername}
+		}
+		if strings.HasPrefix(str, "ERROR #23505") && strings.Contains(str, "webauthn_credentials_credential_id_idx") {
+			return DALError{Inner: errUniqueCredential, ErrorCode: DALErrorCodeUniqueCredential}
+		}
+		if strings.HasPrefix(str, "ERROR #23505") && strings.Contains(str, "user_identities_provider_subject_idx") {
+			return DALError{Inner: errUniqueIdentity, ErrorCode: DALErrorCodeUniqueIdentity}
+		}
+		if strings.HasPrefix(str, "ERROR #23505") && strings.Contains(str, "tenants_name_idx") {
+			return DALError{Inner: errUniqueTenantName, ErrorCode: DALErrorCodeUniqueTenantName}
+		}
 		if strings.HasPrefix(str, "pg: no rows in result set") {
 			return DALError{Inner: errNoneAffected, ErrorCode: DALErrorCodeNoneAffected}
 		}
@@ -50,7 +81,12 @@ func wrapError(err error) error {
 
 // dataProvider the data provider struct
 type dataProvider struct {
-	db *pg.DB
+	db          *pg.DB
+	revocations *revocationCache
+	// authTokenDuration is how long auth tokens are valid, revocations are kept as long
+	authTokenDuration time.Duration
+	// tenantID scopes the user and invitation queries
+	tenantID string
 }
 
 // Ping pings the database to ensure that we can connect to it
@@ -62,6 +98,7 @@ func (dp *dataProvider) Ping() (err error) {
 
 // closes the database
 func (dp *dataProvider) Close() error {
+	dp.revocations.stop()
 	return wrapError(dp.db.Close())
 }
 
@@ -132,23 +169,22 @@ func (dp *dataProvider) Tx(fn func(*pg.Tx) error) error {
 
 // GetUserByEmail retrieves a user via email
 func (dp *dataProvider) GetUserByEmail(user *models.User) error {
-	return wrapError(dp.db.Model(user).Where("email = ?email").Select())
+	return wrapError(dp.db.Model(user).Where("email = ?email").Where("deleted_at IS NULL").Where("tenant_id = ?", dp.tenantID).Select())
 }
 
 // GetUserByUserName retrieves a user via username
 func (dp *dataProvider) GetUserByUserName(user *models.User) error {
-	return wrapError(dp.db.Model(user).Where("user_name = ?user_name").Select())
+	return wrapError(dp.db.Model(user).Where("user_name = ?user_name").Where("deleted_at IS NULL").Where("tenant_id = ?", dp.tenantID).Select())
 }
 
 // GetUserByEmailOrUserName retrieves a user via email or username
 func (dp *dataProvider) GetUserByEmailOrUserName(user *models.User) error {
-	return wrapError(dp.db.Model(user).Where("email = ?email OR user_name = ?user_name").Select())
+	return wrapError(dp.db.Model(user).Where("email = ?email OR user_name = ?user_name").Where("deleted_at IS NULL").Where("tenant_id = ?", dp.tenantID).Select())
 }
 
 // GetUserByID retrieves a user via id
 func (dp *dataProvider) GetUserByID(user *models.User) error {
-	//Where("id = ?id")
-	return wrapError(dp.db.Select(user))
+	return wrapError(dp.db.Model(user).Where("id = ?id").Where("deleted_at IS NULL").Where("tenant_id = ?", dp.tenantID).Select())
 }
 
 // GetUsersByIDs retrieves users via ids
@@ -163,6 +199,8 @@ func (dp *dataProvider) GetUsersByIDs(users *models.Users) error {
 
 	err := dp.db.Model(&outUsers).
 		Where("id IN (?)", types.In(ids)).
+		Where("deleted_at IS NULL").
+		Where("tenant_id = ?", dp.tenantID).
 		Select()
 	if err != nil {
 		return wrapError(err)
@@ -194,7 +232,7 @@ func (dp *dataProvider) GetUserByFacebookID(user *models.User) error {
 	//return dp.NoArgFunc(drop)
 	//return dp.FuncWithUser(fe, user).Do()
 	// return dp.Arg("user", user).ReturnUserAndError()
-	return wrapError(dp.db.Model(user).Where("facebook_id = ?facebook_id").Select())
+	return wrapError(dp.db.Model(user).Where("facebook_id = ?facebook_id").Where("deleted_at IS NULL").Where("tenant_id = ?", dp.tenantID).Select())
 }
 
 // GetUsersByFacebookIDs retrieves users via facebook ids
@@ -202,13 +240,15 @@ func (dp *dataProvider) GetUserByFacebookID(user *models.User) error {
 func (dp *dataProvider) GetUsersByFacebookIDs(fbIDs []string, users *models.Users) error {
 	return wrapError(dp.db.Model(users).
 		Where("facebook_id IN (?)", types.In(fbIDs)).
+		Where("deleted_at IS NULL").
+		Where("tenant_id = ?", dp.tenantID).
 		Select())
 }
 
 // UpdateUser updates a user
 func (dp *dataProvider) UpdateUser(model interface{}, user *models.User) error {
 
-	res, err := dp.db.Model(model).Returning("*").Update(user)
+	res, err := dp.db.Model(model).Where("id = ?id").Where("tenant_id = ?", dp.tenantID).Returning("*").Update(user)
 
 	if err == nil {
 		if res.Affected() != 1 {
@@ -223,7 +263,7 @@ func (dp *dataProvider) UpdateUser(model interface{}, user *models.User) error {
 // and 'model/table' enums  so Update.Model(m, data.T).Where(data.T.Y).Returning(&user).Do()
 // or do these functions get generated?
 func (dp *dataProvider) UpdateUserVerified(user *models.User) error {
-	res, err := dp.db.Model(user).Set("verified = ?verified").Where("email = ?email").Returning("*").Update()
+	res, err := dp.db.Model(user).Set("verified = ?verified").Where("email = ?email").Where("deleted_at IS NULL").Where("tenant_id = ?", dp.tenantID).Returning("*").Update()
 	if err == nil {
 		if res.Affected() != 1 {
 			return DALError{Inner: errNoneAffected, ErrorCode: DALErrorCodeNoneAffected}
@@ -239,6 +279,8 @@ func (dp *dataProvider) UpdateUserFacebookInfo(user *models.User) error {
 		Set("facebook_username = ?facebook_username").
 		Set("facebook_email = ?facebook_email").
 		Where("facebook_id = ?facebook_id").
+		Where("deleted_at IS NULL").
+		Where("tenant_id = ?", dp.tenantID).
 		Returning("*").
 		Update()
 	if err == nil {
@@ -251,7 +293,7 @@ func (dp *dataProvider) UpdateUserFacebookInfo(user *models.User) error {
 
 // CreateUserResetToken will update a users password reset token based on email
 func (dp *dataProvider) CreateUserResetToken(user *models.User) error {
-	res, err := dp.db.Model(user).Set("reset_token = ?reset_token").Where("email = ?email").Returning("*").Update()
+	res, err := dp.db.Model(user).Set("reset_token = ?reset_token").Where("email = ?email").Where("deleted_at IS NULL").Where("tenant_id = ?", dp.tenantID).Returning("*").Update()
 	if err == nil {
 		if res.Affected() != 1 {
 			return DALError{Inner: errNoneAffected, ErrorCode: DALErrorCodeNoneAffected}
@@ -262,7 +304,30 @@ func (dp *dataProvider) CreateUserResetToken(user *models.User) error {
 
 // ConsumeUserResetToken will do a bunch of stuff
 func (dp *dataProvider) ConsumeUserResetToken(user *models.User) error {
-	res, err := dp.db.Model(user).Set("reset_token = NULL, hash = ?hash").Where("email = ?email AND reset_token = ?reset_token").Returning("*").Update()
+	res, err := dp.db.Model(user).Set("reset_token = NULL, hash = ?hash").Where("email = ?email AND reset_token = ?reset_token").Where("deleted_at IS NULL").Where("tenant_id = ?", dp.tenantID).Returning("*").Update()
+	if err == nil {
+		if res.Affected() != 1 {
+			return DALError{Inner: errNoneAffected, ErrorCode: DALErrorCodeNoneAffected}
+		}
+	}
+	return wrapError(err)
+}
+
+// CreateUserMagicLinkToken will update a users magic link token based on email
+func (dp *dataProvider) CreateUserMagicLinkToken(user *models.User) error {
+	res, err := dp.db.Model(user).Set("magic_link_token = ?magic_link_token").Where("email = ?email").Where("deleted_at IS NULL").Where("tenant_id = ?", dp.tenantID).Returning("*").Update()
+	if err == nil {
+		if res.Affected() != 1 {
+			return DALError{Inner: errNoneAffected, ErrorCode: DALErrorCodeNoneAffected}
+		}
+	}
+	return wrapError(err)
+}
+
+// ConsumeUserMagicLinkToken clears the magic link token if it matches, so it can only be used once.
+// Following the link proves the user owns the email, so it is marked as verified
+func (dp *dataProvider) ConsumeUserMagicLinkToken(user *models.User) error {
+	res, err := dp.db.Model(user).Set("magic_link_token = NULL, verified = true").Where("email = ?email AND magic_link_token = ?magic_link_token").Where("deleted_at IS NULL").Where("tenant_id = ?", dp.tenantID).Returning("*").Update()
 	if err == nil {
 		if res.Affected() != 1 {
 			return DALError{Inner: errNoneAffected, ErrorCode: DALErrorCodeNoneAffected}
@@ -274,32 +339,51 @@ func (dp *dataProvider) ConsumeUserResetToken(user *models.User) error {
 // CreateUser creates a user
 func (dp *dataProvider) CreateUser(user *models.User) error {
 	return wrapError(dp.Tx(func(tx *pg.Tx) error {
-		// If an invite exists for any code, delete them
-		invitation := models.Invitation{}
-		if user.FacebookID != "" {
-			invitation.Type = constants.InvitationTypeFacebook
-			invitation.Code = user.FacebookID
-		} else if user.Email != "" {
-			invitation.Type = constants.InvitationTypeEmail
-			invitation.Code = user.Email
-		} else {
+		if user.FacebookID == "" && user.Email == "" {
 			return fmt.Errorf("Cannot create a user without FacebookID or Email")
 		}
+		return dp.createUser(tx, user)
+	}))
+}
 
-		if err := tx.Model(&invitation).Where("type = ?type").Where("code = ?code").Select(); err == nil {
-			user.ID = invitation.ID
-			_, err = tx.Model(&invitation).Where("type = ?type").Where("code = ?code").Delete()
-			if err != nil {
-				return err
-			}
-		}
+// createUser creates a user of the tenant in the transaction, taking over the id of their invitation
+// if they have one
+func (dp *dataProvider) createUser(tx *pg.Tx, user *models.User) error {
+	user.TenantID = dp.tenantID
+	// If an invite exists for any code, delete them
+	invitation := models.Invitation{TenantID: dp.tenantID}
+	if user.FacebookID != "" {
+		invitation.Type = constants.InvitationTypeFacebook
+		invitation.Code = user.FacebookID
+	} else if user.Email != "" {
+		invitation.Type = constants.InvitationTypeEmail
+		invitation.Code = user.Email
+	} else {
+		// users signing up with other identity providers may not have an email
 		return tx.Create(user)
-	}))
+	}
+
+	if err := tx.Model(&invitation).Where("type = ?type").Where("code = ?code").Where("tenant_id = ?tenant_id").Select(); err == nil {
+		user.ID = invitation.ID
+		user.InvitedBy = invitation.CreatedBy
+		user.InvitedAt = invitation.CreatedAt
+		_, err = tx.Model(&invitation).Where("id = ?id").Delete()
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Create(user)
 }
 
 // DeleteUser deletes a user (by user id)
 func (dp *dataProvider) DeleteUser(user *models.User) error {
-	return wrapError(dp.db.Delete(user))
+	res, err := dp.db.Model(user).Where("id = ?id").Where("tenant_id = ?", dp.tenantID).Delete()
+	if err == nil {
+		if res.Affected() != 1 {
+			return DALError{Inner: errNoneAffected, ErrorCode: DALErrorCodeNoneAffected}
+		}
+	}
+	return wrapError(err)
 }
 
 // MergeUsers merges two users. First user takes precedence,
@@ -308,10 +392,14 @@ func (dp *dataProvider) MergeUsers(firstUser, secondUser *models.User) error {
 	// Merge with calling user
 	firstUser.Merge(secondUser)
 	return wrapError(dp.Tx(func(tx *pg.Tx) error {
-		if err := tx.Delete(secondUser); err != nil {
+		res, err := tx.Model(secondUser).Where("id = ?id").Where("tenant_id = ?", dp.tenantID).Delete()
+		if err != nil {
 			return err
 		}
-		res, err := tx.Model(firstUser).Returning("*").Update()
+		if res.Affected() != 1 {
+			return DALError{Inner: errNoneAffected, ErrorCode: DALErrorCodeNoneAffected}
+		}
+		res, err = tx.Model(firstUser).Where("id = ?id").Where("tenant_id = ?", dp.tenantID).Returning("*").Update()
 		if err == nil {
 			if res.Affected() != 1 {
 				return DALError{Inner: errNoneAffected, ErrorCode: DALErrorCodeNoneAffected}
@@ -324,7 +412,7 @@ func (dp *dataProvider) MergeUsers(firstUser, secondUser *models.User) error {
 // ChangeUserPassword allows you to change the password of a user
 func (dp *dataProvider) UpdateUserHash(newHash string, user *models.User) error {
 	// TODO: we need to err if no rows were updated
-	res, err := dp.db.Model(user).Set("hash = ?", newHash).Where("id = ?id AND hash = ?hash").Returning("*").Update()
+	res, err := dp.db.Model(user).Set("hash = ?", newHash).Where("id = ?id AND hash = ?hash").Where("tenant_id = ?", dp.tenantID).Returning("*").Update()
 	if err == nil {
 		if res.Affected() != 1 {
 			return DALError{Inner: errNoneAffected, ErrorCode: DALErrorCodeNoneAffected}
@@ -335,7 +423,7 @@ func (dp *dataProvider) UpdateUserHash(newHash string, user *models.User) error
 
 // ClearUserResetToken sets the reset token to nil (test route)
 func (dp *dataProvider) ClearUserResetToken(user *models.User) error {
-	res, err := dp.db.Model(user).Set("reset_token = ?reset_token").Where("id = ?id").Returning("*").Update()
+	res, err := dp.db.Model(user).Set("reset_token = ?reset_token").Where("id = ?id").Where("tenant_id = ?", dp.tenantID).Returning("*").Update()
 	if err == nil {
 		if res.Affected() != 1 {
 			return DALError{Inner: errNoneAffected, ErrorCode: DALErrorCodeNoneAffected}
@@ -345,6 +433,6 @@ func (dp *dataProvider) ClearUserResetToken(user *models.User) error {
 }
 
 func (dp *dataProvider) GetUsernameCount(username string) (int, error) {
-	count, err := dp.db.Model(&models.User{}).Where("user_name LIKE ?", username+"%").Count()
+	count, err := dp.db.Model(&models.User{}).Where("user_name LIKE ?", username+"%").Where("tenant_id = ?", dp.tenantID).Count()
 	return count, wrapError(err)
 }
@@ -0,0 +1,45 @@
+package data
+
+import (
+	"time"
+
+	"github.com/axiomzen/null"
+	"github.com/axiomzen/zenauth/helpers"
+	"github.com/axiomzen/zenauth/models"
+	"gopkg.in/pg.v4"
+	"gopkg.in/pg.v4/types"
+)
+
+// GetLoginThrottles gets the throttles of the keys, keys without failures are left out
+func (dp *dataProvider) GetLoginThrottles(keys ...string) ([]models.LoginThrottle, error) {
+	var throttles []models.LoginThrottle
+	err := dp.db.Model(&throttles).Where("key IN (?)", types.In(keys)).Select()
+	return throttles, wrapError(err)
+}
+
+// RecordLoginFailure counts a failed login against throttle.Key and fills in the throttle.
+// The row is locked while counting so concurrent failures on other replicas all count
+func (dp *dataProvider) RecordLoginFailure(throttle *models.LoginThrottle, policy helpers.LoginThrottlePolicy) error {
+	return wrapError(dp.Tx(func(tx *pg.Tx) error {
+		if _, err := tx.Model(&models.LoginThrottle{Key: throttle.Key}).OnConflict("DO NOTHING").Create(); err != nil {
+			return err
+		}
+		if _, err := tx.QueryOne(throttle, `SELECT * FROM login_throttles WHERE key = ? FOR UPDATE`, throttle.Key); err != nil {
+			return err
+		}
+		now := time.Now()
+		failures, lockedUntil := policy.Failure(throttle.Failures, throttle.LastFailureAt.Time, now)
+		throttle.Failures = failures
+		throttle.LastFailureAt = null.TimeFrom(now)
+		if !lockedUntil.IsZero() {
+			throttle.LockedUntil = null.TimeFrom(lockedUntil)
+		}
+		return tx.Update(throttle)
+	}))
+}
+
+// ClearLoginThrottle forgets the failures of a key, unlocking it
+func (dp *dataProvider) ClearLoginThrottle(key string) error {
+	_, err := dp.db.Model(&models.LoginThrottle{}).Where("key = ?", key).Delete()
+	return wrapError(err)
+}
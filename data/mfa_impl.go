@@ -0,0 +1,113 @@
+package data
+
+import (
+	"github.com/axiomzen/zenauth/models"
+	"gopkg.in/pg.v4"
+)
+
+// SetUserTOTPSecret stores a new (encrypted) TOTP secret, pending confirmation.
+// Fails with DALErrorCodeNoneAffected if TOTP is already enabled
+func (dp *dataProvider) SetUserTOTPSecret(user *models.User) error {
+	res, err := dp.db.Model(user).
+		Set("totp_secret = ?totp_secret, totp_enabled = false, totp_last_step = NULL").
+		Where("id = ?id AND totp_enabled = false").
+		Where("tenant_id = ?", dp.tenantID).
+		Returning("*").
+		Update()
+	if err == nil {
+		if res.Affected() != 1 {
+			return DALError{Inner: errNoneAffected, ErrorCode: DALErrorCodeNoneAffected}
+		}
+	}
+	return wrapError(err)
+}
+
+// EnableUserTOTP enables TOTP once the pending secret has been confirmed
+func (dp *dataProvider) EnableUserTOTP(user *models.User) error {
+	res, err := dp.db.Model(user).
+		Set("totp_enabled = true").
+		Where("id = ?id AND totp_secret IS NOT NULL").
+		Where("tenant_id = ?", dp.tenantID).
+		Returning("*").
+		Update()
+	if err == nil {
+		if res.Affected() != 1 {
+			return DALError{Inner: errNoneAffected, ErrorCode: DALErrorCodeNoneAffected}
+		}
+	}
+	return wrapError(err)
+}
+
+// DisableUserTOTP disables TOTP and removes the secret and recovery codes
+func (dp *dataProvider) DisableUserTOTP(user *models.User) error {
+	return wrapError(dp.Tx(func(tx *pg.Tx) error {
+		res, err := tx.Model(user).
+			Set("totp_secret = NULL, totp_enabled = false, totp_last_step = NULL").
+			Where("id = ?id").
+			Where("tenant_id = ?", dp.tenantID).
+			Returning("*").
+			Update()
+		if err != nil {
+			return err
+		}
+		if res.Affected() != 1 {
+			return DALError{Inner: errNoneAffected, ErrorCode: DALErrorCodeNoneAffected}
+		}
+		_, err = tx.Model(&models.RecoveryCode{}).Where("user_id = ?", user.ID).Delete()
+		return err
+	}))
+}
+
+// ConsumeUserTOTPStep records the time step of a used code, so the code can't be replayed.
+// Fails with DALErrorCodeNoneAffected if the step (or a later one) was already used
+func (dp *dataProvider) ConsumeUserTOTPStep(user *models.User, step int64) error {
+	res, err := dp.db.Model(user).
+		Set("totp_last_step = ?", step).
+		Where("id = ?id").
+		Where("totp_last_step IS NULL OR totp_last_step < ?", step).
+		Where("tenant_id = ?", dp.tenantID).
+		Returning("*").
+		Update()
+	if err == nil {
+		if res.Affected() != 1 {
+			return DALError{Inner: errNoneAffected, ErrorCode: DALErrorCodeNoneAffected}
+		}
+	}
+	return wrapError(err)
+}
+
+// ReplaceRecoveryCodes replaces all of the user's recovery codes
+func (dp *dataProvider) ReplaceRecoveryCodes(userID string, codes *models.RecoveryCodeList) error {
+	return wrapError(dp.Tx(func(tx *pg.Tx) error {
+		if _, err := tx.Model(&models.RecoveryCode{}).Where("user_id = ?", userID).Delete(); err != nil {
+			return err
+		}
+		for _, code := range *codes {
+			code.UserID = userID
+		}
+		_, err := tx.Model(codes).Create()
+		return err
+	}))
+}
+
+// GetUnusedRecoveryCodes gets the user's recovery codes that haven't been used yet
+func (dp *dataProvider) GetUnusedRecoveryCodes(userID string, codes *models.RecoveryCodeList) error {
+	return wrapError(dp.db.Model(codes).Where("user_id = ?", userID).Where("used_at IS NULL").Select())
+}
+
+// ConsumeRecoveryCode marks a recovery code as used.
+// Fails with DALErrorCodeNoneAffected if it was already used
+func (dp *dataProvider) ConsumeRecoveryCode(code *models.RecoveryCode) error {
+	res, err := dp.db.Model(code).
+		Set("used_at = now()").
+		Where("id = ?id").
+		Where("used_at IS NULL").
+		Returning("*").
+		Update()
+	if err == nil {
+		if res.Affected() != 1 {
+			return DALError{Inner: errNoneAffected, ErrorCode: DALErrorCodeNoneAffected}
+		}
+	}
+	return wrapError(err)
+}
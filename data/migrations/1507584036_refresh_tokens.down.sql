@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS refresh_tokens_user_id_idx;
+DROP INDEX IF EXISTS refresh_tokens_family_id_idx;
+DROP INDEX IF EXISTS refresh_tokens_token_hash_idx;
+DROP TABLE IF EXISTS refresh_tokens CASCADE;
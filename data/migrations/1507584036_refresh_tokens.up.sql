@@ -0,0 +1,15 @@
+CREATE TABLE refresh_tokens (
+  id           UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  user_id      UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  -- every token rotated from the same login shares a family
+  family_id    UUID NOT NULL,
+  token_hash   VARCHAR(64) NOT NULL,
+  created_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+  expires_at   TIMESTAMP WITH TIME ZONE NOT NULL,
+  used_at      TIMESTAMP WITH TIME ZONE,
+  revoked_at   TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX refresh_tokens_token_hash_idx ON refresh_tokens (token_hash);
+CREATE INDEX refresh_tokens_family_id_idx ON refresh_tokens (family_id);
+CREATE INDEX refresh_tokens_user_id_idx ON refresh_tokens (user_id);
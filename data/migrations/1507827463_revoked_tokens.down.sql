@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS revoked_tokens_user_id_idx;
+DROP INDEX IF EXISTS revoked_tokens_jti_idx;
+DROP TABLE IF EXISTS revoked_tokens CASCADE;
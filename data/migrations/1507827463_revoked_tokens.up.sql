@@ -0,0 +1,14 @@
+CREATE TABLE revoked_tokens (
+  id            UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  user_id       UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  -- either a single token
+  jti           UUID,
+  -- or every token issued before this time
+  issued_before TIMESTAMP WITH TIME ZONE,
+  expires_at    TIMESTAMP WITH TIME ZONE NOT NULL,
+  created_at    TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+  CHECK (jti IS NOT NULL OR issued_before IS NOT NULL)
+);
+
+CREATE UNIQUE INDEX revoked_tokens_jti_idx ON revoked_tokens (jti);
+CREATE INDEX revoked_tokens_user_id_idx ON revoked_tokens (user_id, expires_at);
@@ -0,0 +1,4 @@
+ALTER TABLE users
+DROP COLUMN IF EXISTS totp_secret,
+DROP COLUMN IF EXISTS totp_enabled,
+DROP COLUMN IF EXISTS totp_last_step;
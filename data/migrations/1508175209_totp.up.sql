@@ -0,0 +1,4 @@
+ALTER TABLE users
+ADD COLUMN totp_secret TEXT,
+ADD COLUMN totp_enabled BOOLEAN NOT NULL DEFAULT false,
+ADD COLUMN totp_last_step BIGINT;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS audit_log_user_id_idx;
+DROP TABLE IF EXISTS audit_log CASCADE;
+DROP INDEX IF EXISTS recovery_codes_user_id_idx;
+DROP TABLE IF EXISTS recovery_codes CASCADE;
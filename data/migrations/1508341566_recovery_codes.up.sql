@@ -0,0 +1,20 @@
+CREATE TABLE recovery_codes (
+  id           UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  user_id      UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  hash         VARCHAR(60) NOT NULL,
+  created_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+  used_at      TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX recovery_codes_user_id_idx ON recovery_codes (user_id);
+
+CREATE TABLE audit_log (
+  id           UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  user_id      UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  event        VARCHAR(64) NOT NULL,
+  ip_address   VARCHAR(64),
+  user_agent   TEXT,
+  created_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE INDEX audit_log_user_id_idx ON audit_log (user_id, created_at);
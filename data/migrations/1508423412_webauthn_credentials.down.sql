@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS webauthn_credentials_user_id_idx;
+DROP INDEX IF EXISTS webauthn_credentials_credential_id_idx;
+DROP TABLE IF EXISTS webauthn_credentials CASCADE;
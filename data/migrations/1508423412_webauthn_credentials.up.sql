@@ -0,0 +1,16 @@
+CREATE TABLE webauthn_credentials (
+  id             UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  user_id        UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  -- base64url encoded, as sent by the client
+  credential_id  VARCHAR(1400) NOT NULL,
+  -- COSE encoded
+  public_key     BYTEA NOT NULL,
+  sign_count     BIGINT NOT NULL DEFAULT 0,
+  transports     TEXT[],
+  name           VARCHAR(255) NOT NULL DEFAULT '',
+  created_at     TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+  last_used_at   TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX webauthn_credentials_credential_id_idx ON webauthn_credentials (credential_id);
+CREATE INDEX webauthn_credentials_user_id_idx ON webauthn_credentials (user_id);
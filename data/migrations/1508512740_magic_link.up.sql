@@ -0,0 +1,2 @@
+ALTER TABLE users
+ADD COLUMN magic_link_token TEXT;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS user_identities_user_id_idx;
+DROP INDEX IF EXISTS user_identities_provider_subject_idx;
+DROP TABLE IF EXISTS user_identities CASCADE;
@@ -0,0 +1,27 @@
+CREATE TABLE user_identities (
+  id             UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  user_id        UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  -- the identity provider, and its id for the account
+  provider       VARCHAR(64) NOT NULL,
+  subject        VARCHAR(255) NOT NULL,
+  email          VARCHAR(256),
+  access_token   TEXT,
+  refresh_token  TEXT,
+  created_at     TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+  updated_at     TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX user_identities_provider_subject_idx ON user_identities (provider, subject);
+CREATE INDEX user_identities_user_id_idx ON user_identities (user_id);
+
+CREATE TRIGGER row_mod_on_user_identities_trigger_
+BEFORE UPDATE
+ON user_identities
+FOR EACH ROW
+EXECUTE PROCEDURE update_row_modified_function_();
+
+-- facebook accounts become identities, the facebook columns are kept for the facebook routes
+INSERT INTO user_identities (user_id, provider, subject, email, access_token)
+SELECT id, 'facebook', facebook_id, facebook_email, facebook_token
+FROM users
+WHERE facebook_id IS NOT NULL AND facebook_id <> '';
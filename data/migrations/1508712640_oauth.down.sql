@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS oauth_authorization_codes_user_id_idx;
+DROP INDEX IF EXISTS oauth_authorization_codes_code_hash_idx;
+DROP TABLE IF EXISTS oauth_authorization_codes CASCADE;
+DROP INDEX IF EXISTS oauth_clients_client_id_idx;
+DROP TABLE IF EXISTS oauth_clients CASCADE;
@@ -0,0 +1,28 @@
+CREATE TABLE oauth_clients (
+  id             UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  client_id      VARCHAR(64) NOT NULL,
+  -- public clients have no secret, they rely on PKCE alone
+  secret_hash    VARCHAR(64),
+  name           VARCHAR(255) NOT NULL DEFAULT '',
+  redirect_uris  TEXT[] NOT NULL,
+  created_at     TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX oauth_clients_client_id_idx ON oauth_clients (client_id);
+
+CREATE TABLE oauth_authorization_codes (
+  id                     UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  code_hash              VARCHAR(64) NOT NULL,
+  client_id              VARCHAR(64) NOT NULL REFERENCES oauth_clients (client_id) ON DELETE CASCADE,
+  user_id                UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  redirect_uri           TEXT NOT NULL,
+  scope                  TEXT NOT NULL DEFAULT '',
+  nonce                  TEXT,
+  code_challenge         VARCHAR(128) NOT NULL,
+  created_at             TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+  expires_at             TIMESTAMP WITH TIME ZONE NOT NULL,
+  used_at                TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX oauth_authorization_codes_code_hash_idx ON oauth_authorization_codes (code_hash);
+CREATE INDEX oauth_authorization_codes_user_id_idx ON oauth_authorization_codes (user_id);
@@ -0,0 +1,7 @@
+CREATE TABLE login_throttles (
+  -- "account:<user id>" or "ip:<address>"
+  key             VARCHAR(128) PRIMARY KEY,
+  failures        INTEGER NOT NULL DEFAULT 0,
+  last_failure_at TIMESTAMP WITH TIME ZONE,
+  locked_until    TIMESTAMP WITH TIME ZONE
+);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS rate_limit_buckets_updated_at_idx;
+DROP TABLE IF EXISTS rate_limit_buckets CASCADE;
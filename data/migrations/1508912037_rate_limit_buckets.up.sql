@@ -0,0 +1,7 @@
+CREATE TABLE rate_limit_buckets (
+  key        VARCHAR(256) PRIMARY KEY,
+  tokens     DOUBLE PRECISION NOT NULL DEFAULT 0,
+  updated_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX rate_limit_buckets_updated_at_idx ON rate_limit_buckets (updated_at);
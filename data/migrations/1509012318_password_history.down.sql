@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS password_history_user_id_idx;
+DROP TABLE IF EXISTS password_history CASCADE;
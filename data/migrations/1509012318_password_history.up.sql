@@ -0,0 +1,8 @@
+CREATE TABLE password_history (
+  id           UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  user_id      UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  hash         TEXT NOT NULL,
+  created_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE INDEX password_history_user_id_idx ON password_history (user_id, created_at);
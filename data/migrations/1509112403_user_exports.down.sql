@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS invitations_created_by_idx;
+DROP INDEX IF EXISTS user_exports_user_id_idx;
+DROP TABLE IF EXISTS user_exports CASCADE;
+ALTER TABLE users DROP COLUMN IF EXISTS invited_at;
+ALTER TABLE users DROP COLUMN IF EXISTS invited_by;
+ALTER TABLE invitations DROP COLUMN IF EXISTS created_by;
@@ -0,0 +1,16 @@
+-- who sent an invitation, kept on the user once it is accepted
+ALTER TABLE invitations ADD COLUMN created_by UUID REFERENCES users (id) ON DELETE SET NULL;
+ALTER TABLE users ADD COLUMN invited_by UUID;
+ALTER TABLE users ADD COLUMN invited_at TIMESTAMP WITH TIME ZONE;
+
+CREATE TABLE user_exports (
+  id           UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  user_id      UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  -- the json bundle, downloaded with a signed link until it expires
+  data         BYTEA NOT NULL,
+  created_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+  expires_at   TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX user_exports_user_id_idx ON user_exports (user_id);
+CREATE INDEX invitations_created_by_idx ON invitations (created_by);
@@ -0,0 +1,4 @@
+-- accounts are soft deleted, then purged once the grace period is over
+ALTER TABLE users ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX users_deleted_at_idx ON users (deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,5 @@
+-- admins can disable an account, which can't log in again until it is enabled
+ALTER TABLE users ADD COLUMN disabled_at TIMESTAMP WITH TIME ZONE;
+
+-- admin user listings page through users in creation order
+CREATE INDEX users_created_at_id_idx ON users (created_at, id);
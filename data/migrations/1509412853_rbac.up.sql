@@ -0,0 +1,34 @@
+CREATE TABLE roles (
+  id           UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  name         VARCHAR(64) NOT NULL,
+  description  TEXT NOT NULL DEFAULT '',
+  created_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX roles_name_idx ON roles (name);
+
+-- permissions are created when a role is first given them
+CREATE TABLE permissions (
+  id           UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  name         VARCHAR(64) NOT NULL,
+  created_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX permissions_name_idx ON permissions (name);
+
+CREATE TABLE role_permissions (
+  role_id        UUID NOT NULL REFERENCES roles (id) ON DELETE CASCADE,
+  permission_id  UUID NOT NULL REFERENCES permissions (id) ON DELETE CASCADE,
+  PRIMARY KEY (role_id, permission_id)
+);
+
+CREATE INDEX role_permissions_permission_id_idx ON role_permissions (permission_id);
+
+CREATE TABLE user_roles (
+  user_id      UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  role_id      UUID NOT NULL REFERENCES roles (id) ON DELETE CASCADE,
+  created_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+  PRIMARY KEY (user_id, role_id)
+);
+
+CREATE INDEX user_roles_role_id_idx ON user_roles (role_id);
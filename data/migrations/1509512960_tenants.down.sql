@@ -0,0 +1,19 @@
+-- users of other tenants can't stay once emails are unique again
+DELETE FROM users WHERE tenant_id <> '00000000-0000-0000-0000-000000000000';
+DELETE FROM invitations WHERE tenant_id <> '00000000-0000-0000-0000-000000000000';
+
+DROP INDEX IF EXISTS invitation_code_idx;
+CREATE UNIQUE INDEX invitation_code_idx ON invitations (type, code);
+DROP INDEX IF EXISTS user_identities_provider_subject_idx;
+CREATE UNIQUE INDEX user_identities_provider_subject_idx ON user_identities (provider, subject);
+DROP INDEX IF EXISTS users_facebook_id_idx;
+ALTER TABLE users ADD CONSTRAINT users_facebook_id_key UNIQUE (facebook_id);
+DROP INDEX IF EXISTS users_user_name_idx;
+ALTER TABLE users ADD CONSTRAINT users_user_name_key UNIQUE (user_name);
+DROP INDEX IF EXISTS users_email_idx;
+CREATE UNIQUE INDEX users_email_idx ON users (lower(email) varchar_pattern_ops);
+
+ALTER TABLE user_identities DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE invitations DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE users DROP COLUMN IF EXISTS tenant_id;
+DROP TABLE IF EXISTS tenants;
@@ -0,0 +1,37 @@
+CREATE TABLE tenants (
+  id              UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  name            VARCHAR(64) NOT NULL,
+  -- SHA-256 of the tenant's API token, the default tenant's is ZENAUTH_APITOKEN
+  api_token_hash  VARCHAR(64),
+  created_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX tenants_name_idx ON tenants (name);
+CREATE UNIQUE INDEX tenants_api_token_hash_idx ON tenants (api_token_hash);
+
+-- the users from before tenants belong to the default tenant
+INSERT INTO tenants (id, name) VALUES ('00000000-0000-0000-0000-000000000000', 'default');
+
+ALTER TABLE users ADD COLUMN tenant_id UUID REFERENCES tenants (id) ON DELETE CASCADE;
+UPDATE users SET tenant_id = '00000000-0000-0000-0000-000000000000';
+ALTER TABLE users ALTER COLUMN tenant_id SET NOT NULL;
+
+ALTER TABLE invitations ADD COLUMN tenant_id UUID REFERENCES tenants (id) ON DELETE CASCADE;
+UPDATE invitations SET tenant_id = '00000000-0000-0000-0000-000000000000';
+ALTER TABLE invitations ALTER COLUMN tenant_id SET NOT NULL;
+
+ALTER TABLE user_identities ADD COLUMN tenant_id UUID REFERENCES tenants (id) ON DELETE CASCADE;
+UPDATE user_identities SET tenant_id = users.tenant_id FROM users WHERE users.id = user_identities.user_id;
+ALTER TABLE user_identities ALTER COLUMN tenant_id SET NOT NULL;
+
+-- emails, usernames, facebook accounts, identities and invitation codes are unique per tenant
+DROP INDEX users_email_idx;
+CREATE UNIQUE INDEX users_email_idx ON users (tenant_id, lower(email) varchar_pattern_ops);
+ALTER TABLE users DROP CONSTRAINT users_user_name_key;
+CREATE UNIQUE INDEX users_user_name_idx ON users (tenant_id, user_name);
+ALTER TABLE users DROP CONSTRAINT users_facebook_id_key;
+CREATE UNIQUE INDEX users_facebook_id_idx ON users (tenant_id, facebook_id);
+DROP INDEX user_identities_provider_subject_idx;
+CREATE UNIQUE INDEX user_identities_provider_subject_idx ON user_identities (tenant_id, provider, subject);
+DROP INDEX invitation_code_idx;
+CREATE UNIQUE INDEX invitation_code_idx ON invitations (tenant_id, type, code);
@@ -0,0 +1,35 @@
+package data
+
+import (
+	"github.com/axiomzen/zenauth/models"
+)
+
+// CreateOAuthClient registers a client
+func (dp *dataProvider) CreateOAuthClient(client *models.OAuthClient) error {
+	return wrapError(dp.db.Create(client))
+}
+
+// GetOAuthClient gets a client by its client id
+func (dp *dataProvider) GetOAuthClient(client *models.OAuthClient) error {
+	return wrapError(dp.db.Model(client).Where("client_id = ?client_id").Select())
+}
+
+// CreateOAuthAuthorizationCode stores a new authorization code
+func (dp *dataProvider) CreateOAuthAuthorizationCode(code *models.OAuthAuthorizationCode) error {
+	return wrapError(dp.db.Create(code))
+}
+
+// ConsumeOAuthAuthorizationCode marks the code matching code.CodeHash as used and fills in the rest of it.
+// Fails with DALErrorCodeNoneAffected if there is no such code or it was already used
+func (dp *dataProvider) ConsumeOAuthAuthorizationCode(code *models.OAuthAuthorizationCode) error {
+	res, err := dp.db.Model(code).
+		Set("used_at = now()").
+		Where("code_hash = ?code_hash").
+		Where("used_at IS NULL").
+		Returning("*").
+		Update()
+	if err == nil && res.Affected() != 1 {
+		return DALError{Inner: errNoneAffected, ErrorCode: DALErrorCodeNoneAffected}
+	}
+	return wrapError(err)
+}
@@ -0,0 +1,27 @@
+package data
+
+import (
+	"github.com/axiomzen/zenauth/models"
+	"gopkg.in/pg.v4"
+)
+
+// GetPasswordHistory gets the last limit password hashes of the user, newest first
+func (dp *dataProvider) GetPasswordHistory(userID string, limit int, history *models.PasswordHistoryList) error {
+	return wrapError(dp.db.Model(history).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Limit(limit).
+		Select())
+}
+
+// AddPasswordHistory adds a password hash to the user's history, keeping only the last keep
+func (dp *dataProvider) AddPasswordHistory(entry *models.PasswordHistory, keep int) error {
+	return wrapError(dp.Tx(func(tx *pg.Tx) error {
+		if err := tx.Create(entry); err != nil {
+			return err
+		}
+		_, err := tx.Exec(`DELETE FROM password_history WHERE user_id = ? AND id NOT IN
+			(SELECT id FROM password_history WHERE user_id = ? ORDER BY created_at DESC LIMIT ?)`, entry.UserID, entry.UserID, keep)
+		return err
+	}))
+}
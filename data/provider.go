@@ -4,6 +4,9 @@
 package data
 
 import (
+	"time"
+
+	"github.com/axiomzen/zenauth/helpers"
 	"github.com/axiomzen/zenauth/models"
 
 	pg "gopkg.in/pg.v4"
@@ -31,6 +34,12 @@ type Provider interface {
 type ZENAUTHProvider interface {
 	Provider
 
+	// ForTenant returns the data provider of the tenant, whose user and invitation queries only see
+	// that tenant. The provider from Get is the default tenant's
+	ForTenant(tenantID string) ZENAUTHProvider
+	// TenantID returns the tenant of the data provider
+	TenantID() string
+
 	// GetUserByEmail retrieves a user via email
 	GetUserByEmail(user *models.User) error
 	// GetUserByUserName retrieves a user via username
@@ -47,6 +56,12 @@ type ZENAUTHProvider interface {
 	GetUsersByFacebookIDs(ids []string, users *models.Users) error
 	// UpdateUserFacebookInfo updates the user's facebook token
 	UpdateUserFacebookInfo(user *models.User) error
+	// GetUserBySocialIdentity gets the user an identity provider account is linked to
+	GetUserBySocialIdentity(identity *models.UserIdentity, user *models.User) error
+	// CreateUserWithIdentity creates a user signing up with an identity provider
+	CreateUserWithIdentity(user *models.User, identity *models.UserIdentity) error
+	// SaveUserIdentity links an identity to the user, or refreshes it if it already is
+	SaveUserIdentity(identity *models.UserIdentity) error
 	// GetUserByResetToken returns the user via reset token
 	//GetUserByResetToken(resetToken string, user *models.User) error
 
@@ -62,12 +77,37 @@ type ZENAUTHProvider interface {
 	ConsumeUserResetToken(user *models.User) error
 	// ClearUserResetToken
 	ClearUserResetToken(user *models.User) error
+	// CreateUserMagicLinkToken will update a users magic link token based on email
+	CreateUserMagicLinkToken(user *models.User) error
+	// ConsumeUserMagicLinkToken clears the magic link token and verifies the email, if the token matches
+	ConsumeUserMagicLinkToken(user *models.User) error
 	// CreateUser creates a user
 	CreateUser(user *models.User) error
 	// DeleteUser deletes a user (by user id)
 	DeleteUser(user *models.User) error
+	// SoftDeleteUser marks the user as deleted, the user lookups leave them out from then on
+	SoftDeleteUser(user *models.User) error
+	// GetDeletedUserByEmail retrieves a user via email who was deleted after since
+	GetDeletedUserByEmail(user *models.User, since time.Time) error
+	// GetDeletedUserByID retrieves a user via id who was deleted after since
+	GetDeletedUserByID(user *models.User, since time.Time) error
+	// RestoreUser undoes the deletion of a user who was deleted after since
+	RestoreUser(user *models.User, since time.Time) error
+	// PurgeDeletedUsers hard deletes the users deleted before before, returning how many
+	PurgeDeletedUsers(before time.Time) (int, error)
+	// PurgeUser hard deletes the user right away, deleted or not
+	PurgeUser(user *models.User) error
+	// SearchUsers gets a page of the users matching the search, in creation order
+	SearchUsers(search *models.UserSearch, users *models.Users) error
+	// SetUserDisabled disables the user, or enables them again
+	SetUserDisabled(user *models.User, disabled bool) error
+	// SetUserHash sets the password of a user, whatever it was before
+	SetUserHash(newHash string, user *models.User) error
 	// MergeUsers merges the users, with the first user taking precedence.
 	MergeUsers(firstUser, secondUser *models.User) error
+	// ImportUsers creates the users in one transaction, a user that fails is rolled back alone.
+	// Returns the error of each user, nil for the ones created
+	ImportUsers(users models.Users) ([]error, error)
 	// GetUsernameCount counts this username
 	GetUsernameCount(username string) (int, error)
 
@@ -85,4 +125,126 @@ type ZENAUTHProvider interface {
 	GetInvitationByEmail(invite *models.Invitation) error
 	// DeleteInvitationByEmail deletes an invite by email
 	DeleteInvitationByEmail(invite *models.Invitation) error
+
+	// CreateRefreshToken stores a new refresh token
+	CreateRefreshToken(token *models.RefreshToken) error
+	// RotateRefreshToken consumes the token matching old.TokenHash and stores next in the same family.
+	// Reusing a consumed token revokes the whole family
+	RotateRefreshToken(old, next *models.RefreshToken) error
+	// RevokeRefreshTokenFamily revokes every refresh token in the family
+	RevokeRefreshTokenFamily(familyID string) error
+
+	// RevokeToken revokes a single auth token by its JTI
+	RevokeToken(userID, jti string, expiresAt time.Time) error
+	// RevokeAllUserTokens logs the user out everywhere, revoking every auth and refresh token issued
+	// to them up until now, down to the microsecond
+	RevokeAllUserTokens(userID string) error
+	// IsTokenRevoked checks whether a user's auth token has been revoked
+	IsTokenRevoked(userID, jti string, issuedAt time.Time) (bool, error)
+
+	// SetUserTOTPSecret stores a new TOTP secret, pending confirmation
+	SetUserTOTPSecret(user *models.User) error
+	// EnableUserTOTP enables TOTP once the pending secret has been confirmed
+	EnableUserTOTP(user *models.User) error
+	// DisableUserTOTP disables TOTP and removes the secret
+	DisableUserTOTP(user *models.User) error
+	// ConsumeUserTOTPStep records the time step of a used code so it can't be replayed
+	ConsumeUserTOTPStep(user *models.User, step int64) error
+	// ReplaceRecoveryCodes replaces all of the user's recovery codes
+	ReplaceRecoveryCodes(userID string, codes *models.RecoveryCodeList) error
+	// GetUnusedRecoveryCodes gets the user's recovery codes that haven't been used yet
+	GetUnusedRecoveryCodes(userID string, codes *models.RecoveryCodeList) error
+	// ConsumeRecoveryCode marks a recovery code as used
+	ConsumeRecoveryCode(code *models.RecoveryCode) error
+
+	// GetPasswordHistory gets the last limit password hashes of the user, newest first
+	GetPasswordHistory(userID string, limit int, history *models.PasswordHistoryList) error
+	// AddPasswordHistory adds a password hash to the user's history, keeping only the last keep
+	AddPasswordHistory(entry *models.PasswordHistory, keep int) error
+
+	// CreateAuditEntry records an event in the audit log
+	CreateAuditEntry(entry *models.AuditEntry) error
+
+	// GetLoginThrottles gets the failed login counters of the keys
+	GetLoginThrottles(keys ...string) ([]models.LoginThrottle, error)
+	// RecordLoginFailure counts a failed login against throttle.Key
+	RecordLoginFailure(throttle *models.LoginThrottle, policy helpers.LoginThrottlePolicy) error
+	// ClearLoginThrottle forgets the failed logins of a key
+	ClearLoginThrottle(key string) error
+
+	// TakeRateLimitToken takes a token from the rate limit bucket of key
+	TakeRateLimitToken(key string, limit helpers.RateLimit) (helpers.RateLimitResult, error)
+	// DeleteRateLimitBuckets deletes the rate limit buckets not used since before
+	DeleteRateLimitBuckets(before time.Time) error
+
+	// CreateWebAuthnCredential stores a newly registered credential
+	CreateWebAuthnCredential(credential *models.WebAuthnCredential) error
+	// GetWebAuthnCredential gets a credential by its credential id
+	GetWebAuthnCredential(credential *models.WebAuthnCredential) error
+	// GetUserWebAuthnCredentials gets all of the user's credentials
+	GetUserWebAuthnCredentials(userID string, credentials *models.WebAuthnCredentials) error
+	// UpdateWebAuthnCredentialSignCount records a successful assertion
+	UpdateWebAuthnCredentialSignCount(credential *models.WebAuthnCredential, signCount int64) error
+	// DeleteWebAuthnCredential deletes one of the user's credentials
+	DeleteWebAuthnCredential(credential *models.WebAuthnCredential) error
+
+	// CreateOAuthClient registers a client of the OpenID Connect provider
+	CreateOAuthClient(client *models.OAuthClient) error
+	// GetOAuthClient gets a client by its client id
+	GetOAuthClient(client *models.OAuthClient) error
+	// CreateOAuthAuthorizationCode stores a new authorization code
+	CreateOAuthAuthorizationCode(code *models.OAuthAuthorizationCode) error
+	// ConsumeOAuthAuthorizationCode marks an authorization code as used, it can only be used once
+	ConsumeOAuthAuthorizationCode(code *models.OAuthAuthorizationCode) error
+
+	// GetUserIdentities gets the identity provider accounts linked to the user
+	GetUserIdentities(userID string, identities *models.UserIdentities) error
+	// GetInvitationsByCreator gets the pending invitations the user sent
+	GetInvitationsByCreator(userID string, invitations *models.Invitations) error
+	// GetUserRefreshTokens gets the user's refresh tokens, oldest first
+	GetUserRefreshTokens(userID string, tokens *models.RefreshTokens) error
+	// GetUserAuditEntries gets the user's audit log, oldest first
+	GetUserAuditEntries(userID string, entries *models.AuditEntries) error
+	// CreateUserExport stores an export, deleting the ones that expired
+	CreateUserExport(export *models.UserExport) error
+	// GetUserExport gets an export by id, if it hasn't expired
+	GetUserExport(export *models.UserExport) error
+	// GetLatestUserExport gets the user's most recent export
+	GetLatestUserExport(userID string, export *models.UserExport) error
+
+	// GetRoles gets all the roles with their permissions, by name
+	GetRoles(roles *models.Roles) error
+	// GetRoleByName gets a role with its permissions
+	GetRoleByName(role *models.Role) error
+	// SaveRole creates the role, or updates the one of that name, replacing its permissions
+	SaveRole(role *models.Role) error
+	// DeleteRole deletes a role by name, taking it away from its users
+	DeleteRole(role *models.Role) error
+	// GetPermissions gets all the permissions, by name
+	GetPermissions(permissions *models.Permissions) error
+	// DeletePermission deletes a permission by name, taking it away from the roles that have it
+	DeletePermission(permission *models.Permission) error
+	// GetUserRoles gets the roles of the user with their permissions
+	GetUserRoles(userID string, roles *models.Roles) error
+	// AssignUserRole gives the role of that name to the user
+	AssignUserRole(userID, roleName string) error
+	// UnassignUserRole takes the role of that name away from the user
+	UnassignUserRole(userID, roleName string) error
+	// GetUserAccess gets the names of the user's roles and of the permissions they give
+	GetUserAccess(userID string, access *models.UserAccess) error
+	// HasPermission checks whether one of the user's roles gives them the permission, deleted and disabled users have none
+	HasPermission(userID, permission string) (bool, error)
+
+	// CreateTenant creates a tenant
+	CreateTenant(tenant *models.Tenant) error
+	// GetTenants gets all the tenants, by name
+	GetTenants(tenants *models.Tenants) error
+	// GetTenantByID gets a tenant via id
+	GetTenantByID(tenant *models.Tenant) error
+	// GetTenantByAPITokenHash gets the tenant of an API token via its hash
+	GetTenantByAPITokenHash(tenant *models.Tenant) error
+	// UpdateTenantAPITokenHash replaces the API token of the tenant
+	UpdateTenantAPITokenHash(tenant *models.Tenant) error
+	// DeleteTenant deletes a tenant along with its users and invitations
+	DeleteTenant(tenant *models.Tenant) error
 }
@@ -0,0 +1,82 @@
+package data
+
+import (
+	"sync"
+	"time"
+
+	"github.com/axiomzen/null"
+	"github.com/axiomzen/zenauth/config"
+	"github.com/axiomzen/zenauth/constants"
+	"github.com/axiomzen/zenauth/helpers"
+	"github.com/axiomzen/zenauth/models"
+	"gopkg.in/pg.v4"
+)
+
+// TakeRateLimitToken takes a token from the bucket of key. The row is locked while
+// taking so concurrent requests on other replicas share the bucket
+func (dp *dataProvider) TakeRateLimitToken(key string, limit helpers.RateLimit) (helpers.RateLimitResult, error) {
+	var result helpers.RateLimitResult
+	err := dp.Tx(func(tx *pg.Tx) error {
+		bucket := models.RateLimitBucket{Key: key}
+		if _, err := tx.Model(&bucket).OnConflict("DO NOTHING").Create(); err != nil {
+			return err
+		}
+		if _, err := tx.QueryOne(&bucket, `SELECT * FROM rate_limit_buckets WHERE key = ? FOR UPDATE`, key); err != nil {
+			return err
+		}
+		now := time.Now()
+		bucket.Tokens, result = limit.Take(bucket.Tokens, bucket.UpdatedAt.Time, now)
+		bucket.UpdatedAt = null.TimeFrom(now)
+		return tx.Update(&bucket)
+	})
+	return result, wrapError(err)
+}
+
+// DeleteRateLimitBuckets deletes the buckets not used since before, which have filled up again
+func (dp *dataProvider) DeleteRateLimitBuckets(before time.Time) error {
+	_, err := dp.db.Model(&models.RateLimitBucket{}).Where("updated_at < ?", before).Delete()
+	return wrapError(err)
+}
+
+// RateLimiter keeps rate limit buckets in the database, so the limits are shared by every replica
+type RateLimiter struct {
+	DAL ZENAUTHProvider
+	// MaxPeriod is the longest period of the limits, buckets unused for longer are deleted
+	MaxPeriod time.Duration
+
+	mu        sync.Mutex
+	lastPrune time.Time
+}
+
+// Take takes a token from the bucket of key
+func (l *RateLimiter) Take(key string, limit helpers.RateLimit) (helpers.RateLimitResult, error) {
+	l.mu.Lock()
+	prune := time.Since(l.lastPrune) > l.MaxPeriod
+	if prune {
+		l.lastPrune = time.Now()
+	}
+	l.mu.Unlock()
+	if prune {
+		go func() {
+			// best effort, the next prune will get what this one missed
+			_ = l.DAL.DeleteRateLimitBuckets(time.Now().Add(-l.MaxPeriod))
+		}()
+	}
+	return l.DAL.TakeRateLimitToken(key, limit)
+}
+
+// NewRateLimiter returns the rate limiter of the configured backend
+func NewRateLimiter(conf *config.ZENAUTHConfig, dal ZENAUTHProvider) helpers.RateLimiter {
+	if conf.RateLimitBackend != constants.RateLimitBackendPostgres {
+		return helpers.NewMemoryRateLimiter()
+	}
+	limiter := &RateLimiter{DAL: dal, MaxPeriod: time.Hour}
+	for _, limits := range []map[string]helpers.RateLimit{conf.RouteRateLimits, conf.GRPCMethodRateLimits} {
+		for _, limit := range limits {
+			if limit.Period > limiter.MaxPeriod {
+				limiter.MaxPeriod = limit.Period
+			}
+		}
+	}
+	return limiter
+}
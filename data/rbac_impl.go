@@ -0,0 +1,174 @@
+package data
+
+import (
+	"github.com/axiomzen/zenauth/models"
+	"gopkg.in/pg.v4"
+	"gopkg.in/pg.v4/orm"
+)
+
+// loadRolePermissions sets the names of the permissions of each role
+func loadRolePermissions(db orm.DB, roles models.Roles) error {
+	if len(roles) == 0 {
+		return nil
+	}
+	byID := make(map[string]*models.Role, len(roles))
+	ids := make([]string, 0, len(roles))
+	for _, role := range roles {
+		role.Permissions = []string{}
+		byID[role.ID] = role
+		ids = append(ids, role.ID)
+	}
+	var rows []struct {
+		RoleID string
+		Name   string
+	}
+	if _, err := db.Query(&rows, `SELECT rp.role_id, p.name FROM role_permissions rp
+		JOIN permissions p ON p.id = rp.permission_id
+		WHERE rp.role_id IN (?) ORDER BY p.name`, pg.In(ids)); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		role := byID[row.RoleID]
+		role.Permissions = append(role.Permissions, row.Name)
+	}
+	return nil
+}
+
+// GetRoles gets all the roles with their permissions, by name
+func (dp *dataProvider) GetRoles(roles *models.Roles) error {
+	if err := dp.db.Model(roles).Order("name ASC").Select(); err != nil {
+		return wrapError(err)
+	}
+	return wrapError(loadRolePermissions(dp.db, *roles))
+}
+
+// GetRoleByName gets a role with its permissions
+func (dp *dataProvider) GetRoleByName(role *models.Role) error {
+	if err := dp.db.Model(role).Where("name = ?name").Select(); err != nil {
+		return wrapError(err)
+	}
+	return wrapError(loadRolePermissions(dp.db, models.Roles{role}))
+}
+
+// SaveRole creates the role, or updates the one of that name. Its permissions are replaced
+// with role.Permissions, which are created if they don't exist yet
+func (dp *dataProvider) SaveRole(role *models.Role) error {
+	permissions := role.Permissions
+	return wrapError(dp.Tx(func(tx *pg.Tx) error {
+		if _, err := tx.Model(role).
+			OnConflict("(name) DO UPDATE SET description = EXCLUDED.description").
+			Returning("*").
+			Create(); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`DELETE FROM role_permissions WHERE role_id = ?`, role.ID); err != nil {
+			return err
+		}
+		role.Permissions = []string{}
+		if len(permissions) == 0 {
+			return nil
+		}
+		for _, name := range permissions {
+			if _, err := tx.Exec(`INSERT INTO permissions (name) VALUES (?) ON CONFLICT (name) DO NOTHING`, name); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.Exec(`INSERT INTO role_permissions (role_id, permission_id)
+			SELECT ?, id FROM permissions WHERE name IN (?)`, role.ID, pg.In(permissions)); err != nil {
+			return err
+		}
+		return loadRolePermissions(tx, models.Roles{role})
+	}))
+}
+
+// DeleteRole deletes a role by name, taking it away from its users
+func (dp *dataProvider) DeleteRole(role *models.Role) error {
+	res, err := dp.db.Model(role).Where("name = ?name").Delete()
+	if err == nil {
+		if res.Affected() != 1 {
+			return DALError{Inner: errNoneAffected, ErrorCode: DALErrorCodeNoneAffected}
+		}
+	}
+	return wrapError(err)
+}
+
+// GetPermissions gets all the permissions, by name
+func (dp *dataProvider) GetPermissions(permissions *models.Permissions) error {
+	return wrapError(dp.db.Model(permissions).Order("name ASC").Select())
+}
+
+// DeletePermission deletes a permission by name, taking it away from the roles that have it
+func (dp *dataProvider) DeletePermission(permission *models.Permission) error {
+	res, err := dp.db.Model(permission).Where("name = ?name").Delete()
+	if err == nil {
+		if res.Affected() != 1 {
+			return DALError{Inner: errNoneAffected, ErrorCode: DALErrorCodeNoneAffected}
+		}
+	}
+	return wrapError(err)
+}
+
+// GetUserRoles gets the roles of the user with their permissions, by name
+func (dp *dataProvider) GetUserRoles(userID string, roles *models.Roles) error {
+	err := dp.db.Model(roles).
+		Where("id IN (SELECT role_id FROM user_roles WHERE user_id = ?)", userID).
+		Order("name ASC").
+		Select()
+	if err != nil {
+		return wrapError(err)
+	}
+	return wrapError(loadRolePermissions(dp.db, *roles))
+}
+
+// AssignUserRole gives the role of that name to the user, if they don't have it already
+func (dp *dataProvider) AssignUserRole(userID, roleName string) error {
+	role := models.Role{Name: roleName}
+	if err := dp.db.Model(&role).Column("id").Where("name = ?name").Select(); err != nil {
+		return wrapError(err)
+	}
+	userRole := models.UserRole{UserID: userID, RoleID: role.ID}
+	_, err := dp.db.Model(&userRole).OnConflict("DO NOTHING").Create()
+	return wrapError(err)
+}
+
+// UnassignUserRole takes the role of that name away from the user
+func (dp *dataProvider) UnassignUserRole(userID, roleName string) error {
+	res, err := dp.db.Exec(`DELETE FROM user_roles WHERE user_id = ? AND role_id = (SELECT id FROM roles WHERE name = ?)`, userID, roleName)
+	if err == nil {
+		if res.Affected() != 1 {
+			return DALError{Inner: errNoneAffected, ErrorCode: DALErrorCodeNoneAffected}
+		}
+	}
+	return wrapError(err)
+}
+
+// GetUserAccess gets the names of the user's roles and of the permissions they give
+func (dp *dataProvider) GetUserAccess(userID string, access *models.UserAccess) error {
+	var roles, permissions pg.Strings
+	if _, err := dp.db.Query(&roles, `SELECT r.name FROM roles r
+		JOIN user_roles ur ON ur.role_id = r.id
+		WHERE ur.user_id = ? ORDER BY r.name`, userID); err != nil {
+		return wrapError(err)
+	}
+	if _, err := dp.db.Query(&permissions, `SELECT DISTINCT p.name FROM permissions p
+		JOIN role_permissions rp ON rp.permission_id = p.id
+		JOIN user_roles ur ON ur.role_id = rp.role_id
+		WHERE ur.user_id = ? ORDER BY p.name`, userID); err != nil {
+		return wrapError(err)
+	}
+	access.Roles, access.Permissions = []string(roles), []string(permissions)
+	return nil
+}
+
+// HasPermission checks whether one of the user's roles gives them the permission.
+// Deleted and disabled users, and the users of other tenants, have none
+func (dp *dataProvider) HasPermission(userID, permission string) (bool, error) {
+	var allowed bool
+	_, err := dp.db.QueryOne(pg.Scan(&allowed), `SELECT EXISTS (SELECT 1 FROM user_roles ur
+		JOIN users u ON u.id = ur.user_id
+		JOIN role_permissions rp ON rp.role_id = ur.role_id
+		JOIN permissions p ON p.id = rp.permission_id
+		WHERE ur.user_id = ? AND p.name = ? AND u.tenant_id = ? AND u.deleted_at IS NULL AND u.disabled_at IS NULL)`,
+		userID, permission, dp.tenantID)
+	return allowed, wrapError(err)
+}
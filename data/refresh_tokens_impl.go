@@ -0,0 +1,59 @@
+package data
+
+import (
+	"time"
+
+	"github.com/axiomzen/zenauth/models"
+	"gopkg.in/pg.v4"
+)
+
+// CreateRefreshToken stores a new refresh token
+func (dp *dataProvider) CreateRefreshToken(token *models.RefreshToken) error {
+	return wrapError(dp.db.Create(token))
+}
+
+// RotateRefreshToken consumes the token matching old.TokenHash and stores next in the same family.
+// If old was already consumed (or revoked) the whole family is revoked,
+// as the token has most likely been stolen
+func (dp *dataProvider) RotateRefreshToken(old, next *models.RefreshToken) error {
+	reused := false
+	err := dp.Tx(func(tx *pg.Tx) error {
+		if _, err := tx.QueryOne(old, `SELECT * FROM refresh_tokens WHERE token_hash = ? FOR UPDATE`, old.TokenHash); err != nil {
+			return err
+		}
+		if old.UsedAt.Valid || old.RevokedAt.Valid {
+			reused = true
+			// commit the revocation, the error is returned after
+			return revokeRefreshTokenFamily(tx, old.FamilyID)
+		}
+		if time.Now().After(old.ExpiresAt) {
+			return DALError{Inner: errTokenExpired, ErrorCode: DALErrorCodeTokenExpired}
+		}
+		if _, err := tx.Model(old).Set("used_at = now()").Where("id = ?id").Returning("*").Update(); err != nil {
+			return err
+		}
+		next.UserID = old.UserID
+		next.FamilyID = old.FamilyID
+		return tx.Create(next)
+	})
+	if err == nil && reused {
+		return DALError{Inner: errTokenReused, ErrorCode: DALErrorCodeTokenReused}
+	}
+	return wrapError(err)
+}
+
+// RevokeRefreshTokenFamily revokes every refresh token in the family
+func (dp *dataProvider) RevokeRefreshTokenFamily(familyID string) error {
+	return wrapError(dp.Tx(func(tx *pg.Tx) error {
+		return revokeRefreshTokenFamily(tx, familyID)
+	}))
+}
+
+func revokeRefreshTokenFamily(tx *pg.Tx, familyID string) error {
+	_, err := tx.Model(&models.RefreshToken{}).
+		Set("revoked_at = now()").
+		Where("family_id = ?", familyID).
+		Where("revoked_at IS NULL").
+		Update()
+	return err
+}
@@ -0,0 +1,210 @@
+package data
+
+import (
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/axiomzen/null"
+	"github.com/axiomzen/zenauth/models"
+	"gopkg.in/pg.v4"
+)
+
+// userRevocations are the revocations that still matter for a user
+type userRevocations struct {
+	issuedBefore time.Time
+	jtis         map[string]struct{}
+	loadedAt     time.Time
+}
+
+// revoked checks a token against the revocations
+func (r *userRevocations) revoked(jti string, issuedAt time.Time) bool {
+	if issuedAt.Before(r.issuedBefore) {
+		return true
+	}
+	_, ok := r.jtis[jti]
+	return ok
+}
+
+// revocationsChannel is the Postgres channel revocations are notified on, with the id of the user
+const revocationsChannel = "token_revocations"
+
+// revocationsRetryInterval is how long to wait before listening for revocations again after the
+// connection failed
+const revocationsRetryInterval = 5 * time.Second
+
+// revocationCache caches revocations per user so we don't
+// hit the database on every authenticated request. Every replica
+// drops the user's entry when any of them notifies a revocation
+type revocationCache struct {
+	sync.RWMutex
+	ttl   time.Duration
+	users map[string]*userRevocations
+	// done is closed when the provider is closed, to stop listening
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func newRevocationCache(ttl time.Duration) *revocationCache {
+	return &revocationCache{ttl: ttl, users: make(map[string]*userRevocations), done: make(chan struct{})}
+}
+
+func (rc *revocationCache) get(userID string) (*userRevocations, bool) {
+	rc.RLock()
+	defer rc.RUnlock()
+	r, ok := rc.users[userID]
+	if !ok || time.Since(r.loadedAt) > rc.ttl {
+		return nil, false
+	}
+	return r, true
+}
+
+func (rc *revocationCache) set(userID string, r *userRevocations) {
+	rc.Lock()
+	defer rc.Unlock()
+	// drop stale entries while we are here, so the cache doesn't grow forever
+	for id, cached := range rc.users {
+		if time.Since(cached.loadedAt) > rc.ttl {
+			delete(rc.users, id)
+		}
+	}
+	rc.users[userID] = r
+}
+
+func (rc *revocationCache) invalidate(userID string) {
+	rc.Lock()
+	defer rc.Unlock()
+	delete(rc.users, userID)
+}
+
+func (rc *revocationCache) clear() {
+	rc.Lock()
+	defer rc.Unlock()
+	rc.users = make(map[string]*userRevocations)
+}
+
+func (rc *revocationCache) stop() {
+	rc.stopOnce.Do(func() { close(rc.done) })
+}
+
+// notifyRevocation tells every replica, once tx commits, to drop their cached revocations of the user
+func notifyRevocation(tx *pg.Tx, userID string) error {
+	_, err := tx.Exec("SELECT pg_notify(?, ?)", revocationsChannel, userID)
+	return err
+}
+
+// listenForRevocations keeps the cache in sync with the revocations of the other replicas until
+// the provider is closed. Revocations notified while the connection is down are lost, so the
+// whole cache is dropped when listening again; until then entries are only as fresh as the ttl
+func (dp *dataProvider) listenForRevocations() {
+	for {
+		listener, err := dp.db.Listen(revocationsChannel)
+		if err != nil {
+			log.WithError(err).Error("Could not listen for token revocations")
+		} else {
+			dp.revocations.clear()
+			dp.receiveRevocations(listener)
+		}
+		select {
+		case <-dp.revocations.done:
+			return
+		case <-time.After(revocationsRetryInterval):
+		}
+	}
+}
+
+// receiveRevocations drops the cached revocations of the users notified on the listener, until
+// its connection fails or the provider is closed
+func (dp *dataProvider) receiveRevocations(listener *pg.Listener) {
+	received := make(chan struct{})
+	defer close(received)
+	go func() {
+		select {
+		case <-dp.revocations.done:
+			listener.Close()
+		case <-received:
+		}
+	}()
+	defer listener.Close()
+	for {
+		_, userID, err := listener.Receive()
+		if err != nil {
+			select {
+			case <-dp.revocations.done:
+			default:
+				log.WithError(err).Error("Stopped listening for token revocations")
+			}
+			return
+		}
+		dp.revocations.invalidate(userID)
+	}
+}
+
+// RevokeToken revokes a single auth token by its JTI
+func (dp *dataProvider) RevokeToken(userID, jti string, expiresAt time.Time) error {
+	revoked := models.RevokedToken{
+		UserID:    userID,
+		JTI:       null.StringFrom(jti),
+		ExpiresAt: expiresAt,
+	}
+	err := dp.Tx(func(tx *pg.Tx) error {
+		if _, err := tx.Model(&revoked).OnConflict("DO NOTHING").Create(); err != nil {
+			return err
+		}
+		return notifyRevocation(tx, userID)
+	})
+	dp.revocations.invalidate(userID)
+	return wrapError(err)
+}
+
+// RevokeAllUserTokens revokes every auth token issued to the user up until now, along with all
+// of the user's refresh tokens. Postgres keeps microseconds, as do token issue times, so tokens
+// issued in the same microsecond are revoked as well
+func (dp *dataProvider) RevokeAllUserTokens(userID string) error {
+	now := time.Now()
+	err := dp.Tx(func(tx *pg.Tx) error {
+		revoked := models.RevokedToken{
+			UserID:       userID,
+			IssuedBefore: null.TimeFrom(now.Truncate(time.Microsecond).Add(time.Microsecond)),
+			// any token issued before now will have expired by then
+			ExpiresAt: now.Add(dp.authTokenDuration),
+		}
+		if err := tx.Create(&revoked); err != nil {
+			return err
+		}
+		_, err := tx.Model(&models.RefreshToken{}).
+			Set("revoked_at = now()").
+			Where("user_id = ?", userID).
+			Where("revoked_at IS NULL").
+			Update()
+		if err != nil {
+			return err
+		}
+		return notifyRevocation(tx, userID)
+	})
+	dp.revocations.invalidate(userID)
+	return wrapError(err)
+}
+
+// IsTokenRevoked checks whether a user's auth token has been revoked
+func (dp *dataProvider) IsTokenRevoked(userID, jti string, issuedAt time.Time) (bool, error) {
+	if r, ok := dp.revocations.get(userID); ok {
+		return r.revoked(jti, issuedAt), nil
+	}
+
+	var rows []models.RevokedToken
+	if err := dp.db.Model(&rows).Where("user_id = ?", userID).Where("expires_at > now()").Select(); err != nil {
+		return false, wrapError(err)
+	}
+	r := &userRevocations{jtis: make(map[string]struct{}, len(rows)), loadedAt: time.Now()}
+	for _, row := range rows {
+		if row.JTI.Valid {
+			r.jtis[row.JTI.String] = struct{}{}
+		}
+		if row.IssuedBefore.Valid && row.IssuedBefore.Time.After(r.issuedBefore) {
+			r.issuedBefore = row.IssuedBefore.Time
+		}
+	}
+	dp.revocations.set(userID, r)
+	return r.revoked(jti, issuedAt), nil
+}
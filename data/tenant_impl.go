@@ -0,0 +1,70 @@
+package data
+
+import (
+	"github.com/axiomzen/zenauth/models"
+	"gopkg.in/pg.v4"
+)
+
+// ForTenant returns the data provider of the tenant, sharing the connections of this one
+func (dp *dataProvider) ForTenant(tenantID string) ZENAUTHProvider {
+	scoped := *dp
+	scoped.tenantID = tenantID
+	return &scoped
+}
+
+// TenantID returns the tenant the user and invitation queries are scoped to
+func (dp *dataProvider) TenantID() string {
+	return dp.tenantID
+}
+
+// CreateTenant creates a tenant
+func (dp *dataProvider) CreateTenant(tenant *models.Tenant) error {
+	_, err := dp.db.Model(tenant).Returning("*").Create()
+	return wrapError(err)
+}
+
+// GetTenants gets all the tenants, by name
+func (dp *dataProvider) GetTenants(tenants *models.Tenants) error {
+	return wrapError(dp.db.Model(tenants).Order("name ASC").Select())
+}
+
+// GetTenantByID gets a tenant via id
+func (dp *dataProvider) GetTenantByID(tenant *models.Tenant) error {
+	return wrapError(dp.db.Model(tenant).Where("id = ?id").Select())
+}
+
+// GetTenantByAPITokenHash gets the tenant of an API token via its hash
+func (dp *dataProvider) GetTenantByAPITokenHash(tenant *models.Tenant) error {
+	return wrapError(dp.db.Model(tenant).Where("api_token_hash = ?api_token_hash").Select())
+}
+
+// UpdateTenantAPITokenHash replaces the API token of the tenant, the previous one stops working
+func (dp *dataProvider) UpdateTenantAPITokenHash(tenant *models.Tenant) error {
+	res, err := dp.db.Model(tenant).Set("api_token_hash = ?api_token_hash").Where("id = ?id").Returning("*").Update()
+	if err == nil {
+		if res.Affected() != 1 {
+			return DALError{Inner: errNoneAffected, ErrorCode: DALErrorCodeNoneAffected}
+		}
+	}
+	return wrapError(err)
+}
+
+// DeleteTenant deletes a tenant along with its users, purged as if they were deleted, and invitations
+func (dp *dataProvider) DeleteTenant(tenant *models.Tenant) error {
+	return wrapError(dp.Tx(func(tx *pg.Tx) error {
+		var ids pg.Strings
+		if _, err := tx.Query(&ids, `SELECT id FROM users WHERE tenant_id = ? FOR UPDATE`, tenant.ID); err != nil {
+			return err
+		}
+		for _, id := range ids {
+			if _, err := purgeUser(tx, id); err != nil {
+				return err
+			}
+		}
+		res, err := tx.Model(tenant).Where("id = ?id").Delete()
+		if err == nil && res.Affected() != 1 {
+			return DALError{Inner: errNoneAffected, ErrorCode: DALErrorCodeNoneAffected}
+		}
+		return err
+	}))
+}
@@ -0,0 +1,93 @@
+package data
+
+import (
+	"time"
+
+	"github.com/axiomzen/zenauth/models"
+	"gopkg.in/pg.v4"
+)
+
+// SoftDeleteUser marks the user as deleted, the user lookups leave them out from then on
+func (dp *dataProvider) SoftDeleteUser(user *models.User) error {
+	res, err := dp.db.Model(user).Set("deleted_at = now()").Where("id = ?id").Where("deleted_at IS NULL").Where("tenant_id = ?", dp.tenantID).Returning("*").Update()
+	if err == nil {
+		if res.Affected() != 1 {
+			return DALError{Inner: errNoneAffected, ErrorCode: DALErrorCodeNoneAffected}
+		}
+	}
+	return wrapError(err)
+}
+
+// GetDeletedUserByEmail retrieves a user via email who was deleted after since
+func (dp *dataProvider) GetDeletedUserByEmail(user *models.User, since time.Time) error {
+	return wrapError(dp.db.Model(user).Where("email = ?email").Where("deleted_at > ?", since).Where("tenant_id = ?", dp.tenantID).Select())
+}
+
+// GetDeletedUserByID retrieves a user via id who was deleted after since
+func (dp *dataProvider) GetDeletedUserByID(user *models.User, since time.Time) error {
+	return wrapError(dp.db.Model(user).Where("id = ?id").Where("deleted_at > ?", since).Where("tenant_id = ?", dp.tenantID).Select())
+}
+
+// RestoreUser undoes the deletion of a user who was deleted after since
+func (dp *dataProvider) RestoreUser(user *models.User, since time.Time) error {
+	res, err := dp.db.Model(user).Set("deleted_at = NULL").Where("id = ?id").Where("deleted_at > ?", since).Where("tenant_id = ?", dp.tenantID).Returning("*").Update()
+	if err == nil {
+		if res.Affected() != 1 {
+			return DALError{Inner: errNoneAffected, ErrorCode: DALErrorCodeNoneAffected}
+		}
+	}
+	return wrapError(err)
+}
+
+// PurgeDeletedUsers hard deletes the users deleted before before, with what is left of them in
+// tables without a foreign key to users. Returns the number of users purged
+func (dp *dataProvider) PurgeDeletedUsers(before time.Time) (int, error) {
+	var ids pg.Strings
+	err := dp.Tx(func(tx *pg.Tx) error {
+		if _, err := tx.Query(&ids, `SELECT id FROM users WHERE deleted_at < ? FOR UPDATE SKIP LOCKED`, before); err != nil {
+			return err
+		}
+		for _, id := range ids {
+			if _, err := purgeUser(tx, id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, wrapError(err)
+	}
+	return len(ids), nil
+}
+
+// PurgeUser hard deletes the user right away, deleted or not
+func (dp *dataProvider) PurgeUser(user *models.User) error {
+	return wrapError(dp.Tx(func(tx *pg.Tx) error {
+		affected, err := purgeUser(tx, user.ID)
+		if err == nil && affected != 1 {
+			return DALError{Inner: errNoneAffected, ErrorCode: DALErrorCodeNoneAffected}
+		}
+		return err
+	}))
+}
+
+// purgeUser deletes the user along with their rows in tables without a foreign key to users.
+// Returns the number of users deleted
+func purgeUser(tx *pg.Tx, id string) (int, error) {
+	if _, err := tx.Exec(`DELETE FROM login_throttles WHERE key = ?`, models.LoginThrottleAccountKey(id)); err != nil {
+		return 0, err
+	}
+	// the invitations they sent name people who never signed up
+	if _, err := tx.Exec(`DELETE FROM invitations WHERE created_by = ?`, id); err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec(`UPDATE users SET invited_by = NULL WHERE invited_by = ?`, id); err != nil {
+		return 0, err
+	}
+	// the rest of their rows cascade
+	res, err := tx.Exec(`DELETE FROM users WHERE id = ?`, id)
+	if err != nil {
+		return 0, err
+	}
+	return res.Affected(), nil
+}
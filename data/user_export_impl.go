@@ -0,0 +1,80 @@
+package data
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/axiomzen/zenauth/models"
+	"gopkg.in/pg.v4"
+)
+
+// GetUserIdentities gets the identity provider accounts linked to the user
+func (dp *dataProvider) GetUserIdentities(userID string, identities *models.UserIdentities) error {
+	return wrapError(dp.db.Model(identities).Where("user_id = ?", userID).Order("created_at").Select())
+}
+
+// GetInvitationsByCreator gets the pending invitations the user sent
+func (dp *dataProvider) GetInvitationsByCreator(userID string, invitations *models.Invitations) error {
+	return wrapError(dp.db.Model(invitations).Where("created_by = ?", userID).Order("created_at").Select())
+}
+
+// GetUserRefreshTokens gets the user's refresh tokens, oldest first
+func (dp *dataProvider) GetUserRefreshTokens(userID string, tokens *models.RefreshTokens) error {
+	return wrapError(dp.db.Model(tokens).Where("user_id = ?", userID).Order("created_at").Select())
+}
+
+// GetUserAuditEntries gets the user's audit log, oldest first
+func (dp *dataProvider) GetUserAuditEntries(userID string, entries *models.AuditEntries) error {
+	return wrapError(dp.db.Model(entries).Where("user_id = ?", userID).Order("created_at").Select())
+}
+
+// CreateUserExport stores an export, deleting the ones that expired
+func (dp *dataProvider) CreateUserExport(export *models.UserExport) error {
+	return wrapError(dp.Tx(func(tx *pg.Tx) error {
+		if _, err := tx.Exec(`DELETE FROM user_exports WHERE expires_at < now()`); err != nil {
+			return err
+		}
+		return tx.Create(export)
+	}))
+}
+
+// GetUserExport gets an export by id, if it hasn't expired
+func (dp *dataProvider) GetUserExport(export *models.UserExport) error {
+	return wrapError(dp.db.Model(export).Where("id = ?id").Where("expires_at > now()").Select())
+}
+
+// GetLatestUserExport gets the user's most recent export
+func (dp *dataProvider) GetLatestUserExport(userID string, export *models.UserExport) error {
+	return wrapError(dp.db.Model(export).Where("user_id = ?", userID).Order("created_at DESC").Limit(1).Select())
+}
+
+// ExportUser gathers what we hold about the user and stores it as an export that expires at expiresAt
+func ExportUser(dal ZENAUTHProvider, user *models.User, expiresAt time.Time) (*models.UserExport, error) {
+	var (
+		identities models.UserIdentities
+		sent       models.Invitations
+		tokens     models.RefreshTokens
+		audit      models.AuditEntries
+	)
+	if err := dal.GetUserIdentities(user.ID, &identities); err != nil {
+		return nil, err
+	}
+	if err := dal.GetInvitationsByCreator(user.ID, &sent); err != nil {
+		return nil, err
+	}
+	if err := dal.GetUserRefreshTokens(user.ID, &tokens); err != nil {
+		return nil, err
+	}
+	if err := dal.GetUserAuditEntries(user.ID, &audit); err != nil {
+		return nil, err
+	}
+	bundle, err := json.MarshalIndent(models.NewUserExportBundle(user, identities, sent, tokens, audit), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	export := &models.UserExport{UserID: user.ID, Data: bundle, ExpiresAt: expiresAt}
+	if err := dal.CreateUserExport(export); err != nil {
+		return nil, err
+	}
+	return export, nil
+}
@@ -0,0 +1,130 @@
+package data
+
+import (
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/axiomzen/zenauth/helpers"
+	"github.com/axiomzen/zenauth/models"
+	"gopkg.in/pg.v4"
+)
+
+// ImportUsers creates the users in one transaction. A user that can't be created, e.g. because the
+// email is taken, is rolled back alone. Returns the error of each user, nil for the ones created
+func (dp *dataProvider) ImportUsers(users models.Users) ([]error, error) {
+	userErrs := make([]error, len(users))
+	err := dp.Tx(func(tx *pg.Tx) error {
+		for i, user := range users {
+			if _, err := tx.Exec(`SAVEPOINT user_import`); err != nil {
+				return err
+			}
+			if err := dp.createUser(tx, user); err != nil {
+				userErrs[i] = wrapError(err)
+				if _, err := tx.Exec(`ROLLBACK TO SAVEPOINT user_import`); err != nil {
+					return err
+				}
+				continue
+			}
+			if _, err := tx.Exec(`RELEASE SAVEPOINT user_import`); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return userErrs, wrapError(err)
+}
+
+// importedUser returns the user to create for an import row
+func importedUser(row *models.UserImport) (*models.User, error) {
+	user := &models.User{}
+	user.Email = helpers.EmailSanitize(row.Email)
+	if strings.Count(user.Email, "@") == 0 {
+		return nil, errors.New("invalid email")
+	}
+	user.UserName = row.UserName
+	user.Verified = row.Verified
+	if row.HashAlgorithm == "" && row.Hash == "" {
+		// they can set a password with a password reset
+		return user, nil
+	}
+	hash, err := helpers.ForeignPasswordHash{
+		Algorithm:     row.HashAlgorithm,
+		Hash:          row.Hash,
+		Salt:          row.Salt,
+		SaltSeparator: row.SaltSeparator,
+		SignerKey:     row.SignerKey,
+		Rounds:        row.Rounds,
+		MemCost:       row.MemCost,
+		SaltPosition:  row.SaltPosition,
+	}.Encode()
+	if err != nil {
+		return nil, err
+	}
+	user.Hash = &hash
+	return user, nil
+}
+
+// ImportUserFile imports the users read from reader in transactions of batchSize users.
+// The report has the error of every row that was not imported; err is only set when the
+// import had to stop, the report then covers the rows up to there
+func ImportUserFile(dal ZENAUTHProvider, reader *models.UserImportReader, batchSize int) (report models.UserImportReport, err error) {
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	report.Errors = []models.UserImportError{}
+	var (
+		batch models.Users
+		lines []int
+	)
+	fail := func(line int, email string, err error) {
+		report.Failed++
+		report.Errors = append(report.Errors, models.UserImportError{Line: line, Email: email, Error: err.Error()})
+	}
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		userErrs, err := dal.ImportUsers(batch)
+		if err != nil {
+			return err
+		}
+		for i, userErr := range userErrs {
+			if userErr != nil {
+				fail(lines[i], batch[i].Email, userErr)
+			} else {
+				report.Imported++
+			}
+		}
+		batch, lines = nil, nil
+		return nil
+	}
+
+	for {
+		var row models.UserImport
+		line, readErr := reader.Next(&row)
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			if line == 0 {
+				return report, readErr
+			}
+			fail(line, row.Email, readErr)
+			continue
+		}
+		user, userErr := importedUser(&row)
+		if userErr != nil {
+			fail(line, row.Email, userErr)
+			continue
+		}
+		batch = append(batch, user)
+		lines = append(lines, line)
+		if len(batch) >= batchSize {
+			if err := flush(); err != nil {
+				return report, err
+			}
+		}
+	}
+	return report, flush()
+}
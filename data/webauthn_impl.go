@@ -0,0 +1,48 @@
+package data
+
+import (
+	"github.com/axiomzen/zenauth/models"
+)
+
+// CreateWebAuthnCredential stores a newly registered credential
+func (dp *dataProvider) CreateWebAuthnCredential(credential *models.WebAuthnCredential) error {
+	return wrapError(dp.db.Create(credential))
+}
+
+// GetWebAuthnCredential gets a credential by its credential id
+func (dp *dataProvider) GetWebAuthnCredential(credential *models.WebAuthnCredential) error {
+	return wrapError(dp.db.Model(credential).Where("credential_id = ?credential_id").Select())
+}
+
+// GetUserWebAuthnCredentials gets all of the user's credentials
+func (dp *dataProvider) GetUserWebAuthnCredentials(userID string, credentials *models.WebAuthnCredentials) error {
+	return wrapError(dp.db.Model(credentials).Where("user_id = ?", userID).Order("created_at").Select())
+}
+
+// UpdateWebAuthnCredentialSignCount records a successful assertion.
+// Fails with DALErrorCodeNoneAffected if a concurrent assertion already moved the count past it
+func (dp *dataProvider) UpdateWebAuthnCredentialSignCount(credential *models.WebAuthnCredential, signCount int64) error {
+	res, err := dp.db.Model(credential).
+		Set("sign_count = ?, last_used_at = now()", signCount).
+		Where("id = ?id").
+		Where("sign_count = ?sign_count").
+		Returning("*").
+		Update()
+	if err == nil {
+		if res.Affected() != 1 {
+			return DALError{Inner: errNoneAffected, ErrorCode: DALErrorCodeNoneAffected}
+		}
+	}
+	return wrapError(err)
+}
+
+// DeleteWebAuthnCredential deletes one of the user's credentials
+func (dp *dataProvider) DeleteWebAuthnCredential(credential *models.WebAuthnCredential) error {
+	res, err := dp.db.Model(credential).Where("id = ?id").Where("user_id = ?user_id").Delete()
+	if err == nil {
+		if res.Affected() != 1 {
+			return DALError{Inner: errNoneAffected, ErrorCode: DALErrorCodeNoneAffected}
+		}
+	}
+	return wrapError(err)
+}
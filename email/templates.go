@@ -6,6 +6,7 @@ import (
 	"html/template"
 	"net/url"
 	"path/filepath"
+	"time"
 
 	"github.com/axiomzen/zenauth/config"
 	"github.com/axiomzen/zenauth/models"
@@ -15,33 +16,52 @@ var resetPasswordHTMLTmpl *template.Template
 var resetPasswordTextTmpl *template.Template
 var verifyEmailHTMLTmpl *template.Template
 var verifyEmailTextTmpl *template.Template
-
-func init() {
-	conf, err := config.Get()
-	if err != nil {
-		panic(err)
-	}
+var magicLinkHTMLTmpl *template.Template
+var magicLinkTextTmpl *template.Template
+var userExportHTMLTmpl *template.Template
+var userExportTextTmpl *template.Template
+
+// LoadTemplates parses the email templates of the configuration. It must be called before any
+// message is built
+func LoadTemplates(conf *config.ZENAUTHConfig) error {
 	templates, err := template.ParseGlob(filepath.Join(conf.TemplatesPath, "*.tmpl"))
 	if err != nil {
-		panic(err)
+		return err
 	}
 
 	resetPasswordHTMLTmpl = templates.Lookup("reset_password.html.tmpl")
 	if resetPasswordHTMLTmpl == nil {
-		panic(fmt.Errorf("Reset password HTML template not found"))
+		return fmt.Errorf("Reset password HTML template not found")
 	}
 	resetPasswordTextTmpl = templates.Lookup("reset_password.txt.tmpl")
 	if resetPasswordTextTmpl == nil {
-		panic(fmt.Errorf("Reset password TEXT template not found"))
+		return fmt.Errorf("Reset password TEXT template not found")
 	}
 	verifyEmailHTMLTmpl = templates.Lookup("verify_email.html.tmpl")
 	if verifyEmailHTMLTmpl == nil {
-		panic(fmt.Errorf("Verify email HTML template not found"))
+		return fmt.Errorf("Verify email HTML template not found")
 	}
 	verifyEmailTextTmpl = templates.Lookup("verify_email.txt.tmpl")
 	if verifyEmailTextTmpl == nil {
-		panic(fmt.Errorf("Verify email TEXT template not found"))
+		return fmt.Errorf("Verify email TEXT template not found")
+	}
+	magicLinkHTMLTmpl = templates.Lookup(conf.MagicLinkTemplate + ".html.tmpl")
+	if magicLinkHTMLTmpl == nil {
+		return fmt.Errorf("Magic link HTML template %s not found", conf.MagicLinkTemplate)
 	}
+	magicLinkTextTmpl = templates.Lookup(conf.MagicLinkTemplate + ".txt.tmpl")
+	if magicLinkTextTmpl == nil {
+		return fmt.Errorf("Magic link TEXT template %s not found", conf.MagicLinkTemplate)
+	}
+	userExportHTMLTmpl = templates.Lookup("user_export.html.tmpl")
+	if userExportHTMLTmpl == nil {
+		return fmt.Errorf("User export HTML template not found")
+	}
+	userExportTextTmpl = templates.Lookup("user_export.txt.tmpl")
+	if userExportTextTmpl == nil {
+		return fmt.Errorf("User export TEXT template not found")
+	}
+	return nil
 }
 
 // GetResetPasswordMessage returns a Message instance for the reset password action
@@ -115,3 +135,79 @@ func GetVerifyEmailMessage(conf *config.ZENAUTHConfig, user *models.User) (*Mess
 
 	return &message, nil
 }
+
+// GetMagicLinkMessage returns a Message instance for the passwordless login action
+func GetMagicLinkMessage(conf *config.ZENAUTHConfig, user *models.User) (*Message, error) {
+	message := Message{}
+	message.Subject = fmt.Sprintf("[%v] Sign In", conf.AppName)
+	message.From = fmt.Sprintf("%v <%v>", conf.AppName, conf.MailGunFrom)
+	message.To = []string{user.Email}
+
+	loginURL, err := url.Parse(conf.MagicLinkURL)
+	if err != nil {
+		return nil, err
+	}
+	query := loginURL.Query()
+	query.Add("token", *user.MagicLinkToken)
+	query.Add("email", user.Email)
+	loginURL.RawQuery = query.Encode()
+
+	variables := map[string]string{
+		"title": message.Subject,
+		"URL":   loginURL.String(),
+	}
+
+	bufHTML := &bytes.Buffer{}
+	if err := magicLinkHTMLTmpl.Execute(bufHTML, variables); err != nil {
+		return nil, err
+	}
+	message.BodyHTML = bufHTML.String()
+
+	bufText := &bytes.Buffer{}
+	if err := magicLinkTextTmpl.Execute(bufText, variables); err != nil {
+		return nil, err
+	}
+	message.Body = bufText.String()
+
+	return &message, nil
+}
+
+// GetUserExportMessage returns a Message instance with the download link of the user's data export
+func GetUserExportMessage(conf *config.ZENAUTHConfig, user *models.User, token string) (*Message, error) {
+	message := Message{}
+	message.Subject = fmt.Sprintf("[%v] Your Data Export", conf.AppName)
+	message.From = fmt.Sprintf("%v <%v>", conf.AppName, conf.MailGunFrom)
+	message.To = []string{user.Email}
+
+	downloadURL, err := url.Parse(conf.UserExportURL)
+	if err != nil {
+		return nil, err
+	}
+	query := downloadURL.Query()
+	query.Add("token", token)
+	downloadURL.RawQuery = query.Encode()
+
+	expires := conf.UserExportValidDuration.String()
+	if days := conf.UserExportValidDuration / (24 * time.Hour); days > 0 && conf.UserExportValidDuration%(24*time.Hour) == 0 {
+		expires = fmt.Sprintf("%d days", days)
+	}
+	variables := map[string]string{
+		"title":   message.Subject,
+		"URL":     downloadURL.String(),
+		"expires": expires,
+	}
+
+	bufHTML := &bytes.Buffer{}
+	if err := userExportHTMLTmpl.Execute(bufHTML, variables); err != nil {
+		return nil, err
+	}
+	message.BodyHTML = bufHTML.String()
+
+	bufText := &bytes.Buffer{}
+	if err := userExportTextTmpl.Execute(bufText, variables); err != nil {
+		return nil, err
+	}
+	message.Body = bufText.String()
+
+	return &message, nil
+}
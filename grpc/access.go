@@ -0,0 +1,102 @@
+package grpc
+
+import (
+	"crypto/subtle"
+	"strings"
+
+	"github.com/axiomzen/zenauth/constants"
+	context "golang.org/x/net/context"
+	"google.golang.org/grpc/metadata"
+)
+
+// methodAccess is what a method needs from the caller
+type methodAccess int
+
+const (
+	// accessAPIToken methods only need the API token
+	accessAPIToken methodAccess = iota
+	// accessNone methods don't need anything, not even the API token, and have no tenant
+	accessNone
+	// accessUser methods need the auth token of a user, whose id is in the context
+	accessUser
+	// accessAdmin methods need the admin token
+	accessAdmin
+)
+
+// methodAccesses declares the access of the methods by full name. Calls to methods that aren't
+// declared are refused
+var methodAccesses = map[string]methodAccess{
+	"/protobuf.Auth/GetCurrentUser":        accessUser,
+	"/protobuf.Auth/GetUserByID":           accessUser,
+	"/protobuf.Auth/LinkUser":              accessUser,
+	"/protobuf.Auth/GetUsersByIDs":         accessUser,
+	"/protobuf.Auth/GetUsersByFacebookIDs": accessUser,
+	"/protobuf.Auth/AuthUserByEmail":       accessAPIToken,
+	"/protobuf.Auth/AuthUserByFacebook":    accessAPIToken,
+	"/protobuf.Auth/UpdateUserEmail":       accessUser,
+	"/protobuf.Auth/UpdateUserName":        accessUser,
+	"/protobuf.Auth/RefreshToken":          accessAPIToken,
+	"/protobuf.Auth/AuthUserByMFA":         accessAPIToken,
+	"/protobuf.Auth/AuthUserBySocial":      accessAPIToken,
+	// the current user's permissions need their auth token, see CheckPermission
+	"/protobuf.Auth/CheckPermission": accessAPIToken,
+	"/protobuf.Auth/ListRoles":       accessAdmin,
+	"/protobuf.Auth/SaveRole":        accessAdmin,
+	"/protobuf.Auth/DeleteRole":      accessAdmin,
+	"/protobuf.Auth/GetUserRoles":    accessAdmin,
+	"/protobuf.Auth/AssignRole":      accessAdmin,
+	"/protobuf.Auth/UnassignRole":    accessAdmin,
+	// the account operations of the REST API, see account.Service
+	"/protobuf.Auth/ChangePassword":            accessUser,
+	"/protobuf.Auth/ForgotPassword":            accessAPIToken,
+	"/protobuf.Auth/ResetPassword":             accessAPIToken,
+	"/protobuf.Auth/VerifyEmail":               accessAPIToken,
+	"/protobuf.Auth/CreateEmailInvitations":    accessUser,
+	"/protobuf.Auth/CreateFacebookInvitations": accessUser,
+	"/protobuf.Auth/Exists":                    accessAPIToken,
+	"/protobuf.Auth/LinkFacebook":              accessUser,
+	// health checks and reflection, see Server
+	"/grpc.health.v1.Health/Check":                                   accessNone,
+	"/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo": accessNone,
+}
+
+// userIDContextKey is the context key of the id of the user authenticated for the call
+type userIDContextKey struct{}
+
+// authorize checks the caller has the access the method is declared with, and puts the id of
+// the user in the context of accessUser methods. It runs after the API token is checked
+func (auth *Auth) authorize(ctx context.Context, fullMethod string) (context.Context, error) {
+	access, ok := methodAccesses[fullMethod]
+	if !ok {
+		return ctx, apiErrorf(constants.APIUnauthorized, "No access declared for %s", fullMethod)
+	}
+	switch access {
+	case accessUser:
+		userID, err := auth.getUserID(ctx)
+		if err != nil {
+			return ctx, err
+		}
+		return context.WithValue(ctx, userIDContextKey{}, userID), nil
+	case accessAdmin:
+		return ctx, auth.checkAdminToken(ctx)
+	}
+	return ctx, nil
+}
+
+// callUserID returns the id of the user authenticated for the call of an accessUser method
+func callUserID(ctx context.Context) string {
+	userID, _ := ctx.Value(userIDContextKey{}).(string)
+	return userID
+}
+
+// checkAdminToken errs unless the admin token is in the metadata, as on the admin routes
+func (auth *Auth) checkAdminToken(ctx context.Context) error {
+	var adminToken string
+	if md, ok := metadata.FromContext(ctx); ok && len(md[strings.ToLower(auth.Config.AdminTokenHeader)]) > 0 {
+		adminToken = md[strings.ToLower(auth.Config.AdminTokenHeader)][0]
+	}
+	if auth.Config.AdminToken == "" || subtle.ConstantTimeCompare([]byte(adminToken), []byte(auth.Config.AdminToken)) != 1 {
+		return apiError(constants.APIAdminUnauthorized, "Not authorized")
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package grpc
+
+import (
+	"github.com/axiomzen/zenauth/account"
+	"github.com/axiomzen/zenauth/constants"
+	"github.com/axiomzen/zenauth/helpers"
+	"github.com/axiomzen/zenauth/models"
+	"github.com/axiomzen/zenauth/protobuf"
+	pEmpty "github.com/golang/protobuf/ptypes/empty"
+	"golang.org/x/net/context"
+)
+
+// account returns the account operations of the call, in its tenant. Their errors are turned
+// into statuses by statusError
+func (auth *Auth) account(ctx context.Context) *account.Service {
+	return &account.Service{
+		Config:    auth.Config,
+		DAL:       auth.dal(ctx),
+		Log:       auth.log(ctx),
+		ClientIP:  auth.clientIP(ctx),
+		UserAgent: userAgent(ctx),
+	}
+}
+
+// ChangePassword changes the password of the current user after checking their old one. Every
+// session of the user is logged out, the user gets new tokens
+func (auth *Auth) ChangePassword(ctx context.Context, change *protobuf.PasswordChange) (*protobuf.User, error) {
+	var user models.User
+	if err := auth.account(ctx).ChangePassword(callUserID(ctx), change.GetOldPassword(), change.GetNewPassword(), &user); err != nil {
+		return nil, err
+	}
+	if err := auth.account(ctx).IssueTokens(&user); err != nil {
+		return nil, err
+	}
+	return user.Protobuf()
+}
+
+// ForgotPassword emails the user with the email the link to reset their password
+func (auth *Auth) ForgotPassword(ctx context.Context, forgot *protobuf.PasswordForgot) (*pEmpty.Empty, error) {
+	if forgot.GetEmail() == "" {
+		return nil, apiError(constants.APIValidationEmailNotValid, "Missing email")
+	}
+	if err := auth.account(ctx).SendPasswordReset(helpers.EmailSanitize(forgot.GetEmail())); err != nil {
+		return nil, err
+	}
+	return &pEmpty.Empty{}, nil
+}
+
+// ResetPassword sets the new password of the user the reset token was sent to, and logs them in
+func (auth *Auth) ResetPassword(ctx context.Context, reset *protobuf.PasswordReset) (*protobuf.User, error) {
+	userPasswordReset := models.UserPasswordReset{
+		Email:       reset.GetEmail(),
+		Token:       reset.GetToken(),
+		NewPassword: reset.GetNewPassword(),
+	}
+	var user models.User
+	if err := auth.account(ctx).ResetPassword(&userPasswordReset, &user); err != nil {
+		return nil, err
+	}
+	if err := auth.account(ctx).IssueTokens(&user); err != nil {
+		return nil, err
+	}
+	return user.Protobuf()
+}
+
+// VerifyEmail marks the email of a verification link's token as verified
+func (auth *Auth) VerifyEmail(ctx context.Context, verification *protobuf.EmailVerification) (*protobuf.User, error) {
+	var user models.User
+	if err := auth.account(ctx).VerifyEmail(verification.GetToken(), verification.GetEmail(), &user); err != nil {
+		return nil, err
+	}
+	return user.Protobuf()
+}
+
+// createInvitations invites the codes of the type on behalf of the current user
+func (auth *Auth) createInvitations(ctx context.Context, invitationType string, codes []string) (*protobuf.UsersPublic, error) {
+	invitations, err := auth.account(ctx).CreateInvitations(callUserID(ctx), invitationType, codes)
+	if err != nil {
+		return nil, err
+	}
+	users := &protobuf.UsersPublic{Users: make([]*protobuf.UserPublic, len(invitations))}
+	for idx, invitation := range invitations {
+		if users.Users[idx], err = invitation.UserPublicProtobuf(); err != nil {
+			return nil, apiError(constants.APIInvitationsCreationError, err.Error())
+		}
+	}
+	return users, nil
+}
+
+// CreateEmailInvitations invites the emails on behalf of the current user
+func (auth *Auth) CreateEmailInvitations(ctx context.Context, invites *protobuf.InviteCodes) (*protobuf.UsersPublic, error) {
+	return auth.createInvitations(ctx, constants.InvitationTypeEmail, invites.GetInviteCodes())
+}
+
+// CreateFacebookInvitations invites the Facebook IDs on behalf of the current user
+func (auth *Auth) CreateFacebookInvitations(ctx context.Context, invites *protobuf.InviteCodes) (*protobuf.UsersPublic, error) {
+	return auth.createInvitations(ctx, constants.InvitationTypeFacebook, invites.GetInviteCodes())
+}
+
+// Exists tells whether a user has the email or the username
+func (auth *Auth) Exists(ctx context.Context, check *protobuf.ExistsCheck) (*protobuf.ExistsResult, error) {
+	if check.GetEmail() == "" && check.GetUserName() == "" {
+		return nil, apiError(constants.APIInvalidRequest, "Email or username expected")
+	}
+	return &protobuf.ExistsResult{Exists: auth.account(ctx).Exists(check.GetEmail(), check.GetUserName())}, nil
+}
+
+// LinkFacebook links a Facebook account to the current user, who gets new tokens
+func (auth *Auth) LinkFacebook(ctx context.Context, facebookAuth *protobuf.UserFacebookAuth) (*protobuf.User, error) {
+	fbUser := models.FacebookUser{
+		FacebookID:       facebookAuth.GetFacebookID(),
+		FacebookEmail:    facebookAuth.GetFacebookEmail(),
+		FacebookUsername: facebookAuth.GetFacebookUsername(),
+		FacebookToken:    facebookAuth.GetFacebookToken(),
+	}
+	var user models.User
+	if err := auth.account(ctx).LinkFacebook(callUserID(ctx), &fbUser, &user); err != nil {
+		return nil, err
+	}
+	if err := auth.account(ctx).IssueTokens(&user); err != nil {
+		return nil, err
+	}
+	return user.Protobuf()
+}
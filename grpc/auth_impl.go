@@ -10,6 +10,7 @@ import (
 	"google.golang.org/grpc/metadata"
 
 	"github.com/Sirupsen/logrus"
+	"github.com/axiomzen/zenauth/account"
 	"github.com/axiomzen/zenauth/config"
 	"github.com/axiomzen/zenauth/constants"
 	"github.com/axiomzen/zenauth/data"
@@ -28,17 +29,19 @@ type Auth struct {
 
 // GetCurrentUser implements the action to return the user from the session token.
 func (auth *Auth) GetCurrentUser(ctx context.Context, _ *pEmpty.Empty) (*protobuf.User, error) {
-	userID, err := auth.getUserID(ctx)
-	if err != nil {
-		return nil, err
-	}
+	userID := callUserID(ctx)
 	var user models.User
 	user.ID = userID
 
 	// get user
-	if err := auth.DAL.GetUserByID(&user); err != nil {
+	if err := auth.dal(ctx).GetUserByID(&user); err != nil {
 		return nil, err
 	}
+	var access models.UserAccess
+	if err := auth.dal(ctx).GetUserAccess(user.ID, &access); err != nil {
+		return nil, err
+	}
+	user.Roles = access.Roles
 
 	return user.Protobuf()
 }
@@ -46,17 +49,11 @@ func (auth *Auth) GetCurrentUser(ctx context.Context, _ *pEmpty.Empty) (*protobu
 // GetUserByID implements the action to return the user from the ID.
 func (auth *Auth) GetUserByID(ctx context.Context, userID *protobuf.UserID) (*protobuf.UserPublic, error) {
 
-	// Get the current user to make sure it's an authenticated request
-	_, err := auth.getUserID(ctx)
-	if err != nil {
-		return nil, err
-	}
-
 	var user models.User
 	user.ID = userID.GetId()
 
 	// get user
-	if err := auth.DAL.GetUserByID(&user); err != nil {
+	if err := auth.dal(ctx).GetUserByID(&user); err != nil {
 		return nil, err
 	}
 
@@ -66,16 +63,13 @@ func (auth *Auth) GetUserByID(ctx context.Context, userID *protobuf.UserID) (*pr
 // LinkUser implements the action to link a user
 func (auth *Auth) LinkUser(ctx context.Context, invite *protobuf.InvitationCode) (*protobuf.UserPublic, error) {
 	if !constants.InvitationTypes[invite.GetType()] {
-		return nil, fmt.Errorf("Invitation type %s not supported", invite.GetType())
-	}
-	userID, err := auth.getUserID(ctx)
-	if err != nil {
-		return nil, err
+		return nil, apiErrorf(constants.APIInvalidRequest, "Invitation type %s not supported", invite.GetType())
 	}
+	userID := callUserID(ctx)
 	var user models.User
 	user.ID = userID
 	// get user
-	if err := auth.DAL.GetUserByID(&user); err != nil {
+	if err := auth.dal(ctx).GetUserByID(&user); err != nil {
 		return nil, err
 	}
 
@@ -84,12 +78,13 @@ func (auth *Auth) LinkUser(ctx context.Context, invite *protobuf.InvitationCode)
 		Code: invite.GetInviteCode(),
 		Type: invite.GetType(),
 	}
-	if err := auth.DAL.GetInvitation(&invitation); err == nil {
+	if err := auth.dal(ctx).GetInvitation(&invitation); err == nil {
+		user.InvitedBy, user.InvitedAt = invitation.CreatedBy, invitation.CreatedAt
 		if userInfoUpdateErr := invitation.UpdateUserWithInvitationInfo(&user); userInfoUpdateErr != nil {
 			return nil, userInfoUpdateErr
-		} else if userInfoUpdateErr = auth.DAL.UpdateUser(&user, &user); userInfoUpdateErr != nil {
+		} else if userInfoUpdateErr = auth.dal(ctx).UpdateUser(&user, &user); userInfoUpdateErr != nil {
 			return nil, userInfoUpdateErr
-		} else if delInvErr := auth.DAL.DeleteInvitation(&invitation); delInvErr != nil {
+		} else if delInvErr := auth.dal(ctx).DeleteInvitation(&invitation); delInvErr != nil {
 			return nil, delInvErr
 		}
 		userPub, err := invitation.UserPublicProtobuf()
@@ -107,11 +102,11 @@ func (auth *Auth) LinkUser(ctx context.Context, invite *protobuf.InvitationCode)
 	case constants.InvitationTypeEmail:
 		user.Email = invite.GetInviteCode()
 		linkToUser.Email = invite.GetInviteCode()
-		linkUserErr = auth.DAL.GetUserByEmail(&linkToUser)
+		linkUserErr = auth.dal(ctx).GetUserByEmail(&linkToUser)
 	case constants.InvitationTypeFacebook:
 		user.FacebookID = invite.GetInviteCode()
 		linkToUser.FacebookID = invite.GetInviteCode()
-		linkUserErr = auth.DAL.GetUserByFacebookID(&linkToUser)
+		linkUserErr = auth.dal(ctx).GetUserByFacebookID(&linkToUser)
 	default:
 		// Should never get here as we check the invite type above,
 		linkUserErr = fmt.Errorf("Invitation type %s not supported", invite.GetType())
@@ -119,16 +114,16 @@ func (auth *Auth) LinkUser(ctx context.Context, invite *protobuf.InvitationCode)
 
 	if linkUserErr == nil {
 		// User found, delete and return
-		if mergeUserErr := auth.DAL.MergeUsers(&user, &linkToUser); mergeUserErr != nil {
+		if mergeUserErr := auth.dal(ctx).MergeUsers(&user, &linkToUser); mergeUserErr != nil {
 			return nil, mergeUserErr
 		}
 		mergedUser, returnErr := linkToUser.ProtobufPublic()
 		mergedUser.Status = protobuf.UserStatus_merged
 		return mergedUser, returnErr
 	}
-	auth.Log.WithError(linkUserErr).Debug("Could not retrieve social account to link")
+	auth.log(ctx).WithError(linkUserErr).Debug("Could not retrieve social account to link")
 
-	if err := auth.DAL.UpdateUser(&user, &user); err != nil {
+	if err := auth.dal(ctx).UpdateUser(&user, &user); err != nil {
 		return nil, err
 	}
 	return user.ProtobufPublic()
@@ -137,12 +132,6 @@ func (auth *Auth) LinkUser(ctx context.Context, invite *protobuf.InvitationCode)
 // GetUsersByIDs implements the action to return the user from the ID.
 func (auth *Auth) GetUsersByIDs(ctx context.Context, userIDs *protobuf.UserIDs) (*protobuf.UsersPublic, error) {
 
-	// Get the current user to make sure it's an authenticated request
-	_, err := auth.getUserID(ctx)
-	if err != nil {
-		return nil, err
-	}
-
 	var users models.Users
 	for _, id := range userIDs.GetIds() {
 		users = append(users, &models.User{
@@ -151,7 +140,7 @@ func (auth *Auth) GetUsersByIDs(ctx context.Context, userIDs *protobuf.UserIDs)
 	}
 
 	// get users
-	if err := auth.DAL.GetUsersByIDs(&users); err != nil {
+	if err := auth.dal(ctx).GetUsersByIDs(&users); err != nil {
 		return nil, err
 	}
 
@@ -161,23 +150,45 @@ func (auth *Auth) GetUsersByIDs(ctx context.Context, userIDs *protobuf.UserIDs)
 // GetUsersByFacebookIDs implements the action to return a list of users from their facebook IDs.
 func (auth *Auth) GetUsersByFacebookIDs(ctx context.Context, userIDs *protobuf.UserIDs) (*protobuf.UsersPublic, error) {
 
-	// Get the current user to make sure it's an authenticated request
-	_, err := auth.getUserID(ctx)
-	if err != nil {
-		return nil, err
-	}
-
 	var users models.Users
 
 	// get users
-	if err := auth.DAL.GetUsersByFacebookIDs(userIDs.GetIds(), &users); err != nil {
+	if err := auth.dal(ctx).GetUsersByFacebookIDs(userIDs.GetIds(), &users); err != nil {
 		return nil, err
 	}
-	auth.Log.Info(userIDs.GetIds(), users)
+	auth.log(ctx).Info(userIDs.GetIds(), users)
 
 	return users.ProtobufPublic()
 }
 
+// loginUser issues the user's tokens, or the mfa token the client finishes logging in with
+// through AuthUserByMFA if the user enabled two factor authentication
+func (auth *Auth) loginUser(ctx context.Context, user *models.User) (*protobuf.User, error) {
+	mfaToken := ""
+	if user.TOTPEnabled {
+		if err := account.CheckUserEnabled(user); err != nil {
+			return nil, err
+		}
+		var err error
+		if mfaToken, err = auth.account(ctx).NewMFAToken(user.ID); err != nil {
+			return nil, apiError(constants.APIAuthTokenCreation, err.Error())
+		}
+	}
+	return auth.loggedInUser(ctx, user, mfaToken)
+}
+
+// loggedInUser returns the user of a login with their new tokens, or only the mfa token
+// if the login still needs a two factor code
+func (auth *Auth) loggedInUser(ctx context.Context, user *models.User, mfaToken string) (*protobuf.User, error) {
+	if mfaToken != "" {
+		return &protobuf.User{Id: user.ID, Status: protobuf.UserStatus_mfa_required, MfaToken: mfaToken}, nil
+	}
+	if err := auth.account(ctx).IssueTokens(user); err != nil {
+		return nil, err
+	}
+	return user.Protobuf()
+}
+
 // AuthUserByEmail implements the action to either signup or login
 func (auth *Auth) AuthUserByEmail(ctx context.Context, emailAuth *protobuf.UserEmailAuth) (*protobuf.User, error) {
 
@@ -189,82 +200,104 @@ func (auth *Auth) AuthUserByEmail(ctx context.Context, emailAuth *protobuf.UserE
 	}
 	var err error
 	if auth.Config.RequireUsername {
-		err = auth.DAL.GetUserByEmailOrUserName(&user)
+		err = auth.dal(ctx).GetUserByEmailOrUserName(&user)
 	} else {
-		err = auth.DAL.GetUserByEmail(&user)
+		err = auth.dal(ctx).GetUserByEmail(&user)
 	}
 	if err == nil {
 		// Can just login
-		// check that they have a password - not sure how they wouldn't
-		if helpers.IsZeroString(user.Hash) {
-			return nil, fmt.Errorf("%d: Wrong account type (No password saved)", constants.APIIncorrectAccountType)
+		mfaToken, err := auth.account(ctx).Login(&user, emailAuth.GetPassword())
+		if err != nil {
+			return nil, auth.loginError(ctx, err)
 		}
-
-		if passwordOK, err := helpers.CheckPasswordBcrypt(*user.Hash, emailAuth.GetPassword()); err != nil {
-			return nil, fmt.Errorf("%d: %s", constants.APIParsingPasswordHash, err.Error())
-		} else if !passwordOK {
-			// wrong password
-			return nil, fmt.Errorf("%d: Invalid email/username/password combination", constants.APILoginSignupInvalidCombination)
-		}
-		authToken, tokenErr := auth.NewAuthToken(user.ID)
-		if tokenErr != nil {
-			return nil, tokenErr
-		}
-		user.AuthToken = authToken
-		return user.Protobuf()
+		return auth.loggedInUser(ctx, &user, mfaToken)
 	}
 
 	if dalErr, isDALError := err.(data.DALError); isDALError && dalErr.ErrorCode != data.DALErrorCodeNoneAffected {
 		// Error getting user, not that it doesn't exist
-		return nil, fmt.Errorf("%d: %s", constants.APIDatabaseGetUser, err.Error())
+		return nil, apiError(constants.APIDatabaseGetUser, err.Error())
 	}
 	// Else, Sign Up
 	// Validate
-	// if len(emailAuth.GetPassword()) < int(auth.Config.MinPasswordLength) {
-	// 	// check password long enough
-	// 	return nil, fmt.Errorf("%d: Password too short", constants.APIValidationPasswordTooShort)
-	// } else
 	if strings.Count(user.Email, "@") == 0 {
 		// check email
-		return nil, fmt.Errorf("%d: Invalid Email", constants.APIValidationEmailNotValid)
+		return nil, apiError(constants.APIValidationEmailNotValid, "Invalid Email")
 	} else if auth.Config.RequireUsername && user.UserName == "" {
-		return nil, fmt.Errorf("%d: Please enter a username", constants.APIValidationUserNameNotValid)
+		return nil, apiError(constants.APIValidationUserNameNotValid, "Please enter a username")
+	}
+	if policyErr := auth.account(ctx).CheckPasswordPolicy(emailAuth.GetPassword(), &user); policyErr != nil {
+		return nil, policyErr
 	}
 
-	hash, hashErr := helpers.HashPasswordBcrypt(emailAuth.GetPassword(), int(auth.Config.BcryptCost))
+	hash, hashErr := auth.Config.PasswordHasher.Hash(emailAuth.GetPassword())
 
 	if hashErr != nil {
-		return nil, fmt.Errorf("%d: %s", constants.APIParsingPasswordHash, hashErr.Error())
+		return nil, apiError(constants.APIParsingPasswordHash, hashErr.Error())
 	}
 
 	user.Hash = &hash
 
-	if userErr := auth.DAL.CreateUser(&user); userErr != nil {
-		return nil, userErr
+	if userErr := auth.dal(ctx).CreateUser(&user); userErr != nil {
+		return nil, dalError(userErr, constants.APIDatabaseCreateUser)
 	}
-	// Generate the auth token
-	authToken, tokenErr := auth.NewAuthToken(user.ID)
-	if tokenErr != nil {
-		return nil, fmt.Errorf("%d: %s", constants.APIAuthTokenCreation, tokenErr.Error())
+	auth.account(ctx).RecordPasswordHistory(user.ID, hash)
+	// Generate the auth and refresh tokens
+	if tokenErr := auth.account(ctx).IssueTokens(&user); tokenErr != nil {
+		return nil, tokenErr
 	}
-	user.AuthToken = authToken
 	protoUser, protoErr := user.Protobuf()
 	protoUser.Status = protobuf.UserStatus_new
 	return protoUser, protoErr
 }
 
+// AuthUserByMFA exchanges the mfa token returned by a login and a TOTP code for the user's tokens
+func (auth *Auth) AuthUserByMFA(ctx context.Context, mfaLogin *protobuf.MFALogin) (*protobuf.User, error) {
+	jwt := helpers.JWTHelper{HashSecretBytes: auth.Config.HashSecretBytes, Keys: auth.Config.JwtKeyring, Token: mfaLogin.GetMfaToken()}
+	jwtTokenResult := jwt.Validate(auth.Config.JwtClaimMFAPending)
+	switch jwtTokenResult.Status {
+	case helpers.JWTokenStatusValid:
+	case helpers.JWTokenStatusExpired:
+		return nil, apiError(constants.APIInvalidMFAToken, "MFA token is expired")
+	default:
+		return nil, apiError(constants.APIInvalidMFAToken, "Invalid mfa token")
+	}
+
+	var user models.User
+	if err := auth.account(ctx).GetMFAUser(jwtTokenResult, &user); err != nil {
+		return nil, err
+	}
+	if !user.TOTPEnabled || user.TOTPSecret == nil {
+		return nil, apiError(constants.APIInvalidMFAToken, "Invalid mfa token")
+	}
+	if err := auth.checkLoginThrottle(ctx, user.ID); err != nil {
+		return nil, err
+	}
+	if err := auth.account(ctx).CheckMFALogin(jwtTokenResult, &user, mfaLogin.GetCode()); err != nil {
+		return nil, err
+	}
+	if account.IsRestoreMFAToken(auth.Config, jwtTokenResult) {
+		if err := auth.account(ctx).RestoreUser(&user); err != nil {
+			return nil, err
+		}
+	}
+	if tokenErr := auth.account(ctx).IssueTokens(&user); tokenErr != nil {
+		return nil, tokenErr
+	}
+	return user.Protobuf()
+}
+
 // AuthUserByFacebook implements the action to return the user from the ID.
 func (auth *Auth) AuthUserByFacebook(ctx context.Context, facebookAuth *protobuf.UserFacebookAuth) (*protobuf.User, error) {
 
 	// validate
 	if facebookAuth.GetFacebookID() == "" || facebookAuth.GetFacebookToken() == "" {
-		return nil, fmt.Errorf("Missing a field in request")
+		return nil, apiError(constants.APIInvalidRequest, "Missing a field in request")
 	}
 
-	if valid, err := helpers.ValidateFacebookLogin(facebookAuth.GetFacebookID(), facebookAuth.GetFacebookToken(), auth.Config.FacebookAppID, auth.Config.FacebookAppSecret); err != nil {
+	if valid, err := helpers.ValidateFacebookLogin(auth.Config.SocialHTTPClient, facebookAuth.GetFacebookID(), facebookAuth.GetFacebookToken(), auth.Config.FacebookAppID, auth.Config.FacebookAppSecret); err != nil {
 		return nil, err
 	} else if !valid {
-		return nil, fmt.Errorf("Could not validate facebook token")
+		return nil, apiError(constants.APIFacebookLoginNotValid, "Could not validate facebook token")
 	}
 	// create a user
 	user := models.User{
@@ -276,45 +309,120 @@ func (auth *Auth) AuthUserByFacebook(ctx context.Context, facebookAuth *protobuf
 		},
 	}
 	fbAPIUser, err := helpers.GetFacebookUserInfo(
+		auth.Config.SocialHTTPClient,
 		facebookAuth.GetFacebookID(),
 		facebookAuth.GetFacebookToken(),
 		auth.Config.FacebookAppID,
 		auth.Config.FacebookAppSecret)
 	if err != nil {
-		auth.Log.WithError(err).Error("Could not retreive user profile picture")
+		auth.log(ctx).WithError(err).Error("Could not retreive user profile picture")
 	} else {
 		user.FacebookEmail = fbAPIUser.Email
 		user.FacebookUsername = fbAPIUser.Name
 		user.FacebookPicture = fbAPIUser.ProfilePictureURL()
 	}
 
-	if err := auth.DAL.UpdateUserFacebookInfo(&user); err == nil {
-		authToken, tokenErr := auth.NewAuthToken(user.ID)
-		if tokenErr != nil {
-			return nil, tokenErr
+	identity := models.UserIdentity{
+		Provider:    constants.SocialProviderFacebook,
+		Subject:     user.FacebookID,
+		Email:       user.FacebookEmail,
+		AccessToken: user.FacebookToken,
+	}
+	if err := auth.dal(ctx).UpdateUserFacebookInfo(&user); err == nil {
+		identity.UserID = user.ID
+		if err := auth.dal(ctx).SaveUserIdentity(&identity); err != nil {
+			auth.log(ctx).WithError(err).Warn("Could not update facebook identity")
 		}
-		user.AuthToken = authToken
-		return user.Protobuf()
+		return auth.loginUser(ctx, &user)
 	}
 
 	// Else signup for new legends account
 	if user.UserName == "" {
 		user.UserName = user.FacebookUsername
 	}
-	if count, err := auth.DAL.GetUsernameCount(user.UserName); err != nil {
-		auth.Log.WithError(err).Errorf("Could not count similar usernames")
+	if count, err := auth.dal(ctx).GetUsernameCount(user.UserName); err != nil {
+		auth.log(ctx).WithError(err).Errorf("Could not count similar usernames")
 	} else if count > 0 {
 		user.UserName = user.UserName + " " + strconv.Itoa(count)
 	}
 
-	if err := auth.DAL.CreateUser(&user); err != nil {
-		return nil, err
+	if err := auth.dal(ctx).CreateUserWithIdentity(&user, &identity); err != nil {
+		return nil, dalError(err, constants.APIDatabaseCreateUser)
 	}
-	authToken, tokenErr := auth.NewAuthToken(user.ID)
-	if tokenErr != nil {
+	if tokenErr := auth.account(ctx).IssueTokens(&user); tokenErr != nil {
+		return nil, tokenErr
+	}
+	protoUser, protoErr := user.Protobuf()
+	protoUser.Status = protobuf.UserStatus_new
+	return protoUser, protoErr
+}
+
+// AuthUserBySocial logs in or signs up a user with any of the configured identity providers
+func (auth *Auth) AuthUserBySocial(ctx context.Context, socialAuth *protobuf.UserSocialAuth) (*protobuf.User, error) {
+	provider, ok := auth.Config.SocialProviders[socialAuth.GetProvider()]
+	if !ok {
+		return nil, apiErrorf(constants.APISocialProviderNotFound, "Unknown provider %s", socialAuth.GetProvider())
+	}
+	social, err := provider.Authenticate(&helpers.SocialCredentials{
+		AccessToken:  socialAuth.GetAccessToken(),
+		IDToken:      socialAuth.GetIdToken(),
+		Code:         socialAuth.GetCode(),
+		RedirectURI:  socialAuth.GetRedirectURI(),
+		CodeVerifier: socialAuth.GetCodeVerifier(),
+		Nonce:        socialAuth.GetNonce(),
+		Subject:      socialAuth.GetSubject(),
+	})
+	if err != nil {
+		return nil, apiError(constants.APISocialLoginNotValid, err.Error())
+	}
+	identity := models.UserIdentity{
+		Provider:     social.Provider,
+		Subject:      social.Subject,
+		Email:        social.Email,
+		AccessToken:  social.AccessToken,
+		RefreshToken: social.RefreshToken,
+	}
+
+	var user models.User
+	err = auth.dal(ctx).GetUserBySocialIdentity(&identity, &user)
+	if err == nil {
+		identity.UserID = user.ID
+		if err := auth.dal(ctx).SaveUserIdentity(&identity); err != nil {
+			auth.log(ctx).WithError(err).Warn("Could not update identity")
+		}
+		return auth.loginUser(ctx, &user)
+	}
+	if dalErr, isDALError := err.(data.DALError); isDALError && dalErr.ErrorCode != data.DALErrorCodeNoneAffected {
+		return nil, apiError(constants.APIDatabaseGetUser, err.Error())
+	}
+
+	// Else, Sign Up
+	if social.Provider == constants.SocialProviderFacebook {
+		user.FacebookUser = models.FacebookUser{
+			FacebookID:       social.Subject,
+			FacebookToken:    social.AccessToken,
+			FacebookEmail:    social.Email,
+			FacebookUsername: social.Name,
+			FacebookPicture:  social.Picture,
+		}
+	} else if social.Email != "" {
+		user.Email = helpers.EmailSanitize(social.Email)
+		user.Verified = social.EmailVerified
+	}
+	if auth.Config.RequireUsername {
+		user.UserName = social.Name
+		if count, err := auth.dal(ctx).GetUsernameCount(user.UserName); err != nil {
+			auth.log(ctx).WithError(err).Errorf("Could not count similar usernames")
+		} else if count > 0 {
+			user.UserName = user.UserName + " " + strconv.Itoa(count)
+		}
+	}
+	if err := auth.dal(ctx).CreateUserWithIdentity(&user, &identity); err != nil {
+		return nil, dalError(err, constants.APIDatabaseCreateUser)
+	}
+	if tokenErr := auth.account(ctx).IssueTokens(&user); tokenErr != nil {
 		return nil, tokenErr
 	}
-	user.AuthToken = authToken
 	protoUser, protoErr := user.Protobuf()
 	protoUser.Status = protobuf.UserStatus_new
 	return protoUser, protoErr
@@ -323,11 +431,11 @@ func (auth *Auth) AuthUserByFacebook(ctx context.Context, facebookAuth *protobuf
 func (auth *Auth) getUserToken(ctx context.Context) (string, error) {
 	md, ok := metadata.FromContext(ctx)
 	if !ok {
-		return "", fmt.Errorf("Error getting the metadata of the context")
+		return "", apiError(constants.APIUnauthorized, "Error getting the metadata of the context")
 	}
 	tokenSlice := md[auth.Config.AuthTokenHeader]
 	if len(tokenSlice) < 1 {
-		return "", fmt.Errorf("Token header not set")
+		return "", apiError(constants.APIUnauthorized, "Token header not set")
 	}
 	return tokenSlice[0], nil
 }
@@ -337,37 +445,50 @@ func (auth *Auth) getUserID(ctx context.Context) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	jwt := helpers.JWTHelper{HashSecretBytes: auth.Config.HashSecretBytes, Token: token}
+	jwt := helpers.JWTHelper{HashSecretBytes: auth.Config.HashSecretBytes, Keys: auth.Config.JwtKeyring, Token: token}
 	jwtTokenResult := jwt.Validate(auth.Config.JwtClaimUserID)
 	switch jwtTokenResult.Status {
 	case helpers.JWTokenStatusValid:
 		if _, err := uuid.Parse(jwtTokenResult.Value); err != nil {
-			return "", err
+			return "", apiError(constants.APIInvalidAuthToken, err.Error())
+		}
+		// tokens only work in the tenant they were issued for
+		tenantID := jwtTokenResult.StringClaim(auth.Config.JwtClaimTenant)
+		if tenantID == "" {
+			tenantID = constants.DefaultTenantID
+		}
+		if tenantID != auth.dal(ctx).TenantID() {
+			return "", apiError(constants.APIInvalidAuthToken, "JWT token is not valid")
+		}
+		if revoked, err := auth.dal(ctx).IsTokenRevoked(jwtTokenResult.Value, jwtTokenResult.JTI, jwtTokenResult.IssuedAt); err != nil {
+			return "", dalError(err, constants.APIDatabaseGet)
+		} else if revoked {
+			return "", apiError(constants.APIRevokedAuthToken, "JWT token has been revoked")
 		}
 		return jwtTokenResult.Value, nil
 	case helpers.JWTokenStatusExpired:
-		return "", fmt.Errorf("JWT token is expired")
+		return "", apiError(constants.APIExpiredAuthToken, "JWT token is expired")
 	case helpers.JWTokenStatusInvalid, helpers.JWTokenNotAvailableYet:
-		return "", fmt.Errorf("JWT token is not valid")
+		return "", apiError(constants.APIInvalidAuthToken, "JWT token is not valid")
 	}
-	return "", fmt.Errorf("Unexpected status of the JWT token")
+	return "", apiError(constants.APIInvalidAuthToken, "Unexpected status of the JWT token")
 }
 
-// NewAuthToken creates a new auth token for a given user id
-func (auth *Auth) NewAuthToken(ID string) (string, error) {
-	claims := make(map[string]interface{}, 2)
-	claims[auth.Config.JwtClaimUserID] = ID
-	jwt := helpers.JWTHelper{HashSecretBytes: auth.Config.HashSecretBytes}
-	err := jwt.Generate(claims, auth.Config.JwtUserTokenDuration)
-	return jwt.Token, err
+// RefreshToken exchanges a refresh token for a new auth token and refresh token
+func (auth *Auth) RefreshToken(ctx context.Context, refresh *protobuf.RefreshTokenRequest) (*protobuf.User, error) {
+	if refresh.GetRefreshToken() == "" {
+		return nil, apiError(constants.APIInvalidRefreshToken, "Missing refresh token")
+	}
+	var user models.User
+	if err := auth.account(ctx).RefreshToken(refresh.GetRefreshToken(), &user); err != nil {
+		return nil, err
+	}
+	return user.Protobuf()
 }
 
 // UpdateUserEmail updates the users email only
 func (auth *Auth) UpdateUserEmail(ctx context.Context, user *protobuf.UserEmailAuth) (*protobuf.User, error) {
-	userID, err := auth.getUserID(ctx)
-	if err != nil {
-		return nil, err
-	}
+	userID := callUserID(ctx)
 	var userModel models.User
 	userChangeEmail := models.UserChangeEmail{
 		Email: strings.ToLower(strings.Trim(user.GetEmail(), " ")),
@@ -375,11 +496,11 @@ func (auth *Auth) UpdateUserEmail(ctx context.Context, user *protobuf.UserEmailA
 	}
 
 	if len(userChangeEmail.Email) == 0 {
-		return nil, fmt.Errorf("Empty email sent")
+		return nil, apiError(constants.APIValidationEmailNotValid, "Empty email sent")
 	}
 
 	// get user
-	if err := auth.DAL.UpdateUser(&userChangeEmail, &userModel); err != nil {
+	if err := auth.dal(ctx).UpdateUser(&userChangeEmail, &userModel); err != nil {
 		return nil, err
 	}
 
@@ -388,10 +509,7 @@ func (auth *Auth) UpdateUserEmail(ctx context.Context, user *protobuf.UserEmailA
 
 // UpdateUserName updates the users username only
 func (auth *Auth) UpdateUserName(ctx context.Context, user *protobuf.UserEmailAuth) (*protobuf.User, error) {
-	userID, err := auth.getUserID(ctx)
-	if err != nil {
-		return nil, err
-	}
+	userID := callUserID(ctx)
 	var userModel models.User
 	userChangeUserName := models.UserChangeUserName{
 		UserName: user.GetUserName(),
@@ -399,11 +517,11 @@ func (auth *Auth) UpdateUserName(ctx context.Context, user *protobuf.UserEmailAu
 	}
 
 	if len(userChangeUserName.UserName) == 0 {
-		return nil, fmt.Errorf("Empty username sent")
+		return nil, apiError(constants.APIValidationUserNameNotValid, "Empty username sent")
 	}
 
 	// get user
-	if err := auth.DAL.UpdateUser(&userChangeUserName, &userModel); err != nil {
+	if err := auth.dal(ctx).UpdateUser(&userChangeUserName, &userModel); err != nil {
 		return nil, err
 	}
 
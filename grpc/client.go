@@ -0,0 +1,60 @@
+package grpc
+
+import (
+	"path"
+	"strings"
+
+	"github.com/axiomzen/zenauth/constants"
+	"github.com/axiomzen/zenauth/helpers"
+	context "golang.org/x/net/context"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+// clientContextKey is the context key of the identities of the client certificate of the call
+type clientContextKey struct{}
+
+// withClient puts the identities of the verified client certificate of the call in the context,
+// and refuses calls to methods of GRPCMethodClients from other clients
+func (auth *Auth) withClient(ctx context.Context, fullMethod string) (context.Context, error) {
+	identities := peerIdentities(ctx)
+	if allowed, ok := auth.Config.GRPCMethodClientIdentities[path.Base(fullMethod)]; ok && !containsAny(allowed, identities) {
+		return ctx, apiErrorf(constants.APIClientUnauthorized, "Client not allowed to call %s", path.Base(fullMethod))
+	}
+	if len(identities) > 0 {
+		ctx = withLog(ctx, auth.log(ctx).WithField("client", identities[0]))
+	}
+	return context.WithValue(ctx, clientContextKey{}, identities), nil
+}
+
+// callClientIdentities returns the identities of the client certificate of the call, the subject
+// common name first, see helpers.CertificateIdentities. There are none without mutual TLS
+func callClientIdentities(ctx context.Context) []string {
+	identities, _ := ctx.Value(clientContextKey{}).([]string)
+	return identities
+}
+
+// peerIdentities returns the identities of the verified client certificate of the connection
+func peerIdentities(ctx context.Context) []string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 || len(info.State.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return helpers.CertificateIdentities(info.State.VerifiedChains[0][0])
+}
+
+// containsAny tells whether one of the values is in the list
+func containsAny(list, values []string) bool {
+	for _, value := range values {
+		for _, item := range list {
+			if strings.EqualFold(item, value) {
+				return true
+			}
+		}
+	}
+	return false
+}
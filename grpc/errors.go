@@ -0,0 +1,165 @@
+package grpc
+
+import (
+	"fmt"
+
+	"github.com/axiomzen/zenauth/account"
+	"github.com/axiomzen/zenauth/constants"
+	"github.com/axiomzen/zenauth/data"
+	"github.com/axiomzen/zenauth/protobuf"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/any"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// statusCodes are the gRPC status codes of the API error codes that aren't internal errors
+var statusCodes = map[constants.APIErrorCode]codes.Code{
+	constants.APINotFound:                      codes.NotFound,
+	constants.APIInvalidRequest:                codes.InvalidArgument,
+	constants.APIDatabaseUnreachable:           codes.Unavailable,
+	constants.APIRateLimited:                   codes.ResourceExhausted,
+	constants.APIUnauthorized:                  codes.Unauthenticated,
+	constants.APILoginSignupUnauthorized:       codes.Unauthenticated,
+	constants.APILoginUserDoesNotExist:         codes.NotFound,
+	constants.APIFacebookLoginNotValid:         codes.Unauthenticated,
+	constants.APIEmailInUse:                    codes.AlreadyExists,
+	constants.APISocialAccountExists:           codes.AlreadyExists,
+	constants.APILoginSignupInvalidCombination: codes.Unauthenticated,
+	constants.APILoginNotVerified:              codes.FailedPrecondition,
+	constants.APIIncorrectAccountType:          codes.FailedPrecondition,
+	constants.APIEmailNotFound:                 codes.NotFound,
+	constants.APIInvalidMFACode:                codes.Unauthenticated,
+	constants.APIMFAAlreadyEnabled:             codes.AlreadyExists,
+	constants.APIMFANotEnrolled:                codes.FailedPrecondition,
+	constants.APIWebAuthnInvalid:               codes.Unauthenticated,
+	constants.APIWebAuthnCredentialExists:      codes.AlreadyExists,
+	constants.APISocialLoginNotValid:           codes.Unauthenticated,
+	constants.APISocialProviderNotFound:        codes.NotFound,
+	constants.APILoginThrottled:                codes.ResourceExhausted,
+	constants.APIAdminUnauthorized:             codes.PermissionDenied,
+	constants.APIConfirmationRequired:          codes.FailedPrecondition,
+	constants.APIAccountDisabled:               codes.PermissionDenied,
+	constants.APIUserNameInUse:                 codes.AlreadyExists,
+	constants.APIClientUnauthorized:            codes.PermissionDenied,
+	constants.APIExpiredAuthToken:              codes.Unauthenticated,
+	constants.APIInvalidAuthToken:              codes.Unauthenticated,
+	constants.APIInvalidRefreshToken:           codes.Unauthenticated,
+	constants.APIExpiredRefreshToken:           codes.Unauthenticated,
+	constants.APIRefreshTokenReused:            codes.Unauthenticated,
+	constants.APIRevokedAuthToken:              codes.Unauthenticated,
+	constants.APIInvalidMFAToken:               codes.Unauthenticated,
+	constants.APIInvalidWebAuthnChallenge:      codes.Unauthenticated,
+	constants.APIInvalidMagicLinkToken:         codes.Unauthenticated,
+	constants.APIInvalidUserExportToken:        codes.Unauthenticated,
+	constants.APIInvalidResetToken:             codes.InvalidArgument,
+	constants.APIInvalidVerifyEmailToken:       codes.InvalidArgument,
+}
+
+// httpStatusCodes are the gRPC status codes of the HTTP statuses of client errors, for account
+// errors whose API error code alone would be an internal error, see statusError
+var httpStatusCodes = map[constants.HTTPStatusCode]codes.Code{
+	constants.StatusBadRequest:   codes.InvalidArgument,
+	constants.StatusUnauthorized: codes.Unauthenticated,
+	constants.StatusForbidden:    codes.PermissionDenied,
+	constants.StatusNotFound:     codes.NotFound,
+}
+
+// statusCode returns the gRPC status code of an API error code. Parsing and validation errors
+// are invalid arguments, and the codes of failures on our side are internal errors
+func statusCode(code constants.APIErrorCode) codes.Code {
+	if statusCode, ok := statusCodes[code]; ok {
+		return statusCode
+	}
+	switch {
+	case code >= constants.APIParsing && code < constants.APIGeneric,
+		code >= constants.APIValidation && code < constants.APINetworkError:
+		return codes.InvalidArgument
+	}
+	return codes.Internal
+}
+
+// apiError returns the error of a failed call, with the status code of the API error code and
+// the code and msg in a protobuf.ErrorDetail
+func apiError(code constants.APIErrorCode, msg string) error {
+	return codeError(statusCode(code), code, msg)
+}
+
+// codeError is an apiError with the status code c
+func codeError(c codes.Code, code constants.APIErrorCode, msg string) error {
+	return detailError(c, &protobuf.ErrorDetail{Code: int32(code), Message: msg})
+}
+
+// detailError returns the error of a failed call with the status code c and the detail
+func detailError(c codes.Code, detail *protobuf.ErrorDetail) error {
+	st := &spb.Status{Code: int32(c), Message: detail.Message}
+	if anyDetail, err := ptypes.MarshalAny(detail); err == nil {
+		st.Details = []*any.Any{anyDetail}
+	}
+	return status.ErrorProto(st)
+}
+
+// passwordPolicyError returns the error of a password breaking the policy, with each rule it
+// breaks in the violations of the detail, like the details of the REST error response
+func passwordPolicyError(policyErr *account.PasswordPolicyError) error {
+	detail := &protobuf.ErrorDetail{Code: int32(policyErr.Code()), Message: policyErr.Error()}
+	for _, violation := range policyErr.Violations {
+		detail.Violations = append(detail.Violations, &protobuf.Violation{Field: "password", Rule: violation.Rule, Message: violation.Message})
+	}
+	return detailError(statusCode(policyErr.Code()), detail)
+}
+
+// loginThrottledError returns the error of a login the client has to wait before trying again,
+// Auth.loginError also sets the retry-after header
+func loginThrottledError(throttled *account.LoginThrottledError) error {
+	return apiErrorf(constants.APILoginThrottled, "Too many failed logins, try again in %s seconds", retryAfterSeconds(throttled))
+}
+
+// apiErrorf formats the message of an apiError
+func apiErrorf(code constants.APIErrorCode, format string, a ...interface{}) error {
+	return apiError(code, fmt.Sprintf(format, a...))
+}
+
+// dalError returns the error of a failed data access, see data.ToAPIError. Errors
+// clients can't act on are reported with code
+func dalError(err error, code constants.APIErrorCode) error {
+	apiErr := data.ToAPIError(err, code, err.Error())
+	return apiError(apiErr.Code, apiErr.Message)
+}
+
+// notFound returns the not found error of what for a none affected DAL error, see dalError
+func notFound(err error, what string) error {
+	if dalErr, ok := err.(data.DALError); ok && dalErr.ErrorCode == data.DALErrorCodeNoneAffected {
+		return apiErrorf(constants.APINotFound, "%s not found", what)
+	}
+	return dalError(err, constants.APIDatabase)
+}
+
+// statusError returns the status error of an error a call returned. DAL errors are mapped with
+// dalError, account errors keep their API error code, and other errors that aren't statuses yet
+// are internal errors
+func statusError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	switch e := err.(type) {
+	case data.DALError:
+		return dalError(err, constants.APIDatabase)
+	case *account.PasswordPolicyError:
+		return passwordPolicyError(e)
+	case *account.LoginThrottledError:
+		return loginThrottledError(e)
+	case data.APIError:
+		// the HTTP status tells the client errors of codes like APIDatabaseUpdateUser apart
+		c := statusCode(e.Code)
+		if httpCode, ok := httpStatusCodes[e.Status]; ok && c == codes.Internal {
+			c = httpCode
+		}
+		return codeError(c, e.Code, e.Message)
+	}
+	return apiError(constants.APIGeneric, err.Error())
+}
@@ -0,0 +1,40 @@
+package grpc
+
+import (
+	"sync/atomic"
+
+	"github.com/axiomzen/zenauth/constants"
+	context "golang.org/x/net/context"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// authService is the name of the Auth service health can be checked by
+const authService = "protobuf.Auth"
+
+// healthServer is the grpc.health.v1 service. The server, and the Auth service, serve while the
+// database answers and the server isn't shutting down
+type healthServer struct {
+	auth *Auth
+	// stopping is set once the server is shutting down
+	stopping int32
+}
+
+// Check reports the serving status of the server, or of the Auth service
+func (h *healthServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	if req.Service != "" && req.Service != authService {
+		return nil, apiErrorf(constants.APINotFound, "Service %s not found", req.Service)
+	}
+	if atomic.LoadInt32(&h.stopping) == 1 {
+		return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_NOT_SERVING}, nil
+	}
+	if err := h.auth.DAL.Ping(); err != nil {
+		h.auth.log(ctx).WithError(err).Error("Could not ping the database")
+		return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_NOT_SERVING}, nil
+	}
+	return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}, nil
+}
+
+// stop makes the checks report that the server isn't serving
+func (h *healthServer) stop() {
+	atomic.StoreInt32(&h.stopping, 1)
+}
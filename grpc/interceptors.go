@@ -0,0 +1,173 @@
+package grpc
+
+import (
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/axiomzen/zenauth/constants"
+	"github.com/twinj/uuid"
+	context "golang.org/x/net/context"
+	google_grpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+// chainUnary composes unary interceptors, the first one runs first
+func chainUnary(interceptors ...google_grpc.UnaryServerInterceptor) google_grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *google_grpc.UnaryServerInfo, handler google_grpc.UnaryHandler) (interface{}, error) {
+		next := handler
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			interceptor, inner := interceptors[i], next
+			next = func(ctx context.Context, req interface{}) (interface{}, error) {
+				return interceptor(ctx, req, info, inner)
+			}
+		}
+		return next(ctx, req)
+	}
+}
+
+// chainStream composes stream interceptors, the first one runs first
+func chainStream(interceptors ...google_grpc.StreamServerInterceptor) google_grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream google_grpc.ServerStream, info *google_grpc.StreamServerInfo, handler google_grpc.StreamHandler) error {
+		next := handler
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			interceptor, inner := interceptors[i], next
+			next = func(srv interface{}, stream google_grpc.ServerStream) error {
+				return interceptor(srv, stream, info, inner)
+			}
+		}
+		return next(srv, stream)
+	}
+}
+
+// contextInterceptor prepares the context of a call to the method before it runs, or refuses the call
+type contextInterceptor func(ctx context.Context, fullMethod string) (context.Context, error)
+
+// unary returns the unary interceptor running the contextInterceptor
+func (interceptor contextInterceptor) unary() google_grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *google_grpc.UnaryServerInfo, handler google_grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := interceptor(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// stream returns the stream interceptor running the contextInterceptor
+func (interceptor contextInterceptor) stream() google_grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream google_grpc.ServerStream, info *google_grpc.StreamServerInfo, handler google_grpc.StreamHandler) error {
+		ctx, err := interceptor(stream.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &contextStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+// contextStream is a server stream with the context prepared by the interceptors
+type contextStream struct {
+	google_grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the context of the stream
+func (stream *contextStream) Context() context.Context {
+	return stream.ctx
+}
+
+// logContextKey is the context key of the logger of the call
+type logContextKey struct{}
+
+// withLog sets the logger of the call
+func withLog(ctx context.Context, log *logrus.Entry) context.Context {
+	return context.WithValue(ctx, logContextKey{}, log)
+}
+
+// log returns the logger of the call, with its request id, method and tenant
+func (auth *Auth) log(ctx context.Context) *logrus.Entry {
+	if log, ok := ctx.Value(logContextKey{}).(*logrus.Entry); ok {
+		return log
+	}
+	return auth.Log
+}
+
+// startCall sets up the logger of the call with the request id of the metadata, or a new one,
+// which is sent back in the header metadata as the HTTP server does
+func (auth *Auth) startCall(ctx context.Context, fullMethod string) context.Context {
+	header := strings.ToLower(auth.Config.RequestIDHeader)
+	requestID := uuid.NewV4()
+	if md, ok := metadata.FromContext(ctx); ok && len(md[header]) > 0 {
+		if parsed, err := uuid.Parse(md[header][0]); err == nil {
+			requestID = parsed
+		}
+	}
+	log := auth.Log.WithFields(logrus.Fields{"server": "grpc", "id": requestID.String(), "method": fullMethod})
+	if err := google_grpc.SetHeader(ctx, metadata.Pairs(header, requestID.String())); err != nil {
+		log.WithError(err).Error("Could not set request id header")
+	}
+	log.Debug("Received call")
+	return withLog(ctx, log)
+}
+
+// endCall logs the result of a call, with the status code of its error
+func (auth *Auth) endCall(ctx context.Context, start time.Time, err error) {
+	code := google_grpc.Code(err)
+	log := auth.log(ctx).WithFields(logrus.Fields{"code": code.String(), "duration": time.Since(start)})
+	switch code {
+	case codes.OK:
+		log.Debug("Returned")
+	case codes.Internal, codes.Unknown, codes.Unavailable, codes.DataLoss:
+		log.WithError(err).Error("Returned")
+	default:
+		log.WithError(err).Info("Returned")
+	}
+}
+
+// logUnaryInterceptor logs unary calls, see startCall
+func (auth *Auth) logUnaryInterceptor(ctx context.Context, req interface{}, info *google_grpc.UnaryServerInfo, handler google_grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	ctx = auth.startCall(ctx, info.FullMethod)
+	resp, err := handler(ctx, req)
+	auth.endCall(ctx, start, err)
+	return resp, err
+}
+
+// logStreamInterceptor logs stream calls, see startCall
+func (auth *Auth) logStreamInterceptor(srv interface{}, stream google_grpc.ServerStream, info *google_grpc.StreamServerInfo, handler google_grpc.StreamHandler) error {
+	start := time.Now()
+	ctx := auth.startCall(stream.Context(), info.FullMethod)
+	err := handler(srv, &contextStream{ServerStream: stream, ctx: ctx})
+	auth.endCall(ctx, start, err)
+	return err
+}
+
+// recovered logs a panic of a call with its stack, and returns its internal error
+func (auth *Auth) recovered(ctx context.Context, r interface{}) error {
+	auth.log(ctx).WithField("stack", string(debug.Stack())).Errorf("Recovered from panic: %v", r)
+	return apiError(constants.APIPanic, "API Panic!")
+}
+
+// statusUnaryInterceptor turns the error a unary call ends with into a status, see statusError,
+// and a panic into an internal error
+func (auth *Auth) statusUnaryInterceptor(ctx context.Context, req interface{}, info *google_grpc.UnaryServerInfo, handler google_grpc.UnaryHandler) (resp interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			resp, err = nil, auth.recovered(ctx, r)
+		}
+	}()
+	resp, err = handler(ctx, req)
+	return resp, statusError(err)
+}
+
+// statusStreamInterceptor is statusUnaryInterceptor for stream calls
+func (auth *Auth) statusStreamInterceptor(srv interface{}, stream google_grpc.ServerStream, info *google_grpc.StreamServerInfo, handler google_grpc.StreamHandler) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = auth.recovered(stream.Context(), r)
+		}
+	}()
+	return statusError(handler(srv, stream))
+}
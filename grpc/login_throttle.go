@@ -0,0 +1,62 @@
+package grpc
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	context "golang.org/x/net/context"
+
+	google_grpc "google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	"github.com/axiomzen/zenauth/account"
+	"github.com/axiomzen/zenauth/helpers"
+)
+
+// clientIP returns the address of the client making the call
+func (auth *Auth) clientIP(ctx context.Context) string {
+	forwarded := ""
+	if auth.Config.ClientIPHeader != "" {
+		if md, ok := metadata.FromContext(ctx); ok && len(md[strings.ToLower(auth.Config.ClientIPHeader)]) > 0 {
+			forwarded = md[strings.ToLower(auth.Config.ClientIPHeader)][0]
+		}
+	}
+	remoteAddr := ""
+	if p, ok := peer.FromContext(ctx); ok {
+		remoteAddr = p.Addr.String()
+	}
+	return helpers.ClientIP(remoteAddr, forwarded)
+}
+
+// userAgent returns the user agent the client sent with the call
+func userAgent(ctx context.Context) string {
+	if md, ok := metadata.FromContext(ctx); ok && len(md["user-agent"]) > 0 {
+		return md["user-agent"][0]
+	}
+	return ""
+}
+
+// checkLoginThrottle returns the throttled error, and sets the retry-after header,
+// if the client has to wait before logging in to the account again
+func (auth *Auth) checkLoginThrottle(ctx context.Context, userID string) error {
+	return auth.loginError(ctx, auth.account(ctx).CheckLoginThrottle(userID))
+}
+
+// loginError returns the error of a login, setting the retry-after header if the login was throttled
+func (auth *Auth) loginError(ctx context.Context, err error) error {
+	throttled, ok := err.(*account.LoginThrottledError)
+	if !ok {
+		return err
+	}
+	if err := google_grpc.SetHeader(ctx, metadata.Pairs("retry-after", retryAfterSeconds(throttled))); err != nil {
+		auth.log(ctx).WithError(err).Error("Could not set retry-after header")
+	}
+	return loginThrottledError(throttled)
+}
+
+// retryAfterSeconds returns how long a throttled client waits, in whole seconds
+func retryAfterSeconds(throttled *account.LoginThrottledError) string {
+	return strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds())))
+}
@@ -0,0 +1,63 @@
+package grpc
+
+import (
+	"math"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	context "golang.org/x/net/context"
+
+	google_grpc "google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/axiomzen/zenauth/constants"
+	"github.com/axiomzen/zenauth/helpers"
+)
+
+// rateLimitInterceptor takes a token from the bucket of the method for every call,
+// methods without a limit in GRPCMethodRateLimits are not limited
+func (auth *Auth) rateLimitInterceptor(limiter helpers.RateLimiter) google_grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *google_grpc.UnaryServerInfo, handler google_grpc.UnaryHandler) (interface{}, error) {
+		method := path.Base(info.FullMethod)
+		limit, ok := auth.Config.GRPCMethodRateLimits[method]
+		if !ok {
+			return handler(ctx, req)
+		}
+		result, err := limiter.Take(method+":"+auth.rateLimitKey(ctx, limit.Key), limit)
+		if err != nil {
+			auth.log(ctx).WithError(err).WithField("method", method).Error("Could not take rate limit token")
+			return handler(ctx, req)
+		}
+		headers := metadata.Pairs(
+			"ratelimit-limit", strconv.Itoa(result.Limit),
+			"ratelimit-remaining", strconv.Itoa(result.Remaining),
+			"ratelimit-reset", strconv.Itoa(int(math.Ceil(result.Reset.Seconds()))),
+			"ratelimit-policy", strconv.Itoa(limit.Burst)+";w="+strconv.Itoa(int(limit.Period/time.Second)))
+		if !result.Allowed {
+			seconds := strconv.Itoa(int(math.Ceil(result.RetryAfter.Seconds())))
+			headers = metadata.Join(headers, metadata.Pairs("retry-after", seconds))
+		}
+		if err := google_grpc.SetHeader(ctx, headers); err != nil {
+			auth.log(ctx).WithError(err).Error("Could not set rate limit headers")
+		}
+		if !result.Allowed {
+			return nil, apiErrorf(constants.APIRateLimited, "Too many requests, try again in %s seconds", headers["retry-after"][0])
+		}
+		return handler(ctx, req)
+	}
+}
+
+// rateLimitKey returns what the call is counted by. The calls don't carry a user, so
+// user limits are counted by ip
+func (auth *Auth) rateLimitKey(ctx context.Context, key string) string {
+	if key == helpers.RateLimitKeyAPIToken {
+		token := ""
+		if md, ok := metadata.FromContext(ctx); ok && len(md[strings.ToLower(auth.Config.APITokenHeader)]) > 0 {
+			token = md[strings.ToLower(auth.Config.APITokenHeader)][0]
+		}
+		return key + ":" + helpers.HashOpaqueToken(token)
+	}
+	return helpers.RateLimitKeyIP + ":" + auth.clientIP(ctx)
+}
@@ -0,0 +1,91 @@
+package grpc
+
+import (
+	"github.com/axiomzen/zenauth/constants"
+	"github.com/axiomzen/zenauth/models"
+	"github.com/axiomzen/zenauth/protobuf"
+	pEmpty "github.com/golang/protobuf/ptypes/empty"
+	"golang.org/x/net/context"
+)
+
+// CheckPermission tells whether one of the user's roles gives them the permission. The user is the
+// one of the request, or the current user when it has no user id
+func (auth *Auth) CheckPermission(ctx context.Context, check *protobuf.PermissionCheck) (*protobuf.PermissionCheckResult, error) {
+	if check.GetPermission() == "" {
+		return nil, apiError(constants.APIParsingRole, "Missing permission")
+	}
+	userID := check.GetUserId()
+	if userID == "" {
+		var err error
+		if userID, err = auth.getUserID(ctx); err != nil {
+			return nil, err
+		}
+	}
+	allowed, err := auth.dal(ctx).HasPermission(userID, check.GetPermission())
+	if err != nil {
+		return nil, err
+	}
+	return &protobuf.PermissionCheckResult{Allowed: allowed}, nil
+}
+
+// ListRoles lists all the roles with their permissions, admin token required
+func (auth *Auth) ListRoles(ctx context.Context, _ *pEmpty.Empty) (*protobuf.Roles, error) {
+	var roles models.Roles
+	if err := auth.dal(ctx).GetRoles(&roles); err != nil {
+		return nil, err
+	}
+	return roles.Protobuf(), nil
+}
+
+// SaveRole creates the role, or replaces the description and permissions of the one of that name.
+// Admin token required
+func (auth *Auth) SaveRole(ctx context.Context, protoRole *protobuf.Role) (*protobuf.Role, error) {
+	role := models.NewRoleFromProtobuf(protoRole)
+	if err := role.Validate(); err != nil {
+		return nil, apiError(constants.APIParsingRole, err.Error())
+	}
+	if err := auth.dal(ctx).SaveRole(role); err != nil {
+		return nil, err
+	}
+	return role.Protobuf(), nil
+}
+
+// DeleteRole deletes a role, taking it away from its users. Admin token required
+func (auth *Auth) DeleteRole(ctx context.Context, name *protobuf.RoleName) (*pEmpty.Empty, error) {
+	role := models.Role{Name: name.GetName()}
+	if err := auth.dal(ctx).DeleteRole(&role); err != nil {
+		return nil, notFound(err, "Role")
+	}
+	return &pEmpty.Empty{}, nil
+}
+
+// GetUserRoles gets the roles of a user with their permissions. Admin token required
+func (auth *Auth) GetUserRoles(ctx context.Context, userID *protobuf.UserID) (*protobuf.Roles, error) {
+	var roles models.Roles
+	if err := auth.dal(ctx).GetUserRoles(userID.GetId(), &roles); err != nil {
+		return nil, err
+	}
+	return roles.Protobuf(), nil
+}
+
+// AssignRole gives a role to a user, it is in their auth tokens from the next one.
+// Admin token required
+func (auth *Auth) AssignRole(ctx context.Context, userRole *protobuf.UserRole) (*pEmpty.Empty, error) {
+	var user models.User
+	user.ID = userRole.GetUserId()
+	if err := auth.dal(ctx).GetUserByID(&user); err != nil {
+		return nil, notFound(err, "User")
+	}
+	if err := auth.dal(ctx).AssignUserRole(user.ID, userRole.GetRole()); err != nil {
+		return nil, notFound(err, "Role")
+	}
+	return &pEmpty.Empty{}, nil
+}
+
+// UnassignRole takes a role away from a user. Admin token required
+func (auth *Auth) UnassignRole(ctx context.Context, userRole *protobuf.UserRole) (*pEmpty.Empty, error) {
+	if err := auth.dal(ctx).UnassignUserRole(userRole.GetUserId(), userRole.GetRole()); err != nil {
+		return nil, notFound(err, "User role")
+	}
+	return &pEmpty.Empty{}, nil
+}
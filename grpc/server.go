@@ -4,33 +4,126 @@ import (
 	"fmt"
 	"log"
 	"net"
+	"sync"
+	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/axiomzen/zenauth/config"
 	"github.com/axiomzen/zenauth/data"
+	"github.com/axiomzen/zenauth/helpers"
 	"github.com/axiomzen/zenauth/protobuf"
 
 	google_grpc "google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
 )
 
 type Server struct {
 	Config *config.ZENAUTHConfig
 	DAL    data.ZENAUTHProvider
 	Log    *logrus.Entry
+
+	mu         sync.Mutex
+	grpcServer *google_grpc.Server
+	health     *healthServer
+	stopped    bool
 }
 
+// ListenAndServe serves the Auth, health and reflection services on GRPCPort until Shutdown
 func (s *Server) ListenAndServe() error {
 	ln, err := net.Listen("tcp", fmt.Sprintf(":%v", s.Config.GRPCPort))
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	grpcServer := google_grpc.NewServer()
-	protobuf.RegisterAuthServer(grpcServer, &Auth{
+	auth := &Auth{
 		Config: s.Config,
 		DAL:    s.DAL,
 		Log:    s.Log.WithField("GRPC Service", "Auth"),
-	})
+	}
+	// calls are logged first, so the log has the status the others end them with, and the client and
+	// tenant are known before the call is counted against the rate limits and authorized
+	options := []google_grpc.ServerOption{
+		google_grpc.UnaryInterceptor(chainUnary(
+			auth.logUnaryInterceptor,
+			auth.statusUnaryInterceptor,
+			contextInterceptor(auth.withClient).unary(),
+			contextInterceptor(auth.withTenant).unary(),
+			auth.rateLimitInterceptor(data.NewRateLimiter(s.Config, s.DAL)),
+			contextInterceptor(auth.authorize).unary(),
+		)),
+		google_grpc.StreamInterceptor(chainStream(
+			auth.logStreamInterceptor,
+			auth.statusStreamInterceptor,
+			contextInterceptor(auth.withClient).stream(),
+			contextInterceptor(auth.withTenant).stream(),
+			contextInterceptor(auth.authorize).stream(),
+		)),
+	}
+	if s.Config.GRPCTLSCertFile != "" {
+		certificates, err := helpers.NewCertificateReloader(s.Config.GRPCTLSCertFile, s.Config.GRPCTLSKeyFile, s.Config.GRPCTLSClientCAFile)
+		if err != nil {
+			return err
+		}
+		stop := certificates.Watch(s.Config.GRPCTLSReloadInterval, func(err error) {
+			if err != nil {
+				s.Log.WithError(err).Error("Could not reload the GRPC TLS certificate")
+				return
+			}
+			s.Log.Info("Reloaded the GRPC TLS certificate")
+		})
+		defer stop()
+		options = append(options, google_grpc.Creds(credentials.NewTLS(certificates.TLSConfig())))
+	}
+	grpcServer := google_grpc.NewServer(options...)
+	protobuf.RegisterAuthServer(grpcServer, auth)
+	health := &healthServer{auth: auth}
+	healthpb.RegisterHealthServer(grpcServer, health)
+	if s.Config.GRPCReflection {
+		reflection.Register(grpcServer)
+	}
+
+	s.mu.Lock()
+	if s.stopped {
+		s.mu.Unlock()
+		ln.Close()
+		return nil
+	}
+	s.grpcServer, s.health = grpcServer, health
+	s.mu.Unlock()
 	log.Printf("Starting GRPC Server on Port %v", s.Config.GRPCPort)
-	return grpcServer.Serve(ln)
+	err = grpcServer.Serve(ln)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped {
+		return nil
+	}
+	return err
+}
+
+// Shutdown stops accepting connections and waits for the calls in progress to end, for up to
+// timeout before they are cancelled. Health checks report NOT_SERVING from then on
+func (s *Server) Shutdown(timeout time.Duration) {
+	s.mu.Lock()
+	s.stopped = true
+	grpcServer, health := s.grpcServer, s.health
+	s.mu.Unlock()
+	if grpcServer == nil {
+		return
+	}
+	health.stop()
+
+	drained := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-time.After(timeout):
+		s.Log.Warn("Cancelling the GRPC calls still in progress")
+		grpcServer.Stop()
+		<-drained
+	}
 }
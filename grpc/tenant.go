@@ -0,0 +1,78 @@
+package grpc
+
+import (
+	"strings"
+
+	"github.com/axiomzen/zenauth/constants"
+	"github.com/axiomzen/zenauth/data"
+	"github.com/axiomzen/zenauth/helpers"
+	"github.com/axiomzen/zenauth/models"
+	"github.com/twinj/uuid"
+	context "golang.org/x/net/context"
+	"google.golang.org/grpc/metadata"
+)
+
+// tenantContextKey is the context key of the id of the call's tenant
+type tenantContextKey struct{}
+
+// withTenant puts the tenant of the call in the context, see callTenantID. accessNone methods
+// have no tenant
+func (auth *Auth) withTenant(ctx context.Context, fullMethod string) (context.Context, error) {
+	if access, ok := methodAccesses[fullMethod]; ok && access == accessNone {
+		return ctx, nil
+	}
+	tenantID, err := auth.callTenantID(ctx)
+	if err != nil {
+		return ctx, err
+	}
+	ctx = withLog(ctx, auth.log(ctx).WithField("tenantID", tenantID))
+	return context.WithValue(ctx, tenantContextKey{}, tenantID), nil
+}
+
+// callTenantID returns the tenant of the API token in the metadata. ZENAUTH_APITOKEN is the token of
+// the default tenant, and only it can act for any other with its id in TenantHeader. Calls without
+// an API token are refused, unless GRPCRequireAPIToken is off, and are the default tenant's
+func (auth *Auth) callTenantID(ctx context.Context) (string, error) {
+	md, _ := metadata.FromContext(ctx)
+	var token string
+	if tokens := md[strings.ToLower(auth.Config.APITokenHeader)]; len(tokens) > 0 {
+		token = tokens[0]
+	}
+	if token == "" {
+		if auth.Config.GRPCRequireAPIToken {
+			return "", apiError(constants.APIUnauthorized, "Not authorized")
+		}
+		return constants.DefaultTenantID, nil
+	}
+	if token != auth.Config.APIToken {
+		hash := helpers.HashOpaqueToken(token)
+		tenant := models.Tenant{APITokenHash: &hash}
+		if err := auth.DAL.GetTenantByAPITokenHash(&tenant); err != nil {
+			if dalErr, _ := err.(data.DALError); dalErr.ErrorCode == data.DALErrorCodeNoneAffected {
+				return "", apiError(constants.APIUnauthorized, "Not authorized")
+			}
+			return "", dalError(err, constants.APIDatabaseGet)
+		}
+		return tenant.ID, nil
+	}
+	if ids := md[strings.ToLower(auth.Config.TenantHeader)]; len(ids) > 0 {
+		if _, err := uuid.Parse(ids[0]); err != nil {
+			return "", apiError(constants.APINotFound, "Tenant not found")
+		}
+		tenant := models.Tenant{ID: ids[0]}
+		if err := auth.DAL.GetTenantByID(&tenant); err != nil {
+			return "", notFound(err, "Tenant")
+		}
+		return tenant.ID, nil
+	}
+	return constants.DefaultTenantID, nil
+}
+
+// dal returns the data provider of the call's tenant
+func (auth *Auth) dal(ctx context.Context) data.ZENAUTHProvider {
+	tenantID, ok := ctx.Value(tenantContextKey{}).(string)
+	if !ok {
+		tenantID = constants.DefaultTenantID
+	}
+	return auth.DAL.ForTenant(tenantID)
+}
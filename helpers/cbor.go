@@ -0,0 +1,239 @@
+package helpers
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// A minimal CBOR (RFC 7049) codec, enough for WebAuthn attestation objects and COSE keys.
+// Maps decode to map[interface{}]interface{}, integers to int64, byte strings to []byte
+// and text strings to string
+
+const (
+	cborUnsigned = 0
+	cborNegative = 1
+	cborBytes    = 2
+	cborText     = 3
+	cborArray    = 4
+	cborMap      = 5
+	cborTag      = 6
+	cborSimple   = 7
+
+	// cborMaxDepth limits nesting when decoding untrusted input
+	cborMaxDepth = 16
+)
+
+var errCBORTruncated = errors.New("cbor: unexpected end of data")
+
+// DecodeCBOR decodes the first CBOR item in data, returning it and the bytes after it
+func DecodeCBOR(data []byte) (interface{}, []byte, error) {
+	return decodeCBOR(data, 0)
+}
+
+func decodeCBOR(data []byte, depth int) (interface{}, []byte, error) {
+	if depth > cborMaxDepth {
+		return nil, nil, errors.New("cbor: nested too deeply")
+	}
+	if len(data) == 0 {
+		return nil, nil, errCBORTruncated
+	}
+	major := data[0] >> 5
+	info := data[0] & 0x1f
+	data = data[1:]
+
+	if major == cborSimple {
+		switch info {
+		case 20:
+			return false, data, nil
+		case 21:
+			return true, data, nil
+		case 22, 23:
+			return nil, data, nil
+		}
+		return nil, nil, fmt.Errorf("cbor: unsupported simple value %d", info)
+	}
+
+	arg, data, err := cborArgument(info, data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch major {
+	case cborUnsigned:
+		if arg > math.MaxInt64 {
+			return nil, nil, errors.New("cbor: integer overflow")
+		}
+		return int64(arg), data, nil
+	case cborNegative:
+		if arg > math.MaxInt64 {
+			return nil, nil, errors.New("cbor: integer overflow")
+		}
+		return -1 - int64(arg), data, nil
+	case cborBytes, cborText:
+		if arg > uint64(len(data)) {
+			return nil, nil, errCBORTruncated
+		}
+		b := make([]byte, arg)
+		copy(b, data[:arg])
+		if major == cborText {
+			return string(b), data[arg:], nil
+		}
+		return b, data[arg:], nil
+	case cborArray:
+		// every item is at least a byte
+		if arg > uint64(len(data)) {
+			return nil, nil, errCBORTruncated
+		}
+		items := make([]interface{}, arg)
+		for i := range items {
+			if items[i], data, err = decodeCBOR(data, depth+1); err != nil {
+				return nil, nil, err
+			}
+		}
+		return items, data, nil
+	case cborMap:
+		if arg > uint64(len(data)) {
+			return nil, nil, errCBORTruncated
+		}
+		m := make(map[interface{}]interface{}, arg)
+		for i := uint64(0); i < arg; i++ {
+			var key, value interface{}
+			if key, data, err = decodeCBOR(data, depth+1); err != nil {
+				return nil, nil, err
+			}
+			switch key.(type) {
+			case int64, string:
+			default:
+				return nil, nil, fmt.Errorf("cbor: unsupported map key type %T", key)
+			}
+			if value, data, err = decodeCBOR(data, depth+1); err != nil {
+				return nil, nil, err
+			}
+			m[key] = value
+		}
+		return m, data, nil
+	case cborTag:
+		// tags are ignored
+		return decodeCBOR(data, depth+1)
+	}
+	return nil, nil, fmt.Errorf("cbor: unsupported major type %d", major)
+}
+
+// cborArgument reads the argument of an item, indefinite lengths are not supported
+func cborArgument(info byte, data []byte) (uint64, []byte, error) {
+	switch {
+	case info < 24:
+		return uint64(info), data, nil
+	case info == 24 && len(data) >= 1:
+		return uint64(data[0]), data[1:], nil
+	case info == 25 && len(data) >= 2:
+		return uint64(binary.BigEndian.Uint16(data)), data[2:], nil
+	case info == 26 && len(data) >= 4:
+		return uint64(binary.BigEndian.Uint32(data)), data[4:], nil
+	case info == 27 && len(data) >= 8:
+		return binary.BigEndian.Uint64(data), data[8:], nil
+	case info > 27:
+		return 0, nil, fmt.Errorf("cbor: unsupported additional information %d", info)
+	}
+	return 0, nil, errCBORTruncated
+}
+
+// EncodeCBOR encodes v, which may be made of integers, []byte, strings, bools, nil,
+// []interface{} and maps with int or string keys. Maps are encoded in canonical key order
+func EncodeCBOR(v interface{}) ([]byte, error) {
+	switch value := v.(type) {
+	case nil:
+		return []byte{cborSimple<<5 | 22}, nil
+	case bool:
+		if value {
+			return []byte{cborSimple<<5 | 21}, nil
+		}
+		return []byte{cborSimple<<5 | 20}, nil
+	case int:
+		return encodeCBORInt(int64(value)), nil
+	case int64:
+		return encodeCBORInt(value), nil
+	case []byte:
+		return append(cborHead(cborBytes, uint64(len(value))), value...), nil
+	case string:
+		return append(cborHead(cborText, uint64(len(value))), value...), nil
+	case []interface{}:
+		out := cborHead(cborArray, uint64(len(value)))
+		for _, item := range value {
+			b, err := EncodeCBOR(item)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, b...)
+		}
+		return out, nil
+	case map[interface{}]interface{}:
+		return encodeCBORMap(value)
+	case map[string]interface{}:
+		m := make(map[interface{}]interface{}, len(value))
+		for k, item := range value {
+			m[k] = item
+		}
+		return encodeCBORMap(m)
+	}
+	return nil, fmt.Errorf("cbor: unsupported type %T", v)
+}
+
+func encodeCBORMap(m map[interface{}]interface{}) ([]byte, error) {
+	type entry struct{ key, value []byte }
+	entries := make([]entry, 0, len(m))
+	for k, v := range m {
+		key, err := EncodeCBOR(k)
+		if err != nil {
+			return nil, err
+		}
+		value, err := EncodeCBOR(v)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry{key, value})
+	}
+	// canonical CBOR sorts keys by length, then bytewise
+	sort.Slice(entries, func(i, j int) bool {
+		if len(entries[i].key) != len(entries[j].key) {
+			return len(entries[i].key) < len(entries[j].key)
+		}
+		return string(entries[i].key) < string(entries[j].key)
+	})
+	out := cborHead(cborMap, uint64(len(entries)))
+	for _, e := range entries {
+		out = append(out, e.key...)
+		out = append(out, e.value...)
+	}
+	return out, nil
+}
+
+func encodeCBORInt(i int64) []byte {
+	if i < 0 {
+		return cborHead(cborNegative, uint64(-1-i))
+	}
+	return cborHead(cborUnsigned, uint64(i))
+}
+
+func cborHead(major byte, arg uint64) []byte {
+	switch {
+	case arg < 24:
+		return []byte{major<<5 | byte(arg)}
+	case arg <= math.MaxUint8:
+		return []byte{major<<5 | 24, byte(arg)}
+	case arg <= math.MaxUint16:
+		b := []byte{major<<5 | 25, 0, 0}
+		binary.BigEndian.PutUint16(b[1:], uint16(arg))
+		return b
+	case arg <= math.MaxUint32:
+		b := []byte{major<<5 | 26, 0, 0, 0, 0}
+		binary.BigEndian.PutUint32(b[1:], uint32(arg))
+		return b
+	}
+	b := []byte{major<<5 | 27, 0, 0, 0, 0, 0, 0, 0, 0}
+	binary.BigEndian.PutUint64(b[1:], arg)
+	return b
+}
@@ -0,0 +1,64 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/hex"
+	"reflect"
+	"testing"
+)
+
+func TestCBORRoundTrip(t *testing.T) {
+	value := map[interface{}]interface{}{
+		int64(1):  int64(2),
+		int64(-1): int64(-300),
+		"fmt":     "none",
+		"bytes":   []byte{1, 2, 3},
+		"list":    []interface{}{true, false, nil, int64(70000)},
+	}
+	encoded, err := EncodeCBOR(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, rest, err := DecodeCBOR(append(encoded, 0xff))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(rest, []byte{0xff}) {
+		t.Errorf("expected the trailing bytes back, got %x", rest)
+	}
+	if !reflect.DeepEqual(decoded, value) {
+		t.Errorf("expected %v, got %v", value, decoded)
+	}
+}
+
+func TestDecodeCBOR(t *testing.T) {
+	// RFC 7049 appendix A examples
+	for encoded, expected := range map[string]interface{}{
+		"00":           int64(0),
+		"1903e8":       int64(1000),
+		"3863":         int64(-100),
+		"4401020304":   []byte{1, 2, 3, 4},
+		"6449455446":   "IETF",
+		"83010203":     []interface{}{int64(1), int64(2), int64(3)},
+		"a201020304":   map[interface{}]interface{}{int64(1): int64(2), int64(3): int64(4)},
+		"c11a514b67b0": int64(1363896240),
+		"f5":           true,
+	} {
+		data, _ := hex.DecodeString(encoded)
+		decoded, _, err := DecodeCBOR(data)
+		if err != nil {
+			t.Errorf("%s: %s", encoded, err)
+			continue
+		}
+		if !reflect.DeepEqual(decoded, expected) {
+			t.Errorf("%s: expected %v, got %v", encoded, expected, decoded)
+		}
+	}
+
+	for _, encoded := range []string{"", "19", "44010203", "5f", "a1f501"} {
+		data, _ := hex.DecodeString(encoded)
+		if _, _, err := DecodeCBOR(data); err == nil {
+			t.Errorf("%s: expected an error", encoded)
+		}
+	}
+}
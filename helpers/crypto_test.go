@@ -0,0 +1,69 @@
+package helpers
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestGenerateOpaqueToken(t *testing.T) {
+	token, hash, err := GenerateOpaqueToken(32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(token) != 43 {
+		t.Errorf("expected a 43 character token, got %d", len(token))
+	}
+	if hash != HashOpaqueToken(token) {
+		t.Error("hash should match the hash of the token")
+	}
+	if hash == token {
+		t.Error("hash should not be the token")
+	}
+
+	other, _, err := GenerateOpaqueToken(32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other == token {
+		t.Error("tokens should be random")
+	}
+}
+
+func TestEncryptSecret(t *testing.T) {
+	key := bytes.Repeat([]byte("k"), 32)
+	ciphertext, err := EncryptSecret(key, "JBSWY3DPEHPK3PXP")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(ciphertext, "JBSWY3DPEHPK3PXP") {
+		t.Error("secret should be encrypted")
+	}
+	plaintext, err := DecryptSecret(key, ciphertext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plaintext != "JBSWY3DPEHPK3PXP" {
+		t.Errorf("expected the secret back, got %s", plaintext)
+	}
+	if _, err := DecryptSecret(bytes.Repeat([]byte("x"), 32), ciphertext); err == nil {
+		t.Error("expected decrypting with the wrong key to fail")
+	}
+}
+
+func TestGenerateRecoveryCode(t *testing.T) {
+	code, err := GenerateRecoveryCode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(code) != 11 || code[5] != '-' {
+		t.Errorf("expected a code formatted as xxxxx-xxxxx, got %s", code)
+	}
+	normalized := NormalizeRecoveryCode(code)
+	if len(normalized) != 10 || strings.Contains(normalized, "-") {
+		t.Errorf("expected the dash to be removed, got %s", normalized)
+	}
+	if NormalizeRecoveryCode(" "+strings.ToUpper(code)) != normalized {
+		t.Error("expected normalizing to ignore case and spaces")
+	}
+}
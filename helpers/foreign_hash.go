@@ -0,0 +1,185 @@
+package helpers
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+)
+
+// hash schemes of users imported from other systems, they are only checked and
+// replaced with the preferred scheme on their first login
+const (
+	// Django's pbkdf2_sha256$iterations$salt$hash (and pbkdf2_sha1)
+	PasswordSchemeDjangoPBKDF2 = "django-pbkdf2"
+	// Firebase Authentication's modified scrypt
+	PasswordSchemeFirebaseScrypt = "firebase-scrypt"
+	// a single salted SHA-256
+	PasswordSchemeSHA256 = "sha256"
+)
+
+// positions of the salt of salted SHA-256 hashes
+const (
+	SaltPositionPrefix = "prefix"
+	SaltPositionSuffix = "suffix"
+)
+
+// ForeignPasswordHash is a password hash exported from another system, with the parameters it was made with
+type ForeignPasswordHash struct {
+	// Algorithm is one of the password hash schemes
+	Algorithm string
+	// Hash is the full encoded hash for bcrypt, argon2id, pbkdf2 and django-pbkdf2, base64 for firebase-scrypt
+	// and hex for sha256
+	Hash string
+	// Salt is base64 for firebase-scrypt, used as is for sha256
+	Salt string
+	// firebase-scrypt project parameters, base64 like in the Firebase console
+	SaltSeparator string
+	SignerKey     string
+	Rounds        int
+	MemCost       int
+	// SaltPosition of sha256, prefix (the default) or suffix
+	SaltPosition string
+}
+
+// Encode validates the foreign hash and returns it encoded to be stored as a user's hash
+func (f ForeignPasswordHash) Encode() (string, error) {
+	switch f.Algorithm {
+	case PasswordSchemeBcrypt, PasswordSchemePBKDF2, PasswordSchemeDjangoPBKDF2:
+		if PasswordHashScheme(f.Hash) != f.Algorithm {
+			return "", fmt.Errorf("hash is not a %s hash", f.Algorithm)
+		}
+		return f.Hash, nil
+	case PasswordSchemeArgon2id:
+		if _, _, _, err := decodeArgon2idHash(f.Hash); err != nil {
+			return "", err
+		}
+		return f.Hash, nil
+	case PasswordSchemeFirebaseScrypt:
+		if f.Rounds < 1 || f.Rounds > 8 || f.MemCost < 1 || f.MemCost > 14 {
+			return "", errors.New("firebase-scrypt needs rounds between 1 and 8 and a mem cost between 1 and 14")
+		}
+		var encoded []string
+		for _, field := range []struct{ name, value string }{{"signer key", f.SignerKey}, {"salt separator", f.SaltSeparator}, {"salt", f.Salt}, {"hash", f.Hash}} {
+			decoded, err := base64.StdEncoding.DecodeString(field.value)
+			if err != nil || len(decoded) == 0 {
+				return "", fmt.Errorf("firebase-scrypt %s is not base64", field.name)
+			}
+			encoded = append(encoded, base64.RawStdEncoding.EncodeToString(decoded))
+		}
+		return fmt.Sprintf("$firebase-scrypt$r=%d,m=%d$%s", f.Rounds, f.MemCost, strings.Join(encoded, "$")), nil
+	case PasswordSchemeSHA256:
+		position := f.SaltPosition
+		if position == "" {
+			position = SaltPositionPrefix
+		}
+		if position != SaltPositionPrefix && position != SaltPositionSuffix {
+			return "", fmt.Errorf("salt position must be %s or %s", SaltPositionPrefix, SaltPositionSuffix)
+		}
+		sum, err := hex.DecodeString(f.Hash)
+		if err != nil || len(sum) != sha256.Size {
+			return "", errors.New("sha256 hash is not 64 hex characters")
+		}
+		return fmt.Sprintf("$sha256$s=%s$%s$%s", position,
+			base64.RawStdEncoding.EncodeToString([]byte(f.Salt)), base64.RawStdEncoding.EncodeToString(sum)), nil
+	}
+	return "", fmt.Errorf("unknown hash algorithm %q", f.Algorithm)
+}
+
+// CheckPasswordDjangoPBKDF2 compares a Django pbkdf2_sha256 or pbkdf2_sha1 hash to the attempted password
+func CheckPasswordDjangoPBKDF2(userHash, attemptedPassword string) (bool, error) {
+	parts := strings.Split(userHash, "$")
+	if len(parts) != 4 {
+		return false, errors.New("invalid django pbkdf2 hash")
+	}
+	var digest func() hash.Hash
+	switch parts[0] {
+	case "pbkdf2_sha256":
+		digest = sha256.New
+	case "pbkdf2_sha1":
+		digest = sha1.New
+	default:
+		return false, fmt.Errorf("unsupported django hasher %s", parts[0])
+	}
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations < 1 {
+		return false, errors.New("invalid django pbkdf2 iterations")
+	}
+	key, err := base64.StdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return false, err
+	}
+	attempted := pbkdf2.Key([]byte(attemptedPassword), []byte(parts[2]), iterations, len(key), digest)
+	return subtle.ConstantTimeCompare(key, attempted) == 1, nil
+}
+
+// CheckPasswordFirebaseScrypt compares an encoded Firebase scrypt hash to the attempted password.
+// Firebase derives a key with scrypt from the password and the salt followed by the salt separator,
+// and the hash is the project's signer key encrypted with it in AES-256-CTR
+func CheckPasswordFirebaseScrypt(userHash, attemptedPassword string) (bool, error) {
+	parts := strings.Split(userHash, "$")
+	if len(parts) != 7 || parts[1] != PasswordSchemeFirebaseScrypt {
+		return false, errors.New("invalid firebase scrypt hash")
+	}
+	var rounds, memCost int
+	if _, err := fmt.Sscanf(parts[2], "r=%d,m=%d", &rounds, &memCost); err != nil {
+		return false, fmt.Errorf("invalid firebase scrypt parameters %q", parts[2])
+	}
+	var decoded [4][]byte
+	for i := range decoded {
+		var err error
+		if decoded[i], err = base64.RawStdEncoding.DecodeString(parts[3+i]); err != nil {
+			return false, err
+		}
+	}
+	signerKey, saltSeparator, salt, key := decoded[0], decoded[1], decoded[2], decoded[3]
+
+	derived, err := scrypt.Key([]byte(attemptedPassword), append(salt, saltSeparator...), 1<<uint(memCost), rounds, 1, 32)
+	if err != nil {
+		return false, err
+	}
+	block, err := aes.NewCipher(derived)
+	if err != nil {
+		return false, err
+	}
+	attempted := make([]byte, len(signerKey))
+	cipher.NewCTR(block, make([]byte, aes.BlockSize)).XORKeyStream(attempted, signerKey)
+	return subtle.ConstantTimeCompare(key, attempted) == 1, nil
+}
+
+// CheckPasswordSHA256 compares an encoded salted SHA-256 hash to the attempted password
+func CheckPasswordSHA256(userHash, attemptedPassword string) (bool, error) {
+	parts := strings.Split(userHash, "$")
+	if len(parts) != 5 || parts[1] != PasswordSchemeSHA256 {
+		return false, errors.New("invalid sha256 hash")
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return false, err
+	}
+	sum, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false, err
+	}
+	var attempted [sha256.Size]byte
+	switch parts[2] {
+	case "s=" + SaltPositionPrefix:
+		attempted = sha256.Sum256(append(salt, attemptedPassword...))
+	case "s=" + SaltPositionSuffix:
+		attempted = sha256.Sum256(append([]byte(attemptedPassword), salt...))
+	default:
+		return false, fmt.Errorf("invalid sha256 salt position %q", parts[2])
+	}
+	return subtle.ConstantTimeCompare(sum, attempted[:]) == 1, nil
+}
@@ -0,0 +1,61 @@
+package helpers
+
+import (
+	"testing"
+)
+
+func TestForeignPasswordHashes(t *testing.T) {
+	hasher := PasswordHasher{Scheme: PasswordSchemeArgon2id, Argon2: testArgon2Params}
+	cases := []struct {
+		foreign  ForeignPasswordHash
+		password string
+	}{
+		// from Django's PBKDF2PasswordHasher
+		{ForeignPasswordHash{Algorithm: PasswordSchemeDjangoPBKDF2, Hash: "pbkdf2_sha256$1000$seasalt$mQnueSakb748zqBAC1tmWVZsZbi2zPGZarEzTGdfmso="}, "correct horse"},
+		// the example of github.com/firebase/scrypt
+		{ForeignPasswordHash{
+			Algorithm:     PasswordSchemeFirebaseScrypt,
+			Hash:          "lSrfV15cpx95/sZS2W9c9Kp6i/LVgQNDNC/qzrCnh1SAyZvqmZqAjTdn3aoItz+VHjoZilo78198JAdRuid5lQ==",
+			Salt:          "42xEC+ixf3L2lw==",
+			SaltSeparator: "Bw==",
+			SignerKey:     "jxspr8Ki0RYycVU8zykbdLGjFQ3McFUH0uiiTvC8pVMXAn210wjLNmdZJzxUECKbm0QsEmYUSDzZvpjeJ9WmXA==",
+			Rounds:        8,
+			MemCost:       14,
+		}, "user1password"},
+		{ForeignPasswordHash{Algorithm: PasswordSchemeSHA256, Hash: "869a1fa0609a1d15a5c3eb891428b7a6c8395b2ee10c4762e97ffca0ebe8c0b5", Salt: "pepper"}, "correct horse"},
+		{ForeignPasswordHash{Algorithm: PasswordSchemeSHA256, Hash: "8a20677826351fcddb2a88d0c11867b9791797798e648b89ac09630c1802cb90", Salt: "pepper", SaltPosition: SaltPositionSuffix}, "correct horse"},
+	}
+	for _, c := range cases {
+		encoded, err := c.foreign.Encode()
+		if err != nil {
+			t.Fatalf("%s: %s", c.foreign.Algorithm, err)
+		}
+		if scheme := PasswordHashScheme(encoded); scheme != c.foreign.Algorithm {
+			t.Errorf("expected scheme %s, got %s", c.foreign.Algorithm, scheme)
+		}
+		if ok, err := hasher.Check(encoded, c.password); err != nil || !ok {
+			t.Errorf("expected %s hash to match, got %v, %v", c.foreign.Algorithm, ok, err)
+		}
+		if ok, _ := hasher.Check(encoded, "wrong horse"); ok {
+			t.Errorf("expected %s hash not to match a wrong password", c.foreign.Algorithm)
+		}
+		if !hasher.NeedsRehash(encoded) {
+			t.Errorf("expected %s hash to be rehashed", c.foreign.Algorithm)
+		}
+	}
+}
+
+func TestForeignPasswordHashEncodeInvalid(t *testing.T) {
+	invalid := []ForeignPasswordHash{
+		{Algorithm: "md5", Hash: "5f4dcc3b5aa765d61d8327deb882cf99"},
+		{Algorithm: PasswordSchemeBcrypt, Hash: "pbkdf2_sha256$1000$salt$aGFzaA=="},
+		{Algorithm: PasswordSchemeSHA256, Hash: "not hex"},
+		{Algorithm: PasswordSchemeSHA256, Hash: "869a1fa0609a1d15a5c3eb891428b7a6c8395b2ee10c4762e97ffca0ebe8c0b5", SaltPosition: "middle"},
+		{Algorithm: PasswordSchemeFirebaseScrypt, Hash: "aGFzaA==", Salt: "c2FsdA==", SaltSeparator: "Bw==", SignerKey: "a2V5", Rounds: 8},
+	}
+	for _, foreign := range invalid {
+		if _, err := foreign.Encode(); err == nil {
+			t.Errorf("expected %+v to be invalid", foreign)
+		}
+	}
+}
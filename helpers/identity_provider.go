@@ -0,0 +1,522 @@
+package helpers
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/dgrijalva/jwt-go.v3"
+)
+
+// Social login with OAuth2 and OpenID Connect identity providers
+
+const (
+	// how long a key set is trusted before an unknown key id refetches it
+	oidcKeysRefreshInterval = time.Minute
+
+	googleIssuer   = "https://accounts.google.com"
+	appleIssuer    = "https://appleid.apple.com"
+	gitHubTokenURL = "https://github.com/login/oauth/access_token"
+	gitHubAPIURL   = "https://api.github.com"
+)
+
+// ErrSocialCredentials is returned when the credentials are missing what the provider needs
+var ErrSocialCredentials = errors.New("missing social login credentials")
+
+// SocialCredentials are what a client got from an identity provider: either
+// tokens, or an authorization code for us to exchange
+type SocialCredentials struct {
+	AccessToken  string
+	IDToken      string
+	Code         string
+	RedirectURI  string
+	CodeVerifier string
+	// Nonce is checked against the id token when set
+	Nonce string
+	// Subject is the account id, needed by providers without id tokens
+	Subject string
+}
+
+// SocialIdentity is the provider account the credentials belong to
+type SocialIdentity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Picture       string
+	AccessToken   string
+	RefreshToken  string
+}
+
+// IdentityProvider verifies credentials issued by an identity provider
+type IdentityProvider interface {
+	// Name identifies the provider in routes and stored identities
+	Name() string
+	// Authenticate checks the credentials were issued to us, and returns the account they belong to
+	Authenticate(credentials *SocialCredentials) (*SocialIdentity, error)
+}
+
+// FacebookProvider verifies Facebook access tokens, which need the user id as the subject
+type FacebookProvider struct {
+	ProviderName string
+	AppID        string
+	AppSecret    string
+	// Client makes the requests to Facebook, http.DefaultClient if nil
+	Client *http.Client
+}
+
+// Name implements IdentityProvider
+func (p *FacebookProvider) Name() string {
+	return p.ProviderName
+}
+
+// Authenticate implements IdentityProvider
+func (p *FacebookProvider) Authenticate(credentials *SocialCredentials) (*SocialIdentity, error) {
+	if credentials.Subject == "" || credentials.AccessToken == "" {
+		return nil, ErrSocialCredentials
+	}
+	if valid, err := ValidateFacebookLogin(p.Client, credentials.Subject, credentials.AccessToken, p.AppID, p.AppSecret); err != nil {
+		return nil, err
+	} else if !valid {
+		return nil, errors.New("Could not validate facebook token")
+	}
+	identity := &SocialIdentity{Provider: p.ProviderName, Subject: credentials.Subject, AccessToken: credentials.AccessToken}
+	// the profile is best effort, the token is what matters
+	if apiUser, err := GetFacebookUserInfo(p.Client, credentials.Subject, credentials.AccessToken, p.AppID, p.AppSecret); err == nil {
+		identity.Email = apiUser.Email
+		identity.Name = apiUser.Name
+		identity.Picture, _ = apiUser.Picture.Data["url"].(string)
+	}
+	return identity, nil
+}
+
+// OIDCProvider verifies id tokens from an OpenID Connect provider,
+// configured through discovery from its issuer
+type OIDCProvider struct {
+	ProviderName string
+	Issuer       string
+	// AlternateIssuers are other iss values the provider's id tokens may carry
+	AlternateIssuers []string
+	ClientID         string
+	// ClientSecret is only needed to exchange authorization codes
+	ClientSecret string
+	// Client makes the requests to the provider, http.DefaultClient if nil
+	Client *http.Client
+
+	mu        sync.Mutex
+	discovery *oidcDiscovery
+	keys      map[string]interface{}
+	keysAt    time.Time
+}
+
+type oidcDiscovery struct {
+	Issuer        string `json:"issuer"`
+	TokenEndpoint string `json:"token_endpoint"`
+	JWKSURI       string `json:"jwks_uri"`
+}
+
+type oauth2Tokens struct {
+	AccessToken      string `json:"access_token"`
+	IDToken          string `json:"id_token"`
+	RefreshToken     string `json:"refresh_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// NewGoogleProvider creates a provider for Sign in with Google
+func NewGoogleProvider(name, clientID, clientSecret string, client *http.Client) *OIDCProvider {
+	return &OIDCProvider{
+		ProviderName:     name,
+		Issuer:           googleIssuer,
+		AlternateIssuers: []string{strings.TrimPrefix(googleIssuer, "https://")},
+		ClientID:         clientID,
+		ClientSecret:     clientSecret,
+		Client:           client,
+	}
+}
+
+// NewAppleProvider creates a provider for Sign in with Apple. The client secret is the
+// JWT signed with the team's key, and is only needed to exchange codes
+func NewAppleProvider(name, clientID, clientSecret string, client *http.Client) *OIDCProvider {
+	return &OIDCProvider{ProviderName: name, Issuer: appleIssuer, ClientID: clientID, ClientSecret: clientSecret, Client: client}
+}
+
+// Name implements IdentityProvider
+func (p *OIDCProvider) Name() string {
+	return p.ProviderName
+}
+
+// Authenticate implements IdentityProvider. Access tokens on their own are refused,
+// as only the id token proves the login was meant for us
+func (p *OIDCProvider) Authenticate(credentials *SocialCredentials) (*SocialIdentity, error) {
+	tokens := &oauth2Tokens{AccessToken: credentials.AccessToken, IDToken: credentials.IDToken}
+	if credentials.Code != "" {
+		discovery, err := p.getDiscovery()
+		if err != nil {
+			return nil, err
+		}
+		if tokens, err = exchangeOAuth2Code(httpClient(p.Client), discovery.TokenEndpoint, p.ClientID, p.ClientSecret, credentials); err != nil {
+			return nil, err
+		}
+	}
+	if tokens.IDToken == "" {
+		return nil, ErrSocialCredentials
+	}
+	claims, err := p.VerifyIDToken(tokens.IDToken, credentials.Nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	identity := &SocialIdentity{
+		Provider:     p.ProviderName,
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+	}
+	identity.Subject, _ = claims["sub"].(string)
+	identity.Email, _ = claims["email"].(string)
+	identity.Name, _ = claims["name"].(string)
+	identity.Picture, _ = claims["picture"].(string)
+	// some providers send booleans as strings
+	switch verified := claims["email_verified"].(type) {
+	case bool:
+		identity.EmailVerified = verified
+	case string:
+		identity.EmailVerified = verified == "true"
+	}
+	return identity, nil
+}
+
+// VerifyIDToken verifies the signature, issuer, audience and expiry of an id token,
+// and the nonce if one is given
+func (p *OIDCProvider) VerifyIDToken(idToken, nonce string) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(idToken, p.idTokenKey)
+	if err != nil {
+		return nil, err
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, errors.New("oidc: invalid id token")
+	}
+	if !claims.VerifyExpiresAt(time.Now().Unix(), true) {
+		return nil, errors.New("oidc: id token has no expiry")
+	}
+	iss, _ := claims["iss"].(string)
+	if !p.validIssuer(iss) {
+		return nil, fmt.Errorf("oidc: unexpected issuer %q", iss)
+	}
+	if !audienceContains(claims["aud"], p.ClientID) {
+		return nil, errors.New("oidc: id token was not issued to this client")
+	}
+	if sub, _ := claims["sub"].(string); sub == "" {
+		return nil, errors.New("oidc: id token has no subject")
+	}
+	if tokenNonce, _ := claims["nonce"].(string); nonce != "" && tokenNonce != nonce {
+		return nil, errors.New("oidc: nonce mismatch")
+	}
+	return claims, nil
+}
+
+func (p *OIDCProvider) validIssuer(iss string) bool {
+	if iss == p.Issuer {
+		return true
+	}
+	for _, alternate := range p.AlternateIssuers {
+		if iss == alternate {
+			return true
+		}
+	}
+	return false
+}
+
+// idTokenKey finds the provider key an id token was signed with. The key type has to match
+// the algorithm, so a public key can't be passed off as an HMAC secret
+func (p *OIDCProvider) idTokenKey(token *jwt.Token) (interface{}, error) {
+	keyID, _ := token.Header[kid].(string)
+	key, err := p.key(keyID)
+	if err != nil {
+		return nil, err
+	}
+	switch key.(type) {
+	case *rsa.PublicKey:
+		if _, ok := token.Method.(*jwt.SigningMethodRSA); ok {
+			return key, nil
+		}
+	case *ecdsa.PublicKey:
+		if _, ok := token.Method.(*jwt.SigningMethodECDSA); ok {
+			return key, nil
+		}
+	case ed25519.PublicKey:
+		if token.Method == SigningMethodEdDSA {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("oidc: unexpected signing method %v", token.Header["alg"])
+}
+
+func (p *OIDCProvider) key(keyID string) (interface{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	lookup := func() interface{} {
+		if keyID == "" && len(p.keys) == 1 {
+			for _, key := range p.keys {
+				return key
+			}
+		}
+		return p.keys[keyID]
+	}
+	if key := lookup(); key != nil {
+		return key, nil
+	}
+	// providers rotate keys, so an unknown key id refetches the set
+	if p.keys != nil && time.Since(p.keysAt) < oidcKeysRefreshInterval {
+		return nil, fmt.Errorf("oidc: unknown key id %q", keyID)
+	}
+	discovery, err := p.discover()
+	if err != nil {
+		return nil, err
+	}
+	var set JWKS
+	if err := getJSON(httpClient(p.Client), discovery.JWKSURI, &set); err != nil {
+		return nil, err
+	}
+	p.keys = make(map[string]interface{}, len(set.Keys))
+	p.keysAt = time.Now()
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		if key, err := jwk.PublicKey(); err == nil {
+			p.keys[jwk.Kid] = key
+		}
+	}
+	if key := lookup(); key != nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("oidc: unknown key id %q", keyID)
+}
+
+func (p *OIDCProvider) getDiscovery() (*oidcDiscovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.discover()
+}
+
+// discover fetches the provider configuration once, p.mu must be held
+func (p *OIDCProvider) discover() (*oidcDiscovery, error) {
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+	var discovery oidcDiscovery
+	if err := getJSON(httpClient(p.Client), strings.TrimSuffix(p.Issuer, "/")+"/.well-known/openid-configuration", &discovery); err != nil {
+		return nil, err
+	}
+	if discovery.Issuer != p.Issuer {
+		return nil, fmt.Errorf("oidc: discovered issuer %q does not match %q", discovery.Issuer, p.Issuer)
+	}
+	if discovery.JWKSURI == "" {
+		return nil, errors.New("oidc: no jwks_uri discovered")
+	}
+	p.discovery = &discovery
+	return p.discovery, nil
+}
+
+// GitHubProvider verifies GitHub OAuth app logins. GitHub has no id tokens,
+// so access tokens are checked against the app with the client secret
+type GitHubProvider struct {
+	ProviderName string
+	ClientID     string
+	ClientSecret string
+	// TokenURL and APIURL default to github.com, and can point at GitHub Enterprise
+	TokenURL string
+	APIURL   string
+	// Client makes the requests to GitHub, http.DefaultClient if nil
+	Client *http.Client
+}
+
+type gitHubUser struct {
+	ID        int64  `json:"id"`
+	Login     string `json:"login"`
+	Name      string `json:"name"`
+	Email     string `json:"email"`
+	AvatarURL string `json:"avatar_url"`
+}
+
+type gitHubEmail struct {
+	Email    string `json:"email"`
+	Primary  bool   `json:"primary"`
+	Verified bool   `json:"verified"`
+}
+
+// Name implements IdentityProvider
+func (p *GitHubProvider) Name() string {
+	return p.ProviderName
+}
+
+// Authenticate implements IdentityProvider
+func (p *GitHubProvider) Authenticate(credentials *SocialCredentials) (*SocialIdentity, error) {
+	client := httpClient(p.Client)
+	apiURL := strings.TrimSuffix(p.APIURL, "/")
+	if apiURL == "" {
+		apiURL = gitHubAPIURL
+	}
+
+	var user gitHubUser
+	accessToken := credentials.AccessToken
+	switch {
+	case credentials.Code != "":
+		tokenURL := p.TokenURL
+		if tokenURL == "" {
+			tokenURL = gitHubTokenURL
+		}
+		tokens, err := exchangeOAuth2Code(client, tokenURL, p.ClientID, p.ClientSecret, credentials)
+		if err != nil {
+			return nil, err
+		}
+		accessToken = tokens.AccessToken
+		if err := p.getJSON(client, apiURL+"/user", accessToken, &user); err != nil {
+			return nil, err
+		}
+	case accessToken != "":
+		// only succeeds for tokens issued to this app, and returns their user
+		body, _ := json.Marshal(map[string]string{"access_token": accessToken})
+		req, err := http.NewRequest(http.MethodPost, apiURL+"/applications/"+url.PathEscape(p.ClientID)+"/token", bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.SetBasicAuth(p.ClientID, p.ClientSecret)
+		req.Header.Set("Content-Type", "application/json")
+		var check struct {
+			User gitHubUser `json:"user"`
+		}
+		if err := doJSON(client, req, &check); err != nil {
+			return nil, err
+		}
+		user = check.User
+	default:
+		return nil, ErrSocialCredentials
+	}
+	if user.ID == 0 {
+		return nil, errors.New("github: no user for token")
+	}
+
+	identity := &SocialIdentity{
+		Provider:    p.ProviderName,
+		Subject:     strconv.FormatInt(user.ID, 10),
+		Name:        user.Name,
+		Picture:     user.AvatarURL,
+		Email:       user.Email,
+		AccessToken: accessToken,
+	}
+	if identity.Name == "" {
+		identity.Name = user.Login
+	}
+	// the profile email isn't necessarily verified, the email list needs the user:email scope
+	var emails []gitHubEmail
+	if err := p.getJSON(client, apiURL+"/user/emails", accessToken, &emails); err == nil {
+		for _, email := range emails {
+			if email.Primary && email.Verified {
+				identity.Email = email.Email
+				identity.EmailVerified = true
+			}
+		}
+	}
+	return identity, nil
+}
+
+func (p *GitHubProvider) getJSON(client *http.Client, endpoint, accessToken string, v interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	return doJSON(client, req, v)
+}
+
+// exchangeOAuth2Code exchanges an authorization code at the token endpoint
+func exchangeOAuth2Code(client *http.Client, tokenURL, clientID, clientSecret string, credentials *SocialCredentials) (*oauth2Tokens, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", credentials.Code)
+	form.Set("client_id", clientID)
+	if clientSecret != "" {
+		form.Set("client_secret", clientSecret)
+	}
+	if credentials.RedirectURI != "" {
+		form.Set("redirect_uri", credentials.RedirectURI)
+	}
+	if credentials.CodeVerifier != "" {
+		form.Set("code_verifier", credentials.CodeVerifier)
+	}
+	req, err := http.NewRequest(http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var tokens oauth2Tokens
+	if err := doJSON(client, req, &tokens); err != nil {
+		return nil, err
+	}
+	if tokens.Error != "" {
+		return nil, fmt.Errorf("oauth2: %s %s", tokens.Error, tokens.ErrorDescription)
+	}
+	if tokens.AccessToken == "" && tokens.IDToken == "" {
+		return nil, errors.New("oauth2: no tokens in response")
+	}
+	return &tokens, nil
+}
+
+func getJSON(client *http.Client, endpoint string, v interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	return doJSON(client, req, v)
+}
+
+func doJSON(client *http.Client, req *http.Request, v interface{}) error {
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s %s: response code %d", req.Method, req.URL.Host+req.URL.Path, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func httpClient(client *http.Client) *http.Client {
+	if client == nil {
+		return http.DefaultClient
+	}
+	return client
+}
+
+// audienceContains checks the aud claim, which can be a string or a list
+func audienceContains(aud interface{}, clientID string) bool {
+	switch aud := aud.(type) {
+	case string:
+		return clientID != "" && aud == clientID
+	case []interface{}:
+		for _, value := range aud {
+			if value == clientID && clientID != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,191 @@
+package helpers
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gopkg.in/dgrijalva/jwt-go.v3"
+)
+
+// oidcStandIn is an OpenID Connect provider serving discovery, keys and code exchange
+type oidcStandIn struct {
+	*httptest.Server
+	t        *testing.T
+	key      *SigningKey
+	keyFetch int
+	// codes maps authorization codes to the id tokens they exchange for
+	codes map[string]string
+}
+
+func newOIDCStandIn(t *testing.T) *oidcStandIn {
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &oidcStandIn{t: t, codes: map[string]string{}}
+	s.key = &SigningKey{Method: jwt.SigningMethodES256, signKey: private, verifyKey: &private.PublicKey}
+	s.key.ID = s.key.thumbprint()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":         s.URL,
+			"token_endpoint": s.URL + "/token",
+			"jwks_uri":       s.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		s.keyFetch++
+		jwk, _ := s.key.JWK()
+		json.NewEncoder(w).Encode(JWKS{Keys: []JWK{jwk}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		idToken, ok := s.codes[r.PostFormValue("code")]
+		if !ok || r.PostFormValue("client_secret") != "secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"access_token": "access", "id_token": idToken, "refresh_token": "refresh"})
+	})
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+func (s *oidcStandIn) idToken(key *SigningKey, claims jwt.MapClaims) string {
+	base := jwt.MapClaims{
+		"iss":   s.URL,
+		"aud":   "client",
+		"sub":   "subject",
+		"email": "user@example.com",
+		"exp":   time.Now().Add(time.Minute).Unix(),
+	}
+	for name, value := range claims {
+		base[name] = value
+	}
+	token := jwt.NewWithClaims(key.Method, base)
+	token.Header[kid] = key.ID
+	signed, err := token.SignedString(key.signKey)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	return signed
+}
+
+func TestOIDCProvider(t *testing.T) {
+	s := newOIDCStandIn(t)
+	defer s.Close()
+	provider := &OIDCProvider{ProviderName: "oidc", Issuer: s.URL, ClientID: "client", ClientSecret: "secret"}
+
+	identity, err := provider.Authenticate(&SocialCredentials{IDToken: s.idToken(s.key, jwt.MapClaims{"email_verified": "true"})})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if identity.Provider != "oidc" || identity.Subject != "subject" || identity.Email != "user@example.com" || !identity.EmailVerified {
+		t.Errorf("unexpected identity %+v", identity)
+	}
+
+	s.codes["code"] = s.idToken(s.key, jwt.MapClaims{"nonce": "nonce"})
+	identity, err = provider.Authenticate(&SocialCredentials{Code: "code", Nonce: "nonce"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if identity.AccessToken != "access" || identity.RefreshToken != "refresh" {
+		t.Errorf("expected the exchanged tokens, got %+v", identity)
+	}
+	if _, err := provider.Authenticate(&SocialCredentials{Code: "unknown"}); err == nil {
+		t.Error("expected an unknown code to fail")
+	}
+
+	invalid := map[string]string{
+		"wrong audience": s.idToken(s.key, jwt.MapClaims{"aud": "other"}),
+		"wrong issuer":   s.idToken(s.key, jwt.MapClaims{"iss": "https://other.example.com"}),
+		"expired":        s.idToken(s.key, jwt.MapClaims{"exp": time.Now().Add(-time.Minute).Unix()}),
+		"no subject":     s.idToken(s.key, jwt.MapClaims{"sub": ""}),
+		"nonce mismatch": s.idToken(s.key, jwt.MapClaims{"nonce": "other"}),
+		// the public key must not verify HMAC signatures
+		"hmac": s.idToken(&SigningKey{ID: s.key.ID, Method: jwt.SigningMethodHS256, signKey: []byte("secret")}, nil),
+	}
+	for name, idToken := range invalid {
+		if _, err := provider.Authenticate(&SocialCredentials{IDToken: idToken, Nonce: "nonce"}); err == nil {
+			t.Errorf("%s: expected the id token to be rejected", name)
+		}
+	}
+	if _, err := provider.Authenticate(&SocialCredentials{AccessToken: "access"}); err != ErrSocialCredentials {
+		t.Errorf("expected an access token on its own to be refused, got %v", err)
+	}
+
+	// unknown key ids don't refetch the keys every time
+	fetches := s.keyFetch
+	other, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	otherKey := &SigningKey{ID: "other", Method: jwt.SigningMethodES256, signKey: other}
+	for i := 0; i < 3; i++ {
+		if _, err := provider.Authenticate(&SocialCredentials{IDToken: s.idToken(otherKey, nil)}); err == nil {
+			t.Error("expected an unknown key to fail")
+		}
+	}
+	if s.keyFetch != fetches {
+		t.Errorf("expected the keys to be cached, fetched %d more times", s.keyFetch-fetches)
+	}
+}
+
+func TestGitHubProvider(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/applications/client/token", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		if id, secret, _ := r.BasicAuth(); id != "client" || secret != "secret" || body["access_token"] != "token" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"user": map[string]interface{}{"id": 42, "login": "octocat", "email": "public@example.com"}})
+	})
+	mux.HandleFunc("/user/emails", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]map[string]interface{}{
+			{"email": "other@example.com", "primary": false, "verified": true},
+			{"email": "octocat@example.com", "primary": true, "verified": true},
+		})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	provider := &GitHubProvider{ProviderName: "github", ClientID: "client", ClientSecret: "secret", APIURL: server.URL}
+
+	identity, err := provider.Authenticate(&SocialCredentials{AccessToken: "token"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if identity.Subject != "42" || identity.Name != "octocat" || identity.Email != "octocat@example.com" || !identity.EmailVerified {
+		t.Errorf("unexpected identity %+v", identity)
+	}
+	if _, err := provider.Authenticate(&SocialCredentials{AccessToken: "someone else's"}); err == nil {
+		t.Error("expected a token for another app to fail")
+	}
+}
+
+func TestJWKPublicKey(t *testing.T) {
+	for _, alg := range []string{"RS256", "ES256", "EdDSA"} {
+		pemBytes, _, err := generateKeyFile(alg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		key, err := ParseSigningKeyPEM(pemBytes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		jwk, _ := key.JWK()
+		pub, err := jwk.PublicKey()
+		if err != nil {
+			t.Fatalf("%s: %s", alg, err)
+		}
+		parsed := &SigningKey{Method: key.Method, verifyKey: pub}
+		if parsedJWK, _ := parsed.JWK(); parsedJWK.X != jwk.X || parsedJWK.N != jwk.N {
+			t.Errorf("%s: expected the same key back", alg)
+		}
+	}
+}
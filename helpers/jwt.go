@@ -32,13 +32,20 @@ const (
 	exp string = "exp"
 	iat string = "iat"
 	jti string = "jti"
+	kid string = "kid"
+
+	// iatMicros is the issue time in microseconds, iat only has second precision
+	iatMicros string = "iat_us"
 )
 
 // JWTHelper is a nice wrapper for you
 type JWTHelper struct {
 	HashSecretBytes []byte
-	Token           string
-	JTI             string
+	// Keys signs new tokens and verifies tokens with a kid header;
+	// tokens without one are verified with HashSecretBytes
+	Keys  *Keyring
+	Token string
+	JTI   string
 }
 
 // Generate creates a new jwt returns the erorr if there was any
@@ -50,15 +57,25 @@ func (h *JWTHelper) Generate(claims map[string]interface{}, d time.Duration) err
 	//fmt.Println("expiry time: " + later.String())
 	// replay attacks prevention
 	claims[iat] = now.Unix()
+	claims[iatMicros] = now.UnixNano() / int64(time.Microsecond)
 	// v4 is crypto-secure
 	// allows for individual token invalidation (if we tie it back to the user in some way)
 	uuidStr := uuid.NewV4().String()
 	claims[jti] = uuidStr
-	token := jwt.New(jwt.GetSigningMethod("HS256"))
+	var token *jwt.Token
+	var signKey interface{}
+	if h.Keys != nil {
+		token = jwt.New(h.Keys.Current.Method)
+		token.Header[kid] = h.Keys.Current.ID
+		signKey = h.Keys.Current.signKey
+	} else {
+		token = jwt.New(jwt.GetSigningMethod("HS256"))
+		signKey = h.HashSecretBytes
+	}
 	token.Claims = jwt.MapClaims(claims)
 	// TODO: Hide the actual error ?
 	//fmt.Println("Generate hash secret bytes: " + string(h.HashSecretBytes))
-	signedToken, err := token.SignedString(h.HashSecretBytes)
+	signedToken, err := token.SignedString(signKey)
 
 	if err != nil {
 		return err
@@ -74,6 +91,17 @@ type JWTokenValidateResult struct {
 	Message string
 	Status  JWTokenStatus
 	Value   string
+	// JTI, IssuedAt, ExpiresAt and Claims are only set for valid tokens
+	JTI       string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+	Claims    map[string]interface{}
+}
+
+// StringClaim returns a string claim of the token, "" if it doesn't have it
+func (r *JWTokenValidateResult) StringClaim(claim string) string {
+	value, _ := r.Claims[claim].(string)
+	return value
 }
 
 // Validate checks the JWT Auth token, returning the status and
@@ -124,19 +152,47 @@ func (h *JWTHelper) Validate(claim string) *JWTokenValidateResult {
 		return &JWTokenValidateResult{Message: "Invalid token [2]", Status: JWTokenStatusInvalid, Value: ""}
 	}
 
-	return &JWTokenValidateResult{Message: "Token Valid", Status: JWTokenStatusValid, Value: value}
+	result := &JWTokenValidateResult{Message: "Token Valid", Status: JWTokenStatusValid, Value: value, Claims: claims}
+	result.JTI, _ = claims[jti].(string)
+	if issued, ok := claims[iatMicros].(float64); ok {
+		result.IssuedAt = time.Unix(0, int64(issued)*int64(time.Microsecond))
+	} else if issued, ok := claims[iat].(float64); ok {
+		result.IssuedAt = time.Unix(int64(issued), 0)
+	}
+	if expires, ok := claims[exp].(float64); ok {
+		result.ExpiresAt = time.Unix(int64(expires), 0)
+	}
+	return result
 }
 
 // validateToken validates that the token is signed using the correct algorithm
 // if so, it returns the secret, otherwise an error
 // must have the signature: func(*Token) (interface{}, error)
 func (h *JWTHelper) validateToken(token *jwt.Token) (interface{}, error) {
+	key, err := h.tokenKey(token)
+	if err != nil {
+		return nil, err
+	}
+	return key.verifyKey, nil
+}
+
+// tokenKey finds the key the token was signed with
+func (h *JWTHelper) tokenKey(token *jwt.Token) (*SigningKey, error) {
+	var key *SigningKey
+	if keyID, ok := token.Header[kid].(string); ok && h.Keys != nil {
+		if key = h.Keys.Key(keyID); key == nil {
+			return nil, fmt.Errorf("Unknown key id: %s", keyID)
+		}
+	} else {
+		// tokens without a kid were signed with the hash secret
+		key = &SigningKey{Method: jwt.SigningMethodHS256, signKey: h.HashSecretBytes, verifyKey: h.HashSecretBytes}
+	}
 	// Don't forget to validate the alg is what you expect:
-	if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+	if token.Method.Alg() != key.Method.Alg() {
 		//fmt.Println("SIGNING METHOD DIFFERENT")
 		return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
 	}
-	return h.HashSecretBytes, nil
+	return key, nil
 }
 
 // Expire used for manually expiring a token (typically in testing scenarios)
@@ -152,7 +208,11 @@ func (h *JWTHelper) Expire() error {
 	claims := token.Claims.(jwt.MapClaims)
 	claims[exp] = time.Now().Unix()
 
-	h.Token, err = token.SignedString(h.HashSecretBytes)
+	key, err := h.tokenKey(token)
+	if err != nil {
+		return errors.New("could not expire token [1]")
+	}
+	h.Token, err = token.SignedString(key.signKey)
 	if err != nil {
 		return errors.New("could not expire token [1]")
 	}
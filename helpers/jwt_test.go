@@ -0,0 +1,54 @@
+package helpers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidateReturnsTokenClaims(t *testing.T) {
+	jwt := JWTHelper{HashSecretBytes: []byte("secret")}
+	before := time.Now()
+	if err := jwt.Generate(map[string]interface{}{"userid": "abc"}, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	after := time.Now()
+
+	result := jwt.Validate("userid")
+	if result.Status != JWTokenStatusValid {
+		t.Fatalf("expected a valid token, got %s", result.Message)
+	}
+	if result.Value != "abc" {
+		t.Errorf("expected claim value abc, got %s", result.Value)
+	}
+	if result.JTI == "" || result.JTI != jwt.JTI {
+		t.Errorf("expected jti %s, got %s", jwt.JTI, result.JTI)
+	}
+	// revocations compare issue times down to the microsecond
+	if result.IssuedAt.Before(before.Truncate(time.Microsecond)) || result.IssuedAt.After(after) {
+		t.Errorf("expected issued at between %s and %s, got %s", before, after, result.IssuedAt)
+	}
+	if until := time.Until(result.ExpiresAt); until < 59*time.Minute || until > time.Hour {
+		t.Errorf("unexpected expires at %s", result.ExpiresAt)
+	}
+}
+
+func TestStringClaim(t *testing.T) {
+	jwt := JWTHelper{HashSecretBytes: []byte("secret")}
+	if err := jwt.Generate(map[string]interface{}{"userid": "abc", "tenant": "t1", "roles": []string{"admin"}}, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	result := jwt.Validate("userid")
+	if tenant := result.StringClaim("tenant"); tenant != "t1" {
+		t.Errorf("expected tenant t1, got %q", tenant)
+	}
+	if roles := result.StringClaim("roles"); roles != "" {
+		t.Errorf("expected no string for a list claim, got %q", roles)
+	}
+	if missing := result.StringClaim("missing"); missing != "" {
+		t.Errorf("expected no missing claim, got %q", missing)
+	}
+	if invalid := (&JWTokenValidateResult{}).StringClaim("tenant"); invalid != "" {
+		t.Errorf("expected no claims on invalid tokens, got %q", invalid)
+	}
+}
@@ -0,0 +1,262 @@
+package helpers
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// keyringCurrentFile names the kid of the current signing key
+	keyringCurrentFile = "current"
+	// keyringPEMExt is the extension of asymmetric private keys
+	keyringPEMExt = ".pem"
+	// keyringSecretExt is the extension of HMAC secrets
+	keyringSecretExt = ".secret"
+)
+
+// Keyring holds the key new tokens are signed with,
+// along with every key tokens can be verified with
+type Keyring struct {
+	Current *SigningKey
+	keys    map[string]*SigningKey
+}
+
+// NewKeyring creates a keyring that signs with current and also verifies with others
+func NewKeyring(current *SigningKey, others ...*SigningKey) *Keyring {
+	kr := &Keyring{Current: current, keys: make(map[string]*SigningKey, len(others)+1)}
+	for _, key := range others {
+		kr.keys[key.ID] = key
+	}
+	kr.keys[current.ID] = current
+	return kr
+}
+
+// Key returns the key for a kid, nil if there is none
+func (kr *Keyring) Key(kid string) *SigningKey {
+	return kr.keys[kid]
+}
+
+// JWKS returns the public keys of the keyring
+func (kr *Keyring) JWKS() JWKS {
+	jwks := JWKS{Keys: []JWK{}}
+	// current key first
+	if jwk, ok := kr.Current.JWK(); ok {
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+	for kid, key := range kr.keys {
+		if kid == kr.Current.ID {
+			continue
+		}
+		if jwk, ok := key.JWK(); ok {
+			jwks.Keys = append(jwks.Keys, jwk)
+		}
+	}
+	return jwks
+}
+
+// LoadKeyring loads a keyring directory. Every <kid>.pem (private key) and
+// <kid>.secret (HMAC secret) file in the directory is a verification key,
+// and the "current" file names the kid new tokens are signed with.
+// others are added as verification keys as well
+func LoadKeyring(dir string, others ...*SigningKey) (*Keyring, error) {
+	currentID, err := ioutil.ReadFile(filepath.Join(dir, keyringCurrentFile))
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := keyringFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var current *SigningKey
+	for _, file := range files {
+		key, err := file.load()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", file.path, err.Error())
+		}
+		if key.ID == strings.TrimSpace(string(currentID)) {
+			current = key
+		} else {
+			others = append(others, key)
+		}
+	}
+	if current == nil {
+		return nil, fmt.Errorf("current key %s not found in %s", strings.TrimSpace(string(currentID)), dir)
+	}
+	return NewKeyring(current, others...), nil
+}
+
+// AddKey generates a new key for the signing method alg (RS256, ES256, EdDSA or HS256) in the
+// keyring directory. The key only verifies tokens, and its public key is published, until it is
+// promoted with PromoteKey; that gives other services time to fetch it before tokens are signed
+// with it. The first key of a new keyring is promoted right away
+func AddKey(dir, alg string) (string, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	data, ext, err := generateKeyFile(alg)
+	if err != nil {
+		return "", err
+	}
+	var key *SigningKey
+	if ext == keyringSecretExt {
+		key = NewHMACSigningKey(data)
+	} else if key, err = ParseSigningKeyPEM(data); err != nil {
+		return "", err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, key.ID+ext), data, 0600); err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(filepath.Join(dir, keyringCurrentFile)); os.IsNotExist(err) {
+		return key.ID, PromoteKey(dir, key.ID, 0)
+	} else if err != nil {
+		return "", err
+	}
+	return key.ID, nil
+}
+
+// PromoteKey makes an existing key of the keyring directory the current key, which new tokens are
+// signed with; promoting a previous key rolls back. Only the keep most recent other keys are kept,
+// tokens signed with older keys stop validating
+func PromoteKey(dir, kid string, keep int) error {
+	files, err := keyringFiles(dir)
+	if err != nil {
+		return err
+	}
+	found := false
+	for _, file := range files {
+		found = found || file.id == kid
+	}
+	if !found {
+		return fmt.Errorf("key %s not found in %s", kid, dir)
+	}
+	// write then rename so a running server never sees a partial file
+	tmp := filepath.Join(dir, keyringCurrentFile+".tmp")
+	if err := ioutil.WriteFile(tmp, []byte(kid+"\n"), 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, filepath.Join(dir, keyringCurrentFile)); err != nil {
+		return err
+	}
+	return pruneKeyring(dir, kid, keep)
+}
+
+// pruneKeyring removes all but the keep most recently modified previous keys
+func pruneKeyring(dir, currentID string, keep int) error {
+	files, err := keyringFiles(dir)
+	if err != nil {
+		return err
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.After(files[j].modTime)
+	})
+	kept := 0
+	for _, file := range files {
+		if file.id == currentID {
+			continue
+		}
+		if kept < keep {
+			kept++
+			continue
+		}
+		if err := os.Remove(file.path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// keyFile is a key file of a keyring directory
+type keyFile struct {
+	path    string
+	id      string
+	ext     string
+	modTime time.Time
+}
+
+// keyringFiles lists the key files of a keyring directory
+func keyringFiles(dir string) ([]keyFile, error) {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []keyFile
+	for _, info := range infos {
+		ext := filepath.Ext(info.Name())
+		if info.IsDir() || (ext != keyringPEMExt && ext != keyringSecretExt) {
+			continue
+		}
+		files = append(files, keyFile{
+			path:    filepath.Join(dir, info.Name()),
+			id:      strings.TrimSuffix(info.Name(), ext),
+			ext:     ext,
+			modTime: info.ModTime(),
+		})
+	}
+	return files, nil
+}
+
+// load loads the key, the kid is the name of the file
+func (file keyFile) load() (*SigningKey, error) {
+	data, err := ioutil.ReadFile(file.path)
+	if err != nil {
+		return nil, err
+	}
+	var key *SigningKey
+	if file.ext == keyringSecretExt {
+		secret := []byte(strings.TrimSpace(string(data)))
+		if len(secret) < 32 {
+			return nil, errors.New("HMAC secrets must be at least 32 bytes")
+		}
+		key = NewHMACSigningKey(secret)
+	} else if key, err = ParseSigningKeyPEM(data); err != nil {
+		return nil, err
+	}
+	key.ID = file.id
+	return key, nil
+}
+
+// generateKeyFile generates the contents of a new key file
+func generateKeyFile(alg string) ([]byte, string, error) {
+	var private interface{}
+	var err error
+	switch alg {
+	case "HS256":
+		secret := make([]byte, 48)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, "", err
+		}
+		return []byte(base64.RawURLEncoding.EncodeToString(secret)), keyringSecretExt, nil
+	case "RS256":
+		private, err = rsa.GenerateKey(rand.Reader, 2048)
+	case "ES256":
+		private, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case "EdDSA":
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, "", fmt.Errorf("unsupported signing method: %s", alg)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, "", err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), keyringPEMExt, nil
+}
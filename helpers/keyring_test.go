@@ -0,0 +1,94 @@
+package helpers
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestKeyringRotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keyring")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// the first key of a new keyring is current right away
+	first, err := AddKey(dir, "ES256")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := LoadKeyring(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys.Current.ID != first {
+		t.Fatalf("expected current key %s, got %s", first, keys.Current.ID)
+	}
+	old := JWTHelper{Keys: keys}
+	if err := old.Generate(map[string]interface{}{"userid": "abc"}, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	// later keys are only published until they are promoted
+	second, err := AddKey(dir, "EdDSA")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys, err = LoadKeyring(dir); err != nil {
+		t.Fatal(err)
+	}
+	if keys.Current.ID != first {
+		t.Fatalf("expected current key %s, got %s", first, keys.Current.ID)
+	}
+	if keys.Key(second) == nil || len(keys.JWKS().Keys) != 2 {
+		t.Errorf("expected both public keys to be published, got %d", len(keys.JWKS().Keys))
+	}
+
+	if err := PromoteKey(dir, second, 1); err != nil {
+		t.Fatal(err)
+	}
+	if keys, err = LoadKeyring(dir); err != nil {
+		t.Fatal(err)
+	}
+	if keys.Current.ID != second || keys.Current.Method.Alg() != "EdDSA" {
+		t.Fatalf("expected current key %s, got %s", second, keys.Current.ID)
+	}
+
+	// tokens signed with the previous key still validate
+	verifier := JWTHelper{Keys: keys, Token: old.Token}
+	if result := verifier.Validate("userid"); result.Status != JWTokenStatusValid {
+		t.Errorf("expected a valid token, got %s", result.Message)
+	}
+
+	// until the key is pruned
+	third, err := AddKey(dir, "HS256")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := PromoteKey(dir, third, 1); err != nil {
+		t.Fatal(err)
+	}
+	if keys, err = LoadKeyring(dir); err != nil {
+		t.Fatal(err)
+	}
+	if keys.Key(first) != nil {
+		t.Error("expected the oldest key to be pruned")
+	}
+	verifier = JWTHelper{Keys: keys, Token: old.Token}
+	if result := verifier.Validate("userid"); result.Status != JWTokenStatusInvalid {
+		t.Error("expected an invalid token")
+	}
+
+	// promoting a previous key rolls back
+	if err := PromoteKey(dir, second, 1); err != nil {
+		t.Fatal(err)
+	}
+	if keys, err = LoadKeyring(dir); err != nil {
+		t.Fatal(err)
+	}
+	if keys.Current.ID != second {
+		t.Errorf("expected current key %s, got %s", second, keys.Current.ID)
+	}
+}
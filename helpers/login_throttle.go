@@ -0,0 +1,69 @@
+package helpers
+
+import (
+	"net"
+	"strings"
+	"time"
+)
+
+// LoginThrottlePolicy is how failed logins are slowed down. After the free attempts each
+// failure doubles the wait before the next attempt, up to MaxDelay, and reaching
+// LockoutThreshold failures locks out further attempts for LockoutDuration.
+// Failures are forgotten once none happened for LockoutDuration
+type LoginThrottlePolicy struct {
+	FreeAttempts     int
+	BaseDelay        time.Duration
+	MaxDelay         time.Duration
+	LockoutThreshold int
+	LockoutDuration  time.Duration
+}
+
+// active returns the failures that still count
+func (p LoginThrottlePolicy) active(failures int, lastFailure, now time.Time) int {
+	if now.Sub(lastFailure) > p.LockoutDuration {
+		return 0
+	}
+	return failures
+}
+
+// Failure returns the failure count after another failed attempt, and the time
+// the lockout ends if this failure locks it out (zero otherwise)
+func (p LoginThrottlePolicy) Failure(failures int, lastFailure, now time.Time) (int, time.Time) {
+	failures = p.active(failures, lastFailure, now) + 1
+	if p.LockoutThreshold > 0 && failures >= p.LockoutThreshold {
+		return failures, now.Add(p.LockoutDuration)
+	}
+	return failures, time.Time{}
+}
+
+// RetryAfter returns how long until the next attempt is allowed, zero if it is allowed now
+func (p LoginThrottlePolicy) RetryAfter(failures int, lastFailure, lockedUntil, now time.Time) time.Duration {
+	if now.Before(lockedUntil) {
+		return lockedUntil.Sub(now)
+	}
+	failures = p.active(failures, lastFailure, now)
+	if failures <= p.FreeAttempts || p.BaseDelay <= 0 {
+		return 0
+	}
+	delay := p.MaxDelay
+	// past 62 doublings the delay overflows, it's long past the max anyway
+	if shift := uint(failures - p.FreeAttempts - 1); shift < 62 && p.BaseDelay<<shift < p.MaxDelay {
+		delay = p.BaseDelay << shift
+	}
+	if wait := lastFailure.Add(delay).Sub(now); wait > 0 {
+		return wait
+	}
+	return 0
+}
+
+// ClientIP returns the address of the client, from the first address in forwarded
+// (the value of a header set by a trusted proxy) if there is one, else from the connection
+func ClientIP(remoteAddr, forwarded string) string {
+	if forwarded != "" {
+		return strings.TrimSpace(strings.Split(forwarded, ",")[0])
+	}
+	if host, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		return host
+	}
+	return remoteAddr
+}
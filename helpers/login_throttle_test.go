@@ -0,0 +1,71 @@
+package helpers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoginThrottlePolicy(t *testing.T) {
+	policy := LoginThrottlePolicy{
+		FreeAttempts:     3,
+		BaseDelay:        time.Second,
+		MaxDelay:         10 * time.Second,
+		LockoutThreshold: 10,
+		LockoutDuration:  15 * time.Minute,
+	}
+	now := time.Now()
+
+	var failures int
+	var lastFailure, lockedUntil time.Time
+	for i := 1; i <= 3; i++ {
+		failures, lockedUntil = policy.Failure(failures, lastFailure, now)
+		lastFailure = now
+		if retry := policy.RetryAfter(failures, lastFailure, lockedUntil, now); retry != 0 {
+			t.Errorf("expected no delay after %d failures, got %s", failures, retry)
+		}
+	}
+
+	// the delay doubles after the free attempts, up to the max
+	for i, expected := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second} {
+		failures, lockedUntil = policy.Failure(failures, lastFailure, now)
+		lastFailure = now
+		if !lockedUntil.IsZero() {
+			t.Fatalf("unexpected lockout after %d failures", failures)
+		}
+		if retry := policy.RetryAfter(failures, lastFailure, lockedUntil, now); retry != expected {
+			t.Errorf("expected a delay of %s after %d failures, got %s", expected, i+4, retry)
+		}
+	}
+	if retry := policy.RetryAfter(failures, lastFailure, lockedUntil, now.Add(10*time.Second)); retry != 0 {
+		t.Errorf("expected no delay once it passed, got %s", retry)
+	}
+
+	failures, lockedUntil = policy.Failure(failures, lastFailure, now)
+	if failures != 10 || !lockedUntil.Equal(now.Add(15*time.Minute)) {
+		t.Fatalf("expected a lockout after 10 failures, got %d failures and %s", failures, lockedUntil)
+	}
+	if retry := policy.RetryAfter(failures, now, lockedUntil, now.Add(time.Minute)); retry != 14*time.Minute {
+		t.Errorf("expected to wait out the lockout, got %s", retry)
+	}
+
+	// old failures are forgotten
+	later := now.Add(time.Hour)
+	if retry := policy.RetryAfter(failures, now, lockedUntil, later); retry != 0 {
+		t.Errorf("expected no delay after the failures expired, got %s", retry)
+	}
+	if failures, _ = policy.Failure(failures, now, later); failures != 1 {
+		t.Errorf("expected the count to start over, got %d", failures)
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	if ip := ClientIP("10.0.0.1:1234", ""); ip != "10.0.0.1" {
+		t.Errorf("unexpected ip %s", ip)
+	}
+	if ip := ClientIP("[::1]:1234", ""); ip != "::1" {
+		t.Errorf("unexpected ip %s", ip)
+	}
+	if ip := ClientIP("10.0.0.1:1234", "203.0.113.7, 10.0.0.2"); ip != "203.0.113.7" {
+		t.Errorf("unexpected forwarded ip %s", ip)
+	}
+}
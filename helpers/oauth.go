@@ -0,0 +1,39 @@
+package helpers
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"regexp"
+)
+
+// PKCEMethodS256 is the only code challenge method accepted, plain challenges
+// would give the verifier away to anyone who sees the authorization request
+const PKCEMethodS256 = "S256"
+
+// pkceVerifierPattern is the length and alphabet of a code verifier (RFC 7636)
+var pkceVerifierPattern = regexp.MustCompile(`^[A-Za-z0-9._~-]{43,128}$`)
+
+// PKCEChallenge returns the S256 code challenge for a code verifier
+func PKCEChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// VerifyPKCE checks a code verifier against the S256 challenge of the authorization request
+func VerifyPKCE(verifier, challenge string) bool {
+	if !pkceVerifierPattern.MatchString(verifier) {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(PKCEChallenge(verifier)), []byte(challenge)) == 1
+}
+
+// GenerateOAuthClientCredentials returns a new client id, and a client secret with the hash
+// to store in its place. Public clients get no secret
+func GenerateOAuthClientCredentials(public bool) (clientID, secret, secretHash string, err error) {
+	if clientID, _, err = GenerateOpaqueToken(16); err != nil || public {
+		return clientID, "", "", err
+	}
+	secret, secretHash, err = GenerateOpaqueToken(32)
+	return clientID, secret, secretHash, err
+}
@@ -0,0 +1,53 @@
+package helpers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVerifyPKCE(t *testing.T) {
+	// the example from RFC 7636 appendix B
+	verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	challenge := "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+	if PKCEChallenge(verifier) != challenge {
+		t.Errorf("unexpected challenge %s", PKCEChallenge(verifier))
+	}
+	if !VerifyPKCE(verifier, challenge) {
+		t.Error("expected the verifier to match")
+	}
+	if VerifyPKCE(verifier+"x", challenge) {
+		t.Error("expected another verifier not to match")
+	}
+	if VerifyPKCE(challenge, challenge) {
+		t.Error("expected the challenge itself not to verify")
+	}
+	short := "abc"
+	if VerifyPKCE(short, PKCEChallenge(short)) {
+		t.Error("expected a short verifier to be rejected")
+	}
+	long := strings.Repeat("a", 129)
+	if VerifyPKCE(long, PKCEChallenge(long)) {
+		t.Error("expected a long verifier to be rejected")
+	}
+}
+
+func TestGenerateOAuthClientCredentials(t *testing.T) {
+	clientID, secret, secretHash, err := GenerateOAuthClientCredentials(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if clientID == "" || secret == "" {
+		t.Error("expected a client id and secret")
+	}
+	if HashOpaqueToken(secret) != secretHash {
+		t.Error("expected the hash of the secret")
+	}
+
+	clientID, secret, secretHash, err = GenerateOAuthClientCredentials(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if clientID == "" || secret != "" || secretHash != "" {
+		t.Error("expected a public client to get no secret")
+	}
+}
@@ -0,0 +1,191 @@
+package helpers
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// password hash schemes
+const (
+	PasswordSchemeBcrypt   = "bcrypt"
+	PasswordSchemePBKDF2   = "pbkdf2"
+	PasswordSchemeArgon2id = "argon2id"
+)
+
+const (
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+)
+
+// Argon2Params are the costs of an argon2id hash
+type Argon2Params struct {
+	Time uint32
+	// Memory in KiB
+	Memory  uint32
+	Threads uint8
+}
+
+// HashPasswordArgon2id returns the argon2id hash of password in the PHC string format,
+// e.g. $argon2id$v=19$m=19456,t=2,p=1$<salt>$<hash>
+func HashPasswordArgon2id(password string, params Argon2Params) (string, error) {
+	salt := make([]byte, argon2SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key, err := argon2IDKey([]byte(password), salt, params, argon2KeyLength)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, params.Memory, params.Time, params.Threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// CheckPasswordArgon2id compares the PHC encoded argon2id hash to the attempted password
+func CheckPasswordArgon2id(userHash, attemptedPassword string) (bool, error) {
+	params, salt, key, err := decodeArgon2idHash(userHash)
+	if err != nil {
+		return false, err
+	}
+	attempted, err := argon2IDKey([]byte(attemptedPassword), salt, params, uint32(len(key)))
+	if err != nil {
+		return false, err
+	}
+	return subtle.ConstantTimeCompare(key, attempted) == 1, nil
+}
+
+// argon2IDKey derives a keyLen byte key from password and salt, refusing the costs
+// argon2.IDKey panics on
+func argon2IDKey(password, salt []byte, params Argon2Params, keyLen uint32) ([]byte, error) {
+	if params.Time < 1 || params.Threads < 1 {
+		return nil, errors.New("argon2id needs at least one pass and one lane")
+	}
+	if keyLen < 4 {
+		return nil, errors.New("argon2id keys are at least 4 bytes")
+	}
+	return argon2.IDKey(password, salt, params.Time, params.Memory, params.Threads, keyLen), nil
+}
+
+// decodeArgon2idHash splits a PHC encoded argon2id hash in its parameters, salt and key
+func decodeArgon2idHash(encoded string) (params Argon2Params, salt, key []byte, err error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != PasswordSchemeArgon2id {
+		return params, nil, nil, errors.New("not an argon2id hash")
+	}
+	var version int
+	if _, err = fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, fmt.Errorf("unsupported argon2id version %q", parts[2])
+	}
+	if _, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Time, &params.Threads); err != nil {
+		return params, nil, nil, fmt.Errorf("invalid argon2id parameters %q", parts[3])
+	}
+	if salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return params, nil, nil, err
+	}
+	if key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return params, nil, nil, err
+	}
+	return params, salt, key, nil
+}
+
+// PasswordHashScheme returns the scheme of a stored hash, empty if it is not one we know
+func PasswordHashScheme(hash string) string {
+	switch {
+	case strings.HasPrefix(hash, "$argon2id$"):
+		return PasswordSchemeArgon2id
+	case strings.HasPrefix(hash, "$2a$"), strings.HasPrefix(hash, "$2b$"), strings.HasPrefix(hash, "$2y$"):
+		return PasswordSchemeBcrypt
+	case strings.HasPrefix(hash, "$firebase-scrypt$"):
+		return PasswordSchemeFirebaseScrypt
+	case strings.HasPrefix(hash, "$sha256$"):
+		return PasswordSchemeSHA256
+	case strings.HasPrefix(hash, "pbkdf2_sha256$"), strings.HasPrefix(hash, "pbkdf2_sha1$"):
+		return PasswordSchemeDjangoPBKDF2
+	case strings.Count(hash, ":") == 1 && !strings.HasPrefix(hash, "$"):
+		// hash:salt of HashPasswordPBKDF2
+		return PasswordSchemePBKDF2
+	}
+	return ""
+}
+
+// PasswordHasher hashes new passwords with the preferred scheme, and checks hashes of every scheme
+type PasswordHasher struct {
+	// Scheme new hashes are made with, bcrypt or argon2id
+	Scheme     string
+	BcryptCost int
+	Argon2     Argon2Params
+	// PBKDF2Iterations the existing pbkdf2 hashes were made with, they don't record it
+	PBKDF2Iterations uint32
+	// AllowDowngrades rehashes with the preferred parameters when they are cheaper than the hash's
+	AllowDowngrades bool
+}
+
+// Hash returns the hash of password with the preferred scheme
+func (h PasswordHasher) Hash(password string) (string, error) {
+	if h.Scheme == PasswordSchemeArgon2id {
+		return HashPasswordArgon2id(password, h.Argon2)
+	}
+	return HashPasswordBcrypt(password, h.BcryptCost)
+}
+
+// Check compares a hash of any scheme to the attempted password
+func (h PasswordHasher) Check(userHash, attemptedPassword string) (bool, error) {
+	switch PasswordHashScheme(userHash) {
+	case PasswordSchemeArgon2id:
+		return CheckPasswordArgon2id(userHash, attemptedPassword)
+	case PasswordSchemeBcrypt:
+		return CheckPasswordBcrypt(userHash, attemptedPassword)
+	case PasswordSchemePBKDF2:
+		return CheckPasswordPBKDF2(userHash, attemptedPassword, h.PBKDF2Iterations)
+	case PasswordSchemeDjangoPBKDF2:
+		return CheckPasswordDjangoPBKDF2(userHash, attemptedPassword)
+	case PasswordSchemeFirebaseScrypt:
+		return CheckPasswordFirebaseScrypt(userHash, attemptedPassword)
+	case PasswordSchemeSHA256:
+		return CheckPasswordSHA256(userHash, attemptedPassword)
+	}
+	return false, errors.New("unknown password hash scheme")
+}
+
+// NeedsRehash returns true if hash is not of the preferred scheme, or is weaker than its parameters
+func (h PasswordHasher) NeedsRehash(hash string) bool {
+	scheme := PasswordHashScheme(hash)
+	if scheme != h.Scheme {
+		return true
+	}
+	switch scheme {
+	case PasswordSchemeBcrypt:
+		cost, err := bcrypt.Cost([]byte(hash))
+		if err != nil {
+			return false
+		}
+		return cost < h.BcryptCost || (cost > h.BcryptCost && h.AllowDowngrades)
+	case PasswordSchemeArgon2id:
+		params, _, _, err := decodeArgon2idHash(hash)
+		if err != nil || params == h.Argon2 {
+			return false
+		}
+		weaker := params.Time < h.Argon2.Time || params.Memory < h.Argon2.Memory || params.Threads < h.Argon2.Threads
+		return weaker || h.AllowDowngrades
+	}
+	return false
+}
+
+// Upgrade returns a new hash of password if hash needs a rehash. Only call it once
+// password has been checked against hash
+func (h PasswordHasher) Upgrade(hash, password string) (upgrade bool, newHash string) {
+	if !h.NeedsRehash(hash) {
+		return false, ""
+	}
+	newHash, err := h.Hash(password)
+	if err != nil {
+		return false, ""
+	}
+	return true, newHash
+}
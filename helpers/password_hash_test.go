@@ -0,0 +1,106 @@
+package helpers
+
+import (
+	"strings"
+	"testing"
+)
+
+var testArgon2Params = Argon2Params{Time: 1, Memory: 64, Threads: 1}
+
+func TestHashPasswordArgon2id(t *testing.T) {
+	hash, err := HashPasswordArgon2id("correct horse", testArgon2Params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=64,t=1,p=1$") {
+		t.Errorf("unexpected encoding %s", hash)
+	}
+	if ok, err := CheckPasswordArgon2id(hash, "correct horse"); err != nil || !ok {
+		t.Errorf("expected the password to match, got %v, %v", ok, err)
+	}
+	if ok, _ := CheckPasswordArgon2id(hash, "wrong horse"); ok {
+		t.Error("expected a wrong password not to match")
+	}
+	if _, err := CheckPasswordArgon2id("$argon2id$v=16$m=64,t=1,p=1$c2FsdA$a2V5", "correct horse"); err == nil {
+		t.Error("expected an unsupported version to fail")
+	}
+	if _, err := CheckPasswordArgon2id("$argon2id$v=19$m=64,t=0,p=1$c2FsdA$a2V5", "correct horse"); err == nil {
+		t.Error("expected a hash without passes to fail")
+	}
+	// hashes stored before, including several lanes and memory that isn't a multiple of them
+	for _, stored := range []string{
+		"$argon2id$v=19$m=64,t=2,p=1$5FuQ6c0QEWyuOlDF2oNuCg$+zpaMtxSUL9rP5bGXtuzrmJnHSQxtcQUx3qbKXKtdq0",
+		"$argon2id$v=19$m=70,t=1,p=3$DeWN5xEQTbs91+SJqOKVbA$4l8Kps1ud2qQEf8OtSLG807awIa/Y4bGucJd082tYXw",
+	} {
+		if ok, err := CheckPasswordArgon2id(stored, "correct horse"); err != nil || !ok {
+			t.Errorf("expected %s to match, got %v, %v", stored, ok, err)
+		}
+	}
+}
+
+func TestPasswordHasherCheck(t *testing.T) {
+	hasher := PasswordHasher{Scheme: PasswordSchemeArgon2id, BcryptCost: 4, Argon2: testArgon2Params, PBKDF2Iterations: 16}
+	bcryptHash, _ := HashPasswordBcrypt("correct horse", 4)
+	pbkdf2Hash, _ := HashPasswordPBKDF2("correct horse", 16, 16)
+	argon2Hash, _ := hasher.Hash("correct horse")
+
+	cases := []struct {
+		hash   string
+		scheme string
+	}{
+		{bcryptHash, PasswordSchemeBcrypt},
+		{pbkdf2Hash, PasswordSchemePBKDF2},
+		{argon2Hash, PasswordSchemeArgon2id},
+	}
+	for _, c := range cases {
+		if scheme := PasswordHashScheme(c.hash); scheme != c.scheme {
+			t.Errorf("expected scheme %s, got %s", c.scheme, scheme)
+		}
+		if ok, err := hasher.Check(c.hash, "correct horse"); err != nil || !ok {
+			t.Errorf("expected %s hash to match, got %v, %v", c.scheme, ok, err)
+		}
+		if ok, _ := hasher.Check(c.hash, "wrong horse"); ok {
+			t.Errorf("expected %s hash not to match a wrong password", c.scheme)
+		}
+	}
+	if _, err := hasher.Check("plaintext", "plaintext"); err == nil {
+		t.Error("expected an unknown scheme to fail")
+	}
+}
+
+func TestPasswordHasherNeedsRehash(t *testing.T) {
+	hasher := PasswordHasher{Scheme: PasswordSchemeArgon2id, BcryptCost: 4, Argon2: testArgon2Params}
+	bcryptHash, _ := HashPasswordBcrypt("correct horse", 4)
+	current, _ := hasher.Hash("correct horse")
+	weaker, _ := HashPasswordArgon2id("correct horse", Argon2Params{Time: 1, Memory: 32, Threads: 1})
+	stronger, _ := HashPasswordArgon2id("correct horse", Argon2Params{Time: 2, Memory: 64, Threads: 1})
+
+	if !hasher.NeedsRehash(bcryptHash) {
+		t.Error("expected a bcrypt hash to be rehashed")
+	}
+	if hasher.NeedsRehash(current) {
+		t.Error("expected a current hash not to be rehashed")
+	}
+	if !hasher.NeedsRehash(weaker) {
+		t.Error("expected a weaker hash to be rehashed")
+	}
+	if hasher.NeedsRehash(stronger) {
+		t.Error("expected a stronger hash not to be rehashed")
+	}
+	hasher.AllowDowngrades = true
+	if !hasher.NeedsRehash(stronger) {
+		t.Error("expected a stronger hash to be rehashed with downgrades")
+	}
+
+	if update, newHash := hasher.Upgrade(bcryptHash, "correct horse"); !update || PasswordHashScheme(newHash) != PasswordSchemeArgon2id {
+		t.Errorf("expected bcrypt to be upgraded to argon2id, got %v %s", update, newHash)
+	}
+
+	bcryptHasher := PasswordHasher{Scheme: PasswordSchemeBcrypt, BcryptCost: 5}
+	if !bcryptHasher.NeedsRehash(bcryptHash) {
+		t.Error("expected a lower bcrypt cost to be rehashed")
+	}
+	if !bcryptHasher.NeedsRehash(current) {
+		t.Error("expected argon2id to be rehashed when bcrypt is preferred")
+	}
+}
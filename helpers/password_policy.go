@@ -0,0 +1,220 @@
+package helpers
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// rules a password can break
+const (
+	PasswordRuleMinLength    = "min_length"
+	PasswordRuleMaxLength    = "max_length"
+	PasswordRuleClasses      = "character_classes"
+	PasswordRulePersonalInfo = "personal_info"
+	PasswordRuleBreached     = "breached"
+	PasswordRuleHistory      = "history"
+)
+
+// character classes a policy can require
+const (
+	PasswordClassUpper  = "upper"
+	PasswordClassLower  = "lower"
+	PasswordClassDigit  = "digit"
+	PasswordClassSymbol = "symbol"
+)
+
+// personal info shorter than this is too likely to be in a password by chance
+const minPersonalInfoLength = 4
+
+// PasswordPolicy is what a new password has to follow
+type PasswordPolicy struct {
+	MinLength int
+	// MaxLength of 0 is no maximum
+	MaxLength       int
+	RequiredClasses []string
+	// RejectPersonalInfo rejects passwords containing the email or username
+	RejectPersonalInfo bool
+	// Breached is checked if not nil
+	Breached BreachedPasswords
+	// HistorySize is how many of the last passwords can't be used again
+	HistorySize int
+}
+
+// PasswordViolation is a rule a password broke
+type PasswordViolation struct {
+	Rule    string
+	Message string
+}
+
+// BreachedPasswords looks up breached passwords by the first 5 hex characters of their SHA1,
+// like the k-anonymity range API of Have I Been Pwned
+type BreachedPasswords interface {
+	// Range returns the upper case SHA1 suffixes of the breached passwords with prefix
+	Range(prefix string) ([]string, error)
+}
+
+// ValidatePasswordClasses returns an error if one of classes is unknown
+func ValidatePasswordClasses(classes []string) error {
+	for _, class := range classes {
+		switch class {
+		case PasswordClassUpper, PasswordClassLower, PasswordClassDigit, PasswordClassSymbol:
+		default:
+			return fmt.Errorf("unknown password character class %q, use upper, lower, digit or symbol", class)
+		}
+	}
+	return nil
+}
+
+// Check returns the rules password breaks, personal is the email and username of the user.
+// The history is checked separately with Reused as it needs the user's hashes
+func (p PasswordPolicy) Check(password string, personal ...string) ([]PasswordViolation, error) {
+	var violations []PasswordViolation
+	if len(password) < p.MinLength {
+		violations = append(violations, PasswordViolation{PasswordRuleMinLength, fmt.Sprintf("Password needs to be at least %d characters long!", p.MinLength)})
+	}
+	if p.MaxLength > 0 && len(password) > p.MaxLength {
+		violations = append(violations, PasswordViolation{PasswordRuleMaxLength, fmt.Sprintf("Password can be at most %d characters long", p.MaxLength)})
+	}
+	if missing := missingPasswordClasses(password, p.RequiredClasses); len(missing) > 0 {
+		violations = append(violations, PasswordViolation{PasswordRuleClasses, "Password needs at least one " + strings.Join(missing, ", ") + " character"})
+	}
+	if p.RejectPersonalInfo && containsPersonalInfo(password, personal) {
+		violations = append(violations, PasswordViolation{PasswordRulePersonalInfo, "Password can't contain your email or username"})
+	}
+	if p.Breached != nil {
+		breached, err := IsBreachedPassword(p.Breached, password)
+		if err != nil {
+			return violations, err
+		}
+		if breached {
+			violations = append(violations, PasswordViolation{PasswordRuleBreached, "Password has appeared in a data breach, choose another one"})
+		}
+	}
+	return violations, nil
+}
+
+// Reused returns the history violation if password matches one of the hashes,
+// which should be the user's current and previous hashes, newest first
+func (p PasswordPolicy) Reused(hasher PasswordHasher, password string, hashes []string) (*PasswordViolation, error) {
+	if p.HistorySize <= 0 {
+		return nil, nil
+	}
+	if len(hashes) > p.HistorySize {
+		hashes = hashes[:p.HistorySize]
+	}
+	for _, hash := range hashes {
+		ok, err := hasher.Check(hash, password)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return &PasswordViolation{PasswordRuleHistory, fmt.Sprintf("Password can't be one of your last %d passwords", p.HistorySize)}, nil
+		}
+	}
+	return nil, nil
+}
+
+// missingPasswordClasses returns the classes password has no character of
+func missingPasswordClasses(password string, classes []string) []string {
+	var has = map[string]bool{}
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			has[PasswordClassUpper] = true
+		case unicode.IsLower(r):
+			has[PasswordClassLower] = true
+		case unicode.IsDigit(r):
+			has[PasswordClassDigit] = true
+		default:
+			has[PasswordClassSymbol] = true
+		}
+	}
+	var missing []string
+	for _, class := range classes {
+		if !has[class] {
+			missing = append(missing, class)
+		}
+	}
+	return missing
+}
+
+// containsPersonalInfo returns true if password contains one of personal, or the name part of an email
+func containsPersonalInfo(password string, personal []string) bool {
+	password = strings.ToLower(password)
+	for _, info := range personal {
+		info = strings.ToLower(info)
+		candidates := []string{info}
+		if at := strings.LastIndex(info, "@"); at > 0 {
+			candidates = append(candidates, info[:at])
+		}
+		for _, candidate := range candidates {
+			if len(candidate) >= minPersonalInfoLength && strings.Contains(password, candidate) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IsBreachedPassword looks password up in breached by the prefix of its SHA1,
+// so only the prefix would leave the service with a remote list
+func IsBreachedPassword(breached BreachedPasswords, password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	suffixes, err := breached.Range(hash[:5])
+	if err != nil {
+		return false, err
+	}
+	i := sort.SearchStrings(suffixes, hash[5:])
+	return i < len(suffixes) && suffixes[i] == hash[5:], nil
+}
+
+// BreachedPasswordFile is a breached password list loaded in memory, indexed by SHA1 prefix
+type BreachedPasswordFile struct {
+	ranges map[string][]string
+}
+
+// LoadBreachedPasswordFile loads a file of SHA1 hashes, one per line and optionally followed by
+// :count, which is the format of the Have I Been Pwned downloads
+func LoadBreachedPasswordFile(path string) (*BreachedPasswordFile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	list := BreachedPasswordFile{ranges: make(map[string][]string)}
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		hash := strings.TrimSpace(scanner.Text())
+		if i := strings.IndexByte(hash, ':'); i >= 0 {
+			hash = hash[:i]
+		}
+		if hash == "" {
+			continue
+		}
+		if _, err := hex.DecodeString(hash); err != nil || len(hash) != 2*sha1.Size {
+			return nil, fmt.Errorf("%s:%d is not a SHA1 hash", path, line)
+		}
+		hash = strings.ToUpper(hash)
+		list.ranges[hash[:5]] = append(list.ranges[hash[:5]], hash[5:])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	for _, suffixes := range list.ranges {
+		sort.Strings(suffixes)
+	}
+	return &list, nil
+}
+
+// Range returns the sorted suffixes of the hashes with prefix
+func (l *BreachedPasswordFile) Range(prefix string) ([]string, error) {
+	return l.ranges[strings.ToUpper(prefix)], nil
+}
@@ -0,0 +1,105 @@
+package helpers
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+// rules returns the rules of violations
+func rules(violations []PasswordViolation) []string {
+	var names []string
+	for _, violation := range violations {
+		names = append(names, violation.Rule)
+	}
+	return names
+}
+
+func TestPasswordPolicyCheck(t *testing.T) {
+	policy := PasswordPolicy{
+		MinLength:          8,
+		MaxLength:          16,
+		RequiredClasses:    []string{PasswordClassUpper, PasswordClassDigit},
+		RejectPersonalInfo: true,
+	}
+	cases := []struct {
+		password string
+		expected []string
+	}{
+		{"Correct1horse", nil},
+		{"Sh0rt", []string{PasswordRuleMinLength}},
+		{"Waaaaaaaaaaaaaay2long", []string{PasswordRuleMaxLength}},
+		{"nouppercase1", []string{PasswordRuleClasses}},
+		{"My1JaneDoe", []string{PasswordRulePersonalInfo}},
+		{"Xjdoe1990x", []string{PasswordRulePersonalInfo}},
+		{"ab", []string{PasswordRuleMinLength, PasswordRuleClasses}},
+	}
+	for _, c := range cases {
+		violations, err := policy.Check(c.password, "jdoe1990@example.com", "janedoe")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := rules(violations); len(got) != len(c.expected) || (len(got) > 0 && got[0] != c.expected[0]) {
+			t.Errorf("expected %q to break %v, got %v", c.password, c.expected, got)
+		}
+	}
+
+	// short personal info is too common to reject
+	if violations, _ := policy.Check("Bobcat1234", "bob@example.com"); len(violations) != 0 {
+		t.Errorf("expected short personal info to be allowed, got %v", rules(violations))
+	}
+}
+
+func TestBreachedPasswordFile(t *testing.T) {
+	file, err := ioutil.TempFile("", "breached")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	// SHA1 of "password" and "123456"
+	file.WriteString("5baa61e4c9b93f3f0682250b6cf8331b7ee68fd8:3730471\n7C4A8D09CA3762AF61E59520943DC26494F8941B:24230577\n")
+	file.Close()
+
+	list, err := LoadBreachedPasswordFile(file.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy := PasswordPolicy{Breached: list}
+	if violations, _ := policy.Check("password"); len(violations) != 1 || violations[0].Rule != PasswordRuleBreached {
+		t.Errorf("expected password to be breached, got %v", rules(violations))
+	}
+	if breached, _ := IsBreachedPassword(list, "123456"); !breached {
+		t.Error("expected 123456 to be breached")
+	}
+	if breached, _ := IsBreachedPassword(list, "correct horse battery staple"); breached {
+		t.Error("expected an unlisted password not to be breached")
+	}
+
+	invalid, _ := ioutil.TempFile("", "breached")
+	defer os.Remove(invalid.Name())
+	invalid.WriteString("not a hash\n")
+	invalid.Close()
+	if _, err := LoadBreachedPasswordFile(invalid.Name()); err == nil {
+		t.Error("expected an invalid file to fail to load")
+	}
+}
+
+func TestPasswordPolicyReused(t *testing.T) {
+	hasher := PasswordHasher{Scheme: PasswordSchemeArgon2id, Argon2: Argon2Params{Time: 1, Memory: 64, Threads: 1}}
+	// history from before the switch to argon2id holds bcrypt hashes
+	old, _ := HashPasswordBcrypt("old password", 4)
+	current, _ := hasher.Hash("current password")
+	policy := PasswordPolicy{HistorySize: 2}
+	if violation, _ := policy.Reused(hasher, "old password", []string{current, old}); violation == nil || violation.Rule != PasswordRuleHistory {
+		t.Error("expected the old password to be reused")
+	}
+	if violation, _ := policy.Reused(hasher, "new password", []string{current, old}); violation != nil {
+		t.Error("expected a new password to be allowed")
+	}
+
+	// only the last HistorySize count
+	policy.HistorySize = 1
+	if violation, _ := policy.Reused(hasher, "old password", []string{current, old}); violation != nil {
+		t.Error("expected passwords older than the history to be allowed")
+	}
+}
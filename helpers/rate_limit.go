@@ -0,0 +1,165 @@
+package helpers
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// what rate limits are counted by
+const (
+	RateLimitKeyIP       = "ip"
+	RateLimitKeyAPIToken = "api_token"
+	RateLimitKeyUser     = "user"
+)
+
+// RateLimit is a token bucket that allows Burst requests at once, and refills at
+// Burst requests per Period. Requests are counted per Key (ip, api_token or user)
+type RateLimit struct {
+	Key    string
+	Burst  int
+	Period time.Duration
+}
+
+// RateLimitResult is the outcome of taking a token from a bucket
+type RateLimitResult struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is how long until the bucket is full again
+	Reset time.Duration
+	// RetryAfter is how long until the next token, zero if the request was allowed
+	RetryAfter time.Duration
+}
+
+// RateLimiter takes tokens from the buckets of a backend
+type RateLimiter interface {
+	// Take takes a token from the bucket of key, which follows limit
+	Take(key string, limit RateLimit) (RateLimitResult, error)
+}
+
+// ParseRateLimit parses a limit written as key:burst/period, e.g. ip:10/1m
+func ParseRateLimit(s string) (RateLimit, error) {
+	var limit RateLimit
+	parts := strings.SplitN(s, ":", 2)
+	if len(parts) != 2 {
+		return limit, fmt.Errorf("rate limit %q is not key:burst/period", s)
+	}
+	limit.Key = parts[0]
+	if limit.Key != RateLimitKeyIP && limit.Key != RateLimitKeyAPIToken && limit.Key != RateLimitKeyUser {
+		return limit, fmt.Errorf("rate limit %q has an unknown key, use ip, api_token or user", s)
+	}
+	parts = strings.SplitN(parts[1], "/", 2)
+	if len(parts) != 2 {
+		return limit, fmt.Errorf("rate limit %q is not key:burst/period", s)
+	}
+	var err error
+	if limit.Burst, err = strconv.Atoi(parts[0]); err != nil || limit.Burst < 0 {
+		return limit, fmt.Errorf("rate limit %q has an invalid burst", s)
+	}
+	if limit.Period, err = time.ParseDuration(parts[1]); err != nil || limit.Period <= 0 {
+		return limit, fmt.Errorf("rate limit %q has an invalid period", s)
+	}
+	return limit, nil
+}
+
+// ParseRateLimits parses named limits written as name=key:burst/period.
+// A burst of 0 turns off the limit of that name
+func ParseRateLimits(entries []string) (map[string]RateLimit, error) {
+	limits := make(map[string]RateLimit, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("rate limit %q is not name=key:burst/period", entry)
+		}
+		limit, err := ParseRateLimit(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		if limit.Burst == 0 {
+			delete(limits, parts[0])
+			continue
+		}
+		limits[parts[0]] = limit
+	}
+	return limits, nil
+}
+
+// Take takes a token from a bucket that had tokens left at updatedAt, returning
+// the tokens left now. A zero updatedAt is a new, full bucket
+func (l RateLimit) Take(tokens float64, updatedAt, now time.Time) (float64, RateLimitResult) {
+	burst := float64(l.Burst)
+	perToken := l.Period / time.Duration(l.Burst)
+	if updatedAt.IsZero() {
+		tokens = burst
+	} else if elapsed := now.Sub(updatedAt); elapsed > 0 {
+		tokens = math.Min(burst, tokens+elapsed.Seconds()/perToken.Seconds())
+	}
+
+	result := RateLimitResult{Limit: l.Burst}
+	if tokens >= 1 {
+		tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration((1 - tokens) * float64(perToken))
+	}
+	result.Remaining = int(tokens)
+	result.Reset = time.Duration((burst - tokens) * float64(perToken))
+	return tokens, result
+}
+
+// memoryBucket is a token bucket of the MemoryRateLimiter
+type memoryBucket struct {
+	tokens    float64
+	updatedAt time.Time
+	period    time.Duration
+}
+
+// MemoryRateLimiter keeps buckets in memory, so each replica has its own limits
+type MemoryRateLimiter struct {
+	sync.Mutex
+	buckets   map[string]*memoryBucket
+	lastPrune time.Time
+}
+
+// NewMemoryRateLimiter returns an empty MemoryRateLimiter
+func NewMemoryRateLimiter() *MemoryRateLimiter {
+	return &MemoryRateLimiter{buckets: make(map[string]*memoryBucket), lastPrune: time.Now()}
+}
+
+// Take takes a token from the bucket of key
+func (m *MemoryRateLimiter) Take(key string, limit RateLimit) (RateLimitResult, error) {
+	m.Lock()
+	defer m.Unlock()
+	now := time.Now()
+	m.prune(now)
+	bucket, ok := m.buckets[key]
+	if !ok {
+		bucket = &memoryBucket{period: limit.Period}
+		m.buckets[key] = bucket
+	}
+	var result RateLimitResult
+	bucket.tokens, result = limit.Take(bucket.tokens, bucket.updatedAt, now)
+	bucket.updatedAt = now
+	return result, nil
+}
+
+// prune drops the buckets that filled up again, which are the same as no bucket
+func (m *MemoryRateLimiter) prune(now time.Time) {
+	if now.Sub(m.lastPrune) < time.Minute {
+		return
+	}
+	m.lastPrune = now
+	for key, bucket := range m.buckets {
+		if now.Sub(bucket.updatedAt) > bucket.period {
+			delete(m.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package helpers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRateLimits(t *testing.T) {
+	limits, err := ParseRateLimits([]string{"signup=ip:10/1h", " exists=api_token:30/1m", "login=user:0/1m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if limit := limits["signup"]; limit.Key != RateLimitKeyIP || limit.Burst != 10 || limit.Period != time.Hour {
+		t.Errorf("unexpected signup limit %+v", limit)
+	}
+	if limit := limits["exists"]; limit.Key != RateLimitKeyAPIToken || limit.Burst != 30 || limit.Period != time.Minute {
+		t.Errorf("unexpected exists limit %+v", limit)
+	}
+	if _, ok := limits["login"]; ok {
+		t.Error("expected a burst of 0 to turn the limit off")
+	}
+
+	for _, invalid := range []string{"signup", "signup=10/1h", "signup=host:10/1h", "signup=ip:ten/1h", "signup=ip:10/never", "signup=ip:10"} {
+		if _, err := ParseRateLimits([]string{invalid}); err == nil {
+			t.Errorf("expected %q to be invalid", invalid)
+		}
+	}
+}
+
+func TestRateLimitTake(t *testing.T) {
+	limit := RateLimit{Key: RateLimitKeyIP, Burst: 3, Period: 3 * time.Second}
+	now := time.Now()
+
+	var tokens float64
+	var updatedAt time.Time
+	var result RateLimitResult
+	for i := 2; i >= 0; i-- {
+		tokens, result = limit.Take(tokens, updatedAt, now)
+		updatedAt = now
+		if !result.Allowed || result.Remaining != i || result.Limit != 3 {
+			t.Fatalf("expected request to be allowed with %d remaining, got %+v", i, result)
+		}
+	}
+	if result.Reset != 3*time.Second {
+		t.Errorf("expected the bucket to be full in 3s, got %s", result.Reset)
+	}
+
+	tokens, result = limit.Take(tokens, updatedAt, now)
+	if result.Allowed || result.RetryAfter != time.Second {
+		t.Errorf("expected to retry after a second, got %+v", result)
+	}
+
+	// a token is back after a second
+	now = now.Add(time.Second)
+	tokens, result = limit.Take(tokens, updatedAt, now)
+	updatedAt = now
+	if !result.Allowed || result.Remaining != 0 {
+		t.Errorf("expected a refilled token, got %+v", result)
+	}
+
+	// and never more than the burst
+	_, result = limit.Take(tokens, updatedAt, now.Add(time.Hour))
+	if !result.Allowed || result.Remaining != 2 {
+		t.Errorf("expected a full bucket, got %+v", result)
+	}
+}
+
+func TestMemoryRateLimiter(t *testing.T) {
+	limiter := NewMemoryRateLimiter()
+	limit := RateLimit{Key: RateLimitKeyIP, Burst: 1, Period: time.Hour}
+	if result, _ := limiter.Take("a", limit); !result.Allowed {
+		t.Error("expected the first request to be allowed")
+	}
+	if result, _ := limiter.Take("a", limit); result.Allowed {
+		t.Error("expected the second request to be limited")
+	}
+	if result, _ := limiter.Take("b", limit); !result.Allowed {
+		t.Error("expected other keys to have their own bucket")
+	}
+}
@@ -0,0 +1,291 @@
+package helpers
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"gopkg.in/dgrijalva/jwt-go.v3"
+)
+
+// SigningKey is a key used to sign and verify json web tokens
+type SigningKey struct {
+	// ID is the kid header of tokens signed with this key
+	ID     string
+	Method jwt.SigningMethod
+	// signKey and verifyKey are the same for HMAC keys
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+// NewHMACSigningKey creates a HS256 signing key from a shared secret
+func NewHMACSigningKey(secret []byte) *SigningKey {
+	key := &SigningKey{Method: jwt.SigningMethodHS256, signKey: secret, verifyKey: secret}
+	key.ID = key.thumbprint()
+	return key
+}
+
+// ParseSigningKeyPEM parses a PEM encoded RSA, EC (P-256, P-384 or P-521) or Ed25519 private key.
+// The signing method is picked from the key type, and the key id is the RFC 7638 thumbprint
+func ParseSigningKeyPEM(data []byte) (*SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+
+	var private interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		private, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		private, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		private, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type: %s", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &SigningKey{signKey: private}
+	switch k := private.(type) {
+	case *rsa.PrivateKey:
+		if k.N.BitLen() < 2048 {
+			return nil, errors.New("RSA keys must be at least 2048 bits")
+		}
+		key.Method = jwt.SigningMethodRS256
+		key.verifyKey = &k.PublicKey
+	case *ecdsa.PrivateKey:
+		switch k.Curve {
+		case elliptic.P256():
+			key.Method = jwt.SigningMethodES256
+		case elliptic.P384():
+			key.Method = jwt.SigningMethodES384
+		case elliptic.P521():
+			key.Method = jwt.SigningMethodES512
+		default:
+			return nil, errors.New("unsupported elliptic curve")
+		}
+		key.verifyKey = &k.PublicKey
+	case ed25519.PrivateKey:
+		key.Method = SigningMethodEdDSA
+		key.verifyKey = k.Public()
+	default:
+		return nil, fmt.Errorf("unsupported private key type: %T", private)
+	}
+	key.ID = key.thumbprint()
+	return key, nil
+}
+
+// Symmetric returns true if the key is a shared secret, which must never be published
+func (k *SigningKey) Symmetric() bool {
+	_, ok := k.Method.(*jwt.SigningMethodHMAC)
+	return ok
+}
+
+// JWK is a public JSON Web Key (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use,omitempty"`
+	Kid string `json:"kid,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// EC and OKP
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWK returns the public half of the key, ok is false for symmetric keys
+func (k *SigningKey) JWK() (jwk JWK, ok bool) {
+	switch pub := k.verifyKey.(type) {
+	case *rsa.PublicKey:
+		jwk = JWK{Kty: "RSA", N: b64(pub.N.Bytes()), E: b64(big.NewInt(int64(pub.E)).Bytes())}
+	case *ecdsa.PublicKey:
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		jwk = JWK{Kty: "EC", Crv: pub.Curve.Params().Name, X: b64(pad(pub.X.Bytes(), size)), Y: b64(pad(pub.Y.Bytes(), size))}
+	case ed25519.PublicKey:
+		jwk = JWK{Kty: "OKP", Crv: "Ed25519", X: b64(pub)}
+	default:
+		return JWK{}, false
+	}
+	jwk.Use = "sig"
+	jwk.Kid = k.ID
+	jwk.Alg = k.Method.Alg()
+	return jwk, true
+}
+
+// PublicKey parses the public key of a RSA, EC or OKP (Ed25519) JWK
+func (jwk JWK) PublicKey() (interface{}, error) {
+	decode := func(value string) ([]byte, error) {
+		return base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+	}
+	switch jwk.Kty {
+	case "RSA":
+		n, err := decode(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decode(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+		if len(n) == 0 || len(e) == 0 || len(e) > 4 {
+			return nil, errors.New("invalid RSA key")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve: %s", jwk.Crv)
+		}
+		x, err := decode(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decode(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+		pub := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(pub.X, pub.Y) {
+			return nil, errors.New("EC key not on curve")
+		}
+		return pub, nil
+	case "OKP":
+		x, err := decode(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		if jwk.Crv != "Ed25519" || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid OKP key")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("unsupported key type: %s", jwk.Kty)
+}
+
+// thumbprint computes the RFC 7638 JWK thumbprint of the key
+func (k *SigningKey) thumbprint() string {
+	// the required members, in lexicographic order
+	var members interface{}
+	if jwk, ok := k.JWK(); ok {
+		switch jwk.Kty {
+		case "RSA":
+			members = struct {
+				E   string `json:"e"`
+				Kty string `json:"kty"`
+				N   string `json:"n"`
+			}{jwk.E, jwk.Kty, jwk.N}
+		case "EC":
+			members = struct {
+				Crv string `json:"crv"`
+				Kty string `json:"kty"`
+				X   string `json:"x"`
+				Y   string `json:"y"`
+			}{jwk.Crv, jwk.Kty, jwk.X, jwk.Y}
+		default:
+			members = struct {
+				Crv string `json:"crv"`
+				Kty string `json:"kty"`
+				X   string `json:"x"`
+			}{jwk.Crv, jwk.Kty, jwk.X}
+		}
+	} else {
+		secret, _ := k.signKey.([]byte)
+		members = struct {
+			K   string `json:"k"`
+			Kty string `json:"kty"`
+		}{b64(secret), "oct"}
+	}
+	// marshalling a struct of strings can't fail
+	data, _ := json.Marshal(members)
+	sum := sha256.Sum256(data)
+	return b64(sum[:])
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// pad left pads b with zeros to size bytes
+func pad(b []byte, size int) []byte {
+	if len(b) >= size {
+		return b
+	}
+	padded := make([]byte, size)
+	copy(padded[size-len(b):], b)
+	return padded
+}
+
+// ErrEdDSAVerification is returned when an EdDSA signature doesn't verify
+var ErrEdDSAVerification = errors.New("ed25519: verification error")
+
+// signingMethodEdDSA implements the EdDSA (Ed25519) signing method of RFC 8037,
+// which jwt-go doesn't support
+type signingMethodEdDSA struct{}
+
+// SigningMethodEdDSA the Ed25519 signing method
+var SigningMethodEdDSA = &signingMethodEdDSA{}
+
+func init() {
+	jwt.RegisterSigningMethod(SigningMethodEdDSA.Alg(), func() jwt.SigningMethod {
+		return SigningMethodEdDSA
+	})
+}
+
+// Alg implements jwt.SigningMethod
+func (m *signingMethodEdDSA) Alg() string {
+	return "EdDSA"
+}
+
+// Verify implements jwt.SigningMethod, key must be an ed25519.PublicKey
+func (m *signingMethodEdDSA) Verify(signingString, signature string, key interface{}) error {
+	pub, ok := key.(ed25519.PublicKey)
+	if !ok || len(pub) != ed25519.PublicKeySize {
+		return jwt.ErrInvalidKeyType
+	}
+	sig, err := jwt.DecodeSegment(signature)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(pub, []byte(signingString), sig) {
+		return ErrEdDSAVerification
+	}
+	return nil
+}
+
+// Sign implements jwt.SigningMethod, key must be an ed25519.PrivateKey
+func (m *signingMethodEdDSA) Sign(signingString string, key interface{}) (string, error) {
+	private, ok := key.(ed25519.PrivateKey)
+	if !ok || len(private) != ed25519.PrivateKeySize {
+		return "", jwt.ErrInvalidKeyType
+	}
+	return jwt.EncodeSegment(ed25519.Sign(private, []byte(signingString))), nil
+}
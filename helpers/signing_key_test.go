@@ -0,0 +1,133 @@
+package helpers
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"gopkg.in/dgrijalva/jwt-go.v3"
+)
+
+func testPEM(t *testing.T, alg string) []byte {
+	var block *pem.Block
+	switch alg {
+	case "RS256":
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatal(err)
+		}
+		block = &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}
+	case "ES256":
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		der, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		block = &pem.Block{Type: "EC PRIVATE KEY", Bytes: der}
+	case "EdDSA":
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		block = &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+	}
+	return pem.EncodeToMemory(block)
+}
+
+func TestSigningKeys(t *testing.T) {
+	secret := NewHMACSigningKey([]byte("secret"))
+
+	for _, alg := range []string{"RS256", "ES256", "EdDSA"} {
+		key, err := ParseSigningKeyPEM(testPEM(t, alg))
+		if err != nil {
+			t.Fatalf("%s: %s", alg, err)
+		}
+		if key.Method.Alg() != alg {
+			t.Errorf("expected %s, got %s", alg, key.Method.Alg())
+		}
+		keys := NewKeyring(key, secret)
+
+		signer := JWTHelper{HashSecretBytes: []byte("secret"), Keys: keys}
+		if err := signer.Generate(map[string]interface{}{"userid": "abc"}, time.Hour); err != nil {
+			t.Fatalf("%s: %s", alg, err)
+		}
+
+		parsed, _ := jwt.Parse(signer.Token, func(*jwt.Token) (interface{}, error) { return nil, nil })
+		if parsed.Header["kid"] != key.ID || parsed.Header["alg"] != alg {
+			t.Errorf("%s: unexpected header %v", alg, parsed.Header)
+		}
+
+		verifier := JWTHelper{HashSecretBytes: []byte("secret"), Keys: keys, Token: signer.Token}
+		if result := verifier.Validate("userid"); result.Status != JWTokenStatusValid || result.Value != "abc" {
+			t.Errorf("%s: expected a valid token, got %s", alg, result.Message)
+		}
+
+		jwks := keys.JWKS()
+		if len(jwks.Keys) != 1 || jwks.Keys[0].Kid != key.ID || jwks.Keys[0].Alg != alg {
+			t.Errorf("%s: expected only the public key in the key set, got %v", alg, jwks.Keys)
+		}
+	}
+}
+
+func TestValidateRejectsUnknownKeys(t *testing.T) {
+	key, err := ParseSigningKeyPEM(testPEM(t, "ES256"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := ParseSigningKeyPEM(testPEM(t, "ES256"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signer := JWTHelper{Keys: NewKeyring(other)}
+	if err := signer.Generate(map[string]interface{}{"userid": "abc"}, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	verifier := JWTHelper{HashSecretBytes: []byte("secret"), Keys: NewKeyring(key), Token: signer.Token}
+	if result := verifier.Validate("userid"); result.Status != JWTokenStatusInvalid {
+		t.Error("a token signed with an unknown key should be invalid")
+	}
+}
+
+func TestValidateLegacyTokens(t *testing.T) {
+	key, err := ParseSigningKeyPEM(testPEM(t, "ES256"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := NewKeyring(key, NewHMACSigningKey([]byte("secret")))
+
+	// issued before signing keys were configured
+	legacy := JWTHelper{HashSecretBytes: []byte("secret")}
+	if err := legacy.Generate(map[string]interface{}{"userid": "abc"}, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	verifier := JWTHelper{HashSecretBytes: []byte("secret"), Keys: keys, Token: legacy.Token}
+	if result := verifier.Validate("userid"); result.Status != JWTokenStatusValid {
+		t.Errorf("expected a valid token, got %s", result.Message)
+	}
+
+	// a kid-less token must not be able to pick its own algorithm
+	token := jwt.New(jwt.SigningMethodHS512)
+	token.Claims = jwt.MapClaims{"userid": "abc"}
+	signed, err := token.SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier.Token = signed
+	if result := verifier.Validate("userid"); result.Status != JWTokenStatusInvalid {
+		t.Error("expected an invalid token")
+	}
+}
@@ -33,11 +33,10 @@ func (fbu *FacebookAPIUser) ProfilePictureURL() string {
 	return fbu.Picture.Data["url"].(string)
 }
 
-// ValidateFacebookLogin takes the id and token strings and sends them to the FACEBOOK_TOKEN_URL.
+// ValidateFacebookLogin takes the id and token strings and sends them to the FACEBOOK_TOKEN_URL with the client.
 // If the inputs are valid, returns true, else it returns false and an error
-func ValidateFacebookLogin(id, token, appID, appSecret string) (bool, error) {
+func ValidateFacebookLogin(client *http.Client, id, token, appID, appSecret string) (bool, error) {
 
-	client := http.Client{}
 	urlValues := url.Values{}
 	urlValues.Set("input_token", token)
 	urlValues.Set("access_token", appID+"|"+appSecret)
@@ -45,7 +44,7 @@ func ValidateFacebookLogin(id, token, appID, appSecret string) (bool, error) {
 	req.Close = true
 	// Accept type?
 	req.Header.Set("Content-Type", "application/json")
-	resp, err := client.Do(req)
+	resp, err := httpClient(client).Do(req)
 	defer func(resp *http.Response) {
 		if resp != nil && resp.Body != nil {
 			resp.Body.Close()
@@ -83,11 +82,10 @@ func ValidateFacebookLogin(id, token, appID, appSecret string) (bool, error) {
 	return true, nil
 }
 
-// GetFacebookUserInfo takes the id and token strings and sends them to the facebookUserURL.
+// GetFacebookUserInfo takes the id and token strings and sends them to the facebookUserURL with the client.
 // Returns a FacebookAPIUser struct
-func GetFacebookUserInfo(id, token, appID, appSecret string) (*FacebookAPIUser, error) {
+func GetFacebookUserInfo(client *http.Client, id, token, appID, appSecret string) (*FacebookAPIUser, error) {
 
-	client := http.Client{}
 	urlValues := url.Values{}
 	urlValues.Set("input_token", token)
 	urlValues.Set("access_token", appID+"|"+appSecret)
@@ -96,7 +94,7 @@ func GetFacebookUserInfo(id, token, appID, appSecret string) (*FacebookAPIUser,
 	req.Close = true
 	// Accept type?
 	req.Header.Set("Content-Type", "application/json")
-	resp, err := client.Do(req)
+	resp, err := httpClient(client).Do(req)
 	defer func(resp *http.Response) {
 		if resp != nil && resp.Body != nil {
 			resp.Body.Close()
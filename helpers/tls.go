@@ -0,0 +1,170 @@
+package helpers
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CertificateReloader serves a TLS certificate, and the CAs client certificates are verified
+// with, from files it loads again when they change
+type CertificateReloader struct {
+	certFile     string
+	keyFile      string
+	clientCAFile string
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	modTimes  []time.Time
+}
+
+// NewCertificateReloader loads the certificate and key, and the client CAs unless clientCAFile
+// is empty
+func NewCertificateReloader(certFile, keyFile, clientCAFile string) (*CertificateReloader, error) {
+	r := &CertificateReloader{certFile: certFile, keyFile: keyFile, clientCAFile: clientCAFile}
+	if _, err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// files returns the files the reloader loads
+func (r *CertificateReloader) files() []string {
+	files := []string{r.certFile, r.keyFile}
+	if r.clientCAFile != "" {
+		files = append(files, r.clientCAFile)
+	}
+	return files
+}
+
+// Reload loads the files again if one of them changed since they were loaded, and tells whether
+// it did. The loaded certificate is kept when the files can't be loaded, e.g. half written
+func (r *CertificateReloader) Reload() (bool, error) {
+	files := r.files()
+	modTimes := make([]time.Time, len(files))
+	for i, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return false, err
+		}
+		modTimes[i] = info.ModTime()
+	}
+	r.mu.RLock()
+	changed := r.cert == nil
+	for i := range r.modTimes {
+		changed = changed || !r.modTimes[i].Equal(modTimes[i])
+	}
+	r.mu.RUnlock()
+	if !changed {
+		return false, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return false, err
+	}
+	var clientCAs *x509.CertPool
+	if r.clientCAFile != "" {
+		pem, err := ioutil.ReadFile(r.clientCAFile)
+		if err != nil {
+			return false, err
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(pem) {
+			return false, fmt.Errorf("no certificates in %s", r.clientCAFile)
+		}
+	}
+
+	r.mu.Lock()
+	r.cert, r.clientCAs, r.modTimes = &cert, clientCAs, modTimes
+	r.mu.Unlock()
+	return true, nil
+}
+
+// Watch calls Reload every interval until the returned stop function is called. onReload is
+// called after the files are loaded again, with the error if they couldn't be
+func (r *CertificateReloader) Watch(interval time.Duration, onReload func(error)) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if reloaded, err := r.Reload(); reloaded || err != nil {
+					onReload(err)
+				}
+			}
+		}
+	}()
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
+}
+
+// TLSConfig returns the server TLS config, which takes the certificate and client CAs loaded
+// last for each handshake. Clients need a certificate signed by the client CAs if there are any
+func (r *CertificateReloader) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			r.mu.RLock()
+			defer r.mu.RUnlock()
+			config := &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{*r.cert},
+				NextProtos:   []string{"h2"},
+			}
+			if r.clientCAs != nil {
+				config.ClientCAs = r.clientCAs
+				config.ClientAuth = tls.RequireAndVerifyClientCert
+			}
+			return config, nil
+		},
+	}
+}
+
+// CertificateIdentities returns the names a certificate identifies a service by: its subject
+// common name, DNS names and URIs
+func CertificateIdentities(cert *x509.Certificate) []string {
+	var identities []string
+	if cert.Subject.CommonName != "" {
+		identities = append(identities, cert.Subject.CommonName)
+	}
+	identities = append(identities, cert.DNSNames...)
+	for _, uri := range cert.URIs {
+		identities = append(identities, uri.String())
+	}
+	return identities
+}
+
+// ParseMethodClients parses the clients allowed to call methods, written as
+// method=identity|identity
+func ParseMethodClients(entries []string) (map[string][]string, error) {
+	clients := make(map[string][]string, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("method clients %q are not method=identity|identity", entry)
+		}
+		for _, identity := range strings.Split(parts[1], "|") {
+			if identity = strings.TrimSpace(identity); identity == "" {
+				return nil, errors.New("method clients can't have an empty identity")
+			}
+			clients[parts[0]] = append(clients[parts[0]], identity)
+		}
+	}
+	return clients, nil
+}
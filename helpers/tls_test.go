@@ -0,0 +1,179 @@
+package helpers
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// testCertificate is a certificate signed by parent, or self signed without one
+func testCertificate(t *testing.T, serial int64, name string, parent *tls.Certificate) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{"localhost"},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	signer, signerKey := template, interface{}(key)
+	if parent == nil {
+		template.IsCA, template.BasicConstraintsValid = true, true
+		template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature
+	} else {
+		if signer, err = x509.ParseCertificate(parent.Certificate[0]); err != nil {
+			t.Fatal(err)
+		}
+		signerKey = parent.PrivateKey
+		uri, _ := url.Parse("spiffe://example.com/" + name)
+		template.URIs = []*url.URL{uri}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// writeTestCertificate writes the certificate and key of cert as PEM files, modified at modTime
+func writeTestCertificate(t *testing.T, cert tls.Certificate, certFile, keyFile string, modTime time.Time) {
+	keyDER, err := x509.MarshalECPrivateKey(cert.PrivateKey.(*ecdsa.PrivateKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]*pem.Block{
+		certFile: {Type: "CERTIFICATE", Bytes: cert.Certificate[0]},
+		keyFile:  {Type: "EC PRIVATE KEY", Bytes: keyDER},
+	}
+	for file, block := range files {
+		if err := ioutil.WriteFile(file, pem.EncodeToMemory(block), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(file, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// testHandshake connects a client with the certificates to a server with the config, and returns
+// the server's connection state and the serial of its certificate
+func testHandshake(t *testing.T, config *tls.Config, certificates []tls.Certificate) (tls.ConnectionState, int64, error) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+	server := tls.Server(serverConn, config)
+	client := tls.Client(clientConn, &tls.Config{InsecureSkipVerify: true, Certificates: certificates})
+	clientErr := make(chan error, 1)
+	go func() {
+		err := client.Handshake()
+		if err == nil {
+			// the server only rejects the client certificate after the client's handshake ends
+			_, err = client.Read(make([]byte, 1))
+		}
+		clientErr <- err
+	}()
+	if err := server.Handshake(); err != nil {
+		return tls.ConnectionState{}, 0, err
+	}
+	server.Write([]byte{0})
+	<-clientErr
+	return server.ConnectionState(), client.ConnectionState().PeerCertificates[0].SerialNumber.Int64(), nil
+}
+
+func TestCertificateReloader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	certFile, keyFile, caFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"), filepath.Join(dir, "ca.pem")
+
+	ca := testCertificate(t, 1, "ca", nil)
+	writeTestCertificate(t, ca, caFile, filepath.Join(dir, "ca.key"), time.Now())
+	writeTestCertificate(t, testCertificate(t, 2, "zenauth", &ca), certFile, keyFile, time.Now().Add(-time.Minute))
+	reloader, err := NewCertificateReloader(certFile, keyFile, caFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := reloader.TLSConfig()
+
+	if _, _, err := testHandshake(t, config, nil); err == nil {
+		t.Error("expected clients without a certificate to be refused")
+	}
+	stranger := testCertificate(t, 3, "stranger", nil)
+	if _, _, err := testHandshake(t, config, []tls.Certificate{stranger}); err == nil {
+		t.Error("expected clients with a certificate of another CA to be refused")
+	}
+	state, serial, err := testHandshake(t, config, []tls.Certificate{testCertificate(t, 4, "billing", &ca)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if serial != 2 {
+		t.Errorf("expected the server certificate 2, got %d", serial)
+	}
+	identities := CertificateIdentities(state.VerifiedChains[0][0])
+	if expected := []string{"billing", "localhost", "spiffe://example.com/billing"}; !reflect.DeepEqual(identities, expected) {
+		t.Errorf("expected the identities %v, got %v", expected, identities)
+	}
+
+	if reloaded, err := reloader.Reload(); err != nil || reloaded {
+		t.Errorf("expected unchanged files not to be reloaded, got %v, %v", reloaded, err)
+	}
+	writeTestCertificate(t, testCertificate(t, 5, "zenauth", &ca), certFile, keyFile, time.Now())
+	if reloaded, err := reloader.Reload(); err != nil || !reloaded {
+		t.Fatalf("expected changed files to be reloaded, got %v, %v", reloaded, err)
+	}
+	if _, serial, err = testHandshake(t, config, []tls.Certificate{testCertificate(t, 6, "billing", &ca)}); err != nil {
+		t.Fatal(err)
+	}
+	if serial != 5 {
+		t.Errorf("expected the reloaded server certificate 5, got %d", serial)
+	}
+
+	// a broken file keeps the loaded certificate
+	if err := ioutil.WriteFile(keyFile, []byte("not a key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reloader.Reload(); err == nil {
+		t.Error("expected a broken key to fail to load")
+	}
+	if _, serial, err = testHandshake(t, config, []tls.Certificate{testCertificate(t, 7, "billing", &ca)}); err != nil || serial != 5 {
+		t.Errorf("expected the loaded server certificate 5 to be kept, got %d, %v", serial, err)
+	}
+}
+
+func TestParseMethodClients(t *testing.T) {
+	clients, err := ParseMethodClients([]string{"ListRoles=billing|spiffe://example.com/admin", " GetUserByID=search "})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string][]string{
+		"ListRoles":   {"billing", "spiffe://example.com/admin"},
+		"GetUserByID": {"search"},
+	}
+	if !reflect.DeepEqual(clients, expected) {
+		t.Errorf("expected %v, got %v", expected, clients)
+	}
+	for _, entry := range []string{"ListRoles", "=billing", "ListRoles=billing||search"} {
+		if _, err := ParseMethodClients([]string{entry}); err == nil {
+			t.Errorf("expected %q not to parse", entry)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package helpers
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// RFC 6238 appendix B test vectors (SHA1), truncated to 6 digits
+func TestTOTPCode(t *testing.T) {
+	secret := totpEncoding.EncodeToString([]byte("12345678901234567890"))
+	vectors := map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	}
+	for unix, expected := range vectors {
+		code, err := TOTPCode(secret, time.Unix(unix, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code != expected {
+			t.Errorf("at %d expected %s, got %s", unix, expected, code)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	code, err := TOTPCode(secret, now.Add(-30*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	step, ok := ValidateTOTP(secret, code, now, 1)
+	if !ok {
+		t.Fatal("expected the previous code to be accepted")
+	}
+	if step != totpStep(now)-1 {
+		t.Errorf("expected the previous step, got %d", step)
+	}
+	if _, ok := ValidateTOTP(secret, code, now, 0); ok {
+		t.Error("expected the previous code to be rejected without skew")
+	}
+	if _, ok := ValidateTOTP(secret, "12345", now, 1); ok {
+		t.Error("expected a short code to be rejected")
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri := TOTPURI("ZenAuth", "user@email.com", "JBSWY3DPEHPK3PXP")
+	if !strings.HasPrefix(uri, "otpauth://totp/ZenAuth:user@email.com?") || !strings.Contains(uri, "secret=JBSWY3DPEHPK3PXP") {
+		t.Errorf("unexpected uri %s", uri)
+	}
+}
+
+func TestIsTOTPCode(t *testing.T) {
+	for code, expected := range map[string]bool{
+		"123456":      true,
+		"12345":       false,
+		"12345a":      false,
+		"abcde-fghij": false,
+	} {
+		if IsTOTPCode(code) != expected {
+			t.Errorf("IsTOTPCode(%q) should be %v", code, expected)
+		}
+	}
+}
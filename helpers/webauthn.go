@@ -0,0 +1,304 @@
+package helpers
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// WebAuthn (https://www.w3.org/TR/webauthn/) registration and assertion verification.
+// Attestation statements are not checked against any trust anchors, as with the "none" conveyance
+
+const (
+	webAuthnFlagUserPresent       = 0x01
+	webAuthnFlagUserVerified      = 0x04
+	webAuthnFlagAttestedData      = 0x40
+	webAuthnChallengeLength       = 32
+	webAuthnAuthenticatorDataSize = 37
+
+	// COSE algorithm identifiers
+	coseAlgES256 = -7
+	coseAlgEdDSA = -8
+	coseAlgRS256 = -257
+)
+
+// WebAuthnAlgorithms are the COSE algorithms public keys may use, in order of preference
+var WebAuthnAlgorithms = []int{coseAlgES256, coseAlgEdDSA, coseAlgRS256}
+
+// WebAuthnRelyingParty verifies ceremonies for a relying party
+type WebAuthnRelyingParty struct {
+	// ID is the domain credentials are scoped to
+	ID string
+	// Origins are the web origins the ceremonies may come from
+	Origins []string
+	// RequireUserVerification rejects authenticators that didn't verify the user (PIN, biometrics)
+	RequireUserVerification bool
+}
+
+// WebAuthnCredential is a credential created by a registration ceremony
+type WebAuthnCredential struct {
+	ID []byte
+	// PublicKey is the COSE encoded public key
+	PublicKey []byte
+	SignCount uint32
+}
+
+type webAuthnClientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
+
+type webAuthnAuthenticatorData struct {
+	rpIDHash     []byte
+	flags        byte
+	signCount    uint32
+	credentialID []byte
+	publicKey    []byte
+}
+
+// NewWebAuthnChallenge generates a random base64url encoded challenge
+func NewWebAuthnChallenge() (string, error) {
+	b := make([]byte, webAuthnChallengeLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// VerifyRegistration verifies the response to a registration ceremony for the challenge
+func (rp *WebAuthnRelyingParty) VerifyRegistration(challenge string, clientDataJSON, attestationObject []byte) (*WebAuthnCredential, error) {
+	if err := rp.verifyClientData(clientDataJSON, "webauthn.create", challenge); err != nil {
+		return nil, err
+	}
+
+	decoded, _, err := DecodeCBOR(attestationObject)
+	if err != nil {
+		return nil, err
+	}
+	attestation, ok := decoded.(map[interface{}]interface{})
+	if !ok {
+		return nil, errors.New("webauthn: invalid attestation object")
+	}
+	format, _ := attestation["fmt"].(string)
+	rawAuthData, _ := attestation["authData"].([]byte)
+	statement, _ := attestation["attStmt"].(map[interface{}]interface{})
+
+	authData, err := rp.verifyAuthenticatorData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+	if authData.credentialID == nil {
+		return nil, errors.New("webauthn: no attested credential data")
+	}
+	publicKey, alg, err := parseCOSEKey(authData.publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signed := append(append([]byte{}, rawAuthData...), clientDataHash[:]...)
+	switch format {
+	case "none":
+	case "packed":
+		sig, _ := statement["sig"].([]byte)
+		statementAlg, _ := statement["alg"].(int64)
+		if x5c, ok := statement["x5c"].([]interface{}); ok && len(x5c) > 0 {
+			// full attestation, the certificate isn't chained to any root so only the signature is checked
+			der, _ := x5c[0].([]byte)
+			cert, err := x509.ParseCertificate(der)
+			if err != nil {
+				return nil, err
+			}
+			if err := verifyCOSESignature(cert.PublicKey, int(statementAlg), signed, sig); err != nil {
+				return nil, err
+			}
+		} else {
+			// self attestation
+			if int(statementAlg) != alg {
+				return nil, errors.New("webauthn: attestation algorithm mismatch")
+			}
+			if err := verifyCOSESignature(publicKey, alg, signed, sig); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		// other formats only matter when attestation is trusted
+	}
+
+	return &WebAuthnCredential{
+		ID:        authData.credentialID,
+		PublicKey: authData.publicKey,
+		SignCount: authData.signCount,
+	}, nil
+}
+
+// VerifyAssertion verifies the response to an authentication ceremony for the challenge,
+// signed by the credential with the COSE encoded public key and last seen signCount.
+// Returns the new sign count of the credential
+func (rp *WebAuthnRelyingParty) VerifyAssertion(challenge string, publicKey []byte, signCount uint32, clientDataJSON, rawAuthData, signature []byte) (uint32, error) {
+	if err := rp.verifyClientData(clientDataJSON, "webauthn.get", challenge); err != nil {
+		return 0, err
+	}
+	authData, err := rp.verifyAuthenticatorData(rawAuthData)
+	if err != nil {
+		return 0, err
+	}
+	key, alg, err := parseCOSEKey(publicKey)
+	if err != nil {
+		return 0, err
+	}
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signed := append(append([]byte{}, rawAuthData...), clientDataHash[:]...)
+	if err := verifyCOSESignature(key, alg, signed, signature); err != nil {
+		return 0, err
+	}
+	// authenticators without a counter always send 0, otherwise it must increase
+	if (authData.signCount != 0 || signCount != 0) && authData.signCount <= signCount {
+		return 0, errors.New("webauthn: sign count did not increase, the authenticator may be cloned")
+	}
+	return authData.signCount, nil
+}
+
+func (rp *WebAuthnRelyingParty) verifyClientData(clientDataJSON []byte, ceremony, challenge string) error {
+	var clientData webAuthnClientData
+	if err := json.Unmarshal(clientDataJSON, &clientData); err != nil {
+		return err
+	}
+	if clientData.Type != ceremony {
+		return fmt.Errorf("webauthn: unexpected client data type %q", clientData.Type)
+	}
+	if challenge == "" || clientData.Challenge != challenge {
+		return errors.New("webauthn: challenge mismatch")
+	}
+	for _, origin := range rp.Origins {
+		if clientData.Origin == origin {
+			return nil
+		}
+	}
+	return fmt.Errorf("webauthn: unexpected origin %q", clientData.Origin)
+}
+
+func (rp *WebAuthnRelyingParty) verifyAuthenticatorData(data []byte) (*webAuthnAuthenticatorData, error) {
+	authData, err := parseAuthenticatorData(data)
+	if err != nil {
+		return nil, err
+	}
+	rpIDHash := sha256.Sum256([]byte(rp.ID))
+	if !bytes.Equal(authData.rpIDHash, rpIDHash[:]) {
+		return nil, errors.New("webauthn: relying party id mismatch")
+	}
+	if authData.flags&webAuthnFlagUserPresent == 0 {
+		return nil, errors.New("webauthn: user not present")
+	}
+	if rp.RequireUserVerification && authData.flags&webAuthnFlagUserVerified == 0 {
+		return nil, errors.New("webauthn: user not verified")
+	}
+	return authData, nil
+}
+
+func parseAuthenticatorData(data []byte) (*webAuthnAuthenticatorData, error) {
+	if len(data) < webAuthnAuthenticatorDataSize {
+		return nil, errors.New("webauthn: authenticator data too short")
+	}
+	authData := &webAuthnAuthenticatorData{
+		rpIDHash:  data[:32],
+		flags:     data[32],
+		signCount: binary.BigEndian.Uint32(data[33:37]),
+	}
+	if authData.flags&webAuthnFlagAttestedData == 0 {
+		return authData, nil
+	}
+	// aaguid (16 bytes), credential id length (2 bytes), credential id, public key
+	rest := data[webAuthnAuthenticatorDataSize:]
+	if len(rest) < 18 {
+		return nil, errors.New("webauthn: attested credential data too short")
+	}
+	idLength := int(binary.BigEndian.Uint16(rest[16:18]))
+	rest = rest[18:]
+	if idLength == 0 || len(rest) < idLength {
+		return nil, errors.New("webauthn: invalid credential id")
+	}
+	authData.credentialID = rest[:idLength]
+	rest = rest[idLength:]
+	// the public key is followed by any extensions
+	_, extensions, err := DecodeCBOR(rest)
+	if err != nil {
+		return nil, err
+	}
+	authData.publicKey = rest[:len(rest)-len(extensions)]
+	return authData, nil
+}
+
+// parseCOSEKey parses a COSE_Key (RFC 8152), returning the public key and its algorithm
+func parseCOSEKey(data []byte) (crypto.PublicKey, int, error) {
+	decoded, _, err := DecodeCBOR(data)
+	if err != nil {
+		return nil, 0, err
+	}
+	key, ok := decoded.(map[interface{}]interface{})
+	if !ok {
+		return nil, 0, errors.New("webauthn: invalid public key")
+	}
+	kty, _ := key[int64(1)].(int64)
+	alg, _ := key[int64(3)].(int64)
+	crv, _ := key[int64(-1)].(int64)
+	x, _ := key[int64(-2)].([]byte)
+	y, _ := key[int64(-3)].([]byte)
+
+	switch {
+	case kty == 2 && alg == coseAlgES256 && crv == 1:
+		if len(x) != 32 || len(y) != 32 {
+			return nil, 0, errors.New("webauthn: invalid EC2 public key")
+		}
+		pub := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !pub.Curve.IsOnCurve(pub.X, pub.Y) {
+			return nil, 0, errors.New("webauthn: EC2 public key not on curve")
+		}
+		return pub, coseAlgES256, nil
+	case kty == 1 && alg == coseAlgEdDSA && crv == 6:
+		if len(x) != ed25519.PublicKeySize {
+			return nil, 0, errors.New("webauthn: invalid OKP public key")
+		}
+		return ed25519.PublicKey(x), coseAlgEdDSA, nil
+	case kty == 3 && alg == coseAlgRS256:
+		// for RSA keys -1 is the modulus and -2 the exponent
+		n, _ := key[int64(-1)].([]byte)
+		if len(n) < 256 || len(x) == 0 || len(x) > 4 {
+			return nil, 0, errors.New("webauthn: invalid RSA public key")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(x).Int64())}, coseAlgRS256, nil
+	}
+	return nil, 0, fmt.Errorf("webauthn: unsupported public key (kty %d, alg %d)", kty, alg)
+}
+
+func verifyCOSESignature(key crypto.PublicKey, alg int, signed, sig []byte) error {
+	digest := sha256.Sum256(signed)
+	switch pub := key.(type) {
+	case *ecdsa.PublicKey:
+		if alg == coseAlgES256 && ecdsa.VerifyASN1(pub, digest[:], sig) {
+			return nil
+		}
+	case ed25519.PublicKey:
+		if alg == coseAlgEdDSA && ed25519.Verify(pub, signed, sig) {
+			return nil
+		}
+	case *rsa.PublicKey:
+		if alg == coseAlgRS256 && rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], sig) == nil {
+			return nil
+		}
+	}
+	return errors.New("webauthn: invalid signature")
+}
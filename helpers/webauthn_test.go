@@ -0,0 +1,157 @@
+package helpers
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+)
+
+// softAuthenticator is a software ES256 authenticator
+type softAuthenticator struct {
+	t         *testing.T
+	rpID      string
+	origin    string
+	id        []byte
+	key       *ecdsa.PrivateKey
+	signCount uint32
+}
+
+func newSoftAuthenticator(t *testing.T, rpID, origin string) *softAuthenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := make([]byte, 16)
+	rand.Read(id)
+	return &softAuthenticator{t: t, rpID: rpID, origin: origin, id: id, key: key}
+}
+
+func (a *softAuthenticator) clientData(ceremony, challenge string) []byte {
+	data, _ := json.Marshal(map[string]string{"type": ceremony, "challenge": challenge, "origin": a.origin})
+	return data
+}
+
+func (a *softAuthenticator) authData(flags byte, attested bool) []byte {
+	rpIDHash := sha256.Sum256([]byte(a.rpID))
+	data := append(rpIDHash[:], flags, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(data[33:], a.signCount)
+	if attested {
+		data = append(data, make([]byte, 16)...)
+		data = append(data, byte(len(a.id)>>8), byte(len(a.id)))
+		data = append(data, a.id...)
+		key, err := EncodeCBOR(map[interface{}]interface{}{
+			1: 2, 3: -7, -1: 1,
+			-2: pad(a.key.X.Bytes(), 32),
+			-3: pad(a.key.Y.Bytes(), 32),
+		})
+		if err != nil {
+			a.t.Fatal(err)
+		}
+		data = append(data, key...)
+	}
+	return data
+}
+
+func (a *softAuthenticator) sign(authData, clientData []byte) []byte {
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	sig, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		a.t.Fatal(err)
+	}
+	return sig
+}
+
+func (a *softAuthenticator) register(challenge, format string) (clientData, attestationObject []byte) {
+	clientData = a.clientData("webauthn.create", challenge)
+	authData := a.authData(webAuthnFlagUserPresent|webAuthnFlagUserVerified|webAuthnFlagAttestedData, true)
+	statement := map[interface{}]interface{}{}
+	if format == "packed" {
+		statement["alg"] = -7
+		statement["sig"] = a.sign(authData, clientData)
+	}
+	attestationObject, err := EncodeCBOR(map[interface{}]interface{}{"fmt": format, "attStmt": statement, "authData": authData})
+	if err != nil {
+		a.t.Fatal(err)
+	}
+	return clientData, attestationObject
+}
+
+func (a *softAuthenticator) assert(challenge string, flags byte) (clientData, authData, signature []byte) {
+	a.signCount++
+	clientData = a.clientData("webauthn.get", challenge)
+	authData = a.authData(flags, false)
+	return clientData, authData, a.sign(authData, clientData)
+}
+
+func TestWebAuthnRegistration(t *testing.T) {
+	rp := WebAuthnRelyingParty{ID: "example.com", Origins: []string{"https://example.com"}}
+	authenticator := newSoftAuthenticator(t, "example.com", "https://example.com")
+	challenge, err := NewWebAuthnChallenge()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, format := range []string{"none", "packed"} {
+		clientData, attestationObject := authenticator.register(challenge, format)
+		credential, err := rp.VerifyRegistration(challenge, clientData, attestationObject)
+		if err != nil {
+			t.Fatalf("%s: %s", format, err)
+		}
+		if string(credential.ID) != string(authenticator.id) {
+			t.Errorf("%s: expected the credential id back", format)
+		}
+	}
+
+	clientData, attestationObject := authenticator.register(challenge, "none")
+	if _, err := rp.VerifyRegistration("other", clientData, attestationObject); err == nil {
+		t.Error("expected a challenge mismatch to fail")
+	}
+	other := WebAuthnRelyingParty{ID: "example.com", Origins: []string{"https://other.com"}}
+	if _, err := other.VerifyRegistration(challenge, clientData, attestationObject); err == nil {
+		t.Error("expected an unexpected origin to fail")
+	}
+	other = WebAuthnRelyingParty{ID: "other.com", Origins: []string{"https://example.com"}}
+	if _, err := other.VerifyRegistration(challenge, clientData, attestationObject); err == nil {
+		t.Error("expected a relying party id mismatch to fail")
+	}
+}
+
+func TestWebAuthnAssertion(t *testing.T) {
+	rp := WebAuthnRelyingParty{ID: "example.com", Origins: []string{"https://example.com"}, RequireUserVerification: true}
+	authenticator := newSoftAuthenticator(t, "example.com", "https://example.com")
+	challenge, _ := NewWebAuthnChallenge()
+	clientData, attestationObject := authenticator.register(challenge, "none")
+	credential, err := rp.VerifyRegistration(challenge, clientData, attestationObject)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clientData, authData, signature := authenticator.assert(challenge, webAuthnFlagUserPresent|webAuthnFlagUserVerified)
+	signCount, err := rp.VerifyAssertion(challenge, credential.PublicKey, credential.SignCount, clientData, authData, signature)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signCount != 1 {
+		t.Errorf("expected sign count 1, got %d", signCount)
+	}
+
+	// replaying the same counter looks like a cloned authenticator
+	if _, err := rp.VerifyAssertion(challenge, credential.PublicKey, signCount, clientData, authData, signature); err == nil {
+		t.Error("expected a stale sign count to fail")
+	}
+
+	signature[len(signature)-1] ^= 0xff
+	if _, err := rp.VerifyAssertion(challenge, credential.PublicKey, 0, clientData, authData, signature); err == nil {
+		t.Error("expected an invalid signature to fail")
+	}
+
+	clientData, authData, signature = authenticator.assert(challenge, webAuthnFlagUserPresent)
+	if _, err := rp.VerifyAssertion(challenge, credential.PublicKey, signCount, clientData, authData, signature); err == nil {
+		t.Error("expected an unverified user to fail")
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/axiomzen/envconfig"
+	"github.com/axiomzen/zenauth/helpers"
+)
+
+const keysUsage = `usage: zenauth keys <command> [flags]
+
+Manages the keyring directory of JWT signing keys (ZENAUTH_JWTKEYRINGDIR).
+To create a keyring, add a key to an empty directory before setting ZENAUTH_JWTKEYRINGDIR.
+
+commands:
+  add      generate a new key, published for verification only until it is promoted
+  promote  make a key the current signing key (also to roll back to a previous key)
+`
+
+// keysConfig is the part of the configuration the keys subcommand uses, so it runs without the
+// settings the server requires
+type keysConfig struct {
+	JwtKeyringDir         string `required:"false"`
+	JwtKeyringMaxPrevious uint16 `default:"3"`
+}
+
+// keysCommand runs the keys subcommand, returns the exit code
+func keysCommand(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, keysUsage)
+		return 2
+	}
+
+	var conf keysConfig
+	if err := envconfig.Process("ZENAUTH", &conf); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+
+	flags := flag.NewFlagSet("keys "+args[0], flag.ContinueOnError)
+	dir := flags.String("dir", conf.JwtKeyringDir, "keyring directory")
+
+	switch args[0] {
+	case "add":
+		alg := flags.String("alg", "ES256", "signing method of the new key: RS256, ES256, EdDSA or HS256")
+		if err := flags.Parse(args[1:]); err != nil {
+			return 2
+		}
+		if *dir == "" {
+			fmt.Fprintln(os.Stderr, "a keyring directory is required")
+			return 2
+		}
+		kid, err := helpers.AddKey(*dir, *alg)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			return 1
+		}
+		fmt.Println(kid)
+	case "promote":
+		keep := flags.Int("keep", int(conf.JwtKeyringMaxPrevious), "number of previous keys to keep for verifying tokens")
+		if err := flags.Parse(args[1:]); err != nil {
+			return 2
+		}
+		if *dir == "" || flags.NArg() != 1 {
+			fmt.Fprintln(os.Stderr, "usage: zenauth keys promote -dir <dir> [-keep <n>] <kid>")
+			return 2
+		}
+		if err := helpers.PromoteKey(*dir, flags.Arg(0), *keep); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			return 1
+		}
+	default:
+		fmt.Fprint(os.Stderr, keysUsage)
+		return 2
+	}
+	return 0
+}
@@ -4,9 +4,12 @@ import (
 	"math/rand"
 	"net/http"
 	"os"
+	"os/signal"
 	"runtime"
 	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	logger "log"
@@ -15,13 +18,26 @@ import (
 	nullformat "github.com/axiomzen/null/format"
 	"github.com/axiomzen/zenauth/config"
 	"github.com/axiomzen/zenauth/constants"
+	"github.com/axiomzen/zenauth/context/v1"
 	"github.com/axiomzen/zenauth/data"
+	"github.com/axiomzen/zenauth/email"
 	"github.com/axiomzen/zenauth/grpc"
 	pg "gopkg.in/pg.v4"
 	"gopkg.in/tylerb/graceful.v1"
 )
 
 func main() {
+	// key management doesn't need the rest of the configuration
+	if len(os.Args) > 1 && os.Args[1] == "keys" {
+		os.Exit(keysCommand(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "clients" {
+		os.Exit(clientsCommand(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "users" {
+		os.Exit(usersCommand(os.Args[2:]))
+	}
+
 	log.Infoln(os.Getenv("ZENAUTH_ENVIRONMENT"))
 
 	// set just in case someone has go 1.4
@@ -40,6 +56,12 @@ func main() {
 		// die, we are not configured properly
 		log.Fatal(err.Error())
 	}
+	if err := email.LoadTemplates(conf); err != nil {
+		log.Fatal(err.Error())
+	}
+	if err := v1.LoadTemplates(conf); err != nil {
+		log.Fatal(err.Error())
+	}
 	log.Infoln("Migrating DB ...")
 	switch conf.Environment {
 	case constants.EnvironmentStaging, constants.EnvironmentProduction, constants.EnvironmentDevelopment:
@@ -88,14 +110,23 @@ func main() {
 	// make sure to close the database connection pool when we exit
 	defer dataP.Close()
 
+	if conf.UserPurgeInterval > 0 {
+		go purgeDeletedUsers(conf, dataP, log.WithField("worker", "purge"))
+	}
+
 	// Error channel for multiple servers
-	errChn := make(chan error)
+	errChn := make(chan error, 2)
 
-	router := InitRouter(conf)
+	router, routerErr := InitRouter(conf)
+	if routerErr != nil {
+		log.Fatal(routerErr.Error())
+	}
 
 	srv := &graceful.Server{
 		// Time to allow for active requests to complete
 		Timeout: conf.DrainAndDieTimeout,
+		// both servers are shut down together below
+		NoSignalHandling: true,
 
 		Server: &http.Server{
 			Addr:         ":" + strconv.FormatInt(int64(conf.Port), 10),
@@ -112,7 +143,7 @@ func main() {
 	}()
 
 	// Runs the GRPC server
-	grpcServer := grpc.Server{
+	grpcServer := &grpc.Server{
 		Config: conf,
 		DAL:    dataP,
 		Log:    log.WithField("server", "grpc"),
@@ -121,5 +152,28 @@ func main() {
 		errChn <- grpcServer.ListenAndServe()
 	}()
 
-	log.Fatal(<-errChn)
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, os.Interrupt)
+	select {
+	case err := <-errChn:
+		log.Fatal(err)
+	case sig := <-signals:
+		log.WithField("signal", sig.String()).Info("Shutting down")
+	}
+
+	// stop accepting requests and calls, and let the ones in progress end before the database
+	// connection pool is closed on return
+	var stopped sync.WaitGroup
+	stopped.Add(2)
+	go func() {
+		defer stopped.Done()
+		srv.Stop(conf.DrainAndDieTimeout)
+		<-srv.StopChan()
+	}()
+	go func() {
+		defer stopped.Done()
+		grpcServer.Shutdown(conf.DrainAndDieTimeout)
+	}()
+	stopped.Wait()
+	log.Info("Servers stopped")
 }
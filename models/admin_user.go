@@ -0,0 +1,109 @@
+package models
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/axiomzen/null"
+)
+
+//go:generate ffjson $GOFILE
+
+// AdminUser is the admin view of a user, with the account state users don't see about themselves
+type AdminUser struct {
+	ID               string      `json:"id"`
+	Email            string      `json:"email"`
+	UserName         string      `json:"userName"`
+	Verified         bool        `json:"verified"`
+	CreatedAt        null.Time   `json:"createdAt"`
+	UpdatedAt        null.Time   `json:"updatedAt"`
+	FacebookID       string      `json:"facebookId,omitempty"`
+	FacebookUsername string      `json:"facebookUsername,omitempty"`
+	FacebookEmail    string      `json:"facebookEmail,omitempty"`
+	HasPassword      bool        `json:"hasPassword"`
+	TOTPEnabled      bool        `json:"totpEnabled"`
+	InvitedBy        null.String `json:"invitedBy"`
+	DisabledAt       null.Time   `json:"disabledAt"`
+}
+
+// NewAdminUser returns the admin view of the user
+func NewAdminUser(user *User) *AdminUser {
+	return &AdminUser{
+		ID:               user.ID,
+		Email:            user.Email,
+		UserName:         user.UserName,
+		Verified:         user.Verified,
+		CreatedAt:        user.CreatedAt,
+		UpdatedAt:        user.UpdatedAt,
+		FacebookID:       user.FacebookID,
+		FacebookUsername: user.FacebookUsername,
+		FacebookEmail:    user.FacebookEmail,
+		HasPassword:      user.Hash != nil && *user.Hash != "",
+		TOTPEnabled:      user.TOTPEnabled,
+		InvitedBy:        user.InvitedBy,
+		DisabledAt:       user.DisabledAt,
+	}
+}
+
+// AdminUserList is a page of the admin user listing. NextCursor is empty on the last page
+type AdminUserList struct {
+	Users      []*AdminUser `json:"users"`
+	NextCursor string       `json:"nextCursor,omitempty"`
+}
+
+// UserSearch filters the admin user listing, empty fields match every user.
+// Users are listed in creation order, from after the cursor
+type UserSearch struct {
+	EmailPrefix   string
+	UserName      string
+	FacebookID    string
+	CreatedAfter  null.Time
+	CreatedBefore null.Time
+	Verified      null.Bool
+	Disabled      null.Bool
+	Cursor        *UserCursor
+	Limit         int
+}
+
+// UserCursor is the position of the last user of a page
+type UserCursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+var errInvalidUserCursor = errors.New("invalid cursor")
+
+// NewUserCursor returns the cursor of the page ending with the user
+func NewUserCursor(user *User) *UserCursor {
+	return &UserCursor{CreatedAt: user.CreatedAt.Time, ID: user.ID}
+}
+
+// Encode returns the cursor as sent to clients, who shouldn't make anything of it
+func (cursor *UserCursor) Encode() string {
+	raw := cursor.CreatedAt.UTC().Format(time.RFC3339Nano) + " " + cursor.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeUserCursor decodes a cursor returned by Encode
+func DecodeUserCursor(s string) (*UserCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errInvalidUserCursor
+	}
+	parts := strings.SplitN(string(raw), " ", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return nil, errInvalidUserCursor
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return nil, errInvalidUserCursor
+	}
+	return &UserCursor{CreatedAt: createdAt, ID: parts[1]}, nil
+}
+
+// AdminPasswordReset sets the user's password, or emails them a reset link when Password is empty
+type AdminPasswordReset struct {
+	Password string `form:"password" json:"password" lorem:"-"`
+}
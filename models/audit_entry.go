@@ -0,0 +1,22 @@
+package models
+
+import (
+	"github.com/axiomzen/null"
+)
+
+//go:generate ffjson $GOFILE
+
+// AuditEntry records a security relevant event on a user's account
+type AuditEntry struct {
+	ID        string    `sql:",pk"`
+	TableName TableName `sql:"audit_log,alias:audit_entry"`
+	UserID    string
+	// Event is one of the constants.AuditEvent values
+	Event     string
+	IPAddress null.String `sql:",null"`
+	UserAgent null.String `sql:",null"`
+	CreatedAt null.Time   `sql:",null"`
+}
+
+// AuditEntries is a list of audit log entries
+type AuditEntries []*AuditEntry
@@ -17,6 +17,15 @@ type ErrorResponse struct {
 	// boo; ffjson messes up if you use the error interface
 	GoError AZError `form:"internalError"        json:"internalError"`
 	//GoError string `form:"serverError"        json:"serverError"`
+	// Details of validation errors, e.g. each password policy rule broken
+	Details []ErrorDetail `form:"details"            json:"details,omitempty"`
+}
+
+// ErrorDetail is one of the reasons for an error
+type ErrorDetail struct {
+	Field   string `form:"field"              json:"field,omitempty"`
+	Rule    string `form:"rule"               json:"rule"`
+	Message string `form:"message"            json:"message"`
 }
 
 // AZError is our own underlying error representation
@@ -21,6 +21,9 @@ type Invitation struct {
 	Type      string
 	Code      string
 	CreatedAt null.Time `sql:",null"`
+	// CreatedBy is the user who sent the invitation
+	CreatedBy null.String `sql:",null"`
+	TenantID  string      `sql:",null" json:"-"`
 }
 
 type Invitations []*Invitation
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"github.com/axiomzen/null"
+)
+
+//go:generate ffjson $GOFILE
+
+// LoginThrottle counts the failed logins of an account or a client ip
+type LoginThrottle struct {
+	TableName TableName `sql:"login_throttles,alias:login_throttle"`
+	// Key is "account:" followed by the user id, "ip:" followed by the address, or "mfa:"
+	// followed by the jti of an mfa token
+	Key           string `sql:",pk"`
+	Failures      int
+	LastFailureAt null.Time `sql:",null"`
+	LockedUntil   null.Time `sql:",null"`
+}
+
+// LoginThrottleAccountKey is the throttle key of an account
+func LoginThrottleAccountKey(userID string) string {
+	return "account:" + userID
+}
+
+// LoginThrottleIPKey is the throttle key of a client ip
+func LoginThrottleIPKey(ip string) string {
+	return "ip:" + ip
+}
+
+// LoginThrottleMFAKey is the key counting the bad codes entered with an mfa token
+func LoginThrottleMFAKey(jti string) string {
+	return "mfa:" + jti
+}
@@ -0,0 +1,14 @@
+package models
+
+//go:generate ffjson $GOFILE
+
+// MagicLinkRequest asks for a passwordless login link to be emailed
+type MagicLinkRequest struct {
+	Email string `form:"email" json:"email" lorem:"email"`
+}
+
+// MagicLinkLogin exchanges the token from a login link for an auth token
+type MagicLinkLogin struct {
+	Email string `form:"email" json:"email" lorem:"email"`
+	Token string `form:"token" json:"token" lorem:"-"`
+}
@@ -0,0 +1,31 @@
+package models
+
+//go:generate ffjson $GOFILE
+
+// TOTPEnrollment is returned when starting TOTP enrollment
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	// URI is the otpauth:// URI for authenticator apps (usually shown as a QR code)
+	URI string `json:"uri"`
+	// RecoveryCodes can each be used once in place of a code
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+// MFACode is a code from the user's authenticator app, or a recovery code
+type MFACode struct {
+	Code string `form:"code" json:"code" lorem:"-"`
+}
+
+// MFALogin exchanges the token from the password step of logging in,
+// along with a code, for an auth token
+type MFALogin struct {
+	MFAToken string `form:"mfaToken" json:"mfaToken" lorem:"-"`
+	Code     string `form:"code"     json:"code"     lorem:"-"`
+}
+
+// MFAChallenge is returned by the password step of logging in
+// when the user has two factor authentication enabled
+type MFAChallenge struct {
+	MFARequired bool   `json:"mfaRequired"`
+	MFAToken    string `json:"mfaToken"`
+}
@@ -0,0 +1,134 @@
+package models
+
+import (
+	"time"
+
+	"github.com/axiomzen/null"
+)
+
+//go:generate ffjson $GOFILE
+
+// OAuthClient is an application that logs users in through the OpenID Connect provider
+type OAuthClient struct {
+	ID        string    `sql:",pk" json:"-"`
+	TableName TableName `sql:"oauth_clients,alias:oauth_client" json:"-"`
+	ClientID  string    `json:"clientId"`
+	// SecretHash is empty for public clients, which can't keep a secret and rely on PKCE alone
+	SecretHash string `sql:",null" json:"-"`
+	// ClientSecret is only returned when the client is registered
+	ClientSecret string    `sql:"-" json:"clientSecret,omitempty"`
+	Name         string    `json:"name"`
+	RedirectURIs []string  `sql:"redirect_uris" pg:",array" json:"redirectUris"`
+	CreatedAt    null.Time `sql:",null" json:"createdAt"`
+}
+
+// OAuthAuthorizationCode is issued to a client once the user logs in, and exchanged
+// for tokens once. Only the hash of the code is stored
+type OAuthAuthorizationCode struct {
+	ID            string    `sql:",pk"`
+	TableName     TableName `sql:"oauth_authorization_codes,alias:oauth_authorization_code"`
+	CodeHash      string
+	ClientID      string
+	UserID        string
+	RedirectURI   string
+	Scope         string
+	Nonce         string `sql:",null"`
+	CodeChallenge string
+	CreatedAt     null.Time `sql:",null"`
+	ExpiresAt     time.Time
+	UsedAt        null.Time `sql:",null"`
+}
+
+// OAuthAuthorizeRequest is the authorization request, along with the
+// credentials when the login page is submitted
+type OAuthAuthorizeRequest struct {
+	ResponseType        string `form:"response_type"`
+	ClientID            string `form:"client_id"`
+	RedirectURI         string `form:"redirect_uri"`
+	Scope               string `form:"scope"`
+	State               string `form:"state"`
+	Nonce               string `form:"nonce"`
+	CodeChallenge       string `form:"code_challenge"`
+	CodeChallengeMethod string `form:"code_challenge_method"`
+	Email               string `form:"email"`
+	Password            string `form:"password"`
+	// MFAToken replaces the password once it was checked, for users with two factor authentication,
+	// who then send the TOTP or recovery code as OTP
+	MFAToken string `form:"mfa_token"`
+	OTP      string `form:"otp"`
+}
+
+// OAuthTokenRequest exchanges an authorization code for tokens
+type OAuthTokenRequest struct {
+	GrantType    string `form:"grant_type"`
+	Code         string `form:"code"`
+	RedirectURI  string `form:"redirect_uri"`
+	ClientID     string `form:"client_id"`
+	ClientSecret string `form:"client_secret"`
+	CodeVerifier string `form:"code_verifier"`
+}
+
+// OAuthTokenResponse is the successful token response
+type OAuthTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+	IDToken     string `json:"id_token,omitempty"`
+	Scope       string `json:"scope,omitempty"`
+}
+
+// OAuthError is an error response as defined by OAuth 2.0
+type OAuthError struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// OIDCConfiguration is the OpenID Connect discovery document
+type OIDCConfiguration struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserInfoEndpoint                  string   `json:"userinfo_endpoint"`
+	JWKSURI                           string   `json:"jwks_uri"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+}
+
+// OIDCUserInfo are the claims about the user in ID tokens and from the userinfo endpoint
+type OIDCUserInfo struct {
+	Subject           string `json:"sub"`
+	Email             string `json:"email,omitempty"`
+	EmailVerified     bool   `json:"email_verified"`
+	PreferredUsername string `json:"preferred_username,omitempty"`
+	Picture           string `json:"picture,omitempty"`
+	UpdatedAt         int64  `json:"updated_at,omitempty"`
+}
+
+// OIDCUserInfo returns the user's claims
+func (user *User) OIDCUserInfo() *OIDCUserInfo {
+	info := &OIDCUserInfo{
+		Subject:           user.ID,
+		Email:             user.Email,
+		EmailVerified:     user.Verified,
+		PreferredUsername: user.UserName,
+		Picture:           user.FacebookPicture,
+	}
+	if user.UpdatedAt.Valid {
+		info.UpdatedAt = user.UpdatedAt.Time.Unix()
+	}
+	return info
+}
+
+// OAuthClientRegistration registers a client
+type OAuthClientRegistration struct {
+	Name         string   `json:"name"`
+	RedirectURIs []string `json:"redirectUris"`
+	// Public clients (e.g. mobile or single page apps) get no secret
+	Public bool `json:"public"`
+}
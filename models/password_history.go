@@ -0,0 +1,19 @@
+package models
+
+import (
+	"github.com/axiomzen/null"
+)
+
+//go:generate ffjson $GOFILE
+
+// PasswordHistory is a hash of a password the user had, so it can't be used again
+type PasswordHistory struct {
+	ID        string    `sql:",pk"`
+	TableName TableName `sql:"password_history,alias:password_history"`
+	UserID    string
+	Hash      string
+	CreatedAt null.Time `sql:",null"`
+}
+
+// PasswordHistoryList is the password history of a user, newest first
+type PasswordHistoryList []*PasswordHistory
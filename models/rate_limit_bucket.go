@@ -0,0 +1,16 @@
+package models
+
+import (
+	"github.com/axiomzen/null"
+)
+
+//go:generate ffjson $GOFILE
+
+// RateLimitBucket is a token bucket shared by every replica
+type RateLimitBucket struct {
+	TableName TableName `sql:"rate_limit_buckets,alias:rate_limit_bucket"`
+	// Key is the name of the limit followed by what it counts, e.g. signup:ip:203.0.113.7
+	Key       string `sql:",pk"`
+	Tokens    float64
+	UpdatedAt null.Time `sql:",null"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"github.com/axiomzen/null"
+)
+
+//go:generate ffjson $GOFILE
+
+// RecoveryCode is a single use code that can be used in place of a TOTP code.
+// Only the bcrypt hash of the code is stored
+type RecoveryCode struct {
+	ID        string    `sql:",pk"`
+	TableName TableName `sql:"recovery_codes,alias:recovery_code"`
+	UserID    string
+	Hash      string
+	CreatedAt null.Time `sql:",null"`
+	UsedAt    null.Time `sql:",null"`
+}
+
+// RecoveryCodeList is a list of stored recovery codes
+type RecoveryCodeList []*RecoveryCode
+
+// RecoveryCodes is the list of recovery codes shown to the user, only once
+type RecoveryCodes struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
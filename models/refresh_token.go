@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/axiomzen/null"
+)
+
+//go:generate ffjson $GOFILE
+
+// RefreshToken is an opaque, single use token that can be exchanged
+// for a new auth token. Only the hash of the token is stored.
+type RefreshToken struct {
+	ID        string    `sql:",pk"`
+	TableName TableName `sql:"refresh_tokens,alias:refresh_token"`
+	UserID    string
+	// FamilyID is shared by every token rotated from the same login
+	FamilyID  string
+	TokenHash string
+	CreatedAt null.Time `sql:",null"`
+	ExpiresAt time.Time
+	UsedAt    null.Time `sql:",null"`
+	RevokedAt null.Time `sql:",null"`
+}
+
+// RefreshTokens is a list of refresh tokens
+type RefreshTokens []*RefreshToken
+
+// TokenRefresh is the request body sent to exchange a refresh token
+type TokenRefresh struct {
+	RefreshToken string `form:"refreshToken" json:"refreshToken" lorem:"-"`
+}
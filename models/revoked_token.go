@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"github.com/axiomzen/null"
+)
+
+// RevokedToken revokes either a single auth token (by JTI)
+// or every auth token issued to a user before IssuedBefore
+type RevokedToken struct {
+	ID           string    `sql:",pk"`
+	TableName    TableName `sql:"revoked_tokens,alias:revoked_token"`
+	UserID       string
+	JTI          null.String `sql:",null"`
+	IssuedBefore null.Time   `sql:",null"`
+	// ExpiresAt is when the revoked token(s) would have expired anyway
+	ExpiresAt time.Time
+	CreatedAt null.Time `sql:",null"`
+}
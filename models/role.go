@@ -0,0 +1,85 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/axiomzen/null"
+	"github.com/axiomzen/zenauth/protobuf"
+)
+
+//go:generate ffjson $GOFILE
+
+// accessNameRegexp is what role and permission names may look like, e.g. admin or posts:write
+var accessNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9_.:-]{1,64}$`)
+
+// Role is a named set of permissions given to users
+type Role struct {
+	ID          string    `sql:",pk" json:"-"`
+	TableName   TableName `sql:"roles,alias:role" json:"-"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	// Permissions are the names of the role's permissions
+	Permissions []string  `sql:"-" json:"permissions"`
+	CreatedAt   null.Time `sql:",null" json:"createdAt"`
+}
+
+// Roles is a list of roles
+type Roles []*Role
+
+// Validate checks the names of the role and its permissions
+func (role *Role) Validate() error {
+	if !accessNameRegexp.MatchString(role.Name) {
+		return fmt.Errorf("invalid role name %q", role.Name)
+	}
+	for _, permission := range role.Permissions {
+		if !accessNameRegexp.MatchString(permission) {
+			return fmt.Errorf("invalid permission name %q", permission)
+		}
+	}
+	return nil
+}
+
+// Protobuf returns the role as a protobuf message
+func (role *Role) Protobuf() *protobuf.Role {
+	return &protobuf.Role{Name: role.Name, Description: role.Description, Permissions: role.Permissions}
+}
+
+// Protobuf returns the roles as a protobuf message
+func (roles Roles) Protobuf() *protobuf.Roles {
+	protoRoles := &protobuf.Roles{Roles: make([]*protobuf.Role, 0, len(roles))}
+	for _, role := range roles {
+		protoRoles.Roles = append(protoRoles.Roles, role.Protobuf())
+	}
+	return protoRoles
+}
+
+// NewRoleFromProtobuf returns the role of a protobuf message
+func NewRoleFromProtobuf(role *protobuf.Role) *Role {
+	return &Role{Name: role.GetName(), Description: role.GetDescription(), Permissions: role.GetPermissions()}
+}
+
+// Permission is something a role allows, checked by the services relying on us
+type Permission struct {
+	ID        string    `sql:",pk" json:"-"`
+	TableName TableName `sql:"permissions,alias:permission" json:"-"`
+	Name      string    `json:"name"`
+	CreatedAt null.Time `sql:",null" json:"createdAt"`
+}
+
+// Permissions is a list of permissions
+type Permissions []*Permission
+
+// UserRole gives a role to a user
+type UserRole struct {
+	TableName TableName `sql:"user_roles,alias:user_role" json:"-"`
+	UserID    string    `json:"userId"`
+	RoleID    string    `json:"-"`
+	CreatedAt null.Time `sql:",null" json:"createdAt"`
+}
+
+// UserAccess is what the user's roles allow, embedded in their auth tokens
+type UserAccess struct {
+	Roles       []string `json:"roles"`
+	Permissions []string `json:"permissions"`
+}
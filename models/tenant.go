@@ -0,0 +1,31 @@
+package models
+
+import (
+	"fmt"
+
+	"github.com/axiomzen/null"
+)
+
+//go:generate ffjson $GOFILE
+
+// Tenant isolates a product's users and invitations from the other tenants of the deployment
+type Tenant struct {
+	ID        string    `sql:",pk" json:"id"`
+	TableName TableName `sql:"tenants,alias:tenant" json:"-"`
+	Name      string    `json:"name"`
+	// APITokenHash is the hash of the API token, which is only returned when it is generated
+	APITokenHash *string   `json:"-"`
+	APIToken     string    `sql:"-" json:"apiToken,omitempty"`
+	CreatedAt    null.Time `sql:",null" json:"createdAt"`
+}
+
+// Tenants is a list of tenants
+type Tenants []*Tenant
+
+// Validate checks the name of the tenant, which looks like role names
+func (tenant *Tenant) Validate() error {
+	if !accessNameRegexp.MatchString(tenant.Name) {
+		return fmt.Errorf("invalid tenant name %q", tenant.Name)
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"github.com/axiomzen/null"
 	"github.com/axiomzen/zenauth/protobuf"
 	gpPtypes "github.com/golang/protobuf/ptypes"
 )
@@ -13,10 +14,32 @@ import (
 type User struct {
 	UserBase
 	ResetToken       *string `json:"-" lorem:"-"`
+	MagicLinkToken   *string `json:"-" lorem:"-" sql:"magic_link_token"`
 	Hash             *string `json:"-" lorem:"-"`
 	AuthToken        string  `json:"authToken,omitempty" lorem:"-" sql:"-"`
+	RefreshToken     string  `json:"refreshToken,omitempty" lorem:"-" sql:"-"`
 	VerifyEmailToken string  `json:"-" lorem:"-" sql:"-"`
 
+	// TOTPSecret is encrypted, and only used once TOTPEnabled is set
+	TOTPSecret   *string `json:"-" lorem:"-" sql:"totp_secret"`
+	TOTPEnabled  bool    `json:"totpEnabled" lorem:"-" sql:"totp_enabled"`
+	TOTPLastStep *int64  `json:"-" lorem:"-" sql:"totp_last_step"`
+
+	// InvitedBy sent the invitation the user signed up with, if they were invited
+	InvitedBy null.String `json:"-" lorem:"-" sql:",null"`
+	InvitedAt null.Time   `json:"-" lorem:"-" sql:",null"`
+
+	// DeletedAt is set when the user deletes their account, which is purged after the grace period
+	DeletedAt null.Time `json:"-" lorem:"-" sql:",null"`
+	// DisabledAt is set when an admin disables the account, it can't get new tokens until it is enabled
+	DisabledAt null.Time `json:"-" lorem:"-" sql:",null"`
+
+	// TenantID is set by the data provider of the request's tenant
+	TenantID string `json:"-" lorem:"-" sql:",null"`
+
+	// Roles are the names of the user's roles, set along with a new auth token
+	Roles []string `json:"roles,omitempty" lorem:"-" sql:"-"`
+
 	FacebookUser
 }
 
@@ -32,6 +55,7 @@ func (user *User) Protobuf() (*protobuf.User, error) {
 	return &protobuf.User{
 		Id:              user.ID,
 		AuthToken:       user.AuthToken,
+		RefreshToken:    user.RefreshToken,
 		Email:           user.Email,
 		Verified:        user.Verified,
 		CreatedAt:       createdAt,
@@ -42,6 +66,7 @@ func (user *User) Protobuf() (*protobuf.User, error) {
 		FacebookPicture: user.FacebookPicture,
 		FacebookToken:   user.FacebookToken,
 		FacebookEmail: 	 user.FacebookEmail,
+		Roles:           user.Roles,
 	}, nil
 }
 func (user *User) ProtobufPublic() (*protobuf.UserPublic, error) {
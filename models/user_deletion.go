@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+)
+
+//go:generate ffjson $GOFILE
+
+// UserDeletion confirms the deletion of the user's account, with their password or a two factor code
+type UserDeletion struct {
+	Password string `form:"password" json:"password" lorem:"-"`
+	Code     string `form:"code"     json:"code"     lorem:"-"`
+}
+
+// UserDeletionStatus is returned once the account is deleted, it can be restored until PurgeAt
+type UserDeletionStatus struct {
+	DeletedAt time.Time `json:"deletedAt"`
+	PurgeAt   time.Time `json:"purgeAt"`
+}
@@ -0,0 +1,146 @@
+package models
+
+import (
+	"time"
+
+	"github.com/axiomzen/null"
+	"github.com/axiomzen/zenauth/constants"
+)
+
+//go:generate ffjson $GOFILE
+
+// UserExport is a stored export of everything we hold about a user
+type UserExport struct {
+	ID        string    `sql:",pk"`
+	TableName TableName `sql:"user_exports,alias:user_export"`
+	UserID    string
+	// Data is the json encoded UserExportBundle
+	Data      []byte
+	CreatedAt null.Time `sql:",null"`
+	ExpiresAt time.Time
+}
+
+// UserExportBundle is the data a user gets when they ask for what we hold about them.
+// Password hashes and other secrets are left out
+type UserExportBundle struct {
+	ExportedAt time.Time         `json:"exportedAt"`
+	User       UserExportProfile `json:"user"`
+	Identities UserIdentities    `json:"identities"`
+	// Invitation the user signed up with
+	Invitation *UserExportInvitation `json:"invitation,omitempty"`
+	// InvitationsSent by the user that were not accepted yet
+	InvitationsSent []UserExportInvitation `json:"invitationsSent"`
+	Sessions        []UserExportSession    `json:"sessions"`
+	AuditLog        []UserExportAuditEntry `json:"auditLog"`
+}
+
+// UserExportProfile is the users row, without the hash and tokens
+type UserExportProfile struct {
+	ID               string    `json:"id"`
+	Email            string    `json:"email"`
+	UserName         string    `json:"userName"`
+	Verified         bool      `json:"verified"`
+	CreatedAt        null.Time `json:"createdAt"`
+	UpdatedAt        null.Time `json:"updatedAt"`
+	FacebookID       string    `json:"facebookId,omitempty"`
+	FacebookUsername string    `json:"facebookUsername,omitempty"`
+	FacebookEmail    string    `json:"facebookEmail,omitempty"`
+	FacebookPicture  string    `json:"facebookPicture,omitempty"`
+	TOTPEnabled      bool      `json:"totpEnabled"`
+}
+
+// UserExportInvitation is an invitation sent or accepted by the user
+type UserExportInvitation struct {
+	Type      string      `json:"type"`
+	Code      string      `json:"code"`
+	CreatedBy null.String `json:"createdBy"`
+	CreatedAt null.Time   `json:"createdAt"`
+}
+
+// UserExportSession is a login, which lasts as long as its refresh tokens are rotated
+type UserExportSession struct {
+	ID              string    `json:"id"`
+	StartedAt       null.Time `json:"startedAt"`
+	LastRefreshedAt null.Time `json:"lastRefreshedAt"`
+	ExpiresAt       time.Time `json:"expiresAt"`
+	RevokedAt       null.Time `json:"revokedAt"`
+}
+
+// UserExportAuditEntry is an event of the audit log
+type UserExportAuditEntry struct {
+	Event     string      `json:"event"`
+	IPAddress null.String `json:"ipAddress"`
+	UserAgent null.String `json:"userAgent"`
+	CreatedAt null.Time   `json:"createdAt"`
+}
+
+// NewUserExportBundle returns the export of the user and their related rows
+func NewUserExportBundle(user *User, identities UserIdentities, sent Invitations, tokens RefreshTokens, audit AuditEntries) *UserExportBundle {
+	bundle := &UserExportBundle{
+		ExportedAt: time.Now().UTC(),
+		User: UserExportProfile{
+			ID:               user.ID,
+			Email:            user.Email,
+			UserName:         user.UserName,
+			Verified:         user.Verified,
+			CreatedAt:        user.CreatedAt,
+			UpdatedAt:        user.UpdatedAt,
+			FacebookID:       user.FacebookID,
+			FacebookUsername: user.FacebookUsername,
+			FacebookEmail:    user.FacebookEmail,
+			FacebookPicture:  user.FacebookPicture,
+			TOTPEnabled:      user.TOTPEnabled,
+		},
+		Identities:      identities,
+		InvitationsSent: []UserExportInvitation{},
+		Sessions:        []UserExportSession{},
+		AuditLog:        []UserExportAuditEntry{},
+	}
+	if bundle.Identities == nil {
+		bundle.Identities = UserIdentities{}
+	}
+	if user.InvitedAt.Valid {
+		// the invitation was for the email or facebook id they signed up with
+		bundle.Invitation = &UserExportInvitation{Type: constants.InvitationTypeEmail, Code: user.Email, CreatedBy: user.InvitedBy, CreatedAt: user.InvitedAt}
+		if user.FacebookID != "" && user.Email == "" {
+			bundle.Invitation.Type, bundle.Invitation.Code = constants.InvitationTypeFacebook, user.FacebookID
+		}
+	}
+	for _, invitation := range sent {
+		bundle.InvitationsSent = append(bundle.InvitationsSent, UserExportInvitation{
+			Type:      invitation.Type,
+			Code:      invitation.Code,
+			CreatedBy: invitation.CreatedBy,
+			CreatedAt: invitation.CreatedAt,
+		})
+	}
+
+	// refresh tokens rotated from the same login share a family, oldest first
+	sessions := map[string]int{}
+	for _, token := range tokens {
+		i, ok := sessions[token.FamilyID]
+		if !ok {
+			sessions[token.FamilyID] = len(bundle.Sessions)
+			bundle.Sessions = append(bundle.Sessions, UserExportSession{ID: token.FamilyID, StartedAt: token.CreatedAt})
+			i = len(bundle.Sessions) - 1
+		}
+		session := &bundle.Sessions[i]
+		session.LastRefreshedAt = token.CreatedAt
+		if token.ExpiresAt.After(session.ExpiresAt) {
+			session.ExpiresAt = token.ExpiresAt
+		}
+		if token.RevokedAt.Valid {
+			session.RevokedAt = token.RevokedAt
+		}
+	}
+
+	for _, entry := range audit {
+		bundle.AuditLog = append(bundle.AuditLog, UserExportAuditEntry{
+			Event:     entry.Event,
+			IPAddress: entry.IPAddress,
+			UserAgent: entry.UserAgent,
+			CreatedAt: entry.CreatedAt,
+		})
+	}
+	return bundle
+}
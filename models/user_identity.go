@@ -0,0 +1,40 @@
+package models
+
+import (
+	"github.com/axiomzen/null"
+)
+
+//go:generate ffjson $GOFILE
+
+// UserIdentity is an account with a social identity provider the user can log in with
+type UserIdentity struct {
+	ID        string    `sql:",pk" json:"id"`
+	TableName TableName `sql:"user_identities,alias:user_identity" json:"-"`
+	UserID    string    `json:"-"`
+	Provider  string    `json:"provider"`
+	// Subject is the provider's id for the account
+	Subject      string    `json:"subject"`
+	Email        string    `sql:",null" json:"email"`
+	AccessToken  string    `sql:",null" json:"-"`
+	RefreshToken string    `sql:",null" json:"-"`
+	CreatedAt    null.Time `sql:",null" json:"createdAt"`
+	UpdatedAt    null.Time `sql:",null" json:"updatedAt"`
+	// TenantID is the tenant of the user, identities are unique per tenant
+	TenantID string `sql:",null" json:"-"`
+}
+
+// UserIdentities is a list of identities
+type UserIdentities []*UserIdentity
+
+// SocialLogin is sent to log in, sign up or link with an identity provider. Clients either
+// send the tokens they got, or the authorization code for us to exchange
+type SocialLogin struct {
+	AccessToken  string `form:"accessToken"  json:"accessToken"  lorem:"-"`
+	IDToken      string `form:"idToken"      json:"idToken"      lorem:"-"`
+	Code         string `form:"code"         json:"code"         lorem:"-"`
+	RedirectURI  string `form:"redirectUri"  json:"redirectUri"  lorem:"-"`
+	CodeVerifier string `form:"codeVerifier" json:"codeVerifier" lorem:"-"`
+	Nonce        string `form:"nonce"        json:"nonce"        lorem:"-"`
+	// Subject is the account id, only needed by providers without id tokens (Facebook)
+	Subject string `form:"subject" json:"subject" lorem:"-"`
+}
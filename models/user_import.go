@@ -0,0 +1,164 @@
+package models
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/axiomzen/zenauth/constants"
+)
+
+//go:generate ffjson $GOFILE
+
+// UserImport is a user migrated from another system, with their password hash and the
+// parameters it was made with. CSV files have a header row with the json names
+type UserImport struct {
+	Email    string `json:"email"`
+	UserName string `json:"userName"`
+	Verified bool   `json:"verified"`
+	// HashAlgorithm is bcrypt, argon2id, pbkdf2, django-pbkdf2, firebase-scrypt or sha256,
+	// empty for users without a password
+	HashAlgorithm string `json:"hashAlgorithm"`
+	Hash          string `json:"hash"`
+	Salt          string `json:"salt,omitempty"`
+	SaltSeparator string `json:"saltSeparator,omitempty"`
+	SignerKey     string `json:"signerKey,omitempty"`
+	Rounds        int    `json:"rounds,omitempty"`
+	MemCost       int    `json:"memCost,omitempty"`
+	SaltPosition  string `json:"saltPosition,omitempty"`
+}
+
+// UserImportError is a row that could not be imported
+type UserImportError struct {
+	// Line of the row in the file, counting the CSV header
+	Line  int    `json:"line"`
+	Email string `json:"email,omitempty"`
+	Error string `json:"error"`
+}
+
+// UserImportReport is the outcome of an import
+type UserImportReport struct {
+	Imported int               `json:"imported"`
+	Failed   int               `json:"failed"`
+	Errors   []UserImportError `json:"errors"`
+}
+
+// UserImportReader streams the users of an NDJSON or CSV file
+type UserImportReader struct {
+	format  string
+	lines   *bufio.Scanner
+	csv     *csv.Reader
+	columns []string
+	line    int
+}
+
+// NewUserImportReader reads users from r in the format, reading the header of CSV files
+func NewUserImportReader(r io.Reader, format string) (*UserImportReader, error) {
+	reader := &UserImportReader{format: format}
+	switch format {
+	case constants.UserImportFormatNDJSON:
+		reader.lines = bufio.NewScanner(r)
+		reader.lines.Buffer(make([]byte, 64*1024), 1024*1024)
+	case constants.UserImportFormatCSV:
+		reader.csv = csv.NewReader(r)
+		reader.csv.FieldsPerRecord = -1
+		header, err := reader.csv.Read()
+		if err != nil {
+			return nil, fmt.Errorf("could not read the csv header: %s", err.Error())
+		}
+		reader.line = 1
+		for _, column := range header {
+			reader.columns = append(reader.columns, strings.TrimSpace(column))
+		}
+	default:
+		return nil, fmt.Errorf("unknown import format %q", format)
+	}
+	return reader, nil
+}
+
+// Next reads the next user into row and returns its line. err is io.EOF at the end of the file,
+// other errors only concern this row, unless the line is 0
+func (r *UserImportReader) Next(row *UserImport) (line int, err error) {
+	*row = UserImport{}
+	if r.lines != nil {
+		for r.lines.Scan() {
+			r.line++
+			text := strings.TrimSpace(r.lines.Text())
+			if text == "" {
+				continue
+			}
+			return r.line, json.Unmarshal([]byte(text), row)
+		}
+		if err := r.lines.Err(); err != nil {
+			return 0, err
+		}
+		return 0, io.EOF
+	}
+
+	record, err := r.csv.Read()
+	if err == io.EOF {
+		return 0, err
+	}
+	r.line++
+	if err != nil {
+		if _, ok := err.(*csv.ParseError); ok {
+			return r.line, err
+		}
+		return 0, err
+	}
+	for i, value := range record {
+		if i >= len(r.columns) {
+			break
+		}
+		if err := row.set(r.columns[i], value); err != nil {
+			return r.line, err
+		}
+	}
+	return r.line, nil
+}
+
+// set sets the field of the json name to a CSV value
+func (row *UserImport) set(column, value string) error {
+	var err error
+	switch column {
+	case "email":
+		row.Email = value
+	case "userName":
+		row.UserName = value
+	case "verified":
+		if value != "" {
+			row.Verified, err = strconv.ParseBool(value)
+		}
+	case "hashAlgorithm":
+		row.HashAlgorithm = value
+	case "hash":
+		row.Hash = value
+	case "salt":
+		row.Salt = value
+	case "saltSeparator":
+		row.SaltSeparator = value
+	case "signerKey":
+		row.SignerKey = value
+	case "rounds":
+		if value != "" {
+			row.Rounds, err = strconv.Atoi(value)
+		}
+	case "memCost":
+		if value != "" {
+			row.MemCost, err = strconv.Atoi(value)
+		}
+	case "saltPosition":
+		row.SaltPosition = value
+	default:
+		// unknown columns are ignored, exports often have more
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("invalid %s %q", column, value)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package models
+
+import (
+	"github.com/axiomzen/null"
+)
+
+//go:generate ffjson $GOFILE
+
+// WebAuthnCredential is a registered passkey or security key
+type WebAuthnCredential struct {
+	ID        string    `sql:",pk" json:"id"`
+	TableName TableName `sql:"webauthn_credentials,alias:webauthn_credential" json:"-"`
+	UserID    string    `json:"-"`
+	// CredentialID is the base64url encoded id the authenticator knows the credential by
+	CredentialID string `json:"credentialId"`
+	// PublicKey is COSE encoded
+	PublicKey  []byte    `json:"-"`
+	SignCount  int64     `json:"-"`
+	Transports []string  `pg:",array" json:"transports"`
+	Name       string    `json:"name"`
+	CreatedAt  null.Time `sql:",null" json:"createdAt"`
+	LastUsedAt null.Time `sql:",null" json:"lastUsedAt"`
+}
+
+// WebAuthnCredentials is a list of credentials
+type WebAuthnCredentials []*WebAuthnCredential
+
+// WebAuthnRelyingPartyEntity identifies the relying party to the authenticator
+type WebAuthnRelyingPartyEntity struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// WebAuthnUserEntity identifies the user to the authenticator, ID is base64url encoded
+type WebAuthnUserEntity struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+// WebAuthnCredentialParameter is a type of credential the relying party accepts
+type WebAuthnCredentialParameter struct {
+	Type string `json:"type"`
+	Alg  int    `json:"alg"`
+}
+
+// WebAuthnCredentialDescriptor refers to an existing credential, ID is base64url encoded
+type WebAuthnCredentialDescriptor struct {
+	Type       string   `json:"type"`
+	ID         string   `json:"id"`
+	Transports []string `json:"transports,omitempty"`
+}
+
+// WebAuthnAuthenticatorSelection restricts the authenticators that can be registered
+type WebAuthnAuthenticatorSelection struct {
+	ResidentKey      string `json:"residentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+// WebAuthnCreationOptions are the options to pass to navigator.credentials.create,
+// with the binary fields base64url encoded
+type WebAuthnCreationOptions struct {
+	Challenge              string                         `json:"challenge"`
+	RP                     WebAuthnRelyingPartyEntity     `json:"rp"`
+	User                   WebAuthnUserEntity             `json:"user"`
+	PubKeyCredParams       []WebAuthnCredentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int64                          `json:"timeout"`
+	ExcludeCredentials     []WebAuthnCredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection WebAuthnAuthenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                         `json:"attestation"`
+}
+
+// WebAuthnRequestOptions are the options to pass to navigator.credentials.get,
+// with the binary fields base64url encoded
+type WebAuthnRequestOptions struct {
+	Challenge        string                         `json:"challenge"`
+	RPID             string                         `json:"rpId"`
+	Timeout          int64                          `json:"timeout"`
+	AllowCredentials []WebAuthnCredentialDescriptor `json:"allowCredentials"`
+	UserVerification string                         `json:"userVerification"`
+}
+
+// WebAuthnRegistrationChallenge starts a registration ceremony. The challenge token
+// has to be sent back with the new credential
+type WebAuthnRegistrationChallenge struct {
+	ChallengeToken string                  `json:"challengeToken"`
+	PublicKey      WebAuthnCreationOptions `json:"publicKey"`
+}
+
+// WebAuthnLoginChallenge starts an authentication ceremony. The challenge token
+// has to be sent back with the assertion
+type WebAuthnLoginChallenge struct {
+	ChallengeToken string                 `json:"challengeToken"`
+	PublicKey      WebAuthnRequestOptions `json:"publicKey"`
+}
+
+// WebAuthnAttestationResponse is the response of a new credential, base64url encoded
+type WebAuthnAttestationResponse struct {
+	ClientDataJSON    string   `json:"clientDataJSON"`
+	AttestationObject string   `json:"attestationObject"`
+	Transports        []string `json:"transports"`
+}
+
+// WebAuthnAssertionResponse is the response of an assertion, base64url encoded
+type WebAuthnAssertionResponse struct {
+	ClientDataJSON    string `json:"clientDataJSON"`
+	AuthenticatorData string `json:"authenticatorData"`
+	Signature         string `json:"signature"`
+	UserHandle        string `json:"userHandle"`
+}
+
+// WebAuthnRegistration finishes a registration ceremony
+type WebAuthnRegistration struct {
+	ChallengeToken string `json:"challengeToken" lorem:"-"`
+	// Name is a label for the user to recognize the credential by
+	Name     string                      `json:"name" lorem:"-"`
+	ID       string                      `json:"id" lorem:"-"`
+	Response WebAuthnAttestationResponse `json:"response" lorem:"-"`
+}
+
+// WebAuthnLoginBegin starts an authentication ceremony, the email is optional
+// and restricts the ceremony to the user's credentials
+type WebAuthnLoginBegin struct {
+	Email string `json:"email" lorem:"-"`
+}
+
+// WebAuthnLogin finishes an authentication ceremony
+type WebAuthnLogin struct {
+	ChallengeToken string                    `json:"challengeToken" lorem:"-"`
+	ID             string                    `json:"id" lorem:"-"`
+	Response       WebAuthnAssertionResponse `json:"response" lorem:"-"`
+}
@@ -6,12 +6,31 @@ Package protobuf is a generated protocol buffer package.
 
 It is generated from these files:
 	auth.proto
+	error.proto
 	user.proto
 
 It has these top-level messages:
 	UserID
 	UserIDs
 	InvitationCode
+	RefreshTokenRequest
+	MFALogin
+	UserSocialAuth
+	Role
+	Roles
+	RoleName
+	UserRole
+	PermissionCheck
+	PermissionCheckResult
+	PasswordChange
+	PasswordForgot
+	PasswordReset
+	EmailVerification
+	InviteCodes
+	ExistsCheck
+	ExistsResult
+	ErrorDetail
+	Violation
 	User
 	UserPublic
 	UsersPublic
@@ -97,10 +116,418 @@ func (m *InvitationCode) GetInviteCode() string {
 	return ""
 }
 
+type RefreshTokenRequest struct {
+	RefreshToken string `protobuf:"bytes,1,opt,name=refreshToken" json:"refreshToken,omitempty"`
+}
+
+func (m *RefreshTokenRequest) Reset()                    { *m = RefreshTokenRequest{} }
+func (m *RefreshTokenRequest) String() string            { return proto.CompactTextString(m) }
+func (*RefreshTokenRequest) ProtoMessage()               {}
+func (*RefreshTokenRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{3} }
+
+func (m *RefreshTokenRequest) GetRefreshToken() string {
+	if m != nil {
+		return m.RefreshToken
+	}
+	return ""
+}
+
+type MFALogin struct {
+	MfaToken string `protobuf:"bytes,1,opt,name=mfaToken" json:"mfaToken,omitempty"`
+	Code     string `protobuf:"bytes,2,opt,name=code" json:"code,omitempty"`
+}
+
+func (m *MFALogin) Reset()                    { *m = MFALogin{} }
+func (m *MFALogin) String() string            { return proto.CompactTextString(m) }
+func (*MFALogin) ProtoMessage()               {}
+func (*MFALogin) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
+
+func (m *MFALogin) GetMfaToken() string {
+	if m != nil {
+		return m.MfaToken
+	}
+	return ""
+}
+
+func (m *MFALogin) GetCode() string {
+	if m != nil {
+		return m.Code
+	}
+	return ""
+}
+
+type UserSocialAuth struct {
+	Provider     string `protobuf:"bytes,1,opt,name=provider" json:"provider,omitempty"`
+	AccessToken  string `protobuf:"bytes,2,opt,name=accessToken" json:"accessToken,omitempty"`
+	IdToken      string `protobuf:"bytes,3,opt,name=idToken" json:"idToken,omitempty"`
+	Code         string `protobuf:"bytes,4,opt,name=code" json:"code,omitempty"`
+	RedirectURI  string `protobuf:"bytes,5,opt,name=redirectURI" json:"redirectURI,omitempty"`
+	CodeVerifier string `protobuf:"bytes,6,opt,name=codeVerifier" json:"codeVerifier,omitempty"`
+	Nonce        string `protobuf:"bytes,7,opt,name=nonce" json:"nonce,omitempty"`
+	Subject      string `protobuf:"bytes,8,opt,name=subject" json:"subject,omitempty"`
+}
+
+func (m *UserSocialAuth) Reset()                    { *m = UserSocialAuth{} }
+func (m *UserSocialAuth) String() string            { return proto.CompactTextString(m) }
+func (*UserSocialAuth) ProtoMessage()               {}
+func (*UserSocialAuth) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{5} }
+
+func (m *UserSocialAuth) GetProvider() string {
+	if m != nil {
+		return m.Provider
+	}
+	return ""
+}
+
+func (m *UserSocialAuth) GetAccessToken() string {
+	if m != nil {
+		return m.AccessToken
+	}
+	return ""
+}
+
+func (m *UserSocialAuth) GetIdToken() string {
+	if m != nil {
+		return m.IdToken
+	}
+	return ""
+}
+
+func (m *UserSocialAuth) GetCode() string {
+	if m != nil {
+		return m.Code
+	}
+	return ""
+}
+
+func (m *UserSocialAuth) GetRedirectURI() string {
+	if m != nil {
+		return m.RedirectURI
+	}
+	return ""
+}
+
+func (m *UserSocialAuth) GetCodeVerifier() string {
+	if m != nil {
+		return m.CodeVerifier
+	}
+	return ""
+}
+
+func (m *UserSocialAuth) GetNonce() string {
+	if m != nil {
+		return m.Nonce
+	}
+	return ""
+}
+
+func (m *UserSocialAuth) GetSubject() string {
+	if m != nil {
+		return m.Subject
+	}
+	return ""
+}
+
+type Role struct {
+	Name        string   `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	Description string   `protobuf:"bytes,2,opt,name=description" json:"description,omitempty"`
+	Permissions []string `protobuf:"bytes,3,rep,name=permissions" json:"permissions,omitempty"`
+}
+
+func (m *Role) Reset()                    { *m = Role{} }
+func (m *Role) String() string            { return proto.CompactTextString(m) }
+func (*Role) ProtoMessage()               {}
+func (*Role) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{6} }
+
+func (m *Role) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *Role) GetDescription() string {
+	if m != nil {
+		return m.Description
+	}
+	return ""
+}
+
+func (m *Role) GetPermissions() []string {
+	if m != nil {
+		return m.Permissions
+	}
+	return nil
+}
+
+type Roles struct {
+	Roles []*Role `protobuf:"bytes,1,rep,name=roles" json:"roles,omitempty"`
+}
+
+func (m *Roles) Reset()                    { *m = Roles{} }
+func (m *Roles) String() string            { return proto.CompactTextString(m) }
+func (*Roles) ProtoMessage()               {}
+func (*Roles) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
+
+func (m *Roles) GetRoles() []*Role {
+	if m != nil {
+		return m.Roles
+	}
+	return nil
+}
+
+type RoleName struct {
+	Name string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+}
+
+func (m *RoleName) Reset()                    { *m = RoleName{} }
+func (m *RoleName) String() string            { return proto.CompactTextString(m) }
+func (*RoleName) ProtoMessage()               {}
+func (*RoleName) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{8} }
+
+func (m *RoleName) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+type UserRole struct {
+	UserId string `protobuf:"bytes,1,opt,name=userId" json:"userId,omitempty"`
+	Role   string `protobuf:"bytes,2,opt,name=role" json:"role,omitempty"`
+}
+
+func (m *UserRole) Reset()                    { *m = UserRole{} }
+func (m *UserRole) String() string            { return proto.CompactTextString(m) }
+func (*UserRole) ProtoMessage()               {}
+func (*UserRole) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{9} }
+
+func (m *UserRole) GetUserId() string {
+	if m != nil {
+		return m.UserId
+	}
+	return ""
+}
+
+func (m *UserRole) GetRole() string {
+	if m != nil {
+		return m.Role
+	}
+	return ""
+}
+
+type PermissionCheck struct {
+	UserId     string `protobuf:"bytes,1,opt,name=userId" json:"userId,omitempty"`
+	Permission string `protobuf:"bytes,2,opt,name=permission" json:"permission,omitempty"`
+}
+
+func (m *PermissionCheck) Reset()                    { *m = PermissionCheck{} }
+func (m *PermissionCheck) String() string            { return proto.CompactTextString(m) }
+func (*PermissionCheck) ProtoMessage()               {}
+func (*PermissionCheck) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{10} }
+
+func (m *PermissionCheck) GetUserId() string {
+	if m != nil {
+		return m.UserId
+	}
+	return ""
+}
+
+func (m *PermissionCheck) GetPermission() string {
+	if m != nil {
+		return m.Permission
+	}
+	return ""
+}
+
+type PermissionCheckResult struct {
+	Allowed bool `protobuf:"varint,1,opt,name=allowed" json:"allowed,omitempty"`
+}
+
+func (m *PermissionCheckResult) Reset()                    { *m = PermissionCheckResult{} }
+func (m *PermissionCheckResult) String() string            { return proto.CompactTextString(m) }
+func (*PermissionCheckResult) ProtoMessage()               {}
+func (*PermissionCheckResult) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{11} }
+
+func (m *PermissionCheckResult) GetAllowed() bool {
+	if m != nil {
+		return m.Allowed
+	}
+	return false
+}
+
+type PasswordChange struct {
+	OldPassword string `protobuf:"bytes,1,opt,name=oldPassword" json:"oldPassword,omitempty"`
+	NewPassword string `protobuf:"bytes,2,opt,name=newPassword" json:"newPassword,omitempty"`
+}
+
+func (m *PasswordChange) Reset()                    { *m = PasswordChange{} }
+func (m *PasswordChange) String() string            { return proto.CompactTextString(m) }
+func (*PasswordChange) ProtoMessage()               {}
+func (*PasswordChange) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{12} }
+
+func (m *PasswordChange) GetOldPassword() string {
+	if m != nil {
+		return m.OldPassword
+	}
+	return ""
+}
+
+func (m *PasswordChange) GetNewPassword() string {
+	if m != nil {
+		return m.NewPassword
+	}
+	return ""
+}
+
+type PasswordForgot struct {
+	Email string `protobuf:"bytes,1,opt,name=email" json:"email,omitempty"`
+}
+
+func (m *PasswordForgot) Reset()                    { *m = PasswordForgot{} }
+func (m *PasswordForgot) String() string            { return proto.CompactTextString(m) }
+func (*PasswordForgot) ProtoMessage()               {}
+func (*PasswordForgot) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{13} }
+
+func (m *PasswordForgot) GetEmail() string {
+	if m != nil {
+		return m.Email
+	}
+	return ""
+}
+
+type PasswordReset struct {
+	Email       string `protobuf:"bytes,1,opt,name=email" json:"email,omitempty"`
+	Token       string `protobuf:"bytes,2,opt,name=token" json:"token,omitempty"`
+	NewPassword string `protobuf:"bytes,3,opt,name=newPassword" json:"newPassword,omitempty"`
+}
+
+func (m *PasswordReset) Reset()                    { *m = PasswordReset{} }
+func (m *PasswordReset) String() string            { return proto.CompactTextString(m) }
+func (*PasswordReset) ProtoMessage()               {}
+func (*PasswordReset) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{14} }
+
+func (m *PasswordReset) GetEmail() string {
+	if m != nil {
+		return m.Email
+	}
+	return ""
+}
+
+func (m *PasswordReset) GetToken() string {
+	if m != nil {
+		return m.Token
+	}
+	return ""
+}
+
+func (m *PasswordReset) GetNewPassword() string {
+	if m != nil {
+		return m.NewPassword
+	}
+	return ""
+}
+
+type EmailVerification struct {
+	Email string `protobuf:"bytes,1,opt,name=email" json:"email,omitempty"`
+	Token string `protobuf:"bytes,2,opt,name=token" json:"token,omitempty"`
+}
+
+func (m *EmailVerification) Reset()                    { *m = EmailVerification{} }
+func (m *EmailVerification) String() string            { return proto.CompactTextString(m) }
+func (*EmailVerification) ProtoMessage()               {}
+func (*EmailVerification) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{15} }
+
+func (m *EmailVerification) GetEmail() string {
+	if m != nil {
+		return m.Email
+	}
+	return ""
+}
+
+func (m *EmailVerification) GetToken() string {
+	if m != nil {
+		return m.Token
+	}
+	return ""
+}
+
+type InviteCodes struct {
+	InviteCodes []string `protobuf:"bytes,1,rep,name=inviteCodes" json:"inviteCodes,omitempty"`
+}
+
+func (m *InviteCodes) Reset()                    { *m = InviteCodes{} }
+func (m *InviteCodes) String() string            { return proto.CompactTextString(m) }
+func (*InviteCodes) ProtoMessage()               {}
+func (*InviteCodes) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{16} }
+
+func (m *InviteCodes) GetInviteCodes() []string {
+	if m != nil {
+		return m.InviteCodes
+	}
+	return nil
+}
+
+type ExistsCheck struct {
+	Email    string `protobuf:"bytes,1,opt,name=email" json:"email,omitempty"`
+	UserName string `protobuf:"bytes,2,opt,name=userName" json:"userName,omitempty"`
+}
+
+func (m *ExistsCheck) Reset()                    { *m = ExistsCheck{} }
+func (m *ExistsCheck) String() string            { return proto.CompactTextString(m) }
+func (*ExistsCheck) ProtoMessage()               {}
+func (*ExistsCheck) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{17} }
+
+func (m *ExistsCheck) GetEmail() string {
+	if m != nil {
+		return m.Email
+	}
+	return ""
+}
+
+func (m *ExistsCheck) GetUserName() string {
+	if m != nil {
+		return m.UserName
+	}
+	return ""
+}
+
+type ExistsResult struct {
+	Exists bool `protobuf:"varint,1,opt,name=exists" json:"exists,omitempty"`
+}
+
+func (m *ExistsResult) Reset()                    { *m = ExistsResult{} }
+func (m *ExistsResult) String() string            { return proto.CompactTextString(m) }
+func (*ExistsResult) ProtoMessage()               {}
+func (*ExistsResult) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{18} }
+
+func (m *ExistsResult) GetExists() bool {
+	if m != nil {
+		return m.Exists
+	}
+	return false
+}
+
 func init() {
 	proto.RegisterType((*UserID)(nil), "protobuf.UserID")
 	proto.RegisterType((*UserIDs)(nil), "protobuf.UserIDs")
 	proto.RegisterType((*InvitationCode)(nil), "protobuf.InvitationCode")
+	proto.RegisterType((*RefreshTokenRequest)(nil), "protobuf.RefreshTokenRequest")
+	proto.RegisterType((*MFALogin)(nil), "protobuf.MFALogin")
+	proto.RegisterType((*UserSocialAuth)(nil), "protobuf.UserSocialAuth")
+	proto.RegisterType((*Role)(nil), "protobuf.Role")
+	proto.RegisterType((*Roles)(nil), "protobuf.Roles")
+	proto.RegisterType((*RoleName)(nil), "protobuf.RoleName")
+	proto.RegisterType((*UserRole)(nil), "protobuf.UserRole")
+	proto.RegisterType((*PermissionCheck)(nil), "protobuf.PermissionCheck")
+	proto.RegisterType((*PermissionCheckResult)(nil), "protobuf.PermissionCheckResult")
+	proto.RegisterType((*PasswordChange)(nil), "protobuf.PasswordChange")
+	proto.RegisterType((*PasswordForgot)(nil), "protobuf.PasswordForgot")
+	proto.RegisterType((*PasswordReset)(nil), "protobuf.PasswordReset")
+	proto.RegisterType((*EmailVerification)(nil), "protobuf.EmailVerification")
+	proto.RegisterType((*InviteCodes)(nil), "protobuf.InviteCodes")
+	proto.RegisterType((*ExistsCheck)(nil), "protobuf.ExistsCheck")
+	proto.RegisterType((*ExistsResult)(nil), "protobuf.ExistsResult")
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -123,6 +550,24 @@ type AuthClient interface {
 	AuthUserByFacebook(ctx context.Context, in *UserFacebookAuth, opts ...grpc.CallOption) (*User, error)
 	UpdateUserEmail(ctx context.Context, in *UserEmailAuth, opts ...grpc.CallOption) (*User, error)
 	UpdateUserName(ctx context.Context, in *UserEmailAuth, opts ...grpc.CallOption) (*User, error)
+	RefreshToken(ctx context.Context, in *RefreshTokenRequest, opts ...grpc.CallOption) (*User, error)
+	AuthUserByMFA(ctx context.Context, in *MFALogin, opts ...grpc.CallOption) (*User, error)
+	AuthUserBySocial(ctx context.Context, in *UserSocialAuth, opts ...grpc.CallOption) (*User, error)
+	CheckPermission(ctx context.Context, in *PermissionCheck, opts ...grpc.CallOption) (*PermissionCheckResult, error)
+	ListRoles(ctx context.Context, in *google_protobuf.Empty, opts ...grpc.CallOption) (*Roles, error)
+	SaveRole(ctx context.Context, in *Role, opts ...grpc.CallOption) (*Role, error)
+	DeleteRole(ctx context.Context, in *RoleName, opts ...grpc.CallOption) (*google_protobuf.Empty, error)
+	GetUserRoles(ctx context.Context, in *UserID, opts ...grpc.CallOption) (*Roles, error)
+	AssignRole(ctx context.Context, in *UserRole, opts ...grpc.CallOption) (*google_protobuf.Empty, error)
+	UnassignRole(ctx context.Context, in *UserRole, opts ...grpc.CallOption) (*google_protobuf.Empty, error)
+	ChangePassword(ctx context.Context, in *PasswordChange, opts ...grpc.CallOption) (*User, error)
+	ForgotPassword(ctx context.Context, in *PasswordForgot, opts ...grpc.CallOption) (*google_protobuf.Empty, error)
+	ResetPassword(ctx context.Context, in *PasswordReset, opts ...grpc.CallOption) (*User, error)
+	VerifyEmail(ctx context.Context, in *EmailVerification, opts ...grpc.CallOption) (*User, error)
+	CreateEmailInvitations(ctx context.Context, in *InviteCodes, opts ...grpc.CallOption) (*UsersPublic, error)
+	CreateFacebookInvitations(ctx context.Context, in *InviteCodes, opts ...grpc.CallOption) (*UsersPublic, error)
+	Exists(ctx context.Context, in *ExistsCheck, opts ...grpc.CallOption) (*ExistsResult, error)
+	LinkFacebook(ctx context.Context, in *UserFacebookAuth, opts ...grpc.CallOption) (*User, error)
 }
 
 type authClient struct {
@@ -133,263 +578,767 @@ func NewAuthClient(cc *grpc.ClientConn) AuthClient {
 	return &authClient{cc}
 }
 
-func (c *authClient) GetCurrentUser(ctx context.Context, in *google_protobuf.Empty, opts ...grpc.CallOption) (*User, error) {
-	out := new(User)
-	err := grpc.Invoke(ctx, "/protobuf.Auth/GetCurrentUser", in, out, c.cc, opts...)
-	if err != nil {
+func (c *authClient) GetCurrentUser(ctx context.Context, in *google_protobuf.Empty, opts ...grpc.CallOption) (*User, error) {
+	out := new(User)
+	err := grpc.Invoke(ctx, "/protobuf.Auth/GetCurrentUser", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authClient) GetUserByID(ctx context.Context, in *UserID, opts ...grpc.CallOption) (*UserPublic, error) {
+	out := new(UserPublic)
+	err := grpc.Invoke(ctx, "/protobuf.Auth/GetUserByID", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authClient) LinkUser(ctx context.Context, in *InvitationCode, opts ...grpc.CallOption) (*UserPublic, error) {
+	out := new(UserPublic)
+	err := grpc.Invoke(ctx, "/protobuf.Auth/LinkUser", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authClient) GetUsersByIDs(ctx context.Context, in *UserIDs, opts ...grpc.CallOption) (*UsersPublic, error) {
+	out := new(UsersPublic)
+	err := grpc.Invoke(ctx, "/protobuf.Auth/GetUsersByIDs", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authClient) GetUsersByFacebookIDs(ctx context.Context, in *UserIDs, opts ...grpc.CallOption) (*UsersPublic, error) {
+	out := new(UsersPublic)
+	err := grpc.Invoke(ctx, "/protobuf.Auth/GetUsersByFacebookIDs", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authClient) AuthUserByEmail(ctx context.Context, in *UserEmailAuth, opts ...grpc.CallOption) (*User, error) {
+	out := new(User)
+	err := grpc.Invoke(ctx, "/protobuf.Auth/AuthUserByEmail", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authClient) AuthUserByFacebook(ctx context.Context, in *UserFacebookAuth, opts ...grpc.CallOption) (*User, error) {
+	out := new(User)
+	err := grpc.Invoke(ctx, "/protobuf.Auth/AuthUserByFacebook", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authClient) UpdateUserEmail(ctx context.Context, in *UserEmailAuth, opts ...grpc.CallOption) (*User, error) {
+	out := new(User)
+	err := grpc.Invoke(ctx, "/protobuf.Auth/UpdateUserEmail", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authClient) UpdateUserName(ctx context.Context, in *UserEmailAuth, opts ...grpc.CallOption) (*User, error) {
+	out := new(User)
+	err := grpc.Invoke(ctx, "/protobuf.Auth/UpdateUserName", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authClient) RefreshToken(ctx context.Context, in *RefreshTokenRequest, opts ...grpc.CallOption) (*User, error) {
+	out := new(User)
+	err := grpc.Invoke(ctx, "/protobuf.Auth/RefreshToken", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authClient) AuthUserByMFA(ctx context.Context, in *MFALogin, opts ...grpc.CallOption) (*User, error) {
+	out := new(User)
+	err := grpc.Invoke(ctx, "/protobuf.Auth/AuthUserByMFA", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authClient) AuthUserBySocial(ctx context.Context, in *UserSocialAuth, opts ...grpc.CallOption) (*User, error) {
+	out := new(User)
+	err := grpc.Invoke(ctx, "/protobuf.Auth/AuthUserBySocial", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authClient) CheckPermission(ctx context.Context, in *PermissionCheck, opts ...grpc.CallOption) (*PermissionCheckResult, error) {
+	out := new(PermissionCheckResult)
+	err := grpc.Invoke(ctx, "/protobuf.Auth/CheckPermission", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authClient) ListRoles(ctx context.Context, in *google_protobuf.Empty, opts ...grpc.CallOption) (*Roles, error) {
+	out := new(Roles)
+	err := grpc.Invoke(ctx, "/protobuf.Auth/ListRoles", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authClient) SaveRole(ctx context.Context, in *Role, opts ...grpc.CallOption) (*Role, error) {
+	out := new(Role)
+	err := grpc.Invoke(ctx, "/protobuf.Auth/SaveRole", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authClient) DeleteRole(ctx context.Context, in *RoleName, opts ...grpc.CallOption) (*google_protobuf.Empty, error) {
+	out := new(google_protobuf.Empty)
+	err := grpc.Invoke(ctx, "/protobuf.Auth/DeleteRole", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authClient) GetUserRoles(ctx context.Context, in *UserID, opts ...grpc.CallOption) (*Roles, error) {
+	out := new(Roles)
+	err := grpc.Invoke(ctx, "/protobuf.Auth/GetUserRoles", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authClient) AssignRole(ctx context.Context, in *UserRole, opts ...grpc.CallOption) (*google_protobuf.Empty, error) {
+	out := new(google_protobuf.Empty)
+	err := grpc.Invoke(ctx, "/protobuf.Auth/AssignRole", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authClient) UnassignRole(ctx context.Context, in *UserRole, opts ...grpc.CallOption) (*google_protobuf.Empty, error) {
+	out := new(google_protobuf.Empty)
+	err := grpc.Invoke(ctx, "/protobuf.Auth/UnassignRole", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authClient) ChangePassword(ctx context.Context, in *PasswordChange, opts ...grpc.CallOption) (*User, error) {
+	out := new(User)
+	err := grpc.Invoke(ctx, "/protobuf.Auth/ChangePassword", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authClient) ForgotPassword(ctx context.Context, in *PasswordForgot, opts ...grpc.CallOption) (*google_protobuf.Empty, error) {
+	out := new(google_protobuf.Empty)
+	err := grpc.Invoke(ctx, "/protobuf.Auth/ForgotPassword", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authClient) ResetPassword(ctx context.Context, in *PasswordReset, opts ...grpc.CallOption) (*User, error) {
+	out := new(User)
+	err := grpc.Invoke(ctx, "/protobuf.Auth/ResetPassword", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authClient) VerifyEmail(ctx context.Context, in *EmailVerification, opts ...grpc.CallOption) (*User, error) {
+	out := new(User)
+	err := grpc.Invoke(ctx, "/protobuf.Auth/VerifyEmail", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authClient) CreateEmailInvitations(ctx context.Context, in *InviteCodes, opts ...grpc.CallOption) (*UsersPublic, error) {
+	out := new(UsersPublic)
+	err := grpc.Invoke(ctx, "/protobuf.Auth/CreateEmailInvitations", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authClient) CreateFacebookInvitations(ctx context.Context, in *InviteCodes, opts ...grpc.CallOption) (*UsersPublic, error) {
+	out := new(UsersPublic)
+	err := grpc.Invoke(ctx, "/protobuf.Auth/CreateFacebookInvitations", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authClient) Exists(ctx context.Context, in *ExistsCheck, opts ...grpc.CallOption) (*ExistsResult, error) {
+	out := new(ExistsResult)
+	err := grpc.Invoke(ctx, "/protobuf.Auth/Exists", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authClient) LinkFacebook(ctx context.Context, in *UserFacebookAuth, opts ...grpc.CallOption) (*User, error) {
+	out := new(User)
+	err := grpc.Invoke(ctx, "/protobuf.Auth/LinkFacebook", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Server API for Auth service
+
+type AuthServer interface {
+	GetCurrentUser(context.Context, *google_protobuf.Empty) (*User, error)
+	GetUserByID(context.Context, *UserID) (*UserPublic, error)
+	LinkUser(context.Context, *InvitationCode) (*UserPublic, error)
+	GetUsersByIDs(context.Context, *UserIDs) (*UsersPublic, error)
+	GetUsersByFacebookIDs(context.Context, *UserIDs) (*UsersPublic, error)
+	AuthUserByEmail(context.Context, *UserEmailAuth) (*User, error)
+	AuthUserByFacebook(context.Context, *UserFacebookAuth) (*User, error)
+	UpdateUserEmail(context.Context, *UserEmailAuth) (*User, error)
+	UpdateUserName(context.Context, *UserEmailAuth) (*User, error)
+	RefreshToken(context.Context, *RefreshTokenRequest) (*User, error)
+	AuthUserByMFA(context.Context, *MFALogin) (*User, error)
+	AuthUserBySocial(context.Context, *UserSocialAuth) (*User, error)
+	CheckPermission(context.Context, *PermissionCheck) (*PermissionCheckResult, error)
+	ListRoles(context.Context, *google_protobuf.Empty) (*Roles, error)
+	SaveRole(context.Context, *Role) (*Role, error)
+	DeleteRole(context.Context, *RoleName) (*google_protobuf.Empty, error)
+	GetUserRoles(context.Context, *UserID) (*Roles, error)
+	AssignRole(context.Context, *UserRole) (*google_protobuf.Empty, error)
+	UnassignRole(context.Context, *UserRole) (*google_protobuf.Empty, error)
+	ChangePassword(context.Context, *PasswordChange) (*User, error)
+	ForgotPassword(context.Context, *PasswordForgot) (*google_protobuf.Empty, error)
+	ResetPassword(context.Context, *PasswordReset) (*User, error)
+	VerifyEmail(context.Context, *EmailVerification) (*User, error)
+	CreateEmailInvitations(context.Context, *InviteCodes) (*UsersPublic, error)
+	CreateFacebookInvitations(context.Context, *InviteCodes) (*UsersPublic, error)
+	Exists(context.Context, *ExistsCheck) (*ExistsResult, error)
+	LinkFacebook(context.Context, *UserFacebookAuth) (*User, error)
+}
+
+func RegisterAuthServer(s *grpc.Server, srv AuthServer) {
+	s.RegisterService(&_Auth_serviceDesc, srv)
+}
+
+func _Auth_GetCurrentUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(google_protobuf.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthServer).GetCurrentUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protobuf.Auth/GetCurrentUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthServer).GetCurrentUser(ctx, req.(*google_protobuf.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Auth_GetUserByID_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UserID)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthServer).GetUserByID(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protobuf.Auth/GetUserByID",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthServer).GetUserByID(ctx, req.(*UserID))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Auth_LinkUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InvitationCode)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthServer).LinkUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protobuf.Auth/LinkUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthServer).LinkUser(ctx, req.(*InvitationCode))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Auth_GetUsersByIDs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UserIDs)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthServer).GetUsersByIDs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protobuf.Auth/GetUsersByIDs",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthServer).GetUsersByIDs(ctx, req.(*UserIDs))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Auth_GetUsersByFacebookIDs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UserIDs)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthServer).GetUsersByFacebookIDs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protobuf.Auth/GetUsersByFacebookIDs",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthServer).GetUsersByFacebookIDs(ctx, req.(*UserIDs))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Auth_AuthUserByEmail_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UserEmailAuth)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthServer).AuthUserByEmail(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protobuf.Auth/AuthUserByEmail",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthServer).AuthUserByEmail(ctx, req.(*UserEmailAuth))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Auth_AuthUserByFacebook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UserFacebookAuth)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthServer).AuthUserByFacebook(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protobuf.Auth/AuthUserByFacebook",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthServer).AuthUserByFacebook(ctx, req.(*UserFacebookAuth))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Auth_UpdateUserEmail_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UserEmailAuth)
+	if err := dec(in); err != nil {
 		return nil, err
 	}
-	return out, nil
+	if interceptor == nil {
+		return srv.(AuthServer).UpdateUserEmail(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protobuf.Auth/UpdateUserEmail",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthServer).UpdateUserEmail(ctx, req.(*UserEmailAuth))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func (c *authClient) GetUserByID(ctx context.Context, in *UserID, opts ...grpc.CallOption) (*UserPublic, error) {
-	out := new(UserPublic)
-	err := grpc.Invoke(ctx, "/protobuf.Auth/GetUserByID", in, out, c.cc, opts...)
-	if err != nil {
+func _Auth_UpdateUserName_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UserEmailAuth)
+	if err := dec(in); err != nil {
 		return nil, err
 	}
-	return out, nil
+	if interceptor == nil {
+		return srv.(AuthServer).UpdateUserName(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protobuf.Auth/UpdateUserName",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthServer).UpdateUserName(ctx, req.(*UserEmailAuth))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func (c *authClient) LinkUser(ctx context.Context, in *InvitationCode, opts ...grpc.CallOption) (*UserPublic, error) {
-	out := new(UserPublic)
-	err := grpc.Invoke(ctx, "/protobuf.Auth/LinkUser", in, out, c.cc, opts...)
-	if err != nil {
+func _Auth_RefreshToken_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RefreshTokenRequest)
+	if err := dec(in); err != nil {
 		return nil, err
 	}
-	return out, nil
+	if interceptor == nil {
+		return srv.(AuthServer).RefreshToken(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protobuf.Auth/RefreshToken",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthServer).RefreshToken(ctx, req.(*RefreshTokenRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func (c *authClient) GetUsersByIDs(ctx context.Context, in *UserIDs, opts ...grpc.CallOption) (*UsersPublic, error) {
-	out := new(UsersPublic)
-	err := grpc.Invoke(ctx, "/protobuf.Auth/GetUsersByIDs", in, out, c.cc, opts...)
-	if err != nil {
+func _Auth_AuthUserByMFA_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MFALogin)
+	if err := dec(in); err != nil {
 		return nil, err
 	}
-	return out, nil
+	if interceptor == nil {
+		return srv.(AuthServer).AuthUserByMFA(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protobuf.Auth/AuthUserByMFA",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthServer).AuthUserByMFA(ctx, req.(*MFALogin))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func (c *authClient) GetUsersByFacebookIDs(ctx context.Context, in *UserIDs, opts ...grpc.CallOption) (*UsersPublic, error) {
-	out := new(UsersPublic)
-	err := grpc.Invoke(ctx, "/protobuf.Auth/GetUsersByFacebookIDs", in, out, c.cc, opts...)
-	if err != nil {
+func _Auth_AuthUserBySocial_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UserSocialAuth)
+	if err := dec(in); err != nil {
 		return nil, err
 	}
-	return out, nil
+	if interceptor == nil {
+		return srv.(AuthServer).AuthUserBySocial(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protobuf.Auth/AuthUserBySocial",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthServer).AuthUserBySocial(ctx, req.(*UserSocialAuth))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func (c *authClient) AuthUserByEmail(ctx context.Context, in *UserEmailAuth, opts ...grpc.CallOption) (*User, error) {
-	out := new(User)
-	err := grpc.Invoke(ctx, "/protobuf.Auth/AuthUserByEmail", in, out, c.cc, opts...)
-	if err != nil {
+func _Auth_CheckPermission_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PermissionCheck)
+	if err := dec(in); err != nil {
 		return nil, err
 	}
-	return out, nil
+	if interceptor == nil {
+		return srv.(AuthServer).CheckPermission(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protobuf.Auth/CheckPermission",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthServer).CheckPermission(ctx, req.(*PermissionCheck))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func (c *authClient) AuthUserByFacebook(ctx context.Context, in *UserFacebookAuth, opts ...grpc.CallOption) (*User, error) {
-	out := new(User)
-	err := grpc.Invoke(ctx, "/protobuf.Auth/AuthUserByFacebook", in, out, c.cc, opts...)
-	if err != nil {
+func _Auth_ListRoles_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(google_protobuf.Empty)
+	if err := dec(in); err != nil {
 		return nil, err
 	}
-	return out, nil
+	if interceptor == nil {
+		return srv.(AuthServer).ListRoles(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protobuf.Auth/ListRoles",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthServer).ListRoles(ctx, req.(*google_protobuf.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func (c *authClient) UpdateUserEmail(ctx context.Context, in *UserEmailAuth, opts ...grpc.CallOption) (*User, error) {
-	out := new(User)
-	err := grpc.Invoke(ctx, "/protobuf.Auth/UpdateUserEmail", in, out, c.cc, opts...)
-	if err != nil {
+func _Auth_SaveRole_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Role)
+	if err := dec(in); err != nil {
 		return nil, err
 	}
-	return out, nil
+	if interceptor == nil {
+		return srv.(AuthServer).SaveRole(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protobuf.Auth/SaveRole",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthServer).SaveRole(ctx, req.(*Role))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func (c *authClient) UpdateUserName(ctx context.Context, in *UserEmailAuth, opts ...grpc.CallOption) (*User, error) {
-	out := new(User)
-	err := grpc.Invoke(ctx, "/protobuf.Auth/UpdateUserName", in, out, c.cc, opts...)
-	if err != nil {
+func _Auth_DeleteRole_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RoleName)
+	if err := dec(in); err != nil {
 		return nil, err
 	}
-	return out, nil
+	if interceptor == nil {
+		return srv.(AuthServer).DeleteRole(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protobuf.Auth/DeleteRole",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthServer).DeleteRole(ctx, req.(*RoleName))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-// Server API for Auth service
-
-type AuthServer interface {
-	GetCurrentUser(context.Context, *google_protobuf.Empty) (*User, error)
-	GetUserByID(context.Context, *UserID) (*UserPublic, error)
-	LinkUser(context.Context, *InvitationCode) (*UserPublic, error)
-	GetUsersByIDs(context.Context, *UserIDs) (*UsersPublic, error)
-	GetUsersByFacebookIDs(context.Context, *UserIDs) (*UsersPublic, error)
-	AuthUserByEmail(context.Context, *UserEmailAuth) (*User, error)
-	AuthUserByFacebook(context.Context, *UserFacebookAuth) (*User, error)
-	UpdateUserEmail(context.Context, *UserEmailAuth) (*User, error)
-	UpdateUserName(context.Context, *UserEmailAuth) (*User, error)
+func _Auth_GetUserRoles_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UserID)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthServer).GetUserRoles(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protobuf.Auth/GetUserRoles",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthServer).GetUserRoles(ctx, req.(*UserID))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func RegisterAuthServer(s *grpc.Server, srv AuthServer) {
-	s.RegisterService(&_Auth_serviceDesc, srv)
+func _Auth_AssignRole_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UserRole)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthServer).AssignRole(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protobuf.Auth/AssignRole",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthServer).AssignRole(ctx, req.(*UserRole))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func _Auth_GetCurrentUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(google_protobuf.Empty)
+func _Auth_UnassignRole_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UserRole)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(AuthServer).GetCurrentUser(ctx, in)
+		return srv.(AuthServer).UnassignRole(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/protobuf.Auth/GetCurrentUser",
+		FullMethod: "/protobuf.Auth/UnassignRole",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(AuthServer).GetCurrentUser(ctx, req.(*google_protobuf.Empty))
+		return srv.(AuthServer).UnassignRole(ctx, req.(*UserRole))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Auth_GetUserByID_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(UserID)
+func _Auth_ChangePassword_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PasswordChange)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(AuthServer).GetUserByID(ctx, in)
+		return srv.(AuthServer).ChangePassword(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/protobuf.Auth/GetUserByID",
+		FullMethod: "/protobuf.Auth/ChangePassword",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(AuthServer).GetUserByID(ctx, req.(*UserID))
+		return srv.(AuthServer).ChangePassword(ctx, req.(*PasswordChange))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Auth_LinkUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(InvitationCode)
+func _Auth_ForgotPassword_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PasswordForgot)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(AuthServer).LinkUser(ctx, in)
+		return srv.(AuthServer).ForgotPassword(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/protobuf.Auth/LinkUser",
+		FullMethod: "/protobuf.Auth/ForgotPassword",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(AuthServer).LinkUser(ctx, req.(*InvitationCode))
+		return srv.(AuthServer).ForgotPassword(ctx, req.(*PasswordForgot))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Auth_GetUsersByIDs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(UserIDs)
+func _Auth_ResetPassword_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PasswordReset)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(AuthServer).GetUsersByIDs(ctx, in)
+		return srv.(AuthServer).ResetPassword(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/protobuf.Auth/GetUsersByIDs",
+		FullMethod: "/protobuf.Auth/ResetPassword",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(AuthServer).GetUsersByIDs(ctx, req.(*UserIDs))
+		return srv.(AuthServer).ResetPassword(ctx, req.(*PasswordReset))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Auth_GetUsersByFacebookIDs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(UserIDs)
+func _Auth_VerifyEmail_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EmailVerification)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(AuthServer).GetUsersByFacebookIDs(ctx, in)
+		return srv.(AuthServer).VerifyEmail(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/protobuf.Auth/GetUsersByFacebookIDs",
+		FullMethod: "/protobuf.Auth/VerifyEmail",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(AuthServer).GetUsersByFacebookIDs(ctx, req.(*UserIDs))
+		return srv.(AuthServer).VerifyEmail(ctx, req.(*EmailVerification))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Auth_AuthUserByEmail_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(UserEmailAuth)
+func _Auth_CreateEmailInvitations_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InviteCodes)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(AuthServer).AuthUserByEmail(ctx, in)
+		return srv.(AuthServer).CreateEmailInvitations(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/protobuf.Auth/AuthUserByEmail",
+		FullMethod: "/protobuf.Auth/CreateEmailInvitations",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(AuthServer).AuthUserByEmail(ctx, req.(*UserEmailAuth))
+		return srv.(AuthServer).CreateEmailInvitations(ctx, req.(*InviteCodes))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Auth_AuthUserByFacebook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(UserFacebookAuth)
+func _Auth_CreateFacebookInvitations_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InviteCodes)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(AuthServer).AuthUserByFacebook(ctx, in)
+		return srv.(AuthServer).CreateFacebookInvitations(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/protobuf.Auth/AuthUserByFacebook",
+		FullMethod: "/protobuf.Auth/CreateFacebookInvitations",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(AuthServer).AuthUserByFacebook(ctx, req.(*UserFacebookAuth))
+		return srv.(AuthServer).CreateFacebookInvitations(ctx, req.(*InviteCodes))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Auth_UpdateUserEmail_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(UserEmailAuth)
+func _Auth_Exists_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExistsCheck)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(AuthServer).UpdateUserEmail(ctx, in)
+		return srv.(AuthServer).Exists(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/protobuf.Auth/UpdateUserEmail",
+		FullMethod: "/protobuf.Auth/Exists",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(AuthServer).UpdateUserEmail(ctx, req.(*UserEmailAuth))
+		return srv.(AuthServer).Exists(ctx, req.(*ExistsCheck))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Auth_UpdateUserName_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(UserEmailAuth)
+func _Auth_LinkFacebook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UserFacebookAuth)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(AuthServer).UpdateUserName(ctx, in)
+		return srv.(AuthServer).LinkFacebook(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/protobuf.Auth/UpdateUserName",
+		FullMethod: "/protobuf.Auth/LinkFacebook",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(AuthServer).UpdateUserName(ctx, req.(*UserEmailAuth))
+		return srv.(AuthServer).LinkFacebook(ctx, req.(*UserFacebookAuth))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -434,6 +1383,78 @@ var _Auth_serviceDesc = grpc.ServiceDesc{
 			MethodName: "UpdateUserName",
 			Handler:    _Auth_UpdateUserName_Handler,
 		},
+		{
+			MethodName: "RefreshToken",
+			Handler:    _Auth_RefreshToken_Handler,
+		},
+		{
+			MethodName: "AuthUserByMFA",
+			Handler:    _Auth_AuthUserByMFA_Handler,
+		},
+		{
+			MethodName: "AuthUserBySocial",
+			Handler:    _Auth_AuthUserBySocial_Handler,
+		},
+		{
+			MethodName: "CheckPermission",
+			Handler:    _Auth_CheckPermission_Handler,
+		},
+		{
+			MethodName: "ListRoles",
+			Handler:    _Auth_ListRoles_Handler,
+		},
+		{
+			MethodName: "SaveRole",
+			Handler:    _Auth_SaveRole_Handler,
+		},
+		{
+			MethodName: "DeleteRole",
+			Handler:    _Auth_DeleteRole_Handler,
+		},
+		{
+			MethodName: "GetUserRoles",
+			Handler:    _Auth_GetUserRoles_Handler,
+		},
+		{
+			MethodName: "AssignRole",
+			Handler:    _Auth_AssignRole_Handler,
+		},
+		{
+			MethodName: "UnassignRole",
+			Handler:    _Auth_UnassignRole_Handler,
+		},
+		{
+			MethodName: "ChangePassword",
+			Handler:    _Auth_ChangePassword_Handler,
+		},
+		{
+			MethodName: "ForgotPassword",
+			Handler:    _Auth_ForgotPassword_Handler,
+		},
+		{
+			MethodName: "ResetPassword",
+			Handler:    _Auth_ResetPassword_Handler,
+		},
+		{
+			MethodName: "VerifyEmail",
+			Handler:    _Auth_VerifyEmail_Handler,
+		},
+		{
+			MethodName: "CreateEmailInvitations",
+			Handler:    _Auth_CreateEmailInvitations_Handler,
+		},
+		{
+			MethodName: "CreateFacebookInvitations",
+			Handler:    _Auth_CreateFacebookInvitations_Handler,
+		},
+		{
+			MethodName: "Exists",
+			Handler:    _Auth_Exists_Handler,
+		},
+		{
+			MethodName: "LinkFacebook",
+			Handler:    _Auth_LinkFacebook_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "auth.proto",
@@ -442,27 +1463,70 @@ var _Auth_serviceDesc = grpc.ServiceDesc{
 func init() { proto.RegisterFile("auth.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 344 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x90, 0x4f, 0x6b, 0xc2, 0x40,
-	0x10, 0xc5, 0xfd, 0x87, 0xd5, 0x29, 0x8d, 0x76, 0xa8, 0x6d, 0x88, 0x50, 0x24, 0x27, 0x4f, 0x11,
-	0xda, 0x43, 0xa1, 0x7a, 0xa9, 0xc6, 0x4a, 0xa0, 0x94, 0x22, 0xf8, 0x01, 0x12, 0x33, 0xd5, 0x45,
-	0xcd, 0x86, 0xec, 0xa6, 0xe0, 0x37, 0xeb, 0xc7, 0x2b, 0x9b, 0x18, 0xd3, 0x94, 0x7a, 0xf0, 0xb4,
-	0xc3, 0xdb, 0xdf, 0x9b, 0xb7, 0x6f, 0x01, 0xdc, 0x58, 0xae, 0xad, 0x30, 0xe2, 0x92, 0x63, 0x23,
-	0x39, 0xbc, 0xf8, 0xd3, 0xe8, 0xae, 0x38, 0x5f, 0x6d, 0x69, 0x90, 0x09, 0x03, 0xda, 0x85, 0x72,
-	0x9f, 0x62, 0x06, 0xc4, 0x82, 0xa2, 0x74, 0x36, 0x75, 0xa8, 0x2f, 0x04, 0x45, 0x8e, 0x8d, 0x1a,
-	0x54, 0x98, 0xaf, 0x97, 0x7b, 0xe5, 0x7e, 0x73, 0x5e, 0x61, 0xbe, 0xd9, 0x85, 0x8b, 0xf4, 0x46,
-	0x60, 0x1b, 0xaa, 0xcc, 0x17, 0x7a, 0xb9, 0x57, 0xed, 0x37, 0xe7, 0x6a, 0x34, 0x6d, 0xd0, 0x9c,
-	0xe0, 0x8b, 0x49, 0x57, 0x32, 0x1e, 0x4c, 0xb8, 0x4f, 0x88, 0x50, 0x93, 0xfb, 0x90, 0x0e, 0x0b,
-	0x92, 0x19, 0xef, 0x01, 0x98, 0xa2, 0x48, 0x11, 0x7a, 0x25, 0xb9, 0xf9, 0xa5, 0x3c, 0x7c, 0xd7,
-	0xa0, 0xf6, 0x12, 0xcb, 0x35, 0x3e, 0x83, 0x36, 0x23, 0x39, 0x89, 0xa3, 0x88, 0x02, 0xa9, 0x52,
-	0xf1, 0xd6, 0x4a, 0x1b, 0x58, 0x59, 0x03, 0x6b, 0xaa, 0x1a, 0x18, 0x5a, 0x2e, 0x28, 0xce, 0x2c,
-	0xe1, 0x13, 0x5c, 0xce, 0x28, 0x31, 0x8d, 0xf7, 0x8e, 0x8d, 0xed, 0x22, 0xe0, 0xd8, 0xc6, 0x4d,
-	0x51, 0xf9, 0x88, 0xbd, 0x2d, 0x5b, 0x9a, 0x25, 0x1c, 0x41, 0xe3, 0x8d, 0x05, 0x9b, 0x24, 0x4e,
-	0xcf, 0x99, 0x62, 0xaf, 0x93, 0xee, 0x21, 0x5c, 0x1d, 0x62, 0x85, 0xca, 0x15, 0x78, 0xfd, 0x37,
-	0x58, 0x18, 0x9d, 0xa2, 0x24, 0x8e, 0xe6, 0x09, 0x74, 0x72, 0xf3, 0xab, 0xbb, 0x24, 0x8f, 0xf3,
-	0xcd, 0xb9, 0x4b, 0x46, 0xd0, 0x52, 0x9f, 0x97, 0x36, 0x9f, 0xee, 0x5c, 0xb6, 0xc5, 0xbb, 0x22,
-	0x9b, 0x88, 0x8a, 0xf9, 0xe7, 0xdb, 0xc6, 0x80, 0xb9, 0x3b, 0x7b, 0x02, 0x1a, 0x45, 0x2e, 0xd3,
-	0x4f, 0xec, 0x18, 0x41, 0x6b, 0x11, 0xfa, 0xae, 0xa4, 0x63, 0xd8, 0x39, 0x2f, 0x18, 0x82, 0x96,
-	0xbb, 0xdf, 0xdd, 0x1d, 0x9d, 0x61, 0xf6, 0xea, 0x89, 0xf0, 0xf8, 0x13, 0x00, 0x00, 0xff, 0xff,
-	0x2a, 0xa6, 0x37, 0xe4, 0xff, 0x02, 0x00, 0x00,
+	// 1036 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x55, 0xef, 0x4e, 0x1b, 0x47,
+	0x10, 0x37, 0x01, 0x8c, 0x19, 0x1b, 0x43, 0xb6, 0x81, 0x5c, 0x0e, 0x95, 0xa2, 0x55, 0x15, 0xe5,
+	0x43, 0x0b, 0x6a, 0xa2, 0xa6, 0x0a, 0x41, 0x45, 0xc4, 0x86, 0xd4, 0x2a, 0x69, 0x91, 0x13, 0xfa,
+	0xad, 0x95, 0x8e, 0xbb, 0xc1, 0x6c, 0x39, 0xdf, 0xba, 0xb7, 0x6b, 0x28, 0x6f, 0xd3, 0x67, 0xec,
+	0x13, 0x54, 0xb3, 0x7b, 0xff, 0x39, 0x23, 0x91, 0x7e, 0xba, 0x9d, 0x99, 0xdf, 0xfc, 0x66, 0x6e,
+	0x67, 0x76, 0x06, 0xc0, 0x9b, 0xea, 0xcb, 0x9d, 0x49, 0x2c, 0xb5, 0x64, 0x2d, 0xf3, 0x39, 0x9f,
+	0x5e, 0xb8, 0x9b, 0x23, 0x29, 0x47, 0x21, 0xee, 0xa6, 0x8a, 0x5d, 0x1c, 0x4f, 0xf4, 0xad, 0x85,
+	0xb9, 0x30, 0x55, 0x18, 0xdb, 0x33, 0x77, 0xa0, 0x79, 0xa6, 0x30, 0x1e, 0xf4, 0x59, 0x17, 0x1e,
+	0x89, 0xc0, 0x99, 0xdb, 0x9e, 0x7b, 0xb1, 0x3c, 0x7c, 0x24, 0x02, 0xbe, 0x09, 0x4b, 0xd6, 0xa2,
+	0xd8, 0x1a, 0xcc, 0x8b, 0x40, 0x39, 0x73, 0xdb, 0xf3, 0x2f, 0x96, 0x87, 0x74, 0xe4, 0x7d, 0xe8,
+	0x0e, 0xa2, 0x6b, 0xa1, 0x3d, 0x2d, 0x64, 0xd4, 0x93, 0x01, 0x32, 0x06, 0x0b, 0xfa, 0x76, 0x82,
+	0x09, 0x81, 0x39, 0xb3, 0x2d, 0x00, 0x41, 0x28, 0x24, 0x84, 0xf3, 0xc8, 0x58, 0x0a, 0x1a, 0xfe,
+	0x06, 0xbe, 0x18, 0xe2, 0x45, 0x8c, 0xea, 0xf2, 0x93, 0xbc, 0xc2, 0x68, 0x88, 0x7f, 0x4d, 0x51,
+	0x69, 0xc6, 0xa1, 0x13, 0x17, 0xd4, 0x09, 0x65, 0x49, 0xc7, 0xf7, 0xa0, 0xf5, 0xe1, 0xf8, 0xf0,
+	0x44, 0x8e, 0x44, 0xc4, 0x5c, 0x68, 0x8d, 0x2f, 0xbc, 0x22, 0x36, 0x93, 0x29, 0x2d, 0x3f, 0x0f,
+	0x6e, 0xce, 0xfc, 0xdf, 0x39, 0xe8, 0xd2, 0xaf, 0x7d, 0x94, 0xbe, 0xf0, 0xc2, 0xc3, 0xa9, 0xbe,
+	0x24, 0x8a, 0x49, 0x2c, 0xaf, 0x45, 0x80, 0x71, 0x4a, 0x91, 0xca, 0x6c, 0x1b, 0xda, 0x9e, 0xef,
+	0xa3, 0x52, 0x36, 0x82, 0x65, 0x2a, 0xaa, 0x98, 0x03, 0x4b, 0x22, 0xb0, 0xd6, 0x79, 0x63, 0x4d,
+	0xc5, 0x2c, 0xfc, 0x42, 0x1e, 0x9e, 0xf8, 0x62, 0x0c, 0x44, 0x8c, 0xbe, 0x3e, 0x1b, 0x0e, 0x9c,
+	0x45, 0xcb, 0x57, 0x50, 0xd1, 0x05, 0x10, 0xf2, 0x37, 0x8c, 0xc5, 0x85, 0xc0, 0xd8, 0x69, 0xda,
+	0x0b, 0x28, 0xea, 0xd8, 0x13, 0x58, 0x8c, 0x64, 0xe4, 0xa3, 0xb3, 0x64, 0x8c, 0x56, 0xa0, 0x4c,
+	0xd4, 0xf4, 0xfc, 0x4f, 0xf4, 0xb5, 0xd3, 0xb2, 0x99, 0x24, 0x22, 0xff, 0x03, 0x16, 0x86, 0x32,
+	0x34, 0x75, 0x8a, 0xbc, 0x71, 0x56, 0x27, 0x3a, 0x53, 0x46, 0x01, 0x2a, 0x3f, 0x16, 0x13, 0x2a,
+	0x67, 0xfa, 0x87, 0x05, 0x15, 0x21, 0x26, 0x18, 0x8f, 0x85, 0x52, 0x42, 0x46, 0xca, 0x99, 0x37,
+	0x9d, 0x50, 0x54, 0xf1, 0x6f, 0x61, 0x91, 0xf8, 0x15, 0xfb, 0x1a, 0x16, 0x63, 0x3a, 0x98, 0x76,
+	0x69, 0xbf, 0xec, 0xee, 0xa4, 0x3d, 0xb8, 0x43, 0xf6, 0xa1, 0x35, 0xf2, 0x2d, 0x68, 0x91, 0xf8,
+	0x8b, 0x37, 0xae, 0x4d, 0x89, 0xbf, 0x86, 0x16, 0x95, 0xc8, 0xa4, 0xbc, 0x01, 0x4d, 0xea, 0xd8,
+	0x41, 0xda, 0x9d, 0x89, 0x44, 0x7e, 0x44, 0x96, 0xd6, 0x96, 0xce, 0x7c, 0x00, 0xab, 0xa7, 0x59,
+	0x56, 0xbd, 0x4b, 0xf4, 0xaf, 0x66, 0xba, 0x6f, 0x01, 0xe4, 0x3f, 0x90, 0x76, 0x67, 0xae, 0xe1,
+	0xdf, 0xc1, 0x7a, 0x85, 0x6a, 0x88, 0x6a, 0x1a, 0x6a, 0xba, 0x64, 0x2f, 0x0c, 0xe5, 0x0d, 0x5a,
+	0xc6, 0xd6, 0x30, 0x15, 0xf9, 0x27, 0xe8, 0x9e, 0x7a, 0x4a, 0xdd, 0xc8, 0x38, 0xe8, 0x5d, 0x7a,
+	0xd1, 0xc8, 0x5c, 0xad, 0x0c, 0x83, 0x54, 0x99, 0x64, 0x50, 0x54, 0x11, 0x22, 0xc2, 0x9b, 0x0c,
+	0x91, 0x5c, 0x7e, 0x41, 0xc5, 0x9f, 0xe7, 0xac, 0xc7, 0x32, 0x1e, 0x49, 0x4d, 0xc5, 0xc7, 0xb1,
+	0x27, 0xc2, 0x84, 0xcf, 0x0a, 0xfc, 0x77, 0x58, 0x49, 0x71, 0x43, 0x54, 0x38, 0x03, 0x46, 0x5a,
+	0x5d, 0xe8, 0x64, 0x2b, 0x54, 0xd3, 0x98, 0xbf, 0x9b, 0xc6, 0x01, 0x3c, 0x3e, 0x22, 0x02, 0xdb,
+	0x82, 0xbe, 0x79, 0xfa, 0x0f, 0x09, 0xc1, 0x77, 0xa1, 0x3d, 0xc8, 0x1e, 0xbf, 0xa2, 0x88, 0xf9,
+	0x2c, 0x48, 0xa7, 0x4b, 0x51, 0xc5, 0x0f, 0xa0, 0x7d, 0xf4, 0xb7, 0x50, 0x5a, 0xd9, 0x42, 0xd6,
+	0xc7, 0x72, 0xa1, 0x45, 0x05, 0xa5, 0x4e, 0x4a, 0xc2, 0x65, 0x32, 0x7f, 0x0e, 0x1d, 0x4b, 0x90,
+	0x54, 0x6e, 0x03, 0x9a, 0x68, 0xe4, 0xa4, 0x70, 0x89, 0xf4, 0xf2, 0x9f, 0x15, 0x58, 0x30, 0x73,
+	0x60, 0x0f, 0xba, 0xef, 0x51, 0xf7, 0xa6, 0x71, 0x8c, 0x91, 0xa6, 0x06, 0x64, 0x1b, 0x3b, 0x76,
+	0x94, 0xe6, 0x6d, 0x7c, 0x44, 0xa3, 0xd4, 0x2d, 0xf4, 0x35, 0xe1, 0x78, 0x83, 0xfd, 0x00, 0xed,
+	0xf7, 0x68, 0x9c, 0xde, 0xdd, 0x0e, 0xfa, 0x6c, 0xad, 0x0c, 0x18, 0xf4, 0xdd, 0x27, 0x65, 0xcd,
+	0xe9, 0xf4, 0x3c, 0x14, 0x3e, 0x6f, 0xb0, 0x7d, 0x68, 0x9d, 0x88, 0xe8, 0xca, 0x84, 0x73, 0x72,
+	0x4c, 0x79, 0xc0, 0xce, 0xf4, 0x7e, 0x0b, 0x2b, 0x49, 0x58, 0x45, 0x71, 0x15, 0x7b, 0x5c, 0x0d,
+	0xac, 0xdc, 0xf5, 0xb2, 0x4a, 0x65, 0xce, 0x3d, 0x58, 0xcf, 0x9d, 0x8f, 0x3d, 0x1f, 0xcf, 0xa5,
+	0xbc, 0x7a, 0x28, 0xc9, 0x3e, 0xac, 0xd2, 0xe5, 0xd9, 0x3f, 0x37, 0x2d, 0xc2, 0x9e, 0x96, 0xb1,
+	0x46, 0x49, 0x98, 0x9a, 0x6b, 0x7b, 0x07, 0x2c, 0xf7, 0x4e, 0x53, 0x60, 0x6e, 0x19, 0x97, 0xea,
+	0x67, 0x70, 0xec, 0xc3, 0xea, 0xd9, 0x24, 0xf0, 0x34, 0x66, 0xc1, 0x1e, 0x92, 0xc1, 0x5b, 0xe8,
+	0xe6, 0xde, 0x66, 0x22, 0x3d, 0xc0, 0xf9, 0x00, 0x3a, 0xc5, 0x1d, 0xc6, 0xbe, 0xcc, 0x11, 0x35,
+	0xbb, 0xad, 0x86, 0xe0, 0x7b, 0x58, 0xc9, 0xff, 0xff, 0xc3, 0xf1, 0x21, 0x63, 0x39, 0x24, 0x5d,
+	0x71, 0x35, 0x6e, 0x3f, 0xc2, 0x5a, 0xee, 0x66, 0x37, 0x59, 0xb1, 0x79, 0xca, 0xfb, 0xad, 0xc6,
+	0xff, 0x57, 0x58, 0x35, 0xaf, 0x2a, 0x1f, 0x71, 0xec, 0x59, 0x0e, 0xaa, 0x0c, 0x3e, 0xf7, 0xab,
+	0x99, 0x26, 0xfb, 0xb2, 0x78, 0x83, 0xbd, 0x86, 0xe5, 0x13, 0xa1, 0xb4, 0x5d, 0x02, 0xb3, 0x5e,
+	0xcd, 0x6a, 0x79, 0x1b, 0x28, 0xde, 0x60, 0xdf, 0x40, 0xeb, 0xa3, 0x77, 0x8d, 0x24, 0xb2, 0xca,
+	0xb2, 0x70, 0x2b, 0x32, 0x6f, 0xb0, 0x3d, 0x80, 0x3e, 0x86, 0xa8, 0x2d, 0x9e, 0x95, 0xed, 0x54,
+	0x3b, 0x77, 0x46, 0x68, 0xde, 0x60, 0xaf, 0xa0, 0x93, 0x34, 0xbb, 0x4d, 0xf2, 0xee, 0x0b, 0xad,
+	0x49, 0x6f, 0x0f, 0xe0, 0x50, 0x29, 0x31, 0x8a, 0xaa, 0x01, 0x53, 0x9e, 0x7b, 0x02, 0xee, 0x43,
+	0xe7, 0x2c, 0xf2, 0x3e, 0xdf, 0xbb, 0x6b, 0x97, 0x48, 0xb6, 0x2a, 0x0a, 0xf5, 0x2d, 0xaf, 0x99,
+	0x9a, 0xfa, 0xf6, 0xa1, 0x6b, 0x97, 0xc5, 0x7d, 0xde, 0x16, 0x71, 0x4f, 0x0e, 0x7b, 0xb0, 0x62,
+	0x56, 0x49, 0x46, 0xf2, 0xf4, 0x2e, 0x89, 0x01, 0xd4, 0x3e, 0xca, 0xb6, 0x59, 0x15, 0xc9, 0x48,
+	0xd8, 0x2c, 0xb2, 0x57, 0xd6, 0x48, 0x8d, 0xf7, 0x4f, 0xb0, 0xd1, 0x8b, 0xd1, 0xd3, 0x68, 0xc0,
+	0xf9, 0x2c, 0x54, 0x6c, 0xbd, 0x32, 0x22, 0xed, 0xb2, 0x98, 0x3d, 0x9e, 0x7e, 0x86, 0x67, 0x96,
+	0x29, 0x9b, 0x6f, 0xff, 0x83, 0xec, 0x0d, 0x34, 0xed, 0x46, 0x29, 0x7a, 0x16, 0x96, 0x94, 0xbb,
+	0x51, 0x55, 0x67, 0x0f, 0x64, 0x1f, 0x3a, 0x34, 0xe6, 0x3f, 0x6f, 0xc4, 0x9d, 0x37, 0x8d, 0xe2,
+	0xd5, 0x7f, 0x03, 0x00, 0x56, 0x7d, 0xd7, 0xcd, 0xf0, 0x0b, 0x00, 0x00,
 }
@@ -0,0 +1,99 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: error.proto
+
+package protobuf
+
+import proto "github.com/golang/protobuf/proto"
+import fmt "fmt"
+import math "math"
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+type ErrorDetail struct {
+	Code       int32        `protobuf:"varint,1,opt,name=code" json:"code,omitempty"`
+	Message    string       `protobuf:"bytes,2,opt,name=message" json:"message,omitempty"`
+	Violations []*Violation `protobuf:"bytes,3,rep,name=violations" json:"violations,omitempty"`
+}
+
+func (m *ErrorDetail) Reset()                    { *m = ErrorDetail{} }
+func (m *ErrorDetail) String() string            { return proto.CompactTextString(m) }
+func (*ErrorDetail) ProtoMessage()               {}
+func (*ErrorDetail) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{0} }
+
+func (m *ErrorDetail) GetCode() int32 {
+	if m != nil {
+		return m.Code
+	}
+	return 0
+}
+
+func (m *ErrorDetail) GetMessage() string {
+	if m != nil {
+		return m.Message
+	}
+	return ""
+}
+
+func (m *ErrorDetail) GetViolations() []*Violation {
+	if m != nil {
+		return m.Violations
+	}
+	return nil
+}
+
+type Violation struct {
+	Field   string `protobuf:"bytes,1,opt,name=field" json:"field,omitempty"`
+	Rule    string `protobuf:"bytes,2,opt,name=rule" json:"rule,omitempty"`
+	Message string `protobuf:"bytes,3,opt,name=message" json:"message,omitempty"`
+}
+
+func (m *Violation) Reset()                    { *m = Violation{} }
+func (m *Violation) String() string            { return proto.CompactTextString(m) }
+func (*Violation) ProtoMessage()               {}
+func (*Violation) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{1} }
+
+func (m *Violation) GetField() string {
+	if m != nil {
+		return m.Field
+	}
+	return ""
+}
+
+func (m *Violation) GetRule() string {
+	if m != nil {
+		return m.Rule
+	}
+	return ""
+}
+
+func (m *Violation) GetMessage() string {
+	if m != nil {
+		return m.Message
+	}
+	return ""
+}
+
+func init() {
+	proto.RegisterType((*ErrorDetail)(nil), "protobuf.ErrorDetail")
+	proto.RegisterType((*Violation)(nil), "protobuf.Violation")
+}
+
+func init() { proto.RegisterFile("error.proto", fileDescriptor1) }
+
+var fileDescriptor1 = []byte{
+	// 163 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0xe2, 0x4e, 0x2d, 0x2a, 0xca,
+	0x2f, 0xd2, 0x2b, 0x28, 0xca, 0x2f, 0xc9, 0x17, 0xe2, 0x00, 0x53, 0x49, 0xa5, 0x69, 0x4a, 0x05,
+	0x5c, 0xdc, 0xae, 0x20, 0x09, 0x97, 0xd4, 0x92, 0xc4, 0xcc, 0x1c, 0x21, 0x21, 0x2e, 0x96, 0xe4,
+	0xfc, 0x94, 0x54, 0x09, 0x46, 0x05, 0x46, 0x0d, 0xd6, 0x20, 0x30, 0x5b, 0x48, 0x82, 0x8b, 0x3d,
+	0x37, 0xb5, 0xb8, 0x38, 0x31, 0x3d, 0x55, 0x82, 0x49, 0x81, 0x51, 0x83, 0x33, 0x08, 0xc6, 0x15,
+	0x32, 0xe6, 0xe2, 0x2a, 0xcb, 0xcc, 0xcf, 0x49, 0x2c, 0xc9, 0xcc, 0xcf, 0x2b, 0x96, 0x60, 0x56,
+	0x60, 0xd6, 0xe0, 0x36, 0x12, 0xd6, 0x83, 0x99, 0xad, 0x17, 0x06, 0x93, 0x0b, 0x42, 0x52, 0xa6,
+	0xe4, 0xcf, 0xc5, 0x09, 0x97, 0x10, 0x12, 0xe1, 0x62, 0x4d, 0xcb, 0x4c, 0xcd, 0x49, 0x01, 0x5b,
+	0xc8, 0x19, 0x04, 0xe1, 0x80, 0x5c, 0x51, 0x54, 0x9a, 0x03, 0xb3, 0x0e, 0xcc, 0x46, 0x76, 0x05,
+	0x33, 0x8a, 0x2b, 0x92, 0xd8, 0xc0, 0x16, 0x1a, 0x03, 0x06, 0x00, 0x1d, 0xcb, 0xcf, 0x25, 0xe2,
+	0x00, 0x00, 0x00,
+}
@@ -16,10 +16,11 @@ var _ = math.Inf
 type UserStatus int32
 
 const (
-	UserStatus_invited UserStatus = 0
-	UserStatus_created UserStatus = 1
-	UserStatus_merged  UserStatus = 2
-	UserStatus_new     UserStatus = 3
+	UserStatus_invited      UserStatus = 0
+	UserStatus_created      UserStatus = 1
+	UserStatus_merged       UserStatus = 2
+	UserStatus_new          UserStatus = 3
+	UserStatus_mfa_required UserStatus = 4
 )
 
 var UserStatus_name = map[int32]string{
@@ -27,18 +28,20 @@ var UserStatus_name = map[int32]string{
 	1: "created",
 	2: "merged",
 	3: "new",
+	4: "mfa_required",
 }
 var UserStatus_value = map[string]int32{
-	"invited": 0,
-	"created": 1,
-	"merged":  2,
-	"new":     3,
+	"invited":      0,
+	"created":      1,
+	"merged":       2,
+	"new":          3,
+	"mfa_required": 4,
 }
 
 func (x UserStatus) String() string {
 	return proto.EnumName(UserStatus_name, int32(x))
 }
-func (UserStatus) EnumDescriptor() ([]byte, []int) { return fileDescriptor1, []int{0} }
+func (UserStatus) EnumDescriptor() ([]byte, []int) { return fileDescriptor2, []int{0} }
 
 type User struct {
 	Id              string                      `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
@@ -53,12 +56,15 @@ type User struct {
 	FacebookPicture string                      `protobuf:"bytes,10,opt,name=facebookPicture" json:"facebookPicture,omitempty"`
 	FacebookToken   string                      `protobuf:"bytes,11,opt,name=facebookToken" json:"facebookToken,omitempty"`
 	FacebookEmail   string                      `protobuf:"bytes,12,opt,name=facebookEmail" json:"facebookEmail,omitempty"`
+	RefreshToken    string                      `protobuf:"bytes,13,opt,name=refreshToken" json:"refreshToken,omitempty"`
+	MfaToken        string                      `protobuf:"bytes,14,opt,name=mfaToken" json:"mfaToken,omitempty"`
+	Roles           []string                    `protobuf:"bytes,15,rep,name=roles" json:"roles,omitempty"`
 }
 
 func (m *User) Reset()                    { *m = User{} }
 func (m *User) String() string            { return proto.CompactTextString(m) }
 func (*User) ProtoMessage()               {}
-func (*User) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{0} }
+func (*User) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{0} }
 
 func (m *User) GetId() string {
 	if m != nil {
@@ -144,6 +150,27 @@ func (m *User) GetFacebookEmail() string {
 	return ""
 }
 
+func (m *User) GetRefreshToken() string {
+	if m != nil {
+		return m.RefreshToken
+	}
+	return ""
+}
+
+func (m *User) GetMfaToken() string {
+	if m != nil {
+		return m.MfaToken
+	}
+	return ""
+}
+
+func (m *User) GetRoles() []string {
+	if m != nil {
+		return m.Roles
+	}
+	return nil
+}
+
 type UserPublic struct {
 	Id              string     `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
 	Email           string     `protobuf:"bytes,2,opt,name=email" json:"email,omitempty"`
@@ -156,7 +183,7 @@ type UserPublic struct {
 func (m *UserPublic) Reset()                    { *m = UserPublic{} }
 func (m *UserPublic) String() string            { return proto.CompactTextString(m) }
 func (*UserPublic) ProtoMessage()               {}
-func (*UserPublic) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{1} }
+func (*UserPublic) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{1} }
 
 func (m *UserPublic) GetId() string {
 	if m != nil {
@@ -207,7 +234,7 @@ type UsersPublic struct {
 func (m *UsersPublic) Reset()                    { *m = UsersPublic{} }
 func (m *UsersPublic) String() string            { return proto.CompactTextString(m) }
 func (*UsersPublic) ProtoMessage()               {}
-func (*UsersPublic) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{2} }
+func (*UsersPublic) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{2} }
 
 func (m *UsersPublic) GetUsers() []*UserPublic {
 	if m != nil {
@@ -225,7 +252,7 @@ type UserEmailAuth struct {
 func (m *UserEmailAuth) Reset()                    { *m = UserEmailAuth{} }
 func (m *UserEmailAuth) String() string            { return proto.CompactTextString(m) }
 func (*UserEmailAuth) ProtoMessage()               {}
-func (*UserEmailAuth) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{3} }
+func (*UserEmailAuth) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{3} }
 
 func (m *UserEmailAuth) GetEmail() string {
 	if m != nil {
@@ -258,7 +285,7 @@ type UserFacebookAuth struct {
 func (m *UserFacebookAuth) Reset()                    { *m = UserFacebookAuth{} }
 func (m *UserFacebookAuth) String() string            { return proto.CompactTextString(m) }
 func (*UserFacebookAuth) ProtoMessage()               {}
-func (*UserFacebookAuth) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{4} }
+func (*UserFacebookAuth) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{4} }
 
 func (m *UserFacebookAuth) GetFacebookID() string {
 	if m != nil {
@@ -297,38 +324,40 @@ func init() {
 	proto.RegisterEnum("protobuf.UserStatus", UserStatus_name, UserStatus_value)
 }
 
-func init() { proto.RegisterFile("user.proto", fileDescriptor1) }
-
-var fileDescriptor1 = []byte{
-	// 466 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x53, 0xc1, 0x6e, 0xd3, 0x40,
-	0x10, 0x65, 0xed, 0xc4, 0xb1, 0xc7, 0xb4, 0x58, 0xab, 0x1e, 0x56, 0x11, 0x02, 0xcb, 0xe2, 0x60,
-	0x45, 0x28, 0x95, 0xca, 0x05, 0xc4, 0xa9, 0x12, 0x20, 0x71, 0x41, 0x95, 0x29, 0x47, 0x0e, 0x4e,
-	0x3c, 0x49, 0x57, 0x8d, 0xe3, 0xc8, 0xbb, 0xdb, 0xfe, 0x13, 0x9f, 0xc0, 0x07, 0xf0, 0x5d, 0x68,
-	0x77, 0xed, 0x38, 0x89, 0x23, 0x42, 0x4f, 0xd6, 0xbc, 0x7d, 0xbb, 0xef, 0xcd, 0x9b, 0x31, 0x80,
-	0x12, 0x58, 0x4f, 0x37, 0x75, 0x25, 0x2b, 0xea, 0x9b, 0xcf, 0x4c, 0x2d, 0xc6, 0xaf, 0x97, 0x55,
-	0xb5, 0x5c, 0xe1, 0x65, 0x0b, 0x5c, 0x4a, 0x5e, 0xa2, 0x90, 0x79, 0xb9, 0xb1, 0xd4, 0xe4, 0xb7,
-	0x0b, 0x83, 0x1f, 0x02, 0x6b, 0x7a, 0x0e, 0x0e, 0x2f, 0x18, 0x89, 0x49, 0x1a, 0x64, 0x0e, 0x2f,
-	0xe8, 0x05, 0x0c, 0xb1, 0xcc, 0xf9, 0x8a, 0x39, 0x06, 0xb2, 0x05, 0x7d, 0x0f, 0xc1, 0xbc, 0xc6,
-	0x5c, 0x62, 0x71, 0x2d, 0x99, 0x1b, 0x93, 0x34, 0xbc, 0x1a, 0x4f, 0xad, 0xc6, 0xb4, 0xd5, 0x98,
-	0xde, 0xb6, 0x1a, 0x59, 0x47, 0xd6, 0x37, 0xd5, 0xa6, 0x68, 0x6e, 0x0e, 0x4e, 0xdf, 0xdc, 0x92,
-	0xe9, 0x18, 0xfc, 0x07, 0xac, 0xf9, 0x82, 0x63, 0xc1, 0x86, 0x31, 0x49, 0xfd, 0x6c, 0x5b, 0xd3,
-	0x97, 0x10, 0xe4, 0x4a, 0xde, 0xdd, 0x56, 0xf7, 0xb8, 0x66, 0x9e, 0x71, 0xda, 0x01, 0xf4, 0x2d,
-	0x78, 0x42, 0xe6, 0x52, 0x09, 0x36, 0x8a, 0x49, 0x7a, 0x7e, 0x75, 0xd1, 0x29, 0xe9, 0x9e, 0xbf,
-	0x9b, 0xb3, 0xac, 0xe1, 0xd0, 0x57, 0x00, 0x8b, 0x7c, 0x8e, 0xb3, 0xaa, 0xba, 0xff, 0xfa, 0x89,
-	0xf9, 0xe6, 0xb1, 0x1d, 0x44, 0xfb, 0xd0, 0x19, 0x7f, 0xcb, 0x4b, 0x64, 0x81, 0x39, 0xdd, 0xd6,
-	0x34, 0x85, 0x17, 0x2d, 0xf3, 0x86, 0xcf, 0xa5, 0xaa, 0x91, 0x81, 0xa1, 0x1c, 0xc2, 0xf4, 0x0d,
-	0x9c, 0xb5, 0x90, 0x75, 0x1d, 0x1a, 0xde, 0x3e, 0xb8, 0xcb, 0xfa, 0x6c, 0xa6, 0xf0, 0x7c, 0x9f,
-	0x65, 0xc0, 0xe4, 0x0f, 0x01, 0xd0, 0x8d, 0xdc, 0xa8, 0xd9, 0x8a, 0xcf, 0xff, 0x73, 0x84, 0x5d,
-	0x28, 0xee, 0x93, 0x43, 0x19, 0xfc, 0x33, 0x94, 0xe1, 0xe9, 0x50, 0xbc, 0xa3, 0xa1, 0x24, 0x1f,
-	0x20, 0xd4, 0xda, 0xa2, 0x69, 0x64, 0x02, 0x43, 0xfd, 0x88, 0x60, 0x24, 0x76, 0xd3, 0xf0, 0xd0,
-	0xa1, 0x25, 0x65, 0x96, 0x92, 0xfc, 0x84, 0x33, 0x0d, 0x9a, 0x40, 0xae, 0x95, 0xbc, 0xeb, 0xba,
-	0x26, 0xbb, 0x5d, 0xef, 0xfa, 0x74, 0x0e, 0x7c, 0x8e, 0xc1, 0xdf, 0xe4, 0x42, 0x3c, 0x56, 0x75,
-	0x61, 0x32, 0x09, 0xb2, 0x6d, 0x9d, 0xfc, 0x22, 0x10, 0xe9, 0xf7, 0xbf, 0x34, 0x8e, 0x8d, 0xc4,
-	0x7e, 0x28, 0xa4, 0x17, 0x4a, 0x6f, 0x7a, 0xce, 0x91, 0xe9, 0xd1, 0x09, 0x44, 0x2d, 0xa0, 0x15,
-	0xd6, 0xda, 0x9a, 0x95, 0xef, 0xe1, 0xfd, 0xad, 0x19, 0x1c, 0xd9, 0x9a, 0xc9, 0x47, 0xbb, 0x0e,
-	0x76, 0x84, 0x34, 0x84, 0x11, 0x5f, 0x3f, 0x70, 0x89, 0x45, 0xf4, 0x4c, 0x17, 0xcd, 0xbf, 0x18,
-	0x11, 0x0a, 0xe0, 0x95, 0x58, 0x2f, 0xb1, 0x88, 0x1c, 0x3a, 0x02, 0x77, 0x8d, 0x8f, 0x91, 0x3b,
-	0xf3, 0x4c, 0xc8, 0xef, 0xfe, 0x06, 0x00, 0x00, 0xff, 0xff, 0x86, 0x12, 0xe4, 0xec, 0x49, 0x04,
-	0x00, 0x00,
+func init() { proto.RegisterFile("user.proto", fileDescriptor2) }
+
+var fileDescriptor2 = []byte{
+	// 509 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x53, 0x5d, 0x6f, 0xd3, 0x30,
+	0x14, 0xc5, 0x49, 0xfa, 0x75, 0xd3, 0x76, 0x91, 0xb5, 0x07, 0xab, 0x42, 0x10, 0x45, 0x3c, 0x44,
+	0x15, 0xea, 0xa4, 0xf1, 0x02, 0x8f, 0x93, 0x00, 0x89, 0x07, 0xd0, 0x14, 0xc6, 0x23, 0x42, 0x6e,
+	0x73, 0xd3, 0x59, 0x6b, 0x9a, 0x62, 0x3b, 0xdb, 0xdf, 0xe0, 0x77, 0xf0, 0x43, 0xf8, 0x5d, 0xc8,
+	0x4e, 0xd2, 0xf4, 0x4b, 0x8c, 0x3d, 0x45, 0xf7, 0xf8, 0xd8, 0xe7, 0xdc, 0x73, 0x6f, 0x00, 0x4a,
+	0x85, 0x72, 0xb6, 0x91, 0x85, 0x2e, 0x68, 0xdf, 0x7e, 0xe6, 0x65, 0x36, 0x79, 0xb9, 0x2c, 0x8a,
+	0xe5, 0x0a, 0x2f, 0x1a, 0xe0, 0x42, 0x8b, 0x1c, 0x95, 0xe6, 0xf9, 0xa6, 0xa2, 0x46, 0xbf, 0x3c,
+	0xf0, 0xbe, 0x29, 0x94, 0x74, 0x0c, 0x8e, 0x48, 0x19, 0x09, 0x49, 0x3c, 0x48, 0x1c, 0x91, 0xd2,
+	0x73, 0xe8, 0x60, 0xce, 0xc5, 0x8a, 0x39, 0x16, 0xaa, 0x0a, 0xfa, 0x16, 0x06, 0x0b, 0x89, 0x5c,
+	0x63, 0x7a, 0xa5, 0x99, 0x1b, 0x92, 0xd8, 0xbf, 0x9c, 0xcc, 0x2a, 0x8d, 0x59, 0xa3, 0x31, 0xbb,
+	0x69, 0x34, 0x92, 0x96, 0x6c, 0x6e, 0x96, 0x9b, 0xb4, 0xbe, 0xe9, 0x3d, 0x7e, 0x73, 0x4b, 0xa6,
+	0x13, 0xe8, 0xdf, 0xa3, 0x14, 0x99, 0xc0, 0x94, 0x75, 0x42, 0x12, 0xf7, 0x93, 0x6d, 0x4d, 0x9f,
+	0xc3, 0x80, 0x97, 0xfa, 0xf6, 0xa6, 0xb8, 0xc3, 0x35, 0xeb, 0x5a, 0xa7, 0x2d, 0x40, 0x5f, 0x43,
+	0x57, 0x69, 0xae, 0x4b, 0xc5, 0x7a, 0x21, 0x89, 0xc7, 0x97, 0xe7, 0xad, 0x92, 0xe9, 0xf9, 0xab,
+	0x3d, 0x4b, 0x6a, 0x0e, 0x7d, 0x01, 0x90, 0xf1, 0x05, 0xce, 0x8b, 0xe2, 0xee, 0xd3, 0x7b, 0xd6,
+	0xb7, 0x8f, 0xed, 0x20, 0xc6, 0x87, 0xc9, 0xf8, 0x0b, 0xcf, 0x91, 0x0d, 0xec, 0xe9, 0xb6, 0xa6,
+	0x31, 0x9c, 0x35, 0xcc, 0x6b, 0xb1, 0xd0, 0xa5, 0x44, 0x06, 0x96, 0x72, 0x08, 0xd3, 0x57, 0x30,
+	0x6a, 0xa0, 0xca, 0xb5, 0x6f, 0x79, 0xfb, 0xe0, 0x2e, 0xeb, 0x83, 0x9d, 0xc2, 0x70, 0x9f, 0x65,
+	0x41, 0x1a, 0xc1, 0x50, 0x62, 0x26, 0x51, 0xd5, 0x01, 0x8c, 0x2c, 0x69, 0x0f, 0x33, 0xae, 0xf3,
+	0x8c, 0x57, 0xe7, 0xe3, 0xca, 0x75, 0x53, 0x9b, 0x19, 0xcb, 0x62, 0x85, 0x8a, 0x9d, 0x85, 0xae,
+	0x99, 0xb1, 0x2d, 0xa2, 0x3f, 0x04, 0xc0, 0xc4, 0x73, 0x5d, 0xce, 0x57, 0x62, 0xf1, 0x9f, 0x8b,
+	0xd1, 0x46, 0xed, 0x3e, 0x39, 0x6a, 0xef, 0x9f, 0x51, 0x77, 0x1e, 0x8f, 0xba, 0x7b, 0x32, 0xea,
+	0xe8, 0x1d, 0xf8, 0x46, 0x5b, 0xd5, 0x8d, 0x4c, 0xa1, 0x63, 0x1e, 0x51, 0x8c, 0x84, 0x6e, 0xec,
+	0x1f, 0x3a, 0xac, 0x48, 0x49, 0x45, 0x89, 0xbe, 0xc3, 0xc8, 0x80, 0x36, 0xe6, 0xab, 0x52, 0xdf,
+	0xb6, 0x5d, 0x93, 0xdd, 0xae, 0x77, 0x7d, 0x3a, 0x07, 0x3e, 0x27, 0xd0, 0xdf, 0x70, 0xa5, 0x1e,
+	0x0a, 0x99, 0xda, 0x4c, 0x06, 0xc9, 0xb6, 0x8e, 0x7e, 0x13, 0x08, 0xcc, 0xfb, 0x1f, 0x6b, 0xc7,
+	0x56, 0x62, 0x3f, 0x14, 0x72, 0x14, 0xca, 0xd1, 0x4e, 0x38, 0xa7, 0x76, 0x62, 0x0a, 0x41, 0x03,
+	0x18, 0x85, 0xb5, 0xb1, 0x56, 0xc9, 0x1f, 0xe1, 0xc7, 0xbb, 0xe8, 0x9d, 0xd8, 0xc5, 0xe9, 0x67,
+	0x80, 0x76, 0x84, 0xd4, 0x87, 0x9e, 0x58, 0xdf, 0x0b, 0x8d, 0x69, 0xf0, 0xcc, 0x14, 0xf5, 0x1f,
+	0x1e, 0x10, 0x0a, 0xd0, 0xcd, 0x51, 0x2e, 0x31, 0x0d, 0x1c, 0xda, 0x03, 0x77, 0x8d, 0x0f, 0x81,
+	0x4b, 0x03, 0x18, 0xe6, 0x19, 0xff, 0x21, 0xf1, 0x67, 0x29, 0x24, 0xa6, 0x81, 0x37, 0xef, 0xda,
+	0xd8, 0xdf, 0xfc, 0x1d, 0x00, 0x40, 0x04, 0x4d, 0x52, 0xb1, 0x04, 0x00, 0x00,
 }
@@ -5,12 +5,13 @@ import (
 	"github.com/axiomzen/zenauth/constants"
 	"github.com/axiomzen/zenauth/context/core"
 	"github.com/axiomzen/zenauth/context/v1"
+	"github.com/axiomzen/zenauth/data"
 	"github.com/axiomzen/zenauth/routes"
 	"github.com/gocraft/web"
 )
 
 // InitRouter initializes the router
-func InitRouter(c *config.ZENAUTHConfig) *web.Router {
+func InitRouter(c *config.ZENAUTHConfig) (*web.Router, error) {
 	// Setup Base router with middleware
 	coreRouter := web.New(core.RequestContext{})
 
@@ -33,6 +34,19 @@ func InitRouter(c *config.ZENAUTHConfig) *web.Router {
 
 	router := coreRouter.Subrouter(core.RequestContext{}, "")
 
+	// rate limits are configured per group of routes, see RateLimits
+	dal, err := data.Get(c)
+	if err != nil {
+		return nil, err
+	}
+	limiter := data.NewRateLimiter(c, dal)
+	rateLimited := func(r *web.Router, group string) *web.Router {
+		if limit, ok := c.RouteRateLimits[group]; ok {
+			r.Middleware(core.RateLimit(limiter, group, limit))
+		}
+		return r
+	}
+
 	// new relic plugin
 	if core.InitNewRelicPlugin(c) {
 		router.Middleware(core.GoRelicHandler)
@@ -45,6 +59,22 @@ func InitRouter(c *config.ZENAUTHConfig) *web.Router {
 
 	// support ping here (before /v1)
 	router.Get(routes.ResourcePing, (*core.RequestContext).PingResponse)
+	// public keys to verify our tokens with
+	router.Get(routes.ResourceWellKnown+routes.ResourceJWKS, (*core.RequestContext).JWKSResponse)
+
+	// OpenID Connect provider, at the root so the issuer is our url.
+	// Clients don't have an API token, they are checked by the handlers
+	oauthRouter := router.
+		Subrouter(v1.APIAuthContext{}, "").
+		Subrouter(v1.UserContext{}, "").
+		Subrouter(v1.OAuthContext{}, "")
+	oauthRouter.
+		Get(routes.ResourceWellKnown+routes.ResourceOpenIDConfiguration, (*v1.OAuthContext).Configuration).
+		Get(routes.ResourceAuthorize, (*v1.OAuthContext).Authorize).
+		Post(routes.ResourceAuthorize, (*v1.OAuthContext).Authorize).
+		Post(routes.ResourceToken, (*v1.OAuthContext).Token).
+		Get(routes.ResourceUserInfo, (*v1.OAuthContext).UserInfo).
+		Post(routes.ResourceUserInfo, (*v1.OAuthContext).UserInfo)
 
 	// =========
 	// V1 Routes
@@ -70,19 +100,41 @@ func InitRouter(c *config.ZENAUTHConfig) *web.Router {
 		Get(routes.ResourceForgotPassword, (*v1.UserContext).ForgotPassword).
 		Get(routes.ResourceMessage, (*v1.UserContext).GeneralMessageHTML)
 
+	// passwordless login links are opened from the email, so no API auth either
+	v1APIRouter.Subrouter(v1.MagicLinkContext{}, routes.ResourceUsers+routes.ResourceMagicLink).
+		Get(routes.ResourceConsume, (*v1.MagicLinkContext).ConsumeGet).
+		Post(routes.ResourceConsume, (*v1.MagicLinkContext).ConsumePost)
+
+	// data export downloads are opened from the email too
+	v1APIRouter.Subrouter(v1.UserContext{}, routes.ResourceUsers).
+		Subrouter(v1.UserExportContext{}, routes.ResourceExport).
+		Get(routes.ResourceDownload, (*v1.UserExportContext).Download)
+
 	{
 		// API auth, but no user auth
 		v1APIAuthUserRouter := v1APIAuthRouter.
-			Subrouter(v1.UserContext{}, routes.ResourceUsers).
-			// user signup
-			Post(routes.ResourceSignup, (*v1.UserContext).Signup).
-			// user login
-			Post(routes.ResourceLogin, (*v1.UserContext).Login).
-			// Accepts query parameter of: ?email=example@email.ca
-			Get(routes.ResourceExists, (*v1.UserContext).Exists).
+			Subrouter(v1.UserContext{}, routes.ResourceUsers)
+
+		// exchange a refresh token for a new auth token
+		rateLimited(v1APIAuthUserRouter.Subrouter(v1.UserContext{}, ""), "refresh").
+			Post(routes.ResourceToken+routes.ResourceRefresh, (*v1.UserContext).RefreshToken)
+
+		// user signup
+		rateLimited(v1APIAuthUserRouter.Subrouter(v1.UserContext{}, ""), "signup").
+			Post(routes.ResourceSignup, (*v1.UserContext).Signup)
+		// user login
+		rateLimited(v1APIAuthUserRouter.Subrouter(v1.UserContext{}, ""), "login").
+			Post(routes.ResourceLogin, (*v1.UserContext).Login)
+		// cancel the deletion of an account, which logs in
+		rateLimited(v1APIAuthUserRouter.Subrouter(v1.UserDeletionContext{}, ""), "login").
+			Post(routes.ResourceRestore, (*v1.UserDeletionContext).Restore)
+		// Accepts query parameter of: ?email=example@email.ca
+		rateLimited(v1APIAuthUserRouter.Subrouter(v1.UserContext{}, ""), "exists").
+			Get(routes.ResourceExists, (*v1.UserContext).Exists)
+		rateLimited(v1APIAuthUserRouter.Subrouter(v1.UserContext{}, ""), "forgot_password").
 			Put(routes.ResourceForgotPassword, (*v1.UserContext).ForgotPassword)
 
-		v1APIAuthUserRouter.Subrouter(v1.FacebookContext{}, "").
+		rateLimited(v1APIAuthUserRouter.Subrouter(v1.FacebookContext{}, ""), "facebook").
 			// Facebook login
 			Post(routes.ResourceFacebookLogin, (*v1.FacebookContext).Login).
 			// Facebook signup
@@ -90,6 +142,25 @@ func InitRouter(c *config.ZENAUTHConfig) *web.Router {
 			// Facebook login + signup
 			Post(routes.ResourceFacebook, (*v1.FacebookContext).Facebook)
 
+		rateLimited(v1APIAuthUserRouter.Subrouter(v1.SocialContext{}, routes.ResourceSocial), "social").
+			// login + signup with any configured identity provider
+			Post("/:provider", (*v1.SocialContext).Social).
+			Post("/:provider"+routes.ResourceLogin, (*v1.SocialContext).Login).
+			Post("/:provider"+routes.ResourceSignup, (*v1.SocialContext).Signup)
+
+		rateLimited(v1APIAuthUserRouter.Subrouter(v1.MagicLinkContext{}, ""), "magic_link").
+			// email a passwordless login link
+			Post(routes.ResourceMagicLink, (*v1.MagicLinkContext).Send)
+
+		// second step of login with two factor authentication
+		rateLimited(v1APIAuthUserRouter.Subrouter(v1.MFAContext{}, ""), "login").
+			Post(routes.ResourceLogin+routes.ResourceMFA, (*v1.MFAContext).Login)
+
+		rateLimited(v1APIAuthUserRouter.Subrouter(v1.WebAuthnContext{}, routes.ResourceWebAuthn), "webauthn_login").
+			// passkey login
+			Post(routes.ResourceLogin+routes.ResourceBegin, (*v1.WebAuthnContext).LoginBegin).
+			Post(routes.ResourceLogin+routes.ResourceFinish, (*v1.WebAuthnContext).LoginFinish)
+
 		{
 			// API auth and user auth
 			v1APIAuthUserAuthRouter := v1APIAuthUserRouter.
@@ -99,9 +170,31 @@ func InitRouter(c *config.ZENAUTHConfig) *web.Router {
 				Get(routes.ResourceRoot, (*v1.UserContext).GetSelf).
 				Put(routes.ResourcePassword, (*v1.UserContext).PasswordPut).
 				Put(routes.ResourceEmail, (*v1.UserContext).EmailPut).
+				Post(routes.ResourceLogout, (*v1.UserContext).Logout).
+				Post(routes.ResourceLogoutAll, (*v1.UserContext).LogoutAll).
 				Get("/:id", (*v1.UserContext).Get)
+			v1APIAuthUserAuthRouter.Subrouter(v1.UserExportContext{}, routes.ResourceMe).
+				Get(routes.ResourceExport, (*v1.UserExportContext).Request)
+			v1APIAuthUserAuthRouter.Subrouter(v1.UserDeletionContext{}, "").
+				Delete(routes.ResourceMe, (*v1.UserDeletionContext).Delete)
 			v1APIAuthUserAuthRouter.Subrouter(v1.FacebookContext{}, "").
 				Post(routes.ResourceFacebookLink, (*v1.FacebookContext).Link)
+			v1APIAuthUserAuthRouter.Subrouter(v1.SocialContext{}, routes.ResourceSocial).
+				Post("/:provider"+routes.ResourceLink, (*v1.SocialContext).Link)
+			// Two factor authentication
+			v1APIAuthUserAuthRouter.
+				Subrouter(v1.MFAContext{}, routes.ResourceMFA).
+				Post(routes.ResourceTOTP, (*v1.MFAContext).EnrollTOTP).
+				Post(routes.ResourceTOTP+routes.ResourceConfirm, (*v1.MFAContext).ConfirmTOTP).
+				Post(routes.ResourceTOTP+routes.ResourceDisable, (*v1.MFAContext).DisableTOTP).
+				Post(routes.ResourceRecoveryCodes, (*v1.MFAContext).RegenerateRecoveryCodes)
+			// Passkeys
+			v1APIAuthUserAuthRouter.
+				Subrouter(v1.WebAuthnContext{}, routes.ResourceWebAuthn).
+				Post(routes.ResourceRegister+routes.ResourceBegin, (*v1.WebAuthnContext).RegisterBegin).
+				Post(routes.ResourceRegister+routes.ResourceFinish, (*v1.WebAuthnContext).RegisterFinish).
+				Get(routes.ResourceCredentials, (*v1.WebAuthnContext).Credentials).
+				Delete(routes.ResourceCredentials+"/:id:"+c.UUIDRegex, (*v1.WebAuthnContext).DeleteCredential)
 			// Invitations
 			v1APIAuthUserAuthRouter.
 				Subrouter(v1.InvitationContext{}, routes.ResourceInvitations).
@@ -110,6 +203,41 @@ func InitRouter(c *config.ZENAUTHConfig) *web.Router {
 		}
 	}
 
+	// Admin routes, API auth and the admin token
+	adminUser := routes.ResourceUsers + "/:id:" + c.UUIDRegex
+	adminTenant := routes.ResourceTenants + "/:id:" + c.UUIDRegex
+	v1APIAuthRouter.
+		Subrouter(v1.UserContext{}, routes.ResourceAdmins).
+		Subrouter(v1.AdminContext{}, "").
+		Middleware((*v1.AdminContext).AdminAuthRequired).
+		Get(routes.ResourceUsers, (*v1.AdminContext).ListUsers).
+		Post(routes.ResourceUsers+routes.ResourceImport, (*v1.AdminContext).ImportUsers).
+		Get(adminUser, (*v1.AdminContext).GetUser).
+		Delete(adminUser, (*v1.AdminContext).DeleteUser).
+		Post(adminUser+routes.ResourceRestore, (*v1.AdminContext).RestoreUser).
+		Post(adminUser+routes.ResourceExport, (*v1.AdminContext).ExportUser).
+		Post(adminUser+routes.ResourceVerifyEmail, (*v1.AdminContext).VerifyUserEmail).
+		Post(adminUser+routes.ResourceResetPassword, (*v1.AdminContext).ResetUserPassword).
+		Post(adminUser+routes.ResourceDisable, (*v1.AdminContext).DisableUser).
+		Post(adminUser+routes.ResourceEnable, (*v1.AdminContext).EnableUser).
+		Post(adminUser+routes.ResourceRevokeTokens, (*v1.AdminContext).RevokeUserTokens).
+		Post(adminUser+routes.ResourceUnlock, (*v1.AdminContext).UnlockUser).
+		// Roles and permissions
+		Get(routes.ResourceRoles, (*v1.AdminContext).ListRoles).
+		Get(routes.ResourceRoles+"/:name", (*v1.AdminContext).GetRole).
+		Put(routes.ResourceRoles+"/:name", (*v1.AdminContext).SaveRole).
+		Delete(routes.ResourceRoles+"/:name", (*v1.AdminContext).DeleteRole).
+		Get(routes.ResourcePermissions, (*v1.AdminContext).ListPermissions).
+		Delete(routes.ResourcePermissions+"/:name", (*v1.AdminContext).DeletePermission).
+		Get(adminUser+routes.ResourceRoles, (*v1.AdminContext).GetUserRoles).
+		Put(adminUser+routes.ResourceRoles+"/:name", (*v1.AdminContext).AssignUserRole).
+		Delete(adminUser+routes.ResourceRoles+"/:name", (*v1.AdminContext).UnassignUserRole).
+		// Tenants, whatever the tenant of the API token
+		Get(routes.ResourceTenants, (*v1.AdminContext).ListTenants).
+		Post(routes.ResourceTenants, (*v1.AdminContext).CreateTenant).
+		Post(adminTenant+routes.ResourceAPIToken, (*v1.AdminContext).RotateTenantAPIToken).
+		Delete(adminTenant, (*v1.AdminContext).DeleteTenant)
+
 	// Integration test Routes
 	if c.Environment == constants.EnvironmentTest {
 		testRouter := v1APIAuthRouter.Subrouter(v1.TestContext{}, routes.ResourceTest)
@@ -118,13 +246,21 @@ func InitRouter(c *config.ZENAUTHConfig) *web.Router {
 
 		testRouter.Subrouter(v1.TestContext{}, routes.ResourceUsers).
 			Get(routes.ResourcePasswordReset, (*v1.TestContext).UserPasswordResetTokenGet).
+			Get(routes.ResourceMagicLinkToken, (*v1.TestContext).UserMagicLinkTokenGet).
+			Get(routes.ResourceExportToken, (*v1.TestContext).UserExportTokenGet).
 			// for now using user id, see if we need to delete via token or email
 			Delete(routes.ResourcePasswordReset+"/:user_id:"+c.UUIDRegex, (*v1.TestContext).UserPasswordResetTokenDelete).
 			Delete(routes.ResourceInvitations, (*v1.TestContext).InvitationsDelete).
+			Delete(routes.ResourceLoginThrottle, (*v1.TestContext).UserLoginThrottleDelete).
 			Delete("/:user_id:"+c.UUIDRegex, (*v1.TestContext).UserDelete)
+
+		testRouter.Post(routes.ResourceOAuthClients, (*v1.TestContext).OAuthClientCreate)
+
+		rateLimited(testRouter.Subrouter(v1.TestContext{}, ""), "test").
+			Get(routes.ResourceRateLimited, (*v1.TestContext).RateLimited)
 	}
 
 	// your application routes here
 
-	return coreRouter
+	return coreRouter, nil
 }
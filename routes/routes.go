@@ -6,6 +6,10 @@ const (
 	ResourceRoot = "/"
 	// ResourcePing ping resource
 	ResourcePing = "/ping"
+	// ResourceWellKnown well known URIs (RFC 5785)
+	ResourceWellKnown = "/.well-known"
+	// ResourceJWKS json web key set resource
+	ResourceJWKS = "/jwks.json"
 	// ResourcePanic creates a panic on purpose
 	ResourcePanic = "/panic"
 	// ResourceUsers users resource
@@ -22,10 +26,50 @@ const (
 	ResourcePasswordReset = "/password-reset" // for testing
 	// ResourceVerifyEmail for verifying an email
 	ResourceVerifyEmail = "/verify_email"
+	// ResourceMagicLink passwordless login link resource
+	ResourceMagicLink = "/magic_link"
+	// ResourceConsume consume resource
+	ResourceConsume = "/consume"
+	// ResourceMagicLinkToken magic link token resource
+	ResourceMagicLinkToken = "/magic-link" // for testing
+	// ResourceLoginThrottle failed login counters resource
+	ResourceLoginThrottle = "/login-throttle" // for testing
+	// ResourceRateLimited rate limited resource
+	ResourceRateLimited = "/rate-limited" // for testing
 	// ResourceSignup signup resource
 	ResourceSignup = "/signup"
 	// ResourceLogin login resource
 	ResourceLogin = "/login"
+	// ResourceLogout logout resource
+	ResourceLogout = "/logout"
+	// ResourceLogoutAll logout everywhere resource
+	ResourceLogoutAll = "/logout_all"
+	// ResourceMFA two factor authentication resource
+	ResourceMFA = "/mfa"
+	// ResourceTOTP totp resource
+	ResourceTOTP = "/totp"
+	// ResourceConfirm confirm resource
+	ResourceConfirm = "/confirm"
+	// ResourceDisable disable resource
+	ResourceDisable = "/disable"
+	// ResourceEnable enable resource
+	ResourceEnable = "/enable"
+	// ResourceRevokeTokens revoke tokens resource
+	ResourceRevokeTokens = "/revoke_tokens"
+	// ResourceUnlock unlock resource
+	ResourceUnlock = "/unlock"
+	// ResourceRecoveryCodes recovery codes resource
+	ResourceRecoveryCodes = "/recovery_codes"
+	// ResourceWebAuthn webauthn resource
+	ResourceWebAuthn = "/webauthn"
+	// ResourceRegister register resource
+	ResourceRegister = "/register"
+	// ResourceBegin begin resource
+	ResourceBegin = "/begin"
+	// ResourceFinish finish resource
+	ResourceFinish = "/finish"
+	// ResourceCredentials credentials resource
+	ResourceCredentials = "/credentials"
 	// ResourcePassword password resource
 	ResourcePassword = "/password"
 	// ResourceEmail email resource
@@ -34,6 +78,10 @@ const (
 	ResourceExists = "/exists"
 	// ResourceInvitations invitations resource
 	ResourceInvitations = "/invitations"
+	// ResourceSocial social identity providers resource
+	ResourceSocial = "/social"
+	// ResourceLink link resource
+	ResourceLink = "/link"
 	// ResourceFacebook facebook resource
 	ResourceFacebook = "/facebook"
 	// ResourceFacebookLogin fblogin resource
@@ -44,4 +92,36 @@ const (
 	ResourceFacebookLink = "/fblink"
 	// ResourceMessage
 	ResourceMessage = "/message"
+	// ResourceToken token resource
+	ResourceToken = "/token"
+	// ResourceRefresh refresh resource
+	ResourceRefresh = "/refresh"
+	// ResourceOpenIDConfiguration OpenID Connect discovery resource
+	ResourceOpenIDConfiguration = "/openid-configuration"
+	// ResourceAuthorize OAuth 2.0 authorization resource
+	ResourceAuthorize = "/authorize"
+	// ResourceUserInfo OpenID Connect userinfo resource
+	ResourceUserInfo = "/userinfo"
+	// ResourceImport import resource
+	ResourceImport = "/import"
+	// ResourceMe the authenticated user resource
+	ResourceMe = "/me"
+	// ResourceExport data export resource
+	ResourceExport = "/export"
+	// ResourceDownload download resource
+	ResourceDownload = "/download"
+	// ResourceRestore restore resource
+	ResourceRestore = "/restore"
+	// ResourceRoles roles resource
+	ResourceRoles = "/roles"
+	// ResourcePermissions permissions resource
+	ResourcePermissions = "/permissions"
+	// ResourceTenants tenants resource
+	ResourceTenants = "/tenants"
+	// ResourceAPIToken api token resource
+	ResourceAPIToken = "/api_token"
+	// ResourceExportToken data export token resource
+	ResourceExportToken = "/export-token" // for testing
+	// ResourceOAuthClients OAuth 2.0 clients resource
+	ResourceOAuthClients = "/oauth_clients" // for testing
 )
@@ -0,0 +1,197 @@
+package integration
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/axiomzen/golorem"
+	"github.com/axiomzen/zenauth/constants"
+	"github.com/axiomzen/zenauth/models"
+	"github.com/axiomzen/zenauth/routes"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("Admin", func() {
+
+	var (
+		userAuth models.UserAuth
+		user     models.User
+	)
+
+	ginkgo.BeforeEach(func() {
+		gomega.Expect(lorem.Fill(&userAuth)).To(gomega.Succeed())
+		statusCode, err := TestRequestV1().Post(routes.ResourceUsers + routes.ResourceSignup).RequestBody(&userAuth).ResponseBody(&user).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusCreated))
+	})
+
+	ginkgo.AfterEach(func() {
+		deleteUser(user.ID)
+	})
+
+	userPath := func(resource string) string {
+		return routes.ResourceAdmins + routes.ResourceUsers + "/" + user.ID + resource
+	}
+
+	login := func(password string) int {
+		var loggedIn models.User
+		auth := models.Login{Email: userAuth.Email, Password: password}
+		statusCode, err := TestRequestV1().Post(routes.ResourceUsers + routes.ResourceLogin).RequestBody(&auth).ResponseBody(&loggedIn).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		return statusCode
+	}
+
+	getSelf := func() int {
+		statusCode, err := TestRequestV1().Get(routes.ResourceUsers).Header(theConf.AuthTokenHeader, user.AuthToken).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		return statusCode
+	}
+
+	ginkgo.It("should need the admin token", func() {
+		statusCode, err := TestRequestV1().Get(userPath("")).Header(theConf.AdminTokenHeader, "wrong token").Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusUnauthorized))
+	})
+
+	ginkgo.It("should get the user", func() {
+		var adminUser models.AdminUser
+		statusCode, err := TestRequestV1().Get(userPath("")).Header(theConf.AdminTokenHeader, adminTestToken).ResponseBody(&adminUser).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusOK))
+		gomega.Expect(adminUser.Email).To(gomega.Equal(user.Email))
+		gomega.Expect(adminUser.HasPassword).To(gomega.BeTrue())
+		gomega.Expect(adminUser.DisabledAt.Valid).To(gomega.BeFalse())
+	})
+
+	ginkgo.It("should search users by email prefix, a page at a time", func() {
+		var other models.User
+		otherAuth := models.UserAuth{Email: strings.Replace(userAuth.Email, "@", "+other@", 1), Password: userAuth.Password}
+		statusCode, err := TestRequestV1().Post(routes.ResourceUsers + routes.ResourceSignup).RequestBody(&otherAuth).ResponseBody(&other).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusCreated))
+		defer deleteUser(other.ID)
+
+		prefix := strings.SplitN(userAuth.Email, "@", 2)[0]
+		var page models.AdminUserList
+		statusCode, err = TestRequestV1().Get(routes.ResourceAdmins+routes.ResourceUsers).Header(theConf.AdminTokenHeader, adminTestToken).
+			URLParam("email", prefix).URLParam("limit", "1").ResponseBody(&page).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusOK))
+		gomega.Expect(page.Users).To(gomega.HaveLen(1))
+		gomega.Expect(page.Users[0].ID).To(gomega.Equal(user.ID))
+		gomega.Expect(page.NextCursor).ToNot(gomega.BeEmpty())
+
+		var next models.AdminUserList
+		statusCode, err = TestRequestV1().Get(routes.ResourceAdmins+routes.ResourceUsers).Header(theConf.AdminTokenHeader, adminTestToken).
+			URLParam("email", prefix).URLParam("limit", "1").URLParam("cursor", page.NextCursor).ResponseBody(&next).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusOK))
+		gomega.Expect(next.Users).To(gomega.HaveLen(1))
+		gomega.Expect(next.Users[0].ID).To(gomega.Equal(other.ID))
+		gomega.Expect(next.NextCursor).To(gomega.BeEmpty())
+	})
+
+	ginkgo.It("should reject an invalid cursor", func() {
+		var errResp models.ErrorResponse
+		statusCode, err := TestRequestV1().Get(routes.ResourceAdmins+routes.ResourceUsers).Header(theConf.AdminTokenHeader, adminTestToken).
+			URLParam("cursor", "not a cursor").ErrorResponseBody(&errResp).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusBadRequest))
+		gomega.Expect(errResp.Code).To(gomega.Equal(constants.APIParsingQueryParams))
+	})
+
+	ginkgo.It("should verify the email", func() {
+		var adminUser models.AdminUser
+		statusCode, err := TestRequestV1().Post(userPath(routes.ResourceVerifyEmail)).Header(theConf.AdminTokenHeader, adminTestToken).ResponseBody(&adminUser).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusOK))
+		gomega.Expect(adminUser.Verified).To(gomega.BeTrue())
+	})
+
+	ginkgo.It("should set the password and log the user out", func() {
+		newPassword := lorem.Word(8, 12) + "aA1!" + lorem.Word(8, 12)
+		statusCode, err := TestRequestV1().Post(userPath(routes.ResourceResetPassword)).Header(theConf.AdminTokenHeader, adminTestToken).
+			RequestBody(&models.AdminPasswordReset{Password: newPassword}).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusNoContent))
+		gomega.Expect(getSelf()).To(gomega.Equal(http.StatusUnauthorized))
+		gomega.Expect(login(userAuth.Password)).To(gomega.Equal(http.StatusUnauthorized))
+		gomega.Expect(login(newPassword)).To(gomega.Equal(http.StatusOK))
+	})
+
+	ginkgo.It("should revoke the user's tokens", func() {
+		statusCode, err := TestRequestV1().Post(userPath(routes.ResourceRevokeTokens)).Header(theConf.AdminTokenHeader, adminTestToken).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusNoContent))
+		gomega.Expect(getSelf()).To(gomega.Equal(http.StatusUnauthorized))
+	})
+
+	ginkgo.Context("User is disabled", func() {
+
+		ginkgo.BeforeEach(func() {
+			var adminUser models.AdminUser
+			statusCode, err := TestRequestV1().Post(userPath(routes.ResourceDisable)).Header(theConf.AdminTokenHeader, adminTestToken).ResponseBody(&adminUser).Do()
+			gomega.Expect(err).ToNot(gomega.HaveOccurred())
+			gomega.Expect(statusCode).To(gomega.Equal(http.StatusOK))
+			gomega.Expect(adminUser.DisabledAt.Valid).To(gomega.BeTrue())
+		})
+
+		ginkgo.It("should not log in or use their tokens", func() {
+			gomega.Expect(login(userAuth.Password)).To(gomega.Equal(http.StatusForbidden))
+			gomega.Expect(getSelf()).To(gomega.Equal(http.StatusUnauthorized))
+			var refreshed models.User
+			statusCode, err := TestRequestV1().Post(routes.ResourceUsers+routes.ResourceToken+routes.ResourceRefresh).
+				RequestBody(&models.TokenRefresh{RefreshToken: user.RefreshToken}).ResponseBody(&refreshed).Do()
+			gomega.Expect(err).ToNot(gomega.HaveOccurred())
+			gomega.Expect(statusCode).To(gomega.Equal(http.StatusUnauthorized))
+		})
+
+		ginkgo.It("should log in once enabled", func() {
+			statusCode, err := TestRequestV1().Post(userPath(routes.ResourceEnable)).Header(theConf.AdminTokenHeader, adminTestToken).Do()
+			gomega.Expect(err).ToNot(gomega.HaveOccurred())
+			gomega.Expect(statusCode).To(gomega.Equal(http.StatusOK))
+			gomega.Expect(login(userAuth.Password)).To(gomega.Equal(http.StatusOK))
+		})
+	})
+
+	ginkgo.It("should delete the user, who can restore their account", func() {
+		var status models.UserDeletionStatus
+		statusCode, err := TestRequestV1().Delete(userPath("")).Header(theConf.AdminTokenHeader, adminTestToken).ResponseBody(&status).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusOK))
+		gomega.Expect(login(userAuth.Password)).To(gomega.Equal(http.StatusUnauthorized))
+
+		statusCode, err = TestRequestV1().Get(userPath("")).Header(theConf.AdminTokenHeader, adminTestToken).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusNotFound))
+	})
+
+	ginkgo.It("should restore a deleted user", func() {
+		statusCode, err := TestRequestV1().Delete(userPath("")).Header(theConf.AdminTokenHeader, adminTestToken).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusOK))
+
+		var adminUser models.AdminUser
+		statusCode, err = TestRequestV1().Post(userPath(routes.ResourceRestore)).Header(theConf.AdminTokenHeader, adminTestToken).ResponseBody(&adminUser).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusOK))
+		gomega.Expect(adminUser.ID).To(gomega.Equal(user.ID))
+		gomega.Expect(login(userAuth.Password)).To(gomega.Equal(http.StatusOK))
+
+		// only deleted users can be restored
+		statusCode, err = TestRequestV1().Post(userPath(routes.ResourceRestore)).Header(theConf.AdminTokenHeader, adminTestToken).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusNotFound))
+	})
+
+	ginkgo.It("should purge the user", func() {
+		statusCode, err := TestRequestV1().Delete(userPath("")).Header(theConf.AdminTokenHeader, adminTestToken).URLParam("purge", "true").Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusNoContent))
+
+		statusCode, err = TestRequestV1().Delete(userPath("")).Header(theConf.AdminTokenHeader, adminTestToken).URLParam("purge", "true").Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusNotFound))
+	})
+})
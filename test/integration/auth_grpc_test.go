@@ -12,6 +12,7 @@ import (
 	pEmpty "github.com/golang/protobuf/ptypes/empty"
 	"github.com/onsi/ginkgo"
 	"github.com/onsi/gomega"
+	"google.golang.org/grpc/codes"
 )
 
 var _ = ginkgo.Describe("Auth GRPC", func() {
@@ -48,6 +49,9 @@ var _ = ginkgo.Describe("Auth GRPC", func() {
 			grpcUser, err := grpcAuthClient.GetCurrentUser(ctx, &pEmpty.Empty{})
 			gomega.Expect(grpcUser).To(gomega.BeNil())
 			gomega.Expect(err).To(gomega.HaveOccurred())
+			code, apiCode := grpcError(err)
+			gomega.Expect(code).To(gomega.Equal(codes.Unauthenticated))
+			gomega.Expect(apiCode).To(gomega.Equal(constants.APIInvalidAuthToken))
 		})
 	})
 
@@ -214,6 +218,18 @@ var _ = ginkgo.Describe("Auth GRPC", func() {
 			gomega.Expect(protoUser.UserName).To(gomega.Equal(signup.UserName))
 			gomega.Expect(protoUser.AuthToken).ToNot(gomega.BeEmpty())
 		})
+		ginkgo.It("Returns unauthenticated for a wrong password", func() {
+			ctx := context.Background()
+			protoUser, authErr = grpcAuthClient.AuthUserByEmail(ctx, &signup)
+			gomega.Expect(authErr).ToNot(gomega.HaveOccurred())
+
+			wrong := signup
+			wrong.Password = signup.Password + "x"
+			_, err := grpcAuthClient.AuthUserByEmail(ctx, &wrong)
+			code, apiCode := grpcError(err)
+			gomega.Expect(code).To(gomega.Equal(codes.Unauthenticated))
+			gomega.Expect(apiCode).To(gomega.Equal(constants.APILoginSignupInvalidCombination))
+		})
 	})
 
 	ginkgo.Context("AuthUserByFacebook", func() {
@@ -400,6 +416,9 @@ var _ = ginkgo.Describe("Auth GRPC", func() {
 			})
 			gomega.Expect(grpcUser).To(gomega.BeNil())
 			gomega.Expect(err).To(gomega.HaveOccurred())
+			code, apiCode := grpcError(err)
+			gomega.Expect(code).To(gomega.Equal(codes.InvalidArgument))
+			gomega.Expect(apiCode).To(gomega.Equal(constants.APIInvalidRequest))
 		})
 
 	})
@@ -0,0 +1,145 @@
+package integration
+
+import (
+	"context"
+	"net/http"
+
+	lorem "github.com/axiomzen/golorem"
+	"github.com/axiomzen/zenauth/constants"
+	"github.com/axiomzen/zenauth/models"
+	"github.com/axiomzen/zenauth/protobuf"
+	"github.com/axiomzen/zenauth/routes"
+	pEmpty "github.com/golang/protobuf/ptypes/empty"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	"google.golang.org/grpc/codes"
+)
+
+var _ = ginkgo.Describe("Account GRPC", func() {
+	var (
+		signup models.Signup
+		user   models.User
+	)
+
+	ginkgo.BeforeEach(func() {
+		gomega.Expect(lorem.Fill(&signup)).To(gomega.Succeed())
+		statusCode, err := TestRequestV1().
+			Post(routes.ResourceUsers + routes.ResourceSignup).
+			RequestBody(&signup).
+			ResponseBody(&user).
+			Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusCreated))
+	})
+
+	ginkgo.AfterEach(func() {
+		deleteUser(user.ID)
+	})
+
+	ginkgo.Context("ChangePassword", func() {
+		ginkgo.It("Changes the password of the current user", func() {
+			ctx := getGRPCAuthenticatedContext(user.AuthToken)
+			change := protobuf.PasswordChange{OldPassword: signup.Password, NewPassword: lorem.Word(8, 16) + "1"}
+			grpcUser, err := grpcAuthClient.ChangePassword(ctx, &change)
+			gomega.Expect(err).ToNot(gomega.HaveOccurred())
+			gomega.Expect(grpcUser.Id).To(gomega.Equal(user.ID))
+			// every other session is logged out
+			gomega.Expect(grpcUser.AuthToken).ToNot(gomega.Equal(user.AuthToken))
+			_, err = grpcAuthClient.GetCurrentUser(getGRPCAuthenticatedContext(user.AuthToken), &pEmpty.Empty{})
+			gomega.Expect(err).To(gomega.HaveOccurred())
+
+			login := protobuf.UserEmailAuth{Email: user.Email, Password: change.NewPassword}
+			_, err = grpcAuthClient.AuthUserByEmail(context.Background(), &login)
+			gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		})
+		ginkgo.It("Returns invalid argument for a wrong old password", func() {
+			ctx := getGRPCAuthenticatedContext(user.AuthToken)
+			change := protobuf.PasswordChange{OldPassword: signup.Password + "x", NewPassword: lorem.Word(8, 16) + "1"}
+			_, err := grpcAuthClient.ChangePassword(ctx, &change)
+			code, apiCode := grpcError(err)
+			gomega.Expect(code).To(gomega.Equal(codes.InvalidArgument))
+			gomega.Expect(apiCode).To(gomega.Equal(constants.APIDatabaseUpdateUser))
+		})
+	})
+
+	ginkgo.Context("ForgotPassword and ResetPassword", func() {
+		ginkgo.It("Resets the password with the emailed token", func() {
+			_, err := grpcAuthClient.ForgotPassword(context.Background(), &protobuf.PasswordForgot{Email: user.Email})
+			gomega.Expect(err).ToNot(gomega.HaveOccurred())
+
+			// use the test route to get the actual token
+			var trt models.TestResetToken
+			statusCode, err := TestRequestV1().
+				Get(routes.ResourceTest+routes.ResourceUsers+routes.ResourcePasswordReset).URLParam("email", user.Email).
+				ResponseBody(&trt).
+				Do()
+			gomega.Expect(err).ToNot(gomega.HaveOccurred())
+			gomega.Expect(statusCode).To(gomega.Equal(http.StatusOK))
+
+			reset := protobuf.PasswordReset{Email: user.Email, Token: trt.Token, NewPassword: lorem.Word(8, 16) + "1"}
+			grpcUser, err := grpcAuthClient.ResetPassword(context.Background(), &reset)
+			gomega.Expect(err).ToNot(gomega.HaveOccurred())
+			gomega.Expect(grpcUser.Id).To(gomega.Equal(user.ID))
+			gomega.Expect(grpcUser.AuthToken).ToNot(gomega.BeEmpty())
+
+			// the token can only be used once
+			reset.NewPassword = lorem.Word(8, 16) + "2"
+			_, err = grpcAuthClient.ResetPassword(context.Background(), &reset)
+			code, _ := grpcError(err)
+			gomega.Expect(code).To(gomega.Equal(codes.NotFound))
+		})
+		ginkgo.It("Returns not found for an unknown email", func() {
+			_, err := grpcAuthClient.ForgotPassword(context.Background(), &protobuf.PasswordForgot{Email: lorem.Email()})
+			code, apiCode := grpcError(err)
+			gomega.Expect(code).To(gomega.Equal(codes.NotFound))
+			gomega.Expect(apiCode).To(gomega.Equal(constants.APIEmailNotFound))
+		})
+		ginkgo.It("Returns invalid argument for an invalid token", func() {
+			reset := protobuf.PasswordReset{Email: user.Email, Token: "invalidToken", NewPassword: lorem.Word(8, 16) + "1"}
+			_, err := grpcAuthClient.ResetPassword(context.Background(), &reset)
+			code, apiCode := grpcError(err)
+			gomega.Expect(code).To(gomega.Equal(codes.InvalidArgument))
+			gomega.Expect(apiCode).To(gomega.Equal(constants.APIInvalidResetToken))
+		})
+	})
+
+	ginkgo.Context("VerifyEmail", func() {
+		ginkgo.It("Returns invalid argument for an invalid token", func() {
+			verification := protobuf.EmailVerification{Email: user.Email, Token: "invalidToken"}
+			_, err := grpcAuthClient.VerifyEmail(context.Background(), &verification)
+			code, apiCode := grpcError(err)
+			gomega.Expect(code).To(gomega.Equal(codes.InvalidArgument))
+			gomega.Expect(apiCode).To(gomega.Equal(constants.APIInvalidVerifyEmailToken))
+		})
+	})
+
+	ginkgo.Context("Exists", func() {
+		ginkgo.It("Tells whether a user has the email", func() {
+			result, err := grpcAuthClient.Exists(context.Background(), &protobuf.ExistsCheck{Email: user.Email})
+			gomega.Expect(err).ToNot(gomega.HaveOccurred())
+			gomega.Expect(result.Exists).To(gomega.BeTrue())
+
+			result, err = grpcAuthClient.Exists(context.Background(), &protobuf.ExistsCheck{Email: lorem.Email()})
+			gomega.Expect(err).ToNot(gomega.HaveOccurred())
+			gomega.Expect(result.Exists).To(gomega.BeFalse())
+		})
+	})
+
+	ginkgo.Context("CreateEmailInvitations", func() {
+		ginkgo.It("Invites the emails", func() {
+			ctx := getGRPCAuthenticatedContext(user.AuthToken)
+			invites := protobuf.InviteCodes{InviteCodes: []string{lorem.Email(), lorem.Email()}}
+			users, err := grpcAuthClient.CreateEmailInvitations(ctx, &invites)
+			gomega.Expect(err).ToNot(gomega.HaveOccurred())
+			gomega.Expect(users.Users).To(gomega.HaveLen(2))
+		})
+		ginkgo.It("Returns invalid argument for the email of a user", func() {
+			ctx := getGRPCAuthenticatedContext(user.AuthToken)
+			invites := protobuf.InviteCodes{InviteCodes: []string{user.Email}}
+			_, err := grpcAuthClient.CreateEmailInvitations(ctx, &invites)
+			code, apiCode := grpcError(err)
+			gomega.Expect(code).To(gomega.Equal(codes.InvalidArgument))
+			gomega.Expect(apiCode).To(gomega.Equal(constants.APIDatabaseCreateInvitation))
+		})
+	})
+})
@@ -0,0 +1,59 @@
+package integration
+
+import (
+	"fmt"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	"golang.org/x/net/context"
+	google_grpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+)
+
+var _ = ginkgo.Describe("gRPC health and reflection", func() {
+
+	var conn *google_grpc.ClientConn
+
+	ginkgo.BeforeEach(func() {
+		// health checks and reflection don't need the API token
+		var err error
+		conn, err = google_grpc.Dial(fmt.Sprintf(":%v", theConf.GRPCPort), google_grpc.WithInsecure())
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+	})
+
+	ginkgo.AfterEach(func() {
+		conn.Close()
+	})
+
+	ginkgo.It("should report the server and the Auth service as serving", func() {
+		client := healthpb.NewHealthClient(conn)
+		for _, service := range []string{"", "protobuf.Auth"} {
+			resp, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+			gomega.Expect(err).ToNot(gomega.HaveOccurred())
+			gomega.Expect(resp.Status).To(gomega.Equal(healthpb.HealthCheckResponse_SERVING))
+		}
+
+		_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "protobuf.Nothing"})
+		code, _ := grpcError(err)
+		gomega.Expect(code).To(gomega.Equal(codes.NotFound))
+	})
+
+	ginkgo.It("should list the services by reflection", func() {
+		stream, err := rpb.NewServerReflectionClient(conn).ServerReflectionInfo(context.Background())
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		err = stream.Send(&rpb.ServerReflectionRequest{MessageRequest: &rpb.ServerReflectionRequest_ListServices{}})
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		resp, err := stream.Recv()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(stream.CloseSend()).To(gomega.Succeed())
+
+		var services []string
+		for _, service := range resp.GetListServicesResponse().Service {
+			services = append(services, service.Name)
+		}
+		gomega.Expect(services).To(gomega.ContainElement("protobuf.Auth"))
+		gomega.Expect(services).To(gomega.ContainElement("grpc.health.v1.Health"))
+	})
+})
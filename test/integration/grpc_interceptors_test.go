@@ -0,0 +1,68 @@
+package integration
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/axiomzen/zenauth/constants"
+	"github.com/axiomzen/zenauth/protobuf"
+	pEmpty "github.com/golang/protobuf/ptypes/empty"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	"github.com/twinj/uuid"
+	"golang.org/x/net/context"
+	google_grpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+var _ = ginkgo.Describe("gRPC interceptors", func() {
+
+	ginkgo.It("should refuse calls without a valid API token", func() {
+		// this connection doesn't send the API token of the tests
+		conn, err := google_grpc.Dial(fmt.Sprintf(":%v", theConf.GRPCPort), google_grpc.WithInsecure())
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		defer conn.Close()
+		client := protobuf.NewAuthClient(conn)
+
+		_, err = client.AuthUserByEmail(context.Background(), &protobuf.UserEmailAuth{Email: "nobody@example.com", Password: "password"})
+		code, apiCode := grpcError(err)
+		gomega.Expect(code).To(gomega.Equal(codes.Unauthenticated))
+		gomega.Expect(apiCode).To(gomega.Equal(constants.APIUnauthorized))
+
+		ctx := metadata.NewContext(context.Background(), metadata.Pairs(theConf.APITokenHeader, "wrong"))
+		_, err = client.AuthUserByEmail(ctx, &protobuf.UserEmailAuth{Email: "nobody@example.com", Password: "password"})
+		code, apiCode = grpcError(err)
+		gomega.Expect(code).To(gomega.Equal(codes.Unauthenticated))
+		gomega.Expect(apiCode).To(gomega.Equal(constants.APIUnauthorized))
+	})
+
+	ginkgo.It("should refuse calls without the access of the method", func() {
+		_, err := grpcAuthClient.GetCurrentUser(context.Background(), &pEmpty.Empty{})
+		code, apiCode := grpcError(err)
+		gomega.Expect(code).To(gomega.Equal(codes.Unauthenticated))
+		gomega.Expect(apiCode).To(gomega.Equal(constants.APIUnauthorized))
+
+		_, err = grpcAuthClient.ListRoles(context.Background(), &pEmpty.Empty{})
+		code, apiCode = grpcError(err)
+		gomega.Expect(code).To(gomega.Equal(codes.PermissionDenied))
+		gomega.Expect(apiCode).To(gomega.Equal(constants.APIAdminUnauthorized))
+	})
+
+	ginkgo.It("should send back the request id", func() {
+		requestIDHeader := strings.ToLower(theConf.RequestIDHeader)
+		requestID := uuid.NewV4().String()
+		check := &protobuf.PermissionCheck{UserId: uuid.NewV4().String(), Permission: "posts:read"}
+		var header metadata.MD
+		ctx := metadata.NewContext(context.Background(), metadata.Pairs(requestIDHeader, requestID))
+		_, err := grpcAuthClient.CheckPermission(ctx, check, google_grpc.Header(&header))
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(header[requestIDHeader]).To(gomega.Equal([]string{requestID}))
+
+		// calls without one get a new one
+		_, err = grpcAuthClient.CheckPermission(context.Background(), check, google_grpc.Header(&header))
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(header[requestIDHeader]).To(gomega.HaveLen(1))
+		gomega.Expect(header[requestIDHeader][0]).ToNot(gomega.Equal(requestID))
+	})
+})
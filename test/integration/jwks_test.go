@@ -0,0 +1,47 @@
+package integration
+
+import (
+	"net/http"
+
+	"github.com/axiomzen/golorem"
+	"github.com/axiomzen/yawgh"
+	"github.com/axiomzen/zenauth/helpers"
+	"github.com/axiomzen/zenauth/models"
+	"github.com/axiomzen/zenauth/routes"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	"gopkg.in/dgrijalva/jwt-go.v3"
+)
+
+var _ = ginkgo.Describe("JWKS", func() {
+
+	ginkgo.It("should publish the key set without an api token", func() {
+		var jwks helpers.JWKS
+		statusCode, err := yawgh.New().
+			Transport("http").
+			DomainHost(theConf.TestDomainHost).
+			Port(uint(theConf.Port)).
+			Marshaler(marshaler).
+			Unmarshaler(unmarshaler).
+			ResponseBody(&jwks).
+			Get(routes.ResourceWellKnown + routes.ResourceJWKS).
+			Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusOK))
+		// the tests sign with the hash secret, which must never be published
+		gomega.Expect(jwks.Keys).To(gomega.BeEmpty())
+	})
+
+	ginkgo.It("should set the kid header on issued tokens", func() {
+		var userAuth models.UserAuth
+		var user models.User
+		gomega.Expect(lorem.Fill(&userAuth)).To(gomega.Succeed())
+		statusCode, err := TestRequestV1().Post(routes.ResourceUsers + routes.ResourceSignup).RequestBody(&userAuth).ResponseBody(&user).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusCreated))
+		defer deleteUser(user.ID)
+
+		token, _ := new(jwt.Parser).Parse(user.AuthToken, func(*jwt.Token) (interface{}, error) { return theConf.HashSecretBytes, nil })
+		gomega.Expect(token.Header["kid"]).To(gomega.Equal(theConf.JwtKeyring.Current.ID))
+	})
+})
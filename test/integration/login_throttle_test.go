@@ -0,0 +1,133 @@
+package integration
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/axiomzen/golorem"
+	"github.com/axiomzen/zenauth/constants"
+	"github.com/axiomzen/zenauth/data"
+	"github.com/axiomzen/zenauth/models"
+	"github.com/axiomzen/zenauth/protobuf"
+	"github.com/axiomzen/zenauth/routes"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	"google.golang.org/grpc/codes"
+)
+
+var _ = ginkgo.Describe("Login throttling", func() {
+
+	var (
+		signup models.Signup
+		user   models.User
+	)
+
+	login := func(password string, errResp *models.ErrorResponse) (int, string) {
+		var retryAfter string
+		auth := models.Login{Email: signup.Email, Password: password}
+		statusCode, err := TestRequestV1().Post(routes.ResourceUsers + routes.ResourceLogin).
+			RequestBody(&auth).
+			ErrorResponseBody(errResp).
+			ResponseInterceptor(responseIntFunc(func(r *http.Response, body []byte, contentType string) error {
+				retryAfter = r.Header.Get("Retry-After")
+				return nil
+			})).
+			Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		return statusCode, retryAfter
+	}
+
+	lockOut := func() {
+		for i := 0; i < theConf.LoginAccountThrottle.LockoutThreshold; i++ {
+			var errResp models.ErrorResponse
+			statusCode, _ := login("wrong password", &errResp)
+			gomega.Expect(statusCode).To(gomega.Equal(http.StatusUnauthorized))
+			// wait out the backoff, it is a millisecond per failure in the tests
+			time.Sleep(50 * time.Millisecond)
+		}
+	}
+
+	ginkgo.BeforeEach(func() {
+		user = models.User{}
+		gomega.Expect(lorem.Fill(&signup)).To(gomega.Succeed())
+		statusCode, err := TestRequestV1().Post(routes.ResourceUsers + routes.ResourceSignup).RequestBody(&signup).ResponseBody(&user).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusCreated))
+	})
+
+	ginkgo.AfterEach(func() {
+		deleteUser(user.ID)
+	})
+
+	ginkgo.It("should lock the account after too many failed logins", func() {
+		lockOut()
+
+		var errResp models.ErrorResponse
+		statusCode, retryAfter := login(signup.Password, &errResp)
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusTooManyRequests))
+		gomega.Expect(errResp.Code).To(gomega.Equal(constants.APILoginThrottled))
+		seconds, err := strconv.Atoi(retryAfter)
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(time.Duration(seconds) * time.Second).To(gomega.BeNumerically("~", theConf.LoginLockoutDuration, time.Minute))
+
+		_, err = grpcAuthClient.AuthUserByEmail(context.Background(), &protobuf.UserEmailAuth{Email: signup.Email, Password: signup.Password})
+		gomega.Expect(err).To(gomega.HaveOccurred())
+		code, apiCode := grpcError(err)
+		gomega.Expect(code).To(gomega.Equal(codes.ResourceExhausted))
+		gomega.Expect(apiCode).To(gomega.Equal(constants.APILoginThrottled))
+	})
+
+	ginkgo.It("should log in once an admin unlocks the account", func() {
+		lockOut()
+
+		statusCode, err := TestRequestV1().Post(routes.ResourceAdmins+routes.ResourceUsers+"/"+user.ID+routes.ResourceUnlock).Header(theConf.AdminTokenHeader, adminTestToken).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusNoContent))
+
+		var errResp models.ErrorResponse
+		statusCode, _ = login(signup.Password, &errResp)
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusOK))
+
+		dal, err := data.CreateProvider(theConf)
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		defer dal.Close()
+		var entries models.AuditEntries
+		gomega.Expect(dal.GetUserAuditEntries(user.ID, &entries)).To(gomega.Succeed())
+		events := make([]string, len(entries))
+		for i, entry := range entries {
+			events[i] = entry.Event
+		}
+		gomega.Expect(events).To(gomega.ContainElement(constants.AuditEventAccountUnlocked))
+	})
+
+	ginkgo.It("should forget the failures after a successful login", func() {
+		for i := 0; i < theConf.LoginAccountThrottle.LockoutThreshold-1; i++ {
+			var errResp models.ErrorResponse
+			statusCode, _ := login("wrong password", &errResp)
+			gomega.Expect(statusCode).To(gomega.Equal(http.StatusUnauthorized))
+			time.Sleep(50 * time.Millisecond)
+		}
+		var errResp models.ErrorResponse
+		statusCode, _ := login(signup.Password, &errResp)
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusOK))
+
+		statusCode, _ = login("wrong password", &errResp)
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusUnauthorized))
+		statusCode, _ = login(signup.Password, &errResp)
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusOK))
+	})
+
+	ginkgo.It("should count failed gRPC logins too", func() {
+		for i := 0; i < theConf.LoginAccountThrottle.LockoutThreshold; i++ {
+			_, err := grpcAuthClient.AuthUserByEmail(context.Background(), &protobuf.UserEmailAuth{Email: signup.Email, Password: "wrong password"})
+			gomega.Expect(err).To(gomega.HaveOccurred())
+			time.Sleep(50 * time.Millisecond)
+		}
+
+		var errResp models.ErrorResponse
+		statusCode, _ := login(signup.Password, &errResp)
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusTooManyRequests))
+	})
+})
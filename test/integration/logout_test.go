@@ -0,0 +1,111 @@
+package integration
+
+import (
+	"net/http"
+
+	"github.com/axiomzen/golorem"
+	"github.com/axiomzen/zenauth/constants"
+	"github.com/axiomzen/zenauth/models"
+	"github.com/axiomzen/zenauth/routes"
+	pEmpty "github.com/golang/protobuf/ptypes/empty"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+func getSelf(token string, errResp *models.ErrorResponse) int {
+	statusCode, err := TestRequestV1().Get(routes.ResourceUsers).Header(theConf.AuthTokenHeader, token).ErrorResponseBody(errResp).Do()
+	gomega.Expect(err).ToNot(gomega.HaveOccurred())
+	return statusCode
+}
+
+var _ = ginkgo.Describe("Logout", func() {
+
+	var (
+		userAuth models.UserAuth
+		user     models.User
+		other    models.User
+	)
+
+	ginkgo.BeforeEach(func() {
+		gomega.Expect(lorem.Fill(&userAuth)).To(gomega.Succeed())
+		statusCode, err := TestRequestV1().Post(routes.ResourceUsers + routes.ResourceSignup).RequestBody(&userAuth).ResponseBody(&user).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusCreated))
+
+		// a second session for the same user
+		statusCode, err = TestRequestV1().Post(routes.ResourceUsers + routes.ResourceLogin).RequestBody(&userAuth).ResponseBody(&other).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusOK))
+	})
+
+	ginkgo.AfterEach(func() {
+		deleteUser(user.ID)
+	})
+
+	ginkgo.It("should revoke only the current token on logout", func() {
+		statusCode, err := TestRequestV1().Post(routes.ResourceUsers+routes.ResourceLogout).Header(theConf.AuthTokenHeader, user.AuthToken).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusNoContent))
+
+		var errResp models.ErrorResponse
+		gomega.Expect(getSelf(user.AuthToken, &errResp)).To(gomega.Equal(http.StatusUnauthorized))
+		gomega.Expect(errResp.Code).To(gomega.Equal(constants.APIRevokedAuthToken))
+
+		gomega.Expect(getSelf(other.AuthToken, &errResp)).To(gomega.Equal(http.StatusOK))
+	})
+
+	ginkgo.It("should revoke only the refresh tokens of the current login on logout", func() {
+		statusCode, err := TestRequestV1().Post(routes.ResourceUsers+routes.ResourceLogout).Header(theConf.AuthTokenHeader, user.AuthToken).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusNoContent))
+
+		var refreshed models.User
+		var errResp models.ErrorResponse
+		gomega.Expect(refreshToken(user.RefreshToken, &refreshed, &errResp)).To(gomega.Equal(http.StatusUnauthorized))
+		gomega.Expect(refreshToken(other.RefreshToken, &refreshed, &errResp)).To(gomega.Equal(http.StatusOK))
+	})
+
+	ginkgo.It("should not hand back a revoked token on login", func() {
+		statusCode, err := TestRequestV1().Post(routes.ResourceUsers+routes.ResourceLogout).Header(theConf.AuthTokenHeader, user.AuthToken).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusNoContent))
+
+		var loggedIn models.User
+		statusCode, err = TestRequestV1().Post(routes.ResourceUsers+routes.ResourceLogin).Header(theConf.AuthTokenHeader, user.AuthToken).RequestBody(&userAuth).ResponseBody(&loggedIn).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusOK))
+		gomega.Expect(loggedIn.AuthToken).ToNot(gomega.Equal(user.AuthToken))
+	})
+
+	ginkgo.It("should revoke every token on logout_all", func() {
+		statusCode, err := TestRequestV1().Post(routes.ResourceUsers+routes.ResourceLogoutAll).Header(theConf.AuthTokenHeader, user.AuthToken).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusNoContent))
+
+		var errResp models.ErrorResponse
+		gomega.Expect(getSelf(user.AuthToken, &errResp)).To(gomega.Equal(http.StatusUnauthorized))
+		gomega.Expect(getSelf(other.AuthToken, &errResp)).To(gomega.Equal(http.StatusUnauthorized))
+
+		// refresh tokens are revoked too
+		var refreshed models.User
+		gomega.Expect(refreshToken(other.RefreshToken, &refreshed, &errResp)).To(gomega.Equal(http.StatusUnauthorized))
+
+		var loggedIn models.User
+		statusCode, err = TestRequestV1().Post(routes.ResourceUsers + routes.ResourceLogin).RequestBody(&userAuth).ResponseBody(&loggedIn).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusOK))
+		gomega.Expect(getSelf(loggedIn.AuthToken, &errResp)).To(gomega.Equal(http.StatusOK))
+	})
+
+	ginkgo.It("should reject revoked tokens over GRPC", func() {
+		_, err := grpcAuthClient.GetCurrentUser(getGRPCAuthenticatedContext(user.AuthToken), &pEmpty.Empty{})
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+
+		statusCode, err := TestRequestV1().Post(routes.ResourceUsers+routes.ResourceLogout).Header(theConf.AuthTokenHeader, user.AuthToken).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusNoContent))
+
+		_, err = grpcAuthClient.GetCurrentUser(getGRPCAuthenticatedContext(user.AuthToken), &pEmpty.Empty{})
+		gomega.Expect(err).To(gomega.HaveOccurred())
+	})
+})
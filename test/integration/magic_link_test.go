@@ -0,0 +1,123 @@
+package integration
+
+import (
+	"net/http"
+
+	"github.com/axiomzen/golorem"
+	"github.com/axiomzen/zenauth/constants"
+	"github.com/axiomzen/zenauth/models"
+	"github.com/axiomzen/zenauth/routes"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("Magic Link", func() {
+
+	var (
+		userAuth models.UserAuth
+		user     models.User
+	)
+
+	ginkgo.BeforeEach(func() {
+		gomega.Expect(lorem.Fill(&userAuth)).To(gomega.Succeed())
+		statusCode, err := TestRequestV1().Post(routes.ResourceUsers + routes.ResourceSignup).RequestBody(&userAuth).ResponseBody(&user).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusCreated))
+	})
+
+	ginkgo.AfterEach(func() {
+		deleteUser(user.ID)
+	})
+
+	send := func(email string) int {
+		statusCode, err := TestRequestV1().Post(routes.ResourceUsers + routes.ResourceMagicLink).RequestBody(&models.MagicLinkRequest{Email: email}).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		return statusCode
+	}
+
+	token := func() string {
+		var trt models.TestResetToken
+		statusCode, err := TestRequestV1().Get(routes.ResourceTest+routes.ResourceUsers+routes.ResourceMagicLinkToken).URLParam("email", user.Email).ResponseBody(&trt).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusOK))
+		gomega.Expect(trt.Token).ToNot(gomega.BeEmpty())
+		return trt.Token
+	}
+
+	consume := func(login *models.MagicLinkLogin, loggedIn *models.User, errResp *models.ErrorResponse) int {
+		statusCode, err := TestRequestV1().Post(routes.ResourceUsers + routes.ResourceMagicLink + routes.ResourceConsume).RequestBody(login).ResponseBody(loggedIn).ErrorResponseBody(errResp).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		return statusCode
+	}
+
+	ginkgo.It("should fail if using an email that is not a valid user", func() {
+		gomega.Expect(send(lorem.Email())).To(gomega.Equal(http.StatusBadRequest))
+	})
+
+	ginkgo.Context("User has requested a magic link", func() {
+
+		var magicToken string
+
+		ginkgo.BeforeEach(func() {
+			gomega.Expect(send(user.Email)).To(gomega.Equal(http.StatusNoContent))
+			magicToken = token()
+		})
+
+		ginkgo.It("should log in with the link once", func() {
+			var loggedIn models.User
+			var errResp models.ErrorResponse
+			login := models.MagicLinkLogin{Email: user.Email, Token: magicToken}
+			gomega.Expect(consume(&login, &loggedIn, &errResp)).To(gomega.Equal(http.StatusOK))
+			gomega.Expect(loggedIn.ID).To(gomega.Equal(user.ID))
+			gomega.Expect(loggedIn.AuthToken).ToNot(gomega.BeEmpty())
+			gomega.Expect(loggedIn.Verified).To(gomega.BeTrue())
+
+			gomega.Expect(consume(&login, &loggedIn, &errResp)).To(gomega.Equal(http.StatusUnauthorized))
+			gomega.Expect(errResp.Code).To(gomega.Equal(constants.APIInvalidMagicLinkToken))
+		})
+
+		ginkgo.It("should log in by following the link", func() {
+			var loggedIn models.User
+			statusCode, err := TestRequestV1().Get(routes.ResourceUsers+routes.ResourceMagicLink+routes.ResourceConsume).
+				URLParam("email", user.Email).URLParam("token", magicToken).ResponseBody(&loggedIn).Do()
+			gomega.Expect(err).ToNot(gomega.HaveOccurred())
+			gomega.Expect(statusCode).To(gomega.Equal(http.StatusOK))
+			gomega.Expect(loggedIn.AuthToken).ToNot(gomega.BeEmpty())
+		})
+
+		ginkgo.It("should not log in with a token for another email", func() {
+			var loggedIn models.User
+			var errResp models.ErrorResponse
+			login := models.MagicLinkLogin{Email: lorem.Email(), Token: magicToken}
+			gomega.Expect(consume(&login, &loggedIn, &errResp)).To(gomega.Equal(http.StatusUnauthorized))
+			gomega.Expect(errResp.Code).To(gomega.Equal(constants.APIInvalidMagicLinkToken))
+		})
+
+		ginkgo.It("should not log in with a password reset token", func() {
+			statusCode, err := TestRequestV1().Put(routes.ResourceUsers+routes.ResourceForgotPassword).URLParam("email", user.Email).Do()
+			gomega.Expect(err).ToNot(gomega.HaveOccurred())
+			gomega.Expect(statusCode).To(gomega.Equal(http.StatusNoContent))
+			var trt models.TestResetToken
+			statusCode, err = TestRequestV1().Get(routes.ResourceTest+routes.ResourceUsers+routes.ResourcePasswordReset).URLParam("email", user.Email).ResponseBody(&trt).Do()
+			gomega.Expect(err).ToNot(gomega.HaveOccurred())
+			gomega.Expect(statusCode).To(gomega.Equal(http.StatusOK))
+
+			var loggedIn models.User
+			var errResp models.ErrorResponse
+			login := models.MagicLinkLogin{Email: user.Email, Token: trt.Token}
+			gomega.Expect(consume(&login, &loggedIn, &errResp)).To(gomega.Equal(http.StatusUnauthorized))
+			gomega.Expect(errResp.Code).To(gomega.Equal(constants.APIInvalidMagicLinkToken))
+		})
+
+		ginkgo.It("should only accept the latest link", func() {
+			gomega.Expect(send(user.Email)).To(gomega.Equal(http.StatusNoContent))
+			latest := token()
+			gomega.Expect(latest).ToNot(gomega.Equal(magicToken))
+
+			var loggedIn models.User
+			var errResp models.ErrorResponse
+			gomega.Expect(consume(&models.MagicLinkLogin{Email: user.Email, Token: magicToken}, &loggedIn, &errResp)).To(gomega.Equal(http.StatusUnauthorized))
+			gomega.Expect(consume(&models.MagicLinkLogin{Email: user.Email, Token: latest}, &loggedIn, &errResp)).To(gomega.Equal(http.StatusOK))
+		})
+	})
+})
@@ -0,0 +1,240 @@
+package integration
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/axiomzen/golorem"
+	"github.com/axiomzen/zenauth/constants"
+	"github.com/axiomzen/zenauth/helpers"
+	"github.com/axiomzen/zenauth/models"
+	"github.com/axiomzen/zenauth/protobuf"
+	"github.com/axiomzen/zenauth/routes"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	context "golang.org/x/net/context"
+)
+
+func totpCode(secret string, t time.Time) string {
+	code, err := helpers.TOTPCode(secret, t)
+	gomega.Expect(err).ToNot(gomega.HaveOccurred())
+	return code
+}
+
+var _ = ginkgo.Describe("MFA", func() {
+
+	var (
+		userAuth   models.UserAuth
+		user       models.User
+		enrollment models.TOTPEnrollment
+	)
+
+	ginkgo.BeforeEach(func() {
+		gomega.Expect(lorem.Fill(&userAuth)).To(gomega.Succeed())
+		statusCode, err := TestRequestV1().Post(routes.ResourceUsers + routes.ResourceSignup).RequestBody(&userAuth).ResponseBody(&user).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusCreated))
+
+		statusCode, err = TestRequestV1().Post(routes.ResourceUsers+routes.ResourceMFA+routes.ResourceTOTP).Header(theConf.AuthTokenHeader, user.AuthToken).ResponseBody(&enrollment).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusOK))
+		gomega.Expect(enrollment.Secret).ToNot(gomega.BeEmpty())
+		gomega.Expect(enrollment.URI).To(gomega.HavePrefix("otpauth://totp/"))
+		gomega.Expect(enrollment.RecoveryCodes).To(gomega.HaveLen(int(theConf.RecoveryCodeCount)))
+	})
+
+	ginkgo.AfterEach(func() {
+		deleteUser(user.ID)
+	})
+
+	confirm := func(code string, errResp *models.ErrorResponse) int {
+		var confirmed models.User
+		statusCode, err := TestRequestV1().Post(routes.ResourceUsers+routes.ResourceMFA+routes.ResourceTOTP+routes.ResourceConfirm).Header(theConf.AuthTokenHeader, user.AuthToken).RequestBody(&models.MFACode{Code: code}).ResponseBody(&confirmed).ErrorResponseBody(errResp).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		return statusCode
+	}
+
+	login := func() models.MFAChallenge {
+		var challenge models.MFAChallenge
+		statusCode, err := TestRequestV1().Post(routes.ResourceUsers + routes.ResourceLogin).RequestBody(&userAuth).ResponseBody(&challenge).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusOK))
+		gomega.Expect(challenge.MFARequired).To(gomega.BeTrue())
+		gomega.Expect(challenge.MFAToken).ToNot(gomega.BeEmpty())
+		return challenge
+	}
+
+	loginMFA := func(mfaLogin *models.MFALogin, loggedIn *models.User, errResp *models.ErrorResponse) int {
+		statusCode, err := TestRequestV1().Post(routes.ResourceUsers + routes.ResourceLogin + routes.ResourceMFA).RequestBody(mfaLogin).ResponseBody(loggedIn).ErrorResponseBody(errResp).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		return statusCode
+	}
+
+	ginkgo.It("should not require a code before enrollment is confirmed", func() {
+		var loggedIn models.User
+		statusCode, err := TestRequestV1().Post(routes.ResourceUsers + routes.ResourceLogin).RequestBody(&userAuth).ResponseBody(&loggedIn).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusOK))
+		gomega.Expect(loggedIn.AuthToken).ToNot(gomega.BeEmpty())
+	})
+
+	ginkgo.It("should reject an invalid confirmation code", func() {
+		var errResp models.ErrorResponse
+		gomega.Expect(confirm("000000", &errResp)).To(gomega.Equal(http.StatusUnauthorized))
+		gomega.Expect(errResp.Code).To(gomega.Equal(constants.APIInvalidMFACode))
+	})
+
+	ginkgo.It("should require a code to login once enabled", func() {
+		var errResp models.ErrorResponse
+		gomega.Expect(confirm(totpCode(enrollment.Secret, time.Now()), &errResp)).To(gomega.Equal(http.StatusOK))
+
+		challenge := login()
+
+		// the mfa token is not an auth token
+		gomega.Expect(getSelf(challenge.MFAToken, &errResp)).To(gomega.Equal(http.StatusUnauthorized))
+
+		var loggedIn models.User
+		gomega.Expect(loginMFA(&models.MFALogin{MFAToken: challenge.MFAToken, Code: "000000"}, &loggedIn, &errResp)).To(gomega.Equal(http.StatusUnauthorized))
+		gomega.Expect(errResp.Code).To(gomega.Equal(constants.APIInvalidMFACode))
+
+		// the confirmation code was used, so use the next one
+		mfaLogin := models.MFALogin{MFAToken: challenge.MFAToken, Code: totpCode(enrollment.Secret, time.Now().Add(30*time.Second))}
+		gomega.Expect(loginMFA(&mfaLogin, &loggedIn, &errResp)).To(gomega.Equal(http.StatusOK))
+		gomega.Expect(loggedIn.AuthToken).ToNot(gomega.BeEmpty())
+		gomega.Expect(loggedIn.TOTPEnabled).To(gomega.BeTrue())
+		gomega.Expect(getSelf(loggedIn.AuthToken, &errResp)).To(gomega.Equal(http.StatusOK))
+
+		// codes can't be replayed
+		gomega.Expect(loginMFA(&mfaLogin, &loggedIn, &errResp)).To(gomega.Equal(http.StatusUnauthorized))
+		gomega.Expect(errResp.Code).To(gomega.Equal(constants.APIInvalidMFACode))
+	})
+
+	ginkgo.It("should reject an invalid mfa token", func() {
+		var errResp models.ErrorResponse
+		gomega.Expect(confirm(totpCode(enrollment.Secret, time.Now()), &errResp)).To(gomega.Equal(http.StatusOK))
+
+		var loggedIn models.User
+		mfaLogin := models.MFALogin{MFAToken: user.AuthToken, Code: totpCode(enrollment.Secret, time.Now().Add(30*time.Second))}
+		gomega.Expect(loginMFA(&mfaLogin, &loggedIn, &errResp)).To(gomega.Equal(http.StatusUnauthorized))
+		gomega.Expect(errResp.Code).To(gomega.Equal(constants.APIInvalidMFAToken))
+	})
+
+	ginkgo.It("should revoke the mfa token after too many bad codes", func() {
+		var errResp models.ErrorResponse
+		gomega.Expect(confirm(totpCode(enrollment.Secret, time.Now()), &errResp)).To(gomega.Equal(http.StatusOK))
+
+		challenge := login()
+		var loggedIn models.User
+		for i := 0; i < int(theConf.MFAMaxAttempts); i++ {
+			gomega.Expect(loginMFA(&models.MFALogin{MFAToken: challenge.MFAToken, Code: "000000"}, &loggedIn, &errResp)).To(gomega.Equal(http.StatusUnauthorized))
+			gomega.Expect(errResp.Code).To(gomega.Equal(constants.APIInvalidMFACode))
+			// only the mfa token's own count is under test
+			statusCode, err := TestRequestV1().Delete(routes.ResourceTest+routes.ResourceUsers+routes.ResourceLoginThrottle).URLParam("email", userAuth.Email).Do()
+			gomega.Expect(err).ToNot(gomega.HaveOccurred())
+			gomega.Expect(statusCode).To(gomega.Equal(http.StatusNoContent))
+		}
+
+		mfaLogin := models.MFALogin{MFAToken: challenge.MFAToken, Code: totpCode(enrollment.Secret, time.Now().Add(30*time.Second))}
+		gomega.Expect(loginMFA(&mfaLogin, &loggedIn, &errResp)).To(gomega.Equal(http.StatusUnauthorized))
+		gomega.Expect(errResp.Code).To(gomega.Equal(constants.APIInvalidMFAToken))
+
+		// a new login gets a new mfa token
+		mfaLogin.MFAToken = login().MFAToken
+		gomega.Expect(loginMFA(&mfaLogin, &loggedIn, &errResp)).To(gomega.Equal(http.StatusOK))
+	})
+
+	ginkgo.It("should disable with a code", func() {
+		var errResp models.ErrorResponse
+		gomega.Expect(confirm(totpCode(enrollment.Secret, time.Now()), &errResp)).To(gomega.Equal(http.StatusOK))
+
+		var disabled models.User
+		code := models.MFACode{Code: totpCode(enrollment.Secret, time.Now().Add(30*time.Second))}
+		statusCode, err := TestRequestV1().Post(routes.ResourceUsers+routes.ResourceMFA+routes.ResourceTOTP+routes.ResourceDisable).Header(theConf.AuthTokenHeader, user.AuthToken).RequestBody(&code).ResponseBody(&disabled).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusOK))
+		gomega.Expect(disabled.TOTPEnabled).To(gomega.BeFalse())
+
+		var loggedIn models.User
+		statusCode, err = TestRequestV1().Post(routes.ResourceUsers + routes.ResourceLogin).RequestBody(&userAuth).ResponseBody(&loggedIn).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusOK))
+		gomega.Expect(loggedIn.AuthToken).ToNot(gomega.BeEmpty())
+	})
+
+	ginkgo.It("should require a code to login over GRPC", func() {
+		var errResp models.ErrorResponse
+		gomega.Expect(confirm(totpCode(enrollment.Secret, time.Now()), &errResp)).To(gomega.Equal(http.StatusOK))
+
+		challenge, err := grpcAuthClient.AuthUserByEmail(context.Background(), &protobuf.UserEmailAuth{Email: userAuth.Email, Password: userAuth.Password})
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(challenge.Status).To(gomega.Equal(protobuf.UserStatus_mfa_required))
+		gomega.Expect(challenge.AuthToken).To(gomega.BeEmpty())
+
+		loggedIn, err := grpcAuthClient.AuthUserByMFA(context.Background(), &protobuf.MFALogin{MfaToken: challenge.MfaToken, Code: totpCode(enrollment.Secret, time.Now().Add(30*time.Second))})
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(loggedIn.AuthToken).ToNot(gomega.BeEmpty())
+	})
+
+	ginkgo.It("should require a code to login with Facebook", func() {
+		var errResp models.ErrorResponse
+		fbLink := models.FacebookUpdate{FacebookUser: models.FacebookUser{FacebookID: FacebookTestId, FacebookToken: FacebookTestToken}}
+		var linked models.User
+		statusCode, err := TestRequestV1().Post(routes.ResourceUsers+routes.ResourceFacebookLink).Header(theConf.AuthTokenHeader, user.AuthToken).RequestBody(&fbLink).ResponseBody(&linked).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusOK))
+		gomega.Expect(confirm(totpCode(enrollment.Secret, time.Now()), &errResp)).To(gomega.Equal(http.StatusOK))
+
+		for _, resource := range []string{routes.ResourceFacebookLogin, routes.ResourceFacebook} {
+			var challenge models.MFAChallenge
+			statusCode, err := TestRequestV1().Post(routes.ResourceUsers + resource).RequestBody(&fbLink.FacebookUser).ResponseBody(&challenge).Do()
+			gomega.Expect(err).ToNot(gomega.HaveOccurred())
+			gomega.Expect(statusCode).To(gomega.Equal(http.StatusOK), resource)
+			gomega.Expect(challenge.MFARequired).To(gomega.BeTrue(), resource)
+			gomega.Expect(challenge.MFAToken).ToNot(gomega.BeEmpty(), resource)
+		}
+
+		challenge, err := grpcAuthClient.AuthUserByFacebook(context.Background(), &protobuf.UserFacebookAuth{FacebookID: FacebookTestId, FacebookToken: FacebookTestToken})
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(challenge.Status).To(gomega.Equal(protobuf.UserStatus_mfa_required))
+		gomega.Expect(challenge.AuthToken).To(gomega.BeEmpty())
+
+		var loggedIn models.User
+		mfaLogin := models.MFALogin{MFAToken: challenge.MfaToken, Code: totpCode(enrollment.Secret, time.Now().Add(30*time.Second))}
+		gomega.Expect(loginMFA(&mfaLogin, &loggedIn, &errResp)).To(gomega.Equal(http.StatusOK))
+		gomega.Expect(loggedIn.ID).To(gomega.Equal(user.ID))
+		gomega.Expect(loggedIn.AuthToken).ToNot(gomega.BeEmpty())
+	})
+
+	ginkgo.It("should accept a recovery code once in place of a code", func() {
+		var errResp models.ErrorResponse
+		gomega.Expect(confirm(totpCode(enrollment.Secret, time.Now()), &errResp)).To(gomega.Equal(http.StatusOK))
+
+		var loggedIn models.User
+		mfaLogin := models.MFALogin{MFAToken: login().MFAToken, Code: enrollment.RecoveryCodes[0]}
+		gomega.Expect(loginMFA(&mfaLogin, &loggedIn, &errResp)).To(gomega.Equal(http.StatusOK))
+		gomega.Expect(loggedIn.AuthToken).ToNot(gomega.BeEmpty())
+
+		gomega.Expect(loginMFA(&mfaLogin, &loggedIn, &errResp)).To(gomega.Equal(http.StatusUnauthorized))
+		gomega.Expect(errResp.Code).To(gomega.Equal(constants.APIInvalidMFACode))
+	})
+
+	ginkgo.It("should regenerate recovery codes", func() {
+		var errResp models.ErrorResponse
+		gomega.Expect(confirm(totpCode(enrollment.Secret, time.Now()), &errResp)).To(gomega.Equal(http.StatusOK))
+
+		var regenerated models.RecoveryCodes
+		code := models.MFACode{Code: enrollment.RecoveryCodes[0]}
+		statusCode, err := TestRequestV1().Post(routes.ResourceUsers+routes.ResourceMFA+routes.ResourceRecoveryCodes).Header(theConf.AuthTokenHeader, user.AuthToken).RequestBody(&code).ResponseBody(&regenerated).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusOK))
+		gomega.Expect(regenerated.RecoveryCodes).To(gomega.HaveLen(int(theConf.RecoveryCodeCount)))
+
+		// the old codes no longer work
+		var loggedIn models.User
+		mfaLogin := models.MFALogin{MFAToken: login().MFAToken, Code: enrollment.RecoveryCodes[1]}
+		gomega.Expect(loginMFA(&mfaLogin, &loggedIn, &errResp)).To(gomega.Equal(http.StatusUnauthorized))
+
+		mfaLogin.Code = regenerated.RecoveryCodes[0]
+		gomega.Expect(loginMFA(&mfaLogin, &loggedIn, &errResp)).To(gomega.Equal(http.StatusOK))
+	})
+})
@@ -0,0 +1,234 @@
+package integration
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/axiomzen/golorem"
+	"github.com/axiomzen/yawgh"
+	"github.com/axiomzen/zenauth/constants"
+	"github.com/axiomzen/zenauth/helpers"
+	"github.com/axiomzen/zenauth/models"
+	"github.com/axiomzen/zenauth/routes"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	"gopkg.in/dgrijalva/jwt-go.v3"
+)
+
+var _ = ginkgo.Describe("OAuth", func() {
+
+	const (
+		redirectURI = "https://client.example.com/callback"
+		verifier    = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	)
+
+	var (
+		client models.OAuthClient
+		signup models.Signup
+		user   models.User
+		// don't follow the redirects back to the client
+		httpClient = &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	)
+
+	oauthURL := func(path string) string {
+		return fmt.Sprintf("http://%s:%d%s", theConf.TestDomainHost, theConf.Port, path)
+	}
+
+	authorizeParams := func() url.Values {
+		return url.Values{
+			"response_type":         {constants.OAuthResponseTypeCode},
+			"client_id":             {client.ClientID},
+			"redirect_uri":          {redirectURI},
+			"scope":                 {"openid email"},
+			"state":                 {"xyz"},
+			"nonce":                 {"n-0S6_WzA2Mj"},
+			"code_challenge":        {helpers.PKCEChallenge(verifier)},
+			"code_challenge_method": {helpers.PKCEMethodS256},
+		}
+	}
+
+	// authorize logs in on the login page and returns the redirect back to the client
+	authorize := func(params url.Values) *url.URL {
+		params.Set("email", signup.Email)
+		params.Set("password", signup.Password)
+		resp, err := httpClient.PostForm(oauthURL(routes.ResourceAuthorize), params)
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		resp.Body.Close()
+		gomega.Expect(resp.StatusCode).To(gomega.Equal(http.StatusFound))
+		location, err := resp.Location()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		return location
+	}
+
+	token := func(code, codeVerifier string, tokens *models.OAuthTokenResponse, oauthErr *models.OAuthError) int {
+		params := url.Values{
+			"grant_type":    {constants.OAuthGrantTypeAuthorizationCode},
+			"code":          {code},
+			"redirect_uri":  {redirectURI},
+			"client_id":     {client.ClientID},
+			"client_secret": {client.ClientSecret},
+			"code_verifier": {codeVerifier},
+		}
+		resp, err := httpClient.PostForm(oauthURL(routes.ResourceToken), params)
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		if resp.StatusCode == http.StatusOK {
+			gomega.Expect(json.Unmarshal(body, tokens)).To(gomega.Succeed())
+		} else {
+			gomega.Expect(json.Unmarshal(body, oauthErr)).To(gomega.Succeed())
+		}
+		return resp.StatusCode
+	}
+
+	ginkgo.BeforeEach(func() {
+		client = models.OAuthClient{}
+		registration := models.OAuthClientRegistration{Name: "Client", RedirectURIs: []string{redirectURI}}
+		statusCode, err := TestRequestV1().Post(routes.ResourceTest + routes.ResourceOAuthClients).RequestBody(&registration).ResponseBody(&client).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusCreated))
+		gomega.Expect(client.ClientSecret).ToNot(gomega.BeEmpty())
+
+		user = models.User{}
+		gomega.Expect(lorem.Fill(&signup)).To(gomega.Succeed())
+		statusCode, err = TestRequestV1().Post(routes.ResourceUsers + routes.ResourceSignup).RequestBody(&signup).ResponseBody(&user).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusCreated))
+	})
+
+	ginkgo.AfterEach(func() {
+		deleteUser(user.ID)
+	})
+
+	ginkgo.It("should publish the discovery document without an api token", func() {
+		var configuration models.OIDCConfiguration
+		statusCode, err := yawgh.New().
+			Transport("http").
+			DomainHost(theConf.TestDomainHost).
+			Port(uint(theConf.Port)).
+			Marshaler(marshaler).
+			Unmarshaler(unmarshaler).
+			ResponseBody(&configuration).
+			Get(routes.ResourceWellKnown + routes.ResourceOpenIDConfiguration).
+			Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusOK))
+		gomega.Expect(configuration.Issuer).To(gomega.Equal(theConf.OAuthIssuer))
+		gomega.Expect(configuration.AuthorizationEndpoint).To(gomega.Equal(theConf.OAuthIssuer + routes.ResourceAuthorize))
+		gomega.Expect(configuration.CodeChallengeMethodsSupported).To(gomega.ConsistOf(helpers.PKCEMethodS256))
+	})
+
+	ginkgo.It("should show the login page", func() {
+		resp, err := httpClient.Get(oauthURL(routes.ResourceAuthorize) + "?" + authorizeParams().Encode())
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		defer resp.Body.Close()
+		gomega.Expect(resp.StatusCode).To(gomega.Equal(http.StatusOK))
+		gomega.Expect(resp.Header.Get("Content-Type")).To(gomega.HavePrefix("text/html"))
+		body, err := ioutil.ReadAll(resp.Body)
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(string(body)).To(gomega.ContainSubstring(`name="password"`))
+	})
+
+	ginkgo.It("should not redirect to an unregistered uri", func() {
+		params := authorizeParams()
+		params.Set("redirect_uri", "https://attacker.example.com/callback")
+		resp, err := httpClient.Get(oauthURL(routes.ResourceAuthorize) + "?" + params.Encode())
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		resp.Body.Close()
+		gomega.Expect(resp.StatusCode).To(gomega.Equal(http.StatusBadRequest))
+	})
+
+	ginkgo.It("should redirect back with an error without a code challenge", func() {
+		params := authorizeParams()
+		params.Del("code_challenge")
+		resp, err := httpClient.Get(oauthURL(routes.ResourceAuthorize) + "?" + params.Encode())
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		resp.Body.Close()
+		gomega.Expect(resp.StatusCode).To(gomega.Equal(http.StatusFound))
+		location, err := resp.Location()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(location.Query().Get("error")).To(gomega.Equal(constants.OAuthErrorInvalidRequest))
+		gomega.Expect(location.Query().Get("state")).To(gomega.Equal("xyz"))
+	})
+
+	ginkgo.It("should show the login page again for a wrong password", func() {
+		params := authorizeParams()
+		params.Set("email", signup.Email)
+		params.Set("password", "wrong password")
+		resp, err := httpClient.PostForm(oauthURL(routes.ResourceAuthorize), params)
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		resp.Body.Close()
+		gomega.Expect(resp.StatusCode).To(gomega.Equal(http.StatusUnauthorized))
+	})
+
+	ginkgo.It("should exchange the code for tokens", func() {
+		location := authorize(authorizeParams())
+		gomega.Expect(location.String()).To(gomega.HavePrefix(redirectURI))
+		gomega.Expect(location.Query().Get("state")).To(gomega.Equal("xyz"))
+		code := location.Query().Get("code")
+		gomega.Expect(code).ToNot(gomega.BeEmpty())
+
+		var tokens models.OAuthTokenResponse
+		var oauthErr models.OAuthError
+		gomega.Expect(token(code, verifier, &tokens, &oauthErr)).To(gomega.Equal(http.StatusOK))
+		gomega.Expect(tokens.TokenType).To(gomega.Equal("Bearer"))
+
+		idToken, err := new(jwt.Parser).Parse(tokens.IDToken, func(*jwt.Token) (interface{}, error) { return theConf.HashSecretBytes, nil })
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		claims := idToken.Claims.(jwt.MapClaims)
+		gomega.Expect(claims["iss"]).To(gomega.Equal(theConf.OAuthIssuer))
+		gomega.Expect(claims["aud"]).To(gomega.Equal(client.ClientID))
+		gomega.Expect(claims["sub"]).To(gomega.Equal(user.ID))
+		gomega.Expect(claims["email"]).To(gomega.Equal(user.Email))
+		gomega.Expect(claims["nonce"]).To(gomega.Equal("n-0S6_WzA2Mj"))
+
+		req, err := http.NewRequest("GET", oauthURL(routes.ResourceUserInfo), nil)
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		req.Header.Set("Authorization", "Bearer "+tokens.AccessToken)
+		resp, err := httpClient.Do(req)
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		defer resp.Body.Close()
+		gomega.Expect(resp.StatusCode).To(gomega.Equal(http.StatusOK))
+		var userInfo models.OIDCUserInfo
+		gomega.Expect(json.NewDecoder(resp.Body).Decode(&userInfo)).To(gomega.Succeed())
+		gomega.Expect(userInfo.Subject).To(gomega.Equal(user.ID))
+		gomega.Expect(userInfo.Email).To(gomega.Equal(user.Email))
+
+		// codes are single use
+		gomega.Expect(token(code, verifier, &tokens, &oauthErr)).To(gomega.Equal(http.StatusBadRequest))
+		gomega.Expect(oauthErr.Error).To(gomega.Equal(constants.OAuthErrorInvalidGrant))
+	})
+
+	ginkgo.It("should not exchange the code without the code verifier", func() {
+		code := authorize(authorizeParams()).Query().Get("code")
+		var tokens models.OAuthTokenResponse
+		var oauthErr models.OAuthError
+		gomega.Expect(token(code, strings.Repeat("a", 43), &tokens, &oauthErr)).To(gomega.Equal(http.StatusBadRequest))
+		gomega.Expect(oauthErr.Error).To(gomega.Equal(constants.OAuthErrorInvalidGrant))
+	})
+
+	ginkgo.It("should not exchange the code with a wrong client secret", func() {
+		code := authorize(authorizeParams()).Query().Get("code")
+		client.ClientSecret = "wrong"
+		var tokens models.OAuthTokenResponse
+		var oauthErr models.OAuthError
+		gomega.Expect(token(code, verifier, &tokens, &oauthErr)).To(gomega.Equal(http.StatusUnauthorized))
+		gomega.Expect(oauthErr.Error).To(gomega.Equal(constants.OAuthErrorInvalidClient))
+	})
+
+	ginkgo.It("should not return user info without a valid token", func() {
+		req, err := http.NewRequest("GET", oauthURL(routes.ResourceUserInfo), nil)
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		req.Header.Set("Authorization", "Bearer nope")
+		resp, err := httpClient.Do(req)
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		resp.Body.Close()
+		gomega.Expect(resp.StatusCode).To(gomega.Equal(http.StatusUnauthorized))
+		gomega.Expect(resp.Header.Get("WWW-Authenticate")).To(gomega.ContainSubstring(constants.OAuthErrorInvalidToken))
+	})
+})
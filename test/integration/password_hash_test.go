@@ -0,0 +1,74 @@
+package integration
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/axiomzen/golorem"
+	"github.com/axiomzen/zenauth/data"
+	"github.com/axiomzen/zenauth/helpers"
+	"github.com/axiomzen/zenauth/models"
+	"github.com/axiomzen/zenauth/routes"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("Password hashing", func() {
+
+	var (
+		signup models.Signup
+		user   models.User
+		dal    data.ZENAUTHProvider
+	)
+
+	// storedHash returns the hash the user has in the database
+	storedHash := func() string {
+		stored := models.User{ID: user.ID}
+		gomega.Expect(dal.GetUserByID(&stored)).To(gomega.Succeed())
+		return *stored.Hash
+	}
+
+	ginkgo.BeforeEach(func() {
+		var err error
+		dal, err = data.CreateProvider(theConf)
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+
+		user = models.User{}
+		gomega.Expect(lorem.Fill(&signup)).To(gomega.Succeed())
+		statusCode, err := TestRequestV1().Post(routes.ResourceUsers + routes.ResourceSignup).RequestBody(&signup).ResponseBody(&user).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusCreated))
+	})
+
+	ginkgo.AfterEach(func() {
+		deleteUser(user.ID)
+		dal.Close()
+	})
+
+	ginkgo.It("should hash new passwords with argon2id", func() {
+		gomega.Expect(helpers.PasswordHashScheme(storedHash())).To(gomega.Equal(helpers.PasswordSchemeArgon2id))
+	})
+
+	ginkgo.It("should replace a bcrypt hash with argon2id on login", func() {
+		stored := models.User{ID: user.ID}
+		gomega.Expect(dal.GetUserByID(&stored)).To(gomega.Succeed())
+		bcryptHash, err := helpers.HashPasswordBcrypt(signup.Password, int(theConf.BcryptCost))
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(dal.UpdateUserHash(bcryptHash, &stored)).To(gomega.Succeed())
+
+		login := models.Login{Email: signup.Email, Password: signup.Password}
+		statusCode, err := TestRequestV1().Post(routes.ResourceUsers + routes.ResourceLogin).RequestBody(&login).ResponseBody(&user).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusOK))
+
+		// the rehash happens after the response
+		gomega.Eventually(func() string {
+			return helpers.PasswordHashScheme(storedHash())
+		}, time.Second).Should(gomega.Equal(helpers.PasswordSchemeArgon2id))
+
+		// and the password still works
+		statusCode, err = TestRequestV1().Post(routes.ResourceUsers + routes.ResourceLogin).RequestBody(&login).ResponseBody(&user).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusOK))
+	})
+})
@@ -0,0 +1,123 @@
+package integration
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/axiomzen/golorem"
+	"github.com/axiomzen/zenauth/constants"
+	"github.com/axiomzen/zenauth/helpers"
+	"github.com/axiomzen/zenauth/models"
+	"github.com/axiomzen/zenauth/protobuf"
+	"github.com/axiomzen/zenauth/routes"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	"google.golang.org/grpc/codes"
+)
+
+var _ = ginkgo.Describe("Password policy", func() {
+
+	var (
+		signup models.Signup
+		user   models.User
+	)
+
+	// rulesOf returns the rules in the details of an error
+	rulesOf := func(errResp models.ErrorResponse) []string {
+		var rules []string
+		for _, detail := range errResp.Details {
+			gomega.Expect(detail.Field).To(gomega.Equal("password"))
+			rules = append(rules, detail.Rule)
+		}
+		return rules
+	}
+
+	changePassword := func(oldPassword, newPassword string, errResp *models.ErrorResponse) int {
+		change := models.UserChangePassword{OldPassword: oldPassword, NewPassword: newPassword}
+		statusCode, err := TestRequestV1().Put(routes.ResourceUsers+routes.ResourcePassword).
+			Header(theConf.AuthTokenHeader, user.AuthToken).
+			RequestBody(&change).
+			ErrorResponseBody(errResp).
+			Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		return statusCode
+	}
+
+	ginkgo.BeforeEach(func() {
+		user = models.User{}
+		gomega.Expect(lorem.Fill(&signup)).To(gomega.Succeed())
+	})
+
+	ginkgo.AfterEach(func() {
+		if user.ID != "" {
+			deleteUser(user.ID)
+		}
+	})
+
+	ginkgo.It("should reject a breached password with the rule in the details", func() {
+		signup.Password = breachedTestPassword
+		var errResp models.ErrorResponse
+		statusCode, err := TestRequestV1().Post(routes.ResourceUsers + routes.ResourceSignup).RequestBody(&signup).ErrorResponseBody(&errResp).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusBadRequest))
+		gomega.Expect(errResp.Code).To(gomega.Equal(constants.APIValidationPasswordPolicy))
+		gomega.Expect(rulesOf(errResp)).To(gomega.Equal([]string{helpers.PasswordRuleBreached}))
+	})
+
+	ginkgo.It("should reject a password containing the email", func() {
+		signup.Password = "my" + signup.Email
+		var errResp models.ErrorResponse
+		statusCode, err := TestRequestV1().Post(routes.ResourceUsers + routes.ResourceSignup).RequestBody(&signup).ErrorResponseBody(&errResp).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusBadRequest))
+		gomega.Expect(rulesOf(errResp)).To(gomega.ContainElement(helpers.PasswordRulePersonalInfo))
+	})
+
+	ginkgo.It("should reject a breached password over gRPC", func() {
+		_, err := grpcAuthClient.AuthUserByEmail(context.Background(), &protobuf.UserEmailAuth{Email: signup.Email, Password: breachedTestPassword})
+		gomega.Expect(err).To(gomega.HaveOccurred())
+		code, apiCode := grpcError(err)
+		gomega.Expect(code).To(gomega.Equal(codes.InvalidArgument))
+		gomega.Expect(apiCode).To(gomega.Equal(constants.APIValidationPasswordPolicy))
+
+		// the rules broken are told apart like in the REST error details
+		_, detail := grpcErrorDetail(err)
+		gomega.Expect(detail.Violations).To(gomega.HaveLen(1))
+		gomega.Expect(detail.Violations[0].Field).To(gomega.Equal("password"))
+		gomega.Expect(detail.Violations[0].Rule).To(gomega.Equal(helpers.PasswordRuleBreached))
+		gomega.Expect(detail.Violations[0].Message).ToNot(gomega.BeEmpty())
+	})
+
+	ginkgo.Context("User has signed up", func() {
+
+		ginkgo.BeforeEach(func() {
+			statusCode, err := TestRequestV1().Post(routes.ResourceUsers + routes.ResourceSignup).RequestBody(&signup).ResponseBody(&user).Do()
+			gomega.Expect(err).ToNot(gomega.HaveOccurred())
+			gomega.Expect(statusCode).To(gomega.Equal(http.StatusCreated))
+		})
+
+		ginkgo.It("should not reuse the current password", func() {
+			var errResp models.ErrorResponse
+			gomega.Expect(changePassword(signup.Password, signup.Password, &errResp)).To(gomega.Equal(http.StatusBadRequest))
+			gomega.Expect(rulesOf(errResp)).To(gomega.Equal([]string{helpers.PasswordRuleHistory}))
+		})
+
+		ginkgo.It("should not reuse a password until it is out of the history", func() {
+			passwords := []string{signup.Password}
+			for i := 0; i < theConf.PasswordPolicy.HistorySize; i++ {
+				next := lorem.Word(12, 16)
+				var errResp models.ErrorResponse
+				gomega.Expect(changePassword(passwords[len(passwords)-1], next, &errResp)).To(gomega.Equal(http.StatusOK))
+				passwords = append(passwords, next)
+
+				// the first password is in the history until HistorySize passwords came after it
+				if i < theConf.PasswordPolicy.HistorySize-1 {
+					gomega.Expect(changePassword(next, signup.Password, &errResp)).To(gomega.Equal(http.StatusBadRequest))
+					gomega.Expect(rulesOf(errResp)).To(gomega.Equal([]string{helpers.PasswordRuleHistory}))
+				}
+			}
+			var errResp models.ErrorResponse
+			gomega.Expect(changePassword(passwords[len(passwords)-1], signup.Password, &errResp)).To(gomega.Equal(http.StatusOK))
+		})
+	})
+})
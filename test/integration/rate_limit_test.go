@@ -0,0 +1,47 @@
+package integration
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/axiomzen/zenauth/constants"
+	"github.com/axiomzen/zenauth/models"
+	"github.com/axiomzen/zenauth/routes"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("Rate limiting", func() {
+
+	ginkgo.It("should limit requests with 429 once the bucket is empty", func() {
+		limit := theConf.RouteRateLimits["test"]
+		var header http.Header
+		request := func(errResp *models.ErrorResponse) int {
+			statusCode, err := TestRequestV1().Get(routes.ResourceTest + routes.ResourceRateLimited).
+				ErrorResponseBody(errResp).
+				ResponseInterceptor(responseIntFunc(func(r *http.Response, body []byte, contentType string) error {
+					header = r.Header
+					return nil
+				})).
+				Do()
+			gomega.Expect(err).ToNot(gomega.HaveOccurred())
+			return statusCode
+		}
+
+		for i := limit.Burst - 1; i >= 0; i-- {
+			var errResp models.ErrorResponse
+			gomega.Expect(request(&errResp)).To(gomega.Equal(http.StatusNoContent))
+			gomega.Expect(header.Get("RateLimit-Limit")).To(gomega.Equal(strconv.Itoa(limit.Burst)))
+			gomega.Expect(header.Get("RateLimit-Remaining")).To(gomega.Equal(strconv.Itoa(i)))
+			gomega.Expect(header.Get("RateLimit-Policy")).To(gomega.Equal(strconv.Itoa(limit.Burst) + ";w=" + strconv.Itoa(int(limit.Period.Seconds()))))
+		}
+
+		var errResp models.ErrorResponse
+		gomega.Expect(request(&errResp)).To(gomega.Equal(http.StatusTooManyRequests))
+		gomega.Expect(errResp.Code).To(gomega.Equal(constants.APIRateLimited))
+		gomega.Expect(header.Get("RateLimit-Remaining")).To(gomega.Equal("0"))
+		retryAfter, err := strconv.Atoi(header.Get("Retry-After"))
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(retryAfter).To(gomega.BeNumerically(">", 0))
+	})
+})
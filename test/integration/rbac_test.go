@@ -0,0 +1,162 @@
+package integration
+
+import (
+	"net/http"
+
+	"github.com/axiomzen/golorem"
+	"github.com/axiomzen/zenauth/models"
+	"github.com/axiomzen/zenauth/protobuf"
+	"github.com/axiomzen/zenauth/routes"
+	pEmpty "github.com/golang/protobuf/ptypes/empty"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/metadata"
+)
+
+var _ = ginkgo.Describe("Roles and permissions", func() {
+
+	var (
+		userAuth models.UserAuth
+		user     models.User
+		roleName string
+	)
+
+	rolePath := func() string {
+		return routes.ResourceAdmins + routes.ResourceRoles + "/" + roleName
+	}
+
+	userRolePath := func() string {
+		return routes.ResourceAdmins + routes.ResourceUsers + "/" + user.ID + routes.ResourceRoles + "/" + roleName
+	}
+
+	login := func() models.User {
+		var loggedIn models.User
+		auth := models.Login{Email: userAuth.Email, Password: userAuth.Password}
+		statusCode, err := TestRequestV1().Post(routes.ResourceUsers + routes.ResourceLogin).RequestBody(&auth).ResponseBody(&loggedIn).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusOK))
+		return loggedIn
+	}
+
+	adminContext := func() context.Context {
+		return metadata.NewContext(context.Background(), metadata.Pairs(theConf.AdminTokenHeader, adminTestToken))
+	}
+
+	ginkgo.BeforeEach(func() {
+		roleName = "editor-" + lorem.Word(8, 10)
+		gomega.Expect(lorem.Fill(&userAuth)).To(gomega.Succeed())
+		statusCode, err := TestRequestV1().Post(routes.ResourceUsers + routes.ResourceSignup).RequestBody(&userAuth).ResponseBody(&user).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusCreated))
+
+		var role models.Role
+		statusCode, err = TestRequestV1().Put(rolePath()).Header(theConf.AdminTokenHeader, adminTestToken).
+			RequestBody(&models.Role{Description: "Edits posts", Permissions: []string{"posts:read", "posts:write"}}).ResponseBody(&role).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusOK))
+		gomega.Expect(role.Name).To(gomega.Equal(roleName))
+		gomega.Expect(role.Permissions).To(gomega.Equal([]string{"posts:read", "posts:write"}))
+	})
+
+	ginkgo.AfterEach(func() {
+		deleteUser(user.ID)
+		_, err := TestRequestV1().Delete(rolePath()).Header(theConf.AdminTokenHeader, adminTestToken).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+	})
+
+	ginkgo.It("should reject invalid role names", func() {
+		var errResp models.ErrorResponse
+		statusCode, err := TestRequestV1().Put(routes.ResourceAdmins+routes.ResourceRoles+"/"+roleName).Header(theConf.AdminTokenHeader, adminTestToken).
+			RequestBody(&models.Role{Permissions: []string{"not a permission"}}).ErrorResponseBody(&errResp).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusBadRequest))
+	})
+
+	ginkgo.It("should replace the permissions of a role", func() {
+		var role models.Role
+		statusCode, err := TestRequestV1().Put(rolePath()).Header(theConf.AdminTokenHeader, adminTestToken).
+			RequestBody(&models.Role{Permissions: []string{"posts:read"}}).ResponseBody(&role).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusOK))
+
+		statusCode, err = TestRequestV1().Get(rolePath()).Header(theConf.AdminTokenHeader, adminTestToken).ResponseBody(&role).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusOK))
+		gomega.Expect(role.Permissions).To(gomega.Equal([]string{"posts:read"}))
+	})
+
+	ginkgo.It("should not find roles the user doesn't have", func() {
+		statusCode, err := TestRequestV1().Delete(userRolePath()).Header(theConf.AdminTokenHeader, adminTestToken).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusNotFound))
+	})
+
+	ginkgo.Context("User has the role", func() {
+
+		ginkgo.BeforeEach(func() {
+			statusCode, err := TestRequestV1().Put(userRolePath()).Header(theConf.AdminTokenHeader, adminTestToken).Do()
+			gomega.Expect(err).ToNot(gomega.HaveOccurred())
+			gomega.Expect(statusCode).To(gomega.Equal(http.StatusNoContent))
+		})
+
+		ginkgo.It("should list the user's roles", func() {
+			var roles models.Roles
+			statusCode, err := TestRequestV1().Get(routes.ResourceAdmins+routes.ResourceUsers+"/"+user.ID+routes.ResourceRoles).
+				Header(theConf.AdminTokenHeader, adminTestToken).ResponseBody(&roles).Do()
+			gomega.Expect(err).ToNot(gomega.HaveOccurred())
+			gomega.Expect(statusCode).To(gomega.Equal(http.StatusOK))
+			gomega.Expect(roles).To(gomega.HaveLen(1))
+			gomega.Expect(roles[0].Name).To(gomega.Equal(roleName))
+		})
+
+		ginkgo.It("should put the roles and permissions in new auth tokens", func() {
+			loggedIn := login()
+			gomega.Expect(loggedIn.Roles).To(gomega.Equal([]string{roleName}))
+			claims := tokenClaims(loggedIn.AuthToken)
+			gomega.Expect(claims[theConf.JwtClaimRoles]).To(gomega.Equal([]interface{}{roleName}))
+			gomega.Expect(claims[theConf.JwtClaimPermissions]).To(gomega.Equal([]interface{}{"posts:read", "posts:write"}))
+		})
+
+		ginkgo.It("should check permissions over gRPC", func() {
+			result, err := grpcAuthClient.CheckPermission(context.Background(), &protobuf.PermissionCheck{UserId: user.ID, Permission: "posts:write"})
+			gomega.Expect(err).ToNot(gomega.HaveOccurred())
+			gomega.Expect(result.Allowed).To(gomega.BeTrue())
+
+			result, err = grpcAuthClient.CheckPermission(getGRPCAuthenticatedContext(user.AuthToken), &protobuf.PermissionCheck{Permission: "posts:delete"})
+			gomega.Expect(err).ToNot(gomega.HaveOccurred())
+			gomega.Expect(result.Allowed).To(gomega.BeFalse())
+		})
+
+		ginkgo.It("should return the roles of the current user over gRPC", func() {
+			grpcUser, err := grpcAuthClient.GetCurrentUser(getGRPCAuthenticatedContext(user.AuthToken), &pEmpty.Empty{})
+			gomega.Expect(err).ToNot(gomega.HaveOccurred())
+			gomega.Expect(grpcUser.Roles).To(gomega.Equal([]string{roleName}))
+		})
+
+		ginkgo.It("should take the role away", func() {
+			statusCode, err := TestRequestV1().Delete(userRolePath()).Header(theConf.AdminTokenHeader, adminTestToken).Do()
+			gomega.Expect(err).ToNot(gomega.HaveOccurred())
+			gomega.Expect(statusCode).To(gomega.Equal(http.StatusNoContent))
+			gomega.Expect(login().Roles).To(gomega.BeEmpty())
+		})
+	})
+
+	ginkgo.It("should manage roles over gRPC with the admin token", func() {
+		_, err := grpcAuthClient.ListRoles(context.Background(), &pEmpty.Empty{})
+		gomega.Expect(err).To(gomega.HaveOccurred())
+
+		_, err = grpcAuthClient.AssignRole(adminContext(), &protobuf.UserRole{UserId: user.ID, Role: roleName})
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		roles, err := grpcAuthClient.GetUserRoles(adminContext(), &protobuf.UserID{Id: user.ID})
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(roles.Roles).To(gomega.HaveLen(1))
+		gomega.Expect(roles.Roles[0].Permissions).To(gomega.Equal([]string{"posts:read", "posts:write"}))
+
+		_, err = grpcAuthClient.UnassignRole(adminContext(), &protobuf.UserRole{UserId: user.ID, Role: roleName})
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		result, err := grpcAuthClient.CheckPermission(context.Background(), &protobuf.PermissionCheck{UserId: user.ID, Permission: "posts:read"})
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(result.Allowed).To(gomega.BeFalse())
+	})
+})
@@ -0,0 +1,112 @@
+package integration
+
+import (
+	"net/http"
+
+	"github.com/axiomzen/golorem"
+	"github.com/axiomzen/zenauth/constants"
+	"github.com/axiomzen/zenauth/models"
+	"github.com/axiomzen/zenauth/protobuf"
+	"github.com/axiomzen/zenauth/routes"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	context "golang.org/x/net/context"
+)
+
+func refreshToken(refreshToken string, user *models.User, errResp *models.ErrorResponse) int {
+	statusCode, err := TestRequestV1().
+		Post(routes.ResourceUsers + routes.ResourceToken + routes.ResourceRefresh).
+		RequestBody(&models.TokenRefresh{RefreshToken: refreshToken}).
+		ResponseBody(user).
+		ErrorResponseBody(errResp).
+		Do()
+	gomega.Expect(err).ToNot(gomega.HaveOccurred())
+	return statusCode
+}
+
+var _ = ginkgo.Describe("Refresh Tokens", func() {
+
+	var (
+		userAuth models.UserAuth
+		user     models.User
+	)
+
+	ginkgo.BeforeEach(func() {
+		gomega.Expect(lorem.Fill(&userAuth)).To(gomega.Succeed())
+		statusCode, err := TestRequestV1().Post(routes.ResourceUsers + routes.ResourceSignup).RequestBody(&userAuth).ResponseBody(&user).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusCreated))
+		gomega.Expect(user.RefreshToken).ToNot(gomega.BeEmpty())
+	})
+
+	ginkgo.AfterEach(func() {
+		deleteUser(user.ID)
+	})
+
+	ginkgo.It("should issue a refresh token on login", func() {
+		var loggedIn models.User
+		statusCode, err := TestRequestV1().Post(routes.ResourceUsers + routes.ResourceLogin).RequestBody(&userAuth).ResponseBody(&loggedIn).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusOK))
+		gomega.Expect(loggedIn.RefreshToken).ToNot(gomega.BeEmpty())
+		gomega.Expect(loggedIn.RefreshToken).ToNot(gomega.Equal(user.RefreshToken))
+	})
+
+	ginkgo.It("should exchange a refresh token for new tokens", func() {
+		var refreshed models.User
+		var errResp models.ErrorResponse
+		gomega.Expect(refreshToken(user.RefreshToken, &refreshed, &errResp)).To(gomega.Equal(http.StatusOK))
+		gomega.Expect(refreshed.ID).To(gomega.Equal(user.ID))
+		gomega.Expect(refreshed.AuthToken).ToNot(gomega.BeEmpty())
+		gomega.Expect(refreshed.RefreshToken).ToNot(gomega.BeEmpty())
+		gomega.Expect(refreshed.RefreshToken).ToNot(gomega.Equal(user.RefreshToken))
+
+		// the new auth token works
+		var self models.User
+		statusCode, err := TestRequestV1().Get(routes.ResourceUsers).Header(theConf.AuthTokenHeader, refreshed.AuthToken).ResponseBody(&self).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusOK))
+		gomega.Expect(self.ID).To(gomega.Equal(user.ID))
+	})
+
+	ginkgo.It("should reject an unknown refresh token", func() {
+		var refreshed models.User
+		var errResp models.ErrorResponse
+		gomega.Expect(refreshToken("notarealtoken", &refreshed, &errResp)).To(gomega.Equal(http.StatusUnauthorized))
+		gomega.Expect(errResp.Code).To(gomega.Equal(constants.APIInvalidRefreshToken))
+	})
+
+	ginkgo.It("should reject a missing refresh token", func() {
+		var refreshed models.User
+		var errResp models.ErrorResponse
+		gomega.Expect(refreshToken("", &refreshed, &errResp)).To(gomega.Equal(http.StatusBadRequest))
+	})
+
+	ginkgo.It("should revoke the token family when a refresh token is reused", func() {
+		var first, second, third models.User
+		var errResp models.ErrorResponse
+		gomega.Expect(refreshToken(user.RefreshToken, &first, &errResp)).To(gomega.Equal(http.StatusOK))
+
+		// replaying the original token is detected
+		gomega.Expect(refreshToken(user.RefreshToken, &second, &errResp)).To(gomega.Equal(http.StatusUnauthorized))
+		gomega.Expect(errResp.Code).To(gomega.Equal(constants.APIRefreshTokenReused))
+
+		// and the legitimately rotated token no longer works either
+		errResp = models.ErrorResponse{}
+		gomega.Expect(refreshToken(first.RefreshToken, &third, &errResp)).To(gomega.Equal(http.StatusUnauthorized))
+		gomega.Expect(errResp.Code).To(gomega.Equal(constants.APIRefreshTokenReused))
+	})
+
+	ginkgo.Context("GRPC", func() {
+		ginkgo.It("should exchange a refresh token for new tokens", func() {
+			refreshed, err := grpcAuthClient.RefreshToken(context.Background(), &protobuf.RefreshTokenRequest{RefreshToken: user.RefreshToken})
+			gomega.Expect(err).ToNot(gomega.HaveOccurred())
+			gomega.Expect(refreshed.GetId()).To(gomega.Equal(user.ID))
+			gomega.Expect(refreshed.GetAuthToken()).ToNot(gomega.BeEmpty())
+			gomega.Expect(refreshed.GetRefreshToken()).ToNot(gomega.Equal(user.RefreshToken))
+
+			_, err = grpcAuthClient.RefreshToken(context.Background(), &protobuf.RefreshTokenRequest{RefreshToken: user.RefreshToken})
+			gomega.Expect(err).To(gomega.HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,168 @@
+package integration
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	lorem "github.com/axiomzen/golorem"
+	"github.com/axiomzen/zenauth/constants"
+	"github.com/axiomzen/zenauth/helpers"
+	"github.com/axiomzen/zenauth/models"
+	"github.com/axiomzen/zenauth/protobuf"
+	"github.com/axiomzen/zenauth/routes"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	"gopkg.in/dgrijalva/jwt-go.v3"
+)
+
+var _ = ginkgo.Describe("Social", func() {
+
+	var (
+		subject string
+		email   string
+		user    models.User
+	)
+
+	social := func(path string, login *models.SocialLogin, user *models.User, errResp *models.ErrorResponse) int {
+		statusCode, err := TestRequestV1().Post(routes.ResourceUsers + routes.ResourceSocial + path).RequestBody(login).ResponseBody(user).ErrorResponseBody(errResp).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		return statusCode
+	}
+
+	ginkgo.BeforeEach(func() {
+		subject = lorem.Word(10, 20)
+		email = lorem.Email()
+		user = models.User{}
+	})
+
+	ginkgo.AfterEach(func() {
+		if user.ID != "" {
+			deleteUser(user.ID)
+		}
+	})
+
+	ginkgo.It("should not log in with an account that isn't linked", func() {
+		var errResp models.ErrorResponse
+		login := models.SocialLogin{IDToken: oidcStandIn.idToken(subject, email)}
+		gomega.Expect(social("/"+oidcTestProvider+routes.ResourceLogin, &login, &user, &errResp)).To(gomega.Equal(http.StatusForbidden))
+		gomega.Expect(errResp.Code).To(gomega.Equal(constants.APILoginUserDoesNotExist))
+	})
+
+	ginkgo.It("should fail for an unknown provider", func() {
+		var errResp models.ErrorResponse
+		login := models.SocialLogin{IDToken: oidcStandIn.idToken(subject, email)}
+		gomega.Expect(social("/unknown", &login, &user, &errResp)).To(gomega.Equal(http.StatusNotFound))
+		gomega.Expect(errResp.Code).To(gomega.Equal(constants.APISocialProviderNotFound))
+	})
+
+	ginkgo.It("should fail for an id token the provider didn't sign", func() {
+		var errResp models.ErrorResponse
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"iss": oidcStandIn.URL, "aud": oidcTestClientID, "sub": subject, "exp": time.Now().Add(time.Minute).Unix()})
+		token.Header["kid"] = oidcStandIn.kid
+		signed, err := token.SignedString([]byte("secret"))
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(social("/"+oidcTestProvider, &models.SocialLogin{IDToken: signed}, &user, &errResp)).To(gomega.Equal(http.StatusBadRequest))
+		gomega.Expect(errResp.Code).To(gomega.Equal(constants.APISocialLoginNotValid))
+	})
+
+	ginkgo.Context("User signed up with the provider", func() {
+
+		ginkgo.BeforeEach(func() {
+			var errResp models.ErrorResponse
+			login := models.SocialLogin{IDToken: oidcStandIn.idToken(subject, email)}
+			gomega.Expect(social("/"+oidcTestProvider+routes.ResourceSignup, &login, &user, &errResp)).To(gomega.Equal(http.StatusCreated))
+			gomega.Expect(user.AuthToken).ToNot(gomega.BeEmpty())
+			gomega.Expect(user.Email).To(gomega.Equal(helpers.EmailSanitize(email)))
+			gomega.Expect(user.Verified).To(gomega.BeTrue())
+		})
+
+		ginkgo.It("should log in", func() {
+			var loggedIn models.User
+			var errResp models.ErrorResponse
+			login := models.SocialLogin{IDToken: oidcStandIn.idToken(subject, email)}
+			gomega.Expect(social("/"+oidcTestProvider+routes.ResourceLogin, &login, &loggedIn, &errResp)).To(gomega.Equal(http.StatusOK))
+			gomega.Expect(loggedIn.ID).To(gomega.Equal(user.ID))
+
+			gomega.Expect(social("/"+oidcTestProvider, &login, &loggedIn, &errResp)).To(gomega.Equal(http.StatusOK))
+			gomega.Expect(loggedIn.ID).To(gomega.Equal(user.ID))
+		})
+
+		ginkgo.It("should not sign up twice", func() {
+			var other models.User
+			var errResp models.ErrorResponse
+			login := models.SocialLogin{IDToken: oidcStandIn.idToken(subject, lorem.Email())}
+			gomega.Expect(social("/"+oidcTestProvider+routes.ResourceSignup, &login, &other, &errResp)).To(gomega.Equal(http.StatusForbidden))
+			gomega.Expect(errResp.Code).To(gomega.Equal(constants.APISocialAccountExists))
+		})
+
+		ginkgo.It("should not sign up another account with the same email", func() {
+			var other models.User
+			var errResp models.ErrorResponse
+			login := models.SocialLogin{IDToken: oidcStandIn.idToken(lorem.Word(10, 20), email)}
+			gomega.Expect(social("/"+oidcTestProvider, &login, &other, &errResp)).To(gomega.Equal(http.StatusForbidden))
+			gomega.Expect(errResp.Code).To(gomega.Equal(constants.APIEmailInUse))
+		})
+
+		ginkgo.It("should not link the account to another user", func() {
+			var signup models.Signup
+			gomega.Expect(lorem.Fill(&signup)).To(gomega.Succeed())
+			var other models.User
+			statusCode, err := TestRequestV1().Post(routes.ResourceUsers + routes.ResourceSignup).RequestBody(&signup).ResponseBody(&other).Do()
+			gomega.Expect(err).ToNot(gomega.HaveOccurred())
+			gomega.Expect(statusCode).To(gomega.Equal(http.StatusCreated))
+			defer deleteUser(other.ID)
+
+			var errResp models.ErrorResponse
+			login := models.SocialLogin{IDToken: oidcStandIn.idToken(subject, email)}
+			statusCode, err = TestRequestV1().Post(routes.ResourceUsers+routes.ResourceSocial+"/"+oidcTestProvider+routes.ResourceLink).
+				Header(theConf.AuthTokenHeader, other.AuthToken).RequestBody(&login).ErrorResponseBody(&errResp).Do()
+			gomega.Expect(err).ToNot(gomega.HaveOccurred())
+			gomega.Expect(statusCode).To(gomega.Equal(http.StatusForbidden))
+			gomega.Expect(errResp.Code).To(gomega.Equal(constants.APISocialAccountExists))
+		})
+	})
+
+	ginkgo.It("should link an account to a user who signed up with email", func() {
+		var signup models.Signup
+		gomega.Expect(lorem.Fill(&signup)).To(gomega.Succeed())
+		statusCode, err := TestRequestV1().Post(routes.ResourceUsers + routes.ResourceSignup).RequestBody(&signup).ResponseBody(&user).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusCreated))
+
+		var linked models.User
+		login := models.SocialLogin{IDToken: oidcStandIn.idToken(subject, lorem.Email())}
+		statusCode, err = TestRequestV1().Post(routes.ResourceUsers+routes.ResourceSocial+"/"+oidcTestProvider+routes.ResourceLink).
+			Header(theConf.AuthTokenHeader, user.AuthToken).RequestBody(&login).ResponseBody(&linked).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusOK))
+		gomega.Expect(linked.ID).To(gomega.Equal(user.ID))
+
+		var loggedIn models.User
+		var errResp models.ErrorResponse
+		gomega.Expect(social("/"+oidcTestProvider+routes.ResourceLogin, &login, &loggedIn, &errResp)).To(gomega.Equal(http.StatusOK))
+		gomega.Expect(loggedIn.ID).To(gomega.Equal(user.ID))
+	})
+
+	ginkgo.Context("AuthUserBySocial", func() {
+		ginkgo.It("signs up, then logs in", func() {
+			auth := protobuf.UserSocialAuth{Provider: oidcTestProvider, IdToken: oidcStandIn.idToken(subject, email)}
+			protoUser, err := grpcAuthClient.AuthUserBySocial(context.Background(), &auth)
+			gomega.Expect(err).ToNot(gomega.HaveOccurred())
+			gomega.Expect(protoUser.Status).To(gomega.Equal(protobuf.UserStatus_new))
+			gomega.Expect(protoUser.AuthToken).ToNot(gomega.BeEmpty())
+			user.ID = protoUser.Id
+
+			protoUser, err = grpcAuthClient.AuthUserBySocial(context.Background(), &auth)
+			gomega.Expect(err).ToNot(gomega.HaveOccurred())
+			gomega.Expect(protoUser.Id).To(gomega.Equal(user.ID))
+			gomega.Expect(protoUser.Status).ToNot(gomega.Equal(protobuf.UserStatus_new))
+		})
+
+		ginkgo.It("fails for an unknown provider", func() {
+			auth := protobuf.UserSocialAuth{Provider: "unknown", IdToken: oidcStandIn.idToken(subject, email)}
+			_, err := grpcAuthClient.AuthUserBySocial(context.Background(), &auth)
+			gomega.Expect(err).To(gomega.HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,144 @@
+package integration
+
+import (
+	"net/http"
+
+	"github.com/axiomzen/golorem"
+	"github.com/axiomzen/zenauth/constants"
+	"github.com/axiomzen/zenauth/models"
+	"github.com/axiomzen/zenauth/protobuf"
+	"github.com/axiomzen/zenauth/routes"
+	pEmpty "github.com/golang/protobuf/ptypes/empty"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/metadata"
+)
+
+var _ = ginkgo.Describe("Tenants", func() {
+
+	var (
+		tenant       models.Tenant
+		userAuth     models.UserAuth
+		defaultUser  models.User
+		tenantUser   models.User
+		tenantPrefix = routes.ResourceAdmins + routes.ResourceTenants
+	)
+
+	signup := func(apiToken string, user *models.User) {
+		statusCode, err := TestRequestV1().Post(routes.ResourceUsers+routes.ResourceSignup).
+			Header(theConf.APITokenHeader, apiToken).RequestBody(&userAuth).ResponseBody(user).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusCreated))
+	}
+
+	getSelf := func(apiToken, authToken string) int {
+		var user models.User
+		statusCode, err := TestRequestV1().Get(routes.ResourceUsers+routes.ResourceMe).
+			Header(theConf.APITokenHeader, apiToken).Header(theConf.AuthTokenHeader, authToken).ResponseBody(&user).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		return statusCode
+	}
+
+	ginkgo.BeforeEach(func() {
+		tenant = models.Tenant{}
+		statusCode, err := TestRequestV1().Post(tenantPrefix).Header(theConf.AdminTokenHeader, adminTestToken).
+			RequestBody(&models.Tenant{Name: "tenant-" + lorem.Word(8, 10)}).ResponseBody(&tenant).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusCreated))
+		gomega.Expect(tenant.ID).ToNot(gomega.BeEmpty())
+		gomega.Expect(tenant.APIToken).ToNot(gomega.BeEmpty())
+
+		// the same account signs up in both tenants
+		gomega.Expect(lorem.Fill(&userAuth)).To(gomega.Succeed())
+		signup(theConf.APIToken, &defaultUser)
+		signup(tenant.APIToken, &tenantUser)
+	})
+
+	ginkgo.AfterEach(func() {
+		deleteUser(defaultUser.ID)
+		statusCode, err := TestRequestV1().Delete(tenantPrefix+"/"+tenant.ID).Header(theConf.AdminTokenHeader, adminTestToken).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusNoContent))
+	})
+
+	ginkgo.It("should keep the users of each tenant apart", func() {
+		gomega.Expect(tenantUser.ID).ToNot(gomega.Equal(defaultUser.ID))
+		gomega.Expect(getSelf(tenant.APIToken, tenantUser.AuthToken)).To(gomega.Equal(http.StatusOK))
+		gomega.Expect(getSelf(theConf.APIToken, defaultUser.AuthToken)).To(gomega.Equal(http.StatusOK))
+		// auth tokens only work with the API token of their tenant
+		gomega.Expect(getSelf(theConf.APIToken, tenantUser.AuthToken)).To(gomega.Equal(http.StatusUnauthorized))
+		gomega.Expect(getSelf(tenant.APIToken, defaultUser.AuthToken)).To(gomega.Equal(http.StatusUnauthorized))
+	})
+
+	ginkgo.It("should put the tenant in auth tokens", func() {
+		gomega.Expect(tokenClaims(tenantUser.AuthToken)[theConf.JwtClaimTenant]).To(gomega.Equal(tenant.ID))
+		gomega.Expect(tokenClaims(defaultUser.AuthToken)[theConf.JwtClaimTenant]).To(gomega.Equal(constants.DefaultTenantID))
+	})
+
+	ginkgo.It("should select the tenant from the gRPC metadata", func() {
+		tenantContext := func(authToken string) context.Context {
+			md := metadata.Pairs(theConf.AuthTokenHeader, authToken, theConf.TenantHeader, tenant.ID)
+			return metadata.NewContext(context.Background(), md)
+		}
+		user, err := grpcAuthClient.GetCurrentUser(tenantContext(tenantUser.AuthToken), &pEmpty.Empty{})
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(user.Id).To(gomega.Equal(tenantUser.ID))
+
+		_, err = grpcAuthClient.GetCurrentUser(tenantContext(defaultUser.AuthToken), &pEmpty.Empty{})
+		gomega.Expect(err).To(gomega.HaveOccurred())
+		_, err = grpcAuthClient.GetCurrentUser(getGRPCAuthenticatedContext(tenantUser.AuthToken), &pEmpty.Empty{})
+		gomega.Expect(err).To(gomega.HaveOccurred())
+
+		// GetUsersByIDs needs an auth token of the tenant
+		md := metadata.Pairs(theConf.APITokenHeader, tenant.APIToken, theConf.AuthTokenHeader, tenantUser.AuthToken)
+		users, err := grpcAuthClient.GetUsersByIDs(metadata.NewContext(context.Background(), md), &protobuf.UserIDs{Ids: []string{tenantUser.ID}})
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(users.Users).To(gomega.HaveLen(1))
+
+		// only the default API token can pick another tenant
+		md = metadata.Pairs(theConf.APITokenHeader, tenant.APIToken, theConf.AuthTokenHeader, tenantUser.AuthToken, theConf.TenantHeader, constants.DefaultTenantID)
+		user, err = grpcAuthClient.GetCurrentUser(metadata.NewContext(context.Background(), md), &pEmpty.Empty{})
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(user.Id).To(gomega.Equal(tenantUser.ID))
+	})
+
+	ginkgo.It("should list the tenants", func() {
+		var tenants models.Tenants
+		statusCode, err := TestRequestV1().Get(tenantPrefix).Header(theConf.AdminTokenHeader, adminTestToken).ResponseBody(&tenants).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusOK))
+		names := make([]string, len(tenants))
+		for i, t := range tenants {
+			gomega.Expect(t.APIToken).To(gomega.BeEmpty())
+			names[i] = t.Name
+		}
+		gomega.Expect(names).To(gomega.ContainElement("default"))
+		gomega.Expect(names).To(gomega.ContainElement(tenant.Name))
+	})
+
+	ginkgo.It("should reject taken and invalid tenant names", func() {
+		var errResp models.ErrorResponse
+		statusCode, err := TestRequestV1().Post(tenantPrefix).Header(theConf.AdminTokenHeader, adminTestToken).
+			RequestBody(&models.Tenant{Name: tenant.Name}).ErrorResponseBody(&errResp).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusBadRequest))
+
+		statusCode, err = TestRequestV1().Post(tenantPrefix).Header(theConf.AdminTokenHeader, adminTestToken).
+			RequestBody(&models.Tenant{Name: "not a name"}).ErrorResponseBody(&errResp).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusBadRequest))
+	})
+
+	ginkgo.It("should rotate the API token", func() {
+		var rotated models.Tenant
+		statusCode, err := TestRequestV1().Post(tenantPrefix+"/"+tenant.ID+routes.ResourceAPIToken).
+			Header(theConf.AdminTokenHeader, adminTestToken).ResponseBody(&rotated).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusOK))
+		gomega.Expect(rotated.APIToken).ToNot(gomega.Equal(tenant.APIToken))
+
+		gomega.Expect(getSelf(tenant.APIToken, tenantUser.AuthToken)).To(gomega.Equal(http.StatusUnauthorized))
+		gomega.Expect(getSelf(rotated.APIToken, tenantUser.AuthToken)).To(gomega.Equal(http.StatusOK))
+	})
+})
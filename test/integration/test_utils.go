@@ -2,17 +2,28 @@ package integration
 
 import (
 	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/base64"
 	"encoding/json"
+	"encoding/pem"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"net/http/httputil"
 	"os"
 	"os/exec"
+	"strings"
 	"time"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/axiomzen/envconfig"
+	lorem "github.com/axiomzen/golorem"
 	"github.com/axiomzen/yawgh"
 	"github.com/axiomzen/zenauth/config"
 	"github.com/axiomzen/zenauth/constants"
@@ -21,6 +32,7 @@ import (
 	"github.com/axiomzen/zenauth/models"
 	"github.com/axiomzen/zenauth/protobuf"
 	"github.com/axiomzen/zenauth/routes"
+	"github.com/golang/protobuf/ptypes"
 	"github.com/joho/godotenv"
 	"github.com/mattes/migrate"
 	_ "github.com/mattes/migrate/database/postgres"
@@ -29,11 +41,16 @@ import (
 	"github.com/twinj/uuid"
 	context "golang.org/x/net/context"
 	google_grpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"gopkg.in/dgrijalva/jwt-go.v3"
 )
 
 const (
 	TesterToken = ""
+	// breachedTestPassword is in the app's breached password file
+	breachedTestPassword = "correcthorsebatterystaple"
 )
 
 var (
@@ -287,6 +304,16 @@ func fireUpApp() error {
 	theConf.VerifyEmailURL = "http://www.zenauth.com/verify"
 	theConf.ResetPasswordRedirectURL = "http://localhost:5000/v1/users/message"
 	theConf.TemplatesPath = "email/templates"
+	// lock accounts out quickly, and never the ip every test logs in from
+	theConf.LoginThrottleBaseDelay = time.Millisecond
+	theConf.LoginLockoutThreshold = 5
+	theConf.LoginIPThrottleFreeAttempts = 10000
+	theConf.LoginIPLockoutThreshold = 20000
+	// only rate limit the test route, in the shared backend so it is covered too
+	theConf.RateLimitBackend = constants.RateLimitBackendPostgres
+	theConf.RateLimits = []string{"test=ip:3/1h"}
+	theConf.GRPCRateLimits = []string{}
+	theConf.PasswordRejectPersonalInfo = true
 	f := false
 	theConf.PostgreSQLSSL = &f
 	if len(theConf.PostgreSQLHost) == 0 {
@@ -310,7 +337,7 @@ func fireUpApp() error {
 	} else if len(out) != 0 {
 		// Successful go install is silent
 		fmt.Println(string(out))
-		return fmt.Errorf(string(out))
+		return fmt.Errorf("%s", out)
 	}
 
 	fmt.Println("Firing up the app...")
@@ -340,6 +367,28 @@ func fireUpApp() error {
 	//fmt.Printf("fb app id: %s, {{ .FacebookAppID }}\n", theConf.FacebookAppID)
 	theConf.FacebookAppSecret = os.Getenv("ZENAUTH_FACEBOOKAPPSECRET")
 
+	// the app verifies social logins against the stand in
+	var err error
+	if oidcStandIn, err = startOIDCStandIn(); err != nil {
+		return err
+	}
+	theConf.OIDCIssuer = oidcStandIn.URL
+	theConf.OIDCClientID = oidcTestClientID
+
+	// keep a few passwords, and reject a known breached one
+	theConf.PasswordHistorySize = 3
+	if theConf.BreachedPasswordsFile, err = writeBreachedPasswords(breachedTestPassword); err != nil {
+		return err
+	}
+
+	theConf.AdminToken = adminTestToken
+
+	// argon2id with small costs, so the tests don't spend their time hashing
+	theConf.PasswordHashScheme = helpers.PasswordSchemeArgon2id
+	theConf.Argon2Time = 1
+	theConf.Argon2Memory = 1024
+	theConf.Argon2Threads = 1
+
 	// compute dependent variables
 	gomega.Expect(theConf.ComputeDependents()).To(gomega.Succeed())
 
@@ -372,7 +421,7 @@ func fireUpApp() error {
 	fmt.Println("Sleeping 1 second...")
 	time.Sleep(1 * time.Second)
 
-	grpcConn, err = google_grpc.Dial(fmt.Sprintf(":%v", theConf.GRPCPort), google_grpc.WithInsecure())
+	grpcConn, err = google_grpc.Dial(fmt.Sprintf(":%v", theConf.GRPCPort), google_grpc.WithInsecure(), google_grpc.WithUnaryInterceptor(withTestAPIToken))
 	if err != nil {
 		return err
 	}
@@ -384,6 +433,9 @@ func fireUpApp() error {
 func killApp() error {
 	fmt.Println("Closing GRPC client connection...")
 	grpcConn.Close()
+	if oidcStandIn != nil {
+		oidcStandIn.Close()
+	}
 
 	fmt.Println("Dropping the database...")
 
@@ -408,6 +460,15 @@ func killApp() error {
 	return err
 }
 
+// withTestAPIToken sends the API token with the calls that don't have one, as TestRequestV1 does
+func withTestAPIToken(ctx context.Context, method string, req, reply interface{}, cc *google_grpc.ClientConn, invoker google_grpc.UnaryInvoker, opts ...google_grpc.CallOption) error {
+	md, _ := metadata.FromContext(ctx)
+	if len(md[theConf.APITokenHeader]) == 0 {
+		ctx = metadata.NewContext(ctx, metadata.Join(md, metadata.Pairs(theConf.APITokenHeader, theConf.APIToken)))
+	}
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
 func getGRPCAuthenticatedContext(token string) context.Context {
 	md := metadata.Pairs(theConf.AuthTokenHeader, token)
 	ctx := context.Background()
@@ -432,9 +493,117 @@ func clearInvitations() {
 	gomega.Expect(statusCode).To(gomega.Equal(http.StatusNoContent))
 }
 
+// adminTestToken is the token of the admin routes in the tests
+const adminTestToken = "admin-test-token"
+
+// writeBreachedPasswords writes a breached password file of passwords, in the Have I Been Pwned format
+func writeBreachedPasswords(passwords ...string) (string, error) {
+	file, err := ioutil.TempFile("", "breached-passwords")
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	for _, password := range passwords {
+		sum := sha1.Sum([]byte(password))
+		if _, err := fmt.Fprintf(file, "%X:1\n", sum); err != nil {
+			return "", err
+		}
+	}
+	return file.Name(), nil
+}
+
 func deleteUser(userID string) {
 	// delete this user
 	statusCode, err := TestRequestV1().Delete(routes.ResourceTest+routes.ResourceUsers+"/"+userID).Header(theConf.AuthTokenHeader, TesterToken).Do()
 	gomega.Expect(err).ToNot(gomega.HaveOccurred())
 	gomega.Expect(statusCode).To(gomega.Equal(http.StatusNoContent))
 }
+
+const (
+	oidcTestProvider = "oidc"
+	oidcTestClientID = "zenauth-test"
+)
+
+var oidcStandIn *oidcServer
+
+// oidcServer is an OpenID Connect provider serving discovery and keys,
+// so the app can verify the id tokens the tests sign
+type oidcServer struct {
+	*httptest.Server
+	private *ecdsa.PrivateKey
+	kid     string
+}
+
+// startOIDCStandIn starts the provider, it has to be running before the app is
+func startOIDCStandIn() (*oidcServer, error) {
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	der, err := x509.MarshalECPrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+	key, err := helpers.ParseSigningKeyPEM(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}))
+	if err != nil {
+		return nil, err
+	}
+	jwk, _ := key.JWK()
+
+	s := &oidcServer{private: private, kid: jwk.Kid}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"issuer": s.URL, "jwks_uri": s.URL + "/jwks"})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(helpers.JWKS{Keys: []helpers.JWK{jwk}})
+	})
+	s.Server = httptest.NewServer(mux)
+	return s, nil
+}
+
+// idToken signs an id token for the subject
+func (s *oidcServer) idToken(subject, email string) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"iss":            s.URL,
+		"aud":            oidcTestClientID,
+		"sub":            subject,
+		"email":          email,
+		"email_verified": true,
+		"name":           lorem.Word(4, 10),
+		"exp":            time.Now().Add(time.Minute).Unix(),
+	})
+	token.Header["kid"] = s.kid
+	signed, err := token.SignedString(s.private)
+	gomega.Expect(err).ToNot(gomega.HaveOccurred())
+	return signed
+}
+
+// tokenClaims decodes the claims of an auth token, which were signed by the server
+func tokenClaims(token string) map[string]interface{} {
+	parts := strings.Split(token, ".")
+	gomega.Expect(parts).To(gomega.HaveLen(3))
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	gomega.Expect(err).ToNot(gomega.HaveOccurred())
+	var claims map[string]interface{}
+	gomega.Expect(json.Unmarshal(payload, &claims)).To(gomega.Succeed())
+	return claims
+}
+
+// grpcError returns the status code of a failed call, and the API error code of its error detail
+func grpcError(err error) (codes.Code, constants.APIErrorCode) {
+	st, detail := grpcErrorDetail(err)
+	return st.Code(), constants.APIErrorCode(detail.Code)
+}
+
+// grpcErrorDetail returns the status of a failed call and its error detail
+func grpcErrorDetail(err error) (*status.Status, *protobuf.ErrorDetail) {
+	st, ok := status.FromError(err)
+	gomega.Expect(ok).To(gomega.BeTrue())
+	details := st.Proto().GetDetails()
+	gomega.Expect(details).To(gomega.HaveLen(1))
+	var detail protobuf.ErrorDetail
+	gomega.Expect(ptypes.UnmarshalAny(details[0], &detail)).To(gomega.Succeed())
+	gomega.Expect(detail.Message).To(gomega.Equal(st.Message()))
+	return st, &detail
+}
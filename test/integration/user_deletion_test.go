@@ -0,0 +1,128 @@
+package integration
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/axiomzen/golorem"
+	"github.com/axiomzen/zenauth/constants"
+	"github.com/axiomzen/zenauth/data"
+	"github.com/axiomzen/zenauth/models"
+	"github.com/axiomzen/zenauth/routes"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("User deletion", func() {
+
+	var (
+		userAuth models.UserAuth
+		user     models.User
+	)
+
+	ginkgo.BeforeEach(func() {
+		gomega.Expect(lorem.Fill(&userAuth)).To(gomega.Succeed())
+		statusCode, err := TestRequestV1().Post(routes.ResourceUsers + routes.ResourceSignup).RequestBody(&userAuth).ResponseBody(&user).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusCreated))
+	})
+
+	ginkgo.AfterEach(func() {
+		deleteUser(user.ID)
+	})
+
+	deleteAccount := func(confirmation *models.UserDeletion, status *models.UserDeletionStatus, errResp *models.ErrorResponse) int {
+		statusCode, err := TestRequestV1().Delete(routes.ResourceUsers+routes.ResourceMe).Header(theConf.AuthTokenHeader, user.AuthToken).
+			RequestBody(confirmation).ResponseBody(status).ErrorResponseBody(errResp).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		return statusCode
+	}
+
+	login := func(route string) int {
+		var loggedIn models.User
+		auth := models.Login{Email: userAuth.Email, Password: userAuth.Password}
+		statusCode, err := TestRequestV1().Post(routes.ResourceUsers + route).RequestBody(&auth).ResponseBody(&loggedIn).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		return statusCode
+	}
+
+	ginkgo.It("should need the password to delete the account", func() {
+		var status models.UserDeletionStatus
+		var errResp models.ErrorResponse
+		gomega.Expect(deleteAccount(&models.UserDeletion{}, &status, &errResp)).To(gomega.Equal(http.StatusUnauthorized))
+		gomega.Expect(errResp.Code).To(gomega.Equal(constants.APIConfirmationRequired))
+		gomega.Expect(deleteAccount(&models.UserDeletion{Password: "wrong password"}, &status, &errResp)).To(gomega.Equal(http.StatusUnauthorized))
+		gomega.Expect(login(routes.ResourceLogin)).To(gomega.Equal(http.StatusOK))
+	})
+
+	ginkgo.Context("User has deleted their account", func() {
+
+		ginkgo.BeforeEach(func() {
+			var status models.UserDeletionStatus
+			var errResp models.ErrorResponse
+			gomega.Expect(deleteAccount(&models.UserDeletion{Password: userAuth.Password}, &status, &errResp)).To(gomega.Equal(http.StatusOK))
+			gomega.Expect(status.PurgeAt.Sub(status.DeletedAt)).To(gomega.Equal(theConf.UserDeletionGracePeriod))
+		})
+
+		ginkgo.It("should not be found or log in", func() {
+			gomega.Expect(login(routes.ResourceLogin)).To(gomega.Equal(http.StatusUnauthorized))
+			statusCode, err := TestRequestV1().Get(routes.ResourceUsers).Header(theConf.AuthTokenHeader, user.AuthToken).Do()
+			gomega.Expect(err).ToNot(gomega.HaveOccurred())
+			gomega.Expect(statusCode).To(gomega.Equal(http.StatusUnauthorized))
+		})
+
+		ginkgo.It("should restore the account during the grace period", func() {
+			gomega.Expect(login(routes.ResourceRestore)).To(gomega.Equal(http.StatusOK))
+			gomega.Expect(login(routes.ResourceLogin)).To(gomega.Equal(http.StatusOK))
+		})
+
+		ginkgo.It("should purge the account after the grace period", func() {
+			dal, err := data.CreateProvider(theConf)
+			gomega.Expect(err).ToNot(gomega.HaveOccurred())
+			defer dal.Close()
+			purged, err := dal.PurgeDeletedUsers(time.Now().Add(time.Second))
+			gomega.Expect(err).ToNot(gomega.HaveOccurred())
+			gomega.Expect(purged).To(gomega.BeNumerically(">=", 1))
+			gomega.Expect(login(routes.ResourceRestore)).To(gomega.Equal(http.StatusUnauthorized))
+		})
+	})
+
+	ginkgo.Context("User with two factor authentication has deleted their account", func() {
+
+		var enrollment models.TOTPEnrollment
+
+		ginkgo.BeforeEach(func() {
+			statusCode, err := TestRequestV1().Post(routes.ResourceUsers+routes.ResourceMFA+routes.ResourceTOTP).Header(theConf.AuthTokenHeader, user.AuthToken).ResponseBody(&enrollment).Do()
+			gomega.Expect(err).ToNot(gomega.HaveOccurred())
+			gomega.Expect(statusCode).To(gomega.Equal(http.StatusOK))
+			code := models.MFACode{Code: totpCode(enrollment.Secret, time.Now())}
+			statusCode, err = TestRequestV1().Post(routes.ResourceUsers+routes.ResourceMFA+routes.ResourceTOTP+routes.ResourceConfirm).Header(theConf.AuthTokenHeader, user.AuthToken).RequestBody(&code).Do()
+			gomega.Expect(err).ToNot(gomega.HaveOccurred())
+			gomega.Expect(statusCode).To(gomega.Equal(http.StatusOK))
+
+			var status models.UserDeletionStatus
+			var errResp models.ErrorResponse
+			gomega.Expect(deleteAccount(&models.UserDeletion{Code: enrollment.RecoveryCodes[0]}, &status, &errResp)).To(gomega.Equal(http.StatusOK))
+		})
+
+		ginkgo.It("should only restore the account once the code is checked", func() {
+			var challenge models.MFAChallenge
+			auth := models.Login{Email: userAuth.Email, Password: userAuth.Password}
+			statusCode, err := TestRequestV1().Post(routes.ResourceUsers + routes.ResourceRestore).RequestBody(&auth).ResponseBody(&challenge).Do()
+			gomega.Expect(err).ToNot(gomega.HaveOccurred())
+			gomega.Expect(statusCode).To(gomega.Equal(http.StatusOK))
+			gomega.Expect(challenge.MFARequired).To(gomega.BeTrue())
+
+			// the password alone doesn't restore it
+			gomega.Expect(login(routes.ResourceLogin)).To(gomega.Equal(http.StatusUnauthorized))
+
+			var loggedIn models.User
+			mfaLogin := models.MFALogin{MFAToken: challenge.MFAToken, Code: totpCode(enrollment.Secret, time.Now().Add(30*time.Second))}
+			statusCode, err = TestRequestV1().Post(routes.ResourceUsers + routes.ResourceLogin + routes.ResourceMFA).RequestBody(&mfaLogin).ResponseBody(&loggedIn).Do()
+			gomega.Expect(err).ToNot(gomega.HaveOccurred())
+			gomega.Expect(statusCode).To(gomega.Equal(http.StatusOK))
+			gomega.Expect(loggedIn.ID).To(gomega.Equal(user.ID))
+			gomega.Expect(login(routes.ResourceLogin)).To(gomega.Equal(http.StatusOK))
+		})
+	})
+})
@@ -0,0 +1,128 @@
+package integration
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"github.com/axiomzen/golorem"
+	"github.com/axiomzen/zenauth/constants"
+	"github.com/axiomzen/zenauth/helpers"
+	"github.com/axiomzen/zenauth/models"
+	"github.com/axiomzen/zenauth/routes"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("User export", func() {
+
+	var (
+		userAuth models.UserAuth
+		user     models.User
+		invitee  string
+	)
+
+	ginkgo.BeforeEach(func() {
+		gomega.Expect(lorem.Fill(&userAuth)).To(gomega.Succeed())
+		statusCode, err := TestRequestV1().Post(routes.ResourceUsers + routes.ResourceSignup).RequestBody(&userAuth).ResponseBody(&user).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusCreated))
+
+		invitee = helpers.EmailSanitize(lorem.Email())
+		var res models.InvitationResponse
+		gomega.Expect(createInvitations(user.AuthToken, &models.InvitationRequest{InviteCodes: []string{invitee}}, &res)).To(gomega.Equal(http.StatusCreated))
+	})
+
+	ginkgo.AfterEach(func() {
+		clearInvitations()
+		deleteUser(user.ID)
+	})
+
+	// exportToken waits for the export to be ready, and returns the token of its download link
+	exportToken := func() string {
+		var trt models.TestResetToken
+		gomega.Eventually(func() int {
+			statusCode, err := TestRequestV1().Get(routes.ResourceTest+routes.ResourceUsers+routes.ResourceExportToken).URLParam("email", user.Email).ResponseBody(&trt).Do()
+			gomega.Expect(err).ToNot(gomega.HaveOccurred())
+			return statusCode
+		}, "5s").Should(gomega.Equal(http.StatusOK))
+		gomega.Expect(trt.Token).ToNot(gomega.BeEmpty())
+		return trt.Token
+	}
+
+	// download follows the emailed link, which needs no API token
+	download := func(token, format string) (int, []byte) {
+		query := url.Values{"token": {token}}
+		if format != "" {
+			query.Set("format", format)
+		}
+		resp, err := http.Get(fmt.Sprintf("http://%s:%d%s?%s", theConf.TestDomainHost, theConf.Port,
+			routes.V1+routes.ResourceUsers+routes.ResourceExport+routes.ResourceDownload, query.Encode()))
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		return resp.StatusCode, body
+	}
+
+	checkBundle := func(data []byte) {
+		var bundle models.UserExportBundle
+		gomega.Expect(json.Unmarshal(data, &bundle)).To(gomega.Succeed())
+		gomega.Expect(bundle.User.ID).To(gomega.Equal(user.ID))
+		gomega.Expect(bundle.User.Email).To(gomega.Equal(user.Email))
+		gomega.Expect(bundle.InvitationsSent).To(gomega.HaveLen(1))
+		gomega.Expect(bundle.InvitationsSent[0].Code).To(gomega.Equal(invitee))
+		gomega.Expect(bundle.Sessions).ToNot(gomega.BeEmpty())
+		gomega.Expect(string(data)).ToNot(gomega.ContainSubstring(`"hash`))
+		gomega.Expect(string(data)).ToNot(gomega.ContainSubstring(user.RefreshToken))
+	}
+
+	ginkgo.It("should email a link to download the user's data", func() {
+		statusCode, err := TestRequestV1().Get(routes.ResourceUsers+routes.ResourceMe+routes.ResourceExport).Header(theConf.AuthTokenHeader, user.AuthToken).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusAccepted))
+		token := exportToken()
+
+		statusCode, body := download(token, "")
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusOK))
+		checkBundle(body)
+
+		statusCode, body = download(token, constants.UserExportFormatZip)
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusOK))
+		archive, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(archive.File).To(gomega.HaveLen(1))
+		file, err := archive.File[0].Open()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		defer file.Close()
+		data, err := ioutil.ReadAll(file)
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		checkBundle(data)
+	})
+
+	ginkgo.It("should need a valid download token", func() {
+		statusCode, body := download("not a token", "")
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusUnauthorized))
+		var errResp models.ErrorResponse
+		gomega.Expect(json.Unmarshal(body, &errResp)).To(gomega.Succeed())
+		gomega.Expect(errResp.Code).To(gomega.Equal(constants.APIInvalidUserExportToken))
+	})
+
+	ginkgo.It("should let admins export a user's data", func() {
+		path := routes.ResourceAdmins + routes.ResourceUsers + "/" + user.ID + routes.ResourceExport
+		statusCode, err := TestRequestV1().Post(path).Header(theConf.AdminTokenHeader, "wrong token").Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusUnauthorized))
+
+		statusCode, err = TestRequestV1().Post(path).Header(theConf.AdminTokenHeader, adminTestToken).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusAccepted))
+		statusCode, body := download(exportToken(), "")
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusOK))
+		checkBundle(body)
+	})
+})
@@ -0,0 +1,127 @@
+package integration
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/axiomzen/golorem"
+	"github.com/axiomzen/zenauth/constants"
+	"github.com/axiomzen/zenauth/data"
+	"github.com/axiomzen/zenauth/helpers"
+	"github.com/axiomzen/zenauth/models"
+	"github.com/axiomzen/zenauth/routes"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("User import", func() {
+
+	var (
+		emails  []string
+		userIDs []string
+	)
+
+	// importUsers posts the file to the import route, decoding the report or the error in v
+	importUsers := func(adminToken, contentType string, body io.Reader, v interface{}) int {
+		url := fmt.Sprintf("http://%s:%d%s", theConf.TestDomainHost, theConf.Port, routes.V1+routes.ResourceAdmins+routes.ResourceUsers+routes.ResourceImport)
+		req, err := http.NewRequest(http.MethodPost, url, body)
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		req.Header.Set("Content-Type", contentType)
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set(theConf.APITokenHeader, theConf.APIToken)
+		req.Header.Set(theConf.AdminTokenHeader, adminToken)
+		resp, err := http.DefaultClient.Do(req)
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		defer resp.Body.Close()
+		gomega.Expect(json.NewDecoder(resp.Body).Decode(v)).To(gomega.Succeed())
+		return resp.StatusCode
+	}
+
+	// login logs the imported user in, keeping their id to delete them
+	login := func(email, password string) int {
+		var user models.User
+		auth := models.Login{Email: email, Password: password}
+		statusCode, err := TestRequestV1().Post(routes.ResourceUsers + routes.ResourceLogin).RequestBody(&auth).ResponseBody(&user).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		if statusCode == http.StatusOK {
+			userIDs = append(userIDs, user.ID)
+		}
+		return statusCode
+	}
+
+	ginkgo.BeforeEach(func() {
+		emails = nil
+		userIDs = nil
+		for i := 0; i < 3; i++ {
+			var signup models.Signup
+			gomega.Expect(lorem.Fill(&signup)).To(gomega.Succeed())
+			emails = append(emails, strings.ToLower(signup.Email))
+		}
+	})
+
+	ginkgo.AfterEach(func() {
+		for _, userID := range userIDs {
+			deleteUser(userID)
+		}
+	})
+
+	ginkgo.It("should need the admin token", func() {
+		var errResp models.ErrorResponse
+		statusCode := importUsers("wrong token", "application/x-ndjson", strings.NewReader(""), &errResp)
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusUnauthorized))
+		gomega.Expect(errResp.Code).To(gomega.Equal(constants.APIAdminUnauthorized))
+	})
+
+	ginkgo.It("should import NDJSON users and report the rows that failed", func() {
+		body := strings.Join([]string{
+			fmt.Sprintf(`{"email": %q, "verified": true, "hashAlgorithm": "django-pbkdf2", "hash": "pbkdf2_sha256$1000$seasalt$mQnueSakb748zqBAC1tmWVZsZbi2zPGZarEzTGdfmso="}`, emails[0]),
+			fmt.Sprintf(`{"email": %q, "hashAlgorithm": "sha256", "hash": "869a1fa0609a1d15a5c3eb891428b7a6c8395b2ee10c4762e97ffca0ebe8c0b5", "salt": "pepper"}`, emails[1]),
+			fmt.Sprintf(`{"email": %q, "hashAlgorithm": "md5", "hash": "5f4dcc3b5aa765d61d8327deb882cf99"}`, emails[2]),
+			fmt.Sprintf(`{"email": %q, "hashAlgorithm": "sha256", "hash": "869a1fa0609a1d15a5c3eb891428b7a6c8395b2ee10c4762e97ffca0ebe8c0b5", "salt": "pepper"}`, emails[0]),
+			`not json`,
+		}, "\n")
+		var report models.UserImportReport
+		statusCode := importUsers(adminTestToken, "application/x-ndjson", strings.NewReader(body), &report)
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusOK))
+		gomega.Expect(report.Imported).To(gomega.Equal(2))
+		gomega.Expect(report.Failed).To(gomega.Equal(3))
+		var lines []int
+		for _, importErr := range report.Errors {
+			lines = append(lines, importErr.Line)
+		}
+		gomega.Expect(lines).To(gomega.ConsistOf(3, 4, 5))
+
+		gomega.Expect(login(emails[0], "wrong horse")).To(gomega.Equal(http.StatusUnauthorized))
+		gomega.Expect(login(emails[0], "correct horse")).To(gomega.Equal(http.StatusOK))
+		gomega.Expect(login(emails[1], "correct horse")).To(gomega.Equal(http.StatusOK))
+
+		// the first login replaced the imported hash
+		dal, err := data.CreateProvider(theConf)
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		defer dal.Close()
+		gomega.Eventually(func() string {
+			user := models.User{}
+			user.Email = emails[0]
+			gomega.Expect(dal.GetUserByEmail(&user)).To(gomega.Succeed())
+			return helpers.PasswordHashScheme(*user.Hash)
+		}, time.Second).Should(gomega.Equal(helpers.PasswordSchemeArgon2id))
+		gomega.Expect(login(emails[0], "correct horse")).To(gomega.Equal(http.StatusOK))
+	})
+
+	ginkgo.It("should import CSV users with firebase scrypt hashes", func() {
+		body := "email,verified,hashAlgorithm,hash,salt,saltSeparator,signerKey,rounds,memCost\n" +
+			emails[0] + ",true,firebase-scrypt,lSrfV15cpx95/sZS2W9c9Kp6i/LVgQNDNC/qzrCnh1SAyZvqmZqAjTdn3aoItz+VHjoZilo78198JAdRuid5lQ==," +
+			"42xEC+ixf3L2lw==,Bw==,jxspr8Ki0RYycVU8zykbdLGjFQ3McFUH0uiiTvC8pVMXAn210wjLNmdZJzxUECKbm0QsEmYUSDzZvpjeJ9WmXA==,8,14\n"
+		var report models.UserImportReport
+		statusCode := importUsers(adminTestToken, "text/csv", strings.NewReader(body), &report)
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusOK))
+		gomega.Expect(report.Imported).To(gomega.Equal(1))
+		gomega.Expect(report.Errors).To(gomega.BeEmpty())
+
+		gomega.Expect(login(emails[0], "user1password")).To(gomega.Equal(http.StatusOK))
+	})
+})
@@ -384,11 +384,18 @@ var _ = ginkgo.Describe("Users", func() {
 						gomega.Expect(user.UpdatedAt.Time.Before(updatedUser.UpdatedAt.Time)).To(gomega.BeTrue())
 						gomega.Expect(t1.Before(updatedUser.UpdatedAt.Time)).To(gomega.BeTrue())
 
+						// the old token was revoked, the new one works
+						statusCode, err = TestRequestV1().Get(routes.ResourceUsers).Header(theConf.AuthTokenHeader, user.AuthToken).Do()
+						gomega.Expect(err).ToNot(gomega.HaveOccurred())
+						gomega.Expect(statusCode).To(gomega.Equal(http.StatusUnauthorized))
+						gomega.Expect(updatedUser.AuthToken).ToNot(gomega.Equal(user.AuthToken))
+
 						// try a user get
 						var userGet models.User
-						statusCode, err = TestRequestV1().Get(routes.ResourceUsers).Header(theConf.AuthTokenHeader, user.AuthToken).ResponseBody(&userGet).Do()
+						statusCode, err = TestRequestV1().Get(routes.ResourceUsers).Header(theConf.AuthTokenHeader, updatedUser.AuthToken).ResponseBody(&userGet).Do()
 						gomega.Expect(err).ToNot(gomega.HaveOccurred())
 						gomega.Expect(statusCode).To(gomega.Equal(http.StatusOK))
+						userGet.RefreshToken = updatedUser.RefreshToken
 
 						// compare stuff
 						gomega.Ω(compare.New().DeepEquals(updatedUser, userGet, "userGet")).Should(gomega.Succeed())
@@ -405,7 +412,7 @@ var _ = ginkgo.Describe("Users", func() {
 						gomega.Expect(newUser.AuthToken).ToNot(gomega.BeEmpty())
 
 						// see if we can log in
-						statusCode, err = TestRequestV1().Get(routes.ResourceUsers).Header(theConf.AuthTokenHeader, user.AuthToken).Do()
+						statusCode, err = TestRequestV1().Get(routes.ResourceUsers).Header(theConf.AuthTokenHeader, newUser.AuthToken).Do()
 						gomega.Expect(err).ToNot(gomega.HaveOccurred())
 						gomega.Expect(statusCode).To(gomega.Equal(http.StatusOK))
 					})
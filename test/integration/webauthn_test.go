@@ -0,0 +1,262 @@
+package integration
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+
+	"github.com/axiomzen/golorem"
+	"github.com/axiomzen/zenauth/constants"
+	"github.com/axiomzen/zenauth/helpers"
+	"github.com/axiomzen/zenauth/models"
+	"github.com/axiomzen/zenauth/routes"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+// softAuthenticator is a software ES256 passkey
+type softAuthenticator struct {
+	id        []byte
+	key       *ecdsa.PrivateKey
+	userID    string
+	signCount uint32
+}
+
+func newSoftAuthenticator() *softAuthenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	gomega.Expect(err).ToNot(gomega.HaveOccurred())
+	id := make([]byte, 16)
+	_, err = rand.Read(id)
+	gomega.Expect(err).ToNot(gomega.HaveOccurred())
+	return &softAuthenticator{id: id, key: key}
+}
+
+func (a *softAuthenticator) clientData(ceremony, challenge string) []byte {
+	data, err := json.Marshal(map[string]string{"type": ceremony, "challenge": challenge, "origin": theConf.WebAuthnOrigins[0]})
+	gomega.Expect(err).ToNot(gomega.HaveOccurred())
+	return data
+}
+
+func (a *softAuthenticator) authData(attested bool) []byte {
+	rpIDHash := sha256.Sum256([]byte(theConf.WebAuthnRPID))
+	// user present and verified
+	flags := byte(0x05)
+	if attested {
+		flags |= 0x40
+	}
+	data := append(rpIDHash[:], flags, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(data[33:], a.signCount)
+	if attested {
+		x := make([]byte, 32)
+		y := make([]byte, 32)
+		a.key.X.FillBytes(x)
+		a.key.Y.FillBytes(y)
+		key, err := helpers.EncodeCBOR(map[interface{}]interface{}{1: 2, 3: -7, -1: 1, -2: x, -3: y})
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		data = append(data, make([]byte, 16)...)
+		data = append(data, byte(len(a.id)>>8), byte(len(a.id)))
+		data = append(data, a.id...)
+		data = append(data, key...)
+	}
+	return data
+}
+
+func (a *softAuthenticator) register(options *models.WebAuthnRegistrationChallenge) *models.WebAuthnRegistration {
+	userID, err := base64.RawURLEncoding.DecodeString(options.PublicKey.User.ID)
+	gomega.Expect(err).ToNot(gomega.HaveOccurred())
+	a.userID = string(userID)
+	attestationObject, err := helpers.EncodeCBOR(map[interface{}]interface{}{
+		"fmt":      "none",
+		"attStmt":  map[interface{}]interface{}{},
+		"authData": a.authData(true),
+	})
+	gomega.Expect(err).ToNot(gomega.HaveOccurred())
+	return &models.WebAuthnRegistration{
+		ChallengeToken: options.ChallengeToken,
+		Name:           "Software authenticator",
+		ID:             base64.RawURLEncoding.EncodeToString(a.id),
+		Response: models.WebAuthnAttestationResponse{
+			ClientDataJSON:    base64.RawURLEncoding.EncodeToString(a.clientData("webauthn.create", options.PublicKey.Challenge)),
+			AttestationObject: base64.RawURLEncoding.EncodeToString(attestationObject),
+			Transports:        []string{"internal"},
+		},
+	}
+}
+
+func (a *softAuthenticator) assert(options *models.WebAuthnLoginChallenge) *models.WebAuthnLogin {
+	a.signCount++
+	clientData := a.clientData("webauthn.get", options.PublicKey.Challenge)
+	authData := a.authData(false)
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	gomega.Expect(err).ToNot(gomega.HaveOccurred())
+	return &models.WebAuthnLogin{
+		ChallengeToken: options.ChallengeToken,
+		ID:             base64.RawURLEncoding.EncodeToString(a.id),
+		Response: models.WebAuthnAssertionResponse{
+			ClientDataJSON:    base64.RawURLEncoding.EncodeToString(clientData),
+			AuthenticatorData: base64.RawURLEncoding.EncodeToString(authData),
+			Signature:         base64.RawURLEncoding.EncodeToString(signature),
+			UserHandle:        base64.RawURLEncoding.EncodeToString([]byte(a.userID)),
+		},
+	}
+}
+
+var _ = ginkgo.Describe("WebAuthn", func() {
+
+	var (
+		userAuth      models.UserAuth
+		user          models.User
+		authenticator *softAuthenticator
+		credential    models.WebAuthnCredential
+	)
+
+	loginBegin := func(email string) *models.WebAuthnLoginChallenge {
+		var options models.WebAuthnLoginChallenge
+		statusCode, err := TestRequestV1().Post(routes.ResourceUsers + routes.ResourceWebAuthn + routes.ResourceLogin + routes.ResourceBegin).RequestBody(&models.WebAuthnLoginBegin{Email: email}).ResponseBody(&options).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusOK))
+		gomega.Expect(options.ChallengeToken).ToNot(gomega.BeEmpty())
+		return &options
+	}
+
+	loginFinish := func(login *models.WebAuthnLogin, loggedIn *models.User, errResp *models.ErrorResponse) int {
+		statusCode, err := TestRequestV1().Post(routes.ResourceUsers + routes.ResourceWebAuthn + routes.ResourceLogin + routes.ResourceFinish).RequestBody(login).ResponseBody(loggedIn).ErrorResponseBody(errResp).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		return statusCode
+	}
+
+	ginkgo.BeforeEach(func() {
+		gomega.Expect(lorem.Fill(&userAuth)).To(gomega.Succeed())
+		statusCode, err := TestRequestV1().Post(routes.ResourceUsers + routes.ResourceSignup).RequestBody(&userAuth).ResponseBody(&user).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusCreated))
+
+		var options models.WebAuthnRegistrationChallenge
+		statusCode, err = TestRequestV1().Post(routes.ResourceUsers+routes.ResourceWebAuthn+routes.ResourceRegister+routes.ResourceBegin).Header(theConf.AuthTokenHeader, user.AuthToken).ResponseBody(&options).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusOK))
+		gomega.Expect(options.PublicKey.RP.ID).To(gomega.Equal(theConf.WebAuthnRPID))
+		gomega.Expect(options.PublicKey.ExcludeCredentials).To(gomega.BeEmpty())
+
+		authenticator = newSoftAuthenticator()
+		statusCode, err = TestRequestV1().Post(routes.ResourceUsers+routes.ResourceWebAuthn+routes.ResourceRegister+routes.ResourceFinish).Header(theConf.AuthTokenHeader, user.AuthToken).RequestBody(authenticator.register(&options)).ResponseBody(&credential).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusCreated))
+		gomega.Expect(credential.CredentialID).To(gomega.Equal(base64.RawURLEncoding.EncodeToString(authenticator.id)))
+	})
+
+	ginkgo.AfterEach(func() {
+		deleteUser(user.ID)
+	})
+
+	ginkgo.It("should list the user's credentials", func() {
+		var credentials models.WebAuthnCredentials
+		statusCode, err := TestRequestV1().Get(routes.ResourceUsers+routes.ResourceWebAuthn+routes.ResourceCredentials).Header(theConf.AuthTokenHeader, user.AuthToken).ResponseBody(&credentials).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusOK))
+		gomega.Expect(credentials).To(gomega.HaveLen(1))
+		gomega.Expect(credentials[0].Name).To(gomega.Equal("Software authenticator"))
+		gomega.Expect(credentials[0].Transports).To(gomega.Equal([]string{"internal"}))
+	})
+
+	ginkgo.It("should not register a credential twice with the same challenge", func() {
+		var options models.WebAuthnRegistrationChallenge
+		statusCode, err := TestRequestV1().Post(routes.ResourceUsers+routes.ResourceWebAuthn+routes.ResourceRegister+routes.ResourceBegin).Header(theConf.AuthTokenHeader, user.AuthToken).ResponseBody(&options).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusOK))
+		gomega.Expect(options.PublicKey.ExcludeCredentials).To(gomega.HaveLen(1))
+
+		other := newSoftAuthenticator()
+		registration := other.register(&options)
+		statusCode, err = TestRequestV1().Post(routes.ResourceUsers+routes.ResourceWebAuthn+routes.ResourceRegister+routes.ResourceFinish).Header(theConf.AuthTokenHeader, user.AuthToken).RequestBody(registration).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusCreated))
+
+		var errResp models.ErrorResponse
+		statusCode, err = TestRequestV1().Post(routes.ResourceUsers+routes.ResourceWebAuthn+routes.ResourceRegister+routes.ResourceFinish).Header(theConf.AuthTokenHeader, user.AuthToken).RequestBody(registration).ErrorResponseBody(&errResp).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusUnauthorized))
+		gomega.Expect(errResp.Code).To(gomega.Equal(constants.APIInvalidWebAuthnChallenge))
+	})
+
+	ginkgo.It("should login with a passkey", func() {
+		options := loginBegin(userAuth.Email)
+		gomega.Expect(options.PublicKey.AllowCredentials).To(gomega.HaveLen(1))
+
+		var loggedIn models.User
+		var errResp models.ErrorResponse
+		gomega.Expect(loginFinish(authenticator.assert(options), &loggedIn, &errResp)).To(gomega.Equal(http.StatusOK))
+		gomega.Expect(loggedIn.ID).To(gomega.Equal(user.ID))
+		gomega.Expect(loggedIn.AuthToken).ToNot(gomega.BeEmpty())
+		gomega.Expect(loggedIn.RefreshToken).ToNot(gomega.BeEmpty())
+		gomega.Expect(getSelf(loggedIn.AuthToken, &errResp)).To(gomega.Equal(http.StatusOK))
+	})
+
+	ginkgo.It("should not tell which emails have passkeys", func() {
+		unknown := lorem.Email()
+		options := loginBegin(unknown)
+		gomega.Expect(options.PublicKey.AllowCredentials).To(gomega.HaveLen(1))
+		gomega.Expect(loginBegin(unknown).PublicKey.AllowCredentials).To(gomega.Equal(options.PublicKey.AllowCredentials))
+	})
+
+	ginkgo.It("should login with a discoverable passkey", func() {
+		options := loginBegin("")
+		gomega.Expect(options.PublicKey.AllowCredentials).To(gomega.BeEmpty())
+
+		var loggedIn models.User
+		var errResp models.ErrorResponse
+		gomega.Expect(loginFinish(authenticator.assert(options), &loggedIn, &errResp)).To(gomega.Equal(http.StatusOK))
+		gomega.Expect(loggedIn.ID).To(gomega.Equal(user.ID))
+	})
+
+	ginkgo.It("should not accept a challenge twice", func() {
+		options := loginBegin(userAuth.Email)
+
+		var loggedIn models.User
+		var errResp models.ErrorResponse
+		gomega.Expect(loginFinish(authenticator.assert(options), &loggedIn, &errResp)).To(gomega.Equal(http.StatusOK))
+		gomega.Expect(loginFinish(authenticator.assert(options), &loggedIn, &errResp)).To(gomega.Equal(http.StatusUnauthorized))
+		gomega.Expect(errResp.Code).To(gomega.Equal(constants.APIInvalidWebAuthnChallenge))
+	})
+
+	ginkgo.It("should reject a replayed assertion", func() {
+		options := loginBegin(userAuth.Email)
+		login := authenticator.assert(options)
+
+		var loggedIn models.User
+		var errResp models.ErrorResponse
+		gomega.Expect(loginFinish(login, &loggedIn, &errResp)).To(gomega.Equal(http.StatusOK))
+
+		// same counter, with a fresh challenge token
+		authenticator.signCount--
+		login = authenticator.assert(loginBegin(userAuth.Email))
+		gomega.Expect(loginFinish(login, &loggedIn, &errResp)).To(gomega.Equal(http.StatusUnauthorized))
+		gomega.Expect(errResp.Code).To(gomega.Equal(constants.APIWebAuthnInvalid))
+	})
+
+	ginkgo.It("should reject an unknown credential", func() {
+		options := loginBegin(userAuth.Email)
+
+		var loggedIn models.User
+		var errResp models.ErrorResponse
+		gomega.Expect(loginFinish(newSoftAuthenticator().assert(options), &loggedIn, &errResp)).To(gomega.Equal(http.StatusUnauthorized))
+		gomega.Expect(errResp.Code).To(gomega.Equal(constants.APIWebAuthnInvalid))
+	})
+
+	ginkgo.It("should not login with a deleted credential", func() {
+		statusCode, err := TestRequestV1().Delete(routes.ResourceUsers+routes.ResourceWebAuthn+routes.ResourceCredentials+"/"+credential.ID).Header(theConf.AuthTokenHeader, user.AuthToken).Do()
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+		gomega.Expect(statusCode).To(gomega.Equal(http.StatusNoContent))
+
+		var loggedIn models.User
+		var errResp models.ErrorResponse
+		gomega.Expect(loginFinish(authenticator.assert(loginBegin(userAuth.Email)), &loggedIn, &errResp)).To(gomega.Equal(http.StatusUnauthorized))
+	})
+})
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/axiomzen/zenauth/config"
+	"github.com/axiomzen/zenauth/constants"
+	"github.com/axiomzen/zenauth/data"
+	"github.com/axiomzen/zenauth/helpers"
+	"github.com/axiomzen/zenauth/models"
+)
+
+const usersUsage = `usage: zenauth users <command> [flags]
+
+Manages user accounts.
+
+commands:
+  unlock   forget the failed logins of an account (-email) or a client ip (-ip), lifting any lockout
+  import   create users from an NDJSON or CSV file (-file) with their password hashes, prints the report
+
+unlock and import act on the default tenant, or the one of -tenant <id>
+  purge    delete the accounts whose deletion grace period is over
+`
+
+// usersCommand runs the users subcommand, returns the exit code
+func usersCommand(args []string) int {
+	if len(args) > 0 && args[0] == "import" {
+		return usersImportCommand(args[1:])
+	}
+	if len(args) > 0 && args[0] == "purge" {
+		return usersPurgeCommand()
+	}
+	if len(args) == 0 || args[0] != "unlock" {
+		fmt.Fprint(os.Stderr, usersUsage)
+		return 2
+	}
+
+	flags := flag.NewFlagSet("users unlock", flag.ContinueOnError)
+	email := flags.String("email", "", "email of the account to unlock")
+	ip := flags.String("ip", "", "client ip to unlock")
+	tenant := flags.String("tenant", "", "id of the tenant of the account (defaults to the default tenant)")
+	if err := flags.Parse(args[1:]); err != nil {
+		return 2
+	}
+	if *email == "" && *ip == "" {
+		fmt.Fprintln(os.Stderr, "usage: zenauth users unlock [-email <email>] [-ip <ip>] [-tenant <id>]")
+		return 2
+	}
+
+	conf, err := config.Get()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+	dal, err := data.Get(conf)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+
+	if *tenant != "" {
+		dal = dal.ForTenant(*tenant)
+	}
+	if *email != "" {
+		var user models.User
+		user.Email = helpers.EmailSanitize(*email)
+		if err := dal.GetUserByEmail(&user); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			return 1
+		}
+		if err := dal.ClearLoginThrottle(models.LoginThrottleAccountKey(user.ID)); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			return 1
+		}
+		entry := models.AuditEntry{UserID: user.ID, Event: constants.AuditEventAccountUnlocked}
+		if err := dal.CreateAuditEntry(&entry); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			return 1
+		}
+	}
+	if *ip != "" {
+		if err := dal.ClearLoginThrottle(models.LoginThrottleIPKey(*ip)); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			return 1
+		}
+	}
+	return 0
+}
+
+// usersImportCommand runs users import, returns the exit code
+func usersImportCommand(args []string) int {
+	flags := flag.NewFlagSet("users import", flag.ContinueOnError)
+	file := flags.String("file", "", "NDJSON or CSV file of the users")
+	format := flags.String("format", "", "format of the file, ndjson or csv (defaults to the file extension)")
+	batchSize := flags.Int("batch-size", 0, "users created per transaction (defaults to ZENAUTH_USERIMPORTBATCHSIZE)")
+	tenant := flags.String("tenant", "", "id of the tenant to create the users in (defaults to the default tenant)")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *file == "" {
+		fmt.Fprintln(os.Stderr, "usage: zenauth users import -file <file> [-format ndjson|csv] [-batch-size <n>] [-tenant <id>]")
+		return 2
+	}
+	if *format == "" {
+		switch filepath.Ext(*file) {
+		case ".csv":
+			*format = constants.UserImportFormatCSV
+		default:
+			*format = constants.UserImportFormatNDJSON
+		}
+	}
+
+	conf, err := config.Get()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+	dal, err := data.Get(conf)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+	if *batchSize <= 0 {
+		*batchSize = int(conf.UserImportBatchSize)
+	}
+	if *tenant != "" {
+		dal = dal.ForTenant(*tenant)
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+	defer f.Close()
+	reader, err := models.NewUserImportReader(f, *format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+	report, importErr := data.ImportUserFile(dal, reader, *batchSize)
+	// the report covers the batches committed before an error too
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(&report); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+	if importErr != nil {
+		fmt.Fprintln(os.Stderr, importErr.Error())
+		return 1
+	}
+	if report.Failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// usersPurgeCommand runs users purge, returns the exit code
+func usersPurgeCommand() int {
+	conf, err := config.Get()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+	dal, err := data.Get(conf)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+	purged, err := dal.PurgeDeletedUsers(time.Now().Add(-conf.UserDeletionGracePeriod))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+	fmt.Printf("purged %d users\n", purged)
+	return 0
+}
+
+// purgeDeletedUsers purges the accounts whose grace period is over every UserPurgeInterval
+func purgeDeletedUsers(conf *config.ZENAUTHConfig, dal data.ZENAUTHProvider, entry *log.Entry) {
+	for range time.Tick(conf.UserPurgeInterval) {
+		purged, err := dal.PurgeDeletedUsers(time.Now().Add(-conf.UserDeletionGracePeriod))
+		if err != nil {
+			entry.WithError(err).Error("Could not purge deleted users")
+			continue
+		}
+		if purged > 0 {
+			entry.WithField("purged", purged).Info("Purged deleted users")
+		}
+	}
+}